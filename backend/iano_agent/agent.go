@@ -2,10 +2,10 @@ package iano_agent
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"iano_agent/tools"
-	"runtime"
-	"strings"
 	"sync"
 	"time"
 
@@ -16,40 +16,89 @@ import (
 )
 
 type Config struct {
-	Tools           []Tool
-	Callback        MessageCallback
-	MaxRounds       int
-	AllowedTools    []string
-	AllowedCommands []string
-	SystemPrompt    string
-	WorkDir         string
-	Timeout         int
+	Name               string // Agent 名称，提示词模板中通过 {{.AgentName}} 引用
+	Tools              []Tool
+	Callback           MessageCallback
+	MaxRounds          int // 单次运行最多调用模型的轮数
+	AllowedTools       []string
+	AllowedCommands    []string
+	SystemPrompt       string       // 指令，按 text/template 渲染，可用变量见 PromptVars
+	PromptLoader       PromptLoader // 加载指令中 include 引用的提示词片段
+	WorkDir            string
+	Timeout            int
+	ApprovalPolicy     ApprovalPolicy            // Agent 级审批策略，为空时使用内置默认值
+	ToolApprovals      map[string]ApprovalPolicy // 工具级审批策略，优先于 Agent 级
+	ApprovalHandler    ApprovalHandler           // 审批处理函数
+	ApprovalTimeout    time.Duration             // 等待审批的超时时间，超时视为拒绝
+	MaxParallelTools   int                       // 同时执行的工具调用数，1 表示全部串行
+	MaxToolCalls       int                       // 单次运行最多执行的工具调用次数，0 表示不限制
+	TokenBudget        int64                     // 单次运行的 Token 预算，0 表示不限制
+	RunTimeout         time.Duration             // 单次运行的时长上限，0 表示不限制
+	MaxDelegationDepth int                       // 子 Agent 最大委派深度
+	ResponseSchema     *ResponseSchema           // 最终答复需要满足的 JSON Schema，为空表示自由文本
+	MaxSchemaRetries   int                       // 最终答复不符合 Schema 时的重新提示次数
+	MemoryStore        tools.MemoryStore         // 长期记忆存储，为空时不启用记忆
+	MemoryScope        tools.MemoryScope         // 记忆的归属范围
+	MemoryRecallLimit  int                       // 每次运行自动注入系统提示词的记忆条数，0 表示不自动注入
+	KnowledgeSearcher  tools.KnowledgeSearcher   // 知识库检索，为空时不启用 kb_search
+	KnowledgeBaseIDs   []string                  // 绑定的知识库
+	AllowGitWrite      bool                      // 允许 Git 写操作（创建/切换分支、提交）
+	CheckpointHandler  CheckpointHandler         // 修改文件前保存检查点，为空时不创建检查点
+	ArtifactSaver      tools.ArtifactSaver       // 保存命令的完整日志等工具产物，为空时只返回摘要
+	ProcessManager     *tools.ProcessManager     // 后台进程管理器，为空时不启用后台进程工具
+	ProcessSession     string                    // 后台进程所属的会话
+	TerminalManager    *tools.TerminalManager    // 终端管理器，为空时不启用终端工具
+	TerminalSession    string                    // 终端所属的会话
 }
 
 func DefaultConfig() *Config {
 	return &Config{
-		Tools:        make([]Tool, 0),
-		MaxRounds:    50,
-		SystemPrompt: "你是一个智能助手。",
-		Timeout:      30,
+		Tools:              make([]Tool, 0),
+		MaxRounds:          50,
+		SystemPrompt:       DefaultSystemPrompt,
+		Timeout:            30,
+		ApprovalTimeout:    DefaultApprovalTimeout,
+		MaxParallelTools:   DefaultMaxParallelTools,
+		MaxDelegationDepth: DefaultMaxDelegationDepth,
+		MaxSchemaRetries:   DefaultMaxSchemaRetries,
+		MemoryRecallLimit:  DefaultMemoryRecallLimit,
 	}
 }
 
+// ErrTokenBudgetExceeded 本次运行已用完 Token 预算
+var ErrTokenBudgetExceeded = errors.New("已用完 Token 预算")
+
 type Agent struct {
-	config          *Config
-	ra              *react.Agent
-	chatModel       model.ToolCallingChatModel
-	mu              sync.RWMutex
-	tokenUsage      *TokenUsage
-	maxRounds       int
-	toolRegistry    tools.Registry
-	workDir         string
-	timeout         int
-	allowedCommands []string
-	IsThink         bool              // 是否在思考中
-	IsReasoning     bool              // 是否在推理中
-	CBs             []MessageCallback // 回调函数
-	IsDone          bool              // 是否完成
+	config            *Config
+	ra                *react.Agent
+	chatModel         model.ToolCallingChatModel
+	mu                sync.RWMutex
+	tokenUsage        *TokenUsage
+	runUsage          *TokenUsage
+	runProvider       string
+	toolRegistry      tools.Registry
+	workDir           string
+	timeout           int
+	allowedCommands   []string
+	approvalHandler   ApprovalHandler
+	approvals         map[string]*ApprovalDecision // 本次运行中已审批的工具调用
+	checkpointHandler CheckpointHandler            // 修改文件前保存检查点
+	artifactSaver     tools.ArtifactSaver          // 保存工具产物
+	limiter           *toolLimiter                 // 工具调用并发限制
+	runCancel         context.CancelFunc           // 取消本次运行
+	budget            RunBudget                    // 本次运行生效的预算
+	budgetOverride    *RunBudget                   // 请求级运行预算，优先于配置
+	stopErr           *RunStopError                // 本次运行提前结束的原因
+	rounds            int                          // 本次运行已调用模型的轮数
+	toolCalls         int                          // 本次运行已执行的工具调用次数
+	responseSchema    *ResponseSchema              // 请求级响应 Schema，优先于配置
+	structured        json.RawMessage              // 本次运行通过 Schema 校验的最终答复
+	citations         []*tools.KnowledgeChunk      // 本次运行中 kb_search 返回的分块，按引用编号排列
+	promptVars        *PromptVars                  // 请求级提示词变量
+	IsThink           bool                         // 是否在思考中
+	IsReasoning       bool                         // 是否在推理中
+	CBs               []MessageCallback            // 回调函数
+	IsDone            bool                         // 是否完成
 }
 
 func NewAgent(chatModel model.ToolCallingChatModel, opts ...Option) (*Agent, error) {
@@ -59,17 +108,38 @@ func NewAgent(chatModel model.ToolCallingChatModel, opts ...Option) (*Agent, err
 		opt(cfg)
 	}
 
+	if err := ParsePromptTemplate(cfg.SystemPrompt); err != nil {
+		return nil, err
+	}
+
 	agent := &Agent{
-		config:          cfg,
-		maxRounds:       cfg.MaxRounds,
-		tokenUsage:      &TokenUsage{LastUpdated: time.Now()},
-		workDir:         cfg.WorkDir,
-		timeout:         cfg.Timeout,
-		allowedCommands: cfg.AllowedCommands,
-		CBs:             make([]MessageCallback, 0),
+		config:            cfg,
+		tokenUsage:        &TokenUsage{LastUpdated: time.Now()},
+		runUsage:          &TokenUsage{LastUpdated: time.Now()},
+		workDir:           cfg.WorkDir,
+		timeout:           cfg.Timeout,
+		allowedCommands:   cfg.AllowedCommands,
+		approvalHandler:   cfg.ApprovalHandler,
+		approvals:         make(map[string]*ApprovalDecision),
+		checkpointHandler: cfg.CheckpointHandler,
+		artifactSaver:     cfg.ArtifactSaver,
+		limiter:           newToolLimiter(cfg.MaxParallelTools),
+		CBs:               make([]MessageCallback, 0),
 	}
 
 	agent.toolRegistry = tools.NewScopedRegistry(tools.GlobalRegistry, cfg.AllowedTools)
+	if err := agent.registerMemoryTools(); err != nil {
+		return nil, fmt.Errorf("failed to register memory tools: %w", err)
+	}
+	if err := agent.registerKnowledgeTools(); err != nil {
+		return nil, fmt.Errorf("failed to register knowledge tools: %w", err)
+	}
+	if err := agent.registerProcessTools(); err != nil {
+		return nil, fmt.Errorf("failed to register process tools: %w", err)
+	}
+	if err := agent.registerTerminalTools(); err != nil {
+		return nil, fmt.Errorf("failed to register terminal tools: %w", err)
+	}
 
 	toolsConfig, err := agent.makeToolsConfig()
 	if err != nil {
@@ -78,9 +148,10 @@ func NewAgent(chatModel model.ToolCallingChatModel, opts ...Option) (*Agent, err
 
 	ctx := context.Background()
 	ra, err := react.NewAgent(ctx, &react.AgentConfig{
-		ToolCallingModel: chatModel,
-		ToolsConfig:      toolsConfig,
-		MaxStep:          30,
+		ToolCallingModel:      chatModel,
+		ToolsConfig:           toolsConfig,
+		MaxStep:               2 * cfg.MaxRounds, // 每轮包含模型和工具两步，运行时按剩余轮数覆盖
+		StreamToolCallChecker: agent.toolCallChecker,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create react agent: %w", err)
@@ -92,70 +163,61 @@ func NewAgent(chatModel model.ToolCallingChatModel, opts ...Option) (*Agent, err
 	return agent, nil
 }
 
-func (a *Agent) buildSystemPrompt() string {
-	var parts []string
-
-	if a.config.SystemPrompt != "" {
-		parts = append(parts, a.config.SystemPrompt)
-	} else {
-		parts = append(parts, "你是一个智能助手。")
-	}
-
-	osInfo := "你运行在 "
-	if runtime.GOOS == "windows" {
-		osInfo += "Windows 操作系统上。使用 PowerShell 命令，例如：dir 代替 ls，type 代替 cat，cd 代替 pwd。"
-	} else {
-		osInfo += "Linux/Unix 操作系统上。使用标准的 Bash 命令。"
-	}
-	parts = append(parts, osInfo)
-
-	return strings.Join(parts, "")
-}
-
 func (a *Agent) makeToolsConfig() (compose.ToolsNodeConfig, error) {
-	bts := a.toolRegistry.List()
-
-	if len(bts) == 0 {
+	if len(a.toolRegistry.List()) == 0 {
 		ctx := context.Background()
 		if err := tools.RegisterBuiltinTools(ctx, a.workDir, a.timeout); err != nil {
 			return compose.ToolsNodeConfig{}, fmt.Errorf("注册内置工具失败: %w", err)
 		}
-		bts = a.toolRegistry.List()
-	}
-
-	if a.workDir != "" {
-		bts = tools.CreateToolsWithBasePath(a.workDir, a.toolRegistry.Names())
 	}
 
 	toolNames := a.toolRegistry.Names()
-	hasCommandTool := false
+	toolsList := make([]tool.BaseTool, 0, len(toolNames))
 	for _, name := range toolNames {
-		if name == "command_execute" {
-			hasCommandTool = true
-			break
+		if t, ok := a.resolveTool(name); ok {
+			toolsList = append(toolsList, t)
 		}
 	}
 
-	toolsList := make([]tool.BaseTool, 0, len(bts))
-	for _, t := range bts {
-		toolsList = append(toolsList, t)
-	}
+	return compose.ToolsNodeConfig{
+		Tools:               toolsList,
+		ToolCallMiddlewares: []compose.ToolMiddleware{a.budgetMiddleware(), a.approvalMiddleware(), a.limiterMiddleware(), a.checkpointMiddleware(), a.progressMiddleware()},
+	}, nil
+}
 
-	if hasCommandTool && len(a.allowedCommands) > 0 {
-		cmdTool := tools.NewCommandExecuteToolWithConfig(&tools.CommandToolConfig{
-			AllowedCommands: a.allowedCommands,
-		})
-		for i, name := range toolNames {
-			if name == "command_execute" {
-				toolsList[i] = cmdTool
-				break
-			}
+// resolveTool 按名称获取实际执行的工具实例
+//
+// 设置了工作目录时内置工具绑定到该目录，Agent 自行注册的工具（记忆、知识库、子 Agent 等）保持不变；
+// command_execute 使用 Agent 的命令白名单，Git 写操作按 Agent 配置开启。
+func (a *Agent) resolveTool(name string) (tool.InvokableTool, bool) {
+	t, ok := a.toolRegistry.Get(name)
+	if !ok {
+		return nil, false
+	}
+	if a.workDir != "" {
+		if scoped := tools.CreateToolsWithBasePath(a.workDir, []string{name}); len(scoped) > 0 {
+			t = scoped[0]
 		}
 	}
 
-	return compose.ToolsNodeConfig{
-		Tools: toolsList,
-	}, nil
+	switch name {
+	case "command_execute":
+		if len(a.allowedCommands) > 0 {
+			t = tools.NewCommandExecuteToolWithConfig(&tools.CommandToolConfig{
+				AllowedCommands: a.allowedCommands,
+				WorkingDir:      a.workDir,
+			})
+		}
+	case tools.GitBranchToolName:
+		if a.config.AllowGitWrite {
+			t = tools.NewGitBranchTool(a.workDir, true)
+		}
+	case tools.GitCommitToolName:
+		if a.config.AllowGitWrite {
+			t = tools.NewGitCommitTool(a.workDir, true)
+		}
+	}
+	return t, true
 }
 
 func (a *Agent) ClearHistory() {
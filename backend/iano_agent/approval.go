@@ -0,0 +1,189 @@
+package iano_agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+)
+
+// ApprovalPolicy 工具调用审批策略
+type ApprovalPolicy string
+
+const (
+	ApprovalAuto ApprovalPolicy = "auto" // 直接执行
+	ApprovalAsk  ApprovalPolicy = "ask"  // 执行前等待人工确认
+	ApprovalDeny ApprovalPolicy = "deny" // 始终拒绝
+)
+
+// DefaultApprovalTimeout 等待审批的默认超时时间，超时视为拒绝
+const DefaultApprovalTimeout = 5 * time.Minute
+
+// DangerousTools 未显式配置时默认需要审批的工具
+var DangerousTools = map[string]bool{
+	"file_delete":    true,
+	"shell_execute":  true,
+	"grep_replace":   true,
+	"env_set":        true,
+	"process_start":  true,
+	"process_input":  true,
+	"terminal_open":  true,
+	"terminal_write": true,
+}
+
+// IsValid 是否为合法的审批策略
+func (p ApprovalPolicy) IsValid() bool {
+	switch p {
+	case ApprovalAuto, ApprovalAsk, ApprovalDeny:
+		return true
+	}
+	return false
+}
+
+// ApprovalRequest 工具调用审批请求
+type ApprovalRequest struct {
+	ID        string        `json:"id"`        // 工具调用 ID
+	ToolName  string        `json:"tool"`      // 工具名称
+	Arguments string        `json:"arguments"` // 调用参数（JSON）
+	Timeout   time.Duration `json:"-"`         // 等待超时时间
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// ApprovalDecision 审批结果
+type ApprovalDecision struct {
+	Approved bool   `json:"approved"`
+	Reason   string `json:"reason"`
+}
+
+// ApprovalHandler 审批处理函数，阻塞直到得到结果或 ctx 结束
+type ApprovalHandler func(ctx context.Context, req *ApprovalRequest) (*ApprovalDecision, error)
+
+// ResolveApprovalPolicy 解析工具的审批策略
+//
+// 优先级：工具级配置 > Agent 级配置 > 内置默认值（危险工具为 ask，其余为 auto）。
+func (c *Config) ResolveApprovalPolicy(toolName string) ApprovalPolicy {
+	if p, ok := c.ToolApprovals[toolName]; ok && p.IsValid() {
+		return p
+	}
+	if c.ApprovalPolicy.IsValid() {
+		return c.ApprovalPolicy
+	}
+	if DangerousTools[toolName] {
+		return ApprovalAsk
+	}
+	return ApprovalAuto
+}
+
+// SetApprovalHandler 设置审批处理函数，每次请求可替换为绑定当前连接的处理函数
+func (a *Agent) SetApprovalHandler(handler ApprovalHandler) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.approvalHandler = handler
+}
+
+// approveToolCall 按审批策略检查工具调用，返回 nil 表示允许执行
+//
+// 同一次运行中对同一个调用 ID 只审批一次。
+func (a *Agent) approveToolCall(ctx context.Context, callID, name, arguments string) error {
+	policy := a.config.ResolveApprovalPolicy(name)
+	switch policy {
+	case ApprovalAuto:
+		return nil
+	case ApprovalDeny:
+		return fmt.Errorf("工具 %s 已被禁止调用", name)
+	}
+
+	a.mu.Lock()
+	if callID != "" {
+		if decision, ok := a.approvals[callID]; ok {
+			a.mu.Unlock()
+			return decisionError(name, decision)
+		}
+	}
+	handler := a.approvalHandler
+	a.mu.Unlock()
+
+	if handler == nil {
+		return fmt.Errorf("工具 %s 需要审批，但未配置审批处理", name)
+	}
+
+	timeout := a.config.ApprovalTimeout
+	if timeout <= 0 {
+		timeout = DefaultApprovalTimeout
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req := &ApprovalRequest{
+		ID:        callID,
+		ToolName:  name,
+		Arguments: arguments,
+		Timeout:   timeout,
+		CreatedAt: time.Now(),
+	}
+	slog.Info("等待工具调用审批", "id", callID, "name", name)
+
+	decision, err := handler(waitCtx, req)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+			decision = &ApprovalDecision{Approved: false, Reason: "审批超时"}
+		} else {
+			return fmt.Errorf("工具 %s 审批失败: %w", name, err)
+		}
+	}
+	if decision == nil {
+		decision = &ApprovalDecision{Approved: false}
+	}
+
+	if callID != "" {
+		a.mu.Lock()
+		a.approvals[callID] = decision
+		a.mu.Unlock()
+	}
+	slog.Info("工具调用审批完成", "id", callID, "name", name, "approved", decision.Approved, "reason", decision.Reason)
+
+	return decisionError(name, decision)
+}
+
+// decisionError 将拒绝结果转换为错误
+func decisionError(name string, decision *ApprovalDecision) error {
+	if decision.Approved {
+		return nil
+	}
+	if decision.Reason != "" {
+		return fmt.Errorf("工具 %s 调用被拒绝: %s", name, decision.Reason)
+	}
+	return fmt.Errorf("工具 %s 调用被拒绝", name)
+}
+
+// approvalMiddleware 在 ToolsNode 执行工具前进行审批，被拒绝时将原因作为工具结果返回给模型
+func (a *Agent) approvalMiddleware() compose.ToolMiddleware {
+	return compose.ToolMiddleware{
+		Invokable: func(next compose.InvokableToolEndpoint) compose.InvokableToolEndpoint {
+			return func(ctx context.Context, input *compose.ToolInput) (*compose.ToolOutput, error) {
+				if err := a.approveToolCall(ctx, input.CallID, input.Name, input.Arguments); err != nil {
+					if ctx.Err() != nil {
+						return nil, err
+					}
+					return &compose.ToolOutput{Result: err.Error()}, nil
+				}
+				return next(ctx, input)
+			}
+		},
+		Streamable: func(next compose.StreamableToolEndpoint) compose.StreamableToolEndpoint {
+			return func(ctx context.Context, input *compose.ToolInput) (*compose.StreamToolOutput, error) {
+				if err := a.approveToolCall(ctx, input.CallID, input.Name, input.Arguments); err != nil {
+					if ctx.Err() != nil {
+						return nil, err
+					}
+					return &compose.StreamToolOutput{Result: schema.StreamReaderFromArray([]string{err.Error()})}, nil
+				}
+				return next(ctx, input)
+			}
+		},
+	}
+}
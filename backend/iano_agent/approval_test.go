@@ -0,0 +1,120 @@
+package iano_agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/compose"
+)
+
+func newTestApprovalAgent(cfg *Config, handler ApprovalHandler) *Agent {
+	return &Agent{
+		config:          cfg,
+		approvalHandler: handler,
+		approvals:       make(map[string]*ApprovalDecision),
+	}
+}
+
+func TestConfig_ResolveApprovalPolicy(t *testing.T) {
+	cfg := &Config{
+		ApprovalPolicy: ApprovalAuto,
+		ToolApprovals:  map[string]ApprovalPolicy{"file_delete": ApprovalDeny, "file_read": ApprovalAsk},
+	}
+	tests := []struct {
+		name string
+		cfg  *Config
+		tool string
+		want ApprovalPolicy
+	}{
+		{"危险工具默认审批", &Config{}, "shell_execute", ApprovalAsk},
+		{"普通工具默认放行", &Config{}, "file_read", ApprovalAuto},
+		{"Agent 级策略覆盖默认值", cfg, "env_set", ApprovalAuto},
+		{"工具级策略优先", cfg, "file_delete", ApprovalDeny},
+		{"工具级策略可收紧", cfg, "file_read", ApprovalAsk},
+		{"非法策略被忽略", &Config{ApprovalPolicy: "maybe"}, "grep_replace", ApprovalAsk},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.ResolveApprovalPolicy(tt.tool); got != tt.want {
+				t.Errorf("ResolveApprovalPolicy(%q) = %s, want %s", tt.tool, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAgent_approveToolCall(t *testing.T) {
+	t.Run("批准后同一调用不再询问", func(t *testing.T) {
+		calls := 0
+		a := newTestApprovalAgent(&Config{}, func(ctx context.Context, req *ApprovalRequest) (*ApprovalDecision, error) {
+			calls++
+			if req.ToolName != "file_delete" || req.Arguments != `{"path":"a.txt"}` {
+				t.Errorf("req = %+v", req)
+			}
+			return &ApprovalDecision{Approved: true}, nil
+		})
+
+		for i := 0; i < 2; i++ {
+			if err := a.approveToolCall(context.Background(), "call_1", "file_delete", `{"path":"a.txt"}`); err != nil {
+				t.Fatalf("approveToolCall() error = %v", err)
+			}
+		}
+		if calls != 1 {
+			t.Errorf("handler calls = %d, want 1", calls)
+		}
+	})
+
+	t.Run("超时视为拒绝", func(t *testing.T) {
+		a := newTestApprovalAgent(&Config{ApprovalTimeout: 10 * time.Millisecond}, func(ctx context.Context, req *ApprovalRequest) (*ApprovalDecision, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+
+		err := a.approveToolCall(context.Background(), "call_1", "shell_execute", `{}`)
+		if err == nil || !strings.Contains(err.Error(), "审批超时") {
+			t.Errorf("approveToolCall() error = %v, want 审批超时", err)
+		}
+	})
+
+	t.Run("未配置处理函数时拒绝", func(t *testing.T) {
+		a := newTestApprovalAgent(&Config{}, nil)
+		if err := a.approveToolCall(context.Background(), "call_1", "env_set", `{}`); err == nil {
+			t.Error("approveToolCall() error = nil, want rejection")
+		}
+	})
+
+	t.Run("deny 策略不询问", func(t *testing.T) {
+		a := newTestApprovalAgent(&Config{ApprovalPolicy: ApprovalDeny}, func(ctx context.Context, req *ApprovalRequest) (*ApprovalDecision, error) {
+			t.Error("handler should not be called")
+			return &ApprovalDecision{Approved: true}, nil
+		})
+		if err := a.approveToolCall(context.Background(), "call_1", "file_read", `{}`); err == nil {
+			t.Error("approveToolCall() error = nil, want rejection")
+		}
+	})
+}
+
+func TestAgent_approvalMiddleware(t *testing.T) {
+	a := newTestApprovalAgent(&Config{}, func(ctx context.Context, req *ApprovalRequest) (*ApprovalDecision, error) {
+		return &ApprovalDecision{Approved: false, Reason: "不允许删除"}, nil
+	})
+
+	executed := false
+	endpoint := a.approvalMiddleware().Invokable(func(ctx context.Context, input *compose.ToolInput) (*compose.ToolOutput, error) {
+		executed = true
+		return &compose.ToolOutput{Result: "ok"}, nil
+	})
+
+	out, err := endpoint(context.Background(), &compose.ToolInput{Name: "file_delete", Arguments: `{}`, CallID: "call_1"})
+	if err != nil {
+		t.Fatalf("endpoint() error = %v", err)
+	}
+	if executed {
+		t.Error("rejected tool was executed")
+	}
+	if !strings.Contains(out.Result, "不允许删除") {
+		t.Errorf("Result = %q", out.Result)
+	}
+}
@@ -0,0 +1,244 @@
+package iano_agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/compose"
+)
+
+// StopReason 运行因预算限制提前结束的原因
+type StopReason string
+
+const (
+	StopReasonMaxRounds       StopReason = "max_rounds"       // 达到最大轮数
+	StopReasonBudgetExhausted StopReason = "budget_exhausted" // 用完 Token 或工具调用预算
+	StopReasonTimeout         StopReason = "timeout"          // 超过运行时长上限
+)
+
+// RunStopError 运行因预算限制提前结束，已生成的内容仍然有效
+type RunStopError struct {
+	Reason StopReason // 结束原因
+	Detail string     // 面向用户的说明
+	tokens bool       // 是否因 Token 预算结束
+}
+
+func (e *RunStopError) Error() string {
+	return e.Detail
+}
+
+// Unwrap Token 预算用完时可以用 errors.Is(err, ErrTokenBudgetExceeded) 判断
+func (e *RunStopError) Unwrap() error {
+	if e.tokens {
+		return ErrTokenBudgetExceeded
+	}
+	return nil
+}
+
+// RunBudget 单次运行的预算，字段为 0 表示不限制
+type RunBudget struct {
+	MaxRounds    int           // 最多调用模型的轮数
+	MaxToolCalls int           // 最多执行的工具调用次数
+	TokenBudget  int64         // Token 预算
+	Timeout      time.Duration // 运行时长上限
+}
+
+// SetRunBudget 设置请求级运行预算，非零字段只能收紧配置中的限制，为空时使用配置中的预算
+func (a *Agent) SetRunBudget(budget *RunBudget) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.budgetOverride = budget
+}
+
+// GetStopReason 最近一次运行提前结束的原因，正常完成时为空
+func (a *Agent) GetStopReason() StopReason {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.stopErr == nil {
+		return ""
+	}
+	return a.stopErr.Reason
+}
+
+// startBudget 计算本次运行生效的预算，需在 resetRunState 之后调用
+func (a *Agent) startBudget() RunBudget {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	budget := RunBudget{
+		MaxRounds:    a.config.MaxRounds,
+		MaxToolCalls: a.config.MaxToolCalls,
+		TokenBudget:  a.config.TokenBudget,
+		Timeout:      a.config.RunTimeout,
+	}
+	if o := a.budgetOverride; o != nil {
+		budget.MaxRounds = tighten(budget.MaxRounds, o.MaxRounds)
+		budget.MaxToolCalls = tighten(budget.MaxToolCalls, o.MaxToolCalls)
+		budget.TokenBudget = tighten(budget.TokenBudget, o.TokenBudget)
+		budget.Timeout = tighten(budget.Timeout, o.Timeout)
+	}
+	if budget.MaxRounds <= 0 {
+		budget.MaxRounds = DefaultConfig().MaxRounds
+	}
+
+	a.budget = budget
+	return budget
+}
+
+// tighten 用请求级限制收紧配置中的限制，两者为 0 表示不限制，请求不能放宽配置
+func tighten[T int | int64 | time.Duration](limit, override T) T {
+	if override <= 0 {
+		return limit
+	}
+	if limit <= 0 {
+		return override
+	}
+	return min(limit, override)
+}
+
+// stopRun 记录结束原因并取消本次运行，只保留第一个原因
+func (a *Agent) stopRun(reason StopReason, detail string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.stopRunLocked(&RunStopError{Reason: reason, Detail: detail})
+}
+
+// stopRunLocked 调用方需持有 a.mu
+func (a *Agent) stopRunLocked(err *RunStopError) {
+	if a.stopErr != nil {
+		return
+	}
+
+	a.stopErr = err
+	slog.Warn("运行已达到预算上限", "reason", err.Reason, "detail", err.Detail)
+	if a.runCancel != nil {
+		a.runCancel()
+	}
+}
+
+// runStopError 本次运行的结束原因，未提前结束时为 nil
+func (a *Agent) runStopError() *RunStopError {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.stopErr
+}
+
+// budgetStopError 判断运行中的错误是否由预算限制引起，是则返回结束原因
+func (a *Agent) budgetStopError(err error) *RunStopError {
+	if errors.Is(err, compose.ErrExceedMaxSteps) {
+		a.stopRun(StopReasonMaxRounds, fmt.Sprintf("已达到最大轮数 %d", a.budget.MaxRounds))
+	}
+	return a.runStopError()
+}
+
+// useRound 记录一次模型调用，超过最大轮数时结束运行
+func (a *Agent) useRound() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.rounds++
+	if a.rounds > a.budget.MaxRounds {
+		a.stopRunLocked(&RunStopError{
+			Reason: StopReasonMaxRounds,
+			Detail: fmt.Sprintf("已达到最大轮数 %d", a.budget.MaxRounds),
+		})
+	}
+}
+
+// remainingRounds 本次运行剩余的模型调用轮数
+func (a *Agent) remainingRounds() int {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.budget.MaxRounds - a.rounds
+}
+
+// useToolCall 记录一次工具调用，超过工具调用预算时结束运行并返回错误
+func (a *Agent) useToolCall() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.toolCalls++
+	if limit := a.budget.MaxToolCalls; limit > 0 && a.toolCalls > limit {
+		a.stopRunLocked(&RunStopError{
+			Reason: StopReasonBudgetExhausted,
+			Detail: fmt.Sprintf("已用完 %d 次工具调用预算", limit),
+		})
+		return a.stopErr
+	}
+	return nil
+}
+
+// checkTokenBudget 本次运行的用量超过预算时结束运行，调用方需持有 a.mu
+func (a *Agent) checkTokenBudget() {
+	budget := a.budget.TokenBudget
+	if budget <= 0 || a.runUsage.TotalTokens < budget {
+		return
+	}
+
+	a.stopRunLocked(&RunStopError{
+		Reason: StopReasonBudgetExhausted,
+		Detail: fmt.Sprintf("已用完 %d Token 的预算", budget),
+		tokens: true,
+	})
+}
+
+// startDeadline 超过运行时长上限时结束运行，返回的函数用于停止计时
+func (a *Agent) startDeadline(timeout time.Duration) func() {
+	if timeout <= 0 {
+		return func() {}
+	}
+	timer := time.AfterFunc(timeout, func() {
+		a.stopRun(StopReasonTimeout, fmt.Sprintf("运行超过时长上限 %s", timeout))
+	})
+	return func() { timer.Stop() }
+}
+
+// finishStopped 向回调推送结束说明，返回已生成的内容和结束原因
+func (a *Agent) finishStopped(fullResponse string, err *RunStopError) (string, error) {
+	a.emit(&Message{
+		Role:       "assistant",
+		Content:    fmt.Sprintf("\n\n（运行已停止：%s）", err.Detail),
+		StopReason: string(err.Reason),
+	})
+	a.IsDone = true
+	return fullResponse, err
+}
+
+// budgetMiddleware 统计 ToolsNode 中的工具调用次数，应位于最外层
+func (a *Agent) budgetMiddleware() compose.ToolMiddleware {
+	return compose.ToolMiddleware{
+		Invokable: func(next compose.InvokableToolEndpoint) compose.InvokableToolEndpoint {
+			return func(ctx context.Context, input *compose.ToolInput) (*compose.ToolOutput, error) {
+				if err := a.useToolCall(); err != nil {
+					return nil, err
+				}
+				return next(ctx, input)
+			}
+		},
+		Streamable: func(next compose.StreamableToolEndpoint) compose.StreamableToolEndpoint {
+			return func(ctx context.Context, input *compose.ToolInput) (*compose.StreamToolOutput, error) {
+				if err := a.useToolCall(); err != nil {
+					return nil, err
+				}
+				return next(ctx, input)
+			}
+		},
+	}
+}
+
+// roundCallbackHandler 在每次模型调用开始时计数
+func (a *Agent) roundCallbackHandler() callbacks.Handler {
+	return callbacks.NewHandlerBuilder().
+		OnStartFn(func(ctx context.Context, info *callbacks.RunInfo, input callbacks.CallbackInput) context.Context {
+			if info != nil && info.Component == components.ComponentOfChatModel {
+				a.useRound()
+			}
+			return ctx
+		}).
+		Build()
+}
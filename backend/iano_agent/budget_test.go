@@ -0,0 +1,154 @@
+package iano_agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// toolLoopChatModel 每次都要求调用 system_info 的测试模型，用于触发预算限制
+type toolLoopChatModel struct {
+	mu    sync.Mutex
+	calls int
+	delay time.Duration
+}
+
+func (m *toolLoopChatModel) next() *schema.Message {
+	m.mu.Lock()
+	m.calls++
+	id := fmt.Sprintf("call_%d", m.calls)
+	m.mu.Unlock()
+
+	time.Sleep(m.delay)
+	return schema.AssistantMessage("", []schema.ToolCall{{
+		ID:       id,
+		Function: schema.FunctionCall{Name: "system_info", Arguments: `{"info_type": "os"}`},
+	}})
+}
+
+func (m *toolLoopChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	return m.next(), nil
+}
+
+func (m *toolLoopChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	return schema.StreamReaderFromArray([]*schema.Message{m.next()}), nil
+}
+
+func (m *toolLoopChatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	return m, nil
+}
+
+func TestAgent_LoopWithRunBudget(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []Option
+		override  *RunBudget
+		delay     time.Duration
+		want      StopReason
+		wantCalls int
+	}{
+		{name: "最大轮数", opts: []Option{WithMaxRounds(3)}, want: StopReasonMaxRounds, wantCalls: 3},
+		{name: "工具调用次数", opts: []Option{WithMaxToolCalls(2)}, want: StopReasonBudgetExhausted, wantCalls: 3},
+		{name: "请求级预算优先", opts: []Option{WithMaxRounds(5)}, override: &RunBudget{MaxRounds: 2}, want: StopReasonMaxRounds, wantCalls: 2},
+		{name: "运行超时", opts: []Option{WithRunTimeout(50 * time.Millisecond)}, delay: 20 * time.Millisecond, want: StopReasonTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &toolLoopChatModel{delay: tt.delay}
+			opts := append([]Option{WithAllowedTools([]string{"system_info"})}, tt.opts...)
+			a, err := NewAgent(m, opts...)
+			if err != nil {
+				t.Fatalf("NewAgent() error = %v", err)
+			}
+			a.SetRunBudget(tt.override)
+
+			var last *Message
+			a.AppendCB(func(msg *Message) { last = msg })
+
+			_, err = a.Chat(context.Background(), "查看系统信息")
+			var stopped *RunStopError
+			if !errors.As(err, &stopped) || stopped.Reason != tt.want {
+				t.Fatalf("Chat() error = %v, want stop reason %s", err, tt.want)
+			}
+			if a.GetStopReason() != tt.want {
+				t.Errorf("GetStopReason() = %s, want %s", a.GetStopReason(), tt.want)
+			}
+			if last == nil || last.StopReason != string(tt.want) {
+				t.Errorf("last message = %+v, want stop reason %s", last, tt.want)
+			}
+			if tt.wantCalls > 0 && m.calls != tt.wantCalls {
+				t.Errorf("model calls = %d, want %d", m.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRunStopError_TokenBudget(t *testing.T) {
+	a := &Agent{tokenUsage: &TokenUsage{}, runUsage: &TokenUsage{}, budget: RunBudget{TokenBudget: 100}}
+	a.mergeUsage(&TokenUsage{TotalTokens: 120})
+
+	err := error(a.runStopError())
+	if !errors.Is(err, ErrTokenBudgetExceeded) {
+		t.Errorf("runStopError() = %v, want ErrTokenBudgetExceeded", err)
+	}
+	if a.GetStopReason() != StopReasonBudgetExhausted {
+		t.Errorf("GetStopReason() = %s", a.GetStopReason())
+	}
+}
+
+func TestAgent_startBudgetClampsOverride(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   RunBudget
+		override *RunBudget
+		want     RunBudget
+	}{
+		{
+			name:   "未指定时使用配置",
+			config: RunBudget{MaxRounds: 10, MaxToolCalls: 5, TokenBudget: 1000, Timeout: time.Minute},
+			want:   RunBudget{MaxRounds: 10, MaxToolCalls: 5, TokenBudget: 1000, Timeout: time.Minute},
+		},
+		{
+			name:     "请求可以收紧配置",
+			config:   RunBudget{MaxRounds: 10, MaxToolCalls: 5, TokenBudget: 1000, Timeout: time.Minute},
+			override: &RunBudget{MaxRounds: 3, MaxToolCalls: 2, TokenBudget: 100, Timeout: time.Second},
+			want:     RunBudget{MaxRounds: 3, MaxToolCalls: 2, TokenBudget: 100, Timeout: time.Second},
+		},
+		{
+			name:     "请求不能超过配置",
+			config:   RunBudget{MaxRounds: 10, MaxToolCalls: 5, TokenBudget: 1000, Timeout: time.Minute},
+			override: &RunBudget{MaxRounds: 100, MaxToolCalls: 50, TokenBudget: 100000, Timeout: time.Hour},
+			want:     RunBudget{MaxRounds: 10, MaxToolCalls: 5, TokenBudget: 1000, Timeout: time.Minute},
+		},
+		{
+			name:     "配置不限制时使用请求",
+			config:   RunBudget{MaxRounds: 10},
+			override: &RunBudget{MaxToolCalls: 50, TokenBudget: 100000, Timeout: time.Hour},
+			want:     RunBudget{MaxRounds: 10, MaxToolCalls: 50, TokenBudget: 100000, Timeout: time.Hour},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &Agent{
+				config: &Config{
+					MaxRounds:    tt.config.MaxRounds,
+					MaxToolCalls: tt.config.MaxToolCalls,
+					TokenBudget:  tt.config.TokenBudget,
+					RunTimeout:   tt.config.Timeout,
+				},
+				budgetOverride: tt.override,
+			}
+			if got := a.startBudget(); got != tt.want {
+				t.Errorf("startBudget() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
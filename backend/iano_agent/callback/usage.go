@@ -0,0 +1,142 @@
+package callback
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// UsageCallbackHandler 从模型调用结果中采集 Token 用量
+// 流式输出在后台协程中读取，调用方需在运行结束后调用 Wait 等待统计完成
+type UsageCallbackHandler struct {
+	callbacks.HandlerBuilder
+
+	OnUsage func(usage *Usage) // 每次模型调用结束时回调
+	wg      sync.WaitGroup
+}
+
+// NewUsageCallbackHandler 创建 Token 用量回调
+func NewUsageCallbackHandler(onUsage func(usage *Usage)) *UsageCallbackHandler {
+	return &UsageCallbackHandler{OnUsage: onUsage}
+}
+
+// Wait 等待所有流式输出的用量统计完成
+func (cb *UsageCallbackHandler) Wait() {
+	cb.wg.Wait()
+}
+
+func (cb *UsageCallbackHandler) OnStart(ctx context.Context, info *callbacks.RunInfo, input callbacks.CallbackInput) context.Context {
+	return ctx
+}
+
+func (cb *UsageCallbackHandler) OnError(ctx context.Context, info *callbacks.RunInfo, err error) context.Context {
+	return ctx
+}
+
+func (cb *UsageCallbackHandler) OnEnd(ctx context.Context, info *callbacks.RunInfo, output callbacks.CallbackOutput) context.Context {
+	if !isChatModel(info) {
+		return ctx
+	}
+
+	out := model.ConvCallbackOutput(output)
+	if out == nil {
+		return ctx
+	}
+
+	usage := out.TokenUsage
+	if usage == nil && out.Message != nil && out.Message.ResponseMeta != nil {
+		usage = toModelUsage(out.Message.ResponseMeta.Usage)
+	}
+	cb.report(usage)
+	return ctx
+}
+
+func (cb *UsageCallbackHandler) OnEndWithStreamOutput(ctx context.Context, info *callbacks.RunInfo,
+	output *schema.StreamReader[callbacks.CallbackOutput]) context.Context {
+
+	if !isChatModel(info) {
+		output.Close()
+		return ctx
+	}
+
+	cb.wg.Add(1)
+	go func() {
+		defer cb.wg.Done()
+		defer func() {
+			if err := recover(); err != nil {
+				slog.Error("Token 用量统计异常", "error", err)
+			}
+		}()
+		defer output.Close()
+
+		// 用量通常只在最后一个分片中返回，取最后一个非空值
+		var usage *model.TokenUsage
+		for {
+			frame, err := output.Recv()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				break
+			}
+
+			out := model.ConvCallbackOutput(frame)
+			if out == nil {
+				continue
+			}
+			if out.TokenUsage != nil {
+				usage = out.TokenUsage
+			} else if out.Message != nil && out.Message.ResponseMeta != nil && out.Message.ResponseMeta.Usage != nil {
+				usage = toModelUsage(out.Message.ResponseMeta.Usage)
+			}
+		}
+
+		cb.report(usage)
+	}()
+
+	return ctx
+}
+
+func (cb *UsageCallbackHandler) OnStartWithStreamInput(ctx context.Context, info *callbacks.RunInfo,
+	input *schema.StreamReader[callbacks.CallbackInput]) context.Context {
+	defer input.Close()
+	return ctx
+}
+
+func (cb *UsageCallbackHandler) report(usage *model.TokenUsage) {
+	if usage == nil || cb.OnUsage == nil {
+		return
+	}
+
+	cb.OnUsage(&Usage{
+		PromptTokens:           usage.PromptTokens,
+		PromptTokenDetails:     PromptTokenDetails{CachedTokens: usage.PromptTokenDetails.CachedTokens},
+		CompletionTokens:       usage.CompletionTokens,
+		TotalTokens:            usage.TotalTokens,
+		CompletionTokenDetails: CompletionTokenDetails{ReasoningTokens: usage.CompletionTokensDetails.ReasoningTokens},
+	})
+}
+
+func isChatModel(info *callbacks.RunInfo) bool {
+	return info != nil && info.Component == components.ComponentOfChatModel
+}
+
+func toModelUsage(usage *schema.TokenUsage) *model.TokenUsage {
+	if usage == nil {
+		return nil
+	}
+	return &model.TokenUsage{
+		PromptTokens:            usage.PromptTokens,
+		PromptTokenDetails:      model.PromptTokenDetails{CachedTokens: usage.PromptTokenDetails.CachedTokens},
+		CompletionTokens:        usage.CompletionTokens,
+		TotalTokens:             usage.TotalTokens,
+		CompletionTokensDetails: model.CompletionTokensDetails{ReasoningTokens: usage.CompletionTokensDetails.ReasoningTokens},
+	}
+}
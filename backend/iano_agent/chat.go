@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"iano_agent/callback"
+	"iano_agent/metrics"
+	agentmodel "iano_agent/model"
 	"io"
 	"log/slog"
 	"strings"
@@ -34,8 +36,8 @@ func (a *Agent) ChatSimple(ctx context.Context, userInput string) (string, error
 }
 
 func (a *Agent) invokeTool(ctx context.Context, name string, arguments string) (string, error) {
-	// 查找工具
-	tool, isFind := a.toolRegistry.Get(name)
+	// 查找工具，与 ToolsNode 使用相同的工具实例
+	tool, isFind := a.resolveTool(name)
 	if !isFind {
 		return "", fmt.Errorf("工具 %s 不存在", name)
 	}
@@ -63,25 +65,97 @@ func (a *Agent) Loop(ctx context.Context, messages []*schema.Message) (string, e
 	loopMessage := make([]*schema.Message, 0)
 	for _, msg := range messages {
 		loopMessage = append(loopMessage, &schema.Message{
-			Role:    msg.Role,
-			Content: msg.Content,
+			Role:                  msg.Role,
+			Content:               msg.Content,
+			ReasoningContent:      msg.ReasoningContent,
+			UserInputMultiContent: msg.UserInputMultiContent,
+			ToolCalls:             msg.ToolCalls,
+			ToolCallID:            msg.ToolCallID,
+			Extra:                 msg.Extra,
 		})
 	}
 
 	fullResponse := ""
-	maxIterations := 30
 	iteration := 0
 
-	for iteration < maxIterations {
+	// 配置了响应 Schema 时要求模型输出 JSON，最终答复校验失败后带上错误重新提示
+	responseSchema := a.activeResponseSchema()
+	schemaRetries := 0
+	var answer strings.Builder
+	// 配置了响应 Schema 时先缓冲回复分片，校验通过或确定不是最终答复后再回调，避免不合格的答复被推送和保存
+	var pending []*schema.Message
+	emit := func(msg *schema.Message) {
+		// 处理思考或者推理
+		a.AiThinkStart(msg)
+
+		fullResponse += msg.Content + msg.ReasoningContent
+
+		//消息回调
+		a.InvokeMsgCB(msg)
+
+		// 处理思考结束
+		a.AiThinkEnd(msg)
+	}
+	flush := func() {
+		for _, msg := range pending {
+			emit(msg)
+		}
+		pending = nil
+	}
+	if responseSchema != nil {
+		loopMessage = append([]*schema.Message{schema.SystemMessage(responseSchema.instruction())}, loopMessage...)
+	}
+
+	// 渲染指令模板作为系统提示词，并附上与本次用户消息相关的记忆
+	systemPrompt, err := a.buildSystemPrompt()
+	if err != nil {
+		return "", err
+	}
+	systemPrompt += a.recallMemories(ctx, messages)
+	loopMessage = append([]*schema.Message{schema.SystemMessage(systemPrompt)}, loopMessage...)
+
+	// 重置上一次运行的状态，统计本次运行的 Token 用量
+	a.resetRunState()
+	budget := a.startBudget()
+	usageHandler := callback.NewUsageCallbackHandler(a.addUsage)
+	defer usageHandler.Wait()
+
+	// 记录实际响应的提供商（配置了故障转移时可能不是首选提供商）
+	ctx = agentmodel.WithProviderReporter(ctx, a.setRunProvider)
+
+	// 预算用尽或超时时取消本次运行
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	a.setRunCancel(cancel)
+	defer a.startDeadline(budget.Timeout)()
+
+	for {
+		if stopErr := a.runStopError(); stopErr != nil {
+			return a.finishStopped(fullResponse, stopErr)
+		}
+		if a.remainingRounds() <= 0 {
+			a.stopRun(StopReasonMaxRounds, fmt.Sprintf("已达到最大轮数 %d", budget.MaxRounds))
+			continue
+		}
 		iteration++
 
-		opts := a.MakeStreamOpts()
+		// ReAct 内部的每次模型调用都计入轮数，每轮包含模型和工具两步，步数上限按剩余轮数计算
+		opts := append(a.MakeStreamOpts(), agent.WithComposeOptions(
+			compose.WithCallbacks(usageHandler, a.roundCallbackHandler()),
+			compose.WithRuntimeMaxSteps(2*a.remainingRounds()),
+		))
 		msgReader, err := a.ra.Stream(ctx, loopMessage, opts...)
 		if err != nil {
+			if stopErr := a.budgetStopError(err); stopErr != nil {
+				return a.finishStopped(fullResponse, stopErr)
+			}
 			return "", fmt.Errorf("流式对话失败: %w", err)
 		}
 
-		hasToolCalls := false
+		// 同一次回复的分片合并为一条助手消息，思考签名等 Extra 和工具调用随消息回传给模型
+		var reply *schema.Message
+		// 工具调用的参数可能分多个分片返回，读完整个回复后合并再执行
+		var toolChunks []*schema.Message
 
 		for {
 			msg, err := msgReader.Recv()
@@ -90,54 +164,87 @@ func (a *Agent) Loop(ctx context.Context, messages []*schema.Message) (string, e
 					slog.Info("流式对话结束", "iteration", iteration)
 					break
 				}
+				if stopErr := a.budgetStopError(err); stopErr != nil {
+					flush()
+					return a.finishStopped(fullResponse, stopErr)
+				}
 				slog.Error("读取消息失败", slog.String("error", err.Error()))
 				return "", fmt.Errorf("流式对话接收消息失败: %w", err)
 			}
 
 			if msg.Content != "" || msg.ReasoningContent != "" {
-				// 处理思考或者推理
-				a.AiThinkStart(msg)
+				answer.WriteString(msg.Content)
+				reply = appendReply(&loopMessage, reply, msg)
 
-				fullResponse += msg.Content + msg.ReasoningContent
+				if responseSchema != nil {
+					pending = append(pending, msg)
+				} else {
+					emit(msg)
+				}
+			}
+
+			if len(msg.Extra) > 0 {
+				reply = appendReply(&loopMessage, reply, msg)
+			}
+			if len(msg.ToolCalls) > 0 {
+				toolChunks = append(toolChunks, &schema.Message{Role: schema.Assistant, ToolCalls: msg.ToolCalls})
+			}
+		}
 
+		// 先输出内容后调用工具，或同一批中包含需要串行执行的工具时，由这里按顺序执行工具调用
+		if len(toolChunks) > 0 {
+			merged, err := schema.ConcatMessages(toolChunks)
+			if err != nil {
+				return "", fmt.Errorf("合并工具调用失败: %w", err)
+			}
+			reply = appendReply(&loopMessage, reply, &schema.Message{})
+			reply.ToolCalls = merged.ToolCalls
+			answer.Reset()
+			// 工具调用前的内容不是最终答复，无需校验
+			flush()
+			toolResults, err := a.runToolCalls(ctx, merged.ToolCalls)
+			if err != nil {
+				if stopErr := a.budgetStopError(err); stopErr != nil {
+					return a.finishStopped(fullResponse, stopErr)
+				}
+				return "", err
+			}
+			for i, tc := range merged.ToolCalls {
+				toolResult := toolResults[i]
 				loopMessage = append(loopMessage, &schema.Message{
-					Role:             msg.Role,
-					Content:          msg.Content,
-					ReasoningContent: msg.ReasoningContent,
+					Role:       schema.Tool,
+					Content:    fmt.Sprintf("工具调用结果: %s", toolResult),
+					ToolCallID: tc.ID,
 				})
+				fullResponse += fmt.Sprintf("\n工具调用结果: %s", toolResult)
 
-				//消息回调
-				a.InvokeMsgCB(msg)
-
-				// 处理思考结束
-				a.AiThinkEnd(msg)
+				// 工具回调
+				a.InvokeToolCB(tc, toolResult)
+			}
+		} else {
+			if responseSchema == nil {
+				break
 			}
 
-			if len(msg.ToolCalls) > 0 {
-				hasToolCalls = true
-				for _, tc := range msg.ToolCalls {
-					toolResult, err := a.invokeTool(ctx, tc.Function.Name, tc.Function.Arguments)
-					if err != nil {
-						slog.Error("工具调用失败", "id", tc.ID, "name", tc.Function.Name, "arguments", tc.Function.Arguments, "error", err.Error())
-						toolResult = fmt.Sprintf("工具调用错误: %s", err.Error())
-					}
-					slog.Info("工具调用完成", "id", tc.ID, "name", tc.Function.Name, "arguments", tc.Function.Arguments, "result", toolResult)
-
-					loopMessage = append(loopMessage, &schema.Message{
-						Role:       schema.Tool,
-						Content:    fmt.Sprintf("工具调用结果: %s", toolResult),
-						ToolCallID: tc.ID,
-					})
-					fullResponse += fmt.Sprintf("\n工具调用结果: %s", toolResult)
-
-					// 工具回调
-					a.InvokeToolCB(tc, toolResult)
-				}
+			output, errs := responseSchema.Validate(answer.String())
+			if len(errs) == 0 {
+				flush()
+				a.setStructuredOutput(output)
+				break
+			}
+			if schemaRetries >= a.config.MaxSchemaRetries {
+				// 重试次数用尽时保留最后一次答复，便于排查
+				flush()
+				return fullResponse, fmt.Errorf("%w: %s", ErrInvalidStructuredOutput, strings.Join(errs, "; "))
 			}
-		}
 
-		if !hasToolCalls {
-			break
+			schemaRetries++
+			slog.Warn("最终答复不符合响应 Schema，重新提示", "retry", schemaRetries, "errors", errs)
+			loopMessage = append(loopMessage, schema.UserMessage(responseSchema.retryPrompt(errs)))
+			answer.Reset()
+			// 不合格的答复只回传给模型，不推送给调用方
+			pending = nil
+			continue
 		}
 
 		slog.Info("工具调用完成，继续对话循环", "iteration", iteration)
@@ -148,6 +255,28 @@ func (a *Agent) Loop(ctx context.Context, messages []*schema.Message) (string, e
 	return fullResponse, nil
 }
 
+// appendReply 将流式分片合并到当前回复消息，reply 为空时新建一条消息加入 messages
+func appendReply(messages *[]*schema.Message, reply *schema.Message, chunk *schema.Message) *schema.Message {
+	if reply == nil {
+		reply = &schema.Message{Role: schema.Assistant}
+		*messages = append(*messages, reply)
+	}
+	reply.Content += chunk.Content
+	reply.ReasoningContent += chunk.ReasoningContent
+	for k, v := range chunk.Extra {
+		if reply.Extra == nil {
+			reply.Extra = make(map[string]any)
+		}
+		// 字符串按分片拼接，与 schema.ConcatMessages 一致
+		if s, ok := v.(string); ok {
+			prev, _ := reply.Extra[k].(string)
+			v = prev + s
+		}
+		reply.Extra[k] = v
+	}
+	return reply
+}
+
 func (a *Agent) InvokeToolCB(tc schema.ToolCall, callToolError string) {
 	if a.CBs == nil {
 		return
@@ -316,9 +445,10 @@ func (a *Agent) AddToolToRegistry(name string, t tool.InvokableTool) error {
 
 	ctx := context.Background()
 	ra, err := react.NewAgent(ctx, &react.AgentConfig{
-		ToolCallingModel: a.chatModel,
-		ToolsConfig:      toolsConfig,
-		MaxStep:          30,
+		ToolCallingModel:      a.chatModel,
+		ToolsConfig:           toolsConfig,
+		MaxStep:               2 * a.config.MaxRounds,
+		StreamToolCallChecker: a.toolCallChecker,
 	})
 	if err != nil {
 		return fmt.Errorf("创建代理失败: %w", err)
@@ -343,9 +473,10 @@ func (a *Agent) RemoveTool(name string) error {
 
 	ctx := context.Background()
 	ra, err := react.NewAgent(ctx, &react.AgentConfig{
-		ToolCallingModel: a.chatModel,
-		ToolsConfig:      toolsConfig,
-		MaxStep:          30,
+		ToolCallingModel:      a.chatModel,
+		ToolsConfig:           toolsConfig,
+		MaxStep:               2 * a.config.MaxRounds,
+		StreamToolCallChecker: a.toolCallChecker,
 	})
 	if err != nil {
 		return fmt.Errorf("创建代理失败: %w", err)
@@ -381,3 +512,96 @@ func (a *Agent) GetTokenUsage() *TokenUsage {
 	usage := *a.tokenUsage
 	return &usage
 }
+
+// GetRunUsage 获取最近一次运行的 Token 用量
+func (a *Agent) GetRunUsage() *TokenUsage {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	usage := *a.runUsage
+	return &usage
+}
+
+// resetRunState 重置上一次运行留下的状态，包括用量、实际响应的提供商、审批决定、结构化输出、引用、停止原因和预算计数
+func (a *Agent) resetRunState() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.runUsage = &TokenUsage{LastUpdated: time.Now()}
+	a.runProvider = ""
+	a.approvals = make(map[string]*ApprovalDecision)
+	a.structured = nil
+	a.citations = nil
+	a.stopErr = nil
+	a.rounds = 0
+	a.toolCalls = 0
+}
+
+// GetRunProvider 获取最近一次运行中实际响应的提供商，未配置故障转移时为空
+func (a *Agent) GetRunProvider() string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.runProvider
+}
+
+func (a *Agent) setRunProvider(provider string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.runProvider = provider
+}
+
+// addUsage 累加一次模型调用的 Token 用量
+func (a *Agent) addUsage(usage *callback.Usage) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	total := usage.TotalTokens
+	if total == 0 {
+		total = usage.PromptTokens + usage.CompletionTokens
+	}
+
+	now := time.Now()
+	for _, u := range []*TokenUsage{a.tokenUsage, a.runUsage} {
+		u.PromptTokens += int64(usage.PromptTokens)
+		u.CompletionTokens += int64(usage.CompletionTokens)
+		u.TotalTokens += int64(total)
+		u.CachedTokens += int64(usage.PromptTokenDetails.CachedTokens)
+		u.ReasoningTokens += int64(usage.CompletionTokenDetails.ReasoningTokens)
+		u.LastUpdated = now
+	}
+
+	metrics.GlobalMetrics.RecordChatTokens(usage.PromptTokens, usage.CompletionTokens)
+
+	a.checkTokenBudget()
+}
+
+// mergeUsage 将子 Agent 的用量计入本次运行
+func (a *Agent) mergeUsage(usage *TokenUsage) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	for _, u := range []*TokenUsage{a.tokenUsage, a.runUsage} {
+		u.PromptTokens += usage.PromptTokens
+		u.CompletionTokens += usage.CompletionTokens
+		u.TotalTokens += usage.TotalTokens
+		u.CachedTokens += usage.CachedTokens
+		u.ReasoningTokens += usage.ReasoningTokens
+		u.LastUpdated = now
+	}
+
+	a.checkTokenBudget()
+}
+
+func (a *Agent) setRunCancel(cancel context.CancelFunc) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.runCancel = cancel
+}
+
+// emit 将消息分发给所有回调
+func (a *Agent) emit(msg *Message) {
+	for _, cb := range a.CBs {
+		cb(msg)
+	}
+}
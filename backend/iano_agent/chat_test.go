@@ -0,0 +1,104 @@
+package iano_agent
+
+import (
+	"context"
+	"encoding/json"
+	agentmodel "iano_agent/model"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+)
+
+// claudeTextReply 只包含一段文本的 Messages API 流式响应
+const claudeTextReply = `event: message_start
+data: {"type":"message_start","message":{"id":"msg_02","type":"message","role":"assistant","content":[],"usage":{"input_tokens":200,"output_tokens":1}}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"目录下没有文件。"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":0}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":8}}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`
+
+func TestAgent_LoopKeepsThinkingSignature(t *testing.T) {
+	toolUse, err := os.ReadFile("model/testdata/claude_tool_use.sse")
+	if err != nil {
+		t.Fatalf("读取录制数据失败: %v", err)
+	}
+
+	var mu sync.Mutex
+	var requests []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		requests = append(requests, body)
+		n := len(requests)
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		if n == 1 {
+			w.Write(toolUse)
+		} else {
+			w.Write([]byte(claudeTextReply))
+		}
+	}))
+	defer server.Close()
+
+	cm, err := agentmodel.NewClaudeChatModel(&agentmodel.Config{
+		Type:    agentmodel.ProviderClaude,
+		BaseURL: server.URL,
+		APIKey:  "test-key",
+		Model:   "claude-sonnet-4-5",
+		Extra:   map[string]interface{}{"thinking_budget": float64(2048)},
+	})
+	if err != nil {
+		t.Fatalf("NewClaudeChatModel() error = %v", err)
+	}
+	a, err := NewAgent(cm, WithAllowedTools([]string{"file_list"}), WithWorkDir(t.TempDir()))
+	if err != nil {
+		t.Fatalf("NewAgent() error = %v", err)
+	}
+
+	if _, err := a.Chat(context.Background(), "看看当前目录"); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("requests = %d, want 2", len(requests))
+	}
+
+	// 第二次请求中带工具调用的回复需要原样回传签名过的思考块
+	messages := requests[1]["messages"].([]any)
+	var assistant []any
+	for _, m := range messages {
+		if msg := m.(map[string]any); msg["role"] == "assistant" {
+			assistant = msg["content"].([]any)
+		}
+	}
+	if len(assistant) != 3 {
+		t.Fatalf("assistant content = %+v", assistant)
+	}
+	thinking, text, toolUseBlock := assistant[0].(map[string]any), assistant[1].(map[string]any), assistant[2].(map[string]any)
+	if thinking["type"] != "thinking" || thinking["thinking"] != "需要先查看目录" || thinking["signature"] != "EqQBCgIYAhIM" {
+		t.Errorf("thinking block = %+v", thinking)
+	}
+	if text["text"] != "我来看一下目录内容。" || toolUseBlock["type"] != "tool_use" || toolUseBlock["id"] != "toolu_01" {
+		t.Errorf("text = %+v, tool_use = %+v", text, toolUseBlock)
+	}
+	last := messages[len(messages)-1].(map[string]any)["content"].([]any)[0].(map[string]any)
+	if last["type"] != "tool_result" || last["tool_use_id"] != "toolu_01" {
+		t.Errorf("last message = %+v", last)
+	}
+}
@@ -0,0 +1,85 @@
+package iano_agent
+
+import (
+	"context"
+	"fmt"
+	"iano_agent/tools"
+	"log/slog"
+
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+)
+
+// CheckpointRequest 工具修改文件前创建检查点的请求
+type CheckpointRequest struct {
+	CallID   string   // 工具调用 ID
+	ToolName string   // 工具名称
+	Paths    []string // 即将被写入、创建或删除的文件（绝对路径）
+}
+
+// CheckpointHandler 在工具修改文件前保存这些文件的当前内容，返回错误时不执行该工具调用
+type CheckpointHandler func(ctx context.Context, req *CheckpointRequest) error
+
+// SetCheckpointHandler 设置检查点处理函数，每次请求可替换为绑定当前消息的处理函数，传 nil 关闭检查点
+func (a *Agent) SetCheckpointHandler(handler CheckpointHandler) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.checkpointHandler = handler
+}
+
+// checkpointToolCall 为会修改文件的工具调用创建检查点，返回 nil 表示可以执行
+func (a *Agent) checkpointToolCall(ctx context.Context, callID, name, arguments string) error {
+	a.mu.RLock()
+	handler := a.checkpointHandler
+	a.mu.RUnlock()
+	if handler == nil {
+		return nil
+	}
+
+	t, ok := a.resolveTool(name)
+	if !ok {
+		return nil
+	}
+	paths, err := tools.ChangedFiles(t, arguments)
+	if err != nil {
+		return fmt.Errorf("工具 %s 未执行，创建检查点失败: %w", name, err)
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+
+	if err := handler(ctx, &CheckpointRequest{CallID: callID, ToolName: name, Paths: paths}); err != nil {
+		return fmt.Errorf("工具 %s 未执行，创建检查点失败: %w", name, err)
+	}
+	slog.Info("已创建检查点", "id", callID, "name", name, "files", len(paths))
+	return nil
+}
+
+// checkpointMiddleware 在 ToolsNode 执行会修改文件的工具前创建检查点，应位于并发限制中间件之后，
+// 保证保存的是执行前一刻的文件内容；创建失败时将原因作为工具结果返回给模型
+func (a *Agent) checkpointMiddleware() compose.ToolMiddleware {
+	return compose.ToolMiddleware{
+		Invokable: func(next compose.InvokableToolEndpoint) compose.InvokableToolEndpoint {
+			return func(ctx context.Context, input *compose.ToolInput) (*compose.ToolOutput, error) {
+				if err := a.checkpointToolCall(ctx, input.CallID, input.Name, input.Arguments); err != nil {
+					if ctx.Err() != nil {
+						return nil, err
+					}
+					return &compose.ToolOutput{Result: err.Error()}, nil
+				}
+				return next(ctx, input)
+			}
+		},
+		Streamable: func(next compose.StreamableToolEndpoint) compose.StreamableToolEndpoint {
+			return func(ctx context.Context, input *compose.ToolInput) (*compose.StreamToolOutput, error) {
+				if err := a.checkpointToolCall(ctx, input.CallID, input.Name, input.Arguments); err != nil {
+					if ctx.Err() != nil {
+						return nil, err
+					}
+					return &compose.StreamToolOutput{Result: schema.StreamReaderFromArray([]string{err.Error()})}, nil
+				}
+				return next(ctx, input)
+			}
+		},
+	}
+}
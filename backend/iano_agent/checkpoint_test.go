@@ -0,0 +1,76 @@
+package iano_agent
+
+import (
+	"context"
+	"errors"
+	"iano_agent/tools"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/compose"
+)
+
+func TestAgent_checkpointMiddleware(t *testing.T) {
+	dir := t.TempDir()
+	notePath := filepath.Join(dir, "note.txt")
+	if err := os.WriteFile(notePath, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	registry := tools.NewRegistry()
+	registry.Register("file_write", tools.NewFileWriteTool(dir))
+	registry.Register("file_read", tools.NewFileReadTool(dir))
+
+	var requests []*CheckpointRequest
+	var snapshots []string
+	var handlerErr error
+	a := &Agent{config: &Config{}, toolRegistry: registry}
+	a.SetCheckpointHandler(func(ctx context.Context, req *CheckpointRequest) error {
+		requests = append(requests, req)
+		for _, path := range req.Paths {
+			data, _ := os.ReadFile(path)
+			snapshots = append(snapshots, string(data))
+		}
+		return handlerErr
+	})
+
+	endpoint := a.checkpointMiddleware().Invokable(func(ctx context.Context, input *compose.ToolInput) (*compose.ToolOutput, error) {
+		tl, _ := a.resolveTool(input.Name)
+		result, err := tl.InvokableRun(ctx, input.Arguments)
+		return &compose.ToolOutput{Result: result}, err
+	})
+	run := func(name, args string) string {
+		t.Helper()
+		out, err := endpoint(context.Background(), &compose.ToolInput{Name: name, Arguments: args, CallID: "call_" + name})
+		if err != nil {
+			t.Fatalf("endpoint() error = %v", err)
+		}
+		return out.Result
+	}
+
+	// 只读工具不创建检查点
+	run("file_read", `{"path": "note.txt"}`)
+	if len(requests) != 0 {
+		t.Fatalf("requests for read-only tool = %+v", requests)
+	}
+
+	run("file_write", `{"path": "note.txt", "content": "new"}`)
+	if len(requests) != 1 || requests[0].ToolName != "file_write" || requests[0].CallID != "call_file_write" {
+		t.Fatalf("requests = %+v", requests)
+	}
+	if len(requests[0].Paths) != 1 || requests[0].Paths[0] != notePath || snapshots[0] != "old" {
+		t.Errorf("checkpoint paths = %v, snapshots = %v", requests[0].Paths, snapshots)
+	}
+
+	// 创建检查点失败时不执行工具
+	handlerErr = errors.New("磁盘已满")
+	result := run("file_write", `{"path": "note.txt", "content": "newer"}`)
+	if !strings.Contains(result, "创建检查点失败") || !strings.Contains(result, "磁盘已满") {
+		t.Errorf("result = %q", result)
+	}
+	if data, _ := os.ReadFile(notePath); string(data) != "new" {
+		t.Errorf("note.txt = %q, tool ran after checkpoint failure", data)
+	}
+}
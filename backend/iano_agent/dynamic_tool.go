@@ -16,6 +16,7 @@ type DynamicTool struct {
 	desc       string
 	parameters []ToolParamDef
 	handler    DynamicToolHandler
+	serial     bool
 }
 
 type ToolParamDef struct {
@@ -34,6 +35,7 @@ type DynamicToolConfig struct {
 	Desc       string
 	Parameters []ToolParamDef
 	Handler    DynamicToolHandler
+	Serial     bool // 有副作用，不能与其他工具并发执行
 }
 
 func NewDynamicTool(cfg *DynamicToolConfig) *DynamicTool {
@@ -42,9 +44,15 @@ func NewDynamicTool(cfg *DynamicToolConfig) *DynamicTool {
 		desc:       cfg.Desc,
 		parameters: cfg.Parameters,
 		handler:    cfg.Handler,
+		serial:     cfg.Serial,
 	}
 }
 
+// ParallelSafe 是否可以与其他工具并发执行
+func (t *DynamicTool) ParallelSafe() bool {
+	return !t.serial
+}
+
 func (t *DynamicTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
 	params := make(map[string]*schema.ParameterInfo)
 	for _, p := range t.parameters {
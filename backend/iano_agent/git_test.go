@@ -0,0 +1,51 @@
+package iano_agent
+
+import (
+	"context"
+	"iano_agent/tools"
+	"strings"
+	"testing"
+)
+
+func TestAgent_resolveGitTools(t *testing.T) {
+	dir := t.TempDir()
+	searcher := mapKnowledgeSearcher{}
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		wantErr string
+	}{
+		{name: "默认只读", wantErr: "未开启 Git 写操作"},
+		{name: "开启写操作", opts: []Option{WithGitWrite(true)}, wantErr: "不是 Git 仓库"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]Option{
+				WithWorkDir(dir),
+				WithKnowledgeBases(searcher, "kb1"),
+			}, tt.opts...)
+			a, err := NewAgent(&kbChatModel{}, opts...)
+			if err != nil {
+				t.Fatalf("NewAgent() error = %v", err)
+			}
+			if err := a.GetToolRegistry().Register(tools.GitCommitToolName, tools.NewGitCommitTool("", false)); err != nil {
+				t.Fatalf("Register() error = %v", err)
+			}
+
+			commit, ok := a.resolveTool(tools.GitCommitToolName)
+			if !ok {
+				t.Fatal("resolveTool(git_commit) not found")
+			}
+			_, err = commit.InvokableRun(context.Background(), `{"message": "x", "all": true}`)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("InvokableRun() error = %v, want %q", err, tt.wantErr)
+			}
+
+			// 设置工作目录后仍保留 Agent 注册的知识库工具
+			if _, ok := a.resolveTool(tools.KBSearchToolName); !ok {
+				t.Error("resolveTool(kb_search) not found with work dir")
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package iano_agent
+
+import (
+	"iano_agent/tools"
+)
+
+// registerKnowledgeTools 绑定了知识库时注册 kb_search 工具
+func (a *Agent) registerKnowledgeTools() error {
+	if a.config.KnowledgeSearcher == nil || len(a.config.KnowledgeBaseIDs) == 0 {
+		return nil
+	}
+	return a.toolRegistry.Register(tools.KBSearchToolName,
+		tools.NewKBSearchTool(a.config.KnowledgeSearcher, a.config.KnowledgeBaseIDs, a.recordCitation))
+}
+
+// recordCitation 记录 kb_search 返回的分块，同一分块在一次运行中使用同一个引用编号
+func (a *Agent) recordCitation(chunk *tools.KnowledgeChunk) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for i, c := range a.citations {
+		if c.ID == chunk.ID {
+			return i + 1
+		}
+	}
+	a.citations = append(a.citations, chunk)
+	return len(a.citations)
+}
+
+// GetCitations 最近一次运行中 kb_search 返回的分块，第 i 个元素对应引用编号 [i+1]
+func (a *Agent) GetCitations() []*tools.KnowledgeChunk {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return append([]*tools.KnowledgeChunk(nil), a.citations...)
+}
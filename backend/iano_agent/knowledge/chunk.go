@@ -0,0 +1,196 @@
+package knowledge
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// 默认分块参数，按字符数计算
+const (
+	DefaultChunkSize    = 800
+	DefaultChunkOverlap = 100
+)
+
+// ChunkOptions 分块参数
+type ChunkOptions struct {
+	Size    int // 每块的最大字符数，0 表示使用默认值
+	Overlap int // 相邻块之间重叠的字符数，用于保留上下文，不能超过 Size 的一半
+}
+
+// Chunk 文档的一个分块
+type Chunk struct {
+	Ordinal int    // 分块在文档中的序号，从 0 开始
+	Heading string // 分块所在的 Markdown 小节标题，多级标题用 " > " 连接
+	Content string // 分块内容
+}
+
+// Split 将文本按段落切分为大小不超过 Size 的分块
+//
+// 段落尽量保持完整，超长段落按句子切分，单个句子仍超长时按字符切分；
+// Markdown 标题开始新的分块，并记录在之后分块的 Heading 中。
+func Split(text string, opts ChunkOptions) []Chunk {
+	size := opts.Size
+	if size <= 0 {
+		size = DefaultChunkSize
+	}
+	overlap := min(max(opts.Overlap, 0), size/2)
+
+	var chunks []Chunk
+	var headings []string
+	var current []string
+	currentLen := 0
+	fresh := false // current 中是否有上一块重叠部分以外的新内容
+	heading := ""
+
+	emit := func() {
+		content := strings.TrimSpace(strings.Join(current, "\n\n"))
+		current, currentLen = nil, 0
+		if !fresh || content == "" {
+			return
+		}
+		fresh = false
+		chunks = append(chunks, Chunk{Ordinal: len(chunks), Heading: heading, Content: content})
+		if overlap > 0 {
+			if tail := lastRunes(content, overlap); tail != content {
+				current, currentLen = []string{tail}, utf8.RuneCountInString(tail)
+			}
+		}
+	}
+
+	for _, para := range paragraphs(text) {
+		if level, title := markdownHeading(para); level > 0 {
+			emit()
+			current, currentLen = nil, 0 // 新小节不与上一小节重叠
+			if level > len(headings) {
+				headings = append(headings, make([]string, level-len(headings))...)
+			}
+			headings = append(headings[:level-1], title)
+			heading = joinHeadings(headings)
+			continue
+		}
+
+		for _, piece := range splitLong(para, max(size-overlap-2, 1)) {
+			pieceLen := utf8.RuneCountInString(piece)
+			if currentLen > 0 && currentLen+pieceLen+2 > size {
+				emit()
+			}
+			current = append(current, piece)
+			currentLen += pieceLen + 2
+			fresh = true
+		}
+	}
+	emit()
+	return chunks
+}
+
+// paragraphs 按空行切分段落，去掉段落首尾的空白
+func paragraphs(text string) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	var result []string
+	for _, para := range strings.Split(text, "\n\n") {
+		// Markdown 标题可能紧跟正文，单独拆出来
+		lines := strings.Split(strings.TrimSpace(para), "\n")
+		start := 0
+		for i, line := range lines {
+			if level, _ := markdownHeading(line); level > 0 {
+				if block := strings.TrimSpace(strings.Join(lines[start:i], "\n")); block != "" {
+					result = append(result, block)
+				}
+				result = append(result, strings.TrimSpace(line))
+				start = i + 1
+			}
+		}
+		if block := strings.TrimSpace(strings.Join(lines[start:], "\n")); block != "" {
+			result = append(result, block)
+		}
+	}
+	return result
+}
+
+// markdownHeading 解析 Markdown 标题行，返回级别和标题，不是标题时级别为 0
+func markdownHeading(line string) (int, string) {
+	if strings.Contains(line, "\n") {
+		return 0, ""
+	}
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || level >= len(line) || line[level] != ' ' {
+		return 0, ""
+	}
+	return level, strings.TrimSpace(strings.TrimRight(line[level:], "#"))
+}
+
+func joinHeadings(headings []string) string {
+	parts := make([]string, 0, len(headings))
+	for _, h := range headings {
+		if h != "" {
+			parts = append(parts, h)
+		}
+	}
+	return strings.Join(parts, " > ")
+}
+
+// splitLong 将超过 limit 个字符的段落按句子切分，单个句子仍超长时按字符切分
+func splitLong(para string, limit int) []string {
+	if utf8.RuneCountInString(para) <= limit {
+		return []string{para}
+	}
+
+	var result []string
+	var sentence strings.Builder
+	var piece strings.Builder
+	pieceLen := 0
+	flushPiece := func() {
+		if s := strings.TrimSpace(piece.String()); s != "" {
+			result = append(result, s)
+		}
+		piece.Reset()
+		pieceLen = 0
+	}
+	addSentence := func() {
+		s := sentence.String()
+		sentence.Reset()
+		for _, part := range splitRunes(s, limit) {
+			partLen := utf8.RuneCountInString(part)
+			if pieceLen > 0 && pieceLen+partLen > limit {
+				flushPiece()
+			}
+			piece.WriteString(part)
+			pieceLen += partLen
+		}
+	}
+
+	for _, r := range para {
+		sentence.WriteRune(r)
+		if strings.ContainsRune("。！？；.!?;\n", r) {
+			addSentence()
+		}
+	}
+	addSentence()
+	flushPiece()
+	return result
+}
+
+// splitRunes 按字符数切分字符串
+func splitRunes(s string, limit int) []string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return []string{s}
+	}
+	var result []string
+	for start := 0; start < len(runes); start += limit {
+		result = append(result, string(runes[start:min(start+limit, len(runes))]))
+	}
+	return result
+}
+
+// lastRunes 返回字符串末尾的 n 个字符
+func lastRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[len(runes)-n:])
+}
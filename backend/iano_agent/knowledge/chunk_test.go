@@ -0,0 +1,76 @@
+package knowledge
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{text: "Go 1.24 and Go", want: []string{"go", "1", "24", "and", "go"}},
+		{text: "知识库Search", want: []string{"知识", "识库", "search"}},
+		{text: "猫，狗", want: []string{"猫", "狗"}},
+		{text: " ", want: []string{}},
+	}
+	for _, tt := range tests {
+		if got := Tokenize(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestSplit_Headings(t *testing.T) {
+	text := "# 部署指南\n前言\n\n## 安装\n下载安装包。\n\n执行安装命令。\n\n## 配置\n### 数据库\n填写连接串。"
+	chunks := Split(text, ChunkOptions{Size: 100})
+
+	want := []Chunk{
+		{Ordinal: 0, Heading: "部署指南", Content: "前言"},
+		{Ordinal: 1, Heading: "部署指南 > 安装", Content: "下载安装包。\n\n执行安装命令。"},
+		{Ordinal: 2, Heading: "部署指南 > 配置 > 数据库", Content: "填写连接串。"},
+	}
+	if !reflect.DeepEqual(chunks, want) {
+		t.Errorf("Split() = %+v, want %+v", chunks, want)
+	}
+}
+
+func TestSplit_SizeAndOverlap(t *testing.T) {
+	var paras []string
+	for i := 0; i < 20; i++ {
+		paras = append(paras, strings.Repeat("段落内容。", 6))
+	}
+	long := strings.Repeat("没有标点的超长句子", 30)
+	text := strings.Join(append(paras, long), "\n\n")
+
+	chunks := Split(text, ChunkOptions{Size: 120, Overlap: 20})
+	if len(chunks) < 3 {
+		t.Fatalf("Split() = %d chunks", len(chunks))
+	}
+	for i, c := range chunks {
+		if n := utf8.RuneCountInString(c.Content); n > 120 {
+			t.Errorf("chunk %d has %d runes", i, n)
+		}
+		if c.Ordinal != i {
+			t.Errorf("chunk %d ordinal = %d", i, c.Ordinal)
+		}
+		if i > 0 {
+			tail := lastRunes(chunks[i-1].Content, 20)
+			if !strings.HasPrefix(c.Content, tail) {
+				t.Errorf("chunk %d does not start with the previous tail %q", i, tail)
+			}
+		}
+	}
+	if last := chunks[len(chunks)-1].Content; !strings.HasSuffix(last, "没有标点的超长句子") {
+		t.Errorf("last chunk = %q", last)
+	}
+}
+
+func TestSplit_Empty(t *testing.T) {
+	if chunks := Split("\n\n# 标题\n\n", ChunkOptions{}); len(chunks) != 0 {
+		t.Errorf("Split() = %+v, want none", chunks)
+	}
+}
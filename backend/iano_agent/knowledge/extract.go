@@ -0,0 +1,196 @@
+package knowledge
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// Document 提取出的文档文本
+type Document struct {
+	Title string // 文档标题，取 Markdown 一级标题、HTML title 或文件名
+	Text  string // 纯文本内容，Markdown 保留原文以便按标题分块
+}
+
+// SupportedExtensions 支持提取文本的文件扩展名
+var SupportedExtensions = []string{".md", ".markdown", ".txt", ".text", ".html", ".htm", ".pdf"}
+
+// IsSupported 是否支持提取该文件的文本
+func IsSupported(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, supported := range SupportedExtensions {
+		if ext == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// Extract 按文件扩展名提取文档文本
+func Extract(name string, data []byte) (*Document, error) {
+	title := strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+
+	var doc *Document
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".md", ".markdown":
+		text, err := decodeText(data)
+		if err != nil {
+			return nil, err
+		}
+		doc = &Document{Title: markdownTitle(text), Text: text}
+	case ".txt", ".text":
+		text, err := decodeText(data)
+		if err != nil {
+			return nil, err
+		}
+		doc = &Document{Text: text}
+	case ".html", ".htm":
+		var err error
+		if doc, err = extractHTML(data); err != nil {
+			return nil, err
+		}
+	case ".pdf":
+		text, err := extractPDF(data)
+		if err != nil {
+			return nil, err
+		}
+		doc = &Document{Text: text}
+	default:
+		return nil, fmt.Errorf("不支持的文件类型: %s", filepath.Ext(name))
+	}
+
+	if doc.Title == "" {
+		doc.Title = title
+	}
+	if strings.TrimSpace(doc.Text) == "" {
+		return nil, fmt.Errorf("文档 %s 中没有可提取的文本", name)
+	}
+	return doc, nil
+}
+
+// decodeText 校验文本是 UTF-8 编码并去掉 BOM
+func decodeText(data []byte) (string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if !utf8.Valid(data) {
+		return "", fmt.Errorf("文本不是 UTF-8 编码")
+	}
+	return string(data), nil
+}
+
+// markdownTitle 第一个一级标题
+func markdownTitle(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if level, title := markdownHeading(strings.TrimSpace(line)); level == 1 {
+			return title
+		}
+	}
+	return ""
+}
+
+// htmlBlockTags 结束时需要换段的 HTML 元素
+var htmlBlockTags = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "header": true, "footer": true,
+	"li": true, "tr": true, "table": true, "pre": true, "blockquote": true, "br": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+}
+
+// extractHTML 提取 HTML 正文，h1-h6 转换为 Markdown 标题以便按小节分块
+func extractHTML(data []byte) (*Document, error) {
+	root, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("解析 HTML 失败: %w", err)
+	}
+
+	doc := &Document{}
+	var text strings.Builder
+	last := byte('\n') // 已写入内容的最后一个字节
+	write := func(s string) {
+		if s != "" {
+			text.WriteString(s)
+			last = s[len(s)-1]
+		}
+	}
+	var walk func(n *html.Node, pre bool)
+	walk = func(n *html.Node, pre bool) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "script", "style", "noscript", "template", "nav":
+				return
+			case "title":
+				if n.FirstChild != nil && doc.Title == "" {
+					doc.Title = strings.TrimSpace(n.FirstChild.Data)
+				}
+				return
+			case "h1", "h2", "h3", "h4", "h5", "h6":
+				write("\n\n" + strings.Repeat("#", int(n.Data[1]-'0')) + " ")
+			case "li":
+				write("\n- ")
+			case "pre":
+				pre = true
+			}
+		}
+		if n.Type == html.TextNode {
+			if pre {
+				write(n.Data)
+			} else {
+				// 连续空白合并为一个空格，行首不保留空格
+				s := collapseSpace(n.Data)
+				if last == '\n' || last == ' ' {
+					s = strings.TrimLeft(s, " ")
+				}
+				write(s)
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, pre)
+		}
+		if n.Type == html.ElementNode && htmlBlockTags[n.Data] {
+			write("\n\n")
+		}
+	}
+	walk(root, false)
+
+	// 合并多余的空行
+	var lines []string
+	blank := 0
+	for _, line := range strings.Split(text.String(), "\n") {
+		line = strings.TrimRight(line, " \t")
+		if strings.TrimSpace(line) == "" {
+			blank++
+			continue
+		}
+		if blank > 0 && len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		blank = 0
+		lines = append(lines, line)
+	}
+	doc.Text = strings.Join(lines, "\n")
+	return doc, nil
+}
+
+// collapseSpace 将连续的空白字符合并为一个空格
+func collapseSpace(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(r)
+	}
+	if space {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
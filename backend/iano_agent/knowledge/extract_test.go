@@ -0,0 +1,144 @@
+package knowledge
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestExtract_Markdown(t *testing.T) {
+	doc, err := Extract("docs/guide.md", []byte("\xef\xbb\xbf# 使用指南\n\n正文"))
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	if doc.Title != "使用指南" || doc.Text != "# 使用指南\n\n正文" {
+		t.Errorf("Extract() = %+v", doc)
+	}
+
+	doc, err = Extract("notes.txt", []byte("纯文本"))
+	if err != nil || doc.Title != "notes" {
+		t.Errorf("Extract(txt) = %+v, %v", doc, err)
+	}
+
+	if _, err := Extract("bad.txt", []byte{0xff, 0xfe, 0x00}); err == nil {
+		t.Error("Extract(non UTF-8) error = nil")
+	}
+	if _, err := Extract("empty.md", []byte("  \n")); err == nil {
+		t.Error("Extract(empty) error = nil")
+	}
+	if _, err := Extract("image.png", []byte("data")); err == nil {
+		t.Error("Extract(png) error = nil")
+	}
+}
+
+func TestExtract_HTML(t *testing.T) {
+	page := `<html><head><title>接口文档</title><style>p{color:red}</style></head>
+<body><nav>首页 | 关于</nav>
+<h1>概述</h1><p>服务提供   REST
+接口。</p>
+<h2>列表</h2><ul><li>第一项</li><li>第二项</li></ul>
+<pre>go  run .
+</pre><script>alert(1)</script></body></html>`
+
+	doc, err := Extract("api.html", []byte(page))
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	want := "# 概述\n\n服务提供 REST 接口。\n\n## 列表\n\n- 第一项\n\n- 第二项\n\ngo  run ."
+	if doc.Title != "接口文档" || doc.Text != want {
+		t.Errorf("Extract() = %q %q, want %q", doc.Title, doc.Text, want)
+	}
+}
+
+// buildPDF 按顺序生成对象并写入交叉引用表，streams 中的内容写为对应对象的流数据
+func buildPDF(objects []string, streams map[int][]byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.5\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n", i+1)
+		if data, ok := streams[i+1]; ok {
+			fmt.Fprintf(&buf, "<< %s /Length %d >>\nstream\n", obj, len(data))
+			buf.Write(data)
+			buf.WriteString("\nendstream")
+		} else {
+			buf.WriteString(obj)
+		}
+		buf.WriteString("\nendobj\n")
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+func deflate(data string) []byte {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	w.Write([]byte(data))
+	w.Close()
+	return buf.Bytes()
+}
+
+func TestExtract_PDF(t *testing.T) {
+	cmap := `/CIDInit /ProcSet findresource begin
+begincmap
+1 begincodespacerange <0000> <FFFF> endcodespacerange
+1 beginbfchar <0001> <77E5> endbfchar
+1 beginbfrange <0002> <0003> <8BC6> endbfrange
+endcmap`
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 6 0 R] /Count 2 /Resources << /Font << /F1 4 0 R >> >> >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 5 0 R >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		"/Filter /FlateDecode",
+		"<< /Type /Page /Parent 2 0 R /Resources << /Font << /F2 7 0 R >> >> /Contents [8 0 R] >>",
+		"null",
+		"",
+		"/Filter [/FlateDecode]",
+		"/Type /ObjStm /N 1 /First 4 /Filter /FlateDecode",
+	}
+	streams := map[int][]byte{
+		5: deflate("BT /F1 12 Tf 72 700 Td (Hello \\(PDF\\)) Tj 0 -14 Td [(Wor) -300 (ld)] TJ ET"),
+		8: []byte("BT /F2 10 Tf <00010002> Tj <0003> Tj ET"),
+		9: deflate(cmap),
+		// 对象 7（复合字体）定义在对象流 10 中
+		10: deflate("7 0 << /Type /Font /Subtype /Type0 /BaseFont /Song /ToUnicode 9 0 R >>"),
+	}
+	data := bytes.Replace(buildPDF(objects, streams), []byte("7 0 obj\nnull\nendobj\n"), nil, 1)
+
+	doc, err := Extract("manual.pdf", data)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	// bfrange 中的编码按顺序递增映射，0003 对应 U+8BC7
+	want := "Hello (PDF)\nWor ld\n\n知识诇"
+	if doc.Title != "manual" || doc.Text != want {
+		t.Errorf("Extract() = %q %q, want %q", doc.Title, doc.Text, want)
+	}
+}
+
+func TestExtract_PDFWithoutText(t *testing.T) {
+	data := buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R >>",
+		"",
+	}, map[int][]byte{4: []byte("q 100 0 0 100 0 0 cm /Im1 Do Q")})
+
+	_, err := Extract("scan.pdf", data)
+	if err == nil || !strings.Contains(err.Error(), "文字识别") {
+		t.Errorf("Extract() error = %v", err)
+	}
+	if _, err := Extract("fake.pdf", []byte("not a pdf")); err == nil {
+		t.Error("Extract(invalid pdf) error = nil")
+	}
+}
@@ -0,0 +1,816 @@
+package knowledge
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// PDF 文本提取只实现读取文本所需的最小子集：
+// 解析间接对象（包括对象流中的对象），按页面树顺序读取内容流中的文本操作符，
+// 支持 FlateDecode 压缩和字体的 ToUnicode 映射。不支持加密的 PDF，扫描件中没有可提取的文本。
+
+type (
+	pdfName    string
+	pdfRef     int
+	pdfKeyword string
+	pdfString  []byte
+	pdfArray   []interface{}
+	pdfDict    map[string]interface{}
+)
+
+// pdfMaxDepth 解析嵌套对象和页面树的最大深度，防止循环引用
+const pdfMaxDepth = 32
+
+// extractPDF 按页提取 PDF 中的文本，页之间用空行分隔
+func extractPDF(data []byte) (string, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte("%PDF-")) {
+		return "", fmt.Errorf("不是有效的 PDF 文件")
+	}
+
+	doc := parsePDF(data)
+	if doc.encrypted {
+		return "", fmt.Errorf("不支持加密的 PDF")
+	}
+
+	var pages []string
+	doc.eachPage(func(page, resources pdfDict) {
+		if text := strings.TrimSpace(doc.pageText(page, resources)); text != "" {
+			pages = append(pages, text)
+		}
+	})
+	if len(pages) == 0 {
+		return "", fmt.Errorf("PDF 中没有可提取的文本，扫描件需要先进行文字识别")
+	}
+	return strings.Join(pages, "\n\n"), nil
+}
+
+// pdfObject 间接对象
+type pdfObject struct {
+	value  interface{}
+	stream []byte // 流对象的原始数据
+}
+
+type pdfDoc struct {
+	objects   map[int]*pdfObject
+	fonts     map[pdfRef]*pdfFont
+	encrypted bool
+}
+
+var pdfObjectPattern = regexp.MustCompile(`(\d+)\s+\d+\s+obj\b`)
+
+// parsePDF 扫描文件中的全部间接对象，后出现的同号对象（增量更新）覆盖之前的对象
+func parsePDF(data []byte) *pdfDoc {
+	doc := &pdfDoc{objects: make(map[int]*pdfObject), fonts: make(map[pdfRef]*pdfFont)}
+
+	for _, m := range pdfObjectPattern.FindAllSubmatchIndex(data, -1) {
+		num, err := strconv.Atoi(string(data[m[2]:m[3]]))
+		if err != nil {
+			continue
+		}
+		lex := &pdfLexer{data: data, pos: m[1]}
+		value := lex.value(0)
+		obj := &pdfObject{value: value}
+
+		lex.skipSpace()
+		if bytes.HasPrefix(data[lex.pos:], []byte("stream")) {
+			obj.stream = streamBytes(data, lex.pos+len("stream"), value)
+		}
+		doc.objects[num] = obj
+	}
+
+	// 对象流中的对象
+	for _, obj := range doc.objects {
+		dict, ok := obj.value.(pdfDict)
+		if !ok || dict["Type"] != pdfName("ObjStm") {
+			continue
+		}
+		doc.loadObjectStream(obj, dict)
+	}
+
+	if trailer := bytes.LastIndex(data, []byte("trailer")); trailer >= 0 {
+		lex := &pdfLexer{data: data, pos: trailer + len("trailer")}
+		if dict, ok := lex.value(0).(pdfDict); ok && dict["Encrypt"] != nil {
+			doc.encrypted = true
+		}
+	}
+	for _, obj := range doc.objects {
+		if dict, ok := obj.value.(pdfDict); ok && dict["Type"] == pdfName("XRef") && dict["Encrypt"] != nil {
+			doc.encrypted = true
+		}
+	}
+	return doc
+}
+
+// streamBytes 读取 stream 关键字之后的流数据，优先使用直接给出的 Length
+func streamBytes(data []byte, start int, value interface{}) []byte {
+	if bytes.HasPrefix(data[start:], []byte("\r\n")) {
+		start += 2
+	} else if start < len(data) && (data[start] == '\n' || data[start] == '\r') {
+		start++
+	}
+
+	if dict, ok := value.(pdfDict); ok {
+		if length, ok := dict["Length"].(float64); ok {
+			end := start + int(length)
+			if length >= 0 && end <= len(data) && bytes.HasPrefix(bytes.TrimLeft(data[end:], "\r\n \t"), []byte("endstream")) {
+				return data[start:end]
+			}
+		}
+	}
+
+	end := bytes.Index(data[start:], []byte("endstream"))
+	if end < 0 {
+		return data[start:]
+	}
+	return bytes.TrimRight(data[start:start+end], "\r\n")
+}
+
+// loadObjectStream 加载对象流中的对象，不覆盖文件中直接定义的对象
+func (d *pdfDoc) loadObjectStream(obj *pdfObject, dict pdfDict) {
+	data, ok := d.decodeStream(obj)
+	if !ok {
+		return
+	}
+	n, _ := dict["N"].(float64)
+	first, _ := dict["First"].(float64)
+
+	header := &pdfLexer{data: data}
+	for i := 0; i < int(n); i++ {
+		num, ok1 := header.next()
+		offset, ok2 := header.next()
+		numValue, ok3 := num.(float64)
+		offsetValue, ok4 := offset.(float64)
+		if !ok1 || !ok2 || !ok3 || !ok4 {
+			return
+		}
+		if _, exists := d.objects[int(numValue)]; exists {
+			continue
+		}
+		pos := int(first) + int(offsetValue)
+		if pos < 0 || pos >= len(data) {
+			continue
+		}
+		lex := &pdfLexer{data: data, pos: pos}
+		d.objects[int(numValue)] = &pdfObject{value: lex.value(0)}
+	}
+}
+
+// decodeStream 解码流数据，只支持 FlateDecode，其他过滤器（例如图片）返回 false
+func (d *pdfDoc) decodeStream(obj *pdfObject) ([]byte, bool) {
+	if obj == nil || obj.stream == nil {
+		return nil, false
+	}
+	dict, _ := obj.value.(pdfDict)
+
+	var filters []interface{}
+	switch f := d.resolve(dict["Filter"]).(type) {
+	case pdfName:
+		filters = []interface{}{f}
+	case pdfArray:
+		filters = f
+	}
+
+	data := obj.stream
+	for _, filter := range filters {
+		if d.resolve(filter) != pdfName("FlateDecode") {
+			return nil, false
+		}
+		r, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, false
+		}
+		// 部分 PDF 的压缩流缺少校验和，保留已解压的内容
+		decoded, err := io.ReadAll(r)
+		if err != nil && len(decoded) == 0 {
+			return nil, false
+		}
+		data = decoded
+	}
+	return data, true
+}
+
+// resolve 解析间接引用
+func (d *pdfDoc) resolve(v interface{}) interface{} {
+	for i := 0; i < pdfMaxDepth; i++ {
+		ref, ok := v.(pdfRef)
+		if !ok {
+			return v
+		}
+		obj := d.objects[int(ref)]
+		if obj == nil {
+			return nil
+		}
+		v = obj.value
+	}
+	return nil
+}
+
+func (d *pdfDoc) dict(v interface{}) pdfDict {
+	dict, _ := d.resolve(v).(pdfDict)
+	return dict
+}
+
+// eachPage 按页面树顺序遍历页面，页面的资源可以从父节点继承
+func (d *pdfDoc) eachPage(visit func(page, resources pdfDict)) {
+	var root pdfDict
+	nums := make([]int, 0, len(d.objects))
+	for num := range d.objects {
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+	for _, num := range nums {
+		if dict, ok := d.objects[num].value.(pdfDict); ok && dict["Type"] == pdfName("Catalog") {
+			root = d.dict(dict["Pages"])
+		}
+	}
+
+	if root == nil {
+		// 页面树损坏时按对象编号顺序读取页面
+		for _, num := range nums {
+			if dict, ok := d.objects[num].value.(pdfDict); ok && dict["Type"] == pdfName("Page") {
+				visit(dict, d.dict(dict["Resources"]))
+			}
+		}
+		return
+	}
+
+	var walk func(node, resources pdfDict, depth int)
+	walk = func(node, resources pdfDict, depth int) {
+		if node == nil || depth > pdfMaxDepth {
+			return
+		}
+		if res := d.dict(node["Resources"]); res != nil {
+			resources = res
+		}
+		if kids, ok := d.resolve(node["Kids"]).(pdfArray); ok {
+			for _, kid := range kids {
+				walk(d.dict(kid), resources, depth+1)
+			}
+			return
+		}
+		visit(node, resources)
+	}
+	walk(root, nil, 0)
+}
+
+// pageText 提取一个页面的文本
+func (d *pdfDoc) pageText(page, resources pdfDict) string {
+	var content []byte
+	contents := d.resolve(page["Contents"])
+	refs, ok := contents.(pdfArray)
+	if !ok {
+		refs = pdfArray{page["Contents"]}
+	}
+	for _, ref := range refs {
+		r, ok := ref.(pdfRef)
+		if !ok {
+			continue
+		}
+		if data, ok := d.decodeStream(d.objects[int(r)]); ok {
+			content = append(content, data...)
+			content = append(content, '\n')
+		}
+	}
+
+	fonts := make(map[string]*pdfFont)
+	for name, ref := range d.dict(resources["Font"]) {
+		fonts[name] = d.font(ref)
+	}
+	return contentText(content, fonts)
+}
+
+// pdfFont 字体的文本解码信息
+type pdfFont struct {
+	cmap      *pdfCMap // ToUnicode 映射
+	composite bool     // Type0 字体，没有 ToUnicode 时无法解码
+}
+
+func (d *pdfDoc) font(v interface{}) *pdfFont {
+	ref, isRef := v.(pdfRef)
+	if isRef {
+		if font, ok := d.fonts[ref]; ok {
+			return font
+		}
+	}
+
+	dict := d.dict(v)
+	font := &pdfFont{composite: dict["Subtype"] == pdfName("Type0")}
+	if cmapRef, ok := dict["ToUnicode"].(pdfRef); ok {
+		if data, ok := d.decodeStream(d.objects[int(cmapRef)]); ok {
+			font.cmap = parseCMap(data)
+		}
+	}
+	if isRef {
+		d.fonts[ref] = font
+	}
+	return font
+}
+
+// decode 将字符串中的字符编码转换为文本
+func (f *pdfFont) decode(b []byte) string {
+	if f != nil && f.cmap != nil && len(f.cmap.codes) > 0 {
+		return f.cmap.decode(b)
+	}
+	if f != nil && f.composite {
+		return ""
+	}
+	if bytes.HasPrefix(b, []byte{0xfe, 0xff}) {
+		return decodeUTF16(b[2:])
+	}
+
+	// 简单字体按 WinAnsi 编码近似处理
+	runes := make([]rune, 0, len(b))
+	for _, c := range b {
+		if r, ok := winAnsiSpecial[c]; ok {
+			runes = append(runes, r)
+		} else {
+			runes = append(runes, rune(c))
+		}
+	}
+	return string(runes)
+}
+
+// winAnsiSpecial WinAnsi 编码中与 Latin-1 不同的常用字符
+var winAnsiSpecial = map[byte]rune{
+	0x80: '€', 0x85: '…', 0x91: '‘', 0x92: '’', 0x93: '“', 0x94: '”', 0x95: '•', 0x96: '–', 0x97: '—',
+}
+
+// contentText 解释内容流中的文本操作符
+func contentText(content []byte, fonts map[string]*pdfFont) string {
+	var out strings.Builder
+	newline := func() {
+		if s := out.String(); s != "" && !strings.HasSuffix(s, "\n") {
+			out.WriteByte('\n')
+		}
+	}
+
+	var font *pdfFont
+	var operands []interface{}
+	lastY := math.NaN()
+	lex := &pdfLexer{data: content}
+	for {
+		tok, ok := lex.next()
+		if !ok {
+			break
+		}
+		op, isOp := tok.(pdfKeyword)
+		if !isOp || op == "[" || op == "<<" {
+			operands = append(operands, lex.fromToken(tok, 0))
+			continue
+		}
+
+		arg := func(i int) interface{} {
+			if i < len(operands) {
+				return operands[len(operands)-1-i]
+			}
+			return nil
+		}
+		switch op {
+		case "Tf":
+			if name, ok := arg(1).(pdfName); ok {
+				font = fonts[string(name)]
+			}
+		case "Tj":
+			if s, ok := arg(0).(pdfString); ok {
+				out.WriteString(font.decode(s))
+			}
+		case "'", "\"":
+			newline()
+			if s, ok := arg(0).(pdfString); ok {
+				out.WriteString(font.decode(s))
+			}
+		case "TJ":
+			items, _ := arg(0).(pdfArray)
+			for _, item := range items {
+				switch v := item.(type) {
+				case pdfString:
+					out.WriteString(font.decode(v))
+				case float64:
+					// 较大的负偏移通常表示单词间距
+					if v < -250 && !strings.HasSuffix(out.String(), " ") {
+						out.WriteByte(' ')
+					}
+				}
+			}
+		case "Td", "TD":
+			if ty, ok := arg(0).(float64); ok && ty != 0 {
+				newline()
+			}
+		case "T*", "ET":
+			newline()
+		case "Tm":
+			if y, ok := arg(0).(float64); ok {
+				if y != lastY {
+					newline()
+				}
+				lastY = y
+			}
+		case "ID":
+			lex.skipInlineImage()
+		}
+		operands = operands[:0]
+	}
+	return out.String()
+}
+
+// pdfCMap ToUnicode 映射
+type pdfCMap struct {
+	codes   map[string]string
+	lengths []int // 编码的字节长度，从长到短
+}
+
+// parseCMap 解析 ToUnicode CMap 中的 bfchar 和 bfrange
+func parseCMap(data []byte) *pdfCMap {
+	cmap := &pdfCMap{codes: make(map[string]string)}
+	lengths := make(map[int]bool)
+
+	lex := &pdfLexer{data: data}
+	strs := func(end pdfKeyword) []interface{} {
+		var items []interface{}
+		for {
+			tok, ok := lex.next()
+			if !ok || tok == end {
+				return items
+			}
+			items = append(items, lex.fromToken(tok, 0))
+		}
+	}
+
+	for {
+		tok, ok := lex.next()
+		if !ok {
+			break
+		}
+		switch tok {
+		case pdfKeyword("begincodespacerange"):
+			items := strs("endcodespacerange")
+			for i := 0; i+1 < len(items); i += 2 {
+				if lo, ok := items[i].(pdfString); ok && len(lo) > 0 {
+					lengths[len(lo)] = true
+				}
+			}
+		case pdfKeyword("beginbfchar"):
+			items := strs("endbfchar")
+			for i := 0; i+1 < len(items); i += 2 {
+				src, ok1 := items[i].(pdfString)
+				dst, ok2 := items[i+1].(pdfString)
+				if ok1 && ok2 && len(src) > 0 {
+					cmap.codes[string(src)] = decodeUTF16(dst)
+					lengths[len(src)] = true
+				}
+			}
+		case pdfKeyword("beginbfrange"):
+			items := strs("endbfrange")
+			for i := 0; i+2 < len(items); i += 3 {
+				lo, ok1 := items[i].(pdfString)
+				hi, ok2 := items[i+1].(pdfString)
+				if !ok1 || !ok2 || len(lo) == 0 || len(lo) != len(hi) || len(lo) > 4 {
+					continue
+				}
+				lengths[len(lo)] = true
+				cmap.addRange(lo, hi, items[i+2])
+			}
+		}
+	}
+
+	for n := range lengths {
+		cmap.lengths = append(cmap.lengths, n)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(cmap.lengths)))
+	return cmap
+}
+
+// addRange 添加 bfrange 映射，目标为字符串时按最后一个 UTF-16 码元递增，为数组时逐个对应
+func (c *pdfCMap) addRange(lo, hi pdfString, dst interface{}) {
+	start, end := bytesToInt(lo), bytesToInt(hi)
+	if end < start || end-start > 0xffff {
+		return
+	}
+	for code := start; code <= end; code++ {
+		src := intToBytes(code, len(lo))
+		offset := code - start
+		switch v := dst.(type) {
+		case pdfString:
+			units := make([]uint16, 0, len(v)/2)
+			for i := 0; i+1 < len(v); i += 2 {
+				units = append(units, uint16(v[i])<<8|uint16(v[i+1]))
+			}
+			if len(units) == 0 {
+				continue
+			}
+			units[len(units)-1] += uint16(offset)
+			c.codes[string(src)] = string(utf16.Decode(units))
+		case pdfArray:
+			if offset < len(v) {
+				if s, ok := v[offset].(pdfString); ok {
+					c.codes[string(src)] = decodeUTF16(s)
+				}
+			}
+		}
+	}
+}
+
+// decode 按最长匹配将字符编码转换为文本，没有映射的编码被忽略
+func (c *pdfCMap) decode(b []byte) string {
+	var out strings.Builder
+	for i := 0; i < len(b); {
+		matched := false
+		for _, n := range c.lengths {
+			if i+n <= len(b) {
+				if s, ok := c.codes[string(b[i:i+n])]; ok {
+					out.WriteString(s)
+					i += n
+					matched = true
+					break
+				}
+			}
+		}
+		if !matched {
+			i += c.lengths[len(c.lengths)-1]
+		}
+	}
+	return out.String()
+}
+
+func bytesToInt(b []byte) int {
+	n := 0
+	for _, c := range b {
+		n = n<<8 | int(c)
+	}
+	return n
+}
+
+func intToBytes(n, size int) []byte {
+	b := make([]byte, size)
+	for i := size - 1; i >= 0; i-- {
+		b[i] = byte(n)
+		n >>= 8
+	}
+	return b
+}
+
+func decodeUTF16(b []byte) string {
+	units := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		units = append(units, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	return string(utf16.Decode(units))
+}
+
+// pdfLexer PDF 词法分析器，同时用于解析对象和内容流
+type pdfLexer struct {
+	data []byte
+	pos  int
+}
+
+func isPDFSpace(c byte) bool {
+	return c == 0 || c == '\t' || c == '\n' || c == '\f' || c == '\r' || c == ' '
+}
+
+func isPDFDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+// skipSpace 跳过空白和注释
+func (l *pdfLexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		if !isPDFSpace(c) {
+			return
+		}
+		l.pos++
+	}
+}
+
+// next 读取下一个词法单元：数字、名称、字符串或关键字（包括 [ ] << >> 等分隔符）
+func (l *pdfLexer) next() (interface{}, bool) {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, false
+	}
+
+	c := l.data[l.pos]
+	switch {
+	case c == '(':
+		return l.literalString(), true
+	case c == '<' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '<':
+		l.pos += 2
+		return pdfKeyword("<<"), true
+	case c == '>' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '>':
+		l.pos += 2
+		return pdfKeyword(">>"), true
+	case c == '<':
+		return l.hexString(), true
+	case c == '/':
+		l.pos++
+		return pdfName(l.regular(true)), true
+	case isPDFDelimiter(c):
+		l.pos++
+		return pdfKeyword(string(c)), true
+	}
+
+	word := l.regular(false)
+	if strings.IndexByte("+-.0123456789", word[0]) >= 0 {
+		if n, err := strconv.ParseFloat(word, 64); err == nil {
+			return n, true
+		}
+	}
+	switch word {
+	case "true":
+		return true, true
+	case "false":
+		return false, true
+	case "null":
+		return nil, true
+	}
+	return pdfKeyword(word), true
+}
+
+// regular 读取连续的常规字符，名称中的 #xx 转义按十六进制解码
+func (l *pdfLexer) regular(name bool) string {
+	start := l.pos
+	for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	if l.pos == start && !name {
+		// 无法识别的字符，跳过以免死循环
+		l.pos++
+		return string(l.data[start:l.pos])
+	}
+	word := string(l.data[start:l.pos])
+	if name && strings.Contains(word, "#") {
+		var b strings.Builder
+		for i := 0; i < len(word); i++ {
+			if word[i] == '#' && i+2 < len(word) {
+				if v, err := strconv.ParseUint(word[i+1:i+3], 16, 8); err == nil {
+					b.WriteByte(byte(v))
+					i += 2
+					continue
+				}
+			}
+			b.WriteByte(word[i])
+		}
+		word = b.String()
+	}
+	return word
+}
+
+func (l *pdfLexer) literalString() pdfString {
+	l.pos++ // (
+	var out []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return out
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				return out
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b':
+				out = append(out, '\b')
+			case 'f':
+				out = append(out, '\f')
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+			case '\n':
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					out = append(out, byte(v))
+				} else {
+					out = append(out, e)
+				}
+			}
+			continue
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+func (l *pdfLexer) hexString() pdfString {
+	l.pos++ // <
+	var digits []byte
+	for l.pos < len(l.data) && l.data[l.pos] != '>' {
+		if c := l.data[l.pos]; strings.IndexByte("0123456789abcdefABCDEF", c) >= 0 {
+			digits = append(digits, c)
+		}
+		l.pos++
+	}
+	l.pos++ // >
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, len(digits)/2)
+	for i := range out {
+		v, _ := strconv.ParseUint(string(digits[2*i:2*i+2]), 16, 8)
+		out[i] = byte(v)
+	}
+	return out
+}
+
+// value 读取一个完整的对象，数组和字典递归解析，"n g R" 解析为间接引用
+func (l *pdfLexer) value(depth int) interface{} {
+	tok, ok := l.next()
+	if !ok {
+		return nil
+	}
+	return l.fromToken(tok, depth)
+}
+
+func (l *pdfLexer) fromToken(tok interface{}, depth int) interface{} {
+	if depth > pdfMaxDepth {
+		return nil
+	}
+	switch v := tok.(type) {
+	case pdfKeyword:
+		switch v {
+		case "[":
+			arr := pdfArray{}
+			for {
+				item, ok := l.next()
+				if !ok || item == pdfKeyword("]") {
+					return arr
+				}
+				arr = append(arr, l.fromToken(item, depth+1))
+			}
+		case "<<":
+			dict := pdfDict{}
+			for {
+				key, ok := l.next()
+				if !ok || key == pdfKeyword(">>") {
+					return dict
+				}
+				name, isName := key.(pdfName)
+				if !isName {
+					continue
+				}
+				dict[string(name)] = l.value(depth + 1)
+			}
+		}
+	case float64:
+		// 间接引用 "n g R"
+		save := l.pos
+		gen, ok1 := l.next()
+		r, ok2 := l.next()
+		if _, isNum := gen.(float64); ok1 && ok2 && isNum && r == pdfKeyword("R") {
+			return pdfRef(int(v))
+		}
+		l.pos = save
+	}
+	return tok
+}
+
+// skipInlineImage 跳过内联图片 ID 与 EI 之间的二进制数据
+func (l *pdfLexer) skipInlineImage() {
+	if l.pos < len(l.data) && isPDFSpace(l.data[l.pos]) {
+		l.pos++
+	}
+	for l.pos+2 <= len(l.data) {
+		if l.data[l.pos] == 'E' && l.data[l.pos+1] == 'I' &&
+			l.pos > 0 && isPDFSpace(l.data[l.pos-1]) &&
+			(l.pos+2 == len(l.data) || isPDFSpace(l.data[l.pos+2])) {
+			l.pos += 2
+			return
+		}
+		l.pos++
+	}
+	l.pos = len(l.data)
+}
@@ -0,0 +1,46 @@
+// Package knowledge 提供知识库文档的文本提取、分块和分词
+package knowledge
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Tokenize 将文本切分为检索用的词项：英文和数字按单词切分并转为小写，中文等按相邻两字切分，
+// 孤立的单个汉字保留为一个词项。结果保留重复词项，用于计算词频
+func Tokenize(text string) []string {
+	terms := make([]string, 0)
+	var word, han []rune
+	flush := func() {
+		if len(word) > 0 {
+			terms = append(terms, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+		if len(han) == 1 {
+			terms = append(terms, string(han))
+		}
+		for i := 0; i+1 < len(han); i++ {
+			terms = append(terms, string(han[i:i+2]))
+		}
+		han = han[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			if len(word) > 0 {
+				flush()
+			}
+			han = append(han, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if len(han) > 0 {
+				flush()
+			}
+			word = append(word, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return terms
+}
@@ -0,0 +1,97 @@
+package iano_agent
+
+import (
+	"context"
+	"fmt"
+	"iano_agent/tools"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// mapKnowledgeSearcher 按查询返回预设分块的测试检索
+type mapKnowledgeSearcher map[string][]*tools.KnowledgeChunk
+
+func (s mapKnowledgeSearcher) Search(ctx context.Context, kbIDs []string, query string, limit int) ([]*tools.KnowledgeChunk, error) {
+	return s[query], nil
+}
+
+// kbChatModel 依次检索 queries 中的内容后给出答复，并记录每次收到的消息
+type kbChatModel struct {
+	mu      sync.Mutex
+	queries []string
+	inputs  [][]*schema.Message
+}
+
+func (m *kbChatModel) next(input []*schema.Message) *schema.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.inputs = append(m.inputs, input)
+	if round := len(m.inputs); round <= len(m.queries) {
+		return schema.AssistantMessage("", []schema.ToolCall{{
+			ID:       fmt.Sprintf("call_%d", round),
+			Function: schema.FunctionCall{Name: tools.KBSearchToolName, Arguments: fmt.Sprintf(`{"query": %q}`, m.queries[round-1])},
+		}})
+	}
+	return schema.AssistantMessage("端口为 8080 [2]", nil)
+}
+
+func (m *kbChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	return m.next(input), nil
+}
+
+func (m *kbChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	return schema.StreamReaderFromArray([]*schema.Message{m.next(input)}), nil
+}
+
+func (m *kbChatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	return m, nil
+}
+
+func TestAgent_KnowledgeCitations(t *testing.T) {
+	deploy := &tools.KnowledgeChunk{ID: "c1", Title: "部署指南", Content: "使用 docker compose 部署"}
+	port := &tools.KnowledgeChunk{ID: "c2", Title: "FAQ", Content: "默认端口为 8080"}
+	searcher := mapKnowledgeSearcher{"部署": {deploy}, "端口": {port, deploy}}
+
+	m := &kbChatModel{queries: []string{"部署", "端口"}}
+	a, err := NewAgent(m, WithAllowedTools([]string{"file_read"}), WithKnowledgeBases(searcher, "kb1"))
+	if err != nil {
+		t.Fatalf("NewAgent() error = %v", err)
+	}
+
+	if _, err := a.Chat(context.Background(), "怎么部署，端口是多少？"); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	citations := a.GetCitations()
+	if len(citations) != 2 || citations[0].ID != "c1" || citations[1].ID != "c2" {
+		t.Fatalf("GetCitations() = %+v", citations)
+	}
+	// 第二次检索中已引用过的分块沿用之前的编号
+	last := m.inputs[2]
+	output := last[len(last)-1].Content
+	if !strings.Contains(output, "[2] FAQ\n默认端口为 8080") || !strings.Contains(output, "[1] 部署指南\n使用 docker compose 部署") {
+		t.Errorf("kb_search output = %q", output)
+	}
+
+	m.queries = nil
+	if _, err := a.Chat(context.Background(), "谢谢"); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if citations := a.GetCitations(); len(citations) != 0 {
+		t.Errorf("citations after new run = %+v", citations)
+	}
+}
+
+func TestAgent_KnowledgeToolRequiresBases(t *testing.T) {
+	a, err := NewAgent(&sequenceChatModel{replies: []string{"好的"}}, WithAllowedTools([]string{"file_read"}), WithKnowledgeBases(mapKnowledgeSearcher{}))
+	if err != nil {
+		t.Fatalf("NewAgent() error = %v", err)
+	}
+	if _, ok := a.GetToolRegistry().Get(tools.KBSearchToolName); ok {
+		t.Error("kb_search registered without knowledge bases")
+	}
+}
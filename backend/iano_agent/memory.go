@@ -0,0 +1,59 @@
+package iano_agent
+
+import (
+	"context"
+	"iano_agent/tools"
+	"log/slog"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// DefaultMemoryRecallLimit 每次运行自动注入系统提示词的记忆条数
+const DefaultMemoryRecallLimit = 5
+
+// registerMemoryTools 配置了记忆存储时注册 memory_save、memory_search、memory_forget 工具
+func (a *Agent) registerMemoryTools() error {
+	if a.config.MemoryStore == nil {
+		return nil
+	}
+	for name, t := range tools.NewMemoryTools(a.config.MemoryStore, a.config.MemoryScope) {
+		if err := a.toolRegistry.Register(name, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recallMemories 按最后一条用户消息搜索相关的记忆，返回附加到系统提示词的内容，未启用记忆或没有相关记忆时为空
+func (a *Agent) recallMemories(ctx context.Context, messages []*schema.Message) string {
+	store := a.config.MemoryStore
+	limit := a.config.MemoryRecallLimit
+	if store == nil || limit <= 0 {
+		return ""
+	}
+
+	query := ""
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == schema.User {
+			query = messageText(messages[i])
+			break
+		}
+	}
+	if strings.TrimSpace(query) == "" {
+		return ""
+	}
+
+	items, err := store.Search(ctx, a.config.MemoryScope, query, limit)
+	if err != nil {
+		// 召回失败不影响对话
+		slog.Warn("召回记忆失败", "agentID", a.config.MemoryScope.AgentID, "error", err)
+		return ""
+	}
+	if len(items) == 0 {
+		return ""
+	}
+
+	return "\n\n以下是你在以往会话中记住的信息，可能与当前对话相关，信息过时或有误时使用 memory_forget 删除：\n" +
+		tools.FormatMemories(items)
+}
@@ -0,0 +1,65 @@
+package iano_agent
+
+import (
+	"context"
+	"iano_agent/tools"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// fixedMemoryStore 返回固定记忆并记录搜索条件的测试存储
+type fixedMemoryStore struct {
+	items  []*tools.MemoryItem
+	scope  tools.MemoryScope
+	query  string
+	limit  int
+	called int
+}
+
+func (s *fixedMemoryStore) Save(ctx context.Context, scope tools.MemoryScope, content string, tags []string) (*tools.MemoryItem, error) {
+	return &tools.MemoryItem{ID: "new", Content: content}, nil
+}
+
+func (s *fixedMemoryStore) Search(ctx context.Context, scope tools.MemoryScope, query string, limit int) ([]*tools.MemoryItem, error) {
+	s.called++
+	s.scope, s.query, s.limit = scope, query, limit
+	return s.items, nil
+}
+
+func (s *fixedMemoryStore) Forget(ctx context.Context, scope tools.MemoryScope, id string) error {
+	return nil
+}
+
+func TestAgent_LoopRecallsMemories(t *testing.T) {
+	store := &fixedMemoryStore{items: []*tools.MemoryItem{
+		{ID: "m1", Content: "用户喜欢的编程语言是 Go", CreatedAt: time.Date(2026, 1, 2, 0, 0, 0, 0, time.Local)},
+	}}
+	scope := tools.MemoryScope{AgentID: "agent-1", Workspace: "/work"}
+
+	m := &sequenceChatModel{replies: []string{"好的"}}
+	a, err := NewAgent(m, WithAllowedTools([]string{"file_read"}), WithMemory(store, scope), WithMemoryRecallLimit(3))
+	if err != nil {
+		t.Fatalf("NewAgent() error = %v", err)
+	}
+	for _, name := range []string{tools.MemorySaveToolName, tools.MemorySearchToolName, tools.MemoryForgetToolName} {
+		if _, ok := a.GetToolRegistry().Get(name); !ok {
+			t.Errorf("tool %s not registered", name)
+		}
+	}
+
+	history := []*schema.Message{schema.UserMessage("你好"), schema.AssistantMessage("你好！", nil), schema.UserMessage("用什么语言写后端？")}
+	if _, err := a.Loop(context.Background(), history); err != nil {
+		t.Fatalf("Loop() error = %v", err)
+	}
+
+	if store.called != 1 || store.scope != scope || store.query != "用什么语言写后端？" || store.limit != 3 {
+		t.Errorf("search = %d calls, scope %+v, query %q, limit %d", store.called, store.scope, store.query, store.limit)
+	}
+	system := m.inputs[0][0].Content
+	if !strings.Contains(system, "以往会话中记住的信息") || !strings.Contains(system, "- [m1] 用户喜欢的编程语言是 Go（2026-01-02）") {
+		t.Errorf("system prompt = %q", system)
+	}
+}
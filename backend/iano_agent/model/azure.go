@@ -0,0 +1,81 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino-ext/components/model/openai"
+	"github.com/cloudwego/eino/components/model"
+)
+
+const azureDefaultAPIVersion = "2024-10-21"
+
+// init 注册 Azure OpenAI 工厂
+func init() {
+	GlobalRegistry.Register(ProviderAzure, &AzureFactory{})
+}
+
+// AzureFactory Azure OpenAI 模型工厂
+//
+// BaseURL 为资源地址，例如 https://{resource}.openai.azure.com，请求使用 api-key 请求头鉴权。
+// Extra 支持的参数：
+//   - api_version: API 版本，默认 2024-10-21
+//   - deployment: 部署名称，默认与 Model 相同
+//   - deployments: 模型名称到部署名称的映射，例如 {"gpt-4o": "prod-gpt4o"}
+type AzureFactory struct{}
+
+// Create 创建 Azure OpenAI 模型实例
+func (f *AzureFactory) Create(config *Config) (model.ToolCallingChatModel, error) {
+	ctx := context.Background()
+
+	apiVersion := extraString(config.Extra, "api_version")
+	if apiVersion == "" {
+		apiVersion = azureDefaultAPIVersion
+	}
+
+	chatModel, err := openai.NewChatModel(ctx, &openai.ChatModelConfig{
+		ByAzure:              true,
+		BaseURL:              strings.TrimRight(config.BaseURL, "/"),
+		APIKey:               config.APIKey,
+		APIVersion:           apiVersion,
+		Model:                config.Model,
+		Temperature:          &config.Temperature,
+		MaxTokens:            &config.MaxTokens,
+		AzureModelMapperFunc: azureDeploymentMapper(config),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("创建 Azure OpenAI 模型失败: %w", err)
+	}
+
+	return chatModel, nil
+}
+
+// Support 是否支持该提供商
+func (f *AzureFactory) Support(providerType ProviderType) bool {
+	return providerType == ProviderAzure
+}
+
+// azureDeploymentMapper 根据 Extra 配置将模型名称映射为部署名称
+func azureDeploymentMapper(config *Config) func(string) string {
+	deployment := extraString(config.Extra, "deployment")
+	deployments := make(map[string]string)
+	if m, ok := config.Extra["deployments"].(map[string]interface{}); ok {
+		for k, v := range m {
+			if s, ok := v.(string); ok && s != "" {
+				deployments[k] = s
+			}
+		}
+	}
+
+	return func(modelName string) string {
+		if d, ok := deployments[modelName]; ok {
+			return d
+		}
+		if deployment != "" && modelName == config.Model {
+			return deployment
+		}
+		// 与 Azure SDK 的默认行为一致，去掉部署名中不允许的字符
+		return strings.NewReplacer(".", "", ":", "").Replace(modelName)
+	}
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestAzureFactory_Create(t *testing.T) {
+	var gotPath, gotVersion, gotKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotVersion = r.URL.Query().Get("api-version")
+		gotKey = r.Header.Get("api-key")
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"1","object":"chat.completion","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"你好"},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":2,"total_tokens":5}}`))
+	}))
+	defer server.Close()
+
+	cm, err := CreateModel(&Config{
+		Type:    ProviderAzure,
+		BaseURL: server.URL,
+		APIKey:  "azure-key",
+		Model:   "gpt-4o",
+		Extra:   map[string]interface{}{"deployment": "prod-gpt4o", "api_version": "2024-06-01"},
+	})
+	if err != nil {
+		t.Fatalf("CreateModel() error = %v", err)
+	}
+
+	msg, err := cm.Generate(context.Background(), []*schema.Message{schema.UserMessage("hi")})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	if gotPath != "/openai/deployments/prod-gpt4o/chat/completions" {
+		t.Errorf("path = %q", gotPath)
+	}
+	if gotVersion != "2024-06-01" {
+		t.Errorf("api-version = %q, want 2024-06-01", gotVersion)
+	}
+	if gotKey != "azure-key" {
+		t.Errorf("api-key = %q, want azure-key", gotKey)
+	}
+	if msg.Content != "你好" {
+		t.Errorf("Content = %q", msg.Content)
+	}
+}
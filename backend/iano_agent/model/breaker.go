@@ -0,0 +1,142 @@
+package model
+
+import (
+	"sync"
+	"time"
+)
+
+// BreakerState 熔断器状态
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"    // 正常放行
+	BreakerOpen     BreakerState = "open"      // 熔断中，直接跳过
+	BreakerHalfOpen BreakerState = "half_open" // 冷却结束，放行一次试探请求
+)
+
+// CircuitBreaker 单个提供商的熔断器
+//
+// 连续失败达到阈值后进入熔断状态，冷却时间过后放行一次试探请求，
+// 试探成功则恢复，失败则重新熔断。
+type CircuitBreaker struct {
+	mu          sync.Mutex
+	state       BreakerState
+	failures    int
+	openedAt    time.Time
+	probing     bool
+	threshold   int
+	openTimeout time.Duration
+}
+
+// NewCircuitBreaker 创建熔断器
+func NewCircuitBreaker(threshold int, openTimeout time.Duration) *CircuitBreaker {
+	if threshold <= 0 {
+		threshold = 1
+	}
+	return &CircuitBreaker{
+		state:       BreakerClosed,
+		threshold:   threshold,
+		openTimeout: openTimeout,
+	}
+}
+
+// Allow 是否允许发起请求
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.openTimeout {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return true
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// Success 记录一次成功请求
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = BreakerClosed
+	b.failures = 0
+	b.probing = false
+}
+
+// Failure 记录一次失败请求
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// release 请求被取消时释放试探名额，不影响熔断状态
+func (b *CircuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// State 当前状态
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen && time.Since(b.openedAt) >= b.openTimeout {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+// BreakerRegistry 熔断器注册表，按提供商共享熔断状态
+type BreakerRegistry struct {
+	mu       sync.Mutex
+	breakers map[string]*CircuitBreaker
+}
+
+// NewBreakerRegistry 创建熔断器注册表
+func NewBreakerRegistry() *BreakerRegistry {
+	return &BreakerRegistry{
+		breakers: make(map[string]*CircuitBreaker),
+	}
+}
+
+// Get 获取提供商的熔断器，不存在时按参数创建
+func (r *BreakerRegistry) Get(name string, threshold int, openTimeout time.Duration) *CircuitBreaker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := r.breakers[name]
+	if !ok {
+		b = NewCircuitBreaker(threshold, openTimeout)
+		r.breakers[name] = b
+	}
+	return b
+}
+
+// Reset 重置提供商的熔断状态，提供商配置变更后调用
+func (r *BreakerRegistry) Reset(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.breakers, name)
+}
+
+// GlobalBreakers 全局熔断器注册表
+var GlobalBreakers = NewBreakerRegistry()
@@ -0,0 +1,556 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+const (
+	claudeDefaultBaseURL    = "https://api.anthropic.com"
+	claudeDefaultVersion    = "2023-06-01"
+	claudeDefaultMaxTokens  = 4096
+	claudeMinThinkingBudget = 1024
+
+	// claudeSignatureKey 思考块签名在消息 Extra 中的键，回传历史消息时需要携带
+	claudeSignatureKey = "claude_thinking_signature"
+)
+
+// init 注册 Claude 工厂
+func init() {
+	GlobalRegistry.Register(ProviderClaude, &ClaudeFactory{})
+}
+
+// ClaudeFactory Anthropic Claude 模型工厂
+//
+// Extra 支持的参数：
+//   - anthropic_version: API 版本，默认 2023-06-01
+//   - thinking_budget: 扩展思考的 Token 预算，大于 0 时开启扩展思考
+//   - timeout: 请求超时时间（秒）
+type ClaudeFactory struct{}
+
+// Create 创建 Claude 模型实例
+func (f *ClaudeFactory) Create(config *Config) (model.ToolCallingChatModel, error) {
+	return NewClaudeChatModel(config)
+}
+
+// Support 是否支持该提供商
+func (f *ClaudeFactory) Support(providerType ProviderType) bool {
+	return providerType == ProviderClaude
+}
+
+// ClaudeChatModel 基于 Messages API 的 Claude 模型
+type ClaudeChatModel struct {
+	client         *http.Client
+	baseURL        string
+	apiKey         string
+	model          string
+	version        string
+	temperature    float32
+	maxTokens      int
+	thinkingBudget int
+	tools          []claudeTool
+}
+
+// NewClaudeChatModel 创建 Claude 模型
+func NewClaudeChatModel(config *Config) (*ClaudeChatModel, error) {
+	if config.Model == "" {
+		return nil, fmt.Errorf("创建 Claude 模型失败: 模型名称不能为空")
+	}
+
+	baseURL := strings.TrimRight(config.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = claudeDefaultBaseURL
+	}
+	baseURL = strings.TrimSuffix(baseURL, "/v1")
+
+	version := extraString(config.Extra, "anthropic_version")
+	if version == "" {
+		version = claudeDefaultVersion
+	}
+
+	maxTokens := config.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = claudeDefaultMaxTokens
+	}
+
+	thinkingBudget := extraInt(config.Extra, "thinking_budget")
+	if thinkingBudget > 0 && thinkingBudget < claudeMinThinkingBudget {
+		thinkingBudget = claudeMinThinkingBudget
+	}
+	if thinkingBudget > 0 && maxTokens <= thinkingBudget {
+		maxTokens = thinkingBudget + claudeDefaultMaxTokens
+	}
+
+	return &ClaudeChatModel{
+		client:         newHTTPClient(config),
+		baseURL:        baseURL,
+		apiKey:         config.APIKey,
+		model:          config.Model,
+		version:        version,
+		temperature:    config.Temperature,
+		maxTokens:      maxTokens,
+		thinkingBudget: thinkingBudget,
+	}, nil
+}
+
+type claudeTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"input_schema"`
+}
+
+type claudeImageSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+type claudeContentBlock struct {
+	Type      string             `json:"type"`
+	Text      string             `json:"text,omitempty"`
+	Thinking  string             `json:"thinking,omitempty"`
+	Signature string             `json:"signature,omitempty"`
+	Source    *claudeImageSource `json:"source,omitempty"`
+	ID        string             `json:"id,omitempty"`
+	Name      string             `json:"name,omitempty"`
+	Input     json.RawMessage    `json:"input,omitempty"`
+	ToolUseID string             `json:"tool_use_id,omitempty"`
+	Content   string             `json:"content,omitempty"`
+	IsError   bool               `json:"is_error,omitempty"`
+}
+
+type claudeMessage struct {
+	Role    string               `json:"role"`
+	Content []claudeContentBlock `json:"content"`
+}
+
+type claudeThinking struct {
+	Type         string `json:"type"`
+	BudgetTokens int    `json:"budget_tokens"`
+}
+
+type claudeRequest struct {
+	Model         string          `json:"model"`
+	System        string          `json:"system,omitempty"`
+	Messages      []claudeMessage `json:"messages"`
+	MaxTokens     int             `json:"max_tokens"`
+	Temperature   *float32        `json:"temperature,omitempty"`
+	TopP          *float32        `json:"top_p,omitempty"`
+	StopSequences []string        `json:"stop_sequences,omitempty"`
+	Tools         []claudeTool    `json:"tools,omitempty"`
+	Thinking      *claudeThinking `json:"thinking,omitempty"`
+	Stream        bool            `json:"stream"`
+}
+
+type claudeUsage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+}
+
+type claudeStreamEvent struct {
+	Type    string `json:"type"`
+	Index   int    `json:"index"`
+	Message *struct {
+		ID    string      `json:"id"`
+		Usage claudeUsage `json:"usage"`
+	} `json:"message"`
+	ContentBlock *struct {
+		Type string `json:"type"`
+		ID   string `json:"id"`
+		Name string `json:"name"`
+		Text string `json:"text"`
+	} `json:"content_block"`
+	Delta *struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		Thinking    string `json:"thinking"`
+		Signature   string `json:"signature"`
+		PartialJSON string `json:"partial_json"`
+		StopReason  string `json:"stop_reason"`
+	} `json:"delta"`
+	Usage *claudeUsage `json:"usage"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Generate 生成完整回复
+func (m *ClaudeChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	sr, err := m.Stream(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return concatStream(sr)
+}
+
+// Stream 流式生成回复
+func (m *ClaudeChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	req, err := m.buildRequest(input, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := postJSON(ctx, m.client, ProviderClaude, m.baseURL+"/v1/messages", map[string]string{
+		"x-api-key":         m.apiKey,
+		"anthropic-version": m.version,
+		"Accept":            "text/event-stream",
+	}, req)
+	if err != nil {
+		return nil, err
+	}
+
+	sr, sw := schema.Pipe[*schema.Message](16)
+	go func() {
+		defer resp.Body.Close()
+		defer sw.Close()
+
+		if err := m.readStream(resp.Body, sw); err != nil {
+			sw.Send(nil, err)
+		}
+	}()
+
+	return sr, nil
+}
+
+// WithTools 绑定工具，返回新的模型实例
+func (m *ClaudeChatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	converted, err := toClaudeTools(tools)
+	if err != nil {
+		return nil, err
+	}
+
+	cm := *m
+	cm.tools = converted
+	return &cm, nil
+}
+
+// GetType 组件类型
+func (m *ClaudeChatModel) GetType() string {
+	return "Claude"
+}
+
+func (m *ClaudeChatModel) buildRequest(input []*schema.Message, opts ...model.Option) (*claudeRequest, error) {
+	temperature := m.temperature
+	maxTokens := m.maxTokens
+	modelName := m.model
+	options := model.GetCommonOptions(&model.Options{
+		Temperature: &temperature,
+		MaxTokens:   &maxTokens,
+		Model:       &modelName,
+	}, opts...)
+
+	req := &claudeRequest{
+		Model:         *options.Model,
+		MaxTokens:     *options.MaxTokens,
+		TopP:          options.TopP,
+		StopSequences: options.Stop,
+		Tools:         m.tools,
+		Stream:        true,
+	}
+
+	if len(options.Tools) > 0 {
+		tools, err := toClaudeTools(options.Tools)
+		if err != nil {
+			return nil, err
+		}
+		req.Tools = tools
+	}
+
+	if m.thinkingBudget > 0 {
+		// 扩展思考模式下不允许自定义 temperature
+		req.Thinking = &claudeThinking{Type: "enabled", BudgetTokens: m.thinkingBudget}
+		if req.MaxTokens <= m.thinkingBudget {
+			req.MaxTokens = m.thinkingBudget + claudeDefaultMaxTokens
+		}
+	} else {
+		// temperature 为 0 时同样发送，不能省略后使用服务端默认值 1
+		req.Temperature = options.Temperature
+	}
+
+	system, messages := toClaudeMessages(input)
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("Claude 请求至少需要一条用户消息")
+	}
+	req.System = system
+	req.Messages = messages
+
+	return req, nil
+}
+
+// readStream 解析 Messages API 的 SSE 事件并转换为消息分片
+func (m *ClaudeChatModel) readStream(body io.Reader, sw *schema.StreamWriter[*schema.Message]) error {
+	type pendingTool struct {
+		id   string
+		name string
+		args strings.Builder
+	}
+
+	tools := make(map[int]*pendingTool)
+	toolIndex := 0
+	usage := &schema.TokenUsage{}
+	stopReason := ""
+
+	err := readSSE(body, func(event string, data []byte) error {
+		var ev claudeStreamEvent
+		if err := json.Unmarshal(data, &ev); err != nil {
+			return fmt.Errorf("解析 Claude 流式事件失败: %w", err)
+		}
+
+		switch ev.Type {
+		case "message_start":
+			if ev.Message != nil {
+				usage.PromptTokens = ev.Message.Usage.InputTokens + ev.Message.Usage.CacheReadInputTokens + ev.Message.Usage.CacheCreationInputTokens
+				usage.PromptTokenDetails.CachedTokens = ev.Message.Usage.CacheReadInputTokens
+				usage.CompletionTokens = ev.Message.Usage.OutputTokens
+			}
+		case "content_block_start":
+			if ev.ContentBlock == nil {
+				return nil
+			}
+			switch ev.ContentBlock.Type {
+			case "tool_use":
+				tools[ev.Index] = &pendingTool{id: ev.ContentBlock.ID, name: ev.ContentBlock.Name}
+			case "text":
+				if ev.ContentBlock.Text != "" {
+					sw.Send(&schema.Message{Role: schema.Assistant, Content: ev.ContentBlock.Text}, nil)
+				}
+			}
+		case "content_block_delta":
+			if ev.Delta == nil {
+				return nil
+			}
+			switch ev.Delta.Type {
+			case "text_delta":
+				if sw.Send(&schema.Message{Role: schema.Assistant, Content: ev.Delta.Text}, nil) {
+					return io.EOF
+				}
+			case "thinking_delta":
+				if sw.Send(&schema.Message{Role: schema.Assistant, ReasoningContent: ev.Delta.Thinking}, nil) {
+					return io.EOF
+				}
+			case "signature_delta":
+				if sw.Send(&schema.Message{Role: schema.Assistant, Extra: map[string]any{claudeSignatureKey: ev.Delta.Signature}}, nil) {
+					return io.EOF
+				}
+			case "input_json_delta":
+				if t, ok := tools[ev.Index]; ok {
+					t.args.WriteString(ev.Delta.PartialJSON)
+				}
+			}
+		case "content_block_stop":
+			t, ok := tools[ev.Index]
+			if !ok {
+				return nil
+			}
+			delete(tools, ev.Index)
+
+			args := t.args.String()
+			if args == "" {
+				args = "{}"
+			}
+			idx := toolIndex
+			toolIndex++
+			if sw.Send(&schema.Message{
+				Role: schema.Assistant,
+				ToolCalls: []schema.ToolCall{{
+					Index:    &idx,
+					ID:       t.id,
+					Type:     "function",
+					Function: schema.FunctionCall{Name: t.name, Arguments: args},
+				}},
+			}, nil) {
+				return io.EOF
+			}
+		case "message_delta":
+			if ev.Delta != nil && ev.Delta.StopReason != "" {
+				stopReason = ev.Delta.StopReason
+			}
+			if ev.Usage != nil {
+				usage.CompletionTokens = ev.Usage.OutputTokens
+			}
+		case "message_stop":
+			return io.EOF
+		case "error":
+			if ev.Error != nil {
+				return &APIError{Provider: ProviderClaude, StatusCode: claudeErrorStatus(ev.Error.Type), Message: ev.Error.Message}
+			}
+			return fmt.Errorf("Claude 流式响应错误: %s", string(data))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	sw.Send(&schema.Message{
+		Role: schema.Assistant,
+		ResponseMeta: &schema.ResponseMeta{
+			FinishReason: claudeFinishReason(stopReason),
+			Usage:        usage,
+		},
+	}, nil)
+	return nil
+}
+
+// toClaudeTools 转换工具定义
+func toClaudeTools(tools []*schema.ToolInfo) ([]claudeTool, error) {
+	result := make([]claudeTool, 0, len(tools))
+	for _, t := range tools {
+		params, err := toolSchema(t)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, claudeTool{
+			Name:        t.Name,
+			Description: t.Desc,
+			InputSchema: params,
+		})
+	}
+	return result, nil
+}
+
+// toClaudeMessages 将消息转换为 Messages API 格式，系统消息合并到 system 字段，相邻同角色消息合并
+func toClaudeMessages(input []*schema.Message) (string, []claudeMessage) {
+	var system []string
+	messages := make([]claudeMessage, 0, len(input))
+
+	appendBlocks := func(role string, blocks []claudeContentBlock) {
+		if len(blocks) == 0 {
+			return
+		}
+		if n := len(messages); n > 0 && messages[n-1].Role == role {
+			messages[n-1].Content = append(messages[n-1].Content, blocks...)
+			return
+		}
+		messages = append(messages, claudeMessage{Role: role, Content: blocks})
+	}
+
+	for _, msg := range input {
+		switch msg.Role {
+		case schema.System:
+			if msg.Content != "" {
+				system = append(system, msg.Content)
+			}
+		case schema.User:
+			appendBlocks("user", claudeUserBlocks(msg))
+		case schema.Assistant:
+			blocks := make([]claudeContentBlock, 0, 2+len(msg.ToolCalls))
+			// 扩展思考模式下，带工具调用的历史回复需要原样回传签名过的思考块
+			if signature, ok := msg.Extra[claudeSignatureKey].(string); ok && signature != "" && msg.ReasoningContent != "" {
+				blocks = append(blocks, claudeContentBlock{Type: "thinking", Thinking: msg.ReasoningContent, Signature: signature})
+			}
+			if msg.Content != "" {
+				blocks = append(blocks, claudeContentBlock{Type: "text", Text: msg.Content})
+			}
+			for _, tc := range msg.ToolCalls {
+				args := tc.Function.Arguments
+				if !json.Valid([]byte(args)) {
+					args = "{}"
+				}
+				blocks = append(blocks, claudeContentBlock{
+					Type:  "tool_use",
+					ID:    tc.ID,
+					Name:  tc.Function.Name,
+					Input: json.RawMessage(args),
+				})
+			}
+			appendBlocks("assistant", blocks)
+		case schema.Tool:
+			content := msg.Content
+			if content == "" {
+				content = "(空)"
+			}
+			if msg.ToolCallID == "" {
+				// 没有对应的 tool_use 时按普通文本提交
+				appendBlocks("user", []claudeContentBlock{{Type: "text", Text: content}})
+				continue
+			}
+			appendBlocks("user", []claudeContentBlock{{
+				Type:      "tool_result",
+				ToolUseID: msg.ToolCallID,
+				Content:   content,
+			}})
+		}
+	}
+
+	return strings.Join(system, "\n\n"), messages
+}
+
+// claudeUserBlocks 转换用户消息，支持图片输入
+func claudeUserBlocks(msg *schema.Message) []claudeContentBlock {
+	blocks := make([]claudeContentBlock, 0, 1+len(msg.UserInputMultiContent))
+	if msg.Content != "" {
+		blocks = append(blocks, claudeContentBlock{Type: "text", Text: msg.Content})
+	}
+
+	for _, part := range msg.UserInputMultiContent {
+		switch part.Type {
+		case schema.ChatMessagePartTypeText:
+			if part.Text != "" {
+				blocks = append(blocks, claudeContentBlock{Type: "text", Text: part.Text})
+			}
+		case schema.ChatMessagePartTypeImageURL:
+			if part.Image == nil {
+				continue
+			}
+			if part.Image.Base64Data != nil {
+				blocks = append(blocks, claudeContentBlock{Type: "image", Source: &claudeImageSource{
+					Type:      "base64",
+					MediaType: part.Image.MIMEType,
+					Data:      *part.Image.Base64Data,
+				}})
+			} else if part.Image.URL != nil {
+				blocks = append(blocks, claudeContentBlock{Type: "image", Source: &claudeImageSource{
+					Type: "url",
+					URL:  *part.Image.URL,
+				}})
+			}
+		}
+	}
+
+	return blocks
+}
+
+// claudeFinishReason 将 stop_reason 转换为通用的结束原因
+func claudeFinishReason(reason string) string {
+	switch reason {
+	case "end_turn", "stop_sequence":
+		return "stop"
+	case "max_tokens":
+		return "length"
+	case "tool_use":
+		return "tool_calls"
+	default:
+		return reason
+	}
+}
+
+// claudeErrorStatus 将流内错误类型映射为 HTTP 状态码，便于上层判断是否可重试
+func claudeErrorStatus(errType string) int {
+	switch errType {
+	case "overloaded_error":
+		return 529
+	case "rate_limit_error":
+		return http.StatusTooManyRequests
+	case "api_error":
+		return http.StatusInternalServerError
+	case "invalid_request_error":
+		return http.StatusBadRequest
+	case "authentication_error":
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,250 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// newClaudeReplayServer 创建回放 SSE 录制数据的 Messages API 模拟服务
+func newClaudeReplayServer(t *testing.T, file string, onRequest func(r *http.Request, body *claudeRequest)) *httptest.Server {
+	t.Helper()
+
+	frames, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("读取录制数据失败: %v", err)
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			http.NotFound(w, r)
+			return
+		}
+
+		var body claudeRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if onRequest != nil {
+			onRequest(r, &body)
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.Write(frames)
+	}))
+}
+
+func TestClaudeChatModel_Stream(t *testing.T) {
+	var gotReq *claudeRequest
+	var gotHeader http.Header
+	server := newClaudeReplayServer(t, "testdata/claude_tool_use.sse", func(r *http.Request, body *claudeRequest) {
+		gotReq = body
+		gotHeader = r.Header.Clone()
+	})
+	defer server.Close()
+
+	cm, err := GlobalRegistry.factories[ProviderClaude].Create(&Config{
+		Type:    ProviderClaude,
+		BaseURL: server.URL,
+		APIKey:  "test-key",
+		Model:   "claude-sonnet-4-5",
+		Extra:   map[string]interface{}{"thinking_budget": float64(2048)},
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	cm, err = cm.WithTools([]*schema.ToolInfo{{
+		Name: "file_list",
+		Desc: "列出目录",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"path": {Type: schema.String, Desc: "目录", Required: true},
+		}),
+	}})
+	if err != nil {
+		t.Fatalf("WithTools() error = %v", err)
+	}
+
+	input := []*schema.Message{
+		schema.SystemMessage("你是一个助手"),
+		schema.UserMessage("看看当前目录"),
+		schema.AssistantMessage("", []schema.ToolCall{{ID: "toolu_00", Function: schema.FunctionCall{Name: "file_list", Arguments: `{"path":"/"}`}}}),
+		schema.ToolMessage("权限不足", "toolu_00"),
+		schema.UserMessage("那就看当前目录"),
+	}
+
+	msg, err := cm.Generate(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	t.Run("请求格式", func(t *testing.T) {
+		if gotHeader.Get("x-api-key") != "test-key" {
+			t.Errorf("x-api-key = %q, want test-key", gotHeader.Get("x-api-key"))
+		}
+		if gotHeader.Get("anthropic-version") != claudeDefaultVersion {
+			t.Errorf("anthropic-version = %q", gotHeader.Get("anthropic-version"))
+		}
+		if gotReq.System != "你是一个助手" {
+			t.Errorf("System = %q", gotReq.System)
+		}
+		if !gotReq.Stream {
+			t.Error("Stream should be true")
+		}
+		if gotReq.Thinking == nil || gotReq.Thinking.BudgetTokens != 2048 {
+			t.Errorf("Thinking = %+v, want budget 2048", gotReq.Thinking)
+		}
+		if gotReq.MaxTokens <= 2048 {
+			t.Errorf("MaxTokens = %d, should exceed thinking budget", gotReq.MaxTokens)
+		}
+		if len(gotReq.Tools) != 1 || gotReq.Tools[0].Name != "file_list" {
+			t.Errorf("Tools = %+v", gotReq.Tools)
+		}
+		// 工具结果与随后的用户消息合并到同一条 user 消息中
+		if len(gotReq.Messages) != 3 {
+			t.Fatalf("len(Messages) = %d, want 3", len(gotReq.Messages))
+		}
+		last := gotReq.Messages[2]
+		if last.Role != "user" || len(last.Content) != 2 || last.Content[0].Type != "tool_result" || last.Content[0].ToolUseID != "toolu_00" {
+			t.Errorf("Messages[2] = %+v", last)
+		}
+		if gotReq.Messages[1].Content[0].Type != "tool_use" {
+			t.Errorf("Messages[1] = %+v", gotReq.Messages[1])
+		}
+	})
+
+	t.Run("响应解析", func(t *testing.T) {
+		if msg.Content != "我来看一下目录内容。" {
+			t.Errorf("Content = %q", msg.Content)
+		}
+		if msg.ReasoningContent != "需要先查看目录" {
+			t.Errorf("ReasoningContent = %q", msg.ReasoningContent)
+		}
+		if msg.Extra[claudeSignatureKey] != "EqQBCgIYAhIM" {
+			t.Errorf("Extra[%s] = %v", claudeSignatureKey, msg.Extra[claudeSignatureKey])
+		}
+		if len(msg.ToolCalls) != 1 {
+			t.Fatalf("len(ToolCalls) = %d, want 1", len(msg.ToolCalls))
+		}
+		tc := msg.ToolCalls[0]
+		if tc.ID != "toolu_01" || tc.Function.Name != "file_list" || tc.Function.Arguments != `{"path": "."}` {
+			t.Errorf("ToolCall = %+v", tc)
+		}
+		if msg.ResponseMeta == nil || msg.ResponseMeta.FinishReason != "tool_calls" {
+			t.Fatalf("ResponseMeta = %+v", msg.ResponseMeta)
+		}
+		usage := msg.ResponseMeta.Usage
+		if usage.PromptTokens != 140 || usage.CompletionTokens != 45 || usage.TotalTokens != 185 || usage.PromptTokenDetails.CachedTokens != 20 {
+			t.Errorf("Usage = %+v", usage)
+		}
+	})
+}
+
+func TestClaudeChatModel_StreamError(t *testing.T) {
+	server := newClaudeReplayServer(t, "testdata/claude_overloaded.sse", nil)
+	defer server.Close()
+
+	cm, err := NewClaudeChatModel(&Config{Type: ProviderClaude, BaseURL: server.URL, APIKey: "k", Model: "claude"})
+	if err != nil {
+		t.Fatalf("NewClaudeChatModel() error = %v", err)
+	}
+
+	_, err = cm.Generate(context.Background(), []*schema.Message{schema.UserMessage("hi")})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Generate() error = %v, want *APIError", err)
+	}
+	if apiErr.StatusCode != 529 {
+		t.Errorf("StatusCode = %d, want 529", apiErr.StatusCode)
+	}
+}
+
+func TestClaudeChatModel_HTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`))
+	}))
+	defer server.Close()
+
+	cm, _ := NewClaudeChatModel(&Config{Type: ProviderClaude, BaseURL: server.URL, APIKey: "k", Model: "claude"})
+	_, err := cm.Stream(context.Background(), []*schema.Message{schema.UserMessage("hi")})
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests || apiErr.Message != "slow down" {
+		t.Errorf("Stream() error = %v", err)
+	}
+}
+
+func TestClaudeChatModel_Temperature(t *testing.T) {
+	tests := []struct {
+		name        string
+		temperature float32
+		extra       map[string]interface{}
+		want        *float32
+	}{
+		{name: "配置为 0 时同样发送", temperature: 0, want: new(float32)},
+		{name: "配置的温度", temperature: 0.5, want: func() *float32 { v := float32(0.5); return &v }()},
+		{name: "扩展思考不发送", temperature: 0.5, extra: map[string]interface{}{"thinking_budget": float64(2048)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cm, err := NewClaudeChatModel(&Config{Type: ProviderClaude, Model: "claude", Temperature: tt.temperature, Extra: tt.extra})
+			if err != nil {
+				t.Fatalf("NewClaudeChatModel() error = %v", err)
+			}
+			req, err := cm.buildRequest([]*schema.Message{schema.UserMessage("hi")})
+			if err != nil {
+				t.Fatalf("buildRequest() error = %v", err)
+			}
+			if (req.Temperature == nil) != (tt.want == nil) || (req.Temperature != nil && *req.Temperature != *tt.want) {
+				t.Errorf("Temperature = %v, want %v", req.Temperature, tt.want)
+			}
+
+			// 请求体中 temperature 为 0 时不能被 omitempty 省略
+			body, _ := json.Marshal(req)
+			var fields map[string]interface{}
+			json.Unmarshal(body, &fields)
+			if _, ok := fields["temperature"]; ok != (tt.want != nil) {
+				t.Errorf("request body = %s", body)
+			}
+		})
+	}
+}
+
+func TestCreateModel_DefaultBaseURL(t *testing.T) {
+	tests := []struct {
+		providerType ProviderType
+		apiKey       string
+		wantErr      bool
+	}{
+		{providerType: ProviderClaude, apiKey: "test-key"},
+		{providerType: ProviderGemini, apiKey: "test-key"},
+		{providerType: ProviderOllama},
+		{providerType: ProviderOpenAI, apiKey: "test-key", wantErr: true},
+		{providerType: ProviderAzure, apiKey: "test-key", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.providerType), func(t *testing.T) {
+			_, err := CreateModel(&Config{Type: tt.providerType, APIKey: tt.apiKey, Model: "m"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CreateModel() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	cm, err := CreateModel(&Config{Type: ProviderClaude, APIKey: "test-key", Model: "m"})
+	if err != nil {
+		t.Fatalf("CreateModel() error = %v", err)
+	}
+	if got := cm.(*ClaudeChatModel).baseURL; got != claudeDefaultBaseURL {
+		t.Errorf("baseURL = %q, want %q", got, claudeDefaultBaseURL)
+	}
+}
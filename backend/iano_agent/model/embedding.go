@@ -0,0 +1,273 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"iano_agent/knowledge"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/cloudwego/eino/components/embedding"
+)
+
+// defaultEmbeddingBatchSize 每次请求最多向量化的文本条数
+const defaultEmbeddingBatchSize = 64
+
+// init 注册向量模型工厂
+func init() {
+	GlobalEmbeddingRegistry.Register(ProviderOpenAI, &OpenAIEmbeddingFactory{})
+	GlobalEmbeddingRegistry.Register(ProviderOllama, &OllamaEmbeddingFactory{})
+	GlobalEmbeddingRegistry.Register(ProviderScript, &HashEmbeddingFactory{})
+}
+
+// EmbeddingFactory 向量模型工厂接口
+type EmbeddingFactory interface {
+	// CreateEmbedder 创建向量模型实例
+	CreateEmbedder(config *Config) (embedding.Embedder, error)
+}
+
+// EmbeddingRegistry 向量模型工厂注册表
+type EmbeddingRegistry struct {
+	factories map[ProviderType]EmbeddingFactory
+}
+
+// NewEmbeddingRegistry 创建向量模型工厂注册表
+func NewEmbeddingRegistry() *EmbeddingRegistry {
+	return &EmbeddingRegistry{
+		factories: make(map[ProviderType]EmbeddingFactory),
+	}
+}
+
+// Register 注册工厂
+func (r *EmbeddingRegistry) Register(providerType ProviderType, factory EmbeddingFactory) {
+	r.factories[providerType] = factory
+}
+
+// Get 获取工厂
+func (r *EmbeddingRegistry) Get(providerType ProviderType) (EmbeddingFactory, bool) {
+	f, ok := r.factories[providerType]
+	return f, ok
+}
+
+// GlobalEmbeddingRegistry 全局向量模型工厂注册表
+var GlobalEmbeddingRegistry = NewEmbeddingRegistry()
+
+// CreateEmbedder 创建向量模型实例，config.Model 为向量模型名称
+func CreateEmbedder(config *Config) (embedding.Embedder, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("配置验证失败: %w", err)
+	}
+
+	factory, ok := GlobalEmbeddingRegistry.Get(config.Type)
+	if !ok {
+		return nil, fmt.Errorf("提供商 %s 不支持向量模型", config.Type)
+	}
+
+	return factory.CreateEmbedder(config)
+}
+
+// CosineSimilarity 两个向量的余弦相似度，维度不同或为零向量时返回 0
+func CosineSimilarity(a, b []float64) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// embedInBatches 按批次调用 embed，保持结果与输入顺序一致
+func embedInBatches(texts []string, batchSize int, embed func(batch []string) ([][]float64, error)) ([][]float64, error) {
+	if batchSize <= 0 {
+		batchSize = defaultEmbeddingBatchSize
+	}
+
+	result := make([][]float64, 0, len(texts))
+	for start := 0; start < len(texts); start += batchSize {
+		end := min(start+batchSize, len(texts))
+		vectors, err := embed(texts[start:end])
+		if err != nil {
+			return nil, err
+		}
+		if len(vectors) != end-start {
+			return nil, fmt.Errorf("向量数量 %d 与文本数量 %d 不一致", len(vectors), end-start)
+		}
+		result = append(result, vectors...)
+	}
+	return result, nil
+}
+
+// OpenAIEmbeddingFactory OpenAI 兼容接口的向量模型工厂
+//
+// Extra 支持的参数：
+//   - dimensions: 输出向量的维度，仅部分模型支持
+//   - batch_size: 每次请求的文本条数
+//   - timeout: 请求超时时间（秒）
+type OpenAIEmbeddingFactory struct{}
+
+// CreateEmbedder 创建 OpenAI 向量模型实例
+func (f *OpenAIEmbeddingFactory) CreateEmbedder(config *Config) (embedding.Embedder, error) {
+	return &OpenAIEmbedder{
+		client:     newHTTPClient(config),
+		baseURL:    strings.TrimRight(config.BaseURL, "/"),
+		apiKey:     config.APIKey,
+		model:      config.Model,
+		dimensions: extraInt(config.Extra, "dimensions"),
+		batchSize:  extraInt(config.Extra, "batch_size"),
+	}, nil
+}
+
+// OpenAIEmbedder 基于 /embeddings 接口的向量模型
+type OpenAIEmbedder struct {
+	client     *http.Client
+	baseURL    string
+	apiKey     string
+	model      string
+	dimensions int
+	batchSize  int
+}
+
+// EmbedStrings 将文本转换为向量
+func (e *OpenAIEmbedder) EmbedStrings(ctx context.Context, texts []string, opts ...embedding.Option) ([][]float64, error) {
+	modelName := e.model
+	options := embedding.GetCommonOptions(&embedding.Options{Model: &modelName}, opts...)
+
+	return embedInBatches(texts, e.batchSize, func(batch []string) ([][]float64, error) {
+		body := map[string]interface{}{
+			"model": *options.Model,
+			"input": batch,
+		}
+		if e.dimensions > 0 {
+			body["dimensions"] = e.dimensions
+		}
+
+		resp, err := postJSON(ctx, e.client, ProviderOpenAI, e.baseURL+"/embeddings",
+			map[string]string{"Authorization": "Bearer " + e.apiKey}, body)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		var result struct {
+			Data []struct {
+				Index     int       `json:"index"`
+				Embedding []float64 `json:"embedding"`
+			} `json:"data"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return nil, fmt.Errorf("解析向量响应失败: %w", err)
+		}
+
+		vectors := make([][]float64, len(batch))
+		for _, item := range result.Data {
+			if item.Index < 0 || item.Index >= len(vectors) {
+				return nil, fmt.Errorf("向量响应的序号 %d 超出范围", item.Index)
+			}
+			vectors[item.Index] = item.Embedding
+		}
+		return vectors, nil
+	})
+}
+
+// OllamaEmbeddingFactory Ollama 本地向量模型工厂
+//
+// Extra 支持的参数：
+//   - keep_alive: 模型在内存中保留的时长，例如 "5m"
+//   - batch_size: 每次请求的文本条数
+//   - timeout: 请求超时时间（秒）
+type OllamaEmbeddingFactory struct{}
+
+// CreateEmbedder 创建 Ollama 向量模型实例
+func (f *OllamaEmbeddingFactory) CreateEmbedder(config *Config) (embedding.Embedder, error) {
+	return &OllamaEmbedder{
+		client:    newHTTPClient(config),
+		baseURL:   ollamaBaseURL(config.BaseURL),
+		model:     config.Model,
+		keepAlive: extraString(config.Extra, "keep_alive"),
+		batchSize: extraInt(config.Extra, "batch_size"),
+	}, nil
+}
+
+// OllamaEmbedder 基于 /api/embed 原生协议的向量模型
+type OllamaEmbedder struct {
+	client    *http.Client
+	baseURL   string
+	model     string
+	keepAlive string
+	batchSize int
+}
+
+// EmbedStrings 将文本转换为向量
+func (e *OllamaEmbedder) EmbedStrings(ctx context.Context, texts []string, opts ...embedding.Option) ([][]float64, error) {
+	modelName := e.model
+	options := embedding.GetCommonOptions(&embedding.Options{Model: &modelName}, opts...)
+
+	return embedInBatches(texts, e.batchSize, func(batch []string) ([][]float64, error) {
+		body := map[string]interface{}{
+			"model": *options.Model,
+			"input": batch,
+		}
+		if e.keepAlive != "" {
+			body["keep_alive"] = e.keepAlive
+		}
+
+		resp, err := postJSON(ctx, e.client, ProviderOllama, e.baseURL+"/api/embed", nil, body)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		var result struct {
+			Embeddings [][]float64 `json:"embeddings"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return nil, fmt.Errorf("解析向量响应失败: %w", err)
+		}
+		return result.Embeddings, nil
+	})
+}
+
+// HashEmbeddingFactory 脚本提供商的向量模型工厂，使用本地特征哈希生成向量，不访问网络，用于离线测试
+//
+// Extra 支持的参数：
+//   - dimensions: 向量维度，默认 256
+type HashEmbeddingFactory struct{}
+
+// CreateEmbedder 创建特征哈希向量模型实例
+func (f *HashEmbeddingFactory) CreateEmbedder(config *Config) (embedding.Embedder, error) {
+	dimensions := extraInt(config.Extra, "dimensions")
+	if dimensions <= 0 {
+		dimensions = 256
+	}
+	return &HashEmbedder{dimensions: dimensions}, nil
+}
+
+// HashEmbedder 特征哈希向量模型，英文按单词、中文按相邻两字计入向量，字面相近的文本相似度高
+type HashEmbedder struct {
+	dimensions int
+}
+
+// EmbedStrings 将文本转换为向量
+func (e *HashEmbedder) EmbedStrings(ctx context.Context, texts []string, opts ...embedding.Option) ([][]float64, error) {
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		vector := make([]float64, e.dimensions)
+		for _, term := range knowledge.Tokenize(text) {
+			h := fnv.New32a()
+			h.Write([]byte(term))
+			vector[h.Sum32()%uint32(e.dimensions)]++
+		}
+		vectors[i] = vector
+	}
+	return vectors, nil
+}
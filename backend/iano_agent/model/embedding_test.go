@@ -0,0 +1,109 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenAIEmbedder_Batches(t *testing.T) {
+	var requests []map[string]interface{}
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" {
+			http.NotFound(w, r)
+			return
+		}
+		auth = r.Header.Get("Authorization")
+		var req map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&req)
+		requests = append(requests, req)
+
+		// 倒序返回，验证按 index 还原顺序
+		input := req["input"].([]interface{})
+		var data []map[string]interface{}
+		for i := len(input) - 1; i >= 0; i-- {
+			data = append(data, map[string]interface{}{"index": i, "embedding": []float64{float64(len(input[i].(string))), 1}})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+	defer server.Close()
+
+	embedder, err := CreateEmbedder(&Config{
+		Type: ProviderOpenAI, BaseURL: server.URL + "/v1/", APIKey: "sk-test", Model: "text-embedding-3-small",
+		Extra: map[string]interface{}{"batch_size": 2, "dimensions": 2},
+	})
+	if err != nil {
+		t.Fatalf("CreateEmbedder() error = %v", err)
+	}
+
+	vectors, err := embedder.EmbedStrings(context.Background(), []string{"a", "bb", "ccc"})
+	if err != nil {
+		t.Fatalf("EmbedStrings() error = %v", err)
+	}
+	if len(requests) != 2 || auth != "Bearer sk-test" {
+		t.Fatalf("requests = %v, auth = %q", requests, auth)
+	}
+	if requests[0]["model"] != "text-embedding-3-small" || requests[0]["dimensions"] != float64(2) {
+		t.Errorf("request = %v", requests[0])
+	}
+	for i, want := range []float64{1, 2, 3} {
+		if vectors[i][0] != want {
+			t.Errorf("vectors[%d] = %v, want first value %v", i, vectors[i], want)
+		}
+	}
+}
+
+func TestOllamaEmbedder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/embed" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"embeddings":[[0.1,0.2],[0.3,0.4]]}`))
+	}))
+	defer server.Close()
+
+	embedder, err := CreateEmbedder(&Config{Type: ProviderOllama, BaseURL: server.URL + "/v1", Model: "bge-m3"})
+	if err != nil {
+		t.Fatalf("CreateEmbedder() error = %v", err)
+	}
+	vectors, err := embedder.EmbedStrings(context.Background(), []string{"a", "b"})
+	if err != nil || len(vectors) != 2 || vectors[1][1] != 0.4 {
+		t.Errorf("EmbedStrings() = %v, %v", vectors, err)
+	}
+
+	// 返回的向量数量与输入不一致
+	if _, err := embedder.EmbedStrings(context.Background(), []string{"a"}); err == nil {
+		t.Error("EmbedStrings() with mismatched count error = nil")
+	}
+}
+
+func TestHashEmbedder_Similarity(t *testing.T) {
+	embedder, err := CreateEmbedder(&Config{Type: ProviderScript, Model: "hash"})
+	if err != nil {
+		t.Fatalf("CreateEmbedder() error = %v", err)
+	}
+	vectors, err := embedder.EmbedStrings(context.Background(), []string{"如何配置数据库连接", "数据库连接的配置方法", "今天天气很好"})
+	if err != nil {
+		t.Fatalf("EmbedStrings() error = %v", err)
+	}
+	if len(vectors[0]) != 256 {
+		t.Errorf("dimensions = %d, want 256", len(vectors[0]))
+	}
+	related, unrelated := CosineSimilarity(vectors[0], vectors[1]), CosineSimilarity(vectors[0], vectors[2])
+	if related <= unrelated || related <= 0.3 {
+		t.Errorf("similarity related = %v, unrelated = %v", related, unrelated)
+	}
+	if CosineSimilarity([]float64{1}, []float64{1, 2}) != 0 {
+		t.Error("CosineSimilarity of different dimensions should be 0")
+	}
+}
+
+func TestCreateEmbedder_Unsupported(t *testing.T) {
+	if _, err := CreateEmbedder(&Config{Type: ProviderClaude, BaseURL: "http://x", APIKey: "k", Model: "m"}); err == nil {
+		t.Error("CreateEmbedder(claude) error = nil")
+	}
+}
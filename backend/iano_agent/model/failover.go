@@ -0,0 +1,342 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	agenterrors "iano_agent/errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	goopenai "github.com/meguminnnnnnnnn/go-openai"
+)
+
+// FailoverConfig 故障转移配置
+type FailoverConfig struct {
+	// 切换到下一个提供商前的初始等待时间，之后每次翻倍
+	BaseBackoff time.Duration
+	// 最大等待时间
+	MaxBackoff time.Duration
+	// 连续失败多少次后熔断
+	FailureThreshold int
+	// 熔断持续时间，到期后放行一次试探请求
+	OpenTimeout time.Duration
+	// 熔断器注册表，为空时使用 GlobalBreakers
+	Breakers *BreakerRegistry
+}
+
+// DefaultFailoverConfig 默认故障转移配置
+func DefaultFailoverConfig() *FailoverConfig {
+	return &FailoverConfig{
+		BaseBackoff:      500 * time.Millisecond,
+		MaxBackoff:       8 * time.Second,
+		FailureThreshold: 3,
+		OpenTimeout:      30 * time.Second,
+	}
+}
+
+// FailoverTarget 故障转移链中的一个提供商
+type FailoverTarget struct {
+	// 提供商标识，同时作为熔断器的键
+	Name string
+	// 模型实例
+	Model model.ToolCallingChatModel
+}
+
+// FailoverChatModel 按顺序尝试多个提供商的模型
+//
+// 遇到限流、超时、网络错误或 5xx 时切换到下一个提供商，切换前按指数退避等待；
+// 每个提供商有独立的熔断器，熔断中的提供商会被直接跳过。
+type FailoverChatModel struct {
+	targets []FailoverTarget
+	config  *FailoverConfig
+}
+
+// NewFailoverChatModel 创建故障转移模型，targets 按优先级排列
+func NewFailoverChatModel(targets []FailoverTarget, config *FailoverConfig) (*FailoverChatModel, error) {
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("创建故障转移模型失败: 至少需要一个提供商")
+	}
+	if config == nil {
+		config = DefaultFailoverConfig()
+	}
+	if config.Breakers == nil {
+		config.Breakers = GlobalBreakers
+	}
+
+	return &FailoverChatModel{targets: targets, config: config}, nil
+}
+
+// Generate 生成完整回复
+func (m *FailoverChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	var result *schema.Message
+	err := m.do(ctx, func(innerCtx context.Context, target FailoverTarget) error {
+		msg, err := target.Model.Generate(innerCtx, input, opts...)
+		if err != nil {
+			return err
+		}
+		result = msg
+		return nil
+	})
+	return result, err
+}
+
+// Stream 流式生成回复
+//
+// 在收到第一个数据块之前发生的错误同样会触发切换，之后的错误直接透传给调用方。
+func (m *FailoverChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	var result *schema.StreamReader[*schema.Message]
+	err := m.do(ctx, func(innerCtx context.Context, target FailoverTarget) error {
+		sr, err := target.Model.Stream(innerCtx, input, opts...)
+		if err != nil {
+			return err
+		}
+
+		first, err := sr.Recv()
+		if errors.Is(err, io.EOF) {
+			sr.Close()
+			result = schema.StreamReaderFromArray([]*schema.Message{})
+			return nil
+		}
+		if err != nil {
+			sr.Close()
+			return err
+		}
+
+		result = prependStream(first, sr)
+		return nil
+	})
+	return result, err
+}
+
+// WithTools 为链上每个模型绑定工具，返回新的故障转移模型
+func (m *FailoverChatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	targets := make([]FailoverTarget, 0, len(m.targets))
+	for _, t := range m.targets {
+		cm, err := t.Model.WithTools(tools)
+		if err != nil {
+			return nil, fmt.Errorf("提供商 %s 绑定工具失败: %w", t.Name, err)
+		}
+		targets = append(targets, FailoverTarget{Name: t.Name, Model: cm})
+	}
+	return &FailoverChatModel{targets: targets, config: m.config}, nil
+}
+
+// GetType 组件类型
+func (m *FailoverChatModel) GetType() string {
+	return "Failover"
+}
+
+// do 按顺序在提供商上执行 call，直到成功或遇到不可切换的错误
+func (m *FailoverChatModel) do(ctx context.Context, call func(ctx context.Context, target FailoverTarget) error) error {
+	// 回调由外层组件统一触发，内部模型不再重复上报
+	innerCtx := callbacks.InitCallbacks(ctx, nil)
+
+	var lastErr error
+	var skipped []string
+	attempt := 0
+
+	for _, target := range m.targets {
+		breaker := m.config.Breakers.Get(target.Name, m.config.FailureThreshold, m.config.OpenTimeout)
+		if !breaker.Allow() {
+			skipped = append(skipped, target.Name)
+			continue
+		}
+
+		if attempt > 0 {
+			if err := sleepContext(ctx, m.backoff(attempt)); err != nil {
+				breaker.release()
+				return err
+			}
+		}
+		attempt++
+
+		err := call(innerCtx, target)
+		if err == nil {
+			breaker.Success()
+			reportProvider(ctx, target.Name)
+			return nil
+		}
+
+		if ctx.Err() != nil {
+			breaker.release()
+			return err
+		}
+
+		classified := ClassifyError(err)
+		if !IsFailoverError(classified) {
+			// 请求本身有问题，提供商是可用的
+			breaker.Success()
+			return classified
+		}
+
+		breaker.Failure()
+		lastErr = classified
+		slog.Warn("模型提供商调用失败，尝试下一个提供商",
+			"provider", target.Name,
+			"code", classified.Code,
+			"error", err)
+	}
+
+	if lastErr == nil {
+		return agenterrors.NewError(agenterrors.ErrCodeModel, "所有模型提供商均处于熔断状态").
+			WithDetail("skipped", skipped)
+	}
+	return agenterrors.WrapError(agenterrors.ErrCodeModel, "所有模型提供商均调用失败", lastErr).
+		WithDetail("skipped", skipped)
+}
+
+// backoff 第 attempt 次切换前的等待时间
+func (m *FailoverChatModel) backoff(attempt int) time.Duration {
+	d := m.config.BaseBackoff
+	for i := 1; i < attempt && d < m.config.MaxBackoff; i++ {
+		d *= 2
+	}
+	if m.config.MaxBackoff > 0 && d > m.config.MaxBackoff {
+		d = m.config.MaxBackoff
+	}
+	return d
+}
+
+// sleepContext 等待指定时间，ctx 结束时提前返回
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// prependStream 将已读取的第一个数据块放回流的开头
+func prependStream(first *schema.Message, rest *schema.StreamReader[*schema.Message]) *schema.StreamReader[*schema.Message] {
+	sr, sw := schema.Pipe[*schema.Message](16)
+	go func() {
+		defer rest.Close()
+		defer sw.Close()
+
+		if sw.Send(first, nil) {
+			return
+		}
+		for {
+			msg, err := rest.Recv()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if sw.Send(msg, err) || err != nil {
+				return
+			}
+		}
+	}()
+	return sr
+}
+
+// StatusCode 从模型调用错误中提取 HTTP 状态码，无法识别时返回 0
+func StatusCode(err error) int {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	var openaiErr *goopenai.APIError
+	if errors.As(err, &openaiErr) {
+		return openaiErr.HTTPStatusCode
+	}
+	var reqErr *goopenai.RequestError
+	if errors.As(err, &reqErr) {
+		return reqErr.HTTPStatusCode
+	}
+	return 0
+}
+
+// ClassifyError 将模型调用错误归类为 AgentError
+//
+// 429 归为 ErrCodeRateLimit，超时归为 ErrCodeTimeout，连接失败归为 ErrCodeNetwork，
+// 其余归为 ErrCodeModel；其中 5xx 在 Details["retryable"] 中标记为可切换。
+func ClassifyError(err error) *agenterrors.AgentError {
+	if err == nil {
+		return nil
+	}
+
+	var agentErr *agenterrors.AgentError
+	if errors.As(err, &agentErr) {
+		return agentErr
+	}
+
+	if status := StatusCode(err); status > 0 {
+		switch {
+		case status == http.StatusTooManyRequests:
+			return agenterrors.NewError(agenterrors.ErrCodeRateLimit, "模型服务限流", err).
+				WithDetail("status_code", status)
+		case status == http.StatusRequestTimeout || status == http.StatusGatewayTimeout:
+			return agenterrors.NewError(agenterrors.ErrCodeTimeout, "模型服务超时", err).
+				WithDetail("status_code", status)
+		case status >= http.StatusInternalServerError:
+			return agenterrors.NewError(agenterrors.ErrCodeModel, "模型服务不可用", err).
+				WithDetail("status_code", status).
+				WithDetail("retryable", true)
+		default:
+			return agenterrors.NewError(agenterrors.ErrCodeModel, "模型请求失败", err).
+				WithDetail("status_code", status)
+		}
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return agenterrors.NewError(agenterrors.ErrCodeTimeout, "模型请求超时", err)
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return agenterrors.NewError(agenterrors.ErrCodeTimeout, "模型请求超时", err)
+		}
+		return agenterrors.NewError(agenterrors.ErrCodeNetwork, "连接模型服务失败", err)
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return agenterrors.NewError(agenterrors.ErrCodeNetwork, "模型服务连接中断", err)
+	}
+
+	return agenterrors.NewError(agenterrors.ErrCodeModel, "模型调用失败", err)
+}
+
+// IsFailoverError 是否应切换到下一个提供商：限流、超时、网络错误和 5xx
+func IsFailoverError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	classified := ClassifyError(err)
+	if agenterrors.IsRetryableError(classified) {
+		return true
+	}
+	retryable, _ := classified.Details["retryable"].(bool)
+	return retryable
+}
+
+type providerReporterKey struct{}
+
+// ProviderReporter 接收实际响应请求的提供商名称
+type ProviderReporter func(provider string)
+
+// WithProviderReporter 在 ctx 中注册提供商上报函数，故障转移模型成功响应后调用
+func WithProviderReporter(ctx context.Context, reporter ProviderReporter) context.Context {
+	return context.WithValue(ctx, providerReporterKey{}, reporter)
+}
+
+// reportProvider 上报实际响应请求的提供商
+func reportProvider(ctx context.Context, provider string) {
+	if reporter, ok := ctx.Value(providerReporterKey{}).(ProviderReporter); ok && reporter != nil {
+		reporter(provider)
+	}
+}
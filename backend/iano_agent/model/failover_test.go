@@ -0,0 +1,177 @@
+package model
+
+import (
+	"context"
+	"errors"
+	agenterrors "iano_agent/errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// stubChatModel 按预设错误返回的测试模型
+type stubChatModel struct {
+	name      string
+	err       error // Generate / Stream 调用时返回的错误
+	streamErr error // 流的第一个数据块返回的错误
+	calls     int
+}
+
+func (m *stubChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	m.calls++
+	if m.err != nil {
+		return nil, m.err
+	}
+	return schema.AssistantMessage("来自 "+m.name, nil), nil
+}
+
+func (m *stubChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	m.calls++
+	if m.err != nil {
+		return nil, m.err
+	}
+	sr, sw := schema.Pipe[*schema.Message](2)
+	go func() {
+		defer sw.Close()
+		if m.streamErr != nil {
+			sw.Send(nil, m.streamErr)
+			return
+		}
+		sw.Send(schema.AssistantMessage("来自 ", nil), nil)
+		sw.Send(schema.AssistantMessage(m.name, nil), nil)
+	}()
+	return sr, nil
+}
+
+func (m *stubChatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	return m, nil
+}
+
+func newTestFailover(t *testing.T, threshold int, models ...*stubChatModel) *FailoverChatModel {
+	t.Helper()
+
+	targets := make([]FailoverTarget, 0, len(models))
+	for _, m := range models {
+		targets = append(targets, FailoverTarget{Name: m.name, Model: m})
+	}
+	fm, err := NewFailoverChatModel(targets, &FailoverConfig{
+		BaseBackoff:      time.Millisecond,
+		MaxBackoff:       5 * time.Millisecond,
+		FailureThreshold: threshold,
+		OpenTimeout:      time.Hour,
+		Breakers:         NewBreakerRegistry(),
+	})
+	if err != nil {
+		t.Fatalf("NewFailoverChatModel() error = %v", err)
+	}
+	return fm
+}
+
+func TestFailoverChatModel_Generate(t *testing.T) {
+	t.Run("限流时切换到下一个提供商", func(t *testing.T) {
+		primary := &stubChatModel{name: "primary", err: &APIError{Provider: ProviderOpenAI, StatusCode: http.StatusTooManyRequests}}
+		backup := &stubChatModel{name: "backup"}
+		fm := newTestFailover(t, 3, primary, backup)
+
+		var answered string
+		ctx := WithProviderReporter(context.Background(), func(provider string) { answered = provider })
+		msg, err := fm.Generate(ctx, []*schema.Message{schema.UserMessage("hi")})
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		if msg.Content != "来自 backup" || answered != "backup" {
+			t.Errorf("Content = %q, answered = %q", msg.Content, answered)
+		}
+	})
+
+	t.Run("请求错误不切换", func(t *testing.T) {
+		primary := &stubChatModel{name: "primary", err: &APIError{Provider: ProviderOpenAI, StatusCode: http.StatusBadRequest}}
+		backup := &stubChatModel{name: "backup"}
+		fm := newTestFailover(t, 3, primary, backup)
+
+		_, err := fm.Generate(context.Background(), []*schema.Message{schema.UserMessage("hi")})
+		if !agenterrors.IsErrorCode(err, agenterrors.ErrCodeModel) {
+			t.Errorf("Generate() error = %v, want MODEL_ERROR", err)
+		}
+		if backup.calls != 0 {
+			t.Errorf("backup.calls = %d, want 0", backup.calls)
+		}
+	})
+
+	t.Run("全部失败时保留最后的错误码", func(t *testing.T) {
+		primary := &stubChatModel{name: "primary", err: &APIError{Provider: ProviderOpenAI, StatusCode: http.StatusBadGateway}}
+		backup := &stubChatModel{name: "backup", err: &APIError{Provider: ProviderClaude, StatusCode: http.StatusTooManyRequests}}
+		fm := newTestFailover(t, 3, primary, backup)
+
+		_, err := fm.Generate(context.Background(), []*schema.Message{schema.UserMessage("hi")})
+		if !agenterrors.IsErrorCode(err, agenterrors.ErrCodeRateLimit) {
+			t.Errorf("Generate() error = %v, want RATE_LIMIT_ERROR", err)
+		}
+	})
+
+	t.Run("熔断后跳过提供商", func(t *testing.T) {
+		primary := &stubChatModel{name: "primary", err: &APIError{Provider: ProviderOpenAI, StatusCode: http.StatusServiceUnavailable}}
+		backup := &stubChatModel{name: "backup"}
+		fm := newTestFailover(t, 1, primary, backup)
+
+		for i := 0; i < 3; i++ {
+			if _, err := fm.Generate(context.Background(), []*schema.Message{schema.UserMessage("hi")}); err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+		}
+		if primary.calls != 1 || backup.calls != 3 {
+			t.Errorf("primary.calls = %d, backup.calls = %d", primary.calls, backup.calls)
+		}
+		if state := fm.config.Breakers.Get("primary", 1, time.Hour).State(); state != BreakerOpen {
+			t.Errorf("primary breaker = %s, want open", state)
+		}
+	})
+}
+
+func TestFailoverChatModel_Stream(t *testing.T) {
+	primary := &stubChatModel{name: "primary", streamErr: &APIError{Provider: ProviderOpenAI, StatusCode: http.StatusInternalServerError}}
+	backup := &stubChatModel{name: "backup"}
+	fm := newTestFailover(t, 3, primary, backup)
+
+	sr, err := fm.Stream(context.Background(), []*schema.Message{schema.UserMessage("hi")})
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	msg, err := concatStream(sr)
+	if err != nil {
+		t.Fatalf("concatStream() error = %v", err)
+	}
+	if msg.Content != "来自 backup" {
+		t.Errorf("Content = %q", msg.Content)
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		code     agenterrors.ErrorCode
+		failover bool
+	}{
+		{"429", &APIError{StatusCode: 429}, agenterrors.ErrCodeRateLimit, true},
+		{"529", &APIError{StatusCode: 529}, agenterrors.ErrCodeModel, true},
+		{"401", &APIError{StatusCode: 401}, agenterrors.ErrCodeModel, false},
+		{"超时", context.DeadlineExceeded, agenterrors.ErrCodeTimeout, true},
+		{"取消", context.Canceled, agenterrors.ErrCodeModel, false},
+		{"未知", errors.New("boom"), agenterrors.ErrCodeModel, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyError(tt.err).Code; got != tt.code {
+				t.Errorf("ClassifyError().Code = %s, want %s", got, tt.code)
+			}
+			if got := IsFailoverError(tt.err); got != tt.failover {
+				t.Errorf("IsFailoverError() = %v, want %v", got, tt.failover)
+			}
+		})
+	}
+}
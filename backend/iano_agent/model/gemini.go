@@ -0,0 +1,575 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+const (
+	geminiDefaultBaseURL    = "https://generativelanguage.googleapis.com"
+	geminiDefaultAPIVersion = "v1beta"
+
+	// geminiSignatureKey 思考签名在 Message.Extra / ToolCall.Extra 中的键名，多轮工具调用时需原样回传
+	geminiSignatureKey = "gemini_thought_signature"
+)
+
+// init 注册 Gemini 工厂
+func init() {
+	GlobalRegistry.Register(ProviderGemini, &GeminiFactory{})
+}
+
+// GeminiFactory Google Gemini 模型工厂
+//
+// Extra 支持的参数：
+//   - api_version: API 版本，默认 v1beta
+//   - thinking_budget: 思考 token 预算，-1 表示由模型动态决定，0 表示关闭思考
+//   - include_thoughts: 是否返回思考摘要，设置了 thinking_budget 时默认开启
+//   - timeout: 请求超时时间（秒）
+type GeminiFactory struct{}
+
+// Create 创建 Gemini 模型实例
+func (f *GeminiFactory) Create(config *Config) (model.ToolCallingChatModel, error) {
+	return NewGeminiChatModel(config)
+}
+
+// Support 是否支持该提供商
+func (f *GeminiFactory) Support(providerType ProviderType) bool {
+	return providerType == ProviderGemini
+}
+
+// GeminiChatModel 基于 generateContent 原生协议的 Gemini 模型
+type GeminiChatModel struct {
+	client          *http.Client
+	baseURL         string
+	apiKey          string
+	apiVersion      string
+	model           string
+	temperature     float32
+	maxTokens       int
+	thinkingBudget  *int
+	includeThoughts bool
+	tools           []geminiTool
+}
+
+// NewGeminiChatModel 创建 Gemini 模型
+func NewGeminiChatModel(config *Config) (*GeminiChatModel, error) {
+	if config.Model == "" {
+		return nil, fmt.Errorf("创建 Gemini 模型失败: 模型名称不能为空")
+	}
+
+	baseURL := strings.TrimRight(config.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = geminiDefaultBaseURL
+	}
+	apiVersion := extraString(config.Extra, "api_version")
+	if apiVersion == "" {
+		apiVersion = geminiDefaultAPIVersion
+	}
+
+	m := &GeminiChatModel{
+		client:      newHTTPClient(config),
+		baseURL:     baseURL,
+		apiKey:      config.APIKey,
+		apiVersion:  apiVersion,
+		model:       strings.TrimPrefix(config.Model, "models/"),
+		temperature: config.Temperature,
+		maxTokens:   config.MaxTokens,
+	}
+
+	if _, ok := config.Extra["thinking_budget"]; ok {
+		budget := extraInt(config.Extra, "thinking_budget")
+		m.thinkingBudget = &budget
+		m.includeThoughts = budget != 0
+	}
+	if v, ok := config.Extra["include_thoughts"].(bool); ok {
+		m.includeThoughts = v
+	}
+
+	return m, nil
+}
+
+type geminiBlob struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"`
+}
+
+type geminiFileData struct {
+	MimeType string `json:"mimeType,omitempty"`
+	FileURI  string `json:"fileUri"`
+}
+
+type geminiFunctionCall struct {
+	ID   string          `json:"id,omitempty"`
+	Name string          `json:"name"`
+	Args json.RawMessage `json:"args,omitempty"`
+}
+
+type geminiFunctionResponse struct {
+	ID       string          `json:"id,omitempty"`
+	Name     string          `json:"name"`
+	Response json.RawMessage `json:"response"`
+}
+
+type geminiPart struct {
+	Text             string                  `json:"text,omitempty"`
+	Thought          bool                    `json:"thought,omitempty"`
+	ThoughtSignature string                  `json:"thoughtSignature,omitempty"`
+	InlineData       *geminiBlob             `json:"inlineData,omitempty"`
+	FileData         *geminiFileData         `json:"fileData,omitempty"`
+	FunctionCall     *geminiFunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *geminiFunctionResponse `json:"functionResponse,omitempty"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiFunctionDeclaration struct {
+	Name                 string          `json:"name"`
+	Description          string          `json:"description,omitempty"`
+	ParametersJSONSchema json.RawMessage `json:"parametersJsonSchema,omitempty"`
+}
+
+type geminiTool struct {
+	FunctionDeclarations []geminiFunctionDeclaration `json:"functionDeclarations"`
+}
+
+type geminiThinkingConfig struct {
+	ThinkingBudget  *int `json:"thinkingBudget,omitempty"`
+	IncludeThoughts bool `json:"includeThoughts,omitempty"`
+}
+
+type geminiGenerationConfig struct {
+	Temperature     *float32              `json:"temperature,omitempty"`
+	MaxOutputTokens int                   `json:"maxOutputTokens,omitempty"`
+	TopP            *float32              `json:"topP,omitempty"`
+	StopSequences   []string              `json:"stopSequences,omitempty"`
+	ThinkingConfig  *geminiThinkingConfig `json:"thinkingConfig,omitempty"`
+}
+
+type geminiRequest struct {
+	Contents          []geminiContent         `json:"contents"`
+	SystemInstruction *geminiContent          `json:"systemInstruction,omitempty"`
+	Tools             []geminiTool            `json:"tools,omitempty"`
+	GenerationConfig  *geminiGenerationConfig `json:"generationConfig,omitempty"`
+}
+
+type geminiUsageMetadata struct {
+	PromptTokenCount        int `json:"promptTokenCount"`
+	CandidatesTokenCount    int `json:"candidatesTokenCount"`
+	TotalTokenCount         int `json:"totalTokenCount"`
+	CachedContentTokenCount int `json:"cachedContentTokenCount"`
+	ThoughtsTokenCount      int `json:"thoughtsTokenCount"`
+}
+
+type geminiResponse struct {
+	Candidates []struct {
+		Content      geminiContent `json:"content"`
+		FinishReason string        `json:"finishReason"`
+	} `json:"candidates"`
+	UsageMetadata  *geminiUsageMetadata `json:"usageMetadata"`
+	PromptFeedback *struct {
+		BlockReason string `json:"blockReason"`
+	} `json:"promptFeedback"`
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Generate 生成完整回复，调用非流式的 generateContent 接口
+func (m *GeminiChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	modelName, req, err := m.buildRequest(input, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/%s/models/%s:generateContent", m.baseURL, m.apiVersion, modelName)
+	headers := map[string]string{"x-goog-api-key": m.apiKey}
+
+	resp, err := postJSON(ctx, m.client, ProviderGemini, url, headers, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result geminiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("解析 Gemini 响应失败: %w", err)
+	}
+
+	var decoder geminiDecoder
+	msg, err := decoder.decode(&result)
+	if err != nil {
+		return nil, err
+	}
+	if msg == nil {
+		msg = &schema.Message{Role: schema.Assistant}
+	}
+	msg.ResponseMeta = decoder.responseMeta()
+	return msg, nil
+}
+
+// Stream 流式生成回复
+func (m *GeminiChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	modelName, req, err := m.buildRequest(input, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/%s/models/%s:streamGenerateContent?alt=sse", m.baseURL, m.apiVersion, modelName)
+	headers := map[string]string{"x-goog-api-key": m.apiKey}
+
+	resp, err := postJSON(ctx, m.client, ProviderGemini, url, headers, req)
+	if err != nil {
+		return nil, err
+	}
+
+	sr, sw := schema.Pipe[*schema.Message](16)
+	go func() {
+		defer resp.Body.Close()
+		defer sw.Close()
+
+		if err := m.readStream(resp.Body, sw); err != nil {
+			sw.Send(nil, err)
+		}
+	}()
+
+	return sr, nil
+}
+
+// WithTools 绑定工具，返回新的模型实例
+func (m *GeminiChatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	converted, err := toGeminiTools(tools)
+	if err != nil {
+		return nil, err
+	}
+
+	cm := *m
+	cm.tools = converted
+	return &cm, nil
+}
+
+// GetType 组件类型
+func (m *GeminiChatModel) GetType() string {
+	return "Gemini"
+}
+
+func (m *GeminiChatModel) buildRequest(input []*schema.Message, opts ...model.Option) (string, *geminiRequest, error) {
+	temperature := m.temperature
+	maxTokens := m.maxTokens
+	modelName := m.model
+	options := model.GetCommonOptions(&model.Options{
+		Temperature: &temperature,
+		MaxTokens:   &maxTokens,
+		Model:       &modelName,
+	}, opts...)
+
+	system, contents := toGeminiContents(input)
+	req := &geminiRequest{
+		Contents:          contents,
+		SystemInstruction: system,
+		Tools:             m.tools,
+		GenerationConfig: &geminiGenerationConfig{
+			TopP:          options.TopP,
+			StopSequences: options.Stop,
+		},
+	}
+
+	if len(options.Tools) > 0 {
+		tools, err := toGeminiTools(options.Tools)
+		if err != nil {
+			return "", nil, err
+		}
+		req.Tools = tools
+	}
+
+	if options.Temperature != nil && *options.Temperature > 0 {
+		req.GenerationConfig.Temperature = options.Temperature
+	}
+	if options.MaxTokens != nil && *options.MaxTokens > 0 {
+		req.GenerationConfig.MaxOutputTokens = *options.MaxTokens
+	}
+	if m.thinkingBudget != nil || m.includeThoughts {
+		req.GenerationConfig.ThinkingConfig = &geminiThinkingConfig{
+			ThinkingBudget:  m.thinkingBudget,
+			IncludeThoughts: m.includeThoughts,
+		}
+	}
+
+	return strings.TrimPrefix(*options.Model, "models/"), req, nil
+}
+
+// readStream 解析 streamGenerateContent 的 SSE 流
+func (m *GeminiChatModel) readStream(body io.Reader, sw *schema.StreamWriter[*schema.Message]) error {
+	var decoder geminiDecoder
+	closed := false
+
+	err := readSSE(body, func(_ string, data []byte) error {
+		var chunk geminiResponse
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			return fmt.Errorf("解析 Gemini 响应失败: %w", err)
+		}
+		msg, err := decoder.decode(&chunk)
+		if err != nil || msg == nil {
+			return err
+		}
+		if sw.Send(msg, nil) {
+			closed = true
+			return io.EOF
+		}
+		return nil
+	})
+	if err != nil || closed {
+		return err
+	}
+
+	sw.Send(&schema.Message{Role: schema.Assistant, ResponseMeta: decoder.responseMeta()}, nil)
+	return nil
+}
+
+// geminiDecoder 将 Gemini 响应转换为消息，流式响应的各个分片依次传入，用量和结束原因取最后出现的值
+type geminiDecoder struct {
+	usage        *geminiUsageMetadata
+	finishReason string
+	toolIndex    int
+}
+
+// decode 转换一个响应或分片，没有内容时返回 nil
+func (d *geminiDecoder) decode(chunk *geminiResponse) (*schema.Message, error) {
+	if chunk.Error != nil {
+		code := chunk.Error.Code
+		if code == 0 {
+			code = http.StatusInternalServerError
+		}
+		return nil, &APIError{Provider: ProviderGemini, StatusCode: code, Message: chunk.Error.Message}
+	}
+	if chunk.PromptFeedback != nil && chunk.PromptFeedback.BlockReason != "" && len(chunk.Candidates) == 0 {
+		return nil, &APIError{Provider: ProviderGemini, StatusCode: http.StatusBadRequest, Message: "提示词被拦截: " + chunk.PromptFeedback.BlockReason}
+	}
+	if chunk.UsageMetadata != nil {
+		d.usage = chunk.UsageMetadata
+	}
+	if len(chunk.Candidates) == 0 {
+		return nil, nil
+	}
+
+	candidate := chunk.Candidates[0]
+	if candidate.FinishReason != "" {
+		d.finishReason = candidate.FinishReason
+	}
+
+	msg := &schema.Message{Role: schema.Assistant}
+	for _, part := range candidate.Content.Parts {
+		switch {
+		case part.FunctionCall != nil:
+			idx := d.toolIndex
+			d.toolIndex++
+			id := part.FunctionCall.ID
+			if id == "" {
+				id = fmt.Sprintf("call_%d_%d", time.Now().UnixNano(), idx)
+			}
+			args := string(part.FunctionCall.Args)
+			if args == "" || args == "null" {
+				args = "{}"
+			}
+			tc := schema.ToolCall{
+				Index:    &idx,
+				ID:       id,
+				Type:     "function",
+				Function: schema.FunctionCall{Name: part.FunctionCall.Name, Arguments: args},
+			}
+			if part.ThoughtSignature != "" {
+				tc.Extra = map[string]any{geminiSignatureKey: part.ThoughtSignature}
+			}
+			msg.ToolCalls = append(msg.ToolCalls, tc)
+		case part.Thought:
+			msg.ReasoningContent += part.Text
+		default:
+			msg.Content += part.Text
+			if part.ThoughtSignature != "" {
+				msg.Extra = map[string]any{geminiSignatureKey: part.ThoughtSignature}
+			}
+		}
+	}
+
+	if msg.Content == "" && msg.ReasoningContent == "" && len(msg.ToolCalls) == 0 {
+		return nil, nil
+	}
+	return msg, nil
+}
+
+// responseMeta 结束原因和用量
+func (d *geminiDecoder) responseMeta() *schema.ResponseMeta {
+	meta := &schema.ResponseMeta{FinishReason: geminiFinishReason(d.finishReason)}
+	if d.toolIndex > 0 {
+		meta.FinishReason = "tool_calls"
+	}
+	if usage := d.usage; usage != nil {
+		meta.Usage = &schema.TokenUsage{
+			PromptTokens:            usage.PromptTokenCount,
+			PromptTokenDetails:      schema.PromptTokenDetails{CachedTokens: usage.CachedContentTokenCount},
+			CompletionTokens:        usage.CandidatesTokenCount + usage.ThoughtsTokenCount,
+			TotalTokens:             usage.TotalTokenCount,
+			CompletionTokensDetails: schema.CompletionTokensDetails{ReasoningTokens: usage.ThoughtsTokenCount},
+		}
+	}
+	return meta
+}
+
+// toGeminiTools 转换工具定义
+func toGeminiTools(tools []*schema.ToolInfo) ([]geminiTool, error) {
+	if len(tools) == 0 {
+		return nil, nil
+	}
+
+	declarations := make([]geminiFunctionDeclaration, 0, len(tools))
+	for _, t := range tools {
+		params, err := toolSchema(t)
+		if err != nil {
+			return nil, err
+		}
+		declarations = append(declarations, geminiFunctionDeclaration{
+			Name:                 t.Name,
+			Description:          t.Desc,
+			ParametersJSONSchema: params,
+		})
+	}
+	return []geminiTool{{FunctionDeclarations: declarations}}, nil
+}
+
+// toGeminiContents 转换消息，system 消息合并为 systemInstruction，相邻同角色消息合并
+func toGeminiContents(input []*schema.Message) (*geminiContent, []geminiContent) {
+	var systemParts []geminiPart
+	toolNames := make(map[string]string)
+	contents := make([]geminiContent, 0, len(input))
+
+	appendContent := func(role string, parts []geminiPart) {
+		if len(parts) == 0 {
+			return
+		}
+		if n := len(contents); n > 0 && contents[n-1].Role == role {
+			contents[n-1].Parts = append(contents[n-1].Parts, parts...)
+			return
+		}
+		contents = append(contents, geminiContent{Role: role, Parts: parts})
+	}
+
+	for _, msg := range input {
+		switch msg.Role {
+		case schema.System:
+			if msg.Content != "" {
+				systemParts = append(systemParts, geminiPart{Text: msg.Content})
+			}
+		case schema.User:
+			appendContent("user", geminiUserParts(msg))
+		case schema.Assistant:
+			parts := make([]geminiPart, 0, len(msg.ToolCalls)+1)
+			if msg.Content != "" {
+				part := geminiPart{Text: msg.Content}
+				if sig, ok := msg.Extra[geminiSignatureKey].(string); ok {
+					part.ThoughtSignature = sig
+				}
+				parts = append(parts, part)
+			}
+			for _, tc := range msg.ToolCalls {
+				toolNames[tc.ID] = tc.Function.Name
+				args := tc.Function.Arguments
+				if !json.Valid([]byte(args)) {
+					args = "{}"
+				}
+				part := geminiPart{FunctionCall: &geminiFunctionCall{
+					Name: tc.Function.Name,
+					Args: json.RawMessage(args),
+				}}
+				if sig, ok := tc.Extra[geminiSignatureKey].(string); ok {
+					part.ThoughtSignature = sig
+				}
+				parts = append(parts, part)
+			}
+			appendContent("model", parts)
+		case schema.Tool:
+			name := msg.ToolName
+			if name == "" {
+				name = toolNames[msg.ToolCallID]
+			}
+			appendContent("user", []geminiPart{{FunctionResponse: &geminiFunctionResponse{
+				Name:     name,
+				Response: geminiToolResponse(msg.Content),
+			}}})
+		}
+	}
+
+	if len(systemParts) == 0 {
+		return nil, contents
+	}
+	return &geminiContent{Parts: systemParts}, contents
+}
+
+// geminiUserParts 转换用户消息内容，支持文本与图片
+func geminiUserParts(msg *schema.Message) []geminiPart {
+	parts := make([]geminiPart, 0, len(msg.UserInputMultiContent)+1)
+	if msg.Content != "" {
+		parts = append(parts, geminiPart{Text: msg.Content})
+	}
+
+	for _, part := range msg.UserInputMultiContent {
+		switch part.Type {
+		case schema.ChatMessagePartTypeText:
+			if part.Text != "" {
+				parts = append(parts, geminiPart{Text: part.Text})
+			}
+		case schema.ChatMessagePartTypeImageURL:
+			if part.Image == nil {
+				continue
+			}
+			if part.Image.Base64Data != nil {
+				parts = append(parts, geminiPart{InlineData: &geminiBlob{
+					MimeType: part.Image.MIMEType,
+					Data:     *part.Image.Base64Data,
+				}})
+			} else if part.Image.URL != nil {
+				parts = append(parts, geminiPart{FileData: &geminiFileData{
+					MimeType: part.Image.MIMEType,
+					FileURI:  *part.Image.URL,
+				}})
+			}
+		}
+	}
+
+	return parts
+}
+
+// geminiToolResponse functionResponse.response 必须是 JSON 对象，非对象结果包装到 content 字段
+func geminiToolResponse(content string) json.RawMessage {
+	trimmed := strings.TrimSpace(content)
+	if strings.HasPrefix(trimmed, "{") && json.Valid([]byte(trimmed)) {
+		return json.RawMessage(trimmed)
+	}
+
+	data, _ := json.Marshal(map[string]string{"content": content})
+	return data
+}
+
+// geminiFinishReason 将 finishReason 转换为通用的结束原因
+func geminiFinishReason(reason string) string {
+	switch reason {
+	case "STOP", "":
+		return "stop"
+	case "MAX_TOKENS":
+		return "length"
+	case "SAFETY", "RECITATION", "BLOCKLIST", "PROHIBITED_CONTENT", "SPII", "IMAGE_SAFETY":
+		return "content_filter"
+	default:
+		return strings.ToLower(reason)
+	}
+}
@@ -0,0 +1,175 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestGeminiChatModel_Stream(t *testing.T) {
+	var gotReq geminiRequest
+	var gotPath, gotKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path + "?" + r.URL.RawQuery
+		gotKey = r.Header.Get("x-goog-api-key")
+		json.NewDecoder(r.Body).Decode(&gotReq)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte(`data: {"candidates":[{"content":{"role":"model","parts":[{"text":"先看目录","thought":true}]}}]}
+
+data: {"candidates":[{"content":{"role":"model","parts":[{"text":"好的"}]}}]}
+
+data: {"candidates":[{"content":{"role":"model","parts":[{"functionCall":{"name":"file_list","args":{"path":"."}},"thoughtSignature":"c2ln"}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":50,"candidatesTokenCount":10,"thoughtsTokenCount":5,"totalTokenCount":65}}
+
+`))
+	}))
+	defer server.Close()
+
+	cm, err := CreateModel(&Config{
+		Type:        ProviderGemini,
+		BaseURL:     server.URL,
+		APIKey:      "test-key",
+		Model:       "gemini-2.5-flash",
+		Temperature: 0.5,
+		Extra:       map[string]interface{}{"thinking_budget": float64(1024)},
+	})
+	if err != nil {
+		t.Fatalf("CreateModel() error = %v", err)
+	}
+
+	cm, err = cm.WithTools([]*schema.ToolInfo{{
+		Name: "file_list",
+		Desc: "列出目录",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"path": {Type: schema.String, Desc: "目录", Required: true},
+		}),
+	}})
+	if err != nil {
+		t.Fatalf("WithTools() error = %v", err)
+	}
+
+	input := []*schema.Message{
+		schema.SystemMessage("你是一个助手"),
+		schema.UserMessage("看看根目录"),
+		{Role: schema.Assistant, ToolCalls: []schema.ToolCall{{
+			ID:       "call_0",
+			Function: schema.FunctionCall{Name: "file_list", Arguments: `{"path":"/"}`},
+			Extra:    map[string]any{geminiSignatureKey: "b2xk"},
+		}}},
+		schema.ToolMessage("权限不足", "call_0"),
+		schema.UserMessage("那就看当前目录"),
+	}
+	sr, err := cm.Stream(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	msg, err := concatStream(sr)
+	if err != nil {
+		t.Fatalf("concatStream() error = %v", err)
+	}
+
+	t.Run("请求格式", func(t *testing.T) {
+		if gotPath != "/v1beta/models/gemini-2.5-flash:streamGenerateContent?alt=sse" {
+			t.Errorf("path = %q", gotPath)
+		}
+		if gotKey != "test-key" {
+			t.Errorf("x-goog-api-key = %q, want test-key", gotKey)
+		}
+		if gotReq.SystemInstruction == nil || gotReq.SystemInstruction.Parts[0].Text != "你是一个助手" {
+			t.Errorf("SystemInstruction = %+v", gotReq.SystemInstruction)
+		}
+		if len(gotReq.Tools) != 1 || gotReq.Tools[0].FunctionDeclarations[0].Name != "file_list" {
+			t.Errorf("Tools = %+v", gotReq.Tools)
+		}
+		cfg := gotReq.GenerationConfig
+		if cfg.ThinkingConfig == nil || *cfg.ThinkingConfig.ThinkingBudget != 1024 || !cfg.ThinkingConfig.IncludeThoughts {
+			t.Errorf("ThinkingConfig = %+v", cfg.ThinkingConfig)
+		}
+		// 工具结果与随后的用户消息合并到同一条 user 内容中
+		if len(gotReq.Contents) != 3 {
+			t.Fatalf("len(Contents) = %d, want 3", len(gotReq.Contents))
+		}
+		call := gotReq.Contents[1].Parts[0]
+		if gotReq.Contents[1].Role != "model" || call.FunctionCall == nil || call.ThoughtSignature != "b2xk" {
+			t.Errorf("Contents[1] = %+v", gotReq.Contents[1])
+		}
+		last := gotReq.Contents[2]
+		if last.Role != "user" || len(last.Parts) != 2 || last.Parts[0].FunctionResponse == nil {
+			t.Fatalf("Contents[2] = %+v", last)
+		}
+		resp := last.Parts[0].FunctionResponse
+		if resp.Name != "file_list" || string(resp.Response) != `{"content":"权限不足"}` {
+			t.Errorf("FunctionResponse = %+v", resp)
+		}
+	})
+
+	t.Run("响应解析", func(t *testing.T) {
+		if msg.Content != "好的" || msg.ReasoningContent != "先看目录" {
+			t.Errorf("msg = %+v", msg)
+		}
+		if len(msg.ToolCalls) != 1 {
+			t.Fatalf("len(ToolCalls) = %d, want 1", len(msg.ToolCalls))
+		}
+		tc := msg.ToolCalls[0]
+		if tc.ID == "" || tc.Function.Arguments != `{"path":"."}` || tc.Extra[geminiSignatureKey] != "c2ln" {
+			t.Errorf("ToolCall = %+v", tc)
+		}
+		if msg.ResponseMeta == nil || msg.ResponseMeta.FinishReason != "tool_calls" {
+			t.Fatalf("ResponseMeta = %+v", msg.ResponseMeta)
+		}
+		usage := msg.ResponseMeta.Usage
+		if usage.PromptTokens != 50 || usage.CompletionTokens != 15 || usage.TotalTokens != 65 {
+			t.Errorf("Usage = %+v", usage)
+		}
+	})
+}
+
+func TestGeminiChatModel_Generate(t *testing.T) {
+	var gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path + "?" + r.URL.RawQuery
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":"想一想","thought":true},{"text":"你好，"},{"text":"有什么可以帮你？","thoughtSignature":"c2ln"}]},"finishReason":"MAX_TOKENS"}],"usageMetadata":{"promptTokenCount":8,"candidatesTokenCount":6,"totalTokenCount":14}}`))
+	}))
+	defer server.Close()
+
+	cm, err := CreateModel(&Config{Type: ProviderGemini, BaseURL: server.URL, APIKey: "test-key", Model: "models/gemini-2.5-flash"})
+	if err != nil {
+		t.Fatalf("CreateModel() error = %v", err)
+	}
+	msg, err := cm.Generate(context.Background(), []*schema.Message{schema.UserMessage("你好")})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	if gotPath != "/v1beta/models/gemini-2.5-flash:generateContent?" {
+		t.Errorf("path = %q", gotPath)
+	}
+	if msg.Content != "你好，有什么可以帮你？" || msg.ReasoningContent != "想一想" || msg.Extra[geminiSignatureKey] != "c2ln" {
+		t.Errorf("msg = %+v", msg)
+	}
+	if msg.ResponseMeta == nil || msg.ResponseMeta.FinishReason != "length" || msg.ResponseMeta.Usage.TotalTokens != 14 {
+		t.Errorf("ResponseMeta = %+v", msg.ResponseMeta)
+	}
+}
+
+func TestGeminiChatModel_HTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error":{"code":429,"message":"Resource has been exhausted","status":"RESOURCE_EXHAUSTED"}}`))
+	}))
+	defer server.Close()
+
+	cm, _ := NewGeminiChatModel(&Config{Type: ProviderGemini, BaseURL: server.URL, APIKey: "k", Model: "gemini"})
+	_, err := cm.Stream(context.Background(), []*schema.Message{schema.UserMessage("hi")})
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests || apiErr.Message != "Resource has been exhausted" {
+		t.Errorf("Stream() error = %v", err)
+	}
+}
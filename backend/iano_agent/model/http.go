@@ -0,0 +1,219 @@
+package model
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// defaultHTTPTimeout 原生 HTTP 模型客户端的默认超时时间
+const defaultHTTPTimeout = 5 * time.Minute
+
+// APIError 模型服务返回的 HTTP 错误
+type APIError struct {
+	Provider   ProviderType // 提供商类型
+	StatusCode int          // HTTP 状态码
+	Message    string       // 错误信息
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s API 错误 (HTTP %d): %s", e.Provider, e.StatusCode, e.Message)
+}
+
+// newHTTPClient 根据 Extra 中的 timeout（秒）创建 HTTP 客户端
+func newHTTPClient(config *Config) *http.Client {
+	timeout := defaultHTTPTimeout
+	if seconds := extraInt(config.Extra, "timeout"); seconds > 0 {
+		timeout = time.Duration(seconds) * time.Second
+	}
+	return &http.Client{Timeout: timeout}
+}
+
+// postJSON 发送 JSON 请求，非 2xx 响应转换为 APIError
+func postJSON(ctx context.Context, client *http.Client, provider ProviderType, url string, headers map[string]string, body interface{}) (*http.Response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("序列化请求失败: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		return nil, readAPIError(provider, resp)
+	}
+
+	return resp, nil
+}
+
+// readAPIError 读取错误响应体
+func readAPIError(provider ProviderType, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	message := strings.TrimSpace(string(body))
+	var payload struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal(body, &payload) == nil && len(payload.Error) > 0 {
+		var detail struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(payload.Error, &detail) == nil && detail.Message != "" {
+			message = detail.Message
+		} else {
+			var text string
+			if json.Unmarshal(payload.Error, &text) == nil && text != "" {
+				message = text
+			}
+		}
+	}
+	if message == "" {
+		message = resp.Status
+	}
+
+	return &APIError{Provider: provider, StatusCode: resp.StatusCode, Message: message}
+}
+
+// readSSE 逐条读取 SSE 事件，fn 返回 io.EOF 时提前结束
+func readSSE(r io.Reader, fn func(event string, data []byte) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 8*1024*1024)
+
+	event := ""
+	var data bytes.Buffer
+
+	dispatch := func() error {
+		if data.Len() == 0 {
+			event = ""
+			return nil
+		}
+		err := fn(event, bytes.TrimSuffix(data.Bytes(), []byte("\n")))
+		event = ""
+		data.Reset()
+		return err
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if err := dispatch(); err != nil {
+				if errors.Is(err, io.EOF) {
+					return nil
+				}
+				return err
+			}
+		case strings.HasPrefix(line, ":"):
+			// 注释行
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+			data.WriteByte('\n')
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if err := dispatch(); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// extraString 读取 Extra 中的字符串参数
+func extraString(extra map[string]interface{}, key string) string {
+	if extra == nil {
+		return ""
+	}
+	if v, ok := extra[key].(string); ok {
+		return v
+	}
+	return ""
+}
+
+// extraInt 读取 Extra 中的整数参数，兼容 JSON 解码得到的 float64
+func extraInt(extra map[string]interface{}, key string) int {
+	if extra == nil {
+		return 0
+	}
+	switch v := extra[key].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	case float32:
+		return int(v)
+	case json.Number:
+		n, _ := v.Int64()
+		return int(n)
+	}
+	return 0
+}
+
+// toolSchema 将工具参数定义转换为 JSON Schema
+func toolSchema(info *schema.ToolInfo) (json.RawMessage, error) {
+	if info.ParamsOneOf == nil {
+		return json.RawMessage(`{"type":"object","properties":{}}`), nil
+	}
+
+	js, err := info.ParamsOneOf.ToJSONSchema()
+	if err != nil {
+		return nil, fmt.Errorf("转换工具 %s 参数失败: %w", info.Name, err)
+	}
+	if js == nil {
+		return json.RawMessage(`{"type":"object","properties":{}}`), nil
+	}
+
+	data, err := json.Marshal(js)
+	if err != nil {
+		return nil, fmt.Errorf("序列化工具 %s 参数失败: %w", info.Name, err)
+	}
+	return data, nil
+}
+
+// concatStream 读取完整的流式输出并合并为一条消息
+func concatStream(sr *schema.StreamReader[*schema.Message]) (*schema.Message, error) {
+	defer sr.Close()
+
+	chunks := make([]*schema.Message, 0)
+	for {
+		msg, err := sr.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, msg)
+	}
+
+	if len(chunks) == 0 {
+		return nil, fmt.Errorf("模型未返回任何内容")
+	}
+	return schema.ConcatMessages(chunks)
+}
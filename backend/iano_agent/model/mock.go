@@ -0,0 +1,409 @@
+package model
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// init 注册 Mock 工厂
+func init() {
+	GlobalRegistry.Register(ProviderMock, &MockFactory{})
+}
+
+// CassetteMode Mock 模型的工作模式
+type CassetteMode string
+
+const (
+	CassetteRecord CassetteMode = "record" // 调用真实模型并写入录制文件
+	CassetteReplay CassetteMode = "replay" // 从录制文件回放，不访问网络
+)
+
+// 回放时的请求匹配方式
+const (
+	CassetteMatchRequest  = "request"  // 按请求内容匹配
+	CassetteMatchSequence = "sequence" // 按调用顺序依次回放
+)
+
+// MockFactory 录制/回放模型工厂
+//
+// Extra 支持的参数：
+//   - cassette: 录制文件路径，必填
+//   - mode: record 或 replay，默认 replay
+//   - upstream: 录制模式下实际调用的提供商类型，BaseURL、APIKey、Model 等沿用当前配置
+//   - match: 回放时的匹配方式 request 或 sequence，默认 request
+type MockFactory struct{}
+
+// Create 创建录制/回放模型实例
+func (f *MockFactory) Create(config *Config) (model.ToolCallingChatModel, error) {
+	path := extraString(config.Extra, "cassette")
+	if path == "" {
+		return nil, fmt.Errorf("创建 Mock 模型失败: 未配置 cassette 录制文件路径")
+	}
+
+	mode := CassetteMode(extraString(config.Extra, "mode"))
+	switch mode {
+	case "", CassetteReplay:
+		return NewReplayChatModel(path, extraString(config.Extra, "match"))
+	case CassetteRecord:
+		upstreamType := ProviderType(extraString(config.Extra, "upstream"))
+		if upstreamType == "" || upstreamType == ProviderMock {
+			return nil, fmt.Errorf("创建 Mock 模型失败: 录制模式需要通过 upstream 指定真实的提供商类型")
+		}
+		upstreamConfig := *config
+		upstreamConfig.Type = upstreamType
+		upstream, err := CreateModel(&upstreamConfig)
+		if err != nil {
+			return nil, fmt.Errorf("创建录制模型的上游模型失败: %w", err)
+		}
+		return NewRecordChatModel(upstream, path)
+	default:
+		return nil, fmt.Errorf("创建 Mock 模型失败: 不支持的模式 %s", mode)
+	}
+}
+
+// Support 是否支持该提供商
+func (f *MockFactory) Support(providerType ProviderType) bool {
+	return providerType == ProviderMock
+}
+
+// CassetteRequest 录制的请求，只保留参与匹配的字段
+type CassetteRequest struct {
+	Messages []*schema.Message `json:"messages"`
+	Tools    []string          `json:"tools,omitempty"`
+}
+
+// CassetteInteraction 一次模型调用的录制结果
+type CassetteInteraction struct {
+	Key      string            `json:"key"`             // 请求内容的哈希
+	Request  *CassetteRequest  `json:"request"`         // 请求内容，便于阅读和排查
+	Stream   bool              `json:"stream"`          // 是否为流式调用
+	Response []*schema.Message `json:"response"`        // 流式调用的每个分片，非流式调用只有一条
+	Error    string            `json:"error,omitempty"` // 调用失败或流中断时的错误
+}
+
+// Cassette 录制文件，同一路径和模式在进程内共享一份
+type Cassette struct {
+	Interactions []*CassetteInteraction `json:"interactions"`
+
+	path string
+	mu   sync.Mutex
+	used []bool
+	next int
+}
+
+var (
+	cassettesMu sync.Mutex
+	cassettes   = make(map[string]*Cassette)
+)
+
+// openCassette 打开录制文件，录制模式下首次打开时清空已有内容
+func openCassette(path string, mode CassetteMode) (*Cassette, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("解析录制文件路径失败: %w", err)
+	}
+
+	cassettesMu.Lock()
+	defer cassettesMu.Unlock()
+
+	key := string(mode) + ":" + abs
+	if c, ok := cassettes[key]; ok {
+		return c, nil
+	}
+
+	c := &Cassette{path: abs}
+	if mode == CassetteReplay {
+		data, err := os.ReadFile(abs)
+		if err != nil {
+			return nil, fmt.Errorf("读取录制文件失败: %w", err)
+		}
+		if err := json.Unmarshal(data, c); err != nil {
+			return nil, fmt.Errorf("解析录制文件失败: %w", err)
+		}
+		c.used = make([]bool, len(c.Interactions))
+	}
+
+	cassettes[key] = c
+	return c, nil
+}
+
+// add 追加一次调用并写回文件
+func (c *Cassette) add(interaction *CassetteInteraction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Interactions = append(c.Interactions, interaction)
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化录制文件失败: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("创建录制文件目录失败: %w", err)
+	}
+	if err := os.WriteFile(c.path, data, 0644); err != nil {
+		return fmt.Errorf("写入录制文件失败: %w", err)
+	}
+	return nil
+}
+
+// match 查找与请求匹配的调用，优先返回尚未回放过的，全部回放过后重复最后一个
+func (c *Cassette) match(key string) *CassetteInteraction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	last := -1
+	for i, interaction := range c.Interactions {
+		if interaction.Key != key {
+			continue
+		}
+		if !c.used[i] {
+			c.used[i] = true
+			return interaction
+		}
+		last = i
+	}
+	if last < 0 {
+		return nil
+	}
+	return c.Interactions[last]
+}
+
+// sequence 按顺序返回下一次调用
+func (c *Cassette) sequence() *CassetteInteraction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.next >= len(c.Interactions) {
+		return nil
+	}
+	interaction := c.Interactions[c.next]
+	c.next++
+	return interaction
+}
+
+// MockChatModel 录制/回放模型
+//
+// 录制模式下透传给上游模型，并把每次请求和响应写入录制文件；
+// 回放模式下根据请求从录制文件中取出响应，用于不依赖网络的确定性测试。
+type MockChatModel struct {
+	mode     CassetteMode
+	match    string
+	cassette *Cassette
+	upstream model.ToolCallingChatModel
+	tools    []string
+}
+
+// NewRecordChatModel 创建录制模型，调用结果写入 path
+func NewRecordChatModel(upstream model.ToolCallingChatModel, path string) (*MockChatModel, error) {
+	cassette, err := openCassette(path, CassetteRecord)
+	if err != nil {
+		return nil, err
+	}
+	return &MockChatModel{mode: CassetteRecord, cassette: cassette, upstream: upstream}, nil
+}
+
+// NewReplayChatModel 创建回放模型，match 为空时按请求内容匹配
+func NewReplayChatModel(path string, match string) (*MockChatModel, error) {
+	switch match {
+	case "":
+		match = CassetteMatchRequest
+	case CassetteMatchRequest, CassetteMatchSequence:
+	default:
+		return nil, fmt.Errorf("不支持的匹配方式: %s", match)
+	}
+
+	cassette, err := openCassette(path, CassetteReplay)
+	if err != nil {
+		return nil, err
+	}
+	return &MockChatModel{mode: CassetteReplay, match: match, cassette: cassette}, nil
+}
+
+// Generate 生成完整回复
+func (m *MockChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	request := m.request(input)
+
+	if m.mode == CassetteReplay {
+		interaction, err := m.replay(request)
+		if err != nil {
+			return nil, err
+		}
+		if interaction.Error != "" {
+			return nil, errors.New(interaction.Error)
+		}
+		return schema.ConcatMessages(interaction.Response)
+	}
+
+	// 回调由外层组件统一触发，上游模型不再重复上报
+	msg, err := m.upstream.Generate(callbacks.InitCallbacks(ctx, nil), input, opts...)
+	interaction := &CassetteInteraction{Key: request.key(), Request: request}
+	if err != nil {
+		interaction.Error = err.Error()
+	} else {
+		interaction.Response = []*schema.Message{msg}
+	}
+	if saveErr := m.cassette.add(interaction); saveErr != nil {
+		return nil, saveErr
+	}
+	return msg, err
+}
+
+// Stream 流式生成回复
+func (m *MockChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	request := m.request(input)
+
+	if m.mode == CassetteReplay {
+		interaction, err := m.replay(request)
+		if err != nil {
+			return nil, err
+		}
+		if interaction.Error != "" && len(interaction.Response) == 0 {
+			return nil, errors.New(interaction.Error)
+		}
+		return replayStream(interaction), nil
+	}
+
+	interaction := &CassetteInteraction{Key: request.key(), Request: request, Stream: true}
+	sr, err := m.upstream.Stream(callbacks.InitCallbacks(ctx, nil), input, opts...)
+	if err != nil {
+		interaction.Error = err.Error()
+		if saveErr := m.cassette.add(interaction); saveErr != nil {
+			return nil, saveErr
+		}
+		return nil, err
+	}
+
+	reader, writer := schema.Pipe[*schema.Message](1)
+	go func() {
+		defer writer.Close()
+		defer sr.Close()
+
+		for {
+			chunk, err := sr.Recv()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				interaction.Error = err.Error()
+				writer.Send(nil, err)
+				break
+			}
+			interaction.Response = append(interaction.Response, chunk)
+			if closed := writer.Send(chunk, nil); closed {
+				break
+			}
+		}
+
+		if err := m.cassette.add(interaction); err != nil {
+			writer.Send(nil, err)
+		}
+	}()
+	return reader, nil
+}
+
+// WithTools 绑定工具，返回新的模型实例，录制文件与原实例共享
+func (m *MockChatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	bound := *m
+	bound.tools = make([]string, 0, len(tools))
+	for _, t := range tools {
+		bound.tools = append(bound.tools, t.Name)
+	}
+	sort.Strings(bound.tools)
+
+	if m.upstream != nil {
+		upstream, err := m.upstream.WithTools(tools)
+		if err != nil {
+			return nil, err
+		}
+		bound.upstream = upstream
+	}
+	return &bound, nil
+}
+
+// GetType 组件类型
+func (m *MockChatModel) GetType() string {
+	return "Mock"
+}
+
+// replay 从录制文件中取出与请求对应的调用
+func (m *MockChatModel) replay(request *CassetteRequest) (*CassetteInteraction, error) {
+	var interaction *CassetteInteraction
+	if m.match == CassetteMatchSequence {
+		interaction = m.cassette.sequence()
+	} else {
+		interaction = m.cassette.match(request.key())
+	}
+	if interaction != nil {
+		return interaction, nil
+	}
+
+	last := ""
+	if n := len(request.Messages); n > 0 {
+		last = truncateRunes(request.Messages[n-1].Content, 80)
+	}
+	return nil, fmt.Errorf("录制文件 %s 中没有匹配的请求（最后一条消息：%q）", m.cassette.path, last)
+}
+
+// request 提取请求中参与匹配的字段，忽略用量、响应元信息等每次调用都会变化的内容
+func (m *MockChatModel) request(input []*schema.Message) *CassetteRequest {
+	messages := make([]*schema.Message, 0, len(input))
+	for _, msg := range input {
+		toolCalls := make([]schema.ToolCall, 0, len(msg.ToolCalls))
+		for _, tc := range msg.ToolCalls {
+			toolCalls = append(toolCalls, schema.ToolCall{ID: tc.ID, Function: tc.Function})
+		}
+		if len(toolCalls) == 0 {
+			toolCalls = nil
+		}
+		messages = append(messages, &schema.Message{
+			Role:                  msg.Role,
+			Content:               msg.Content,
+			UserInputMultiContent: msg.UserInputMultiContent,
+			ToolCalls:             toolCalls,
+			ToolCallID:            msg.ToolCallID,
+		})
+	}
+	return &CassetteRequest{Messages: messages, Tools: m.tools}
+}
+
+// key 请求内容的哈希
+func (r *CassetteRequest) key() string {
+	data, _ := json.Marshal(r)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// replayStream 按录制的分片回放流式响应，流中断的错误在最后返回
+func replayStream(interaction *CassetteInteraction) *schema.StreamReader[*schema.Message] {
+	reader, writer := schema.Pipe[*schema.Message](len(interaction.Response) + 1)
+	for _, chunk := range interaction.Response {
+		writer.Send(chunk, nil)
+	}
+	if interaction.Error != "" {
+		writer.Send(nil, errors.New(interaction.Error))
+	}
+	writer.Close()
+	return reader
+}
+
+// truncateRunes 截断过长的文本用于错误信息
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "..."
+}
@@ -0,0 +1,125 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// readStream 读取全部分片并合并为一条消息
+func readStream(t *testing.T, sr *schema.StreamReader[*schema.Message]) *schema.Message {
+	t.Helper()
+	defer sr.Close()
+
+	var chunks []*schema.Message
+	for {
+		chunk, err := sr.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv() error = %v", err)
+		}
+		chunks = append(chunks, chunk)
+	}
+	msg, err := schema.ConcatMessages(chunks)
+	if err != nil {
+		t.Fatalf("ConcatMessages() error = %v", err)
+	}
+	return msg
+}
+
+func TestMockChatModel_RecordReplay(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Write([]byte(`{"message":{"role":"assistant","content":"好的，"},"done":false}
+{"message":{"role":"assistant","content":"先看看目录"},"done":false}
+{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"file_list","arguments":{"path":"."}}}]},"done":false}
+{"message":{"role":"assistant","content":""},"done":true,"done_reason":"stop","prompt_eval_count":30,"eval_count":12}
+`))
+	}))
+
+	path := filepath.Join(t.TempDir(), "cassettes", "chat.json")
+	input := []*schema.Message{schema.SystemMessage("你是一个智能助手。"), schema.UserMessage("列出文件")}
+	ctx := context.Background()
+
+	recorder, err := CreateModel(&Config{
+		Type:    ProviderMock,
+		BaseURL: server.URL + "/v1",
+		Model:   "qwen3:8b",
+		Extra:   map[string]interface{}{"mode": "record", "upstream": "ollama", "cassette": path},
+	})
+	if err != nil {
+		t.Fatalf("CreateModel(record) error = %v", err)
+	}
+	sr, err := recorder.Stream(ctx, input)
+	if err != nil {
+		t.Fatalf("Stream(record) error = %v", err)
+	}
+	recorded := readStream(t, sr)
+	server.Close()
+
+	replayer, err := CreateModel(&Config{Type: ProviderMock, Model: "qwen3:8b", Extra: map[string]interface{}{"cassette": path}})
+	if err != nil {
+		t.Fatalf("CreateModel(replay) error = %v", err)
+	}
+
+	t.Run("流式回放", func(t *testing.T) {
+		sr, err := replayer.Stream(ctx, input)
+		if err != nil {
+			t.Fatalf("Stream(replay) error = %v", err)
+		}
+		got := readStream(t, sr)
+		if got.Content != "好的，先看看目录" || got.Content != recorded.Content {
+			t.Errorf("content = %q, recorded %q", got.Content, recorded.Content)
+		}
+		if len(got.ToolCalls) != 1 || got.ToolCalls[0].Function.Name != "file_list" {
+			t.Errorf("tool calls = %+v", got.ToolCalls)
+		}
+		if got.ResponseMeta == nil || got.ResponseMeta.Usage == nil || got.ResponseMeta.Usage.TotalTokens != 42 {
+			t.Errorf("response meta = %+v", got.ResponseMeta)
+		}
+	})
+
+	t.Run("非流式调用合并录制的分片", func(t *testing.T) {
+		got, err := replayer.Generate(ctx, input)
+		if err != nil {
+			t.Fatalf("Generate(replay) error = %v", err)
+		}
+		if got.Content != recorded.Content {
+			t.Errorf("content = %q, want %q", got.Content, recorded.Content)
+		}
+	})
+
+	t.Run("没有匹配的请求", func(t *testing.T) {
+		_, err := replayer.Generate(ctx, []*schema.Message{schema.UserMessage("别的问题")})
+		if err == nil {
+			t.Fatal("Generate() error = nil, want no match")
+		}
+	})
+
+	t.Run("按顺序回放", func(t *testing.T) {
+		seq, err := NewReplayChatModel(path, CassetteMatchSequence)
+		if err != nil {
+			t.Fatalf("NewReplayChatModel() error = %v", err)
+		}
+		if _, err := seq.Generate(ctx, []*schema.Message{schema.UserMessage("别的问题")}); err != nil {
+			t.Errorf("Generate() error = %v", err)
+		}
+		if _, err := seq.Generate(ctx, input); err == nil {
+			t.Error("Generate() error = nil, want cassette exhausted")
+		}
+	})
+
+	if requests != 1 {
+		t.Errorf("upstream requests = %d, want 1", requests)
+	}
+}
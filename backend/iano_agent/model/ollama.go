@@ -0,0 +1,425 @@
+package model
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+const ollamaDefaultBaseURL = "http://localhost:11434"
+
+// init 注册 Ollama 工厂
+func init() {
+	GlobalRegistry.Register(ProviderOllama, &OllamaFactory{})
+}
+
+// OllamaFactory Ollama 本地模型工厂
+//
+// Extra 支持的参数：
+//   - think: 是否开启思考模式（支持的模型会返回 thinking 内容）
+//   - num_ctx: 上下文窗口大小
+//   - keep_alive: 模型在内存中保留的时长，例如 "5m"
+//   - timeout: 请求超时时间（秒）
+type OllamaFactory struct{}
+
+// Create 创建 Ollama 模型实例
+func (f *OllamaFactory) Create(config *Config) (model.ToolCallingChatModel, error) {
+	return NewOllamaChatModel(config)
+}
+
+// Support 是否支持该提供商
+func (f *OllamaFactory) Support(providerType ProviderType) bool {
+	return providerType == ProviderOllama
+}
+
+// ListModels 列出本地已安装的模型
+func (f *OllamaFactory) ListModels(ctx context.Context, config *Config) ([]ModelInfo, error) {
+	return ListOllamaModels(ctx, config.BaseURL)
+}
+
+// OllamaChatModel 基于 /api/chat 原生协议的 Ollama 模型
+type OllamaChatModel struct {
+	client      *http.Client
+	baseURL     string
+	model       string
+	temperature float32
+	maxTokens   int
+	think       bool
+	numCtx      int
+	keepAlive   string
+	tools       []ollamaTool
+}
+
+// NewOllamaChatModel 创建 Ollama 模型
+func NewOllamaChatModel(config *Config) (*OllamaChatModel, error) {
+	if config.Model == "" {
+		return nil, fmt.Errorf("创建 Ollama 模型失败: 模型名称不能为空")
+	}
+
+	think, _ := config.Extra["think"].(bool)
+
+	return &OllamaChatModel{
+		client:      newHTTPClient(config),
+		baseURL:     ollamaBaseURL(config.BaseURL),
+		model:       config.Model,
+		temperature: config.Temperature,
+		maxTokens:   config.MaxTokens,
+		think:       think,
+		numCtx:      extraInt(config.Extra, "num_ctx"),
+		keepAlive:   extraString(config.Extra, "keep_alive"),
+	}, nil
+}
+
+// ollamaBaseURL 规范化 Ollama 地址，兼容填写了 OpenAI 兼容地址 /v1 的情况
+func ollamaBaseURL(baseURL string) string {
+	baseURL = strings.TrimRight(baseURL, "/")
+	if baseURL == "" {
+		return ollamaDefaultBaseURL
+	}
+	baseURL = strings.TrimSuffix(baseURL, "/v1")
+	return strings.TrimSuffix(baseURL, "/api")
+}
+
+type ollamaTool struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name        string          `json:"name"`
+		Description string          `json:"description,omitempty"`
+		Parameters  json.RawMessage `json:"parameters"`
+	} `json:"function"`
+}
+
+type ollamaToolCall struct {
+	Function struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"function"`
+}
+
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	Thinking  string           `json:"thinking,omitempty"`
+	Images    []string         `json:"images,omitempty"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"`
+}
+
+type ollamaRequest struct {
+	Model     string                 `json:"model"`
+	Messages  []ollamaMessage        `json:"messages"`
+	Tools     []ollamaTool           `json:"tools,omitempty"`
+	Stream    bool                   `json:"stream"`
+	Think     bool                   `json:"think,omitempty"`
+	KeepAlive string                 `json:"keep_alive,omitempty"`
+	Options   map[string]interface{} `json:"options,omitempty"`
+}
+
+type ollamaResponse struct {
+	Message         ollamaMessage `json:"message"`
+	Done            bool          `json:"done"`
+	DoneReason      string        `json:"done_reason"`
+	PromptEvalCount int           `json:"prompt_eval_count"`
+	EvalCount       int           `json:"eval_count"`
+	Error           string        `json:"error"`
+}
+
+// Generate 生成完整回复
+func (m *OllamaChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	sr, err := m.Stream(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return concatStream(sr)
+}
+
+// Stream 流式生成回复
+func (m *OllamaChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	req, err := m.buildRequest(input, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := postJSON(ctx, m.client, ProviderOllama, m.baseURL+"/api/chat", nil, req)
+	if err != nil {
+		return nil, err
+	}
+
+	sr, sw := schema.Pipe[*schema.Message](16)
+	go func() {
+		defer resp.Body.Close()
+		defer sw.Close()
+
+		if err := m.readStream(resp.Body, sw); err != nil {
+			sw.Send(nil, err)
+		}
+	}()
+
+	return sr, nil
+}
+
+// WithTools 绑定工具，返回新的模型实例
+func (m *OllamaChatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	converted, err := toOllamaTools(tools)
+	if err != nil {
+		return nil, err
+	}
+
+	cm := *m
+	cm.tools = converted
+	return &cm, nil
+}
+
+// GetType 组件类型
+func (m *OllamaChatModel) GetType() string {
+	return "Ollama"
+}
+
+func (m *OllamaChatModel) buildRequest(input []*schema.Message, opts ...model.Option) (*ollamaRequest, error) {
+	temperature := m.temperature
+	maxTokens := m.maxTokens
+	modelName := m.model
+	options := model.GetCommonOptions(&model.Options{
+		Temperature: &temperature,
+		MaxTokens:   &maxTokens,
+		Model:       &modelName,
+	}, opts...)
+
+	req := &ollamaRequest{
+		Model:     *options.Model,
+		Messages:  toOllamaMessages(input),
+		Tools:     m.tools,
+		Stream:    true,
+		Think:     m.think,
+		KeepAlive: m.keepAlive,
+		Options:   map[string]interface{}{},
+	}
+
+	if len(options.Tools) > 0 {
+		tools, err := toOllamaTools(options.Tools)
+		if err != nil {
+			return nil, err
+		}
+		req.Tools = tools
+	}
+
+	if options.Temperature != nil && *options.Temperature > 0 {
+		req.Options["temperature"] = *options.Temperature
+	}
+	if options.MaxTokens != nil && *options.MaxTokens > 0 {
+		req.Options["num_predict"] = *options.MaxTokens
+	}
+	if options.TopP != nil {
+		req.Options["top_p"] = *options.TopP
+	}
+	if len(options.Stop) > 0 {
+		req.Options["stop"] = options.Stop
+	}
+	if m.numCtx > 0 {
+		req.Options["num_ctx"] = m.numCtx
+	}
+
+	return req, nil
+}
+
+// readStream 解析 /api/chat 的 NDJSON 流
+func (m *OllamaChatModel) readStream(body io.Reader, sw *schema.StreamWriter[*schema.Message]) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 8*1024*1024)
+
+	toolIndex := 0
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var chunk ollamaResponse
+		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+			return fmt.Errorf("解析 Ollama 响应失败: %w", err)
+		}
+		if chunk.Error != "" {
+			return &APIError{Provider: ProviderOllama, StatusCode: http.StatusInternalServerError, Message: chunk.Error}
+		}
+
+		msg := &schema.Message{
+			Role:             schema.Assistant,
+			Content:          chunk.Message.Content,
+			ReasoningContent: chunk.Message.Thinking,
+		}
+
+		// Ollama 一次性返回完整的工具调用且没有调用 ID，这里按顺序生成
+		for _, tc := range chunk.Message.ToolCalls {
+			idx := toolIndex
+			toolIndex++
+			args := string(tc.Function.Arguments)
+			if args == "" || args == "null" {
+				args = "{}"
+			}
+			msg.ToolCalls = append(msg.ToolCalls, schema.ToolCall{
+				Index:    &idx,
+				ID:       fmt.Sprintf("call_%d_%d", time.Now().UnixNano(), idx),
+				Type:     "function",
+				Function: schema.FunctionCall{Name: tc.Function.Name, Arguments: args},
+			})
+		}
+
+		if chunk.Done {
+			finishReason := chunk.DoneReason
+			if toolIndex > 0 {
+				finishReason = "tool_calls"
+			}
+			msg.ResponseMeta = &schema.ResponseMeta{
+				FinishReason: finishReason,
+				Usage: &schema.TokenUsage{
+					PromptTokens:     chunk.PromptEvalCount,
+					CompletionTokens: chunk.EvalCount,
+					TotalTokens:      chunk.PromptEvalCount + chunk.EvalCount,
+				},
+			}
+		}
+
+		if sw.Send(msg, nil) {
+			return nil
+		}
+		if chunk.Done {
+			return nil
+		}
+	}
+
+	return scanner.Err()
+}
+
+// toOllamaTools 转换工具定义
+func toOllamaTools(tools []*schema.ToolInfo) ([]ollamaTool, error) {
+	result := make([]ollamaTool, 0, len(tools))
+	for _, t := range tools {
+		params, err := toolSchema(t)
+		if err != nil {
+			return nil, err
+		}
+		var ot ollamaTool
+		ot.Type = "function"
+		ot.Function.Name = t.Name
+		ot.Function.Description = t.Desc
+		ot.Function.Parameters = params
+		result = append(result, ot)
+	}
+	return result, nil
+}
+
+// toOllamaMessages 转换消息，工具结果通过调用 ID 找回工具名称
+func toOllamaMessages(input []*schema.Message) []ollamaMessage {
+	toolNames := make(map[string]string)
+	messages := make([]ollamaMessage, 0, len(input))
+
+	for _, msg := range input {
+		om := ollamaMessage{
+			Role:    string(msg.Role),
+			Content: msg.Content,
+		}
+
+		switch msg.Role {
+		case schema.User:
+			for _, part := range msg.UserInputMultiContent {
+				switch part.Type {
+				case schema.ChatMessagePartTypeText:
+					if part.Text != "" {
+						if om.Content != "" {
+							om.Content += "\n"
+						}
+						om.Content += part.Text
+					}
+				case schema.ChatMessagePartTypeImageURL:
+					if part.Image != nil && part.Image.Base64Data != nil {
+						om.Images = append(om.Images, *part.Image.Base64Data)
+					}
+				}
+			}
+		case schema.Assistant:
+			for _, tc := range msg.ToolCalls {
+				toolNames[tc.ID] = tc.Function.Name
+				args := tc.Function.Arguments
+				if !json.Valid([]byte(args)) {
+					args = "{}"
+				}
+				var otc ollamaToolCall
+				otc.Function.Name = tc.Function.Name
+				otc.Function.Arguments = json.RawMessage(args)
+				om.ToolCalls = append(om.ToolCalls, otc)
+			}
+		case schema.Tool:
+			om.ToolName = msg.ToolName
+			if om.ToolName == "" {
+				om.ToolName = toolNames[msg.ToolCallID]
+			}
+		}
+
+		messages = append(messages, om)
+	}
+
+	return messages
+}
+
+// ListOllamaModels 通过 /api/tags 获取本地已安装的模型列表
+func ListOllamaModels(ctx context.Context, baseURL string) ([]ModelInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ollamaBaseURL(baseURL)+"/api/tags", nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("连接 Ollama 失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, readAPIError(ProviderOllama, resp)
+	}
+
+	var result struct {
+		Models []struct {
+			Name       string    `json:"name"`
+			Model      string    `json:"model"`
+			ModifiedAt time.Time `json:"modified_at"`
+			Size       int64     `json:"size"`
+			Details    struct {
+				Family            string `json:"family"`
+				ParameterSize     string `json:"parameter_size"`
+				QuantizationLevel string `json:"quantization_level"`
+			} `json:"details"`
+		} `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("解析模型列表失败: %w", err)
+	}
+
+	models := make([]ModelInfo, 0, len(result.Models))
+	for _, m := range result.Models {
+		id := m.Model
+		if id == "" {
+			id = m.Name
+		}
+		models = append(models, ModelInfo{
+			ID:            id,
+			Name:          m.Name,
+			Family:        m.Details.Family,
+			ParameterSize: m.Details.ParameterSize,
+			Quantization:  m.Details.QuantizationLevel,
+			Size:          m.Size,
+			ModifiedAt:    m.ModifiedAt,
+		})
+	}
+
+	return models, nil
+}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestOllamaChatModel_Stream(t *testing.T) {
+	var gotReq ollamaRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/chat":
+			json.NewDecoder(r.Body).Decode(&gotReq)
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.Write([]byte(`{"message":{"role":"assistant","content":"","thinking":"想一想"},"done":false}
+{"message":{"role":"assistant","content":"好的"},"done":false}
+{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"file_read","arguments":{"path":"a.txt"}}}]},"done":false}
+{"message":{"role":"assistant","content":""},"done":true,"done_reason":"stop","prompt_eval_count":30,"eval_count":12}
+`))
+		case "/api/tags":
+			w.Write([]byte(`{"models":[{"name":"qwen3:8b","model":"qwen3:8b","size":5200000000,"details":{"family":"qwen3","parameter_size":"8.2B","quantization_level":"Q4_K_M"}}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	cm, err := CreateModel(&Config{Type: ProviderOllama, BaseURL: server.URL + "/v1", Model: "qwen3:8b", MaxTokens: 256})
+	if err != nil {
+		t.Fatalf("CreateModel() error = %v", err)
+	}
+
+	input := []*schema.Message{
+		schema.UserMessage("读取文件"),
+		schema.AssistantMessage("", []schema.ToolCall{{ID: "call_1", Function: schema.FunctionCall{Name: "file_list", Arguments: `{}`}}}),
+		schema.ToolMessage("a.txt", "call_1"),
+	}
+	msg, err := cm.Generate(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	t.Run("请求格式", func(t *testing.T) {
+		if !gotReq.Stream || gotReq.Model != "qwen3:8b" {
+			t.Errorf("request = %+v", gotReq)
+		}
+		if gotReq.Options["num_predict"] != float64(256) {
+			t.Errorf("num_predict = %v", gotReq.Options["num_predict"])
+		}
+		if gotReq.Messages[2].ToolName != "file_list" {
+			t.Errorf("tool_name = %q, want file_list", gotReq.Messages[2].ToolName)
+		}
+	})
+
+	t.Run("响应解析", func(t *testing.T) {
+		if msg.Content != "好的" || msg.ReasoningContent != "想一想" {
+			t.Errorf("msg = %+v", msg)
+		}
+		if len(msg.ToolCalls) != 1 || msg.ToolCalls[0].Function.Arguments != `{"path":"a.txt"}` || msg.ToolCalls[0].ID == "" {
+			t.Errorf("ToolCalls = %+v", msg.ToolCalls)
+		}
+		if msg.ResponseMeta.FinishReason != "tool_calls" || msg.ResponseMeta.Usage.TotalTokens != 42 {
+			t.Errorf("ResponseMeta = %+v", msg.ResponseMeta)
+		}
+	})
+
+	t.Run("模型发现", func(t *testing.T) {
+		models, err := ListModels(context.Background(), &Config{Type: ProviderOllama, BaseURL: server.URL})
+		if err != nil {
+			t.Fatalf("ListModels() error = %v", err)
+		}
+		if len(models) != 1 || models[0].ID != "qwen3:8b" || models[0].ParameterSize != "8.2B" {
+			t.Errorf("models = %+v", models)
+		}
+	})
+}
@@ -1,7 +1,9 @@
 package model
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/cloudwego/eino/components/model"
 )
@@ -16,6 +18,8 @@ const (
 	ProviderOllama   ProviderType = "ollama"
 	ProviderAzure    ProviderType = "azure"
 	ProviderDeepSeek ProviderType = "deepseek"
+	ProviderMock     ProviderType = "mock"   // 录制/回放模型，用于离线测试
+	ProviderScript   ProviderType = "script" // 按规则回复的模型，用于端到端测试
 )
 
 // Config 模型配置
@@ -44,15 +48,28 @@ func (c *Config) Validate() error {
 	if c.Model == "" {
 		return fmt.Errorf("模型名称不能为空")
 	}
+	// Mock 和脚本模型不访问网络，Mock 录制时由上游模型校验
+	if c.Type == ProviderMock || c.Type == ProviderScript {
+		return nil
+	}
 	if c.APIKey == "" && c.Type != ProviderOllama {
 		return fmt.Errorf("API Key 不能为空")
 	}
-	if c.BaseURL == "" {
+	if c.BaseURL == "" && !c.Type.HasDefaultBaseURL() {
 		return fmt.Errorf("BaseURL 不能为空")
 	}
 	return nil
 }
 
+// HasDefaultBaseURL 未配置 BaseURL 时是否使用官方默认地址
+func (t ProviderType) HasDefaultBaseURL() bool {
+	switch t {
+	case ProviderClaude, ProviderGemini, ProviderOllama:
+		return true
+	}
+	return false
+}
+
 // Factory 模型工厂接口
 type Factory interface {
 	// Create 创建模型实例
@@ -61,6 +78,23 @@ type Factory interface {
 	Support(providerType ProviderType) bool
 }
 
+// ModelInfo 可用模型信息
+type ModelInfo struct {
+	ID            string    `json:"id"`
+	Name          string    `json:"name"`
+	Family        string    `json:"family,omitempty"`
+	ParameterSize string    `json:"parameter_size,omitempty"`
+	Quantization  string    `json:"quantization,omitempty"`
+	Size          int64     `json:"size,omitempty"`
+	ModifiedAt    time.Time `json:"modified_at,omitempty"`
+}
+
+// ModelLister 支持模型发现的工厂实现该接口
+type ModelLister interface {
+	// ListModels 列出提供商可用的模型
+	ListModels(ctx context.Context, config *Config) ([]ModelInfo, error)
+}
+
 // Registry 模型工厂注册表
 type Registry struct {
 	factories map[ProviderType]Factory
@@ -100,3 +134,18 @@ func CreateModel(config *Config) (model.ToolCallingChatModel, error) {
 
 	return factory.Create(config)
 }
+
+// ListModels 列出提供商可用的模型
+func ListModels(ctx context.Context, config *Config) ([]ModelInfo, error) {
+	factory, ok := GlobalRegistry.Get(config.Type)
+	if !ok {
+		return nil, fmt.Errorf("不支持的模型提供商: %s", config.Type)
+	}
+
+	lister, ok := factory.(ModelLister)
+	if !ok {
+		return nil, fmt.Errorf("模型提供商 %s 不支持模型发现", config.Type)
+	}
+
+	return lister.ListModels(ctx, config)
+}
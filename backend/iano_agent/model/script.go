@@ -0,0 +1,323 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"gopkg.in/yaml.v3"
+)
+
+// init 注册脚本模型工厂
+func init() {
+	GlobalRegistry.Register(ProviderScript, &ScriptFactory{})
+}
+
+// ScriptFactory 脚本模型工厂
+//
+// Extra 支持的参数：
+//   - script: 规则文件路径，YAML 或 JSON
+//   - rules: 直接内联的规则列表，格式与规则文件中的 rules 相同
+type ScriptFactory struct{}
+
+// Create 创建脚本模型实例
+func (f *ScriptFactory) Create(config *Config) (model.ToolCallingChatModel, error) {
+	var script *Script
+	var err error
+	switch {
+	case extraString(config.Extra, "script") != "":
+		script, err = LoadScript(extraString(config.Extra, "script"))
+	case config.Extra["rules"] != nil:
+		var data []byte
+		data, err = json.Marshal(map[string]interface{}{"rules": config.Extra["rules"]})
+		if err == nil {
+			script, err = ParseScript(data)
+		}
+	default:
+		err = fmt.Errorf("未配置 script 规则文件或 rules 规则")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("创建脚本模型失败: %w", err)
+	}
+
+	return NewScriptChatModel(script), nil
+}
+
+// Support 是否支持该提供商
+func (f *ScriptFactory) Support(providerType ProviderType) bool {
+	return providerType == ProviderScript
+}
+
+// Script 脚本模型的规则
+//
+// 按顺序用最后一条用户消息匹配规则，第一个匹配的规则生效；
+// 规则中的 responses 依次对应该用户消息之后的每次模型调用，用完后重复最后一个。
+//
+//	rules:
+//	  - match: "列出.*文件"
+//	    responses:
+//	      - think: 先看看目录
+//	        tool_calls:
+//	          - name: file_list
+//	            arguments: {path: "."}
+//	      - chunks: ["目录下有", "两个文件"]
+//	  - responses:
+//	      - content: 我不明白
+type Script struct {
+	Rules []*ScriptRule `json:"rules" yaml:"rules"`
+}
+
+// ScriptRule 一条规则
+type ScriptRule struct {
+	Match     string            `json:"match" yaml:"match"`         // 匹配最后一条用户消息的正则表达式，为空时匹配任意消息
+	Responses []*ScriptResponse `json:"responses" yaml:"responses"` // 每次模型调用的回复
+
+	re *regexp.Regexp
+}
+
+// ScriptResponse 一次模型调用的回复
+type ScriptResponse struct {
+	Think     string            `json:"think" yaml:"think"`           // 以 <think> 标签包裹输出在正文之前的思考内容
+	Reasoning string            `json:"reasoning" yaml:"reasoning"`   // 推理内容，通过 ReasoningContent 输出
+	Content   string            `json:"content" yaml:"content"`       // 回复正文
+	Chunks    []string          `json:"chunks" yaml:"chunks"`         // 分片输出的回复正文，设置后忽略 content
+	ToolCalls []*ScriptToolCall `json:"tool_calls" yaml:"tool_calls"` // 工具调用，在最后一个分片中输出
+	Error     string            `json:"error" yaml:"error"`           // 返回错误而不是回复
+	Delay     time.Duration     `json:"delay" yaml:"delay"`           // 每个分片之前的等待时间，例如 50ms
+}
+
+// ScriptToolCall 回复中的工具调用
+type ScriptToolCall struct {
+	Name      string      `json:"name" yaml:"name"`           // 工具名称
+	Arguments interface{} `json:"arguments" yaml:"arguments"` // 工具参数，对象或 JSON 字符串
+}
+
+// LoadScript 从 YAML 或 JSON 文件加载规则
+func LoadScript(path string) (*Script, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取规则文件失败: %w", err)
+	}
+	return ParseScript(data)
+}
+
+// ParseScript 解析 YAML 或 JSON 格式的规则
+func ParseScript(data []byte) (*Script, error) {
+	var script Script
+	if err := yaml.Unmarshal(data, &script); err != nil {
+		return nil, fmt.Errorf("解析规则失败: %w", err)
+	}
+	if len(script.Rules) == 0 {
+		return nil, fmt.Errorf("规则不能为空")
+	}
+
+	for i, rule := range script.Rules {
+		if len(rule.Responses) == 0 {
+			return nil, fmt.Errorf("第 %d 条规则没有配置 responses", i+1)
+		}
+		re, err := regexp.Compile(rule.Match)
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 条规则的 match 无效: %w", i+1, err)
+		}
+		rule.re = re
+	}
+	return &script, nil
+}
+
+// ScriptChatModel 按规则回复的模型，用于不依赖真实模型的端到端测试
+type ScriptChatModel struct {
+	script *Script
+}
+
+// NewScriptChatModel 创建脚本模型
+func NewScriptChatModel(script *Script) *ScriptChatModel {
+	return &ScriptChatModel{script: script}
+}
+
+// Generate 生成完整回复
+func (m *ScriptChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	chunks, err := m.respond(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return schema.ConcatMessages(chunks)
+}
+
+// Stream 流式生成回复
+func (m *ScriptChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	response, err := m.match(input)
+	if err != nil {
+		return nil, err
+	}
+	if response.Error != "" {
+		return nil, errors.New(response.Error)
+	}
+
+	chunks := response.chunks(input)
+	reader, writer := schema.Pipe[*schema.Message](len(chunks))
+	go func() {
+		defer writer.Close()
+		for _, chunk := range chunks {
+			if err := sleepContext(ctx, response.Delay); err != nil {
+				writer.Send(nil, err)
+				return
+			}
+			if closed := writer.Send(chunk, nil); closed {
+				return
+			}
+		}
+	}()
+	return reader, nil
+}
+
+// WithTools 脚本模型不区分绑定的工具，直接返回自身
+func (m *ScriptChatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	return m, nil
+}
+
+// GetType 组件类型
+func (m *ScriptChatModel) GetType() string {
+	return "Script"
+}
+
+// respond 等待各分片的延迟后返回全部分片
+func (m *ScriptChatModel) respond(ctx context.Context, input []*schema.Message) ([]*schema.Message, error) {
+	response, err := m.match(input)
+	if err != nil {
+		return nil, err
+	}
+	if response.Error != "" {
+		return nil, errors.New(response.Error)
+	}
+
+	chunks := response.chunks(input)
+	if err := sleepContext(ctx, response.Delay*time.Duration(len(chunks))); err != nil {
+		return nil, err
+	}
+	return chunks, nil
+}
+
+// match 找到本次调用对应的回复
+func (m *ScriptChatModel) match(input []*schema.Message) (*ScriptResponse, error) {
+	lastUser := -1
+	for i := len(input) - 1; i >= 0; i-- {
+		if input[i].Role == schema.User {
+			lastUser = i
+			break
+		}
+	}
+
+	text := ""
+	step := 0
+	if lastUser >= 0 {
+		text = userText(input[lastUser])
+		for _, msg := range input[lastUser+1:] {
+			if msg.Role == schema.Assistant {
+				step++
+			}
+		}
+	}
+
+	for _, rule := range m.script.Rules {
+		if !rule.re.MatchString(text) {
+			continue
+		}
+		if step >= len(rule.Responses) {
+			step = len(rule.Responses) - 1
+		}
+		return rule.Responses[step], nil
+	}
+	return nil, fmt.Errorf("没有与用户消息匹配的规则: %q", truncateRunes(text, 80))
+}
+
+// chunks 将回复拆分为流式分片，最后一个分片带上工具调用和用量
+func (r *ScriptResponse) chunks(input []*schema.Message) []*schema.Message {
+	var chunks []*schema.Message
+	completion := 0
+
+	add := func(msg *schema.Message) {
+		msg.Role = schema.Assistant
+		completion += utf8.RuneCountInString(msg.Content) + utf8.RuneCountInString(msg.ReasoningContent)
+		chunks = append(chunks, msg)
+	}
+
+	if r.Reasoning != "" {
+		add(&schema.Message{ReasoningContent: r.Reasoning})
+	}
+	if r.Think != "" {
+		add(&schema.Message{Content: "<think>" + r.Think + "</think>"})
+	}
+	parts := r.Chunks
+	if len(parts) == 0 && r.Content != "" {
+		parts = []string{r.Content}
+	}
+	for _, part := range parts {
+		add(&schema.Message{Content: part})
+	}
+
+	last := &schema.Message{}
+	for i, tc := range r.ToolCalls {
+		index := i
+		last.ToolCalls = append(last.ToolCalls, schema.ToolCall{
+			Index:    &index,
+			ID:       fmt.Sprintf("call_%d_%d", len(input), i),
+			Type:     "function",
+			Function: schema.FunctionCall{Name: tc.Name, Arguments: tc.arguments()},
+		})
+	}
+	add(last)
+
+	prompt := 0
+	for _, msg := range input {
+		prompt += utf8.RuneCountInString(msg.Content)
+	}
+	finishReason := "stop"
+	if len(r.ToolCalls) > 0 {
+		finishReason = "tool_calls"
+	}
+	last.ResponseMeta = &schema.ResponseMeta{
+		FinishReason: finishReason,
+		Usage: &schema.TokenUsage{
+			PromptTokens:     prompt,
+			CompletionTokens: completion,
+			TotalTokens:      prompt + completion,
+		},
+	}
+	return chunks
+}
+
+// arguments 工具参数的 JSON 字符串
+func (tc *ScriptToolCall) arguments() string {
+	switch args := tc.Arguments.(type) {
+	case nil:
+		return "{}"
+	case string:
+		return args
+	default:
+		data, err := json.Marshal(args)
+		if err != nil {
+			return "{}"
+		}
+		return string(data)
+	}
+}
+
+// userText 用户消息的文本，多模态消息取文本部分
+func userText(msg *schema.Message) string {
+	if msg.Content != "" {
+		return msg.Content
+	}
+	for _, part := range msg.UserInputMultiContent {
+		if part.Type == schema.ChatMessagePartTypeText {
+			return part.Text
+		}
+	}
+	return ""
+}
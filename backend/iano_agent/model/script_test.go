@@ -0,0 +1,110 @@
+package model
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+const testScript = `
+rules:
+  - match: "列出.*文件"
+    responses:
+      - think: 先看看目录
+        tool_calls:
+          - name: file_list
+            arguments: {path: "."}
+      - chunks: ["目录下有", "两个文件"]
+  - match: "^出错"
+    responses:
+      - error: 服务暂时不可用
+  - responses:
+      - content: 我不明白
+`
+
+func TestScriptChatModel(t *testing.T) {
+	script, err := ParseScript([]byte(testScript))
+	if err != nil {
+		t.Fatalf("ParseScript() error = %v", err)
+	}
+	m := NewScriptChatModel(script)
+	ctx := context.Background()
+
+	t.Run("第一次调用返回工具调用", func(t *testing.T) {
+		input := []*schema.Message{schema.SystemMessage("你是一个智能助手。"), schema.UserMessage("列出当前目录的文件")}
+		sr, err := m.Stream(ctx, input)
+		if err != nil {
+			t.Fatalf("Stream() error = %v", err)
+		}
+		msg := readStream(t, sr)
+		if msg.Content != "<think>先看看目录</think>" {
+			t.Errorf("content = %q", msg.Content)
+		}
+		if len(msg.ToolCalls) != 1 || msg.ToolCalls[0].Function.Name != "file_list" || msg.ToolCalls[0].Function.Arguments != `{"path":"."}` {
+			t.Errorf("tool calls = %+v", msg.ToolCalls)
+		}
+		if msg.ResponseMeta == nil || msg.ResponseMeta.FinishReason != "tool_calls" || msg.ResponseMeta.Usage.TotalTokens == 0 {
+			t.Errorf("response meta = %+v", msg.ResponseMeta)
+		}
+	})
+
+	t.Run("工具结果之后分片回复", func(t *testing.T) {
+		input := []*schema.Message{
+			schema.UserMessage("列出当前目录的文件"),
+			schema.AssistantMessage("", []schema.ToolCall{{ID: "call_1_0", Function: schema.FunctionCall{Name: "file_list", Arguments: `{}`}}}),
+			schema.ToolMessage("a.txt\nb.txt", "call_1_0"),
+		}
+		sr, err := m.Stream(ctx, input)
+		if err != nil {
+			t.Fatalf("Stream() error = %v", err)
+		}
+		chunks := 0
+		var content strings.Builder
+		for {
+			chunk, err := sr.Recv()
+			if err != nil {
+				break
+			}
+			chunks++
+			content.WriteString(chunk.Content)
+		}
+		if content.String() != "目录下有两个文件" || chunks != 3 {
+			t.Errorf("content = %q, chunks = %d", content.String(), chunks)
+		}
+	})
+
+	t.Run("错误和默认规则", func(t *testing.T) {
+		if _, err := m.Generate(ctx, []*schema.Message{schema.UserMessage("出错了吗")}); err == nil || err.Error() != "服务暂时不可用" {
+			t.Errorf("Generate() error = %v", err)
+		}
+		msg, err := m.Generate(ctx, []*schema.Message{schema.UserMessage("你好")})
+		if err != nil || msg.Content != "我不明白" {
+			t.Errorf("Generate() = %v, %v", msg, err)
+		}
+	})
+}
+
+func TestScriptFactory_InlineRules(t *testing.T) {
+	cm, err := CreateModel(&Config{
+		Type:  ProviderScript,
+		Model: "script",
+		Extra: map[string]interface{}{
+			"rules": []interface{}{
+				map[string]interface{}{"match": "ping", "responses": []interface{}{map[string]interface{}{"content": "pong"}}},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateModel() error = %v", err)
+	}
+
+	if _, err := cm.Generate(context.Background(), []*schema.Message{schema.UserMessage("hello")}); err == nil {
+		t.Error("Generate() error = nil, want no matching rule")
+	}
+	msg, err := cm.Generate(context.Background(), []*schema.Message{schema.UserMessage("ping")})
+	if err != nil || msg.Content != "pong" {
+		t.Errorf("Generate() = %v, %v", msg, err)
+	}
+}
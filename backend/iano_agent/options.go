@@ -1,5 +1,10 @@
 package iano_agent
 
+import (
+	"iano_agent/tools"
+	"time"
+)
+
 type Option func(*Config)
 
 func WithTools(tools []Tool) Option {
@@ -34,6 +39,20 @@ func WithSystemPrompt(prompt string) Option {
 	}
 }
 
+// WithName 设置 Agent 名称，提示词模板中通过 {{.AgentName}} 引用
+func WithName(name string) Option {
+	return func(c *Config) {
+		c.Name = name
+	}
+}
+
+// WithPromptLoader 设置提示词片段的加载函数，用于渲染指令中的 include
+func WithPromptLoader(loader PromptLoader) Option {
+	return func(c *Config) {
+		c.PromptLoader = loader
+	}
+}
+
 // WithWorkDir 设置 Agent 的工作目录，限制文件操作工具的操作范围
 func WithWorkDir(workDir string) Option {
 	return func(c *Config) {
@@ -46,3 +65,157 @@ func WithAllowedCommands(commands []string) Option {
 		c.AllowedCommands = commands
 	}
 }
+
+// WithApprovalPolicy 设置 Agent 级工具审批策略
+func WithApprovalPolicy(policy ApprovalPolicy) Option {
+	return func(c *Config) {
+		c.ApprovalPolicy = policy
+	}
+}
+
+// WithToolApprovals 设置工具级审批策略，键为工具名称
+func WithToolApprovals(approvals map[string]ApprovalPolicy) Option {
+	return func(c *Config) {
+		c.ToolApprovals = approvals
+	}
+}
+
+// WithApprovalHandler 设置审批处理函数，策略为 ask 的工具调用会在执行前调用它
+func WithApprovalHandler(handler ApprovalHandler) Option {
+	return func(c *Config) {
+		c.ApprovalHandler = handler
+	}
+}
+
+// WithCheckpointHandler 设置检查点处理函数，会修改文件的工具调用在执行前调用它保存文件原内容
+func WithCheckpointHandler(handler CheckpointHandler) Option {
+	return func(c *Config) {
+		c.CheckpointHandler = handler
+	}
+}
+
+// WithArtifactSaver 设置工具产物的保存函数，命令输出被截断时用它保存完整日志供下载
+func WithArtifactSaver(saver tools.ArtifactSaver) Option {
+	return func(c *Config) {
+		c.ArtifactSaver = saver
+	}
+}
+
+// WithProcessManager 启用后台进程工具，进程记录在 session 下，会话结束时由调用方停止
+func WithProcessManager(manager *tools.ProcessManager, session string) Option {
+	return func(c *Config) {
+		c.ProcessManager = manager
+		c.ProcessSession = session
+	}
+}
+
+// WithTerminalManager 启用终端工具，终端记录在 session 下，会话结束时由调用方关闭
+func WithTerminalManager(manager *tools.TerminalManager, session string) Option {
+	return func(c *Config) {
+		c.TerminalManager = manager
+		c.TerminalSession = session
+	}
+}
+
+// WithApprovalTimeout 设置等待审批的超时时间
+func WithApprovalTimeout(timeout time.Duration) Option {
+	return func(c *Config) {
+		if timeout > 0 {
+			c.ApprovalTimeout = timeout
+		}
+	}
+}
+
+// WithMaxParallelTools 设置同时执行的工具调用数，1 表示全部串行
+func WithMaxParallelTools(n int) Option {
+	return func(c *Config) {
+		if n > 0 {
+			c.MaxParallelTools = n
+		}
+	}
+}
+
+// WithMaxToolCalls 设置单次运行最多执行的工具调用次数，用完后运行以 budget_exhausted 结束
+func WithMaxToolCalls(n int) Option {
+	return func(c *Config) {
+		if n > 0 {
+			c.MaxToolCalls = n
+		}
+	}
+}
+
+// WithTokenBudget 设置单次运行的 Token 预算，用完后运行以 budget_exhausted 结束
+func WithTokenBudget(tokens int64) Option {
+	return func(c *Config) {
+		if tokens > 0 {
+			c.TokenBudget = tokens
+		}
+	}
+}
+
+// WithRunTimeout 设置单次运行的时长上限，超时后运行以 timeout 结束
+func WithRunTimeout(timeout time.Duration) Option {
+	return func(c *Config) {
+		if timeout > 0 {
+			c.RunTimeout = timeout
+		}
+	}
+}
+
+// WithMaxDelegationDepth 设置子 Agent 的最大委派深度
+func WithMaxDelegationDepth(depth int) Option {
+	return func(c *Config) {
+		if depth > 0 {
+			c.MaxDelegationDepth = depth
+		}
+	}
+}
+
+// WithResponseSchema 设置最终答复需要满足的 JSON Schema
+func WithResponseSchema(schema *ResponseSchema) Option {
+	return func(c *Config) {
+		c.ResponseSchema = schema
+	}
+}
+
+// WithMaxSchemaRetries 设置最终答复不符合 Schema 时的重新提示次数，0 表示不重试
+func WithMaxSchemaRetries(n int) Option {
+	return func(c *Config) {
+		if n >= 0 {
+			c.MaxSchemaRetries = n
+		}
+	}
+}
+
+// WithMemory 启用长期记忆，注册 memory_save、memory_search、memory_forget 工具，
+// 并在每次运行开始时将与用户消息相关的记忆注入系统提示词
+func WithMemory(store tools.MemoryStore, scope tools.MemoryScope) Option {
+	return func(c *Config) {
+		c.MemoryStore = store
+		c.MemoryScope = scope
+	}
+}
+
+// WithMemoryRecallLimit 设置每次运行自动注入系统提示词的记忆条数，0 表示不自动注入
+func WithMemoryRecallLimit(limit int) Option {
+	return func(c *Config) {
+		if limit >= 0 {
+			c.MemoryRecallLimit = limit
+		}
+	}
+}
+
+// WithKnowledgeBases 绑定知识库并注册 kb_search 工具，检索结果作为引用记录在本次运行中
+func WithKnowledgeBases(searcher tools.KnowledgeSearcher, kbIDs ...string) Option {
+	return func(c *Config) {
+		c.KnowledgeSearcher = searcher
+		c.KnowledgeBaseIDs = kbIDs
+	}
+}
+
+// WithGitWrite 允许 git_branch 创建、切换、删除分支和 git_commit 提交，默认只能读取仓库
+func WithGitWrite(enabled bool) Option {
+	return func(c *Config) {
+		c.AllowGitWrite = enabled
+	}
+}
@@ -0,0 +1,230 @@
+package iano_agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iano_agent/tools"
+	"io"
+	"log/slog"
+	"sync"
+
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+)
+
+// DefaultMaxParallelTools 默认同时执行的工具调用数
+const DefaultMaxParallelTools = 4
+
+// toolLimiter 限制工具调用的并发
+//
+// 并发安全的工具共享读锁并受信号量限制，其余工具持有写锁独占执行。
+type toolLimiter struct {
+	sem chan struct{}
+	mu  sync.RWMutex
+}
+
+func newToolLimiter(maxParallel int) *toolLimiter {
+	if maxParallel <= 0 {
+		maxParallel = 1
+	}
+	return &toolLimiter{sem: make(chan struct{}, maxParallel)}
+}
+
+// acquire 获取执行名额，返回的函数用于释放
+func (l *toolLimiter) acquire(ctx context.Context, parallel bool) (func(), error) {
+	if !parallel {
+		l.mu.Lock()
+		return l.mu.Unlock, nil
+	}
+
+	l.mu.RLock()
+	select {
+	case l.sem <- struct{}{}:
+		return func() {
+			<-l.sem
+			l.mu.RUnlock()
+		}, nil
+	case <-ctx.Done():
+		l.mu.RUnlock()
+		return nil, ctx.Err()
+	}
+}
+
+// isParallelSafe 工具是否可以与其他工具并发执行，未注册的工具按串行处理
+func (a *Agent) isParallelSafe(name string) bool {
+	t, ok := a.toolRegistry.Get(name)
+	if !ok {
+		return false
+	}
+	return tools.IsParallelSafe(t)
+}
+
+// limiterMiddleware 限制 ToolsNode 中工具调用的并发，应位于审批中间件之后，避免等待审批时占用名额
+func (a *Agent) limiterMiddleware() compose.ToolMiddleware {
+	return compose.ToolMiddleware{
+		Invokable: func(next compose.InvokableToolEndpoint) compose.InvokableToolEndpoint {
+			return func(ctx context.Context, input *compose.ToolInput) (*compose.ToolOutput, error) {
+				release, err := a.limiter.acquire(ctx, a.isParallelSafe(input.Name))
+				if err != nil {
+					return nil, err
+				}
+				defer release()
+				return next(ctx, input)
+			}
+		},
+		Streamable: func(next compose.StreamableToolEndpoint) compose.StreamableToolEndpoint {
+			return func(ctx context.Context, input *compose.ToolInput) (*compose.StreamToolOutput, error) {
+				release, err := a.limiter.acquire(ctx, a.isParallelSafe(input.Name))
+				if err != nil {
+					return nil, err
+				}
+				output, err := next(ctx, input)
+				if err != nil {
+					release()
+					return nil, err
+				}
+				// 工具在输出流读完之前可能仍在执行，流关闭后才释放名额
+				return &compose.StreamToolOutput{Result: releaseOnClose(output.Result, release)}, nil
+			}
+		},
+	}
+}
+
+// releaseOnClose 转发 sr 的输出，读完或下游关闭后调用 release
+func releaseOnClose(sr *schema.StreamReader[string], release func()) *schema.StreamReader[string] {
+	out, w := schema.Pipe[string](1)
+	go func() {
+		defer release()
+		defer w.Close()
+		defer sr.Close()
+		for {
+			chunk, err := sr.Recv()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if closed := w.Send(chunk, err); closed || err != nil {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// toolCallChecker 判断模型输出是否交给 ReAct 的 ToolsNode 执行工具调用
+//
+// ToolsNode 同时执行一批中的全部调用，只有全部并发安全的批次交给它；包含串行工具的批次作为输出返回，
+// 由 Loop 通过 runToolCalls 按模型给出的顺序执行。先输出内容的回复按最终答复处理，与默认行为一致。
+func (a *Agent) toolCallChecker(ctx context.Context, sr *schema.StreamReader[*schema.Message]) (bool, error) {
+	defer sr.Close()
+
+	var chunks []*schema.Message
+	for {
+		msg, err := sr.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return false, err
+		}
+		if len(chunks) == 0 && len(msg.ToolCalls) == 0 {
+			if msg.Content == "" {
+				continue
+			}
+			return false, nil
+		}
+		chunks = append(chunks, msg)
+	}
+	if len(chunks) == 0 {
+		return false, nil
+	}
+
+	merged, err := schema.ConcatMessages(chunks)
+	if err != nil {
+		return false, err
+	}
+	for _, tc := range merged.ToolCalls {
+		if !a.isParallelSafe(tc.Function.Name) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// runToolCalls 执行一轮模型返回的工具调用，结果与 calls 顺序一致
+//
+// 连续的并发安全调用最多同时执行 MaxParallelTools 个；串行调用会等待之前的调用全部完成后单独执行，
+// 之后的调用也要等它完成才开始，保证有副作用的操作按模型给出的顺序生效。
+func (a *Agent) runToolCalls(ctx context.Context, calls []schema.ToolCall) ([]string, error) {
+	results := make([]string, len(calls))
+	errs := make([]error, len(calls))
+
+	maxParallel := a.config.MaxParallelTools
+	if maxParallel <= 0 {
+		maxParallel = 1
+	}
+	sem := make(chan struct{}, maxParallel)
+	var wg sync.WaitGroup
+
+	for i, tc := range calls {
+		if maxParallel == 1 || !a.isParallelSafe(tc.Function.Name) {
+			wg.Wait()
+			results[i], errs[i] = a.runToolCall(ctx, tc)
+			if errs[i] != nil {
+				return nil, errs[i]
+			}
+			continue
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return nil, ctx.Err()
+		}
+		wg.Add(1)
+		go func(i int, tc schema.ToolCall) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i], errs[i] = a.runToolCall(ctx, tc)
+		}(i, tc)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// runToolCall 审批、创建检查点并执行单个工具调用，工具自身的错误作为结果返回给模型，
+// 只有等待审批时 ctx 结束或用完工具调用预算才返回错误
+func (a *Agent) runToolCall(ctx context.Context, tc schema.ToolCall) (string, error) {
+	if err := a.useToolCall(); err != nil {
+		return "", err
+	}
+	if err := a.approveToolCall(ctx, tc.ID, tc.Function.Name, tc.Function.Arguments); err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("等待工具调用审批失败: %w", err)
+		}
+		slog.Warn("工具调用未通过审批", "id", tc.ID, "name", tc.Function.Name, "error", err.Error())
+		return err.Error(), nil
+	}
+	if err := a.checkpointToolCall(ctx, tc.ID, tc.Function.Name, tc.Function.Arguments); err != nil {
+		if ctx.Err() != nil {
+			return "", err
+		}
+		slog.Warn("工具调用未执行", "id", tc.ID, "name", tc.Function.Name, "error", err.Error())
+		return err.Error(), nil
+	}
+
+	toolResult, err := a.invokeTool(a.toolContext(ctx, tc.ID, tc.Function.Name), tc.Function.Name, tc.Function.Arguments)
+	if err != nil {
+		slog.Error("工具调用失败", "id", tc.ID, "name", tc.Function.Name, "arguments", tc.Function.Arguments, "error", err.Error())
+		toolResult = fmt.Sprintf("工具调用错误: %s", err.Error())
+	}
+	slog.Info("工具调用完成", "id", tc.ID, "name", tc.Function.Name, "arguments", tc.Function.Arguments, "result", toolResult)
+	return toolResult, nil
+}
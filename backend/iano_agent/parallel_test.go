@@ -0,0 +1,209 @@
+package iano_agent
+
+import (
+	"context"
+	"fmt"
+	agentmodel "iano_agent/model"
+	"iano_agent/tools"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// sleepTool 等待指定时间后返回参数的测试工具
+type sleepTool struct {
+	name    string
+	serial  bool
+	delay   time.Duration
+	running *int32
+	peak    *int32
+	mu      *sync.Mutex
+	order   *[]string
+}
+
+func (t *sleepTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{Name: t.name}, nil
+}
+
+func (t *sleepTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	n := atomic.AddInt32(t.running, 1)
+	defer atomic.AddInt32(t.running, -1)
+	for {
+		peak := atomic.LoadInt32(t.peak)
+		if n <= peak || atomic.CompareAndSwapInt32(t.peak, peak, n) {
+			break
+		}
+	}
+	if t.serial && n != 1 {
+		return "", context.Canceled
+	}
+
+	time.Sleep(t.delay)
+
+	t.mu.Lock()
+	*t.order = append(*t.order, argumentsInJSON)
+	t.mu.Unlock()
+	return argumentsInJSON, nil
+}
+
+func (t *sleepTool) ParallelSafe() bool {
+	return !t.serial
+}
+
+func TestAgent_runToolCalls(t *testing.T) {
+	var running, peak int32
+	var mu sync.Mutex
+	var order []string
+
+	registry := tools.NewRegistry()
+	registry.Register("fetch", &sleepTool{name: "fetch", delay: 30 * time.Millisecond, running: &running, peak: &peak, mu: &mu, order: &order})
+	registry.Register("write", &sleepTool{name: "write", serial: true, delay: time.Millisecond, running: &running, peak: &peak, mu: &mu, order: &order})
+
+	a := &Agent{
+		config:       &Config{MaxParallelTools: 2, ApprovalPolicy: ApprovalAuto},
+		toolRegistry: registry,
+		approvals:    make(map[string]*ApprovalDecision),
+	}
+
+	call := func(name, args string) schema.ToolCall {
+		return schema.ToolCall{ID: args, Function: schema.FunctionCall{Name: name, Arguments: args}}
+	}
+	calls := []schema.ToolCall{
+		call("fetch", "1"), call("fetch", "2"), call("fetch", "3"),
+		call("write", "4"),
+		call("fetch", "5"),
+	}
+
+	start := time.Now()
+	results, err := a.runToolCalls(context.Background(), calls)
+	if err != nil {
+		t.Fatalf("runToolCalls() error = %v", err)
+	}
+	elapsed := time.Since(start)
+
+	for i, want := range []string{"1", "2", "3", "4", "5"} {
+		if results[i] != want {
+			t.Errorf("results[%d] = %q, want %q", i, results[i], want)
+		}
+	}
+	if peak != 2 {
+		t.Errorf("peak concurrency = %d, want 2", peak)
+	}
+	// 串行工具必须在之前的调用全部完成后执行，之后的调用在它完成后才开始
+	if len(order) != 5 || order[3] != "4" || order[4] != "5" {
+		t.Errorf("execution order = %v", order)
+	}
+	// 1、2 并发，3 单独一批，5 单独一批：约 3 个延迟，全部串行需要 4 个
+	if elapsed >= 120*time.Millisecond {
+		t.Errorf("elapsed = %v, tool calls were not run in parallel", elapsed)
+	}
+}
+
+func TestAgent_runToolCallsProcessBatch(t *testing.T) {
+	manager := tools.NewProcessManager(0)
+	defer manager.Shutdown()
+
+	a, err := NewAgent(&sequenceChatModel{replies: []string{"好的"}},
+		WithAllowedTools([]string{"file_read", tools.ProcessStartToolName, tools.ProcessInputToolName, tools.ProcessOutputToolName}),
+		WithProcessManager(manager, "s1"), WithWorkDir(t.TempDir()),
+		WithApprovalPolicy(ApprovalAuto), WithMaxParallelTools(4))
+	if err != nil {
+		t.Fatalf("NewAgent() error = %v", err)
+	}
+
+	call := func(id, name, args string) schema.ToolCall {
+		return schema.ToolCall{ID: id, Function: schema.FunctionCall{Name: name, Arguments: args}}
+	}
+	// 同一批中的 process_input 必须在 process_start 之后按顺序执行
+	calls := []schema.ToolCall{
+		call("1", tools.ProcessStartToolName, `{"name": "echo", "command": "cat"}`),
+		call("2", tools.ProcessInputToolName, `{"name": "echo", "input": "first"}`),
+		call("3", tools.ProcessInputToolName, `{"name": "echo", "input": "second", "close": true}`),
+	}
+	results, err := a.runToolCalls(context.Background(), calls)
+	if err != nil {
+		t.Fatalf("runToolCalls() error = %v", err)
+	}
+	for i, result := range results {
+		if strings.Contains(result, "错误") {
+			t.Errorf("results[%d] = %s", i, result)
+		}
+	}
+
+	var output string
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		out, err := manager.Output(context.Background(), "s1", "echo", 0, 0, 0)
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if output = out.Output; strings.Contains(output, "second") {
+			break
+		}
+	}
+	if strings.TrimSpace(output) != "first\nsecond" {
+		t.Errorf("process output = %q, want first then second", output)
+	}
+}
+
+func TestAgent_LoopRunsSerialBatchInOrder(t *testing.T) {
+	// 同一批中对同一文件的多次写入，最终内容必须是最后一次写入
+	var calls []string
+	for i := 1; i <= 5; i++ {
+		calls = append(calls, fmt.Sprintf(`{"name": "file_write", "arguments": {"path": "out.txt", "content": "第 %d 次"}}`, i))
+	}
+	script, err := agentmodel.ParseScript([]byte(`{"rules": [{"responses": [{"tool_calls": [` + strings.Join(calls, ",") + `]}, {"content": "已写入"}]}]}`))
+	if err != nil {
+		t.Fatalf("ParseScript() error = %v", err)
+	}
+
+	dir := t.TempDir()
+	a, err := NewAgent(agentmodel.NewScriptChatModel(script), WithAllowedTools([]string{"file_write"}), WithWorkDir(dir),
+		WithApprovalPolicy(ApprovalAuto), WithMaxParallelTools(4))
+	if err != nil {
+		t.Fatalf("NewAgent() error = %v", err)
+	}
+	if _, err := a.Chat(context.Background(), "写文件"); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "out.txt"))
+	if err != nil || string(data) != "第 5 次" {
+		t.Errorf("out.txt = %q, %v", data, err)
+	}
+}
+
+func TestToolLimiter_SerialIsExclusive(t *testing.T) {
+	l := newToolLimiter(4)
+
+	release, err := l.acquire(context.Background(), true)
+	if err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		r, _ := l.acquire(context.Background(), false)
+		close(acquired)
+		r()
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("serial tool ran while a parallel tool was running")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	release()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("serial tool did not run after release")
+	}
+}
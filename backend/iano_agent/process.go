@@ -0,0 +1,39 @@
+package iano_agent
+
+import (
+	"iano_agent/tools"
+	"slices"
+
+	"github.com/cloudwego/eino/components/tool"
+)
+
+// registerProcessTools 配置了后台进程管理器时注册 process_start 等后台进程工具
+func (a *Agent) registerProcessTools() error {
+	if a.config.ProcessManager == nil {
+		return nil
+	}
+	return a.registerAllowedTools(tools.NewProcessTools(a.config.ProcessManager, a.config.ProcessSession, a.workDir))
+}
+
+// registerTerminalTools 配置了终端管理器时注册 terminal_open 等终端工具
+func (a *Agent) registerTerminalTools() error {
+	if a.config.TerminalManager == nil {
+		return nil
+	}
+	return a.registerAllowedTools(tools.NewTerminalTools(a.config.TerminalManager, a.config.TerminalSession, a.workDir))
+}
+
+// registerAllowedTools 注册按会话创建的工具
+//
+// 这些工具可以运行任意命令，只在工具白名单中列出时注册；未限制工具时全部注册。
+func (a *Agent) registerAllowedTools(sessionTools map[string]tool.InvokableTool) error {
+	for name, t := range sessionTools {
+		if len(a.config.AllowedTools) > 0 && !slices.Contains(a.config.AllowedTools, name) {
+			continue
+		}
+		if err := a.toolRegistry.Register(name, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package iano_agent
+
+import (
+	"iano_agent/tools"
+	"testing"
+)
+
+func TestAgent_ProcessToolsFollowAllowedTools(t *testing.T) {
+	manager := tools.NewProcessManager(0)
+	defer manager.Shutdown()
+
+	a, err := NewAgent(&sequenceChatModel{replies: []string{"好的"}},
+		WithAllowedTools([]string{"file_read", tools.ProcessStartToolName, tools.ProcessOutputToolName}),
+		WithProcessManager(manager, "s1"))
+	if err != nil {
+		t.Fatalf("NewAgent() error = %v", err)
+	}
+	registry := a.GetToolRegistry()
+	for _, name := range []string{tools.ProcessStartToolName, tools.ProcessOutputToolName} {
+		if _, ok := registry.Get(name); !ok {
+			t.Errorf("%s not registered", name)
+		}
+	}
+	if _, ok := registry.Get(tools.ProcessStopToolName); ok {
+		t.Errorf("%s registered without being allowed", tools.ProcessStopToolName)
+	}
+
+	a, err = NewAgent(&sequenceChatModel{replies: []string{"好的"}}, WithAllowedTools([]string{"file_read", tools.ProcessStartToolName}))
+	if err != nil {
+		t.Fatalf("NewAgent() error = %v", err)
+	}
+	if _, ok := a.GetToolRegistry().Get(tools.ProcessStartToolName); ok {
+		t.Error("process_start registered without a process manager")
+	}
+}
+
+func TestAgent_TerminalTools(t *testing.T) {
+	manager := tools.NewTerminalManager(0)
+	defer manager.Shutdown()
+
+	names := []string{tools.TerminalOpenToolName, tools.TerminalWriteToolName, tools.TerminalReadToolName, tools.TerminalResizeToolName, tools.TerminalCloseToolName}
+	a, err := NewAgent(&sequenceChatModel{replies: []string{"好的"}},
+		WithAllowedTools(append([]string{"file_read"}, names...)),
+		WithTerminalManager(manager, "s1"))
+	if err != nil {
+		t.Fatalf("NewAgent() error = %v", err)
+	}
+	for _, name := range names {
+		if _, ok := a.GetToolRegistry().Get(name); !ok {
+			t.Errorf("%s not registered", name)
+		}
+	}
+}
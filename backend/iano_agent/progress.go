@@ -0,0 +1,62 @@
+package iano_agent
+
+import (
+	"context"
+	"iano_agent/tools"
+
+	"github.com/cloudwego/eino/compose"
+)
+
+// ToolProgress 工具执行过程中的一行输出，例如命令的标准输出
+type ToolProgress struct {
+	CallID   string `json:"call_id"`   // 工具调用 ID
+	ToolName string `json:"tool_name"` // 工具名称
+	Stream   string `json:"stream"`    // 输出流：stdout 或 stderr
+	Line     string `json:"line"`      // 输出内容，不含换行符
+}
+
+// SetArtifactSaver 设置工具产物的保存函数，传 nil 时命令输出只保留摘要
+func (a *Agent) SetArtifactSaver(saver tools.ArtifactSaver) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.artifactSaver = saver
+}
+
+// toolContext 为工具调用注入进度上报和产物保存函数，进度通过回调推送
+func (a *Agent) toolContext(ctx context.Context, callID, name string) context.Context {
+	ctx = tools.WithProgressReporter(ctx, func(stream, line string) {
+		a.emit(&Message{
+			Role: "tool",
+			Progress: &ToolProgress{
+				CallID:   callID,
+				ToolName: name,
+				Stream:   stream,
+				Line:     line,
+			},
+		})
+	})
+
+	a.mu.RLock()
+	saver := a.artifactSaver
+	a.mu.RUnlock()
+	if saver != nil {
+		ctx = tools.WithArtifactSaver(ctx, saver)
+	}
+	return ctx
+}
+
+// progressMiddleware 为 ToolsNode 中的工具调用注入进度上报和产物保存函数
+func (a *Agent) progressMiddleware() compose.ToolMiddleware {
+	return compose.ToolMiddleware{
+		Invokable: func(next compose.InvokableToolEndpoint) compose.InvokableToolEndpoint {
+			return func(ctx context.Context, input *compose.ToolInput) (*compose.ToolOutput, error) {
+				return next(a.toolContext(ctx, input.CallID, input.Name), input)
+			}
+		},
+		Streamable: func(next compose.StreamableToolEndpoint) compose.StreamableToolEndpoint {
+			return func(ctx context.Context, input *compose.ToolInput) (*compose.StreamToolOutput, error) {
+				return next(a.toolContext(ctx, input.CallID, input.Name), input)
+			}
+		},
+	}
+}
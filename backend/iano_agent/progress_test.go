@@ -0,0 +1,59 @@
+package iano_agent
+
+import (
+	"context"
+	"iano_agent/tools"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/compose"
+)
+
+func TestAgent_progressMiddleware(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("依赖 bash")
+	}
+
+	registry := tools.NewRegistry()
+	registry.Register("shell_execute", tools.NewShellExecuteTool())
+
+	var progress []*ToolProgress
+	a := &Agent{config: &Config{}, toolRegistry: registry}
+	a.AppendCB(func(msg *Message) {
+		if msg.Progress != nil {
+			progress = append(progress, msg.Progress)
+		}
+	})
+	var savedName string
+	a.SetArtifactSaver(func(ctx context.Context, name string, r io.Reader) (*tools.Artifact, error) {
+		savedName = name
+		data, err := io.ReadAll(r)
+		return &tools.Artifact{ID: "log", Name: name, Size: int64(len(data))}, err
+	})
+
+	endpoint := a.progressMiddleware().Invokable(func(ctx context.Context, input *compose.ToolInput) (*compose.ToolOutput, error) {
+		tl, _ := a.resolveTool(input.Name)
+		result, err := tl.InvokableRun(ctx, input.Arguments)
+		return &compose.ToolOutput{Result: result}, err
+	})
+	out, err := endpoint(context.Background(), &compose.ToolInput{
+		Name:      "shell_execute",
+		Arguments: `{"command": "echo building; seq 1 20000"}`,
+		CallID:    "call_1",
+	})
+	if err != nil {
+		t.Fatalf("endpoint() error = %v", err)
+	}
+
+	if len(progress) == 0 {
+		t.Fatal("no progress messages")
+	}
+	if p := progress[0]; p.CallID != "call_1" || p.ToolName != "shell_execute" || p.Stream != tools.StreamStdout || p.Line != "building" {
+		t.Errorf("progress[0] = %+v", p)
+	}
+	if !strings.HasPrefix(savedName, "shell_execute-") || !strings.Contains(out.Result, "完整日志: "+savedName) {
+		t.Errorf("saved %q, result = %q", savedName, out.Result[:200])
+	}
+}
@@ -0,0 +1,145 @@
+package iano_agent
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// DefaultSystemPrompt 未配置指令时使用的系统提示词
+const DefaultSystemPrompt = "你是一个智能助手。"
+
+// maxPromptIncludeDepth 提示词片段嵌套引用的最大层数，避免循环引用
+const maxPromptIncludeDepth = 5
+
+// PromptVars 渲染系统提示词模板时可用的变量
+//
+// 指令按 Go text/template 语法渲染，例如：
+//
+//	你是 {{.AgentName}}，今天是 {{.Date}}，工作目录为 {{.WorkDir}}。
+//	{{if .Vars.project}}当前项目：{{.Vars.project}}{{end}}
+//	{{include "coding-style"}}
+//
+// include 按名称引用提示词库中的片段，片段同样按模板渲染
+type PromptVars struct {
+	Date         string            // 当前日期，格式 2006-01-02，为空时使用渲染时的日期
+	WorkDir      string            // 工作目录，为空时使用 Agent 的工作目录
+	OS           string            // 操作系统，为空时使用 runtime.GOOS
+	AgentName    string            // Agent 名称，为空时使用配置中的名称
+	SessionTitle string            // 会话标题
+	Locale       string            // 用户的语言区域，例如 zh-CN
+	Vars         map[string]string // 会话自定义变量，不存在的变量渲染为空字符串
+}
+
+// PromptLoader 按名称加载提示词库中的片段，名称可以带版本号，例如 coding-style@2
+type PromptLoader func(name string) (string, error)
+
+// ParsePromptTemplate 检查提示词模板的语法，不执行渲染
+func ParsePromptTemplate(text string) error {
+	_, err := newPromptTemplate(text, func(string) (string, error) { return "", nil })
+	if err != nil {
+		return fmt.Errorf("提示词模板语法错误: %w", err)
+	}
+	return nil
+}
+
+// RenderPrompt 使用变量渲染提示词模板，loader 为空时模板中不能使用 include
+func RenderPrompt(text string, vars *PromptVars, loader PromptLoader) (string, error) {
+	return renderPrompt(text, vars, loader, 0)
+}
+
+func renderPrompt(text string, vars *PromptVars, loader PromptLoader, depth int) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	tmpl, err := newPromptTemplate(text, func(name string) (string, error) {
+		if loader == nil {
+			return "", fmt.Errorf("未配置提示词库，无法引用 %s", name)
+		}
+		if depth >= maxPromptIncludeDepth {
+			return "", fmt.Errorf("提示词引用超过 %d 层，请检查是否存在循环引用", maxPromptIncludeDepth)
+		}
+		fragment, err := loader(name)
+		if err != nil {
+			return "", fmt.Errorf("加载提示词 %s 失败: %w", name, err)
+		}
+		return renderPrompt(fragment, vars, loader, depth+1)
+	})
+	if err != nil {
+		return "", fmt.Errorf("提示词模板语法错误: %w", err)
+	}
+
+	if vars == nil {
+		vars = &PromptVars{}
+	}
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return "", fmt.Errorf("渲染提示词模板失败: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// newPromptTemplate 解析提示词模板，include 函数用于引用提示词库中的片段
+func newPromptTemplate(text string, include func(name string) (string, error)) (*template.Template, error) {
+	return template.New("prompt").
+		Option("missingkey=zero").
+		Funcs(template.FuncMap{"include": include}).
+		Parse(text)
+}
+
+// SetPromptVars 设置请求级提示词变量，为空时只使用内置变量
+func (a *Agent) SetPromptVars(vars *PromptVars) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.promptVars = vars
+}
+
+// activePromptVars 本次运行的提示词变量，未设置的内置变量按 Agent 的状态补全
+func (a *Agent) activePromptVars() *PromptVars {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	vars := PromptVars{}
+	if a.promptVars != nil {
+		vars = *a.promptVars
+	}
+	if vars.Date == "" {
+		vars.Date = time.Now().Format("2006-01-02")
+	}
+	if vars.WorkDir == "" {
+		vars.WorkDir = a.workDir
+	}
+	if vars.OS == "" {
+		vars.OS = runtime.GOOS
+	}
+	if vars.AgentName == "" {
+		vars.AgentName = a.config.Name
+	}
+	return &vars
+}
+
+// buildSystemPrompt 渲染指令模板并附加运行环境的提示
+func (a *Agent) buildSystemPrompt() (string, error) {
+	vars := a.activePromptVars()
+
+	instructions := a.config.SystemPrompt
+	if instructions == "" {
+		instructions = DefaultSystemPrompt
+	}
+	prompt, err := RenderPrompt(instructions, vars, a.config.PromptLoader)
+	if err != nil {
+		return "", err
+	}
+
+	osInfo := "你运行在 "
+	if vars.OS == "windows" {
+		osInfo += "Windows 操作系统上。使用 PowerShell 命令，例如：dir 代替 ls，type 代替 cat，cd 代替 pwd。"
+	} else {
+		osInfo += "Linux/Unix 操作系统上。使用标准的 Bash 命令。"
+	}
+
+	return prompt + osInfo, nil
+}
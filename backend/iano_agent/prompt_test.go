@@ -0,0 +1,95 @@
+package iano_agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestRenderPrompt(t *testing.T) {
+	library := map[string]string{
+		"style":    "回答使用{{.Locale}}。",
+		"style@1":  "旧版本",
+		"loop":     `{{include "loop"}}`,
+		"greeting": `你好，{{.SessionTitle}}。{{include "style"}}`,
+	}
+	loader := func(name string) (string, error) {
+		text, ok := library[name]
+		if !ok {
+			return "", fmt.Errorf("提示词不存在")
+		}
+		return text, nil
+	}
+	vars := &PromptVars{
+		Date:         "2026-01-02",
+		WorkDir:      "/work",
+		AgentName:    "助手",
+		SessionTitle: "周报",
+		Locale:       "zh-CN",
+		Vars:         map[string]string{"project": "iano"},
+	}
+
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		wantErr string
+	}{
+		{name: "纯文本", text: "你是一个智能助手。", want: "你是一个智能助手。"},
+		{name: "内置变量", text: "{{.AgentName}} {{.Date}} {{.WorkDir}}", want: "助手 2026-01-02 /work"},
+		{name: "自定义变量", text: "项目：{{.Vars.project}}{{if .Vars.owner}}，负责人：{{.Vars.owner}}{{end}}", want: "项目：iano"},
+		{name: "嵌套引用", text: `{{include "greeting"}}`, want: "你好，周报。回答使用zh-CN。"},
+		{name: "指定版本", text: `{{include "style@1"}}`, want: "旧版本"},
+		{name: "片段不存在", text: `{{include "missing"}}`, wantErr: "加载提示词 missing 失败"},
+		{name: "循环引用", text: `{{include "loop"}}`, wantErr: "请检查是否存在循环引用"},
+		{name: "语法错误", text: "{{.AgentName", wantErr: "提示词模板语法错误"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderPrompt(tt.text, vars, loader)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("RenderPrompt() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("RenderPrompt() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+
+	if _, err := RenderPrompt(`{{include "style"}}`, vars, nil); err == nil {
+		t.Error("RenderPrompt() without loader error = nil")
+	}
+}
+
+func TestAgent_LoopRendersSystemPrompt(t *testing.T) {
+	if _, err := NewAgent(&sequenceChatModel{replies: []string{"好的"}}, WithSystemPrompt("{{.AgentName")); err == nil {
+		t.Fatal("NewAgent() with invalid template error = nil")
+	}
+
+	m := &sequenceChatModel{replies: []string{"好的"}}
+	a, err := NewAgent(m,
+		WithName("周报助手"),
+		WithWorkDir(t.TempDir()),
+		WithSystemPrompt("你是{{.AgentName}}，会话：{{.SessionTitle}}，项目：{{.Vars.project}}。"),
+	)
+	if err != nil {
+		t.Fatalf("NewAgent() error = %v", err)
+	}
+	a.SetPromptVars(&PromptVars{SessionTitle: "本周进展", Vars: map[string]string{"project": "iano"}})
+
+	if _, err := a.Loop(context.Background(), []*schema.Message{schema.UserMessage("开始")}); err != nil {
+		t.Fatalf("Loop() error = %v", err)
+	}
+	if len(m.inputs) == 0 || m.inputs[0][0].Role != schema.System {
+		t.Fatalf("model inputs = %+v", m.inputs)
+	}
+	if got := m.inputs[0][0].Content; !strings.HasPrefix(got, "你是周报助手，会话：本周进展，项目：iano。你运行在 ") {
+		t.Errorf("system prompt = %q", got)
+	}
+}
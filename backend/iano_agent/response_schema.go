@@ -0,0 +1,461 @@
+package iano_agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultMaxSchemaRetries 最终答复不符合 Schema 时默认的重新提示次数
+const DefaultMaxSchemaRetries = 2
+
+// ErrInvalidStructuredOutput 重试后最终答复仍不符合 Schema
+var ErrInvalidStructuredOutput = errors.New("最终答复不符合响应 Schema")
+
+// ResponseSchema 最终答复需要满足的 JSON Schema
+//
+// 支持常用关键字：type、enum、const、properties、required、additionalProperties、
+// items、minItems、maxItems、minLength、maxLength、pattern、minimum、maximum、anyOf，
+// 以及 title、description 等不参与校验的注解。其他关键字（如 $ref、oneOf、allOf）在解析时报错。
+type ResponseSchema struct {
+	raw  json.RawMessage
+	root map[string]any
+}
+
+// ParseResponseSchema 解析 JSON Schema
+func ParseResponseSchema(data []byte) (*ResponseSchema, error) {
+	var root map[string]any
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("响应 Schema 不是合法的 JSON 对象: %w", err)
+	}
+	if len(root) == 0 {
+		return nil, fmt.Errorf("响应 Schema 不能为空")
+	}
+	if err := checkSchema("$", root); err != nil {
+		return nil, err
+	}
+
+	raw, _ := json.Marshal(root)
+	return &ResponseSchema{raw: raw, root: root}, nil
+}
+
+// supportedKeywords 参与校验的关键字
+var supportedKeywords = map[string]bool{
+	"type": true, "enum": true, "const": true,
+	"properties": true, "required": true, "additionalProperties": true,
+	"items": true, "minItems": true, "maxItems": true,
+	"minLength": true, "maxLength": true, "pattern": true,
+	"minimum": true, "maximum": true, "anyOf": true,
+}
+
+// annotationKeywords 只作说明、不影响校验结果的关键字
+var annotationKeywords = map[string]bool{
+	"$schema": true, "$id": true, "$comment": true,
+	"title": true, "description": true, "default": true, "examples": true, "format": true,
+}
+
+// checkSchema 检查 Schema 只使用了支持的关键字且取值合法，避免不支持的约束被静默忽略
+func checkSchema(path string, schema map[string]any) error {
+	keys := make([]string, 0, len(schema))
+	for key := range schema {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if !supportedKeywords[key] && !annotationKeywords[key] {
+			return fmt.Errorf("响应 Schema %s: 不支持的关键字 %s", path, key)
+		}
+	}
+
+	subSchema := func(name string, v any) error {
+		sub, ok := v.(map[string]any)
+		if !ok {
+			return fmt.Errorf("响应 Schema %s: 必须是 JSON 对象", name)
+		}
+		return checkSchema(name, sub)
+	}
+
+	if v, ok := schema["type"]; ok {
+		types := schemaTypes(v)
+		if len(types) == 0 {
+			return fmt.Errorf("响应 Schema %s.type: 必须是字符串或字符串数组", path)
+		}
+		for _, t := range types {
+			switch t {
+			case "null", "boolean", "number", "integer", "string", "array", "object":
+			default:
+				return fmt.Errorf("响应 Schema %s.type: 未知类型 %s", path, t)
+			}
+		}
+	}
+	if v, ok := schema["enum"]; ok {
+		if _, ok := v.([]any); !ok {
+			return fmt.Errorf("响应 Schema %s.enum: 必须是数组", path)
+		}
+	}
+	if v, ok := schema["required"]; ok {
+		required, ok := v.([]any)
+		if !ok {
+			return fmt.Errorf("响应 Schema %s.required: 必须是字符串数组", path)
+		}
+		for _, r := range required {
+			if _, ok := r.(string); !ok {
+				return fmt.Errorf("响应 Schema %s.required: 必须是字符串数组", path)
+			}
+		}
+	}
+	for _, key := range []string{"minItems", "maxItems", "minLength", "maxLength", "minimum", "maximum"} {
+		if v, ok := schema[key]; ok {
+			if _, ok := schemaNumber(v); !ok {
+				return fmt.Errorf("响应 Schema %s.%s: 必须是数字", path, key)
+			}
+		}
+	}
+	if v, ok := schema["pattern"]; ok {
+		pattern, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("响应 Schema %s.pattern: 必须是字符串", path)
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("响应 Schema %s.pattern: 正则不合法: %w", path, err)
+		}
+	}
+
+	if v, ok := schema["properties"]; ok {
+		properties, ok := v.(map[string]any)
+		if !ok {
+			return fmt.Errorf("响应 Schema %s.properties: 必须是 JSON 对象", path)
+		}
+		names := make([]string, 0, len(properties))
+		for name := range properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if err := subSchema(path+".properties."+name, properties[name]); err != nil {
+				return err
+			}
+		}
+	}
+	if v, ok := schema["additionalProperties"]; ok {
+		if _, isBool := v.(bool); !isBool {
+			if err := subSchema(path+".additionalProperties", v); err != nil {
+				return err
+			}
+		}
+	}
+	if v, ok := schema["items"]; ok {
+		if err := subSchema(path+".items", v); err != nil {
+			return err
+		}
+	}
+	if v, ok := schema["anyOf"]; ok {
+		options, ok := v.([]any)
+		if !ok || len(options) == 0 {
+			return fmt.Errorf("响应 Schema %s.anyOf: 必须是非空数组", path)
+		}
+		for i, option := range options {
+			if err := subSchema(fmt.Sprintf("%s.anyOf[%d]", path, i), option); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Raw Schema 的 JSON 文本
+func (s *ResponseSchema) Raw() json.RawMessage {
+	return s.raw
+}
+
+// Validate 校验最终答复，返回提取出的 JSON 和校验错误
+//
+// 答复可以包含 <think> 思考内容或 ```json 代码块，提取其中的 JSON 后再校验。
+func (s *ResponseSchema) Validate(answer string) (json.RawMessage, []string) {
+	text := extractJSON(answer)
+	if text == "" {
+		return nil, []string{"答复中没有找到 JSON"}
+	}
+
+	var value any
+	if err := json.Unmarshal([]byte(text), &value); err != nil {
+		return nil, []string{fmt.Sprintf("答复不是合法的 JSON: %v", err)}
+	}
+
+	var errs []string
+	validateValue("$", s.root, value, &errs)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return json.RawMessage(text), nil
+}
+
+// SetResponseSchema 设置请求级响应 Schema，为空时使用配置中的 Schema
+func (a *Agent) SetResponseSchema(schema *ResponseSchema) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.responseSchema = schema
+}
+
+// GetStructuredOutput 最近一次运行中通过 Schema 校验的最终答复，未配置 Schema 时为空
+func (a *Agent) GetStructuredOutput() json.RawMessage {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.structured
+}
+
+// activeResponseSchema 本次运行生效的响应 Schema
+func (a *Agent) activeResponseSchema() *ResponseSchema {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.responseSchema != nil {
+		return a.responseSchema
+	}
+	return a.config.ResponseSchema
+}
+
+func (a *Agent) setStructuredOutput(output json.RawMessage) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.structured = output
+}
+
+// instruction 要求模型按 Schema 输出的系统提示
+func (s *ResponseSchema) instruction() string {
+	return "最终答复必须是符合以下 JSON Schema 的 JSON，不要输出 JSON 以外的任何内容：\n" + string(s.raw)
+}
+
+// retryPrompt 最终答复校验失败后的重新提示
+func (s *ResponseSchema) retryPrompt(errs []string) string {
+	return fmt.Sprintf("你的答复不符合要求的 JSON Schema：\n- %s\n请修正后重新输出完整的 JSON，不要包含其他内容。", strings.Join(errs, "\n- "))
+}
+
+// extractJSON 去掉思考内容和代码块标记，提取答复中的 JSON 文本
+func extractJSON(answer string) string {
+	if idx := strings.LastIndex(answer, ThinkEndTag); idx != -1 {
+		answer = answer[idx+len(ThinkEndTag):]
+	}
+	text := strings.TrimSpace(answer)
+
+	if start := strings.Index(text, "```"); start != -1 {
+		body := text[start+3:]
+		if nl := strings.Index(body, "\n"); nl != -1 {
+			body = body[nl+1:]
+		}
+		if end := strings.Index(body, "```"); end != -1 {
+			body = body[:end]
+		}
+		text = strings.TrimSpace(body)
+	}
+
+	if strings.HasPrefix(text, "{") || strings.HasPrefix(text, "[") {
+		return text
+	}
+
+	start := strings.IndexAny(text, "{[")
+	if start == -1 {
+		return ""
+	}
+	closing := "}"
+	if text[start] == '[' {
+		closing = "]"
+	}
+	end := strings.LastIndex(text, closing)
+	if end <= start {
+		return ""
+	}
+	return text[start : end+1]
+}
+
+func validateValue(path string, schema map[string]any, value any, errs *[]string) {
+	fail := func(format string, args ...any) {
+		*errs = append(*errs, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if anyOf, ok := schema["anyOf"].([]any); ok {
+		matched := false
+		for _, option := range anyOf {
+			sub, ok := option.(map[string]any)
+			if !ok {
+				continue
+			}
+			var subErrs []string
+			validateValue(path, sub, value, &subErrs)
+			if len(subErrs) == 0 {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			fail("不满足 anyOf 中的任何一个 Schema")
+			return
+		}
+	}
+
+	if types := schemaTypes(schema["type"]); len(types) > 0 {
+		actual := jsonType(value)
+		if !typeMatches(types, actual, value) {
+			fail("类型应为 %s，实际为 %s", strings.Join(types, "/"), actual)
+			return
+		}
+	}
+
+	if want, ok := schema["const"]; ok && !jsonEqual(want, value) {
+		fail("值应为 %s", jsonText(want))
+	}
+
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, want := range enum {
+			if jsonEqual(want, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("值 %s 不在可选范围 %s 内", jsonText(value), jsonText(enum))
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		validateObject(path, schema, v, errs)
+	case []any:
+		if n, ok := schemaNumber(schema["minItems"]); ok && float64(len(v)) < n {
+			fail("至少需要 %v 个元素，实际为 %d 个", n, len(v))
+		}
+		if n, ok := schemaNumber(schema["maxItems"]); ok && float64(len(v)) > n {
+			fail("最多允许 %v 个元素，实际为 %d 个", n, len(v))
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				validateValue(fmt.Sprintf("%s[%d]", path, i), items, item, errs)
+			}
+		}
+	case string:
+		length := len([]rune(v))
+		if n, ok := schemaNumber(schema["minLength"]); ok && float64(length) < n {
+			fail("长度至少为 %v，实际为 %d", n, length)
+		}
+		if n, ok := schemaNumber(schema["maxLength"]); ok && float64(length) > n {
+			fail("长度最多为 %v，实际为 %d", n, length)
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(v) {
+				fail("不匹配正则 %s", pattern)
+			}
+		}
+	case float64:
+		if n, ok := schemaNumber(schema["minimum"]); ok && v < n {
+			fail("不能小于 %v", n)
+		}
+		if n, ok := schemaNumber(schema["maximum"]); ok && v > n {
+			fail("不能大于 %v", n)
+		}
+	}
+}
+
+func validateObject(path string, schema map[string]any, obj map[string]any, errs *[]string) {
+	properties, _ := schema["properties"].(map[string]any)
+
+	if required, ok := schema["required"].([]any); ok {
+		for _, r := range required {
+			name, ok := r.(string)
+			if !ok {
+				continue
+			}
+			if _, exists := obj[name]; !exists {
+				*errs = append(*errs, fmt.Sprintf("%s: 缺少必填字段 %s", path, name))
+			}
+		}
+	}
+
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		childPath := path + "." + name
+		if prop, ok := properties[name].(map[string]any); ok {
+			validateValue(childPath, prop, obj[name], errs)
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				*errs = append(*errs, fmt.Sprintf("%s: 不允许的字段", childPath))
+			}
+		case map[string]any:
+			validateValue(childPath, additional, obj[name], errs)
+		}
+	}
+}
+
+func schemaTypes(v any) []string {
+	switch t := v.(type) {
+	case string:
+		return []string{t}
+	case []any:
+		types := make([]string, 0, len(t))
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+func typeMatches(types []string, actual string, value any) bool {
+	for _, t := range types {
+		if t == actual {
+			return true
+		}
+		if t == "integer" && actual == "number" {
+			if f := value.(float64); f == math.Trunc(f) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func jsonType(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return "unknown"
+}
+
+func schemaNumber(v any) (float64, bool) {
+	n, ok := v.(float64)
+	return n, ok
+}
+
+func jsonEqual(a, b any) bool {
+	return jsonText(a) == jsonText(b)
+}
+
+func jsonText(v any) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
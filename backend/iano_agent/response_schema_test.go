@@ -0,0 +1,157 @@
+package iano_agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+const triageSchema = `{
+	"type": "object",
+	"properties": {
+		"priority": {"type": "string", "enum": ["low", "high"]},
+		"tags": {"type": "array", "items": {"type": "string"}, "minItems": 1}
+	},
+	"required": ["priority", "tags"],
+	"additionalProperties": false
+}`
+
+func TestParseResponseSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		wantErr string
+	}{
+		{"支持的关键字和注解", `{"title": "工单", "type": "object", "properties": {"id": {"type": "string", "pattern": "^T-\\d+$", "description": "编号"}}}`, ""},
+		{"$ref", `{"type": "object", "properties": {"a": {"$ref": "#/definitions/a"}}}`, "$.properties.a: 不支持的关键字 $ref"},
+		{"oneOf", `{"oneOf": [{"type": "string"}, {"type": "number"}]}`, "不支持的关键字 oneOf"},
+		{"allOf", `{"items": {"allOf": [{"type": "string"}]}}`, "$.items: 不支持的关键字 allOf"},
+		{"非法正则", `{"type": "string", "pattern": "([a-z"}`, "$.pattern: 正则不合法"},
+		{"未知类型", `{"type": "str"}`, "未知类型 str"},
+		{"子 Schema 不是对象", `{"type": "object", "properties": {"a": "string"}}`, "$.properties.a: 必须是 JSON 对象"},
+		{"不是对象", `[]`, "不是合法的 JSON 对象"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseResponseSchema([]byte(tt.schema))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ParseResponseSchema() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseResponseSchema() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResponseSchema_Validate(t *testing.T) {
+	s, err := ParseResponseSchema([]byte(triageSchema))
+	if err != nil {
+		t.Fatalf("ParseResponseSchema() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		answer  string
+		wantErr string
+	}{
+		{"合法 JSON", `{"priority": "high", "tags": ["bug"]}`, ""},
+		{"代码块和思考内容", "<think>分析一下</think>\n```json\n{\"priority\": \"low\", \"tags\": [\"ui\"]}\n```", ""},
+		{"缺少字段", `{"priority": "high"}`, "缺少必填字段 tags"},
+		{"枚举不匹配", `{"priority": "urgent", "tags": ["bug"]}`, "$.priority"},
+		{"数组元素类型错误", `{"priority": "low", "tags": [1]}`, "$.tags[0]"},
+		{"多余字段", `{"priority": "low", "tags": ["a"], "extra": 1}`, "$.extra"},
+		{"不是 JSON", "好的，这是结果", "没有找到 JSON"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, errs := s.Validate(tt.answer)
+			if tt.wantErr == "" {
+				if len(errs) > 0 || output == nil {
+					t.Errorf("Validate() errs = %v", errs)
+				}
+				return
+			}
+			if !strings.Contains(strings.Join(errs, "\n"), tt.wantErr) {
+				t.Errorf("Validate() errs = %v, want %q", errs, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAgent_LoopWithResponseSchema(t *testing.T) {
+	s, err := ParseResponseSchema([]byte(triageSchema))
+	if err != nil {
+		t.Fatalf("ParseResponseSchema() error = %v", err)
+	}
+
+	t.Run("校验失败后重新提示", func(t *testing.T) {
+		m := &sequenceChatModel{replies: []string{`{"priority": "urgent"}`, `{"priority": "high", "tags": ["bug"]}`}}
+		var streamed strings.Builder
+		a, err := NewAgent(m, WithAllowedTools([]string{"file_read"}), WithResponseSchema(s))
+		if err != nil {
+			t.Fatalf("NewAgent() error = %v", err)
+		}
+		a.AppendCB(func(msg *Message) { streamed.WriteString(msg.Content) })
+
+		response, err := a.Chat(context.Background(), "登录页白屏")
+		if err != nil {
+			t.Fatalf("Chat() error = %v", err)
+		}
+		// 不合格的答复不推送给回调，也不计入返回内容
+		if response != `{"priority": "high", "tags": ["bug"]}` || streamed.String() != response {
+			t.Errorf("response = %q, streamed = %q", response, streamed.String())
+		}
+		if got := string(a.GetStructuredOutput()); got != `{"priority": "high", "tags": ["bug"]}` {
+			t.Errorf("GetStructuredOutput() = %s", got)
+		}
+		if len(m.inputs) != 2 {
+			t.Fatalf("model calls = %d, want 2", len(m.inputs))
+		}
+		retry := m.inputs[1][len(m.inputs[1])-1]
+		if retry.Role != schema.User || !strings.Contains(retry.Content, "$.priority") {
+			t.Errorf("retry prompt = %+v", retry)
+		}
+	})
+
+	t.Run("超过重试次数", func(t *testing.T) {
+		m := &sequenceChatModel{replies: []string{"无法判断"}}
+		a, err := NewAgent(m, WithAllowedTools([]string{"file_read"}), WithResponseSchema(s), WithMaxSchemaRetries(1))
+		if err != nil {
+			t.Fatalf("NewAgent() error = %v", err)
+		}
+
+		_, err = a.Chat(context.Background(), "登录页白屏")
+		if !errors.Is(err, ErrInvalidStructuredOutput) {
+			t.Errorf("Chat() error = %v, want ErrInvalidStructuredOutput", err)
+		}
+		if len(m.inputs) != 2 || a.GetStructuredOutput() != nil {
+			t.Errorf("model calls = %d, structured = %s", len(m.inputs), a.GetStructuredOutput())
+		}
+	})
+
+	t.Run("请求级 Schema 优先", func(t *testing.T) {
+		m := &sequenceChatModel{replies: []string{`["a"]`}}
+		a, err := NewAgent(m, WithAllowedTools([]string{"file_read"}), WithResponseSchema(s))
+		if err != nil {
+			t.Fatalf("NewAgent() error = %v", err)
+		}
+		override, _ := ParseResponseSchema([]byte(`{"type": "array", "items": {"type": "string"}}`))
+		a.SetResponseSchema(override)
+
+		if _, err := a.Chat(context.Background(), "列出标签"); err != nil {
+			t.Fatalf("Chat() error = %v", err)
+		}
+		if got := string(a.GetStructuredOutput()); got != `["a"]` {
+			t.Errorf("GetStructuredOutput() = %s", got)
+		}
+	})
+}
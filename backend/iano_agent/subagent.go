@@ -0,0 +1,179 @@
+package iano_agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// DelegateToolPrefix 子 Agent 委派工具的名称前缀
+const DelegateToolPrefix = "delegate_to_"
+
+// DefaultMaxDelegationDepth 默认最大委派深度，主 Agent 为第 0 层
+const DefaultMaxDelegationDepth = 2
+
+// SubAgentFactory 创建子 Agent 实例，每次委派调用一次
+type SubAgentFactory func(ctx context.Context) (*Agent, error)
+
+// SubAgentConfig 子 Agent 配置
+type SubAgentConfig struct {
+	Name        string          // 子 Agent 名称，工具名为 delegate_to_<name>
+	Description string          // 子 Agent 职责描述，展示给主 Agent
+	Factory     SubAgentFactory // 子 Agent 构造函数，使用其自身的指令、工具和提供商
+	TokenBudget int64           // 单次委派的 Token 预算，0 表示不限制
+}
+
+type delegationDepthKey struct{}
+
+// DelegationDepth 当前 ctx 所处的委派深度，主 Agent 为 0
+func DelegationDepth(ctx context.Context) int {
+	depth, _ := ctx.Value(delegationDepthKey{}).(int)
+	return depth
+}
+
+func withDelegationDepth(ctx context.Context, depth int) context.Context {
+	return context.WithValue(ctx, delegationDepthKey{}, depth)
+}
+
+var delegateNamePattern = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+
+// DelegateToolName 子 Agent 对应的工具名称，非字母数字字符替换为下划线
+func DelegateToolName(name string) string {
+	name = strings.Trim(delegateNamePattern.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	return DelegateToolPrefix + name
+}
+
+// AddSubAgent 将子 Agent 注册为主 Agent 可调用的 delegate_to_<name> 工具
+func (a *Agent) AddSubAgent(cfg *SubAgentConfig) error {
+	if cfg == nil || cfg.Factory == nil {
+		return fmt.Errorf("子 Agent 配置不完整")
+	}
+	if cfg.Name == "" {
+		return fmt.Errorf("子 Agent 名称不能为空")
+	}
+	return a.AddTool(DelegateToolName(cfg.Name), &delegateTool{parent: a, config: cfg})
+}
+
+// delegateTool 将任务交给子 Agent 执行的工具
+type delegateTool struct {
+	parent *Agent
+	config *SubAgentConfig
+}
+
+func (t *delegateTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	desc := fmt.Sprintf("将任务委派给子 Agent「%s」独立完成，返回其最终答复。", t.config.Name)
+	if t.config.Description != "" {
+		desc += t.config.Description
+	}
+
+	return &schema.ToolInfo{
+		Name: DelegateToolName(t.config.Name),
+		Desc: desc,
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"task": {
+				Type:     schema.String,
+				Desc:     "交给子 Agent 的任务描述，需要包含完成任务所需的全部信息",
+				Required: true,
+			},
+		}),
+	}, nil
+}
+
+// ParallelSafe 子 Agent 可能调用有副作用的工具，委派按顺序执行
+func (t *delegateTool) ParallelSafe() bool {
+	return false
+}
+
+func (t *delegateTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	var args struct {
+		Task string `json:"task"`
+	}
+	if err := json.Unmarshal([]byte(argumentsInJSON), &args); err != nil {
+		return "", fmt.Errorf("参数解析失败: %w", err)
+	}
+	if strings.TrimSpace(args.Task) == "" {
+		return "", fmt.Errorf("任务不能为空")
+	}
+
+	depth := DelegationDepth(ctx) + 1
+	maxDepth := t.parent.config.MaxDelegationDepth
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxDelegationDepth
+	}
+	if depth > maxDepth {
+		return "", fmt.Errorf("超过最大委派深度 %d，请直接完成任务", maxDepth)
+	}
+
+	sub, err := t.config.Factory(ctx)
+	if err != nil {
+		return "", fmt.Errorf("创建子 Agent %s 失败: %w", t.config.Name, err)
+	}
+
+	t.parent.mu.RLock()
+	sub.approvalHandler = t.parent.approvalHandler
+	sub.checkpointHandler = t.parent.checkpointHandler
+	sub.artifactSaver = t.parent.artifactSaver
+	// 子 Agent 沿用会话的提示词变量，Agent 名称使用自身的配置
+	if vars := t.parent.promptVars; vars != nil {
+		inherited := *vars
+		inherited.AgentName = ""
+		sub.promptVars = &inherited
+	}
+	t.parent.mu.RUnlock()
+	sub.config.MaxDelegationDepth = maxDepth
+	if t.config.TokenBudget > 0 {
+		sub.config.TokenBudget = t.config.TokenBudget
+	}
+
+	// 子 Agent 的中间消息转发给主 Agent 的回调，同时收集最后一段答复
+	var mu sync.Mutex
+	var answer strings.Builder
+	sub.CBs = []MessageCallback{func(msg *Message) {
+		nested := *msg
+		if nested.Agent == "" {
+			nested.Agent = t.config.Name
+			nested.Depth = depth
+		}
+		t.parent.emit(&nested)
+
+		if msg.Depth > 0 {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if msg.IsToolCall {
+			answer.Reset()
+		} else if !msg.IsThink {
+			answer.WriteString(msg.Content)
+		}
+	}}
+
+	slog.Info("委派任务给子 Agent", "agent", t.config.Name, "depth", depth)
+	_, err = sub.Loop(withDelegationDepth(ctx, depth), []*schema.Message{schema.UserMessage(args.Task)})
+	t.parent.mergeUsage(sub.GetRunUsage())
+
+	mu.Lock()
+	result := strings.TrimSpace(answer.String())
+	mu.Unlock()
+
+	if err != nil {
+		var stopped *RunStopError
+		if !errors.As(err, &stopped) {
+			return "", fmt.Errorf("子 Agent %s 执行失败: %w", t.config.Name, err)
+		}
+		result += fmt.Sprintf("\n\n（子 Agent 运行已停止：%s，结果可能不完整）", stopped.Detail)
+	}
+	if result == "" {
+		result = "子 Agent 没有返回内容"
+	}
+	slog.Info("子 Agent 任务完成", "agent", t.config.Name, "depth", depth)
+	return result, nil
+}
@@ -0,0 +1,82 @@
+package iano_agent
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// replyChatModel 固定回复的测试模型
+type replyChatModel struct {
+	reply string
+}
+
+func (m *replyChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	return schema.AssistantMessage(m.reply, nil), nil
+}
+
+func (m *replyChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	return schema.StreamReaderFromArray([]*schema.Message{schema.AssistantMessage(m.reply, nil)}), nil
+}
+
+func (m *replyChatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	return m, nil
+}
+
+func TestDelegateToolName(t *testing.T) {
+	if got := DelegateToolName("Code Reviewer"); got != "delegate_to_code_reviewer" {
+		t.Errorf("DelegateToolName() = %q", got)
+	}
+}
+
+func TestDelegateTool_InvokableRun(t *testing.T) {
+	parent := &Agent{
+		config:     DefaultConfig(),
+		tokenUsage: &TokenUsage{LastUpdated: time.Now()},
+		runUsage:   &TokenUsage{LastUpdated: time.Now()},
+	}
+
+	var mu sync.Mutex
+	var nested []*Message
+	parent.AppendCB(func(msg *Message) {
+		mu.Lock()
+		defer mu.Unlock()
+		nested = append(nested, msg)
+	})
+
+	dt := &delegateTool{parent: parent, config: &SubAgentConfig{
+		Name: "researcher",
+		Factory: func(ctx context.Context) (*Agent, error) {
+			return NewAgent(&replyChatModel{reply: "调研完成"}, WithAllowedTools([]string{"file_read"}))
+		},
+	}}
+
+	t.Run("返回子 Agent 的答复并转发中间消息", func(t *testing.T) {
+		result, err := dt.InvokableRun(context.Background(), `{"task": "调研一下"}`)
+		if err != nil {
+			t.Fatalf("InvokableRun() error = %v", err)
+		}
+		if result != "调研完成" {
+			t.Errorf("result = %q", result)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if len(nested) == 0 || nested[0].Agent != "researcher" || nested[0].Depth != 1 {
+			t.Errorf("nested = %+v", nested)
+		}
+	})
+
+	t.Run("超过最大委派深度", func(t *testing.T) {
+		ctx := withDelegationDepth(context.Background(), DefaultMaxDelegationDepth)
+		_, err := dt.InvokableRun(ctx, `{"task": "调研一下"}`)
+		if err == nil || !strings.Contains(err.Error(), "委派深度") {
+			t.Errorf("InvokableRun() error = %v, want depth limit", err)
+		}
+	})
+}
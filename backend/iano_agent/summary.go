@@ -0,0 +1,287 @@
+package iano_agent
+
+import (
+	"context"
+	"fmt"
+	agenterrors "iano_agent/errors"
+	"iano_agent/metrics"
+	"iano_agent/trace"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+const (
+	DefaultKeepRounds     = 4            // 默认保留的最近对话轮数
+	SummaryPrefix         = "[历史对话摘要]\n" // 摘要系统消息前缀
+	maxSummaryInputRunes  = 2000         // 单条消息参与摘要的最大字符数
+	summarySystemPrompt   = "你是一个对话摘要助手。请将下面的历史对话压缩成一段简洁的摘要，保留用户的目标、已确认的事实与约定、做出的决定、涉及的文件路径和命令、工具调用的关键结果以及尚未完成的事项。不要编造内容，不要输出与摘要无关的话。"
+	summaryUserPromptHead = "请总结以下历史对话：\n\n"
+)
+
+// SummaryResult 上下文压缩结果
+type SummaryResult struct {
+	Summary             string            // 生成的摘要，为空表示未压缩
+	Messages            []*schema.Message // 压缩后的消息列表
+	RoundsSummarized    int               // 被摘要的对话轮数
+	LastSummarizedIndex int               // 被摘要的最后一条输入消息下标，未压缩时为 -1
+	OldTokens           int               // 被摘要消息的估算 Token 数
+	SummaryTokens       int               // 摘要的 Token 数
+	SavedTokens         int               // 节省的 Token 数
+	Usage               *TokenUsage       // 生成摘要的模型调用用量，模型未返回用量时为 nil
+	Duration            time.Duration     // 摘要耗时
+}
+
+// Compacted 是否发生了压缩
+func (r *SummaryResult) Compacted() bool {
+	return r != nil && r.Summary != ""
+}
+
+// IsSummaryMessage 判断是否为摘要系统消息
+func IsSummaryMessage(msg *schema.Message) bool {
+	return msg != nil && msg.Role == schema.System && strings.HasPrefix(msg.Content, SummaryPrefix)
+}
+
+// NewSummaryMessage 创建摘要系统消息
+func NewSummaryMessage(summary string) *schema.Message {
+	return schema.SystemMessage(SummaryPrefix + summary)
+}
+
+// SplitRounds 按用户消息划分对话轮次，返回首个用户消息之前的前置消息和各轮消息
+func SplitRounds(messages []*schema.Message) ([]*schema.Message, [][]*schema.Message) {
+	prefix := make([]*schema.Message, 0)
+	rounds := make([][]*schema.Message, 0)
+
+	for _, msg := range messages {
+		if msg.Role == schema.User {
+			rounds = append(rounds, []*schema.Message{msg})
+			continue
+		}
+		if len(rounds) == 0 {
+			prefix = append(prefix, msg)
+			continue
+		}
+		rounds[len(rounds)-1] = append(rounds[len(rounds)-1], msg)
+	}
+
+	return prefix, rounds
+}
+
+// NeedsSummary 未被摘要的轮数超过 keepRounds 的两倍时才需要压缩。
+// 每次压缩一批较早的轮次，避免会话超过保留轮数后每轮对话都额外调用一次模型生成摘要。
+func NeedsSummary(messages []*schema.Message, keepRounds int) bool {
+	if keepRounds <= 0 {
+		keepRounds = DefaultKeepRounds
+	}
+	_, rounds := SplitRounds(messages)
+	return len(rounds) > 2*keepRounds
+}
+
+// Summarize 保留最近 keepRounds 轮对话原文，将更早的轮次（包括已有摘要）用模型压缩为一条摘要系统消息
+func (a *Agent) Summarize(ctx context.Context, messages []*schema.Message, keepRounds int) (*SummaryResult, error) {
+	if keepRounds <= 0 {
+		keepRounds = DefaultKeepRounds
+	}
+
+	result := &SummaryResult{
+		Messages:            messages,
+		LastSummarizedIndex: -1,
+	}
+
+	prefix, rounds := SplitRounds(messages)
+	if len(rounds) <= keepRounds {
+		return result, nil
+	}
+
+	oldRounds := rounds[:len(rounds)-keepRounds]
+	keptRounds := rounds[len(rounds)-keepRounds:]
+
+	// 已有的摘要参与新一轮摘要，其他前置系统消息原样保留
+	keptPrefix := make([]*schema.Message, 0, len(prefix))
+	toSummarize := make([]*schema.Message, 0)
+	for _, msg := range prefix {
+		if IsSummaryMessage(msg) {
+			toSummarize = append(toSummarize, msg)
+		} else {
+			keptPrefix = append(keptPrefix, msg)
+		}
+	}
+	for _, round := range oldRounds {
+		toSummarize = append(toSummarize, round...)
+	}
+
+	start := time.Now()
+	span := trace.GlobalTracer.StartSpan(ctx, "agent.summarize")
+	defer span.End()
+
+	resp, err := a.chatModel.Generate(span, []*schema.Message{
+		schema.SystemMessage(summarySystemPrompt),
+		schema.UserMessage(summaryUserPromptHead + buildTranscript(toSummarize)),
+	})
+	if err != nil {
+		span.SetError(err)
+		metrics.GlobalMetrics.RecordError(string(agenterrors.ErrCodeModel))
+		return nil, fmt.Errorf("生成对话摘要失败: %w", err)
+	}
+
+	summary := strings.TrimSpace(resp.Content)
+	if summary == "" {
+		return result, nil
+	}
+
+	oldTokens := EstimateMessagesTokens(toSummarize)
+	summaryTokens := EstimateTokens(summary)
+	usage := summaryUsage(resp)
+	if usage != nil && usage.CompletionTokens > 0 {
+		summaryTokens = int(usage.CompletionTokens)
+	}
+	savedTokens := oldTokens - summaryTokens
+	if savedTokens < 0 {
+		savedTokens = 0
+	}
+
+	compacted := make([]*schema.Message, 0, len(keptPrefix)+1+len(messages))
+	compacted = append(compacted, keptPrefix...)
+	compacted = append(compacted, NewSummaryMessage(summary))
+	for _, round := range keptRounds {
+		compacted = append(compacted, round...)
+	}
+
+	result.Summary = summary
+	result.Messages = compacted
+	result.RoundsSummarized = len(oldRounds)
+	result.LastSummarizedIndex = len(messages) - countMessages(keptRounds) - 1
+	result.OldTokens = oldTokens
+	result.SummaryTokens = summaryTokens
+	result.SavedTokens = savedTokens
+	result.Usage = usage
+	result.Duration = time.Since(start)
+
+	attrs := &trace.SummarySpanAttributes{
+		RoundsToSummarize: result.RoundsSummarized,
+		OldTokens:         oldTokens,
+		SummaryTokens:     summaryTokens,
+		SavedTokens:       savedTokens,
+		Duration:          result.Duration,
+	}
+	attrs.Apply(span)
+	metrics.GlobalMetrics.RecordSummary(result.Duration, oldTokens, summaryTokens, savedTokens)
+
+	a.mu.Lock()
+	a.tokenUsage.SummaryTokens += int64(summaryTokens)
+	a.tokenUsage.SavedTokens += int64(savedTokens)
+	if usage != nil {
+		// 摘要在运行开始前生成，只计入 Agent 的累计用量
+		a.tokenUsage.PromptTokens += usage.PromptTokens
+		a.tokenUsage.CompletionTokens += usage.CompletionTokens
+		a.tokenUsage.TotalTokens += usage.TotalTokens
+		a.tokenUsage.CachedTokens += usage.CachedTokens
+		a.tokenUsage.ReasoningTokens += usage.ReasoningTokens
+	}
+	a.tokenUsage.LastUpdated = time.Now()
+	a.mu.Unlock()
+
+	slog.Info("对话摘要完成", "rounds", result.RoundsSummarized, "old_tokens", oldTokens, "summary_tokens", summaryTokens, "saved_tokens", savedTokens)
+
+	return result, nil
+}
+
+// summaryUsage 读取摘要调用的完整用量，模型未返回用量时为 nil
+func summaryUsage(resp *schema.Message) *TokenUsage {
+	if resp.ResponseMeta == nil || resp.ResponseMeta.Usage == nil {
+		return nil
+	}
+	u := resp.ResponseMeta.Usage
+	total := u.TotalTokens
+	if total == 0 {
+		total = u.PromptTokens + u.CompletionTokens
+	}
+	return &TokenUsage{
+		PromptTokens:     int64(u.PromptTokens),
+		CompletionTokens: int64(u.CompletionTokens),
+		TotalTokens:      int64(total),
+		CachedTokens:     int64(u.PromptTokenDetails.CachedTokens),
+		ReasoningTokens:  int64(u.CompletionTokensDetails.ReasoningTokens),
+		LastUpdated:      time.Now(),
+	}
+}
+
+// messageText 消息的文本内容，多模态消息拼接其中的文本部分
+func messageText(msg *schema.Message) string {
+	if msg.Content != "" {
+		return msg.Content
+	}
+	var parts []string
+	for _, part := range msg.UserInputMultiContent {
+		if part.Type == schema.ChatMessagePartTypeText {
+			parts = append(parts, part.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// buildTranscript 将消息转换为用于摘要的对话文本
+func buildTranscript(messages []*schema.Message) string {
+	var sb strings.Builder
+	for _, msg := range messages {
+		content := strings.TrimSpace(messageText(msg))
+		if content == "" {
+			continue
+		}
+
+		runes := []rune(content)
+		if len(runes) > maxSummaryInputRunes {
+			content = string(runes[:maxSummaryInputRunes]) + "...(已截断)"
+		}
+
+		switch {
+		case IsSummaryMessage(msg):
+			sb.WriteString("此前的摘要: ")
+			content = strings.TrimPrefix(content, strings.TrimSpace(SummaryPrefix))
+		case msg.Role == schema.User:
+			sb.WriteString("用户: ")
+		case msg.Role == schema.Assistant:
+			sb.WriteString("助手: ")
+		case msg.Role == schema.Tool:
+			sb.WriteString("工具结果: ")
+		default:
+			sb.WriteString("系统: ")
+		}
+		sb.WriteString(strings.TrimSpace(content))
+		sb.WriteString("\n\n")
+	}
+	return sb.String()
+}
+
+func countMessages(rounds [][]*schema.Message) int {
+	n := 0
+	for _, round := range rounds {
+		n += len(round)
+	}
+	return n
+}
+
+// EstimateTokens 粗略估算文本的 Token 数：中日韩字符按 1 个计算，其余字符按 4 个 1 Token 计算
+func EstimateTokens(text string) int {
+	cjk, other := 0, 0
+	for _, r := range text {
+		if unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r) {
+			cjk++
+		} else {
+			other++
+		}
+	}
+	return cjk + (other+3)/4
+}
+
+// EstimateMessagesTokens 估算消息列表的 Token 数
+func EstimateMessagesTokens(messages []*schema.Message) int {
+	total := 0
+	for _, msg := range messages {
+		total += EstimateTokens(messageText(msg)) + EstimateTokens(msg.ReasoningContent) + 4
+	}
+	return total
+}
@@ -0,0 +1,161 @@
+package iano_agent
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// sequenceChatModel 按顺序返回预设回复的测试模型，并记录每次收到的消息
+type sequenceChatModel struct {
+	mu      sync.Mutex
+	replies []string
+	inputs  [][]*schema.Message
+}
+
+func (m *sequenceChatModel) next(input []*schema.Message) *schema.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.inputs = append(m.inputs, input)
+	reply := m.replies[0]
+	if len(m.replies) > 1 {
+		m.replies = m.replies[1:]
+	}
+	return schema.AssistantMessage(reply, nil)
+}
+
+func (m *sequenceChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	return m.next(input), nil
+}
+
+func (m *sequenceChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	return schema.StreamReaderFromArray([]*schema.Message{m.next(input)}), nil
+}
+
+func (m *sequenceChatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	return m, nil
+}
+
+// conversation 生成 n 轮对话，每轮包含用户消息和助手回复
+func conversation(n int) []*schema.Message {
+	messages := make([]*schema.Message, 0, 2*n)
+	for i := 1; i <= n; i++ {
+		messages = append(messages,
+			schema.UserMessage(strings.Repeat("问题", 50)+string(rune('0'+i))),
+			schema.AssistantMessage(strings.Repeat("回答", 50)+string(rune('0'+i)), nil),
+		)
+	}
+	return messages
+}
+
+func TestSplitRounds(t *testing.T) {
+	messages := []*schema.Message{
+		schema.SystemMessage("系统"),
+		NewSummaryMessage("旧摘要"),
+		schema.UserMessage("u1"),
+		schema.AssistantMessage("", []schema.ToolCall{{ID: "call_1"}}),
+		schema.ToolMessage("结果", "call_1"),
+		schema.AssistantMessage("a1", nil),
+		schema.UserMessage("u2"),
+	}
+
+	prefix, rounds := SplitRounds(messages)
+	if len(prefix) != 2 || !IsSummaryMessage(prefix[1]) {
+		t.Errorf("prefix = %+v", prefix)
+	}
+	if len(rounds) != 2 || len(rounds[0]) != 4 || len(rounds[1]) != 1 || rounds[1][0].Content != "u2" {
+		t.Errorf("rounds = %+v", rounds)
+	}
+
+	if prefix, rounds := SplitRounds(nil); len(prefix) != 0 || len(rounds) != 0 {
+		t.Errorf("SplitRounds(nil) = %v, %v", prefix, rounds)
+	}
+}
+
+func TestNeedsSummary(t *testing.T) {
+	tests := []struct {
+		rounds, keep int
+		want         bool
+	}{
+		{rounds: 4, keep: 2, want: false},
+		{rounds: 5, keep: 2, want: true},
+		{rounds: 8, keep: 0, want: false},
+		{rounds: 9, keep: 0, want: true},
+	}
+	for _, tt := range tests {
+		if got := NeedsSummary(conversation(tt.rounds), tt.keep); got != tt.want {
+			t.Errorf("NeedsSummary(%d rounds, keep %d) = %v, want %v", tt.rounds, tt.keep, got, tt.want)
+		}
+	}
+}
+
+func TestAgent_Summarize(t *testing.T) {
+	m := &sequenceChatModel{replies: []string{"  用户在问编号问题  "}}
+	a, err := NewAgent(m, WithAllowedTools([]string{"file_read"}))
+	if err != nil {
+		t.Fatalf("NewAgent() error = %v", err)
+	}
+
+	// 未超过保留轮数时不调用模型
+	result, err := a.Summarize(context.Background(), conversation(2), 2)
+	if err != nil || result.Compacted() || result.LastSummarizedIndex != -1 || len(m.inputs) != 0 {
+		t.Fatalf("Summarize() = %+v, %v, model calls = %d", result, err, len(m.inputs))
+	}
+
+	messages := append([]*schema.Message{schema.SystemMessage("系统"), NewSummaryMessage("更早的摘要")}, conversation(5)...)
+	result, err = a.Summarize(context.Background(), messages, 2)
+	if err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+	if !result.Compacted() || result.Summary != "用户在问编号问题" || result.RoundsSummarized != 3 {
+		t.Errorf("result = %+v", result)
+	}
+	// 前置系统消息和两轮原文保留，已有摘要与较早的三轮合并为新摘要
+	if result.LastSummarizedIndex != 7 || len(result.Messages) != 6 {
+		t.Fatalf("LastSummarizedIndex = %d, messages = %d", result.LastSummarizedIndex, len(result.Messages))
+	}
+	if result.Messages[0].Content != "系统" || result.Messages[1].Content != SummaryPrefix+"用户在问编号问题" || result.Messages[2] != messages[8] {
+		t.Errorf("compacted messages = %+v", result.Messages)
+	}
+	if result.OldTokens <= result.SummaryTokens || result.SavedTokens != result.OldTokens-result.SummaryTokens {
+		t.Errorf("tokens = %d old, %d summary, %d saved", result.OldTokens, result.SummaryTokens, result.SavedTokens)
+	}
+
+	transcript := m.inputs[0][1].Content
+	if !strings.Contains(transcript, "此前的摘要: 更早的摘要") || !strings.Contains(transcript, "回答3") || strings.Contains(transcript, "问题4") {
+		t.Errorf("summary prompt = %q", transcript)
+	}
+	if usage := a.GetTokenUsage(); usage.SummaryTokens != int64(result.SummaryTokens) || usage.SavedTokens != int64(result.SavedTokens) {
+		t.Errorf("token usage = %+v", usage)
+	}
+}
+
+func TestAgent_SummarizeMultiContent(t *testing.T) {
+	m := &sequenceChatModel{replies: []string{"用户发过截图"}}
+	a, err := NewAgent(m, WithAllowedTools([]string{"file_read"}))
+	if err != nil {
+		t.Fatalf("NewAgent() error = %v", err)
+	}
+
+	// 带图片的用户消息文本在多模态内容中，同样需要参与摘要
+	encoded := "iVBORw0KGgo="
+	messages := append([]*schema.Message{{
+		Role: schema.User,
+		UserInputMultiContent: []schema.MessageInputPart{
+			{Type: schema.ChatMessagePartTypeText, Text: "看看这张截图"},
+			{Type: schema.ChatMessagePartTypeImageURL, Image: &schema.MessageInputImage{
+				MessagePartCommon: schema.MessagePartCommon{Base64Data: &encoded, MIMEType: "image/png"},
+			}},
+		},
+	}, schema.AssistantMessage("截图里是登录页", nil)}, conversation(2)...)
+	if _, err := a.Summarize(context.Background(), messages, 1); err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+	if transcript := m.inputs[0][1].Content; !strings.Contains(transcript, "用户: 看看这张截图") {
+		t.Errorf("summary prompt = %q", transcript)
+	}
+}
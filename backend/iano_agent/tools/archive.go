@@ -189,12 +189,7 @@ func (t *ArchiveExtractTool) InvokableRun(ctx context.Context, argumentsInJSON s
 		return "", err
 	}
 
-	outputDir := args.Output
-	if outputDir == "" {
-		outputDir = filepath.Dir(absSource)
-	} else if !filepath.IsAbs(outputDir) {
-		outputDir = filepath.Join(t.basePath, outputDir)
-	}
+	outputDir := t.outputDir(absSource, args.Output)
 
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
 		return "", fmt.Errorf("创建目标目录失败: %w", err)
@@ -245,6 +240,17 @@ func (t *ArchiveExtractTool) InvokableRun(ctx context.Context, argumentsInJSON s
 	return fmt.Sprintf("已解压 %d 个文件到: %s", fileCount, outputDir), nil
 }
 
+// outputDir 解压目标目录，未指定时解压到压缩包所在目录
+func (t *ArchiveExtractTool) outputDir(absSource, output string) string {
+	if output == "" {
+		return filepath.Dir(absSource)
+	}
+	if !filepath.IsAbs(output) {
+		return filepath.Join(t.basePath, output)
+	}
+	return output
+}
+
 func (t *ArchiveExtractTool) resolvePath(path string) (string, error) {
 	absPath := path
 	if !filepath.IsAbs(path) {
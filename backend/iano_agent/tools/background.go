@@ -0,0 +1,743 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+const (
+	ProcessStartToolName  = "process_start"
+	ProcessOutputToolName = "process_output"
+	ProcessInputToolName  = "process_input"
+	ProcessStatusToolName = "process_status"
+	ProcessStopToolName   = "process_stop"
+
+	// DefaultMaxProcessesPerSession 每个会话默认最多同时运行的后台进程数
+	DefaultMaxProcessesPerSession = 5
+
+	// processOutputBufferSize 每个后台进程保留的最近输出字节数
+	processOutputBufferSize = 1 << 20
+	// defaultProcessOutputRead process_output 默认每次读取的字节数
+	defaultProcessOutputRead = 16 * 1024
+	// maxProcessOutputRead process_output 每次最多读取的字节数
+	maxProcessOutputRead = 64 * 1024
+	// maxProcessOutputWait process_output 等待新输出的最长时间
+	maxProcessOutputWait = 30 * time.Second
+	// processStopTimeout 停止进程时等待其自行退出的时间，超时后强制结束
+	processStopTimeout = 5 * time.Second
+)
+
+// 后台进程状态
+const (
+	ProcessStatusRunning = "running" // 运行中
+	ProcessStatusExited  = "exited"  // 自行退出
+	ProcessStatusStopped = "stopped" // 被停止
+)
+
+// processNamePattern 后台进程名称只能包含字母、数字、下划线、点和短横线
+var processNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// ErrProcessNotFound 会话中没有指定名称的后台进程
+var ErrProcessNotFound = errors.New("后台进程不存在")
+
+// ProcessInfo 后台进程的状态
+type ProcessInfo struct {
+	Name       string     `json:"name"`
+	Command    string     `json:"command"`
+	Dir        string     `json:"dir"`
+	PID        int        `json:"pid"`
+	Status     string     `json:"status"`
+	ExitCode   *int       `json:"exit_code,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	ExitedAt   *time.Time `json:"exited_at,omitempty"`
+	OutputSize int64      `json:"output_size"` // 累计输出字节数，即当前输出末尾的偏移
+}
+
+// ProcessOutput process_output 的结果，偏移按进程启动以来的累计输出字节计算
+type ProcessOutput struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	ExitCode   *int   `json:"exit_code,omitempty"`
+	Offset     int64  `json:"offset"`            // 返回内容的起始偏移
+	NextOffset int64  `json:"next_offset"`       // 下次读取时传入的偏移
+	Output     string `json:"output"`            // 标准输出和标准错误按产生顺序合并
+	More       bool   `json:"more"`              // next_offset 之后还有已产生的输出
+	Dropped    int64  `json:"dropped,omitempty"` // 请求的偏移之后已被丢弃的字节数，只保留最近的输出
+}
+
+// ProcessStartOptions 启动后台进程的参数
+type ProcessStartOptions struct {
+	Name    string
+	Command string    // 通过 Shell 执行的命令
+	Dir     string    // 工作目录（绝对路径）
+	Shell   ShellType // 为空时 Windows 使用 PowerShell，其他系统使用 bash
+}
+
+// backgroundProcess 一个后台进程，标准输出和标准错误写入同一个管道，只保留最近的输出
+type backgroundProcess struct {
+	mu       sync.Mutex
+	info     ProcessInfo
+	stdin    io.WriteCloser
+	output   outputBuffer
+	exited   chan struct{} // 进程退出时关闭
+	stopping bool
+}
+
+// ProcessManager 按会话管理后台进程
+//
+// 每个会话最多同时运行 maxPerSession 个进程，已退出的进程保留状态和输出，
+// 直到启动同名进程、数量达到上限时被最早退出的进程让位，或会话结束。
+type ProcessManager struct {
+	mu            sync.Mutex
+	maxPerSession int
+	sessions      map[string]map[string]*backgroundProcess
+	closed        bool
+}
+
+// NewProcessManager 创建后台进程管理器，maxPerSession 不大于 0 时使用默认值
+func NewProcessManager(maxPerSession int) *ProcessManager {
+	if maxPerSession <= 0 {
+		maxPerSession = DefaultMaxProcessesPerSession
+	}
+	return &ProcessManager{
+		maxPerSession: maxPerSession,
+		sessions:      make(map[string]map[string]*backgroundProcess),
+	}
+}
+
+// Start 在会话中启动后台进程，同名进程仍在运行时返回错误
+func (m *ProcessManager) Start(session string, opts ProcessStartOptions) (*ProcessInfo, error) {
+	if !processNamePattern.MatchString(opts.Name) {
+		return nil, fmt.Errorf("进程名称只能包含字母、数字、下划线、点和短横线，且不超过 64 个字符")
+	}
+	if strings.TrimSpace(opts.Command) == "" {
+		return nil, fmt.Errorf("命令不能为空")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return nil, fmt.Errorf("后台进程管理器已关闭")
+	}
+
+	processes := m.sessions[session]
+	if processes == nil {
+		processes = make(map[string]*backgroundProcess)
+		m.sessions[session] = processes
+	}
+	if p, ok := processes[opts.Name]; ok {
+		if p.running() {
+			return nil, fmt.Errorf("后台进程 %s 正在运行，请先停止或换一个名称", opts.Name)
+		}
+		delete(processes, opts.Name)
+	}
+	if len(processes) >= m.maxPerSession && !evictExited(processes) {
+		return nil, fmt.Errorf("会话中已有 %d 个后台进程在运行，达到上限，请先停止不需要的进程", len(processes))
+	}
+
+	p, err := startProcess(opts)
+	if err != nil {
+		return nil, err
+	}
+	processes[opts.Name] = p
+	slog.Info("启动后台进程", "session", session, "name", opts.Name, "pid", p.info.PID, "command", opts.Command)
+	return p.snapshot(), nil
+}
+
+// evictExited 移除最早退出的进程，没有已退出的进程时返回 false
+func evictExited(processes map[string]*backgroundProcess) bool {
+	var oldest string
+	var oldestAt time.Time
+	for name, p := range processes {
+		info := p.snapshot()
+		if info.ExitedAt != nil && (oldest == "" || info.ExitedAt.Before(oldestAt)) {
+			oldest, oldestAt = name, *info.ExitedAt
+		}
+	}
+	if oldest == "" {
+		return false
+	}
+	delete(processes, oldest)
+	return true
+}
+
+func (m *ProcessManager) get(session, name string) (*backgroundProcess, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, ok := m.sessions[session][name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrProcessNotFound, name)
+	}
+	return p, nil
+}
+
+// Status 返回后台进程的状态
+func (m *ProcessManager) Status(session, name string) (*ProcessInfo, error) {
+	p, err := m.get(session, name)
+	if err != nil {
+		return nil, err
+	}
+	return p.snapshot(), nil
+}
+
+// List 返回会话中的全部后台进程，按启动时间排序
+func (m *ProcessManager) List(session string) []*ProcessInfo {
+	m.mu.Lock()
+	list := make([]*ProcessInfo, 0, len(m.sessions[session]))
+	for _, p := range m.sessions[session] {
+		list = append(list, p.snapshot())
+	}
+	m.mu.Unlock()
+
+	sort.Slice(list, func(i, j int) bool { return list[i].StartedAt.Before(list[j].StartedAt) })
+	return list
+}
+
+// Output 从 offset 开始读取最多 maxBytes 字节的输出，offset 小于 0 时返回最近的输出
+//
+// 没有新输出且进程仍在运行时最多等待 wait，期间有新输出或进程退出立即返回。
+func (m *ProcessManager) Output(ctx context.Context, session, name string, offset int64, maxBytes int, wait time.Duration) (*ProcessOutput, error) {
+	p, err := m.get(session, name)
+	if err != nil {
+		return nil, err
+	}
+	if maxBytes <= 0 {
+		maxBytes = defaultProcessOutputRead
+	}
+	maxBytes = min(maxBytes, maxProcessOutputRead)
+	wait = min(wait, maxProcessOutputWait)
+
+	if offset >= 0 {
+		if err := waitForOutput(ctx, &p.mu, &p.output, p.exited, offset, wait); err != nil {
+			return nil, err
+		}
+	}
+	return p.read(offset, maxBytes), nil
+}
+
+// Input 向后台进程的标准输入写入内容，closeStdin 为 true 时写入后关闭标准输入
+func (m *ProcessManager) Input(session, name, input string, closeStdin bool) error {
+	p, err := m.get(session, name)
+	if err != nil {
+		return err
+	}
+	if !p.running() {
+		return fmt.Errorf("后台进程 %s 已退出", name)
+	}
+	if input != "" {
+		if _, err := io.WriteString(p.stdin, input); err != nil {
+			return fmt.Errorf("写入标准输入失败: %w", err)
+		}
+	}
+	if closeStdin {
+		return p.stdin.Close()
+	}
+	return nil
+}
+
+// Stop 停止后台进程及其子进程，先请求进程退出，超时后强制结束
+func (m *ProcessManager) Stop(session, name string) (*ProcessInfo, error) {
+	p, err := m.get(session, name)
+	if err != nil {
+		return nil, err
+	}
+	p.stop()
+	slog.Info("停止后台进程", "session", session, "name", name)
+	return p.snapshot(), nil
+}
+
+// StopSession 停止会话中的全部后台进程并清除记录，会话结束时调用
+func (m *ProcessManager) StopSession(session string) {
+	m.mu.Lock()
+	processes := m.sessions[session]
+	delete(m.sessions, session)
+	m.mu.Unlock()
+
+	stopAll(processes)
+	if len(processes) > 0 {
+		slog.Info("会话结束，已停止后台进程", "session", session, "count", len(processes))
+	}
+}
+
+// Shutdown 停止全部会话的后台进程，之后不能再启动新进程，服务关闭时调用
+func (m *ProcessManager) Shutdown() {
+	m.mu.Lock()
+	m.closed = true
+	sessions := m.sessions
+	m.sessions = make(map[string]map[string]*backgroundProcess)
+	m.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, processes := range sessions {
+		wg.Add(1)
+		go func(processes map[string]*backgroundProcess) {
+			defer wg.Done()
+			stopAll(processes)
+		}(processes)
+	}
+	wg.Wait()
+}
+
+// stopAll 并发停止多个进程
+func stopAll(processes map[string]*backgroundProcess) {
+	var wg sync.WaitGroup
+	for _, p := range processes {
+		wg.Add(1)
+		go func(p *backgroundProcess) {
+			defer wg.Done()
+			p.stop()
+		}(p)
+	}
+	wg.Wait()
+}
+
+func startProcess(opts ProcessStartOptions) (*backgroundProcess, error) {
+	shell := opts.Shell
+	if shell == "" {
+		shell = ShellBash
+		if runtime.GOOS == "windows" {
+			shell = ShellPowerShell
+		}
+	}
+	var cmd *exec.Cmd
+	switch shell {
+	case ShellPowerShell:
+		cmd = exec.Command("powershell", "-NoProfile", "-Command", opts.Command)
+	case ShellCmd:
+		cmd = exec.Command("cmd", "/C", opts.Command)
+	default:
+		cmd = exec.Command("bash", "-c", opts.Command)
+	}
+	cmd.Dir = opts.Dir
+	setProcessGroup(cmd)
+
+	// 输出使用 *os.File，进程退出时 Wait 不必等待仍持有管道的子进程
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("创建输出管道失败: %w", err)
+	}
+	cmd.Stdout = w
+	cmd.Stderr = w
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		r.Close()
+		w.Close()
+		return nil, fmt.Errorf("创建输入管道失败: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		r.Close()
+		w.Close()
+		return nil, fmt.Errorf("启动进程失败: %w", err)
+	}
+	w.Close()
+
+	p := &backgroundProcess{
+		info: ProcessInfo{
+			Name:      opts.Name,
+			Command:   opts.Command,
+			Dir:       opts.Dir,
+			PID:       cmd.Process.Pid,
+			Status:    ProcessStatusRunning,
+			StartedAt: time.Now(),
+		},
+		stdin:  stdin,
+		output: newOutputBuffer(processOutputBufferSize),
+		exited: make(chan struct{}),
+	}
+
+	go func() {
+		defer r.Close()
+		chunk := make([]byte, 32*1024)
+		for {
+			n, err := r.Read(chunk)
+			if n > 0 {
+				p.appendOutput(chunk[:n])
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	go func() {
+		cmd.Wait()
+		exitCode := cmd.ProcessState.ExitCode()
+		now := time.Now()
+
+		p.mu.Lock()
+		p.info.ExitCode = &exitCode
+		p.info.ExitedAt = &now
+		p.info.Status = ProcessStatusExited
+		if p.stopping {
+			p.info.Status = ProcessStatusStopped
+		}
+		p.mu.Unlock()
+		close(p.exited)
+	}()
+	return p, nil
+}
+
+func (p *backgroundProcess) running() bool {
+	select {
+	case <-p.exited:
+		return false
+	default:
+		return true
+	}
+}
+
+func (p *backgroundProcess) snapshot() *ProcessInfo {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	info := p.info
+	info.OutputSize = p.output.end()
+	return &info
+}
+
+func (p *backgroundProcess) appendOutput(data []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.output.write(data)
+}
+
+func (p *backgroundProcess) read(offset int64, maxBytes int) *ProcessOutput {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	out := &ProcessOutput{Name: p.info.Name, Status: p.info.Status, ExitCode: p.info.ExitCode}
+	data := p.output.read(offset, maxBytes)
+	out.Offset = data.offset
+	out.NextOffset = data.next
+	out.Output = string(data.data)
+	out.More = data.more
+	out.Dropped = data.dropped
+	return out
+}
+
+// stop 请求进程组退出，超时后强制结束；进程已退出时结束其遗留的子进程
+func (p *backgroundProcess) stop() {
+	p.mu.Lock()
+	if p.running() {
+		p.stopping = true
+	}
+	pid := p.info.PID
+	p.mu.Unlock()
+
+	if p.running() {
+		if err := signalProcessGroup(pid, false); err != nil {
+			slog.Warn("停止后台进程失败", "pid", pid, "error", err)
+		}
+		select {
+		case <-p.exited:
+		case <-time.After(processStopTimeout):
+		}
+	}
+	signalProcessGroup(pid, true)
+	select {
+	case <-p.exited:
+	case <-time.After(processStopTimeout):
+		slog.Warn("后台进程未能结束", "pid", pid)
+	}
+}
+
+// NewProcessTools 创建绑定会话的后台进程工具，进程在 basePath 下运行
+func NewProcessTools(manager *ProcessManager, session, basePath string) map[string]tool.InvokableTool {
+	base := processTool{manager: manager, session: session, basePath: basePath}
+	return map[string]tool.InvokableTool{
+		ProcessStartToolName:  &ProcessStartTool{base},
+		ProcessOutputToolName: &ProcessOutputTool{base},
+		ProcessInputToolName:  &ProcessInputTool{base},
+		ProcessStatusToolName: &ProcessStatusTool{base},
+		ProcessStopToolName:   &ProcessStopTool{base},
+	}
+}
+
+type processTool struct {
+	manager  *ProcessManager
+	session  string
+	basePath string
+}
+
+// resolveDir 解析进程的工作目录，不能超出 basePath
+func (t processTool) resolveDir(dir string) (string, error) {
+	return resolveWorkDir(t.basePath, dir)
+}
+
+// resolveWorkDir 将 dir 解析为 basePath 下已存在的目录，basePath 为空时使用当前目录
+func resolveWorkDir(basePath, dir string) (string, error) {
+	if basePath == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		basePath = wd
+	}
+	absPath := dir
+	if !filepath.IsAbs(dir) {
+		absPath = filepath.Join(basePath, dir)
+	}
+	absPath = filepath.Clean(absPath)
+
+	rel, err := filepath.Rel(basePath, absPath)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("路径超出允许范围")
+	}
+	if err := checkRealPath(basePath, absPath); err != nil {
+		return "", err
+	}
+	info, err := os.Stat(absPath)
+	if err != nil || !info.IsDir() {
+		return "", fmt.Errorf("目录不存在: %s", dir)
+	}
+	return absPath, nil
+}
+
+func processResult(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// ProcessStartTool 启动后台进程
+type ProcessStartTool struct{ processTool }
+
+func (t *ProcessStartTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: ProcessStartToolName,
+		Desc: "在工作目录中启动一个长时间运行的后台进程（例如开发服务器、文件监听），不会因超时被结束。启动后用 process_output 查看输出，用 process_stop 停止",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"name": {
+				Type:     schema.String,
+				Desc:     "进程名称，用于后续操作，只能包含字母、数字、下划线、点和短横线，例如 dev-server",
+				Required: true,
+			},
+			"command": {
+				Type:     schema.String,
+				Desc:     "要执行的 Shell 命令，例如 npm run dev",
+				Required: true,
+			},
+			"dir": {
+				Type:     schema.String,
+				Desc:     "相对工作目录的子目录，默认为工作目录",
+				Required: false,
+			},
+		}),
+	}, nil
+}
+
+func (t *ProcessStartTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	var args struct {
+		Name    string `json:"name"`
+		Command string `json:"command"`
+		Dir     string `json:"dir"`
+	}
+	if err := json.Unmarshal([]byte(argumentsInJSON), &args); err != nil {
+		return "", fmt.Errorf("参数解析失败: %w", err)
+	}
+	if hasDangerousContent(args.Command) {
+		return "", fmt.Errorf("命令包含危险内容")
+	}
+	dir, err := t.resolveDir(args.Dir)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := t.manager.Start(t.session, ProcessStartOptions{Name: args.Name, Command: args.Command, Dir: dir})
+	if err != nil {
+		return "", err
+	}
+	return processResult(info)
+}
+
+// ProcessOutputTool 读取后台进程的输出
+type ProcessOutputTool struct{ processTool }
+
+func (t *ProcessOutputTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: ProcessOutputToolName,
+		Desc: "读取后台进程的输出（标准输出和标准错误合并）。传入上次结果中的 next_offset 只读取新输出；省略 offset 时返回最近的输出",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"name": {
+				Type:     schema.String,
+				Desc:     "进程名称",
+				Required: true,
+			},
+			"offset": {
+				Type:     schema.Number,
+				Desc:     "从该偏移开始读取",
+				Required: false,
+			},
+			"max_bytes": {
+				Type:     schema.Number,
+				Desc:     fmt.Sprintf("最多读取的字节数，默认 %d，最大 %d", defaultProcessOutputRead, maxProcessOutputRead),
+				Required: false,
+			},
+			"wait": {
+				Type:     schema.Number,
+				Desc:     fmt.Sprintf("没有新输出时最多等待的秒数，最大 %d，期间有新输出或进程退出立即返回，需要同时传入 offset", int(maxProcessOutputWait/time.Second)),
+				Required: false,
+			},
+		}),
+	}, nil
+}
+
+func (t *ProcessOutputTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	var args struct {
+		Name     string `json:"name"`
+		Offset   *int64 `json:"offset"`
+		MaxBytes int    `json:"max_bytes"`
+		Wait     int    `json:"wait"`
+	}
+	if err := json.Unmarshal([]byte(argumentsInJSON), &args); err != nil {
+		return "", fmt.Errorf("参数解析失败: %w", err)
+	}
+	offset := int64(-1)
+	if args.Offset != nil && *args.Offset >= 0 {
+		offset = *args.Offset
+	}
+
+	out, err := t.manager.Output(ctx, t.session, args.Name, offset, args.MaxBytes, time.Duration(args.Wait)*time.Second)
+	if err != nil {
+		return "", err
+	}
+	return processResult(out)
+}
+
+// ProcessInputTool 向后台进程的标准输入写入内容
+type ProcessInputTool struct{ processTool }
+
+func (t *ProcessInputTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: ProcessInputToolName,
+		Desc: "向后台进程的标准输入发送一行内容，例如回答交互式提示",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"name": {
+				Type:     schema.String,
+				Desc:     "进程名称",
+				Required: true,
+			},
+			"input": {
+				Type:     schema.String,
+				Desc:     "要发送的内容，默认在末尾追加换行",
+				Required: false,
+			},
+			"no_newline": {
+				Type:     schema.Boolean,
+				Desc:     "不在末尾追加换行",
+				Required: false,
+			},
+			"close": {
+				Type:     schema.Boolean,
+				Desc:     "发送后关闭标准输入（EOF）",
+				Required: false,
+			},
+		}),
+	}, nil
+}
+
+func (t *ProcessInputTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	var args struct {
+		Name      string `json:"name"`
+		Input     string `json:"input"`
+		NoNewline bool   `json:"no_newline"`
+		Close     bool   `json:"close"`
+	}
+	if err := json.Unmarshal([]byte(argumentsInJSON), &args); err != nil {
+		return "", fmt.Errorf("参数解析失败: %w", err)
+	}
+	input := args.Input
+	if !args.NoNewline && !args.Close {
+		input += "\n"
+	}
+
+	if err := t.manager.Input(t.session, args.Name, input, args.Close); err != nil {
+		return "", err
+	}
+	return processResult(map[string]interface{}{"name": args.Name, "written": len(input), "closed": args.Close})
+}
+
+// ProcessStatusTool 查看后台进程的状态
+type ProcessStatusTool struct{ processTool }
+
+func (t *ProcessStatusTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: ProcessStatusToolName,
+		Desc: "查看后台进程的状态（running/exited/stopped）、退出码和输出大小，省略名称时列出当前会话的全部后台进程",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"name": {
+				Type:     schema.String,
+				Desc:     "进程名称",
+				Required: false,
+			},
+		}),
+	}, nil
+}
+
+func (t *ProcessStatusTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	var args struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal([]byte(argumentsInJSON), &args); err != nil {
+		return "", fmt.Errorf("参数解析失败: %w", err)
+	}
+	if args.Name == "" {
+		return processResult(t.manager.List(t.session))
+	}
+
+	info, err := t.manager.Status(t.session, args.Name)
+	if err != nil {
+		return "", err
+	}
+	return processResult(info)
+}
+
+// ProcessStopTool 停止后台进程
+type ProcessStopTool struct{ processTool }
+
+func (t *ProcessStopTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: ProcessStopToolName,
+		Desc: "停止后台进程及其启动的全部子进程",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"name": {
+				Type:     schema.String,
+				Desc:     "进程名称",
+				Required: true,
+			},
+		}),
+	}, nil
+}
+
+func (t *ProcessStopTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	var args struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal([]byte(argumentsInJSON), &args); err != nil {
+		return "", fmt.Errorf("参数解析失败: %w", err)
+	}
+
+	info, err := t.manager.Stop(t.session, args.Name)
+	if err != nil {
+		return "", err
+	}
+	return processResult(info)
+}
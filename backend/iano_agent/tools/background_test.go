@@ -0,0 +1,225 @@
+//go:build !windows
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// waitOutput 等待进程输出中出现 want，返回读到的全部输出
+func waitOutput(t *testing.T, m *ProcessManager, session, name, want string) string {
+	t.Helper()
+	var output strings.Builder
+	offset := int64(0)
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		out, err := m.Output(context.Background(), session, name, offset, 0, time.Second)
+		if err != nil {
+			t.Fatalf("Output() error = %v", err)
+		}
+		output.WriteString(out.Output)
+		offset = out.NextOffset
+		if strings.Contains(output.String(), want) {
+			return output.String()
+		}
+	}
+	t.Fatalf("output of %s does not contain %q: %q", name, want, output.String())
+	return ""
+}
+
+// processAlive 进程是否仍在运行，已结束但未被回收的僵尸进程视为已结束
+func processAlive(pid int) bool {
+	if stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid)); err == nil {
+		fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+		return len(fields) > 0 && fields[0] != "Z"
+	}
+	return syscall.Kill(pid, 0) == nil
+}
+
+func TestProcessManager_OutputAndInput(t *testing.T) {
+	m := NewProcessManager(2)
+	defer m.Shutdown()
+	dir := t.TempDir()
+
+	info, err := m.Start("s1", ProcessStartOptions{
+		Name:    "echo",
+		Command: `pwd; echo ready; while read line; do echo "got $line"; echo "err $line" >&2; done; echo bye`,
+		Dir:     dir,
+	})
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if info.Status != ProcessStatusRunning || info.PID == 0 {
+		t.Fatalf("info = %+v", info)
+	}
+
+	output := waitOutput(t, m, "s1", "echo", "ready\n")
+	if realDir, _ := filepath.EvalSymlinks(dir); !strings.HasPrefix(output, realDir+"\n") && !strings.HasPrefix(output, dir+"\n") {
+		t.Errorf("process did not run in %s: %q", dir, output)
+	}
+
+	if err := m.Input("s1", "echo", "hello\n", false); err != nil {
+		t.Fatalf("Input() error = %v", err)
+	}
+	waitOutput(t, m, "s1", "echo", "err hello\n")
+
+	// 按偏移分段读取
+	first, err := m.Output(context.Background(), "s1", "echo", 0, 3, 0)
+	if err != nil || first.Output != output[:3] || first.NextOffset != 3 || !first.More {
+		t.Fatalf("Output(0, 3) = %+v, %v", first, err)
+	}
+	// 省略偏移时返回最近的输出
+	tail, _ := m.Output(context.Background(), "s1", "echo", -1, 10, 0)
+	if tail.Output != "err hello\n" {
+		t.Errorf("tail output = %q", tail.Output)
+	}
+
+	// 关闭标准输入后进程自行退出
+	if err := m.Input("s1", "echo", "", true); err != nil {
+		t.Fatalf("Input(close) error = %v", err)
+	}
+	waitOutput(t, m, "s1", "echo", "bye\n")
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if info, _ = m.Status("s1", "echo"); info.Status != ProcessStatusRunning {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if info.Status != ProcessStatusExited || info.ExitCode == nil || *info.ExitCode != 0 {
+		t.Errorf("status after close = %+v", info)
+	}
+	if err := m.Input("s1", "echo", "again\n", false); err == nil {
+		t.Error("Input() to exited process error = nil")
+	}
+
+	// 其他会话看不到该进程
+	if _, err := m.Status("s2", "echo"); !errors.Is(err, ErrProcessNotFound) {
+		t.Errorf("Status() from another session error = %v", err)
+	}
+}
+
+func TestProcessManager_StopAndLimit(t *testing.T) {
+	m := NewProcessManager(2)
+	defer m.Shutdown()
+	dir := t.TempDir()
+
+	// 子进程在后台运行，停止时需要结束整个进程组
+	start := func(name string) *ProcessInfo {
+		t.Helper()
+		info, err := m.Start("s1", ProcessStartOptions{Name: name, Command: "sleep 60 & echo $!; wait", Dir: dir})
+		if err != nil {
+			t.Fatalf("Start(%s) error = %v", name, err)
+		}
+		return info
+	}
+	start("a")
+	start("b")
+	if _, err := m.Start("s1", ProcessStartOptions{Name: "c", Command: "sleep 60", Dir: dir}); err == nil || !strings.Contains(err.Error(), "上限") {
+		t.Errorf("Start() over limit error = %v", err)
+	}
+	if _, err := m.Start("s1", ProcessStartOptions{Name: "a", Command: "true", Dir: dir}); err == nil || !strings.Contains(err.Error(), "正在运行") {
+		t.Errorf("Start() with running name error = %v", err)
+	}
+	if _, err := m.Start("s1", ProcessStartOptions{Name: "bad name", Command: "true", Dir: dir}); err == nil {
+		t.Error("Start() with invalid name error = nil")
+	}
+	// 上限按会话计算
+	if _, err := m.Start("s2", ProcessStartOptions{Name: "a", Command: "sleep 60", Dir: dir}); err != nil {
+		t.Errorf("Start() in another session error = %v", err)
+	}
+
+	var childPID int
+	output := waitOutput(t, m, "s1", "a", "\n")
+	if err := json.Unmarshal([]byte(strings.TrimSpace(output)), &childPID); err != nil {
+		t.Fatalf("child pid %q: %v", output, err)
+	}
+
+	info, err := m.Stop("s1", "a")
+	if err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if info.Status != ProcessStatusStopped || info.ExitedAt == nil {
+		t.Errorf("info after stop = %+v", info)
+	}
+	// 信号异步送达，稍等子进程退出
+	for deadline := time.Now().Add(2 * time.Second); processAlive(childPID) && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if processAlive(childPID) {
+		t.Errorf("child process %d still running after stop", childPID)
+	}
+
+	// 已停止的进程让出名额
+	if _, err := m.Start("s1", ProcessStartOptions{Name: "c", Command: "sleep 60", Dir: dir}); err != nil {
+		t.Errorf("Start() after stop error = %v", err)
+	}
+	if list := m.List("s1"); len(list) != 2 || list[0].Name != "b" || list[1].Name != "c" {
+		t.Errorf("List() = %+v", list)
+	}
+
+	m.StopSession("s1")
+	if list := m.List("s1"); len(list) != 0 {
+		t.Errorf("List() after StopSession = %+v", list)
+	}
+	if list := m.List("s2"); len(list) != 1 || list[0].Status != ProcessStatusRunning {
+		t.Errorf("List(s2) after StopSession(s1) = %+v", list)
+	}
+
+	m.Shutdown()
+	if _, err := m.Start("s2", ProcessStartOptions{Name: "d", Command: "true", Dir: dir}); err == nil {
+		t.Error("Start() after Shutdown error = nil")
+	}
+}
+
+func TestProcessTools(t *testing.T) {
+	m := NewProcessManager(0)
+	defer m.Shutdown()
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "web"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	tools := NewProcessTools(m, "s1", dir)
+
+	if _, err := tools[ProcessStartToolName].InvokableRun(context.Background(), `{"name": "x", "command": "true", "dir": "../"}`); err == nil || !strings.Contains(err.Error(), "路径超出允许范围") {
+		t.Errorf("start outside work dir error = %v", err)
+	}
+
+	var info ProcessInfo
+	out, err := tools[ProcessStartToolName].InvokableRun(context.Background(), `{"name": "dev", "command": "echo listening; sleep 60", "dir": "web"}`)
+	if err != nil {
+		t.Fatalf("process_start error = %v", err)
+	}
+	json.Unmarshal([]byte(out), &info)
+	if info.Name != "dev" || info.Dir != filepath.Join(dir, "web") {
+		t.Errorf("process_start result = %s", out)
+	}
+
+	var output ProcessOutput
+	out, err = tools[ProcessOutputToolName].InvokableRun(context.Background(), `{"name": "dev", "offset": 0, "wait": 5}`)
+	if err != nil {
+		t.Fatalf("process_output error = %v", err)
+	}
+	json.Unmarshal([]byte(out), &output)
+	if output.Output != "listening\n" || output.NextOffset != 10 || output.Status != ProcessStatusRunning {
+		t.Errorf("process_output result = %s", out)
+	}
+
+	out, err = tools[ProcessStopToolName].InvokableRun(context.Background(), `{"name": "dev"}`)
+	if err != nil || !strings.Contains(out, `"status":"stopped"`) {
+		t.Errorf("process_stop = %s, %v", out, err)
+	}
+	out, err = tools[ProcessStatusToolName].InvokableRun(context.Background(), `{}`)
+	if err != nil || !strings.Contains(out, `"name":"dev"`) {
+		t.Errorf("process_status = %s, %v", out, err)
+	}
+}
@@ -0,0 +1,165 @@
+package tools
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudwego/eino/components/tool"
+)
+
+// MaxCheckpointFiles 一次工具调用最多为多少个文件创建检查点
+const MaxCheckpointFiles = 1000
+
+// FileChangingTool 会修改文件的工具，执行前按调用参数返回将被写入、创建或删除的文件，
+// 调用方据此在执行前保存文件原内容（检查点），以便撤销。
+//
+// 返回的路径为绝对路径，可以包含尚不存在的文件；参数无效导致工具不会修改任何文件时返回空列表。
+type FileChangingTool interface {
+	ChangedFiles(argumentsInJSON string) ([]string, error)
+}
+
+// ChangedFiles 返回工具调用将修改的文件，工具未实现 FileChangingTool 时返回 nil
+func ChangedFiles(t tool.BaseTool, argumentsInJSON string) ([]string, error) {
+	c, ok := t.(FileChangingTool)
+	if !ok {
+		return nil, nil
+	}
+	paths, err := c.ChangedFiles(argumentsInJSON)
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) > MaxCheckpointFiles {
+		return nil, fmt.Errorf("将修改 %d 个文件，超过检查点上限 %d 个", len(paths), MaxCheckpointFiles)
+	}
+	return paths, nil
+}
+
+// pathArgument 解析只有一个 path 参数决定目标的工具参数，参数无效时返回空字符串
+func pathArgument(argumentsInJSON string, resolve func(string) (string, error)) string {
+	var args struct {
+		Path string `json:"path"`
+	}
+	if err := json.Unmarshal([]byte(argumentsInJSON), &args); err != nil || args.Path == "" {
+		return ""
+	}
+	absPath, err := resolve(args.Path)
+	if err != nil {
+		return ""
+	}
+	return absPath
+}
+
+func (t *FileWriteTool) ChangedFiles(argumentsInJSON string) ([]string, error) {
+	if path := pathArgument(argumentsInJSON, t.resolvePath); path != "" {
+		return []string{path}, nil
+	}
+	return nil, nil
+}
+
+func (t *FileCreateTool) ChangedFiles(argumentsInJSON string) ([]string, error) {
+	var args struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal([]byte(argumentsInJSON), &args); err != nil || args.Type == "dir" {
+		return nil, nil
+	}
+	if path := pathArgument(argumentsInJSON, t.resolvePath); path != "" {
+		return []string{path}, nil
+	}
+	return nil, nil
+}
+
+func (t *GrepReplaceTool) ChangedFiles(argumentsInJSON string) ([]string, error) {
+	if path := pathArgument(argumentsInJSON, t.resolvePath); path != "" {
+		return []string{path}, nil
+	}
+	return nil, nil
+}
+
+// ChangedFiles 删除目录时返回目录下的全部文件
+func (t *FileDeleteTool) ChangedFiles(argumentsInJSON string) ([]string, error) {
+	path := pathArgument(argumentsInJSON, t.resolvePath)
+	if path == "" {
+		return nil, nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	var paths []string
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			paths = append(paths, p)
+			if len(paths) > MaxCheckpointFiles {
+				return fs.SkipAll
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("遍历目录失败: %w", err)
+	}
+	return paths, nil
+}
+
+// ChangedFiles 返回压缩包中每个文件解压后的路径
+func (t *ArchiveExtractTool) ChangedFiles(argumentsInJSON string) ([]string, error) {
+	var args struct {
+		Source string `json:"source"`
+		Output string `json:"output"`
+	}
+	if err := json.Unmarshal([]byte(argumentsInJSON), &args); err != nil {
+		return nil, nil
+	}
+	absSource, err := t.resolvePath(args.Source)
+	if err != nil {
+		return nil, nil
+	}
+
+	zipReader, err := zip.OpenReader(absSource)
+	if err != nil {
+		return nil, nil
+	}
+	defer zipReader.Close()
+
+	outputDir := t.outputDir(absSource, args.Output)
+	var paths []string
+	for _, file := range zipReader.File {
+		if !file.FileInfo().IsDir() {
+			paths = append(paths, filepath.Join(outputDir, file.Name))
+		}
+	}
+	return paths, nil
+}
+
+// ChangedFiles 补丁无法应用或只预览时不会修改文件
+func (t *FilePatchTool) ChangedFiles(argumentsInJSON string) ([]string, error) {
+	var args struct {
+		Patch  string `json:"patch"`
+		Path   string `json:"path"`
+		DryRun bool   `json:"dry_run"`
+	}
+	if err := json.Unmarshal([]byte(argumentsInJSON), &args); err != nil || args.DryRun {
+		return nil, nil
+	}
+	results, err := t.prepare(args.Path, args.Patch)
+	if err != nil {
+		return nil, nil
+	}
+	paths := make([]string, 0, len(results))
+	for _, r := range results {
+		paths = append(paths, r.absPath)
+	}
+	return paths, nil
+}
@@ -0,0 +1,99 @@
+package tools
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/tool"
+)
+
+func TestChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	writePatchFile(t, dir, "main.go", patchSample)
+	if err := os.MkdirAll(filepath.Join(dir, "docs", "api"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writePatchFile(t, dir, "docs/a.md", "a\n")
+	writePatchFile(t, dir, "docs/api/b.md", "b\n")
+
+	zipFile, err := os.Create(filepath.Join(dir, "bundle.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(zipFile)
+	for _, name := range []string{"pkg/", "pkg/x.txt", "y.txt"} {
+		if _, err := zw.Create(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	zw.Close()
+	zipFile.Close()
+
+	abs := func(names ...string) []string {
+		paths := make([]string, 0, len(names))
+		for _, name := range names {
+			paths = append(paths, filepath.Join(dir, name))
+		}
+		return paths
+	}
+
+	tests := []struct {
+		name string
+		tool tool.BaseTool
+		args string
+		want []string
+	}{
+		{"写入文件", NewFileWriteTool(dir), `{"path": "new/file.txt", "content": "x"}`, abs("new/file.txt")},
+		{"写入超出范围", NewFileWriteTool(dir), `{"path": "../x.txt", "content": "x"}`, nil},
+		{"创建文件", NewFileCreateTool(dir), `{"path": "c.txt"}`, abs("c.txt")},
+		{"创建目录", NewFileCreateTool(dir), `{"path": "d", "type": "dir"}`, nil},
+		{"替换内容", NewGrepReplaceTool(dir), `{"path": "main.go", "pattern": "a", "replacement": "b"}`, abs("main.go")},
+		{"删除目录", NewFileDeleteTool(dir), `{"path": "docs", "recursive": true}`, abs("docs/a.md", "docs/api/b.md")},
+		{"删除不存在的文件", NewFileDeleteTool(dir), `{"path": "missing.txt"}`, nil},
+		{"解压", NewArchiveExtractTool(dir), `{"source": "bundle.zip", "output": "out"}`, abs("out/pkg/x.txt", "out/y.txt")},
+		{"补丁", NewFilePatchTool(dir), `{"path": "main.go", "patch": "<<<<<<< SEARCH\n\treturn 1\n=======\n\treturn 2\n>>>>>>> REPLACE\n"}`, abs("main.go")},
+		{"补丁预览", NewFilePatchTool(dir), `{"path": "main.go", "dry_run": true, "patch": "<<<<<<< SEARCH\n\treturn 1\n=======\n\treturn 2\n>>>>>>> REPLACE\n"}`, nil},
+		{"补丁无法应用", NewFilePatchTool(dir), `{"path": "main.go", "patch": "<<<<<<< SEARCH\nmissing\n=======\nx\n>>>>>>> REPLACE\n"}`, nil},
+		{"只读工具", NewFileReadTool(dir), `{"path": "main.go"}`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ChangedFiles(tt.tool, tt.args)
+			if err != nil {
+				t.Fatalf("ChangedFiles() error = %v", err)
+			}
+			sort.Strings(got)
+			if len(got) != 0 || len(tt.want) != 0 {
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("ChangedFiles() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	newText := strings.Replace(strings.Replace(patchSample, "import \"fmt\"\n", "", 1), "return 1", "return 2\n\t// done", 1)
+	want := "--- a/main.go\n+++ b/main.go\n" +
+		"@@ -1,11 +1,11 @@\n package main\n \n-import \"fmt\"\n \n func main() {\n \tfmt.Println(\"hello\")\n }\n \n" +
+		" func helper() int {\n-\treturn 1\n+\treturn 2\n+\t// done\n }\n"
+	if got := UnifiedDiff("a/main.go", "b/main.go", patchSample, newText); got != want {
+		t.Errorf("UnifiedDiff() = %q, want %q", got, want)
+	}
+
+	if got := UnifiedDiff("a", "b", patchSample, patchSample); got != "" {
+		t.Errorf("UnifiedDiff() of equal text = %q", got)
+	}
+	if got := UnifiedDiff("a", "b", "x\n", "x\r\n"); !strings.Contains(got, "仅换行符不同") {
+		t.Errorf("UnifiedDiff() of line ending change = %q", got)
+	}
+	if got := UnifiedDiff("/dev/null", "b/new.txt", "", "x\ny\n"); got != "--- /dev/null\n+++ b/new.txt\n@@ -0,0 +1,2 @@\n+x\n+y\n" {
+		t.Errorf("UnifiedDiff() of new file = %q", got)
+	}
+}
@@ -1,7 +1,6 @@
 package tools
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -18,7 +17,6 @@ import (
 
 const (
 	defaultTimeout     = 30 * time.Second
-	maxOutputSize      = 100 * 1024
 	allowedCommandsKey = "ALLOWED_COMMANDS"
 )
 
@@ -188,7 +186,7 @@ func (t *CommandExecuteTool) InvokableRun(ctx context.Context, argumentsInJSON s
 		cmdArgs = append(cmdArgs, strings.Fields(args.Args)...)
 	}
 
-	return t.executeCommand(cmdName, cmdArgs, timeout)
+	return t.executeCommand(ctx, cmdName, cmdArgs, timeout)
 }
 
 func (t *CommandExecuteTool) isCommandAllowed(command string) bool {
@@ -211,8 +209,8 @@ func (t *CommandExecuteTool) isCommandAllowed(command string) bool {
 	return false
 }
 
-func (t *CommandExecuteTool) executeCommand(name string, args []string, timeout time.Duration) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+func (t *CommandExecuteTool) executeCommand(parent context.Context, name string, args []string, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(parent, timeout)
 	defer cancel()
 
 	var cmd *exec.Cmd
@@ -233,39 +231,39 @@ func (t *CommandExecuteTool) executeCommand(name string, args []string, timeout
 		cmd.Dir = t.workingDir
 	}
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	// 取消或超时时结束整个进程树，避免子进程继续运行
+	killProcessTree(cmd)
+
+	// 输出逐行上报进度，结果中只保留开头和末尾
+	output := newCommandLog(parent)
+	defer output.close()
+	cmd.Stdout = output.stdout
+	cmd.Stderr = output.stderr
 
 	startTime := time.Now()
 	err := cmd.Run()
 	duration := time.Since(startTime)
+	output.finish()
 
-	output := stdout.String()
-	if len(output) > maxOutputSize {
-		output = output[:maxOutputSize] + "\n... (输出被截断)"
+	if parent.Err() != nil {
+		return "", fmt.Errorf("命令执行已取消: %w", parent.Err())
 	}
-
-	errOutput := stderr.String()
-	if len(errOutput) > maxOutputSize {
-		errOutput = errOutput[:maxOutputSize] + "\n... (错误输出被截断)"
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("命令执行超时 (%v)", timeout)
 	}
 
 	var result strings.Builder
 	result.WriteString(fmt.Sprintf("命令: %s %s\n", name, strings.Join(args, " ")))
 	result.WriteString(fmt.Sprintf("执行时间: %v\n", duration))
 	result.WriteString(fmt.Sprintf("退出码: %d\n", cmd.ProcessState.ExitCode()))
+	result.WriteString(output.saveArtifact(parent, artifactName("command_execute")))
 
-	if output != "" {
-		result.WriteString(fmt.Sprintf("\n--- 标准输出 ---\n%s", output))
+	if stdout := output.stdout.summary(); stdout != "" {
+		result.WriteString(fmt.Sprintf("\n--- 标准输出 ---\n%s", stdout))
 	}
 
-	if errOutput != "" {
-		result.WriteString(fmt.Sprintf("\n--- 标准错误 ---\n%s", errOutput))
-	}
-
-	if ctx.Err() == context.DeadlineExceeded {
-		return "", fmt.Errorf("命令执行超时 (%v)", timeout)
+	if stderr := output.stderr.summary(); stderr != "" {
+		result.WriteString(fmt.Sprintf("\n--- 标准错误 ---\n%s", stderr))
 	}
 
 	if err != nil {
@@ -354,7 +352,7 @@ func (t *ShellExecuteTool) InvokableRun(ctx context.Context, argumentsInJSON str
 		}
 	}
 
-	return t.executeShell(args.Command, timeout)
+	return t.executeShell(ctx, args.Command, timeout)
 }
 
 func hasDangerousContent(s string) bool {
@@ -373,8 +371,8 @@ func hasDangerousContent(s string) bool {
 	return false
 }
 
-func (t *ShellExecuteTool) executeShell(command string, timeout time.Duration) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+func (t *ShellExecuteTool) executeShell(parent context.Context, command string, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(parent, timeout)
 	defer cancel()
 
 	var cmd *exec.Cmd
@@ -393,38 +391,38 @@ func (t *ShellExecuteTool) executeShell(command string, timeout time.Duration) (
 		cmd.Dir = t.workingDir
 	}
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	// 取消或超时时结束整个进程树，避免子进程继续运行
+	killProcessTree(cmd)
+
+	// 输出逐行上报进度，结果中只保留开头和末尾
+	output := newCommandLog(parent)
+	defer output.close()
+	cmd.Stdout = output.stdout
+	cmd.Stderr = output.stderr
 
 	startTime := time.Now()
 	err := cmd.Run()
 	duration := time.Since(startTime)
+	output.finish()
 
-	output := stdout.String()
-	if len(output) > maxOutputSize {
-		output = output[:maxOutputSize] + "\n... (输出被截断)"
+	if parent.Err() != nil {
+		return "", fmt.Errorf("命令执行已取消: %w", parent.Err())
 	}
-
-	errOutput := stderr.String()
-	if len(errOutput) > maxOutputSize {
-		errOutput = errOutput[:maxOutputSize] + "\n... (错误输出被截断)"
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("命令执行超时 (%v)", timeout)
 	}
 
 	var result strings.Builder
 	result.WriteString(fmt.Sprintf("Shell: %s\n", command))
 	result.WriteString(fmt.Sprintf("执行时间: %v\n", duration))
+	result.WriteString(output.saveArtifact(parent, artifactName("shell_execute")))
 
-	if output != "" {
-		result.WriteString(fmt.Sprintf("\n--- 输出 ---\n%s", output))
+	if stdout := output.stdout.summary(); stdout != "" {
+		result.WriteString(fmt.Sprintf("\n--- 输出 ---\n%s", stdout))
 	}
 
-	if errOutput != "" {
-		result.WriteString(fmt.Sprintf("\n--- 错误 ---\n%s", errOutput))
-	}
-
-	if ctx.Err() == context.DeadlineExceeded {
-		return "", fmt.Errorf("命令执行超时 (%v)", timeout)
+	if stderr := output.stderr.summary(); stderr != "" {
+		result.WriteString(fmt.Sprintf("\n--- 错误 ---\n%s", stderr))
 	}
 
 	if err != nil {
@@ -0,0 +1,105 @@
+package tools
+
+import (
+	"context"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestShellExecuteTool_Cancel(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("依赖 bash")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	// 后台子进程同样持有输出管道，必须连同进程组一起结束
+	_, err := NewShellExecuteTool().InvokableRun(ctx, `{"command": "sleep 10 & sleep 10"}`)
+	if err == nil || !strings.Contains(err.Error(), "取消") {
+		t.Errorf("InvokableRun() error = %v, want cancellation", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("InvokableRun() took %v after cancel", elapsed)
+	}
+}
+
+func TestShellExecuteTool_StreamOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("依赖 bash")
+	}
+
+	var mu sync.Mutex
+	lines := make(map[string][]string)
+	ctx := WithProgressReporter(context.Background(), func(stream, line string) {
+		mu.Lock()
+		defer mu.Unlock()
+		lines[stream] = append(lines[stream], line)
+	})
+	if _, err := NewShellExecuteTool().InvokableRun(ctx, `{"command": "echo one; echo oops >&2; printf 'two\\r\\nthree'"}`); err != nil {
+		t.Fatalf("InvokableRun() error = %v", err)
+	}
+	// 最后一行没有换行符，命令结束时上报
+	if got := strings.Join(lines[StreamStdout], "|"); got != "one|two|three" {
+		t.Errorf("stdout progress = %q", got)
+	}
+	if got := strings.Join(lines[StreamStderr], "|"); got != "oops" {
+		t.Errorf("stderr progress = %q", got)
+	}
+}
+
+func TestShellExecuteTool_LongOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("依赖 bash")
+	}
+
+	var saved []byte
+	ctx := WithArtifactSaver(context.Background(), func(ctx context.Context, name string, r io.Reader) (*Artifact, error) {
+		data, err := io.ReadAll(r)
+		saved = data
+		return &Artifact{ID: "a1", Name: name, Size: int64(len(data)), URL: "/files/a1"}, err
+	})
+
+	// 输出 5000 行，超过摘要保留的开头和末尾
+	result, err := NewShellExecuteTool().InvokableRun(ctx, `{"command": "for i in $(seq 1 5000); do echo line-$i; done; echo oops >&2"}`)
+	if err != nil {
+		t.Fatalf("InvokableRun() error = %v", err)
+	}
+
+	for _, want := range []string{"line-1\n", "line-5000\n", "省略", "--- 错误 ---\noops", "完整日志: shell_execute-", "/files/a1"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("result does not contain %q:\n%s", want, result[:200])
+		}
+	}
+	if strings.Contains(result, "line-2500\n") {
+		t.Error("result contains the middle of the output")
+	}
+	if !strings.HasPrefix(string(saved), "line-1\nline-2\n") || !strings.Contains(string(saved), "line-5000\n") || !strings.Contains(string(saved), "oops\n") {
+		t.Errorf("saved log has %d bytes", len(saved))
+	}
+}
+
+func TestShellExecuteTool_ShortOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("依赖 bash")
+	}
+
+	saved := false
+	ctx := WithArtifactSaver(context.Background(), func(ctx context.Context, name string, r io.Reader) (*Artifact, error) {
+		saved = true
+		return &Artifact{}, nil
+	})
+	result, err := NewShellExecuteTool().InvokableRun(ctx, `{"command": "echo hello"}`)
+	if err != nil {
+		t.Fatalf("InvokableRun() error = %v", err)
+	}
+	// 输出未被截断时不保存完整日志
+	if saved || !strings.HasSuffix(result, "--- 输出 ---\nhello\n") {
+		t.Errorf("saved = %v, result = %q", saved, result)
+	}
+}
@@ -0,0 +1,85 @@
+package tools
+
+import "fmt"
+
+// maxDiffCells 逐行比较时 LCS 表的最大单元数，超过时把首尾相同部分之间的内容整体作为一处修改
+const maxDiffCells = 4 << 20
+
+// UnifiedDiff 逐行比较两段文本，返回 unified diff，内容相同时返回空字符串
+//
+// 只有换行符或末尾换行不同时返回只包含文件头和说明的 diff。
+func UnifiedDiff(oldName, newName, oldText, newText string) string {
+	if oldText == newText {
+		return ""
+	}
+	oldLines, _ := splitLines(oldText)
+	newLines, _ := splitLines(newText)
+	if diff := renderDiff(oldName, newName, oldLines, diffLines(oldLines, newLines)); diff != "" {
+		return diff
+	}
+	return fmt.Sprintf("--- %s\n+++ %s\n（仅换行符不同）\n", oldName, newName)
+}
+
+// diffLines 计算把 a 修改为 b 的最少行修改，结果按位置排序且互不重叠
+func diffLines(a, b []string) []lineEdit {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	a, b = a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if len(a) == 0 && len(b) == 0 {
+		return nil
+	}
+	if len(a)*len(b) > maxDiffCells {
+		return []lineEdit{{oldStart: prefix, oldLines: a, newLines: b}}
+	}
+
+	// lcs[i][j] 为 a[i:] 与 b[j:] 的最长公共子序列长度
+	lcs := make([][]int32, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var edits []lineEdit
+	var cur *lineEdit
+	flush := func() {
+		if cur != nil {
+			edits = append(edits, *cur)
+			cur = nil
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		if i < len(a) && j < len(b) && a[i] == b[j] {
+			flush()
+			i++
+			j++
+			continue
+		}
+		if cur == nil {
+			cur = &lineEdit{oldStart: prefix + i}
+		}
+		if j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]) {
+			cur.oldLines = append(cur.oldLines, a[i])
+			i++
+		} else {
+			cur.newLines = append(cur.newLines, b[j])
+			j++
+		}
+	}
+	flush()
+	return edits
+}
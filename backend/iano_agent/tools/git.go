@@ -0,0 +1,1047 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+const (
+	GitStatusToolName = "git_status"
+	GitDiffToolName   = "git_diff"
+	GitLogToolName    = "git_log"
+	GitShowToolName   = "git_show"
+	GitBranchToolName = "git_branch"
+	GitCommitToolName = "git_commit"
+
+	// defaultGitLogLimit git_log 默认返回的提交数
+	defaultGitLogLimit = 20
+	// maxGitLogLimit git_log 最多返回的提交数
+	maxGitLogLimit = 200
+	// maxGitDiffLines diff 结果中最多返回的修改行数，超出的部分只统计增删行数
+	maxGitDiffLines = 2000
+	// maxGitStatusFiles git_status 最多返回的文件数
+	maxGitStatusFiles = 1000
+)
+
+// GitFileStatus 工作区中一个文件的状态
+type GitFileStatus struct {
+	Path     string `json:"path"`
+	OldPath  string `json:"old_path,omitempty"` // 重命名或复制前的路径
+	Index    string `json:"index"`              // 暂存区状态码，例如 M、A、D、R，未修改为 .
+	Worktree string `json:"worktree"`           // 工作区状态码
+	Status   string `json:"status"`             // modified/added/deleted/renamed/copied/typechange/untracked/conflict
+	Staged   bool   `json:"staged"`             // 暂存区是否有修改
+}
+
+// GitStatus git_status 的结果
+type GitStatus struct {
+	Branch    string           `json:"branch"`             // 当前分支，分离 HEAD 时为 (detached)
+	Commit    string           `json:"commit,omitempty"`   // HEAD 提交，尚无提交时为空
+	Upstream  string           `json:"upstream,omitempty"` // 上游分支
+	Ahead     int              `json:"ahead"`              // 领先上游的提交数
+	Behind    int              `json:"behind"`             // 落后上游的提交数
+	Clean     bool             `json:"clean"`              // 工作区和暂存区都没有修改
+	Files     []*GitFileStatus `json:"files"`
+	Truncated bool             `json:"truncated,omitempty"`
+}
+
+// GitDiffHunk diff 中的一个修改块
+type GitDiffHunk struct {
+	Header   string   `json:"header"`
+	OldStart int      `json:"old_start"`
+	OldLines int      `json:"old_lines"`
+	NewStart int      `json:"new_start"`
+	NewLines int      `json:"new_lines"`
+	Lines    []string `json:"lines"` // 带前缀的行，空格为上下文，- 为删除，+ 为新增
+}
+
+// GitDiffFile diff 中一个文件的修改
+type GitDiffFile struct {
+	Path      string         `json:"path"`
+	OldPath   string         `json:"old_path,omitempty"`
+	Status    string         `json:"status"` // added/deleted/modified/renamed/copied
+	Binary    bool           `json:"binary,omitempty"`
+	Additions int            `json:"additions"`
+	Deletions int            `json:"deletions"`
+	Hunks     []*GitDiffHunk `json:"hunks,omitempty"`
+}
+
+// GitDiff git_diff 的结果
+type GitDiff struct {
+	Files     []*GitDiffFile `json:"files"`
+	Truncated bool           `json:"truncated,omitempty"` // 修改行数超过上限，部分修改块没有返回
+}
+
+// GitCommit 提交元数据
+type GitCommit struct {
+	Hash      string   `json:"hash"`
+	ShortHash string   `json:"short_hash"`
+	Author    string   `json:"author"`
+	Email     string   `json:"email"`
+	Date      string   `json:"date"`
+	Subject   string   `json:"subject"`
+	Body      string   `json:"body,omitempty"`
+	Parents   []string `json:"parents,omitempty"`
+}
+
+// GitChangedFile 提交中修改的文件
+type GitChangedFile struct {
+	Path    string `json:"path"`
+	OldPath string `json:"old_path,omitempty"`
+	Status  string `json:"status"` // added/deleted/modified/renamed/copied/typechange
+}
+
+// GitBranch 分支信息
+type GitBranch struct {
+	Name     string `json:"name"`
+	Commit   string `json:"commit"`
+	Current  bool   `json:"current"`
+	Upstream string `json:"upstream,omitempty"`
+	Remote   bool   `json:"remote,omitempty"`
+	Subject  string `json:"subject,omitempty"`
+}
+
+// gitRepo 在工作目录中执行 git 命令，路径参数受工作目录限制
+type gitRepo struct {
+	workDir string
+}
+
+func newGitRepo(workDir string) gitRepo {
+	if workDir == "" {
+		workDir, _ = os.Getwd()
+	}
+	return gitRepo{workDir: workDir}
+}
+
+// run 执行 git 命令并返回标准输出
+func (r gitRepo) run(ctx context.Context, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-c", "core.quotepath=false", "-c", "color.ui=false"}, args...)...)
+	cmd.Dir = r.workDir
+	// 只使用工作目录本身的仓库，不向上查找，避免工作目录位于更大的仓库中时操作到工作目录之外
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_PAGER=cat", "LC_ALL=C",
+		"GIT_CEILING_DIRECTORIES="+filepath.Dir(r.workDir))
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("git %s 执行超时或被取消", args[0])
+		}
+		msg := strings.TrimSpace(stderr.String())
+		if strings.Contains(msg, "not a git repository") {
+			return "", fmt.Errorf("工作目录 %s 不是 Git 仓库的根目录", r.workDir)
+		}
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("git %s 失败: %s", args[0], msg)
+	}
+	return stdout.String(), nil
+}
+
+// pathspec 把路径参数转换为相对工作目录的路径，超出工作目录时报错
+func (r gitRepo) pathspec(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	absPath := path
+	if !filepath.IsAbs(path) {
+		absPath = filepath.Join(r.workDir, path)
+	}
+	absPath = filepath.Clean(absPath)
+
+	rel, err := filepath.Rel(r.workDir, absPath)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("路径超出允许范围")
+	}
+	// 工作目录中指向外部的符号链接同样视为超出范围
+	if err := checkRealPath(r.workDir, absPath); err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// checkRealPath 解析符号链接后检查 absPath 是否仍在 basePath 内，absPath 不存在时检查其已存在的上级目录
+func checkRealPath(basePath, absPath string) error {
+	realBase, err := filepath.EvalSymlinks(basePath)
+	if err != nil {
+		return fmt.Errorf("解析工作目录失败: %w", err)
+	}
+
+	existing, suffix := absPath, ""
+	for {
+		real, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			existing = filepath.Join(real, suffix)
+			break
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			break
+		}
+		suffix = filepath.Join(filepath.Base(existing), suffix)
+		existing = parent
+	}
+
+	rel, err := filepath.Rel(realBase, existing)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("路径超出允许范围")
+	}
+	return nil
+}
+
+// checkRef 校验分支名、提交等引用，避免被当作命令行选项
+func checkRef(kind, ref string) error {
+	if strings.HasPrefix(ref, "-") {
+		return fmt.Errorf("%s不能以 - 开头: %s", kind, ref)
+	}
+	if strings.ContainsAny(ref, " \t\r\n\x00") {
+		return fmt.Errorf("%s不能包含空白字符: %q", kind, ref)
+	}
+	return nil
+}
+
+func toJSON(v interface{}) (string, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("序列化结果失败: %w", err)
+	}
+	return string(data), nil
+}
+
+// ==================== git_status ====================
+
+type GitStatusTool struct {
+	repo gitRepo
+}
+
+func NewGitStatusTool(workDir string) *GitStatusTool {
+	return &GitStatusTool{repo: newGitRepo(workDir)}
+}
+
+func (t *GitStatusTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: GitStatusToolName,
+		Desc: "查看工作目录 Git 仓库的状态，返回 JSON：当前分支、与上游的差距和每个修改文件的暂存区/工作区状态",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"path": {
+				Type:     schema.String,
+				Desc:     "只查看该文件或目录（可选）",
+				Required: false,
+			},
+		}),
+	}, nil
+}
+
+func (t *GitStatusTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	var args struct {
+		Path string `json:"path"`
+	}
+	if err := json.Unmarshal([]byte(argumentsInJSON), &args); err != nil {
+		return "", fmt.Errorf("参数解析失败: %w", err)
+	}
+
+	cmdArgs := []string{"status", "--porcelain=v2", "--branch", "-z", "--untracked-files=all"}
+	spec, err := t.repo.pathspec(args.Path)
+	if err != nil {
+		return "", err
+	}
+	if spec != "" {
+		cmdArgs = append(cmdArgs, "--", spec)
+	}
+
+	out, err := t.repo.run(ctx, cmdArgs...)
+	if err != nil {
+		return "", err
+	}
+	return toJSON(parseGitStatus(out))
+}
+
+// parseGitStatus 解析 git status --porcelain=v2 --branch -z 的输出
+func parseGitStatus(out string) *GitStatus {
+	status := &GitStatus{Files: make([]*GitFileStatus, 0)}
+	entries := strings.Split(out, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if entry == "" {
+			continue
+		}
+
+		if strings.HasPrefix(entry, "# ") {
+			key, value, _ := strings.Cut(entry[2:], " ")
+			switch key {
+			case "branch.oid":
+				if value != "(initial)" {
+					status.Commit = value
+				}
+			case "branch.head":
+				status.Branch = value
+			case "branch.upstream":
+				status.Upstream = value
+			case "branch.ab":
+				fmt.Sscanf(value, "+%d -%d", &status.Ahead, &status.Behind)
+			}
+			continue
+		}
+
+		var file *GitFileStatus
+		switch entry[0] {
+		case '1':
+			// 1 XY sub mH mI mW hH hI path
+			fields := strings.SplitN(entry, " ", 9)
+			if len(fields) == 9 {
+				file = newGitFileStatus(fields[1], fields[8])
+			}
+		case '2':
+			// 2 XY sub mH mI mW hH hI Xscore path，原路径在下一个条目
+			fields := strings.SplitN(entry, " ", 10)
+			if len(fields) == 10 {
+				file = newGitFileStatus(fields[1], fields[9])
+				if i+1 < len(entries) {
+					file.OldPath = entries[i+1]
+					i++
+				}
+			}
+		case 'u':
+			// u XY sub m1 m2 m3 mW h1 h2 h3 path
+			fields := strings.SplitN(entry, " ", 11)
+			if len(fields) == 11 {
+				file = newGitFileStatus(fields[1], fields[10])
+				file.Status = "conflict"
+			}
+		case '?':
+			file = &GitFileStatus{Path: entry[2:], Index: "?", Worktree: "?", Status: "untracked"}
+		}
+		if file == nil {
+			continue
+		}
+		if len(status.Files) >= maxGitStatusFiles {
+			status.Truncated = true
+			continue
+		}
+		status.Files = append(status.Files, file)
+	}
+	status.Clean = len(status.Files) == 0
+	return status
+}
+
+func newGitFileStatus(xy, path string) *GitFileStatus {
+	file := &GitFileStatus{Path: path, Index: xy[:1], Worktree: xy[1:2]}
+	file.Staged = file.Index != "."
+
+	code := file.Index
+	if code == "." {
+		code = file.Worktree
+	}
+	file.Status = gitStatusName(code)
+	return file
+}
+
+// gitStatusName 把 git 的单字母状态码转换为名称
+func gitStatusName(code string) string {
+	switch code {
+	case "A":
+		return "added"
+	case "D":
+		return "deleted"
+	case "R":
+		return "renamed"
+	case "C":
+		return "copied"
+	case "T":
+		return "typechange"
+	default:
+		return "modified"
+	}
+}
+
+// ==================== git_diff ====================
+
+type GitDiffTool struct {
+	repo gitRepo
+}
+
+func NewGitDiffTool(workDir string) *GitDiffTool {
+	return &GitDiffTool{repo: newGitRepo(workDir)}
+}
+
+func (t *GitDiffTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: GitDiffToolName,
+		Desc: "查看 Git 修改内容，返回 JSON：每个文件的状态、增删行数和修改块。默认比较工作区与暂存区，staged=true 比较暂存区与 HEAD，也可以指定提交或范围",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"ref": {
+				Type:     schema.String,
+				Desc:     "比较的提交或范围，例如 HEAD~1、main..feature（可选）",
+				Required: false,
+			},
+			"staged": {
+				Type:     schema.Boolean,
+				Desc:     "查看已暂存的修改，默认 false",
+				Required: false,
+			},
+			"path": {
+				Type:     schema.String,
+				Desc:     "只查看该文件或目录（可选）",
+				Required: false,
+			},
+			"context_lines": {
+				Type:     schema.Number,
+				Desc:     "每个修改块的上下文行数，默认 3",
+				Required: false,
+			},
+		}),
+	}, nil
+}
+
+func (t *GitDiffTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	var args struct {
+		Ref          string `json:"ref"`
+		Staged       bool   `json:"staged"`
+		Path         string `json:"path"`
+		ContextLines *int   `json:"context_lines"`
+	}
+	if err := json.Unmarshal([]byte(argumentsInJSON), &args); err != nil {
+		return "", fmt.Errorf("参数解析失败: %w", err)
+	}
+	if err := checkRef("ref ", args.Ref); err != nil {
+		return "", err
+	}
+
+	contextLines := 3
+	if args.ContextLines != nil && *args.ContextLines >= 0 {
+		contextLines = *args.ContextLines
+	}
+
+	cmdArgs := []string{"diff", "--no-ext-diff", "--find-renames", fmt.Sprintf("-U%d", contextLines)}
+	if args.Staged {
+		cmdArgs = append(cmdArgs, "--cached")
+	}
+	if args.Ref != "" {
+		cmdArgs = append(cmdArgs, args.Ref)
+	}
+	spec, err := t.repo.pathspec(args.Path)
+	if err != nil {
+		return "", err
+	}
+	cmdArgs = append(cmdArgs, "--")
+	if spec != "" {
+		cmdArgs = append(cmdArgs, spec)
+	}
+
+	out, err := t.repo.run(ctx, cmdArgs...)
+	if err != nil {
+		return "", err
+	}
+	return toJSON(parseGitDiff(out))
+}
+
+// parseGitDiff 解析 git diff 的输出
+func parseGitDiff(out string) *GitDiff {
+	diff := &GitDiff{Files: make([]*GitDiffFile, 0)}
+	var (
+		file                 *GitDiffFile
+		hunk                 *GitDiffHunk
+		oldRemain, newRemain int
+		totalLines           int
+	)
+
+	for _, line := range strings.Split(out, "\n") {
+		// 修改块内按行数读取，避免把以 --- 开头的删除行当作文件头
+		if hunk != nil && (oldRemain > 0 || newRemain > 0 || strings.HasPrefix(line, `\`)) {
+			if line == "" && oldRemain == 0 && newRemain == 0 {
+				continue
+			}
+			switch {
+			case strings.HasPrefix(line, "+"):
+				newRemain--
+				file.Additions++
+			case strings.HasPrefix(line, "-"):
+				oldRemain--
+				file.Deletions++
+			case strings.HasPrefix(line, `\`):
+			default:
+				oldRemain--
+				newRemain--
+			}
+			if totalLines < maxGitDiffLines {
+				hunk.Lines = append(hunk.Lines, line)
+				totalLines++
+			} else {
+				diff.Truncated = true
+			}
+			continue
+		}
+		hunk = nil
+
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			file = &GitDiffFile{Status: "modified"}
+			file.OldPath, file.Path = splitDiffGitHeader(line[len("diff --git "):])
+			diff.Files = append(diff.Files, file)
+		case file == nil:
+		case strings.HasPrefix(line, "new file mode"):
+			file.Status = "added"
+		case strings.HasPrefix(line, "deleted file mode"):
+			file.Status = "deleted"
+		case strings.HasPrefix(line, "rename from "):
+			file.Status = "renamed"
+			file.OldPath = unquoteGitPath(line[len("rename from "):])
+		case strings.HasPrefix(line, "rename to "):
+			file.Path = unquoteGitPath(line[len("rename to "):])
+		case strings.HasPrefix(line, "copy from "):
+			file.Status = "copied"
+			file.OldPath = unquoteGitPath(line[len("copy from "):])
+		case strings.HasPrefix(line, "copy to "):
+			file.Path = unquoteGitPath(line[len("copy to "):])
+		case strings.HasPrefix(line, "Binary files "):
+			file.Binary = true
+		case strings.HasPrefix(line, "--- "):
+			if p := line[4:]; p != devNullPath {
+				file.OldPath = strings.TrimPrefix(unquoteGitPath(p), "a/")
+			}
+		case strings.HasPrefix(line, "+++ "):
+			if p := line[4:]; p != devNullPath {
+				file.Path = strings.TrimPrefix(unquoteGitPath(p), "b/")
+			}
+		case strings.HasPrefix(line, "@@"):
+			m := hunkHeaderRe.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			hunk = &GitDiffHunk{
+				Header:   line,
+				OldStart: atoiDefault(m[1], 0),
+				OldLines: atoiDefault(m[2], 1),
+				NewStart: atoiDefault(m[3], 0),
+				NewLines: atoiDefault(m[4], 1),
+				Lines:    make([]string, 0),
+			}
+			oldRemain, newRemain = hunk.OldLines, hunk.NewLines
+			file.Hunks = append(file.Hunks, hunk)
+		}
+	}
+
+	for _, f := range diff.Files {
+		if f.OldPath == f.Path || f.Status == "added" || f.Status == "deleted" {
+			if f.Status == "deleted" {
+				f.Path = f.OldPath
+			}
+			f.OldPath = ""
+		}
+	}
+	return diff
+}
+
+// splitDiffGitHeader 从 diff --git a/x b/x 中取出修改前后的路径
+func splitDiffGitHeader(s string) (string, string) {
+	if strings.HasPrefix(s, `"`) {
+		if old, rest, ok := cutQuoted(s); ok {
+			return strings.TrimPrefix(old, "a/"), strings.TrimPrefix(unquoteGitPath(strings.TrimSpace(rest)), "b/")
+		}
+	}
+	// 路径相同时前后两半长度一致
+	if n := len(s); n%2 == 1 && s[:n/2] == "a/"+s[n/2+3:] {
+		return s[2 : n/2], s[n/2+3:]
+	}
+	if idx := strings.Index(s, " b/"); idx >= 0 {
+		return strings.TrimPrefix(s[:idx], "a/"), s[idx+3:]
+	}
+	return s, s
+}
+
+func cutQuoted(s string) (string, string, bool) {
+	for i := 1; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+			continue
+		}
+		if s[i] == '"' {
+			unquoted, err := strconv.Unquote(s[:i+1])
+			if err != nil {
+				return "", "", false
+			}
+			return unquoted, s[i+1:], true
+		}
+	}
+	return "", "", false
+}
+
+func unquoteGitPath(s string) string {
+	if strings.HasPrefix(s, `"`) {
+		if unquoted, err := strconv.Unquote(s); err == nil {
+			return unquoted
+		}
+	}
+	return s
+}
+
+// ==================== git_log ====================
+
+// gitCommitFormat git log/show 的输出格式，字段以 \x1f 分隔、提交以 \x1e 结束
+const gitCommitFormat = "%H%x1f%h%x1f%an%x1f%ae%x1f%aI%x1f%P%x1f%s%x1f%b%x1e"
+
+func parseGitCommits(out string, withBody bool) []*GitCommit {
+	commits := make([]*GitCommit, 0)
+	for _, record := range strings.Split(out, "\x1e") {
+		record = strings.TrimLeft(record, "\n")
+		fields := strings.Split(record, "\x1f")
+		if len(fields) < 8 {
+			continue
+		}
+		c := &GitCommit{
+			Hash:      fields[0],
+			ShortHash: fields[1],
+			Author:    fields[2],
+			Email:     fields[3],
+			Date:      fields[4],
+			Subject:   fields[6],
+		}
+		if fields[5] != "" {
+			c.Parents = strings.Fields(fields[5])
+		}
+		if withBody {
+			c.Body = strings.TrimSpace(fields[7])
+		}
+		commits = append(commits, c)
+	}
+	return commits
+}
+
+type GitLogTool struct {
+	repo gitRepo
+}
+
+func NewGitLogTool(workDir string) *GitLogTool {
+	return &GitLogTool{repo: newGitRepo(workDir)}
+}
+
+func (t *GitLogTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: GitLogToolName,
+		Desc: "查看 Git 提交历史，返回 JSON 数组：哈希、作者、时间、父提交和标题",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"ref": {
+				Type:     schema.String,
+				Desc:     "起始提交、分支或范围，默认为 HEAD",
+				Required: false,
+			},
+			"path": {
+				Type:     schema.String,
+				Desc:     "只查看修改过该文件或目录的提交（可选）",
+				Required: false,
+			},
+			"limit": {
+				Type:     schema.Number,
+				Desc:     fmt.Sprintf("返回的提交数，默认 %d，最多 %d", defaultGitLogLimit, maxGitLogLimit),
+				Required: false,
+			},
+		}),
+	}, nil
+}
+
+func (t *GitLogTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	var args struct {
+		Ref   string `json:"ref"`
+		Path  string `json:"path"`
+		Limit int    `json:"limit"`
+	}
+	if err := json.Unmarshal([]byte(argumentsInJSON), &args); err != nil {
+		return "", fmt.Errorf("参数解析失败: %w", err)
+	}
+	if err := checkRef("ref ", args.Ref); err != nil {
+		return "", err
+	}
+
+	limit := args.Limit
+	if limit <= 0 {
+		limit = defaultGitLogLimit
+	}
+	limit = min(limit, maxGitLogLimit)
+
+	cmdArgs := []string{"log", fmt.Sprintf("-n%d", limit), "--format=" + gitCommitFormat}
+	if args.Ref != "" {
+		cmdArgs = append(cmdArgs, args.Ref)
+	}
+	spec, err := t.repo.pathspec(args.Path)
+	if err != nil {
+		return "", err
+	}
+	cmdArgs = append(cmdArgs, "--")
+	if spec != "" {
+		cmdArgs = append(cmdArgs, spec)
+	}
+
+	out, err := t.repo.run(ctx, cmdArgs...)
+	if err != nil {
+		if strings.Contains(err.Error(), "does not have any commits") {
+			return toJSON([]*GitCommit{})
+		}
+		return "", err
+	}
+	return toJSON(parseGitCommits(out, false))
+}
+
+// ==================== git_show ====================
+
+type GitShowTool struct {
+	repo gitRepo
+}
+
+func NewGitShowTool(workDir string) *GitShowTool {
+	return &GitShowTool{repo: newGitRepo(workDir)}
+}
+
+func (t *GitShowTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: GitShowToolName,
+		Desc: "查看一个 Git 提交，返回 JSON：提交元数据、完整提交说明和修改内容（合并提交与第一个父提交比较）",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"ref": {
+				Type:     schema.String,
+				Desc:     "提交哈希、分支或标签，默认为 HEAD",
+				Required: false,
+			},
+			"path": {
+				Type:     schema.String,
+				Desc:     "只查看该文件或目录的修改（可选）",
+				Required: false,
+			},
+		}),
+	}, nil
+}
+
+func (t *GitShowTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	var args struct {
+		Ref  string `json:"ref"`
+		Path string `json:"path"`
+	}
+	if err := json.Unmarshal([]byte(argumentsInJSON), &args); err != nil {
+		return "", fmt.Errorf("参数解析失败: %w", err)
+	}
+	if args.Ref == "" {
+		args.Ref = "HEAD"
+	}
+	if err := checkRef("ref ", args.Ref); err != nil {
+		return "", err
+	}
+	spec, err := t.repo.pathspec(args.Path)
+	if err != nil {
+		return "", err
+	}
+
+	out, err := t.repo.run(ctx, "show", "-s", "--format="+gitCommitFormat, args.Ref, "--")
+	if err != nil {
+		return "", err
+	}
+	commits := parseGitCommits(out, true)
+	if len(commits) == 0 {
+		return "", fmt.Errorf("%s 不是提交", args.Ref)
+	}
+	commit := commits[0]
+
+	cmdArgs := []string{"show", "--format=", "--no-ext-diff", "--find-renames", commit.Hash}
+	if len(commit.Parents) > 1 {
+		cmdArgs = []string{"diff", "--no-ext-diff", "--find-renames", commit.Parents[0], commit.Hash}
+	}
+	cmdArgs = append(cmdArgs, "--")
+	if spec != "" {
+		cmdArgs = append(cmdArgs, spec)
+	}
+	out, err = t.repo.run(ctx, cmdArgs...)
+	if err != nil {
+		return "", err
+	}
+
+	return toJSON(struct {
+		*GitCommit
+		*GitDiff
+	}{commit, parseGitDiff(out)})
+}
+
+// ==================== git_branch ====================
+
+type GitBranchTool struct {
+	repo       gitRepo
+	allowWrite bool
+}
+
+// NewGitBranchTool allowWrite 为 false 时只能列出分支
+func NewGitBranchTool(workDir string, allowWrite bool) *GitBranchTool {
+	return &GitBranchTool{repo: newGitRepo(workDir), allowWrite: allowWrite}
+}
+
+func (t *GitBranchTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	desc := "列出 Git 分支，返回 JSON：当前分支和每个分支的最新提交"
+	if t.allowWrite {
+		desc += "；也可以创建、切换和删除分支"
+	} else {
+		desc += "（当前 Agent 未开启 Git 写操作，只能列出分支）"
+	}
+	return &schema.ToolInfo{
+		Name: GitBranchToolName,
+		Desc: desc,
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"action": {
+				Type:     schema.String,
+				Desc:     "操作: list(默认)、create、switch、delete",
+				Enum:     []string{"list", "create", "switch", "delete"},
+				Required: false,
+			},
+			"name": {
+				Type:     schema.String,
+				Desc:     "分支名称，create/switch/delete 时必填",
+				Required: false,
+			},
+			"start_point": {
+				Type:     schema.String,
+				Desc:     "create 时新分支的起点，默认为 HEAD",
+				Required: false,
+			},
+			"checkout": {
+				Type:     schema.Boolean,
+				Desc:     "create 后切换到新分支，默认 false",
+				Required: false,
+			},
+			"all": {
+				Type:     schema.Boolean,
+				Desc:     "list 时包含远程分支，默认 false",
+				Required: false,
+			},
+		}),
+	}, nil
+}
+
+func (t *GitBranchTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	var args struct {
+		Action     string `json:"action"`
+		Name       string `json:"name"`
+		StartPoint string `json:"start_point"`
+		Checkout   bool   `json:"checkout"`
+		All        bool   `json:"all"`
+	}
+	if err := json.Unmarshal([]byte(argumentsInJSON), &args); err != nil {
+		return "", fmt.Errorf("参数解析失败: %w", err)
+	}
+
+	if args.Action == "" || args.Action == "list" {
+		return t.list(ctx, args.All)
+	}
+
+	if !t.allowWrite {
+		return "", fmt.Errorf("当前 Agent 未开启 Git 写操作，不能执行 git_branch %s", args.Action)
+	}
+	if args.Name == "" {
+		return "", fmt.Errorf("分支名称不能为空")
+	}
+	if err := checkRef("分支名称", args.Name); err != nil {
+		return "", err
+	}
+	if err := checkRef("start_point ", args.StartPoint); err != nil {
+		return "", err
+	}
+
+	slog.Info("git_branch 工具参数", "arguments", argumentsInJSON)
+
+	var cmdArgs []string
+	switch args.Action {
+	case "create":
+		cmdArgs = []string{"branch", args.Name}
+		if args.Checkout {
+			cmdArgs = []string{"switch", "-c", args.Name}
+		}
+		if args.StartPoint != "" {
+			cmdArgs = append(cmdArgs, args.StartPoint)
+		}
+	case "switch":
+		cmdArgs = []string{"switch", args.Name}
+	case "delete":
+		// 只删除已合并的分支，未合并的提交不会丢失
+		cmdArgs = []string{"branch", "-d", args.Name}
+	default:
+		return "", fmt.Errorf("不支持的操作: %s", args.Action)
+	}
+
+	if _, err := t.repo.run(ctx, cmdArgs...); err != nil {
+		return "", err
+	}
+	return t.list(ctx, false)
+}
+
+func (t *GitBranchTool) list(ctx context.Context, all bool) (string, error) {
+	cmdArgs := []string{"for-each-ref", "--format=%(refname)%1f%(refname:short)%1f%(objectname:short)%1f%(HEAD)%1f%(upstream:short)%1f%(contents:subject)", "refs/heads"}
+	if all {
+		cmdArgs = append(cmdArgs, "refs/remotes")
+	}
+	out, err := t.repo.run(ctx, cmdArgs...)
+	if err != nil {
+		return "", err
+	}
+
+	result := struct {
+		Current  string       `json:"current"`
+		Branches []*GitBranch `json:"branches"`
+	}{Branches: make([]*GitBranch, 0)}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 6 || strings.HasSuffix(fields[0], "/HEAD") {
+			continue
+		}
+		b := &GitBranch{
+			Name:     fields[1],
+			Commit:   fields[2],
+			Current:  fields[3] == "*",
+			Upstream: fields[4],
+			Remote:   strings.HasPrefix(fields[0], "refs/remotes/"),
+			Subject:  fields[5],
+		}
+		if b.Current {
+			result.Current = b.Name
+		}
+		result.Branches = append(result.Branches, b)
+	}
+	if result.Current == "" {
+		// 尚无提交的仓库没有分支引用，分离 HEAD 时返回 HEAD
+		if head, err := t.repo.run(ctx, "symbolic-ref", "--short", "-q", "HEAD"); err == nil {
+			result.Current = strings.TrimSpace(head)
+		} else {
+			result.Current = "HEAD"
+		}
+	}
+	return toJSON(result)
+}
+
+// ==================== git_commit ====================
+
+type GitCommitTool struct {
+	repo       gitRepo
+	allowWrite bool
+}
+
+// NewGitCommitTool allowWrite 为 false 时拒绝提交
+func NewGitCommitTool(workDir string, allowWrite bool) *GitCommitTool {
+	return &GitCommitTool{repo: newGitRepo(workDir), allowWrite: allowWrite}
+}
+
+func (t *GitCommitTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: GitCommitToolName,
+		Desc: "创建 Git 提交。可以先暂存 paths 中的文件，all=true 时暂存全部修改（包括新文件），然后提交已暂存的修改，返回 JSON：提交元数据和提交的文件。需要 Agent 开启 Git 写操作",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"message": {
+				Type:     schema.String,
+				Desc:     "提交说明，第一行为标题",
+				Required: true,
+			},
+			"paths": {
+				Type:     schema.Array,
+				ElemInfo: &schema.ParameterInfo{Type: schema.String},
+				Desc:     "提交前暂存的文件或目录（可选）",
+				Required: false,
+			},
+			"all": {
+				Type:     schema.Boolean,
+				Desc:     "提交前暂存工作目录中的全部修改，默认 false",
+				Required: false,
+			},
+		}),
+	}, nil
+}
+
+func (t *GitCommitTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	var args struct {
+		Message string   `json:"message"`
+		Paths   []string `json:"paths"`
+		All     bool     `json:"all"`
+	}
+
+	slog.Info("git_commit 工具参数", "arguments", argumentsInJSON)
+
+	if err := json.Unmarshal([]byte(argumentsInJSON), &args); err != nil {
+		return "", fmt.Errorf("参数解析失败: %w", err)
+	}
+	if !t.allowWrite {
+		return "", fmt.Errorf("当前 Agent 未开启 Git 写操作，不能执行 git_commit")
+	}
+	if strings.TrimSpace(args.Message) == "" {
+		return "", fmt.Errorf("提交说明不能为空")
+	}
+
+	if args.All {
+		if _, err := t.repo.run(ctx, "add", "-A", "--", "."); err != nil {
+			return "", err
+		}
+	} else if len(args.Paths) > 0 {
+		specs := make([]string, 0, len(args.Paths))
+		for _, p := range args.Paths {
+			spec, err := t.repo.pathspec(p)
+			if err != nil {
+				return "", err
+			}
+			specs = append(specs, spec)
+		}
+		if _, err := t.repo.run(ctx, append([]string{"add", "-A", "--"}, specs...)...); err != nil {
+			return "", err
+		}
+	}
+
+	staged, err := t.repo.run(ctx, "diff", "--cached", "--name-only")
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(staged) == "" {
+		return "", fmt.Errorf("没有已暂存的修改，请通过 paths 或 all 指定要提交的文件")
+	}
+
+	if _, err := t.repo.run(ctx, "commit", "-q", "-m", args.Message); err != nil {
+		return "", err
+	}
+
+	out, err := t.repo.run(ctx, "show", "-s", "--format="+gitCommitFormat, "HEAD", "--")
+	if err != nil {
+		return "", err
+	}
+	commits := parseGitCommits(out, true)
+	if len(commits) == 0 {
+		return "", fmt.Errorf("读取新提交失败")
+	}
+
+	out, err = t.repo.run(ctx, "show", "--format=", "--name-status", "--find-renames", "HEAD", "--")
+	if err != nil {
+		return "", err
+	}
+	files := make([]*GitChangedFile, 0)
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		// 状态码\t路径，重命名和复制为 R100\t原路径\t新路径
+		fields := strings.Split(line, "\t")
+		if len(fields) < 2 || fields[0] == "" {
+			continue
+		}
+		file := &GitChangedFile{Path: fields[len(fields)-1], Status: gitStatusName(fields[0][:1])}
+		if len(fields) == 3 {
+			file.OldPath = fields[1]
+		}
+		files = append(files, file)
+	}
+
+	return toJSON(struct {
+		*GitCommit
+		Files []*GitChangedFile `json:"files"`
+	}{commits[0], files})
+}
@@ -0,0 +1,231 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/tool"
+)
+
+// newTestGitRepo 创建包含一次提交的临时仓库
+func newTestGitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("需要 git")
+	}
+
+	dir := t.TempDir()
+	gitRun(t, dir, "init", "-q", "-b", "main")
+	gitRun(t, dir, "config", "user.name", "Tester")
+	gitRun(t, dir, "config", "user.email", "tester@example.com")
+	writePatchFile(t, dir, "main.go", patchSample)
+	writePatchFile(t, dir, "README.md", "# demo\n")
+	gitRun(t, dir, "add", "-A")
+	gitRun(t, dir, "commit", "-q", "-m", "init")
+	return dir
+}
+
+func gitRun(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v error = %v: %s", args, err, out)
+	}
+	return string(out)
+}
+
+func invokeGitTool(t *testing.T, tl tool.InvokableTool, args string, result interface{}) {
+	t.Helper()
+	out, err := tl.InvokableRun(context.Background(), args)
+	if err != nil {
+		t.Fatalf("InvokableRun(%s) error = %v", args, err)
+	}
+	if err := json.Unmarshal([]byte(out), result); err != nil {
+		t.Fatalf("result is not JSON: %v\n%s", err, out)
+	}
+}
+
+func TestGitStatusAndDiff(t *testing.T) {
+	dir := newTestGitRepo(t)
+	writePatchFile(t, dir, "main.go", strings.Replace(patchSample, "return 1", "return 2", 1))
+	writePatchFile(t, dir, "new.txt", "new\n")
+	gitRun(t, dir, "mv", "README.md", "README.txt")
+
+	var status GitStatus
+	invokeGitTool(t, NewGitStatusTool(dir), `{}`, &status)
+	if status.Branch != "main" || status.Commit == "" || status.Clean {
+		t.Errorf("status = %+v", status)
+	}
+	got := make(map[string]string)
+	for _, f := range status.Files {
+		got[f.Path] = f.Status
+		if f.Status == "renamed" && (f.OldPath != "README.md" || !f.Staged) {
+			t.Errorf("renamed file = %+v", f)
+		}
+	}
+	want := map[string]string{"main.go": "modified", "new.txt": "untracked", "README.txt": "renamed"}
+	for path, s := range want {
+		if got[path] != s {
+			t.Errorf("status of %s = %q, want %q (files: %v)", path, got[path], s, got)
+		}
+	}
+
+	var diff GitDiff
+	invokeGitTool(t, NewGitDiffTool(dir), `{"path": "main.go", "context_lines": 1}`, &diff)
+	if len(diff.Files) != 1 {
+		t.Fatalf("diff files = %+v", diff.Files)
+	}
+	f := diff.Files[0]
+	if f.Path != "main.go" || f.Status != "modified" || f.Additions != 1 || f.Deletions != 1 || len(f.Hunks) != 1 {
+		t.Fatalf("diff file = %+v", f)
+	}
+	h := f.Hunks[0]
+	wantLines := []string{" func helper() int {", "-\treturn 1", "+\treturn 2", " }"}
+	if h.OldStart != 9 || h.OldLines != 3 || strings.Join(h.Lines, "\n") != strings.Join(wantLines, "\n") {
+		t.Errorf("hunk = %+v", h)
+	}
+
+	invokeGitTool(t, NewGitDiffTool(dir), `{"staged": true}`, &diff)
+	if len(diff.Files) != 1 || diff.Files[0].Status != "renamed" || diff.Files[0].OldPath != "README.md" {
+		t.Errorf("staged diff = %+v", diff.Files)
+	}
+}
+
+func TestGitLogAndShow(t *testing.T) {
+	dir := newTestGitRepo(t)
+	writePatchFile(t, dir, "main.go", strings.Replace(patchSample, "return 1", "--- return 2", 1))
+	gitRun(t, dir, "commit", "-q", "-am", "改返回值\n\n详细说明")
+
+	var commits []*GitCommit
+	invokeGitTool(t, NewGitLogTool(dir), `{"limit": 5}`, &commits)
+	if len(commits) != 2 || commits[0].Subject != "改返回值" || commits[1].Subject != "init" {
+		t.Fatalf("log = %+v", commits)
+	}
+	if commits[0].Author != "Tester" || len(commits[0].Parents) != 1 || commits[0].Parents[0] != commits[1].Hash {
+		t.Errorf("log[0] = %+v", commits[0])
+	}
+
+	var show struct {
+		GitCommit
+		GitDiff
+	}
+	invokeGitTool(t, NewGitShowTool(dir), `{}`, &show)
+	if show.Hash != commits[0].Hash || show.Body != "详细说明" || len(show.Files) != 1 {
+		t.Fatalf("show = %+v", show)
+	}
+	// 内容以 --- 开头的新增行不能被当作文件头
+	if f := show.Files[0]; f.Additions != 1 || f.Deletions != 1 || f.Hunks[0].Lines[len(f.Hunks[0].Lines)-2] != "+\t--- return 2" {
+		t.Errorf("show file = %+v", f)
+	}
+
+	if _, err := NewGitShowTool(dir).InvokableRun(context.Background(), `{"ref": "--output=/tmp/x"}`); err == nil {
+		t.Error("InvokableRun() with option-like ref error = nil")
+	}
+	if _, err := NewGitLogTool(dir).InvokableRun(context.Background(), `{"path": "../outside"}`); err == nil || !strings.Contains(err.Error(), "路径超出允许范围") {
+		t.Errorf("InvokableRun() with outside path error = %v", err)
+	}
+}
+
+func TestGitWriteTools(t *testing.T) {
+	dir := newTestGitRepo(t)
+
+	// 未开启写操作时只能列出分支和查看状态
+	if _, err := NewGitBranchTool(dir, false).InvokableRun(context.Background(), `{"action": "create", "name": "feature"}`); err == nil || !strings.Contains(err.Error(), "未开启 Git 写操作") {
+		t.Errorf("create branch without write error = %v", err)
+	}
+	if _, err := NewGitCommitTool(dir, false).InvokableRun(context.Background(), `{"message": "x", "all": true}`); err == nil || !strings.Contains(err.Error(), "未开启 Git 写操作") {
+		t.Errorf("commit without write error = %v", err)
+	}
+
+	var branches struct {
+		Current  string       `json:"current"`
+		Branches []*GitBranch `json:"branches"`
+	}
+	invokeGitTool(t, NewGitBranchTool(dir, true), `{"action": "create", "name": "feature", "checkout": true}`, &branches)
+	if branches.Current != "feature" || len(branches.Branches) != 2 {
+		t.Fatalf("branches = %+v", branches)
+	}
+
+	if _, err := NewGitCommitTool(dir, true).InvokableRun(context.Background(), `{"message": "空提交"}`); err == nil || !strings.Contains(err.Error(), "没有已暂存的修改") {
+		t.Errorf("commit with nothing staged error = %v", err)
+	}
+
+	writePatchFile(t, dir, "docs.md", "docs\n")
+	writePatchFile(t, dir, "skip.txt", "skip\n")
+	if err := os.Remove(filepath.Join(dir, "README.md")); err != nil {
+		t.Fatal(err)
+	}
+
+	var commit struct {
+		GitCommit
+		Files []*GitChangedFile `json:"files"`
+	}
+	invokeGitTool(t, NewGitCommitTool(dir, true), `{"message": "添加文档", "paths": ["docs.md", "README.md"]}`, &commit)
+	if commit.Subject != "添加文档" || commit.Hash == "" {
+		t.Fatalf("commit = %+v", commit)
+	}
+	got := make(map[string]string)
+	for _, f := range commit.Files {
+		got[f.Path] = f.Status
+	}
+	if len(got) != 2 || got["docs.md"] != "added" || got["README.md"] != "deleted" {
+		t.Errorf("commit files = %v", got)
+	}
+	if status := gitRun(t, dir, "status", "--porcelain"); strings.TrimSpace(status) != "?? skip.txt" {
+		t.Errorf("git status after commit = %q", status)
+	}
+}
+
+func TestGitTool_NotRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("需要 git")
+	}
+	dir := t.TempDir()
+	if _, err := NewGitStatusTool(dir).InvokableRun(context.Background(), `{}`); err == nil || !strings.Contains(err.Error(), "不是 Git 仓库") {
+		t.Errorf("InvokableRun() error = %v, want not a repository", err)
+	}
+}
+
+func TestGitTool_WorkDirInsideRepository(t *testing.T) {
+	dir := newTestGitRepo(t)
+	writePatchFile(t, dir, "secret.txt", "外部文件\n")
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writePatchFile(t, sub, "notes.txt", "子目录文件\n")
+
+	// 工作目录只是更大仓库中的子目录时不能操作上层仓库
+	if _, err := NewGitStatusTool(sub).InvokableRun(context.Background(), `{}`); err == nil || !strings.Contains(err.Error(), "不是 Git 仓库") {
+		t.Errorf("git_status error = %v, want not a repository", err)
+	}
+	if _, err := NewGitCommitTool(sub, true).InvokableRun(context.Background(), `{"message": "提交全部", "all": true}`); err == nil {
+		t.Error("git_commit in sub directory succeeded")
+	}
+	if status := gitRun(t, dir, "status", "--porcelain"); !strings.Contains(status, "?? secret.txt") {
+		t.Errorf("git status of parent = %q", status)
+	}
+}
+
+func TestGitTool_SymlinkOutsideWorkDir(t *testing.T) {
+	dir := newTestGitRepo(t)
+	outside := t.TempDir()
+	writePatchFile(t, outside, "secret.txt", "外部文件\n")
+	if err := os.Symlink(outside, filepath.Join(dir, "link")); err != nil {
+		t.Skipf("不支持符号链接: %v", err)
+	}
+
+	for _, path := range []string{"link", "link/secret.txt", filepath.Join(dir, "link", "secret.txt")} {
+		args, _ := json.Marshal(map[string]interface{}{"message": "提交链接", "paths": []string{path}})
+		if _, err := NewGitCommitTool(dir, true).InvokableRun(context.Background(), string(args)); err == nil || !strings.Contains(err.Error(), "超出允许范围") {
+			t.Errorf("git_commit %s error = %v, want out of range", path, err)
+		}
+	}
+}
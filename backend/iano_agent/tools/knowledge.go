@@ -0,0 +1,124 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// KBSearchToolName 知识库检索工具名称
+const KBSearchToolName = "kb_search"
+
+// DefaultKBSearchLimit 检索知识库时默认返回的分块数
+const DefaultKBSearchLimit = 5
+
+// KnowledgeChunk 知识库检索命中的文档分块
+type KnowledgeChunk struct {
+	ID              string  // 分块 ID
+	KnowledgeBaseID string  // 所属知识库 ID
+	KnowledgeBase   string  // 所属知识库名称
+	DocumentID      string  // 所属文档 ID
+	Title           string  // 文档标题
+	Heading         string  // 分块所在的小节标题
+	Source          string  // 文档来源，例如文件路径或附件名
+	Ordinal         int     // 分块在文档中的序号
+	Content         string  // 分块内容
+	Score           float64 // 相关度，越大越相关
+}
+
+// KnowledgeSearcher 知识库检索
+type KnowledgeSearcher interface {
+	// Search 在指定的知识库中检索与 query 相关的分块，按相关度从高到低排列
+	Search(ctx context.Context, kbIDs []string, query string, limit int) ([]*KnowledgeChunk, error)
+}
+
+// CitationRecorder 记录返回给模型的分块，返回该分块在本次运行中的引用编号
+type CitationRecorder func(chunk *KnowledgeChunk) int
+
+// KBSearchTool 检索绑定的知识库，返回带引用编号的分块
+type KBSearchTool struct {
+	searcher KnowledgeSearcher
+	kbIDs    []string
+	record   CitationRecorder
+}
+
+// NewKBSearchTool 创建限定在 kbIDs 范围内的 kb_search 工具，record 为空时按结果顺序编号
+func NewKBSearchTool(searcher KnowledgeSearcher, kbIDs []string, record CitationRecorder) *KBSearchTool {
+	return &KBSearchTool{searcher: searcher, kbIDs: kbIDs, record: record}
+}
+
+func (t *KBSearchTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: KBSearchToolName,
+		Desc: "在绑定的知识库中检索内部文档。回答涉及内部文档的问题前先检索，并在答复中用 [编号] 标注引用的内容",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"query": {
+				Type:     schema.String,
+				Desc:     "检索内容，使用关键词或完整的问题",
+				Required: true,
+			},
+			"limit": {
+				Type:     schema.Number,
+				Desc:     fmt.Sprintf("最多返回的分块数（默认 %d）", DefaultKBSearchLimit),
+				Required: false,
+			},
+		}),
+	}, nil
+}
+
+func (t *KBSearchTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	var args struct {
+		Query string `json:"query"`
+		Limit int    `json:"limit"`
+	}
+	if err := json.Unmarshal([]byte(argumentsInJSON), &args); err != nil {
+		return "", fmt.Errorf("参数解析失败: %w", err)
+	}
+
+	query := strings.TrimSpace(args.Query)
+	if query == "" {
+		return "", fmt.Errorf("检索内容不能为空")
+	}
+	if args.Limit <= 0 || args.Limit > 20 {
+		args.Limit = DefaultKBSearchLimit
+	}
+
+	chunks, err := t.searcher.Search(ctx, t.kbIDs, query, args.Limit)
+	if err != nil {
+		return "", fmt.Errorf("检索知识库失败: %w", err)
+	}
+	if len(chunks) == 0 {
+		return "知识库中没有找到相关内容", nil
+	}
+
+	var result strings.Builder
+	for i, chunk := range chunks {
+		index := i + 1
+		if t.record != nil {
+			index = t.record(chunk)
+		}
+		result.WriteString(fmt.Sprintf("[%d] %s\n%s\n\n", index, CitationTitle(chunk), chunk.Content))
+	}
+	result.WriteString("引用以上内容时，在相应语句后标注编号，例如 [1]")
+	return result.String(), nil
+}
+
+// CitationTitle 引用的显示标题：文档标题、小节标题和来源，小节标题已包含文档标题时不重复
+func CitationTitle(chunk *KnowledgeChunk) string {
+	title := chunk.Title
+	switch {
+	case chunk.Heading == "" || chunk.Heading == chunk.Title:
+	case strings.HasPrefix(chunk.Heading, chunk.Title+" > "):
+		title = chunk.Heading
+	default:
+		title += " > " + chunk.Heading
+	}
+	if chunk.Source != "" && chunk.Source != chunk.Title {
+		title += "（来源：" + chunk.Source + "）"
+	}
+	return title
+}
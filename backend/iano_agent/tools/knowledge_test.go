@@ -0,0 +1,59 @@
+package tools
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fixedKnowledgeSearcher 返回固定分块并记录检索条件
+type fixedKnowledgeSearcher struct {
+	chunks []*KnowledgeChunk
+	kbIDs  []string
+	query  string
+	limit  int
+}
+
+func (s *fixedKnowledgeSearcher) Search(ctx context.Context, kbIDs []string, query string, limit int) ([]*KnowledgeChunk, error) {
+	s.kbIDs, s.query, s.limit = kbIDs, query, limit
+	return s.chunks, nil
+}
+
+func TestKBSearchTool(t *testing.T) {
+	searcher := &fixedKnowledgeSearcher{chunks: []*KnowledgeChunk{
+		{ID: "c1", Title: "部署指南", Heading: "部署指南 > 数据库", Source: "docs/deploy.md", Content: "连接串写在 config.yaml 中"},
+		{ID: "c2", Title: "FAQ", Content: "默认端口为 8080"},
+	}}
+	var recorded []string
+	kb := NewKBSearchTool(searcher, []string{"kb1", "kb2"}, func(chunk *KnowledgeChunk) int {
+		recorded = append(recorded, chunk.ID)
+		return len(recorded) + 2
+	})
+	ctx := context.Background()
+
+	result, err := kb.InvokableRun(ctx, `{"query": " 数据库配置 ", "limit": 100}`)
+	if err != nil {
+		t.Fatalf("kb_search error = %v", err)
+	}
+	if !reflect.DeepEqual(searcher.kbIDs, []string{"kb1", "kb2"}) || searcher.query != "数据库配置" || searcher.limit != DefaultKBSearchLimit {
+		t.Errorf("search kbIDs %v, query %q, limit %d", searcher.kbIDs, searcher.query, searcher.limit)
+	}
+	for _, want := range []string{
+		"[3] 部署指南 > 数据库（来源：docs/deploy.md）\n连接串写在 config.yaml 中",
+		"[4] FAQ\n默认端口为 8080",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("kb_search = %q, want %q", result, want)
+		}
+	}
+
+	if _, err := kb.InvokableRun(ctx, `{"query": ""}`); err == nil {
+		t.Error("kb_search with empty query error = nil")
+	}
+
+	searcher.chunks = nil
+	if result, _ := kb.InvokableRun(ctx, `{"query": "无关"}`); result != "知识库中没有找到相关内容" {
+		t.Errorf("kb_search without result = %q", result)
+	}
+}
@@ -0,0 +1,286 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// 记忆工具名称
+const (
+	MemorySaveToolName   = "memory_save"
+	MemorySearchToolName = "memory_search"
+	MemoryForgetToolName = "memory_forget"
+)
+
+// DefaultMemorySearchLimit 搜索记忆时默认返回的条数
+const DefaultMemorySearchLimit = 5
+
+// MemoryScope 记忆的归属范围，AgentID 必填，UserID 和 Workspace 为空时表示不按用户或工作区区分
+type MemoryScope struct {
+	AgentID   string // 所属 Agent
+	UserID    string // 所属用户
+	Workspace string // 所属工作区，通常为工作目录
+}
+
+// MemoryItem 一条长期记忆
+type MemoryItem struct {
+	ID        string    // 记忆 ID
+	Content   string    // 记忆内容
+	Tags      []string  // 标签
+	CreatedAt time.Time // 记住的时间
+}
+
+// MemoryStore 长期记忆的存储，所有操作都限定在 scope 范围内
+type MemoryStore interface {
+	// Save 保存一条记忆，范围内已有相同内容时返回已有的记忆
+	Save(ctx context.Context, scope MemoryScope, content string, tags []string) (*MemoryItem, error)
+	// Search 按相关度搜索记忆，query 为空时返回最近的记忆
+	Search(ctx context.Context, scope MemoryScope, query string, limit int) ([]*MemoryItem, error)
+	// Forget 删除一条记忆，记忆不存在或不属于该范围时返回错误
+	Forget(ctx context.Context, scope MemoryScope, id string) error
+}
+
+// NewMemoryTools 创建绑定到指定范围的 memory_save、memory_search、memory_forget 工具
+func NewMemoryTools(store MemoryStore, scope MemoryScope) map[string]tool.InvokableTool {
+	return map[string]tool.InvokableTool{
+		MemorySaveToolName:   &MemorySaveTool{store: store, scope: scope},
+		MemorySearchToolName: &MemorySearchTool{store: store, scope: scope},
+		MemoryForgetToolName: &MemoryForgetTool{store: store, scope: scope},
+	}
+}
+
+// MemorySaveTool 保存长期记忆
+type MemorySaveTool struct {
+	store MemoryStore
+	scope MemoryScope
+}
+
+func (t *MemorySaveTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: MemorySaveToolName,
+		Desc: "记住一条在以后的会话中仍然有用的信息，例如用户的偏好、项目约定或已确认的事实。每条记忆只写一个要点，不要保存密码、密钥等敏感信息",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"content": {
+				Type:     schema.String,
+				Desc:     "要记住的内容，使用完整、独立可读的一句话",
+				Required: true,
+			},
+			"tags": {
+				Type:     schema.String,
+				Desc:     "标签，多个标签用逗号分隔",
+				Required: false,
+			},
+		}),
+	}, nil
+}
+
+// ParallelSafe 写入记忆有副作用，串行执行
+func (t *MemorySaveTool) ParallelSafe() bool {
+	return false
+}
+
+func (t *MemorySaveTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	var args struct {
+		Content string `json:"content"`
+		Tags    string `json:"tags"`
+	}
+	if err := json.Unmarshal([]byte(argumentsInJSON), &args); err != nil {
+		return "", fmt.Errorf("参数解析失败: %w", err)
+	}
+
+	content := strings.TrimSpace(args.Content)
+	if content == "" {
+		return "", fmt.Errorf("记忆内容不能为空")
+	}
+
+	item, err := t.store.Save(ctx, t.scope, content, SplitMemoryTags(args.Tags))
+	if err != nil {
+		return "", fmt.Errorf("保存记忆失败: %w", err)
+	}
+	return fmt.Sprintf("已记住（ID: %s）：%s", item.ID, item.Content), nil
+}
+
+// MemorySearchTool 搜索长期记忆
+type MemorySearchTool struct {
+	store MemoryStore
+	scope MemoryScope
+}
+
+func (t *MemorySearchTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: MemorySearchToolName,
+		Desc: "在长期记忆中搜索与问题相关的信息",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"query": {
+				Type:     schema.String,
+				Desc:     "搜索内容，为空时返回最近的记忆",
+				Required: false,
+			},
+			"limit": {
+				Type:     schema.Number,
+				Desc:     fmt.Sprintf("最多返回的条数（默认 %d）", DefaultMemorySearchLimit),
+				Required: false,
+			},
+		}),
+	}, nil
+}
+
+func (t *MemorySearchTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	var args struct {
+		Query string `json:"query"`
+		Limit int    `json:"limit"`
+	}
+	if err := json.Unmarshal([]byte(argumentsInJSON), &args); err != nil {
+		return "", fmt.Errorf("参数解析失败: %w", err)
+	}
+	if args.Limit <= 0 || args.Limit > 50 {
+		args.Limit = DefaultMemorySearchLimit
+	}
+
+	items, err := t.store.Search(ctx, t.scope, strings.TrimSpace(args.Query), args.Limit)
+	if err != nil {
+		return "", fmt.Errorf("搜索记忆失败: %w", err)
+	}
+	if len(items) == 0 {
+		return "没有找到相关的记忆", nil
+	}
+	return FormatMemories(items), nil
+}
+
+// MemoryForgetTool 删除长期记忆
+type MemoryForgetTool struct {
+	store MemoryStore
+	scope MemoryScope
+}
+
+func (t *MemoryForgetTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: MemoryForgetToolName,
+		Desc: "删除一条已经过时或错误的记忆，记忆 ID 可以通过 memory_search 获得",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"id": {
+				Type:     schema.String,
+				Desc:     "要删除的记忆 ID",
+				Required: true,
+			},
+		}),
+	}, nil
+}
+
+// ParallelSafe 删除记忆有副作用，串行执行
+func (t *MemoryForgetTool) ParallelSafe() bool {
+	return false
+}
+
+func (t *MemoryForgetTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	var args struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal([]byte(argumentsInJSON), &args); err != nil {
+		return "", fmt.Errorf("参数解析失败: %w", err)
+	}
+	if args.ID == "" {
+		return "", fmt.Errorf("记忆 ID 不能为空")
+	}
+
+	if err := t.store.Forget(ctx, t.scope, args.ID); err != nil {
+		return "", fmt.Errorf("删除记忆失败: %w", err)
+	}
+	return fmt.Sprintf("已删除记忆 %s", args.ID), nil
+}
+
+// FormatMemories 将记忆格式化为每行一条的列表
+func FormatMemories(items []*MemoryItem) string {
+	var result strings.Builder
+	for _, item := range items {
+		result.WriteString(fmt.Sprintf("- [%s] %s", item.ID, item.Content))
+		if len(item.Tags) > 0 {
+			result.WriteString(fmt.Sprintf("（标签：%s）", strings.Join(item.Tags, ",")))
+		}
+		result.WriteString(fmt.Sprintf("（%s）\n", item.CreatedAt.Format("2006-01-02")))
+	}
+	return result.String()
+}
+
+// SplitMemoryTags 解析逗号分隔的标签，去掉空白和重复项
+func SplitMemoryTags(tags string) []string {
+	seen := make(map[string]bool)
+	result := make([]string, 0)
+	for _, tag := range strings.FieldsFunc(tags, func(r rune) bool { return r == ',' || r == '，' }) {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+// MemoryTerms 将文本拆分为用于匹配的词项：英文和数字按单词切分并转为小写，中文等按相邻两字切分
+func MemoryTerms(text string) []string {
+	seen := make(map[string]bool)
+	terms := make([]string, 0)
+	add := func(term string) {
+		if term != "" && !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+
+	var word []rune
+	var han []rune
+	flush := func() {
+		if len(word) > 0 {
+			add(strings.ToLower(string(word)))
+			word = word[:0]
+		}
+		if len(han) == 1 {
+			add(string(han))
+		}
+		for i := 0; i+1 < len(han); i++ {
+			add(string(han[i : i+2]))
+		}
+		han = han[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			if len(word) > 0 {
+				flush()
+			}
+			han = append(han, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if len(han) > 0 {
+				flush()
+			}
+			word = append(word, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return terms
+}
+
+// ScoreMemory 记忆内容与查询的相关度，为查询词项在内容中出现的比例，0 表示不相关
+func ScoreMemory(queryTerms []string, content string) float64 {
+	if len(queryTerms) == 0 {
+		return 0
+	}
+	content = strings.ToLower(content)
+	matched := 0
+	for _, term := range queryTerms {
+		if strings.Contains(content, term) {
+			matched++
+		}
+	}
+	return float64(matched) / float64(len(queryTerms))
+}
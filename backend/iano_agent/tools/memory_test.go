@@ -0,0 +1,134 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mapMemoryStore 基于内存的记忆存储，按 ScoreMemory 排序
+type mapMemoryStore struct {
+	items  map[MemoryScope][]*MemoryItem
+	nextID int
+}
+
+func newMapMemoryStore() *mapMemoryStore {
+	return &mapMemoryStore{items: make(map[MemoryScope][]*MemoryItem)}
+}
+
+func (s *mapMemoryStore) Save(ctx context.Context, scope MemoryScope, content string, tags []string) (*MemoryItem, error) {
+	for _, item := range s.items[scope] {
+		if item.Content == content {
+			return item, nil
+		}
+	}
+	s.nextID++
+	item := &MemoryItem{ID: fmt.Sprintf("m%d", s.nextID), Content: content, Tags: tags, CreatedAt: time.Now()}
+	s.items[scope] = append(s.items[scope], item)
+	return item, nil
+}
+
+func (s *mapMemoryStore) Search(ctx context.Context, scope MemoryScope, query string, limit int) ([]*MemoryItem, error) {
+	terms := MemoryTerms(query)
+	var result []*MemoryItem
+	for _, item := range s.items[scope] {
+		if query == "" || ScoreMemory(terms, item.Content) > 0 {
+			result = append(result, item)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return ScoreMemory(terms, result[i].Content) > ScoreMemory(terms, result[j].Content)
+	})
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+func (s *mapMemoryStore) Forget(ctx context.Context, scope MemoryScope, id string) error {
+	items := s.items[scope]
+	for i, item := range items {
+		if item.ID == id {
+			s.items[scope] = append(items[:i], items[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("记忆 %s 不存在", id)
+}
+
+func TestMemoryTerms(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{text: "Go 1.24 and Vue", want: []string{"go", "1", "24", "and", "vue"}},
+		{text: "喜欢用Go写后端", want: []string{"喜欢", "欢用", "go", "写后", "后端"}},
+		{text: "猫，狗", want: []string{"猫", "狗"}},
+		{text: "  ", want: []string{}},
+	}
+	for _, tt := range tests {
+		if got := MemoryTerms(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("MemoryTerms(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestScoreMemory(t *testing.T) {
+	terms := MemoryTerms("用户喜欢的编程语言")
+	if got := ScoreMemory(terms, "用户喜欢的编程语言是 Go"); got != 1 {
+		t.Errorf("ScoreMemory(full match) = %v, want 1", got)
+	}
+	if partial := ScoreMemory(terms, "用户喜欢喝咖啡"); partial <= 0 || partial >= 1 {
+		t.Errorf("ScoreMemory(partial match) = %v", partial)
+	}
+	if got := ScoreMemory(terms, "项目使用 MIT 协议"); got != 0 {
+		t.Errorf("ScoreMemory(no match) = %v, want 0", got)
+	}
+}
+
+func TestMemoryTools(t *testing.T) {
+	store := newMapMemoryStore()
+	scope := MemoryScope{AgentID: "agent-1", UserID: "alice"}
+	memoryTools := NewMemoryTools(store, scope)
+	ctx := context.Background()
+
+	saved, err := memoryTools[MemorySaveToolName].InvokableRun(ctx, `{"content": "用户喜欢的编程语言是 Go", "tags": "偏好, 语言，偏好"}`)
+	if err != nil || !strings.Contains(saved, "ID: m1") {
+		t.Fatalf("memory_save = %q, %v", saved, err)
+	}
+	if tags := store.items[scope][0].Tags; !reflect.DeepEqual(tags, []string{"偏好", "语言"}) {
+		t.Errorf("tags = %q", tags)
+	}
+	if _, err := memoryTools[MemorySaveToolName].InvokableRun(ctx, `{"content": " "}`); err == nil {
+		t.Error("memory_save with empty content error = nil")
+	}
+
+	found, err := memoryTools[MemorySearchToolName].InvokableRun(ctx, `{"query": "编程语言"}`)
+	if err != nil || !strings.Contains(found, "[m1] 用户喜欢的编程语言是 Go（标签：偏好,语言）") {
+		t.Errorf("memory_search = %q, %v", found, err)
+	}
+
+	// 其他用户的范围内看不到这条记忆
+	other := NewMemoryTools(store, MemoryScope{AgentID: "agent-1", UserID: "bob"})
+	if found, _ := other[MemorySearchToolName].InvokableRun(ctx, `{"query": "编程语言"}`); found != "没有找到相关的记忆" {
+		t.Errorf("memory_search in other scope = %q", found)
+	}
+	if _, err := other[MemoryForgetToolName].InvokableRun(ctx, `{"id": "m1"}`); err == nil {
+		t.Error("memory_forget in other scope error = nil")
+	}
+
+	if _, err := memoryTools[MemoryForgetToolName].InvokableRun(ctx, `{"id": "m1"}`); err != nil {
+		t.Fatalf("memory_forget error = %v", err)
+	}
+	if len(store.items[scope]) != 0 {
+		t.Errorf("memories after forget = %+v", store.items[scope])
+	}
+
+	if IsParallelSafe(memoryTools[MemorySaveToolName]) || !IsParallelSafe(memoryTools[MemorySearchToolName]) {
+		t.Error("memory_save should be serial and memory_search parallel safe")
+	}
+}
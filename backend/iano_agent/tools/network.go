@@ -166,13 +166,11 @@ func (t *DNSLookupTool) InvokableRun(ctx context.Context, argumentsInJSON string
 			}
 		}
 	case "CNAME":
-		cnames, err := net.LookupCNAME(args.Host)
+		cname, err := net.LookupCNAME(args.Host)
 		if err != nil {
 			return fmt.Sprintf("查询失败: %v", err), nil
 		}
-		for _, cname := range cnames {
-			result.WriteString(fmt.Sprintf("CNAME %s -> %s\n", args.Host, cname))
-		}
+		result.WriteString(fmt.Sprintf("CNAME %s -> %s\n", args.Host, cname))
 	case "MX":
 		mxs, err := net.LookupMX(args.Host)
 		if err != nil {
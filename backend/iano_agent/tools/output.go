@@ -0,0 +1,280 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	outputHeadSize      = 16 * 1024 // 工具结果中保留的输出开头字节数
+	outputTailSize      = 16 * 1024 // 工具结果中保留的输出末尾字节数
+	maxProgressLineSize = 4 * 1024  // 单条进度的最大长度，超过时不等换行直接上报
+	maxPendingProgress  = 1000      // 等待上报的最大行数，上报跟不上输出时丢弃多出的行
+)
+
+// 输出流名称
+const (
+	StreamStdout = "stdout"
+	StreamStderr = "stderr"
+)
+
+// ProgressReporter 接收工具执行过程中的输出，每次一行（不含换行符）
+type ProgressReporter func(stream, line string)
+
+type progressReporterKey struct{}
+
+// WithProgressReporter 在 ctx 中注册进度上报函数，命令类工具运行期间逐行上报输出
+func WithProgressReporter(ctx context.Context, reporter ProgressReporter) context.Context {
+	return context.WithValue(ctx, progressReporterKey{}, reporter)
+}
+
+func progressReporterFrom(ctx context.Context) ProgressReporter {
+	reporter, _ := ctx.Value(progressReporterKey{}).(ProgressReporter)
+	return reporter
+}
+
+// Artifact 工具执行产生的可下载文件
+type Artifact struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+	URL  string `json:"url"` // 下载地址
+}
+
+// ArtifactSaver 保存工具产生的文件，返回下载信息
+type ArtifactSaver func(ctx context.Context, name string, r io.Reader) (*Artifact, error)
+
+type artifactSaverKey struct{}
+
+// WithArtifactSaver 在 ctx 中注册文件保存函数，命令输出被截断时用于保存完整日志
+func WithArtifactSaver(ctx context.Context, saver ArtifactSaver) context.Context {
+	return context.WithValue(ctx, artifactSaverKey{}, saver)
+}
+
+func artifactSaverFrom(ctx context.Context) ArtifactSaver {
+	saver, _ := ctx.Value(artifactSaverKey{}).(ArtifactSaver)
+	return saver
+}
+
+// commandLog 收集命令的标准输出和标准错误：逐行上报进度，只在内存中保留每个流的开头和末尾，
+// ctx 中注册了文件保存函数时把两个流按行交错写入临时文件作为完整日志
+//
+// 进度在单独的 goroutine 中上报，避免上报较慢时阻塞命令输出的读取。
+type commandLog struct {
+	mu       sync.Mutex
+	report   ProgressReporter
+	saver    ArtifactSaver
+	file     *os.File // 完整日志，未注册保存函数或创建失败时为 nil
+	stdout   *outputStream
+	stderr   *outputStream
+	queue    []progressLine // 等待上报的行
+	dropped  map[string]int // 队列已满时丢弃的行数，按输出流统计
+	finished bool
+	notify   chan struct{}
+	reported chan struct{} // 上报 goroutine 退出时关闭
+}
+
+type progressLine struct {
+	stream string
+	line   string
+}
+
+func newCommandLog(ctx context.Context) *commandLog {
+	l := &commandLog{
+		report: progressReporterFrom(ctx),
+		saver:  artifactSaverFrom(ctx),
+	}
+	l.stdout = &outputStream{log: l, name: StreamStdout}
+	l.stderr = &outputStream{log: l, name: StreamStderr}
+	if l.report != nil {
+		l.dropped = make(map[string]int)
+		l.notify = make(chan struct{}, 1)
+		l.reported = make(chan struct{})
+		go l.reportLoop()
+	}
+	if l.saver != nil {
+		file, err := os.CreateTemp("", "iano-command-*.log")
+		if err != nil {
+			slog.Warn("创建命令日志文件失败", "error", err)
+		} else {
+			l.file = file
+		}
+	}
+	return l
+}
+
+// finish 上报两个流中最后不以换行结尾的内容并等待全部进度上报完成，应在命令结束后调用
+func (l *commandLog) finish() {
+	l.stdout.flush()
+	l.stderr.flush()
+	if l.report == nil {
+		return
+	}
+	l.mu.Lock()
+	l.finished = true
+	l.mu.Unlock()
+	close(l.notify)
+	<-l.reported
+}
+
+// enqueue 将一行输出加入上报队列，调用方需持有 l.mu
+func (l *commandLog) enqueue(stream, line string) {
+	if l.finished {
+		return
+	}
+	if len(l.queue) >= maxPendingProgress {
+		l.dropped[stream]++
+		return
+	}
+	l.queue = append(l.queue, progressLine{stream: stream, line: line})
+	select {
+	case l.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (l *commandLog) reportLoop() {
+	defer close(l.reported)
+	for {
+		_, ok := <-l.notify
+		l.mu.Lock()
+		lines, dropped := l.queue, l.dropped
+		l.queue, l.dropped = nil, make(map[string]int)
+		l.mu.Unlock()
+
+		for _, p := range lines {
+			l.report(p.stream, p.line)
+		}
+		for _, stream := range []string{StreamStdout, StreamStderr} {
+			if n := dropped[stream]; n > 0 {
+				l.report(stream, fmt.Sprintf("... (输出过快，省略 %d 行，完整内容见日志)", n))
+			}
+		}
+		if !ok {
+			return
+		}
+	}
+}
+
+// truncated 是否有输出未包含在摘要中
+func (l *commandLog) truncated() bool {
+	return l.stdout.omitted() > 0 || l.stderr.omitted() > 0
+}
+
+// saveArtifact 输出被截断时保存完整日志，返回写入工具结果的说明，无需保存时返回空字符串
+func (l *commandLog) saveArtifact(ctx context.Context, name string) string {
+	if !l.truncated() || l.file == nil {
+		return ""
+	}
+	if _, err := l.file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Sprintf("完整日志保存失败: %v\n", err)
+	}
+	artifact, err := l.saver(ctx, name, l.file)
+	if err != nil {
+		slog.Warn("保存命令日志失败", "error", err)
+		return fmt.Sprintf("完整日志保存失败: %v\n", err)
+	}
+	return fmt.Sprintf("完整日志: %s (%d 字节) %s\n", artifact.Name, artifact.Size, artifact.URL)
+}
+
+// close 删除临时日志文件
+func (l *commandLog) close() {
+	if l.file != nil {
+		l.file.Close()
+		os.Remove(l.file.Name())
+	}
+}
+
+// artifactName 完整日志的文件名
+func artifactName(toolName string) string {
+	return fmt.Sprintf("%s-%s.log", toolName, time.Now().Format("20060102-150405"))
+}
+
+// outputStream 命令的一个输出流，实现 io.Writer
+type outputStream struct {
+	log     *commandLog
+	name    string
+	pending []byte // 尚未遇到换行的内容
+	head    bytes.Buffer
+	tail    []byte // 超过开头部分的内容，只保留最后 outputTailSize 字节
+	total   int64
+}
+
+func (s *outputStream) Write(p []byte) (int, error) {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	s.pending = append(s.pending, p...)
+	for {
+		i := bytes.IndexByte(s.pending, '\n')
+		if i < 0 {
+			break
+		}
+		s.emit(s.pending[:i+1])
+		s.pending = s.pending[i+1:]
+	}
+	if len(s.pending) >= maxProgressLineSize {
+		s.emit(s.pending)
+		s.pending = s.pending[:0]
+	}
+	// 保留剩余内容的副本，避免 pending 引用的底层数组无限增长
+	s.pending = append([]byte(nil), s.pending...)
+	return len(p), nil
+}
+
+func (s *outputStream) flush() {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+	if len(s.pending) > 0 {
+		s.emit(s.pending)
+		s.pending = nil
+	}
+}
+
+// emit 记录一行输出并上报进度，调用方需持有 log.mu
+func (s *outputStream) emit(line []byte) {
+	s.total += int64(len(line))
+	rest := line
+	if n := outputHeadSize - s.head.Len(); n > 0 {
+		n = min(n, len(rest))
+		s.head.Write(rest[:n])
+		rest = rest[n:]
+	}
+	s.tail = append(s.tail, rest...)
+	if len(s.tail) > 2*outputTailSize {
+		s.tail = append(s.tail[:0], s.tail[len(s.tail)-outputTailSize:]...)
+	}
+
+	if s.log.file != nil {
+		s.log.file.Write(line)
+	}
+	if s.log.report != nil {
+		s.log.enqueue(s.name, strings.TrimRight(string(line), "\r\n"))
+	}
+}
+
+// omitted 未包含在摘要中的字节数
+func (s *outputStream) omitted() int64 {
+	return s.total - int64(s.head.Len()) - int64(min(len(s.tail), outputTailSize))
+}
+
+// summary 返回输出的开头和末尾，中间省略的部分用说明代替
+func (s *outputStream) summary() string {
+	tail := s.tail
+	if len(tail) > outputTailSize {
+		tail = tail[len(tail)-outputTailSize:]
+	}
+	omitted := s.omitted()
+	if omitted <= 0 {
+		return s.head.String() + string(tail)
+	}
+	return fmt.Sprintf("%s\n... (省略 %d 字节) ...\n%s",
+		strings.ToValidUTF8(s.head.String(), ""), omitted, strings.ToValidUTF8(string(tail), ""))
+}
@@ -0,0 +1,99 @@
+package tools
+
+import (
+	"context"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// outputBuffer 只保留最近输出的缓冲区，偏移按累计写入的字节数计算，调用方负责加锁
+type outputBuffer struct {
+	buf     []byte        // 最近的输出
+	start   int64         // buf[0] 的偏移
+	size    int           // 最多保留的字节数
+	changed chan struct{} // 有新输出时关闭并替换
+}
+
+// outputChunk 从缓冲区读取的一段输出
+type outputChunk struct {
+	data    []byte
+	offset  int64 // data 的起始偏移
+	next    int64 // 下次读取的偏移
+	more    bool  // next 之后还有输出
+	dropped int64 // 请求的偏移之后已被丢弃的字节数
+}
+
+func newOutputBuffer(size int) outputBuffer {
+	return outputBuffer{size: size, changed: make(chan struct{})}
+}
+
+// end 当前输出末尾的偏移
+func (b *outputBuffer) end() int64 {
+	return b.start + int64(len(b.buf))
+}
+
+func (b *outputBuffer) write(data []byte) {
+	b.buf = append(b.buf, data...)
+	if over := len(b.buf) - b.size; over > 0 {
+		b.buf = append(b.buf[:0], b.buf[over:]...)
+		b.start += int64(over)
+	}
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+// read 从 offset 开始读取最多 maxBytes 字节，offset 小于 0 时读取最近的输出，不拆开多字节字符
+func (b *outputBuffer) read(offset int64, maxBytes int) outputChunk {
+	end := b.end()
+	var chunk outputChunk
+	if offset < 0 {
+		offset = max(end-int64(maxBytes), b.start)
+		// 从最近的输出读取时从完整的字符开始
+		for offset < end && !utf8.RuneStart(b.buf[offset-b.start]) {
+			offset++
+		}
+	}
+	offset = min(offset, end)
+	if offset < b.start {
+		chunk.dropped = b.start - offset
+		offset = b.start
+	}
+
+	stop := min(end, offset+int64(maxBytes))
+	for stop < end && stop > offset && !utf8.RuneStart(b.buf[stop-b.start]) {
+		stop--
+	}
+	chunk.offset = offset
+	chunk.next = stop
+	chunk.data = append([]byte(nil), b.buf[offset-b.start:stop-b.start]...)
+	chunk.more = stop < end
+	return chunk
+}
+
+// waitForOutput 等待 offset 之后出现输出，最多等待 wait，exited 关闭或 ctx 取消时立即返回
+func waitForOutput(ctx context.Context, mu *sync.Mutex, b *outputBuffer, exited <-chan struct{}, offset int64, wait time.Duration) error {
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	for {
+		mu.Lock()
+		pending := offset < b.end()
+		changed := b.changed
+		mu.Unlock()
+		if pending {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-exited:
+			return nil
+		case <-timer.C:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
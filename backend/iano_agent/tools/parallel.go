@@ -0,0 +1,41 @@
+package tools
+
+import "github.com/cloudwego/eino/components/tool"
+
+// ParallelSafeTool 可声明是否允许与其他工具并发执行的工具
+//
+// 未实现该接口的工具视为并发安全；写文件、执行命令等有副作用的工具应返回 false，
+// 它们会在前面的工具调用完成后独占执行。
+type ParallelSafeTool interface {
+	ParallelSafe() bool
+}
+
+// IsParallelSafe 工具是否可以与其他工具并发执行
+func IsParallelSafe(t tool.BaseTool) bool {
+	if p, ok := t.(ParallelSafeTool); ok {
+		return p.ParallelSafe()
+	}
+	return true
+}
+
+// 以下工具会修改文件系统或进程环境，必须串行执行
+
+func (t *FileWriteTool) ParallelSafe() bool      { return false }
+func (t *FilePatchTool) ParallelSafe() bool      { return false }
+func (t *FileCreateTool) ParallelSafe() bool     { return false }
+func (t *FileDeleteTool) ParallelSafe() bool     { return false }
+func (t *GrepReplaceTool) ParallelSafe() bool    { return false }
+func (t *ArchiveCreateTool) ParallelSafe() bool  { return false }
+func (t *ArchiveExtractTool) ParallelSafe() bool { return false }
+func (t *CommandExecuteTool) ParallelSafe() bool { return false }
+func (t *ShellExecuteTool) ParallelSafe() bool   { return false }
+func (t *ProcessStartTool) ParallelSafe() bool   { return false }
+func (t *ProcessInputTool) ParallelSafe() bool   { return false }
+func (t *ProcessStopTool) ParallelSafe() bool    { return false }
+func (t *TerminalOpenTool) ParallelSafe() bool   { return false }
+func (t *TerminalWriteTool) ParallelSafe() bool  { return false }
+func (t *TerminalResizeTool) ParallelSafe() bool { return false }
+func (t *TerminalCloseTool) ParallelSafe() bool  { return false }
+func (t *EnvironmentSetTool) ParallelSafe() bool { return false }
+func (t *GitBranchTool) ParallelSafe() bool      { return false }
+func (t *GitCommitTool) ParallelSafe() bool      { return false }
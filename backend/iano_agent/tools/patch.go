@@ -0,0 +1,931 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+const (
+	// patchContextLines 生成预览 diff 时每个修改块保留的上下文行数
+	patchContextLines = 3
+	// patchMaxFuzz 上下文匹配失败时最多忽略的首尾上下文行数
+	patchMaxFuzz = 2
+	// maxPatchDiffSize 返回的 diff 最大字节数
+	maxPatchDiffSize = 64 * 1024
+	// devNullPath diff 中表示新建或删除文件的路径
+	devNullPath = "/dev/null"
+)
+
+var (
+	hunkHeaderRe    = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+	searchMarkerRe  = regexp.MustCompile(`^<{5,9} ?SEARCH\s*$`)
+	dividerMarkerRe = regexp.MustCompile(`^={5,9}\s*$`)
+	replaceMarkerRe = regexp.MustCompile(`^>{5,9} ?REPLACE\s*$`)
+	leadingSpaceRe  = regexp.MustCompile(`^[ \t]*`)
+)
+
+// FilePatchTool 按 unified diff 或 SEARCH/REPLACE 块修改文件
+type FilePatchTool struct {
+	basePath string
+}
+
+func NewFilePatchTool(basePath string) *FilePatchTool {
+	if basePath == "" {
+		basePath, _ = os.Getwd()
+	}
+	return &FilePatchTool{basePath: basePath}
+}
+
+func (t *FilePatchTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: "file_patch",
+		Desc: "按补丁修改文件，比 file_write 整体覆盖更安全。支持两种格式：" +
+			"1) unified diff（git diff 格式，可包含多个文件，--- /dev/null 表示新建文件）；" +
+			"2) SEARCH/REPLACE 块：<<<<<<< SEARCH\\n原内容\\n=======\\n新内容\\n>>>>>>> REPLACE，原内容必须在文件中唯一匹配。" +
+			"上下文不完全一致时会尝试忽略空白和偏移的模糊匹配，全部修改块都能应用才会写入。dry_run=true 时只返回修改后的 diff 预览",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"patch": {
+				Type:     schema.String,
+				Desc:     "unified diff 或 SEARCH/REPLACE 块",
+				Required: true,
+			},
+			"path": {
+				Type:     schema.String,
+				Desc:     "目标文件路径，SEARCH/REPLACE 块和不带文件头的 diff 必填",
+				Required: false,
+			},
+			"dry_run": {
+				Type:     schema.Boolean,
+				Desc:     "只预览不写入，默认 false",
+				Required: false,
+			},
+		}),
+	}, nil
+}
+
+func (t *FilePatchTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	var args struct {
+		Patch  string `json:"patch"`
+		Path   string `json:"path"`
+		DryRun bool   `json:"dry_run"`
+	}
+
+	slog.Info("file_patch 工具参数", "arguments", argumentsInJSON)
+
+	if err := json.Unmarshal([]byte(argumentsInJSON), &args); err != nil {
+		return "", fmt.Errorf("参数解析失败: %w", err)
+	}
+
+	if strings.TrimSpace(args.Patch) == "" {
+		return "", fmt.Errorf("补丁内容不能为空")
+	}
+
+	results, err := t.prepare(args.Path, args.Patch)
+	if err != nil {
+		return "", err
+	}
+
+	var diff strings.Builder
+	for _, r := range results {
+		diff.WriteString(r.diff)
+	}
+	diffText := diff.String()
+	if len(diffText) > maxPatchDiffSize {
+		diffText = diffText[:maxPatchDiffSize] + "\n... (diff 被截断)"
+	}
+
+	if args.DryRun {
+		return fmt.Sprintf("预览（未写入文件）:\n%s\n%s", summarizePatch(results), diffText), nil
+	}
+
+	for _, r := range results {
+		if err := r.write(); err != nil {
+			return "", err
+		}
+	}
+
+	return fmt.Sprintf("已修改 %d 个文件:\n%s\n%s", len(results), summarizePatch(results), diffText), nil
+}
+
+// prepare 解析补丁并在内存中计算每个文件修改后的内容
+func (t *FilePatchTool) prepare(path, patch string) ([]*patchResult, error) {
+	if isSearchReplacePatch(patch) {
+		return t.prepareSearchReplace(path, patch)
+	}
+	return t.prepareUnifiedDiff(path, patch)
+}
+
+func (t *FilePatchTool) resolvePath(path string) (string, error) {
+	absPath := path
+	if !filepath.IsAbs(path) {
+		absPath = filepath.Join(t.basePath, path)
+	}
+	absPath = filepath.Clean(absPath)
+
+	if t.basePath != "" {
+		rel, err := filepath.Rel(t.basePath, absPath)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			return "", fmt.Errorf("路径超出允许范围")
+		}
+	}
+
+	return absPath, nil
+}
+
+// patchResult 单个文件的修改结果，写入前全部在内存中计算
+type patchResult struct {
+	path    string   // 显示用的路径
+	absPath string   // 实际写入的路径
+	created bool     // 新建文件
+	deleted bool     // 删除文件
+	lines   []string // 修改后的内容
+	eol     bool     // 末尾是否有换行
+	crlf    bool     // 使用 CRLF 换行
+	mode    os.FileMode
+	edits   []lineEdit
+	notes   []string // 模糊匹配等提示
+	diff    string
+}
+
+func (r *patchResult) write() error {
+	if r.deleted {
+		if err := os.Remove(r.absPath); err != nil {
+			return fmt.Errorf("删除文件 %s 失败: %w", r.path, err)
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.absPath), 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+	if err := os.WriteFile(r.absPath, []byte(joinLines(r.lines, r.eol, r.crlf)), r.mode); err != nil {
+		return fmt.Errorf("写入文件 %s 失败: %w", r.path, err)
+	}
+	return nil
+}
+
+func summarizePatch(results []*patchResult) string {
+	var sb strings.Builder
+	for _, r := range results {
+		added, removed := 0, 0
+		for _, e := range r.edits {
+			added += len(e.newLines)
+			removed += len(e.oldLines)
+		}
+		action := "修改"
+		if r.created {
+			action = "新建"
+		} else if r.deleted {
+			action = "删除"
+		}
+		fmt.Fprintf(&sb, "- %s %s: %d 处修改 (+%d -%d)\n", action, r.path, len(r.edits), added, removed)
+		for _, note := range r.notes {
+			fmt.Fprintf(&sb, "  注意: %s\n", note)
+		}
+	}
+	return sb.String()
+}
+
+// loadPatchTarget 读取补丁的目标文件，文件不存在时返回空内容
+func (t *FilePatchTool) loadPatchTarget(path string) (*patchResult, []string, error) {
+	absPath, err := t.resolvePath(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r := &patchResult{path: path, absPath: absPath, eol: true, mode: 0644}
+	info, err := os.Stat(absPath)
+	if os.IsNotExist(err) {
+		r.created = true
+		return r, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取文件 %s 失败: %w", path, err)
+	}
+	if info.IsDir() {
+		return nil, nil, fmt.Errorf("路径 %s 是目录，不是文件", path)
+	}
+	if info.Size() > maxFileSize {
+		return nil, nil, fmt.Errorf("文件 %s 大小超过限制 (%d MB)", path, maxFileSize/1024/1024)
+	}
+
+	content, err := os.ReadFile(absPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取文件 %s 失败: %w", path, err)
+	}
+	r.mode = info.Mode().Perm()
+	r.crlf = strings.Contains(string(content), "\r\n")
+	lines, eol := splitLines(string(content))
+	r.eol = eol
+	return r, lines, nil
+}
+
+// splitLines 按行切分文本，返回末尾是否有换行
+func splitLines(content string) ([]string, bool) {
+	if content == "" {
+		return nil, true
+	}
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	eol := strings.HasSuffix(content, "\n")
+	content = strings.TrimSuffix(content, "\n")
+	return strings.Split(content, "\n"), eol
+}
+
+func joinLines(lines []string, eol, crlf bool) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	sep := "\n"
+	if crlf {
+		sep = "\r\n"
+	}
+	text := strings.Join(lines, sep)
+	if eol {
+		text += sep
+	}
+	return text
+}
+
+// lineEdit 一处修改，oldStart 为修改前文件中的行下标（从 0 开始）
+type lineEdit struct {
+	oldStart int
+	oldLines []string
+	newLines []string
+}
+
+// applyEdits 把按位置排序、互不重叠的修改应用到原始内容
+func applyEdits(lines []string, edits []lineEdit) []string {
+	out := make([]string, 0, len(lines))
+	cursor := 0
+	for _, e := range edits {
+		out = append(out, lines[cursor:e.oldStart]...)
+		out = append(out, e.newLines...)
+		cursor = e.oldStart + len(e.oldLines)
+	}
+	return append(out, lines[cursor:]...)
+}
+
+// renderDiff 根据修改生成 unified diff
+func renderDiff(oldName, newName string, lines []string, edits []lineEdit) string {
+	trimmed := make([]lineEdit, 0, len(edits))
+	for _, e := range edits {
+		e = trimEdit(e)
+		if len(e.oldLines) > 0 || len(e.newLines) > 0 {
+			trimmed = append(trimmed, e)
+		}
+	}
+	if len(trimmed) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+
+	delta := 0
+	for i := 0; i < len(trimmed); {
+		// 上下文相互覆盖的修改合并为一个修改块
+		j := i + 1
+		for j < len(trimmed) && trimmed[j].oldStart-patchContextLines <= editEnd(trimmed[j-1])+patchContextLines {
+			j++
+		}
+		group := trimmed[i:j]
+
+		start := max(group[0].oldStart-patchContextLines, 0)
+		end := min(editEnd(group[len(group)-1])+patchContextLines, len(lines))
+
+		var body strings.Builder
+		oldCount, newCount := 0, 0
+		cursor := start
+		for _, e := range group {
+			for _, l := range lines[cursor:e.oldStart] {
+				body.WriteString(" " + l + "\n")
+				oldCount++
+				newCount++
+			}
+			for _, l := range e.oldLines {
+				body.WriteString("-" + l + "\n")
+				oldCount++
+			}
+			for _, l := range e.newLines {
+				body.WriteString("+" + l + "\n")
+				newCount++
+			}
+			cursor = editEnd(e)
+		}
+		for _, l := range lines[cursor:end] {
+			body.WriteString(" " + l + "\n")
+			oldCount++
+			newCount++
+		}
+
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n%s", hunkRange(start, oldCount), hunkRange(start+delta, newCount), body.String())
+
+		for _, e := range group {
+			delta += len(e.newLines) - len(e.oldLines)
+		}
+		i = j
+	}
+	return sb.String()
+}
+
+func editEnd(e lineEdit) int {
+	return e.oldStart + len(e.oldLines)
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// trimEdit 去掉修改前后相同的首尾行，使预览只包含真正变化的行
+func trimEdit(e lineEdit) lineEdit {
+	for len(e.oldLines) > 0 && len(e.newLines) > 0 && e.oldLines[0] == e.newLines[0] {
+		e.oldLines = e.oldLines[1:]
+		e.newLines = e.newLines[1:]
+		e.oldStart++
+	}
+	for len(e.oldLines) > 0 && len(e.newLines) > 0 && e.oldLines[len(e.oldLines)-1] == e.newLines[len(e.newLines)-1] {
+		e.oldLines = e.oldLines[:len(e.oldLines)-1]
+		e.newLines = e.newLines[:len(e.newLines)-1]
+	}
+	return e
+}
+
+// lineMatcher 行比较方式，按精确、忽略行尾空白、忽略首尾空白依次放宽
+type lineMatcher struct {
+	name  string
+	equal func(a, b string) bool
+}
+
+var lineMatchers = []lineMatcher{
+	{"", func(a, b string) bool { return a == b }},
+	{"忽略行尾空白", func(a, b string) bool { return strings.TrimRight(a, " \t") == strings.TrimRight(b, " \t") }},
+	{"忽略缩进和空白", func(a, b string) bool { return strings.TrimSpace(a) == strings.TrimSpace(b) }},
+}
+
+func matchAt(lines, block []string, pos int, equal func(a, b string) bool) bool {
+	if pos < 0 || pos+len(block) > len(lines) {
+		return false
+	}
+	for i, l := range block {
+		if !equal(lines[pos+i], l) {
+			return false
+		}
+	}
+	return true
+}
+
+// findBlock 返回 block 在 lines[from:] 中的全部匹配位置
+func findBlock(lines, block []string, from int, equal func(a, b string) bool) []int {
+	var positions []int
+	for pos := from; pos+len(block) <= len(lines); pos++ {
+		if matchAt(lines, block, pos, equal) {
+			positions = append(positions, pos)
+		}
+	}
+	return positions
+}
+
+// describeMismatch 找到与 block 最接近的位置，说明第一处不一致的行
+func describeMismatch(lines, block []string, from int) string {
+	if len(block) == 0 {
+		return ""
+	}
+	bestPos, bestCount := -1, 0
+	for pos := from; pos < len(lines); pos++ {
+		count := 0
+		for i := 0; i < len(block) && pos+i < len(lines); i++ {
+			if strings.TrimSpace(lines[pos+i]) == strings.TrimSpace(block[i]) {
+				count++
+			}
+		}
+		if count > bestCount {
+			bestPos, bestCount = pos, count
+		}
+	}
+	if bestPos < 0 {
+		return fmt.Sprintf("，文件中找不到任何与之相同的行，首行为 %q", block[0])
+	}
+	for i := range block {
+		if bestPos+i >= len(lines) {
+			return fmt.Sprintf("，最接近的位置在第 %d 行，但文件在第 %d 行之后结束", bestPos+1, len(lines))
+		}
+		if strings.TrimSpace(lines[bestPos+i]) != strings.TrimSpace(block[i]) {
+			return fmt.Sprintf("，最接近的位置在第 %d 行，第 %d 行期望 %q，实际为 %q", bestPos+1, bestPos+i+1, block[i], lines[bestPos+i])
+		}
+	}
+	return ""
+}
+
+// ==================== unified diff ====================
+
+type patchLine struct {
+	op    byte   // ' '、'-' 或 '+'
+	text  string // 行内容
+	blank bool   // 补丁中的空行，多数情况下是丢失了前导空格的空上下文行
+}
+
+type patchHunk struct {
+	header   string
+	oldStart int
+	oldCount int
+	lines    []patchLine
+	oldNoEOL bool // 修改前文件末尾没有换行
+	newNoEOL bool // 修改后文件末尾没有换行
+}
+
+type filePatch struct {
+	oldPath string
+	newPath string
+	hunks   []*patchHunk
+}
+
+// parseUnifiedDiff 解析 unified diff，允许省略文件头和 hunk 行数不准确
+func parseUnifiedDiff(text string) ([]*filePatch, error) {
+	rawLines := strings.Split(strings.TrimRight(strings.ReplaceAll(text, "\r\n", "\n"), "\n"), "\n")
+
+	var (
+		files   []*filePatch
+		current *filePatch
+		hunk    *patchHunk
+	)
+	closeHunk := func() {
+		if hunk == nil {
+			return
+		}
+		// 两个文件之间的空行不属于修改块
+		for len(hunk.lines) > 0 {
+			if !hunk.lines[len(hunk.lines)-1].blank {
+				break
+			}
+			hunk.lines = hunk.lines[:len(hunk.lines)-1]
+		}
+		hunk = nil
+	}
+
+	for i := 0; i < len(rawLines); i++ {
+		line := rawLines[i]
+		switch {
+		case strings.HasPrefix(line, "diff "):
+			closeHunk()
+			current = &filePatch{}
+			files = append(files, current)
+		case strings.HasPrefix(line, "--- ") && i+1 < len(rawLines) && strings.HasPrefix(rawLines[i+1], "+++ "):
+			closeHunk()
+			if current == nil || current.oldPath != "" || len(current.hunks) > 0 {
+				current = &filePatch{}
+				files = append(files, current)
+			}
+			current.oldPath = parseDiffPath(line[4:])
+			current.newPath = parseDiffPath(rawLines[i+1][4:])
+			i++
+		case strings.HasPrefix(line, "@@"):
+			closeHunk()
+			m := hunkHeaderRe.FindStringSubmatch(line)
+			if m == nil {
+				return nil, fmt.Errorf("无法解析修改块头 %q，格式应为 @@ -起始行,行数 +起始行,行数 @@", line)
+			}
+			if current == nil {
+				current = &filePatch{}
+				files = append(files, current)
+			}
+			hunk = &patchHunk{header: line, oldStart: atoiDefault(m[1], 1), oldCount: atoiDefault(m[2], 1)}
+			current.hunks = append(current.hunks, hunk)
+		case hunk != nil && line == "":
+			hunk.lines = append(hunk.lines, patchLine{op: ' ', blank: true})
+		case hunk != nil && (line[0] == ' ' || line[0] == '-' || line[0] == '+'):
+			hunk.lines = append(hunk.lines, patchLine{op: line[0], text: strings.TrimSuffix(line[1:], "\r")})
+		case hunk != nil && strings.HasPrefix(line, `\`):
+			if len(hunk.lines) > 0 {
+				switch hunk.lines[len(hunk.lines)-1].op {
+				case '-':
+					hunk.oldNoEOL = true
+				case '+':
+					hunk.newNoEOL = true
+				default:
+					hunk.oldNoEOL = true
+					hunk.newNoEOL = true
+				}
+			}
+		case hunk != nil:
+			return nil, fmt.Errorf("第 %d 行 %q 不是合法的补丁行，修改块中的每一行都必须以空格、- 或 + 开头", i+1, line)
+		}
+		// 其余为 git 的 index、mode 等扩展头或说明文字，直接忽略
+	}
+	closeHunk()
+
+	var result []*filePatch
+	for _, f := range files {
+		if len(f.hunks) > 0 {
+			result = append(result, f)
+		}
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("补丁中没有修改块（@@ ... @@），请使用 unified diff 或 SEARCH/REPLACE 块格式")
+	}
+	return result, nil
+}
+
+func parseDiffPath(s string) string {
+	if idx := strings.Index(s, "\t"); idx >= 0 {
+		s = s[:idx]
+	}
+	s = strings.TrimSpace(s)
+	if s == devNullPath {
+		return s
+	}
+	if strings.HasPrefix(s, "a/") || strings.HasPrefix(s, "b/") {
+		s = s[2:]
+	}
+	return s
+}
+
+func atoiDefault(s string, def int) int {
+	if s == "" {
+		return def
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+func (t *FilePatchTool) prepareUnifiedDiff(path, text string) ([]*patchResult, error) {
+	files, err := parseUnifiedDiff(text)
+	if err != nil {
+		return nil, err
+	}
+	if path != "" && len(files) > 1 {
+		return nil, fmt.Errorf("补丁包含 %d 个文件，不能同时指定 path", len(files))
+	}
+
+	results := make([]*patchResult, 0, len(files))
+	seen := make(map[string]bool)
+	for _, f := range files {
+		target := path
+		if target == "" {
+			target = f.newPath
+			if target == devNullPath || target == "" {
+				target = f.oldPath
+			}
+		}
+		if target == "" || target == devNullPath {
+			return nil, fmt.Errorf("补丁缺少文件头（--- a/文件 +++ b/文件），请通过 path 指定目标文件")
+		}
+
+		r, lines, err := t.loadPatchTarget(target)
+		if err != nil {
+			return nil, err
+		}
+		if seen[r.absPath] {
+			return nil, fmt.Errorf("补丁中文件 %s 出现了多次，请合并为一组修改块", target)
+		}
+		seen[r.absPath] = true
+
+		switch {
+		case f.oldPath == devNullPath && !r.created:
+			return nil, fmt.Errorf("补丁要新建文件 %s，但文件已存在", target)
+		case f.oldPath != devNullPath && r.created:
+			return nil, fmt.Errorf("文件 %s 不存在", target)
+		}
+		r.created = f.oldPath == devNullPath
+		r.deleted = f.newPath == devNullPath
+
+		if err := applyHunks(r, lines, f.hunks); err != nil {
+			return nil, err
+		}
+		if r.deleted && len(r.lines) > 0 {
+			return nil, fmt.Errorf("补丁要删除文件 %s，但修改块没有删除全部内容", target)
+		}
+
+		oldName, newName := "a/"+filepath.ToSlash(target), "b/"+filepath.ToSlash(target)
+		if r.created {
+			oldName = devNullPath
+		}
+		if r.deleted {
+			newName = devNullPath
+		}
+		r.diff = renderDiff(oldName, newName, lines, r.edits)
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// applyHunks 依次定位并应用修改块，任何一个失败都不修改文件
+func applyHunks(r *patchResult, lines []string, hunks []*patchHunk) error {
+	cursor, offset := 0, 0
+	for i, h := range hunks {
+		var oldBlock []string
+		for _, l := range h.lines {
+			if l.op != '+' {
+				oldBlock = append(oldBlock, l.text)
+			}
+		}
+
+		// 行数为 0 时起始行表示在该行之后插入
+		expected := h.oldStart - 1
+		if h.oldCount == 0 {
+			expected = h.oldStart
+		}
+		expected += offset
+
+		pos, lead, trail, note := locateHunk(lines, h, oldBlock, cursor, expected)
+		if pos < 0 {
+			return fmt.Errorf("文件 %s 的第 %d 个修改块（%s）应用失败: 上下文和删除的行与文件内容不一致%s，请先用 file_read 确认当前内容后重新生成补丁",
+				r.path, i+1, h.header, describeMismatch(lines, oldBlock, cursor))
+		}
+		if note != "" {
+			r.notes = append(r.notes, fmt.Sprintf("第 %d 个修改块%s", i+1, note))
+		}
+
+		// 上下文行沿用文件中的原文，避免模糊匹配时改动空白
+		body := h.lines[lead : len(h.lines)-trail]
+		var newBlock []string
+		n := 0
+		for _, l := range body {
+			switch l.op {
+			case ' ':
+				newBlock = append(newBlock, lines[pos+n])
+				n++
+			case '-':
+				n++
+			case '+':
+				newBlock = append(newBlock, l.text)
+			}
+		}
+
+		r.edits = append(r.edits, lineEdit{oldStart: pos, oldLines: lines[pos : pos+n], newLines: newBlock})
+		offset = pos - lead - (expected - offset)
+		cursor = pos + n
+
+		if h.newNoEOL {
+			r.eol = false
+		} else if h.oldNoEOL {
+			r.eol = true
+		}
+	}
+
+	r.lines = applyEdits(lines, r.edits)
+	return nil
+}
+
+// locateHunk 在 from 之后查找修改块的位置，优先精确匹配和离预期行号最近的位置，
+// 失败时依次放宽空白比较和忽略首尾上下文。返回位置、忽略的首尾上下文行数和提示
+func locateHunk(lines []string, h *patchHunk, oldBlock []string, from, expected int) (int, int, int, string) {
+	leadCtx, trailCtx := 0, 0
+	for _, l := range h.lines {
+		if l.op != ' ' {
+			break
+		}
+		leadCtx++
+	}
+	for i := len(h.lines) - 1; i >= 0 && h.lines[i].op == ' '; i-- {
+		trailCtx++
+	}
+
+	prevIgnored := -1
+	for fuzz := 0; fuzz <= patchMaxFuzz; fuzz++ {
+		lead, trail := min(fuzz, leadCtx), min(fuzz, trailCtx)
+		if lead+trail == prevIgnored {
+			break
+		}
+		prevIgnored = lead + trail
+		if lead+trail >= len(oldBlock) && len(oldBlock) > 0 {
+			break
+		}
+		block := oldBlock[lead : len(oldBlock)-trail]
+
+		for _, m := range lineMatchers {
+			if len(block) == 0 {
+				if expected+lead >= from && expected+lead <= len(lines) {
+					return expected + lead, lead, trail, ""
+				}
+				break
+			}
+			positions := findBlock(lines, block, from, m.equal)
+			if len(positions) == 0 {
+				continue
+			}
+			pos := nearest(positions, expected+lead)
+
+			var notes []string
+			if diff := pos - lead - expected; diff != 0 {
+				notes = append(notes, fmt.Sprintf("偏移 %+d 行", diff))
+			}
+			if m.name != "" {
+				notes = append(notes, m.name)
+			}
+			if fuzz > 0 {
+				notes = append(notes, fmt.Sprintf("忽略了 %d 行首尾上下文", lead+trail))
+			}
+			note := ""
+			if len(notes) > 0 && (m.name != "" || fuzz > 0) {
+				note = "使用模糊匹配（" + strings.Join(notes, "，") + "），请检查结果"
+			} else if len(notes) > 0 {
+				note = strings.Join(notes, "，")
+			}
+			return pos, lead, trail, note
+		}
+	}
+	return -1, 0, 0, ""
+}
+
+func nearest(positions []int, expected int) int {
+	best := positions[0]
+	for _, p := range positions[1:] {
+		if abs(p-expected) < abs(best-expected) {
+			best = p
+		}
+	}
+	return best
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// ==================== SEARCH/REPLACE ====================
+
+type searchReplaceBlock struct {
+	search  []string
+	replace []string
+}
+
+func isSearchReplacePatch(text string) bool {
+	for _, line := range strings.Split(text, "\n") {
+		if searchMarkerRe.MatchString(strings.TrimSuffix(line, "\r")) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseSearchReplace(text string) ([]searchReplaceBlock, error) {
+	const (
+		stateOutside = iota
+		stateSearch
+		stateReplace
+	)
+
+	var (
+		blocks  []searchReplaceBlock
+		current searchReplaceBlock
+		state   = stateOutside
+	)
+	for i, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		switch {
+		case searchMarkerRe.MatchString(line):
+			if state != stateOutside {
+				return nil, fmt.Errorf("第 %d 行: 上一个 SEARCH 块没有以 >>>>>>> REPLACE 结束", i+1)
+			}
+			current = searchReplaceBlock{}
+			state = stateSearch
+		case dividerMarkerRe.MatchString(line) && state == stateSearch:
+			state = stateReplace
+		case replaceMarkerRe.MatchString(line):
+			if state != stateReplace {
+				return nil, fmt.Errorf("第 %d 行: >>>>>>> REPLACE 之前缺少 =======", i+1)
+			}
+			blocks = append(blocks, current)
+			state = stateOutside
+		case state == stateSearch:
+			current.search = append(current.search, line)
+		case state == stateReplace:
+			current.replace = append(current.replace, line)
+		}
+	}
+	if state != stateOutside {
+		return nil, fmt.Errorf("SEARCH/REPLACE 块不完整，每个块需要依次包含 <<<<<<< SEARCH、=======、>>>>>>> REPLACE")
+	}
+	return blocks, nil
+}
+
+func (t *FilePatchTool) prepareSearchReplace(path, text string) ([]*patchResult, error) {
+	if path == "" {
+		return nil, fmt.Errorf("使用 SEARCH/REPLACE 块时必须指定 path")
+	}
+	blocks, err := parseSearchReplace(text)
+	if err != nil {
+		return nil, err
+	}
+
+	r, lines, err := t.loadPatchTarget(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.created {
+		if len(blocks) != 1 || len(blocks[0].search) != 0 {
+			return nil, fmt.Errorf("文件 %s 不存在，新建文件时只能使用一个 SEARCH 为空的块", path)
+		}
+		r.edits = []lineEdit{{oldStart: 0, newLines: blocks[0].replace}}
+	} else {
+		for i, b := range blocks {
+			edit, note, err := locateSearchBlock(lines, b)
+			if err != nil {
+				return nil, fmt.Errorf("文件 %s 的第 %d 个 SEARCH 块%s", path, i+1, err.Error())
+			}
+			if note != "" {
+				r.notes = append(r.notes, fmt.Sprintf("第 %d 个 SEARCH 块%s", i+1, note))
+			}
+			r.edits = append(r.edits, edit)
+		}
+		if err := sortEdits(r.edits); err != nil {
+			return nil, fmt.Errorf("文件 %s 的%s", path, err.Error())
+		}
+	}
+
+	r.lines = applyEdits(lines, r.edits)
+	oldName := "a/" + filepath.ToSlash(path)
+	if r.created {
+		oldName = devNullPath
+	}
+	r.diff = renderDiff(oldName, "b/"+filepath.ToSlash(path), lines, r.edits)
+	return []*patchResult{r}, nil
+}
+
+// locateSearchBlock 在原始内容中查找 SEARCH 块，要求唯一匹配
+func locateSearchBlock(lines []string, b searchReplaceBlock) (lineEdit, string, error) {
+	if len(b.search) == 0 {
+		return lineEdit{}, "", fmt.Errorf("的 SEARCH 内容为空，只有新建文件时才能为空")
+	}
+
+	for _, m := range lineMatchers {
+		positions := findBlock(lines, b.search, 0, m.equal)
+		if len(positions) == 0 {
+			continue
+		}
+		if len(positions) > 1 {
+			at := make([]string, len(positions))
+			for i, p := range positions {
+				at[i] = strconv.Itoa(p + 1)
+			}
+			return lineEdit{}, "", fmt.Errorf("匹配到 %d 处（第 %s 行），请加入更多上下文使其唯一", len(positions), strings.Join(at, "、"))
+		}
+
+		pos := positions[0]
+		replace := b.replace
+		note := ""
+		if m.name != "" {
+			replace = reindent(b.search, lines[pos:pos+len(b.search)], b.replace)
+			note = "使用模糊匹配（" + m.name + "），请检查结果"
+		}
+		return lineEdit{oldStart: pos, oldLines: lines[pos : pos+len(b.search)], newLines: replace}, note, nil
+	}
+	return lineEdit{}, "", fmt.Errorf("未找到匹配的内容%s，请先用 file_read 确认当前内容", describeMismatch(lines, b.search, 0))
+}
+
+// reindent 模糊匹配时把 REPLACE 的缩进调整为文件中实际使用的缩进
+func reindent(search, actual, replace []string) []string {
+	for i, l := range search {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		want := leadingSpaceRe.FindString(l)
+		got := leadingSpaceRe.FindString(actual[i])
+		if want == got {
+			return replace
+		}
+		out := make([]string, len(replace))
+		for j, r := range replace {
+			if strings.HasPrefix(r, want) {
+				out[j] = got + r[len(want):]
+			} else {
+				out[j] = r
+			}
+		}
+		return out
+	}
+	return replace
+}
+
+// sortEdits 按位置排序并检查修改之间是否重叠
+func sortEdits(edits []lineEdit) error {
+	for i := 1; i < len(edits); i++ {
+		for j := i; j > 0 && edits[j].oldStart < edits[j-1].oldStart; j-- {
+			edits[j], edits[j-1] = edits[j-1], edits[j]
+		}
+	}
+	for i := 1; i < len(edits); i++ {
+		if edits[i].oldStart < editEnd(edits[i-1]) {
+			return fmt.Errorf("SEARCH 块之间有重叠（第 %d 行附近），请合并为一个块", edits[i].oldStart+1)
+		}
+	}
+	return nil
+}
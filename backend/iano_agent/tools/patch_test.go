@@ -0,0 +1,238 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const patchSample = "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(\"hello\")\n}\n\nfunc helper() int {\n\treturn 1\n}\n"
+
+func runFilePatch(t *testing.T, dir string, args map[string]interface{}) (string, error) {
+	t.Helper()
+	data, err := json.Marshal(args)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	return NewFilePatchTool(dir).InvokableRun(context.Background(), string(data))
+}
+
+func writePatchFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return path
+}
+
+func readPatchFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	return string(data)
+}
+
+func TestFilePatchTool_UnifiedDiff(t *testing.T) {
+	want := strings.Replace(strings.Replace(patchSample, `"hello"`, `"hello, world"`, 1), "return 1", "return 2", 1)
+
+	tests := []struct {
+		name     string
+		patch    string
+		wantNote string
+	}{
+		{
+			name: "精确匹配",
+			patch: "--- a/main.go\n+++ b/main.go\n@@ -5,3 +5,3 @@\n func main() {\n-\tfmt.Println(\"hello\")\n+\tfmt.Println(\"hello, world\")\n }\n" +
+				"@@ -9,3 +9,3 @@\n func helper() int {\n-\treturn 1\n+\treturn 2\n }\n",
+		},
+		{
+			name:     "行号偏移",
+			patch:    "--- a/main.go\n+++ b/main.go\n@@ -1,3 +1,3 @@\n func main() {\n-\tfmt.Println(\"hello\")\n+\tfmt.Println(\"hello, world\")\n }\n@@ -20,3 +20,3 @@\n func helper() int {\n-\treturn 1\n+\treturn 2\n }\n",
+			wantNote: "偏移 +4 行",
+		},
+		{
+			name:     "缩进不一致",
+			patch:    "--- a/main.go\n+++ b/main.go\n@@ -5,3 +5,3 @@\n func main() {\n-    fmt.Println(\"hello\")\n+\tfmt.Println(\"hello, world\")\n }\n@@ -9,3 +9,3 @@\n func helper() int {\n-\treturn 1\n+\treturn 2\n }\n",
+			wantNote: "忽略缩进和空白",
+		},
+		{
+			name:     "上下文有误",
+			patch:    "--- a/main.go\n+++ b/main.go\n@@ -4,4 +4,4 @@\n // 入口\n func main() {\n-\tfmt.Println(\"hello\")\n+\tfmt.Println(\"hello, world\")\n }\n@@ -9,3 +9,3 @@\n func helper() int {\n-\treturn 1\n+\treturn 2\n }\n",
+			wantNote: "忽略了 2 行首尾上下文",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := writePatchFile(t, dir, "main.go", patchSample)
+
+			result, err := runFilePatch(t, dir, map[string]interface{}{"patch": tt.patch})
+			if err != nil {
+				t.Fatalf("InvokableRun() error = %v", err)
+			}
+			if got := readPatchFile(t, path); got != want {
+				t.Errorf("file content = %q, want %q", got, want)
+			}
+			if tt.wantNote != "" && !strings.Contains(result, tt.wantNote) {
+				t.Errorf("result = %q, want note %q", result, tt.wantNote)
+			}
+			if !strings.Contains(result, "+\tfmt.Println(\"hello, world\")") {
+				t.Errorf("result = %q, want resulting diff", result)
+			}
+		})
+	}
+}
+
+func TestFilePatchTool_HunkFailure(t *testing.T) {
+	dir := t.TempDir()
+	path := writePatchFile(t, dir, "main.go", patchSample)
+
+	// 第一个修改块可以应用，第二个失败时整个补丁都不写入
+	patch := "--- a/main.go\n+++ b/main.go\n@@ -5,3 +5,3 @@\n func main() {\n-\tfmt.Println(\"hello\")\n+\tfmt.Println(\"hi\")\n }\n" +
+		"@@ -9,3 +9,3 @@\n func helper() int {\n-\treturn 42\n+\treturn 2\n }\n"
+	_, err := runFilePatch(t, dir, map[string]interface{}{"patch": patch})
+	if err == nil {
+		t.Fatal("InvokableRun() error = nil, want hunk failure")
+	}
+	for _, want := range []string{"第 2 个修改块", "@@ -9,3 +9,3 @@", `第 10 行期望 "\treturn 42"，实际为 "\treturn 1"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error = %q, want to contain %q", err.Error(), want)
+		}
+	}
+	if got := readPatchFile(t, path); got != patchSample {
+		t.Errorf("file content changed after failed patch: %q", got)
+	}
+}
+
+func TestFilePatchTool_DryRun(t *testing.T) {
+	dir := t.TempDir()
+	path := writePatchFile(t, dir, "main.go", patchSample)
+
+	patch := "<<<<<<< SEARCH\nfunc helper() int {\n\treturn 1\n}\n=======\nfunc helper() int {\n\treturn 2\n}\n>>>>>>> REPLACE\n"
+	result, err := runFilePatch(t, dir, map[string]interface{}{"patch": patch, "path": "main.go", "dry_run": true})
+	if err != nil {
+		t.Fatalf("InvokableRun() error = %v", err)
+	}
+
+	wantDiff := "--- a/main.go\n+++ b/main.go\n@@ -7,5 +7,5 @@\n }\n \n func helper() int {\n-\treturn 1\n+\treturn 2\n }\n"
+	if !strings.Contains(result, "未写入") || !strings.Contains(result, wantDiff) {
+		t.Errorf("result = %q, want preview diff %q", result, wantDiff)
+	}
+	if got := readPatchFile(t, path); got != patchSample {
+		t.Errorf("dry run modified file: %q", got)
+	}
+}
+
+func TestFilePatchTool_SearchReplace(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		patch   string
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "多个块",
+			content: patchSample,
+			patch: "<<<<<<< SEARCH\n\treturn 1\n=======\n\treturn 2\n>>>>>>> REPLACE\n" +
+				"<<<<<<< SEARCH\nimport \"fmt\"\n=======\nimport (\n\t\"fmt\"\n)\n>>>>>>> REPLACE\n",
+			want: strings.Replace(strings.Replace(patchSample, "return 1", "return 2", 1), "import \"fmt\"", "import (\n\t\"fmt\"\n)", 1),
+		},
+		{
+			name:    "缩进不同时沿用文件缩进",
+			content: "if ok {\n\t\tcall()\n}\n",
+			patch:   "<<<<<<< SEARCH\n    call()\n=======\n    call()\n    done()\n>>>>>>> REPLACE\n",
+			want:    "if ok {\n\t\tcall()\n\t\tdone()\n}\n",
+		},
+		{
+			name:    "保留 CRLF 换行",
+			content: "a\r\nb\r\nc\r\n",
+			patch:   "<<<<<<< SEARCH\nb\n=======\nB\n>>>>>>> REPLACE\n",
+			want:    "a\r\nB\r\nc\r\n",
+		},
+		{
+			name:    "匹配不唯一",
+			content: "x := 1\ny := 2\nx := 1\n",
+			patch:   "<<<<<<< SEARCH\nx := 1\n=======\nx := 3\n>>>>>>> REPLACE\n",
+			wantErr: "匹配到 2 处（第 1、3 行）",
+		},
+		{
+			name:    "未找到",
+			content: patchSample,
+			patch:   "<<<<<<< SEARCH\nfunc missing() {}\n=======\n>>>>>>> REPLACE\n",
+			wantErr: "未找到匹配的内容",
+		},
+		{
+			name:    "块不完整",
+			content: patchSample,
+			patch:   "<<<<<<< SEARCH\n\treturn 1\n>>>>>>> REPLACE\n",
+			wantErr: "缺少 =======",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := writePatchFile(t, dir, "file.txt", tt.content)
+
+			_, err := runFilePatch(t, dir, map[string]interface{}{"patch": tt.patch, "path": "file.txt"})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("InvokableRun() error = %v, want %q", err, tt.wantErr)
+				}
+				if got := readPatchFile(t, path); got != tt.content {
+					t.Errorf("file content changed after failed patch: %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("InvokableRun() error = %v", err)
+			}
+			if got := readPatchFile(t, path); got != tt.want {
+				t.Errorf("file content = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilePatchTool_CreateAndDelete(t *testing.T) {
+	dir := t.TempDir()
+	writePatchFile(t, dir, "old.txt", "bye\n")
+
+	patch := "diff --git a/docs/new.md b/docs/new.md\nnew file mode 100644\n--- /dev/null\n+++ b/docs/new.md\n@@ -0,0 +1,2 @@\n+# 标题\n+内容\n" +
+		"diff --git a/old.txt b/old.txt\ndeleted file mode 100644\n--- a/old.txt\n+++ /dev/null\n@@ -1 +0,0 @@\n-bye\n"
+	result, err := runFilePatch(t, dir, map[string]interface{}{"patch": patch})
+	if err != nil {
+		t.Fatalf("InvokableRun() error = %v", err)
+	}
+	if !strings.Contains(result, "新建 docs/new.md") || !strings.Contains(result, "删除 old.txt") {
+		t.Errorf("result = %q, want create and delete summary", result)
+	}
+	if got := readPatchFile(t, filepath.Join(dir, "docs", "new.md")); got != "# 标题\n内容\n" {
+		t.Errorf("new file content = %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "old.txt")); !os.IsNotExist(err) {
+		t.Errorf("old.txt still exists, stat error = %v", err)
+	}
+}
+
+func TestFilePatchTool_OutsideBasePath(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []map[string]interface{}{
+		{"patch": "--- a/../escape.txt\n+++ b/../escape.txt\n@@ -0,0 +1 @@\n+x\n"},
+		{"patch": "<<<<<<< SEARCH\n=======\nx\n>>>>>>> REPLACE\n", "path": "../escape.txt"},
+	}
+	for _, args := range tests {
+		if _, err := runFilePatch(t, dir, args); err == nil || !strings.Contains(err.Error(), "路径超出允许范围") {
+			t.Errorf("InvokableRun(%v) error = %v, want path error", args, err)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+//go:build !windows
+
+package tools
+
+import (
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// killProcessTree 让命令在独立的进程组中运行，取消时向整个进程组发送 SIGKILL
+func killProcessTree(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	// 孙进程可能仍持有输出管道，等待一段时间后强制返回
+	cmd.WaitDelay = time.Second
+}
+
+// setProcessGroup 让后台进程在独立的进程组中运行，结束时连同其子进程一起结束
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// signalProcessGroup 结束进程组，force 为 false 时发送 SIGTERM 等待进程自行退出，否则发送 SIGKILL
+func signalProcessGroup(pid int, force bool) error {
+	sig := syscall.SIGTERM
+	if force {
+		sig = syscall.SIGKILL
+	}
+	if err := syscall.Kill(-pid, sig); err != nil && err != syscall.ESRCH {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+//go:build windows
+
+package tools
+
+import (
+	"os/exec"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// killProcessTree 取消时通过 taskkill 结束命令及其所有子进程
+func killProcessTree(cmd *exec.Cmd) {
+	cmd.Cancel = func() error {
+		return exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid)).Run()
+	}
+	// 孙进程可能仍持有输出管道，等待一段时间后强制返回
+	cmd.WaitDelay = time.Second
+}
+
+// setProcessGroup 让后台进程在新的进程组中运行
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+// signalProcessGroup 通过 taskkill 结束进程及其所有子进程，force 为 true 时强制结束
+func signalProcessGroup(pid int, force bool) error {
+	args := []string{"/T", "/PID", strconv.Itoa(pid)}
+	if force {
+		args = append([]string{"/F"}, args...)
+	}
+	return exec.Command("taskkill", args...).Run()
+}
@@ -0,0 +1,67 @@
+//go:build linux
+
+package tools
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// openPTY 打开一对伪终端，master 由服务端读写，slave 作为子进程的控制终端
+func openPTY() (master, slave *os.File, err error) {
+	master, err = os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("打开伪终端失败: %w", err)
+	}
+
+	var index uint32
+	err = controlFile(master, func(fd int) error {
+		if err := unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0); err != nil {
+			return err
+		}
+		index, err = unix.IoctlGetUint32(fd, unix.TIOCGPTN)
+		return err
+	})
+	if err != nil {
+		master.Close()
+		return nil, nil, fmt.Errorf("解锁伪终端失败: %w", err)
+	}
+
+	slave, err = os.OpenFile(fmt.Sprintf("/dev/pts/%d", index), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		master.Close()
+		return nil, nil, fmt.Errorf("打开伪终端从设备失败: %w", err)
+	}
+	return master, slave, nil
+}
+
+// setPTYSize 设置终端的行数和列数，终端中的程序会收到 SIGWINCH
+func setPTYSize(f *os.File, rows, cols int) error {
+	return controlFile(f, func(fd int) error {
+		return unix.IoctlSetWinsize(fd, unix.TIOCSWINSZ, &unix.Winsize{Row: uint16(rows), Col: uint16(cols)})
+	})
+}
+
+// setControllingTerminal 让子进程在新的会话中运行，并以标准输入（伪终端从设备）作为控制终端
+//
+// 新会话的首进程同时是进程组组长，可以用 signalProcessGroup 结束整个终端。
+func setControllingTerminal(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true}
+}
+
+// controlFile 在不改变文件阻塞模式的情况下对文件描述符执行操作
+func controlFile(f *os.File, fn func(fd int) error) error {
+	conn, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var opErr error
+	if err := conn.Control(func(fd uintptr) { opErr = fn(int(fd)) }); err != nil {
+		return err
+	}
+	return opErr
+}
@@ -0,0 +1,21 @@
+//go:build !linux
+
+package tools
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+var errPTYUnsupported = errors.New("当前系统不支持伪终端，终端工具仅在 Linux 上可用")
+
+func openPTY() (master, slave *os.File, err error) {
+	return nil, nil, errPTYUnsupported
+}
+
+func setPTYSize(f *os.File, rows, cols int) error {
+	return errPTYUnsupported
+}
+
+func setControllingTerminal(cmd *exec.Cmd) {}
@@ -153,6 +153,7 @@ func GetBuiltinTools(ctx context.Context) (map[string]tool.BaseTool, error) {
 	basePath, _ := os.Getwd()
 	toolsMap["file_read"] = NewFileReadTool(basePath)
 	toolsMap["file_write"] = NewFileWriteTool(basePath)
+	toolsMap["file_patch"] = NewFilePatchTool(basePath)
 	toolsMap["file_create"] = NewFileCreateTool(basePath)
 	toolsMap["file_list"] = NewFileListTool(basePath)
 	toolsMap["file_delete"] = NewFileDeleteTool(basePath)
@@ -164,6 +165,13 @@ func GetBuiltinTools(ctx context.Context) (map[string]tool.BaseTool, error) {
 	toolsMap["archive_create"] = NewArchiveCreateTool(basePath)
 	toolsMap["archive_extract"] = NewArchiveExtractTool(basePath)
 
+	toolsMap[GitStatusToolName] = NewGitStatusTool(basePath)
+	toolsMap[GitDiffToolName] = NewGitDiffTool(basePath)
+	toolsMap[GitLogToolName] = NewGitLogTool(basePath)
+	toolsMap[GitShowToolName] = NewGitShowTool(basePath)
+	toolsMap[GitBranchToolName] = NewGitBranchTool(basePath, false)
+	toolsMap[GitCommitToolName] = NewGitCommitTool(basePath, false)
+
 	cmdTool := NewCommandExecuteTool()
 	toolsMap["command_execute"] = cmdTool
 	toolsMap["shell_execute"] = NewShellExecuteTool()
@@ -206,6 +214,9 @@ func RegisterBuiltinTools(ctx context.Context, workDir string, timeout int) erro
 	if err := GlobalRegistry.Register("file_write", NewFileWriteTool(basePath)); err != nil {
 		return fmt.Errorf("注册文件写入工具失败: %w", err)
 	}
+	if err := GlobalRegistry.Register("file_patch", NewFilePatchTool(basePath)); err != nil {
+		return fmt.Errorf("注册文件补丁工具失败: %w", err)
+	}
 	if err := GlobalRegistry.Register("file_create", NewFileCreateTool(basePath)); err != nil {
 		return fmt.Errorf("注册文件创建工具失败: %w", err)
 	}
@@ -233,6 +244,21 @@ func RegisterBuiltinTools(ctx context.Context, workDir string, timeout int) erro
 		return fmt.Errorf("注册解压工具失败: %w", err)
 	}
 
+	// Git 写操作默认关闭，由 Agent 配置开启
+	gitTools := map[string]tool.InvokableTool{
+		GitStatusToolName: NewGitStatusTool(basePath),
+		GitDiffToolName:   NewGitDiffTool(basePath),
+		GitLogToolName:    NewGitLogTool(basePath),
+		GitShowToolName:   NewGitShowTool(basePath),
+		GitBranchToolName: NewGitBranchTool(basePath, false),
+		GitCommitToolName: NewGitCommitTool(basePath, false),
+	}
+	for name, t := range gitTools {
+		if err := GlobalRegistry.Register(name, t); err != nil {
+			return fmt.Errorf("注册 Git 工具失败: %w", err)
+		}
+	}
+
 	cmdTool := NewCommandExecuteTool()
 	if err := GlobalRegistry.Register("command_execute", cmdTool); err != nil {
 		return fmt.Errorf("注册命令执行工具失败: %w", err)
@@ -449,6 +475,8 @@ func CreateToolsWithBasePath(basePath string, toolNames []string) []tool.Invokab
 			t = NewFileReadTool(basePath)
 		case "file_write":
 			t = NewFileWriteTool(basePath)
+		case "file_patch":
+			t = NewFilePatchTool(basePath)
 		case "file_create":
 			t = NewFileCreateTool(basePath)
 		case "file_list":
@@ -465,6 +493,18 @@ func CreateToolsWithBasePath(basePath string, toolNames []string) []tool.Invokab
 			t = NewArchiveCreateTool(basePath)
 		case "archive_extract":
 			t = NewArchiveExtractTool(basePath)
+		case GitStatusToolName:
+			t = NewGitStatusTool(basePath)
+		case GitDiffToolName:
+			t = NewGitDiffTool(basePath)
+		case GitLogToolName:
+			t = NewGitLogTool(basePath)
+		case GitShowToolName:
+			t = NewGitShowTool(basePath)
+		case GitBranchToolName:
+			t = NewGitBranchTool(basePath, false)
+		case GitCommitToolName:
+			t = NewGitCommitTool(basePath, false)
 		case "command_execute":
 			t = NewCommandExecuteTool().WithWorkingDir(basePath)
 		case "shell_execute":
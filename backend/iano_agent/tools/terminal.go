@@ -0,0 +1,913 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+const (
+	TerminalOpenToolName   = "terminal_open"
+	TerminalWriteToolName  = "terminal_write"
+	TerminalReadToolName   = "terminal_read"
+	TerminalResizeToolName = "terminal_resize"
+	TerminalCloseToolName  = "terminal_close"
+
+	// DefaultMaxTerminalsPerSession 每个会话默认最多同时打开的终端数
+	DefaultMaxTerminalsPerSession = 3
+
+	// DefaultTerminalRows 终端默认行数
+	DefaultTerminalRows = 24
+	// DefaultTerminalCols 终端默认列数
+	DefaultTerminalCols = 120
+
+	// terminalOutputBufferSize 每个终端保留的最近输出字节数
+	terminalOutputBufferSize = 256 * 1024
+	// defaultTerminalWriteWait terminal_write 写入后默认等待输出的时间
+	defaultTerminalWriteWait = time.Second
+	// terminalQuietPeriod 输出停止这么久后认为本次输出已结束
+	terminalQuietPeriod = 200 * time.Millisecond
+	// maxTerminalSize 终端行数和列数的上限
+	maxTerminalSize = 1000
+)
+
+// ErrTerminalNotFound 会话中没有指定名称的终端
+var ErrTerminalNotFound = errors.New("终端不存在")
+
+// TerminalInfo 终端的状态，Status 取值与后台进程相同
+type TerminalInfo struct {
+	Name       string     `json:"name"`
+	Command    string     `json:"command"`
+	Dir        string     `json:"dir"`
+	PID        int        `json:"pid"`
+	Rows       int        `json:"rows"`
+	Cols       int        `json:"cols"`
+	Status     string     `json:"status"`
+	ExitCode   *int       `json:"exit_code,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	ExitedAt   *time.Time `json:"exited_at,omitempty"`
+	OutputSize int64      `json:"output_size"` // 累计输出字节数，即当前输出末尾的偏移
+}
+
+// TerminalOutput 终端输出，偏移按终端打开以来的累计输出字节计算
+type TerminalOutput struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	ExitCode   *int   `json:"exit_code,omitempty"`
+	Offset     int64  `json:"offset"`            // 返回内容的起始偏移
+	NextOffset int64  `json:"next_offset"`       // 下次读取时传入的偏移
+	Output     string `json:"output"`            // 终端输出，工具返回时已去除 ANSI 控制序列
+	More       bool   `json:"more"`              // next_offset 之后还有已产生的输出
+	Dropped    int64  `json:"dropped,omitempty"` // 请求的偏移之后已被丢弃的字节数，只保留最近的输出
+}
+
+// TerminalOpenOptions 打开终端的参数
+type TerminalOpenOptions struct {
+	Name    string
+	Command string // 在终端中运行的命令，为空时运行交互式 bash
+	Dir     string // 工作目录（绝对路径）
+	Rows    int
+	Cols    int
+}
+
+// terminal 运行在伪终端中的进程
+type terminal struct {
+	mu       sync.Mutex
+	info     TerminalInfo
+	master   *os.File
+	output   outputBuffer
+	exited   chan struct{} // 进程退出且输出读取完毕时关闭
+	stopping bool
+}
+
+// TerminalManager 按会话管理伪终端
+//
+// 终端供 Agent 通过工具操作，也可以由用户通过 WebSocket 查看和接管，两者读写的是同一个终端。
+// 每个会话最多同时打开 maxPerSession 个终端，已退出的终端保留状态和输出，规则与后台进程相同。
+type TerminalManager struct {
+	mu            sync.Mutex
+	maxPerSession int
+	sessions      map[string]map[string]*terminal
+	closed        bool
+}
+
+// NewTerminalManager 创建终端管理器，maxPerSession 不大于 0 时使用默认值
+func NewTerminalManager(maxPerSession int) *TerminalManager {
+	if maxPerSession <= 0 {
+		maxPerSession = DefaultMaxTerminalsPerSession
+	}
+	return &TerminalManager{
+		maxPerSession: maxPerSession,
+		sessions:      make(map[string]map[string]*terminal),
+	}
+}
+
+// Open 在会话中打开终端，同名终端仍在运行时返回错误
+func (m *TerminalManager) Open(session string, opts TerminalOpenOptions) (*TerminalInfo, error) {
+	if !processNamePattern.MatchString(opts.Name) {
+		return nil, fmt.Errorf("终端名称只能包含字母、数字、下划线、点和短横线，且不超过 64 个字符")
+	}
+	if opts.Rows <= 0 {
+		opts.Rows = DefaultTerminalRows
+	}
+	if opts.Cols <= 0 {
+		opts.Cols = DefaultTerminalCols
+	}
+	if opts.Rows > maxTerminalSize || opts.Cols > maxTerminalSize {
+		return nil, fmt.Errorf("终端行数和列数不能超过 %d", maxTerminalSize)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return nil, fmt.Errorf("终端管理器已关闭")
+	}
+
+	terminals := m.sessions[session]
+	if terminals == nil {
+		terminals = make(map[string]*terminal)
+		m.sessions[session] = terminals
+	}
+	if t, ok := terminals[opts.Name]; ok {
+		if t.running() {
+			return nil, fmt.Errorf("终端 %s 正在运行，请先关闭或换一个名称", opts.Name)
+		}
+		delete(terminals, opts.Name)
+	}
+	if len(terminals) >= m.maxPerSession && !evictExitedTerminal(terminals) {
+		return nil, fmt.Errorf("会话中已有 %d 个终端在运行，达到上限，请先关闭不需要的终端", len(terminals))
+	}
+
+	t, err := openTerminal(opts)
+	if err != nil {
+		return nil, err
+	}
+	terminals[opts.Name] = t
+	slog.Info("打开终端", "session", session, "name", opts.Name, "pid", t.info.PID, "command", opts.Command)
+	return t.snapshot(), nil
+}
+
+// evictExitedTerminal 移除最早退出的终端，没有已退出的终端时返回 false
+func evictExitedTerminal(terminals map[string]*terminal) bool {
+	var oldest string
+	var oldestAt time.Time
+	for name, t := range terminals {
+		info := t.snapshot()
+		if info.ExitedAt != nil && (oldest == "" || info.ExitedAt.Before(oldestAt)) {
+			oldest, oldestAt = name, *info.ExitedAt
+		}
+	}
+	if oldest == "" {
+		return false
+	}
+	delete(terminals, oldest)
+	return true
+}
+
+func (m *TerminalManager) get(session, name string) (*terminal, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.sessions[session][name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrTerminalNotFound, name)
+	}
+	return t, nil
+}
+
+// Status 返回终端的状态
+func (m *TerminalManager) Status(session, name string) (*TerminalInfo, error) {
+	t, err := m.get(session, name)
+	if err != nil {
+		return nil, err
+	}
+	return t.snapshot(), nil
+}
+
+// List 返回会话中的全部终端，按打开时间排序
+func (m *TerminalManager) List(session string) []*TerminalInfo {
+	m.mu.Lock()
+	list := make([]*TerminalInfo, 0, len(m.sessions[session]))
+	for _, t := range m.sessions[session] {
+		list = append(list, t.snapshot())
+	}
+	m.mu.Unlock()
+
+	sort.Slice(list, func(i, j int) bool { return list[i].StartedAt.Before(list[j].StartedAt) })
+	return list
+}
+
+// Read 从 offset 开始读取最多 maxBytes 字节的原始输出（含 ANSI 控制序列），offset 小于 0 时返回最近的输出
+//
+// 没有新输出且终端仍在运行时最多等待 wait，期间有新输出或终端退出立即返回。
+func (m *TerminalManager) Read(ctx context.Context, session, name string, offset int64, maxBytes int, wait time.Duration) (*TerminalOutput, error) {
+	t, err := m.get(session, name)
+	if err != nil {
+		return nil, err
+	}
+	if maxBytes <= 0 {
+		maxBytes = defaultProcessOutputRead
+	}
+	maxBytes = min(maxBytes, maxProcessOutputRead)
+	wait = min(wait, maxProcessOutputWait)
+
+	if offset >= 0 {
+		if err := waitForOutput(ctx, &t.mu, &t.output, t.exited, offset, wait); err != nil {
+			return nil, err
+		}
+	}
+	return t.read(offset, maxBytes), nil
+}
+
+// Settle 等待 offset 之后出现输出并且输出停止一段时间，最多等待 timeout
+//
+// 终端程序的一次响应通常分多次写出，读取前等待输出稳定可以拿到完整的提示或结果。
+func (m *TerminalManager) Settle(ctx context.Context, session, name string, offset int64, timeout time.Duration) error {
+	t, err := m.get(session, name)
+	if err != nil {
+		return err
+	}
+	timeout = min(timeout, maxProcessOutputWait)
+	deadline := time.Now().Add(timeout)
+	if err := waitForOutput(ctx, &t.mu, &t.output, t.exited, offset, timeout); err != nil {
+		return err
+	}
+	for t.running() {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil
+		}
+		t.mu.Lock()
+		end := t.output.end()
+		t.mu.Unlock()
+		if err := waitForOutput(ctx, &t.mu, &t.output, t.exited, end, min(terminalQuietPeriod, remaining)); err != nil {
+			return err
+		}
+		t.mu.Lock()
+		quiet := t.output.end() == end
+		t.mu.Unlock()
+		if quiet {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Write 向终端写入按键，返回写入前输出末尾的偏移，从该偏移读取即可得到写入后的输出
+func (m *TerminalManager) Write(session, name, data string) (int64, error) {
+	t, err := m.get(session, name)
+	if err != nil {
+		return 0, err
+	}
+	if !t.running() {
+		return 0, fmt.Errorf("终端 %s 已退出", name)
+	}
+	t.mu.Lock()
+	offset := t.output.end()
+	t.mu.Unlock()
+	if _, err := io.WriteString(t.master, data); err != nil {
+		return 0, fmt.Errorf("写入终端失败: %w", err)
+	}
+	return offset, nil
+}
+
+// Resize 调整终端的行数和列数
+func (m *TerminalManager) Resize(session, name string, rows, cols int) (*TerminalInfo, error) {
+	if rows <= 0 || cols <= 0 || rows > maxTerminalSize || cols > maxTerminalSize {
+		return nil, fmt.Errorf("终端行数和列数必须在 1 到 %d 之间", maxTerminalSize)
+	}
+	t, err := m.get(session, name)
+	if err != nil {
+		return nil, err
+	}
+	if !t.running() {
+		return nil, fmt.Errorf("终端 %s 已退出", name)
+	}
+	if err := setPTYSize(t.master, rows, cols); err != nil {
+		return nil, fmt.Errorf("调整终端大小失败: %w", err)
+	}
+	t.mu.Lock()
+	t.info.Rows, t.info.Cols = rows, cols
+	t.mu.Unlock()
+	return t.snapshot(), nil
+}
+
+// Close 结束终端中的进程及其子进程，终端的输出保留到会话结束
+func (m *TerminalManager) Close(session, name string) (*TerminalInfo, error) {
+	t, err := m.get(session, name)
+	if err != nil {
+		return nil, err
+	}
+	t.stop()
+	slog.Info("关闭终端", "session", session, "name", name)
+	return t.snapshot(), nil
+}
+
+// CloseSession 关闭会话中的全部终端并清除记录，会话结束时调用
+func (m *TerminalManager) CloseSession(session string) {
+	m.mu.Lock()
+	terminals := m.sessions[session]
+	delete(m.sessions, session)
+	m.mu.Unlock()
+
+	stopTerminals(terminals)
+	if len(terminals) > 0 {
+		slog.Info("会话结束，已关闭终端", "session", session, "count", len(terminals))
+	}
+}
+
+// Shutdown 关闭全部会话的终端，之后不能再打开新终端，服务关闭时调用
+func (m *TerminalManager) Shutdown() {
+	m.mu.Lock()
+	m.closed = true
+	sessions := m.sessions
+	m.sessions = make(map[string]map[string]*terminal)
+	m.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, terminals := range sessions {
+		wg.Add(1)
+		go func(terminals map[string]*terminal) {
+			defer wg.Done()
+			stopTerminals(terminals)
+		}(terminals)
+	}
+	wg.Wait()
+}
+
+// stopTerminals 并发关闭多个终端
+func stopTerminals(terminals map[string]*terminal) {
+	var wg sync.WaitGroup
+	for _, t := range terminals {
+		wg.Add(1)
+		go func(t *terminal) {
+			defer wg.Done()
+			t.stop()
+		}(t)
+	}
+	wg.Wait()
+}
+
+func openTerminal(opts TerminalOpenOptions) (*terminal, error) {
+	master, slave, err := openPTY()
+	if err != nil {
+		return nil, err
+	}
+	if err := setPTYSize(master, opts.Rows, opts.Cols); err != nil {
+		master.Close()
+		slave.Close()
+		return nil, fmt.Errorf("设置终端大小失败: %w", err)
+	}
+
+	var cmd *exec.Cmd
+	if opts.Command == "" {
+		cmd = exec.Command("bash", "-i")
+	} else {
+		cmd = exec.Command("bash", "-c", opts.Command)
+	}
+	cmd.Dir = opts.Dir
+	cmd.Env = append(os.Environ(), "TERM=xterm-256color")
+	cmd.Stdin = slave
+	cmd.Stdout = slave
+	cmd.Stderr = slave
+	setControllingTerminal(cmd)
+
+	if err := cmd.Start(); err != nil {
+		master.Close()
+		slave.Close()
+		return nil, fmt.Errorf("启动终端进程失败: %w", err)
+	}
+	slave.Close()
+
+	t := &terminal{
+		info: TerminalInfo{
+			Name:      opts.Name,
+			Command:   opts.Command,
+			Dir:       opts.Dir,
+			PID:       cmd.Process.Pid,
+			Rows:      opts.Rows,
+			Cols:      opts.Cols,
+			Status:    ProcessStatusRunning,
+			StartedAt: time.Now(),
+		},
+		master: master,
+		output: newOutputBuffer(terminalOutputBufferSize),
+		exited: make(chan struct{}),
+	}
+
+	// 终端中的全部进程都关闭从设备后读取返回错误，此时输出已读取完毕
+	readDone := make(chan struct{})
+	go func() {
+		defer close(readDone)
+		chunk := make([]byte, 32*1024)
+		for {
+			n, err := master.Read(chunk)
+			if n > 0 {
+				t.mu.Lock()
+				t.output.write(chunk[:n])
+				t.mu.Unlock()
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	go func() {
+		cmd.Wait()
+		// 后台运行的子进程可能仍持有从设备，等待一段时间后不再读取
+		select {
+		case <-readDone:
+		case <-time.After(processStopTimeout):
+		}
+		master.Close()
+
+		exitCode := cmd.ProcessState.ExitCode()
+		now := time.Now()
+		t.mu.Lock()
+		t.info.ExitCode = &exitCode
+		t.info.ExitedAt = &now
+		t.info.Status = ProcessStatusExited
+		if t.stopping {
+			t.info.Status = ProcessStatusStopped
+		}
+		t.mu.Unlock()
+		close(t.exited)
+	}()
+	return t, nil
+}
+
+func (t *terminal) running() bool {
+	select {
+	case <-t.exited:
+		return false
+	default:
+		return true
+	}
+}
+
+func (t *terminal) snapshot() *TerminalInfo {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	info := t.info
+	info.OutputSize = t.output.end()
+	return &info
+}
+
+func (t *terminal) read(offset int64, maxBytes int) *TerminalOutput {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	data := t.output.read(offset, maxBytes)
+	return &TerminalOutput{
+		Name:       t.info.Name,
+		Status:     t.info.Status,
+		ExitCode:   t.info.ExitCode,
+		Offset:     data.offset,
+		NextOffset: data.next,
+		Output:     string(data.data),
+		More:       data.more,
+		Dropped:    data.dropped,
+	}
+}
+
+// stop 挂断终端：请求进程组退出，超时后强制结束
+func (t *terminal) stop() {
+	t.mu.Lock()
+	if t.running() {
+		t.stopping = true
+	}
+	pid := t.info.PID
+	t.mu.Unlock()
+
+	if t.running() {
+		if err := signalProcessGroup(pid, false); err != nil {
+			slog.Warn("关闭终端失败", "pid", pid, "error", err)
+		}
+		select {
+		case <-t.exited:
+			return
+		case <-time.After(processStopTimeout):
+		}
+	}
+	signalProcessGroup(pid, true)
+	select {
+	case <-t.exited:
+	case <-time.After(2 * processStopTimeout):
+		slog.Warn("终端进程未能结束", "pid", pid)
+	}
+}
+
+// terminalKeys terminal_write 支持的按键名称及其发送的字节序列
+var terminalKeys = map[string]string{
+	"enter":     "\r",
+	"tab":       "\t",
+	"space":     " ",
+	"backspace": "\x7f",
+	"escape":    "\x1b",
+	"up":        "\x1b[A",
+	"down":      "\x1b[B",
+	"right":     "\x1b[C",
+	"left":      "\x1b[D",
+	"home":      "\x1b[H",
+	"end":       "\x1b[F",
+	"delete":    "\x1b[3~",
+	"pageup":    "\x1b[5~",
+	"pagedown":  "\x1b[6~",
+}
+
+// terminalKey 将按键名称转换为字节序列，支持 ctrl+a 到 ctrl+z
+func terminalKey(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if seq, ok := terminalKeys[name]; ok {
+		return seq, nil
+	}
+	if letter, ok := strings.CutPrefix(name, "ctrl+"); ok && len(letter) == 1 && letter[0] >= 'a' && letter[0] <= 'z' {
+		return string(rune(letter[0] - 'a' + 1)), nil
+	}
+	return "", fmt.Errorf("不支持的按键: %s", name)
+}
+
+// StripANSI 去除终端输出中的 ANSI 控制序列，并按回车和退格的效果整理文本
+//
+// 回车后的内容覆盖当前行，退格删除前一个字符，其他控制字符被丢弃；不模拟光标移动和全屏程序的界面。
+func StripANSI(s string) string {
+	var out []rune
+	lineStart := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == 0x1b:
+			i = skipEscape(s, i)
+			continue
+		case c == '\r':
+			if i+1 < len(s) && s[i+1] == '\n' {
+				i++
+				continue
+			}
+			out = out[:lineStart]
+		case c == '\n':
+			out = append(out, '\n')
+			lineStart = len(out)
+		case c == '\b':
+			if len(out) > lineStart {
+				out = out[:len(out)-1]
+			}
+		case c == '\t':
+			out = append(out, '\t')
+		case c < 0x20 || c == 0x7f:
+		default:
+			r, size := utf8.DecodeRuneInString(s[i:])
+			out = append(out, r)
+			i += size
+			continue
+		}
+		i++
+	}
+	return string(out)
+}
+
+// skipEscape 跳过从 s[i] 开始的转义序列，返回序列之后的位置
+func skipEscape(s string, i int) int {
+	if i+1 >= len(s) {
+		return len(s)
+	}
+	switch s[i+1] {
+	case '[': // CSI：参数和中间字节之后以 0x40-0x7E 结尾
+		for j := i + 2; j < len(s); j++ {
+			if s[j] >= 0x40 && s[j] <= 0x7e {
+				return j + 1
+			}
+		}
+		return len(s)
+	case ']', 'P', 'X', '^', '_': // OSC、DCS 等字符串序列：以 BEL 或 ESC \ 结尾
+		for j := i + 2; j < len(s); j++ {
+			if s[j] == 0x07 {
+				return j + 1
+			}
+			if s[j] == 0x1b && j+1 < len(s) && s[j+1] == '\\' {
+				return j + 2
+			}
+		}
+		return len(s)
+	case '(', ')', '*', '+', '#', '%': // 字符集选择等三字节序列
+		return min(i+3, len(s))
+	default:
+		return i + 2
+	}
+}
+
+// NewTerminalTools 创建绑定会话的终端工具，终端在 basePath 下打开
+func NewTerminalTools(manager *TerminalManager, session, basePath string) map[string]tool.InvokableTool {
+	base := terminalTool{manager: manager, session: session, basePath: basePath}
+	return map[string]tool.InvokableTool{
+		TerminalOpenToolName:   &TerminalOpenTool{base},
+		TerminalWriteToolName:  &TerminalWriteTool{base},
+		TerminalReadToolName:   &TerminalReadTool{base},
+		TerminalResizeToolName: &TerminalResizeTool{base},
+		TerminalCloseToolName:  &TerminalCloseTool{base},
+	}
+}
+
+type terminalTool struct {
+	manager  *TerminalManager
+	session  string
+	basePath string
+}
+
+// readText 读取终端输出并去除控制序列
+func (t terminalTool) readText(ctx context.Context, name string, offset int64, maxBytes int) (string, error) {
+	out, err := t.manager.Read(ctx, t.session, name, offset, maxBytes, 0)
+	if err != nil {
+		return "", err
+	}
+	out.Output = StripANSI(out.Output)
+	return processResult(out)
+}
+
+// TerminalOpenTool 打开交互式终端
+type TerminalOpenTool struct{ terminalTool }
+
+func (t *TerminalOpenTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: TerminalOpenToolName,
+		Desc: "在工作目录中打开一个伪终端（PTY），用于需要交互的程序，例如 REPL、npm init、ssh-keygen 的确认提示。打开后用 terminal_write 输入按键，用 terminal_read 查看输出，用完后用 terminal_close 关闭。返回程序启动后的输出。用户可以在界面上实时查看和接管该终端",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"name": {
+				Type:     schema.String,
+				Desc:     "终端名称，用于后续操作，只能包含字母、数字、下划线、点和短横线，例如 repl",
+				Required: true,
+			},
+			"command": {
+				Type:     schema.String,
+				Desc:     "在终端中运行的命令，例如 python3，默认运行交互式 bash",
+				Required: false,
+			},
+			"dir": {
+				Type:     schema.String,
+				Desc:     "相对工作目录的子目录，默认为工作目录",
+				Required: false,
+			},
+			"rows": {
+				Type:     schema.Number,
+				Desc:     fmt.Sprintf("终端行数，默认 %d", DefaultTerminalRows),
+				Required: false,
+			},
+			"cols": {
+				Type:     schema.Number,
+				Desc:     fmt.Sprintf("终端列数，默认 %d", DefaultTerminalCols),
+				Required: false,
+			},
+		}),
+	}, nil
+}
+
+func (t *TerminalOpenTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	var args struct {
+		Name    string `json:"name"`
+		Command string `json:"command"`
+		Dir     string `json:"dir"`
+		Rows    int    `json:"rows"`
+		Cols    int    `json:"cols"`
+	}
+	if err := json.Unmarshal([]byte(argumentsInJSON), &args); err != nil {
+		return "", fmt.Errorf("参数解析失败: %w", err)
+	}
+	if hasDangerousContent(args.Command) {
+		return "", fmt.Errorf("命令包含危险内容")
+	}
+	dir, err := resolveWorkDir(t.basePath, args.Dir)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = t.manager.Open(t.session, TerminalOpenOptions{
+		Name:    args.Name,
+		Command: args.Command,
+		Dir:     dir,
+		Rows:    args.Rows,
+		Cols:    args.Cols,
+	})
+	if err != nil {
+		return "", err
+	}
+	// 等待程序输出提示符
+	t.manager.Settle(ctx, t.session, args.Name, 0, defaultTerminalWriteWait)
+	return t.readText(ctx, args.Name, 0, 0)
+}
+
+// TerminalWriteTool 向终端输入文本和按键
+type TerminalWriteTool struct{ terminalTool }
+
+func (t *TerminalWriteTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	keys := make([]string, 0, len(terminalKeys))
+	for name := range terminalKeys {
+		keys = append(keys, name)
+	}
+	sort.Strings(keys)
+
+	return &schema.ToolInfo{
+		Name: TerminalWriteToolName,
+		Desc: "向终端输入文本和按键，等待输出稳定后返回输入之后的输出（已去除 ANSI 控制序列）。先发送 text，再依次发送 keys",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"name": {
+				Type:     schema.String,
+				Desc:     "终端名称",
+				Required: true,
+			},
+			"text": {
+				Type:     schema.String,
+				Desc:     "要输入的文本，原样发送，不会自动追加回车",
+				Required: false,
+			},
+			"keys": {
+				Type:     schema.Array,
+				ElemInfo: &schema.ParameterInfo{Type: schema.String},
+				Desc:     fmt.Sprintf("在文本之后依次发送的按键：%s，以及 ctrl+a 到 ctrl+z，例如 [\"enter\"]", strings.Join(keys, "、")),
+				Required: false,
+			},
+			"wait": {
+				Type:     schema.Number,
+				Desc:     fmt.Sprintf("等待输出的最长秒数，默认 %d，最大 %d，输出停止后立即返回", int(defaultTerminalWriteWait/time.Second), int(maxProcessOutputWait/time.Second)),
+				Required: false,
+			},
+		}),
+	}, nil
+}
+
+func (t *TerminalWriteTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	var args struct {
+		Name string   `json:"name"`
+		Text string   `json:"text"`
+		Keys []string `json:"keys"`
+		Wait *float64 `json:"wait"`
+	}
+	if err := json.Unmarshal([]byte(argumentsInJSON), &args); err != nil {
+		return "", fmt.Errorf("参数解析失败: %w", err)
+	}
+	var input strings.Builder
+	input.WriteString(args.Text)
+	for _, key := range args.Keys {
+		seq, err := terminalKey(key)
+		if err != nil {
+			return "", err
+		}
+		input.WriteString(seq)
+	}
+	if input.Len() == 0 {
+		return "", fmt.Errorf("text 和 keys 不能同时为空")
+	}
+
+	offset, err := t.manager.Write(t.session, args.Name, input.String())
+	if err != nil {
+		return "", err
+	}
+	wait := defaultTerminalWriteWait
+	if args.Wait != nil {
+		wait = time.Duration(*args.Wait * float64(time.Second))
+	}
+	if err := t.manager.Settle(ctx, t.session, args.Name, offset, wait); err != nil {
+		return "", err
+	}
+	return t.readText(ctx, args.Name, offset, 0)
+}
+
+// TerminalReadTool 读取终端输出
+type TerminalReadTool struct{ terminalTool }
+
+func (t *TerminalReadTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: TerminalReadToolName,
+		Desc: "读取终端输出（已去除 ANSI 控制序列）。传入上次结果中的 next_offset 只读取新输出；省略 offset 时返回最近的输出，相当于查看当前屏幕",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"name": {
+				Type:     schema.String,
+				Desc:     "终端名称",
+				Required: true,
+			},
+			"offset": {
+				Type:     schema.Number,
+				Desc:     "从该偏移开始读取",
+				Required: false,
+			},
+			"max_bytes": {
+				Type:     schema.Number,
+				Desc:     fmt.Sprintf("最多读取的原始字节数，默认 %d，最大 %d", defaultProcessOutputRead, maxProcessOutputRead),
+				Required: false,
+			},
+			"wait": {
+				Type:     schema.Number,
+				Desc:     fmt.Sprintf("等待新输出的最长秒数，最大 %d，输出停止后立即返回，需要同时传入 offset", int(maxProcessOutputWait/time.Second)),
+				Required: false,
+			},
+		}),
+	}, nil
+}
+
+func (t *TerminalReadTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	var args struct {
+		Name     string  `json:"name"`
+		Offset   *int64  `json:"offset"`
+		MaxBytes int     `json:"max_bytes"`
+		Wait     float64 `json:"wait"`
+	}
+	if err := json.Unmarshal([]byte(argumentsInJSON), &args); err != nil {
+		return "", fmt.Errorf("参数解析失败: %w", err)
+	}
+	offset := int64(-1)
+	if args.Offset != nil && *args.Offset >= 0 {
+		offset = *args.Offset
+		if args.Wait > 0 {
+			if err := t.manager.Settle(ctx, t.session, args.Name, offset, time.Duration(args.Wait*float64(time.Second))); err != nil {
+				return "", err
+			}
+		}
+	}
+	return t.readText(ctx, args.Name, offset, args.MaxBytes)
+}
+
+// TerminalResizeTool 调整终端大小
+type TerminalResizeTool struct{ terminalTool }
+
+func (t *TerminalResizeTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: TerminalResizeToolName,
+		Desc: "调整终端的行数和列数，全屏程序会按新大小重新绘制",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"name": {
+				Type:     schema.String,
+				Desc:     "终端名称",
+				Required: true,
+			},
+			"rows": {
+				Type:     schema.Number,
+				Desc:     "行数",
+				Required: true,
+			},
+			"cols": {
+				Type:     schema.Number,
+				Desc:     "列数",
+				Required: true,
+			},
+		}),
+	}, nil
+}
+
+func (t *TerminalResizeTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	var args struct {
+		Name string `json:"name"`
+		Rows int    `json:"rows"`
+		Cols int    `json:"cols"`
+	}
+	if err := json.Unmarshal([]byte(argumentsInJSON), &args); err != nil {
+		return "", fmt.Errorf("参数解析失败: %w", err)
+	}
+	info, err := t.manager.Resize(t.session, args.Name, args.Rows, args.Cols)
+	if err != nil {
+		return "", err
+	}
+	return processResult(info)
+}
+
+// TerminalCloseTool 关闭终端
+type TerminalCloseTool struct{ terminalTool }
+
+func (t *TerminalCloseTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: TerminalCloseToolName,
+		Desc: "关闭终端，结束其中运行的程序及其子进程",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"name": {
+				Type:     schema.String,
+				Desc:     "终端名称",
+				Required: true,
+			},
+		}),
+	}, nil
+}
+
+func (t *TerminalCloseTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	var args struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal([]byte(argumentsInJSON), &args); err != nil {
+		return "", fmt.Errorf("参数解析失败: %w", err)
+	}
+	info, err := t.manager.Close(t.session, args.Name)
+	if err != nil {
+		return "", err
+	}
+	return processResult(info)
+}
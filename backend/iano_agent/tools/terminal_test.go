@@ -0,0 +1,182 @@
+//go:build linux
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStripANSI(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain", "hello\r\nworld", "hello\nworld"},
+		{"colors", "\x1b[1;32mok\x1b[0m done", "ok done"},
+		{"title", "\x1b]0;user@host: ~\x07$ ls", "$ ls"},
+		{"charset", "\x1b(Bab\x1b=c", "abc"},
+		{"carriage return", "10%\r20%\r30%\ndone", "30%\ndone"},
+		{"backspace", "abc\b \bd", "abd"},
+		{"bell and controls", "a\x07b\x00c\td", "abc\td"},
+		{"unicode", "\x1b[31m你好\x1b[m", "你好"},
+		{"truncated escape", "text\x1b[3", "text"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StripANSI(tt.in); got != tt.want {
+				t.Errorf("StripANSI(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTerminalKey(t *testing.T) {
+	for name, want := range map[string]string{"enter": "\r", "Up": "\x1b[A", "ctrl+c": "\x03", "ctrl+d": "\x04"} {
+		if got, err := terminalKey(name); err != nil || got != want {
+			t.Errorf("terminalKey(%q) = %q, %v", name, got, err)
+		}
+	}
+	if _, err := terminalKey("ctrl+1"); err == nil {
+		t.Error("terminalKey(ctrl+1) error = nil")
+	}
+}
+
+// settledOutput 等待 offset 之后的输出稳定并返回去除控制序列后的文本
+func settledOutput(t *testing.T, m *TerminalManager, session, name string, offset int64) string {
+	t.Helper()
+	if err := m.Settle(context.Background(), session, name, offset, 5*time.Second); err != nil {
+		t.Fatalf("Settle() error = %v", err)
+	}
+	out, err := m.Read(context.Background(), session, name, offset, 0, 0)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	return StripANSI(out.Output)
+}
+
+func TestTerminalManager(t *testing.T) {
+	m := NewTerminalManager(2)
+	defer m.Shutdown()
+	dir := t.TempDir()
+
+	info, err := m.Open("s1", TerminalOpenOptions{
+		Name:    "size",
+		Command: `[ -t 0 ] && echo tty; stty size; read -p "next? " line; stty size; echo "got $line"`,
+		Dir:     dir,
+		Rows:    30,
+		Cols:    100,
+	})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if info.Status != ProcessStatusRunning || info.Rows != 30 || info.Cols != 100 {
+		t.Fatalf("info = %+v", info)
+	}
+	if out := settledOutput(t, m, "s1", "size", 0); !strings.Contains(out, "tty\n30 100\nnext? ") {
+		t.Fatalf("initial output = %q", out)
+	}
+
+	if _, err := m.Resize("s1", "size", 40, 90); err != nil {
+		t.Fatalf("Resize() error = %v", err)
+	}
+	offset, err := m.Write("s1", "size", "hello\r")
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if out := settledOutput(t, m, "s1", "size", offset); !strings.Contains(out, "40 90\ngot hello") {
+		t.Errorf("output after resize = %q", out)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if info, _ = m.Status("s1", "size"); info.Status != ProcessStatusRunning {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if info.Status != ProcessStatusExited || info.ExitCode == nil || *info.ExitCode != 0 || info.Rows != 40 {
+		t.Errorf("status after exit = %+v", info)
+	}
+	if _, err := m.Write("s1", "size", "x"); err == nil {
+		t.Error("Write() to exited terminal error = nil")
+	}
+
+	// Ctrl+C 通过终端向前台进程组发送 SIGINT
+	if _, err := m.Open("s1", TerminalOpenOptions{Name: "sleep", Command: "sleep 60; echo done", Dir: dir}); err != nil {
+		t.Fatalf("Open(sleep) error = %v", err)
+	}
+	if _, err := m.Write("s1", "sleep", "\x03"); err != nil {
+		t.Fatalf("Write(ctrl+c) error = %v", err)
+	}
+	deadline = time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if info, _ = m.Status("s1", "sleep"); info.Status != ProcessStatusRunning {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if info.Status != ProcessStatusExited || info.ExitCode == nil || *info.ExitCode == 0 {
+		t.Errorf("status after ctrl+c = %+v", info)
+	}
+
+	// 达到上限时已退出的终端让出名额
+	for _, name := range []string{"a", "b"} {
+		if _, err := m.Open("s1", TerminalOpenOptions{Name: name, Command: "sleep 60", Dir: dir}); err != nil {
+			t.Fatalf("Open(%s) error = %v", name, err)
+		}
+	}
+	if _, err := m.Open("s1", TerminalOpenOptions{Name: "c", Command: "sleep 60", Dir: dir}); err == nil || !strings.Contains(err.Error(), "上限") {
+		t.Errorf("Open() over limit error = %v", err)
+	}
+	info, err = m.Close("s1", "a")
+	if err != nil || info.Status != ProcessStatusStopped {
+		t.Errorf("Close() = %+v, %v", info, err)
+	}
+
+	m.CloseSession("s1")
+	if _, err := m.Status("s1", "b"); !errors.Is(err, ErrTerminalNotFound) {
+		t.Errorf("Status() after CloseSession error = %v", err)
+	}
+}
+
+func TestTerminalTools(t *testing.T) {
+	m := NewTerminalManager(0)
+	defer m.Shutdown()
+	tools := NewTerminalTools(m, "s1", t.TempDir())
+
+	var out TerminalOutput
+	result, err := tools[TerminalOpenToolName].InvokableRun(context.Background(), `{"name": "init", "command": "printf '\\033[1mContinue?\\033[0m [y/N] '; read a; echo answer=$a"}`)
+	if err != nil {
+		t.Fatalf("terminal_open error = %v", err)
+	}
+	json.Unmarshal([]byte(result), &out)
+	if out.Output != "Continue? [y/N] " {
+		t.Errorf("terminal_open output = %q", out.Output)
+	}
+
+	result, err = tools[TerminalWriteToolName].InvokableRun(context.Background(), `{"name": "init", "text": "y", "keys": ["enter"]}`)
+	if err != nil {
+		t.Fatalf("terminal_write error = %v", err)
+	}
+	json.Unmarshal([]byte(result), &out)
+	if !strings.Contains(out.Output, "y\nanswer=y\n") {
+		t.Errorf("terminal_write output = %q", out.Output)
+	}
+
+	if _, err := tools[TerminalWriteToolName].InvokableRun(context.Background(), `{"name": "init", "keys": ["f13"]}`); err == nil {
+		t.Error("terminal_write with unknown key error = nil")
+	}
+	result, err = tools[TerminalReadToolName].InvokableRun(context.Background(), `{"name": "init"}`)
+	if err != nil || !strings.Contains(result, "answer=y") {
+		t.Errorf("terminal_read = %s, %v", result, err)
+	}
+	if _, err := tools[TerminalCloseToolName].InvokableRun(context.Background(), `{"name": "missing"}`); !errors.Is(err, ErrTerminalNotFound) {
+		t.Errorf("terminal_close missing error = %v", err)
+	}
+}
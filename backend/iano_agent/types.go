@@ -21,6 +21,10 @@ type Message struct {
 	IsThink          bool          `json:"is_think"`
 	IsReasoning      bool          `json:"is_reasoning"`
 	IsToolCall       bool          `json:"is_tool_call"`
+	Agent            string        `json:"agent,omitempty"`         // 产生该消息的子 Agent，主 Agent 为空
+	Depth            int           `json:"depth,omitempty"`         // 委派深度，主 Agent 为 0
+	StopReason       string        `json:"stop_reason,omitempty"`   // 运行提前结束的原因
+	Progress         *ToolProgress `json:"tool_progress,omitempty"` // 工具执行过程中的输出
 }
 
 type MessageCallback func(msg *Message)
@@ -29,6 +33,8 @@ type TokenUsage struct {
 	TotalTokens      int64
 	PromptTokens     int64
 	CompletionTokens int64
+	CachedTokens     int64
+	ReasoningTokens  int64
 	SummaryTokens    int64
 	SavedTokens      int64
 	LastUpdated      time.Time
@@ -0,0 +1,79 @@
+package iano_agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// usageChatModel 先调用一次 system_info 再给出答复的测试模型，每次调用在最后一个分片中返回固定用量
+type usageChatModel struct{}
+
+// usageChatResponses 两次模型调用的回复，第一次调用没有返回 TotalTokens
+var usageChatResponses = []struct {
+	toolCall bool
+	usage    schema.TokenUsage
+}{
+	{toolCall: true, usage: schema.TokenUsage{PromptTokens: 100, CompletionTokens: 20, PromptTokenDetails: schema.PromptTokenDetails{CachedTokens: 40}}},
+	{usage: schema.TokenUsage{PromptTokens: 150, CompletionTokens: 30, TotalTokens: 180, CompletionTokensDetails: schema.CompletionTokensDetails{ReasoningTokens: 10}}},
+}
+
+func (m *usageChatModel) respond(input []*schema.Message) []*schema.Message {
+	step := 0
+	if input[len(input)-1].Role == schema.Tool {
+		step = 1
+	}
+	response := usageChatResponses[step]
+	usage := response.usage
+
+	last := &schema.Message{Role: schema.Assistant, ResponseMeta: &schema.ResponseMeta{Usage: &usage}}
+	if response.toolCall {
+		last.ToolCalls = []schema.ToolCall{{
+			ID:       "call_1",
+			Function: schema.FunctionCall{Name: "system_info", Arguments: `{"info_type": "os"}`},
+		}}
+		return []*schema.Message{last}
+	}
+	return []*schema.Message{schema.AssistantMessage("系统是 Linux", nil), last}
+}
+
+func (m *usageChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	return schema.ConcatMessages(m.respond(input))
+}
+
+func (m *usageChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	return schema.StreamReaderFromArray(m.respond(input)), nil
+}
+
+func (m *usageChatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	return m, nil
+}
+
+func TestAgent_TokenUsage(t *testing.T) {
+	a, err := NewAgent(&usageChatModel{}, WithAllowedTools([]string{"system_info"}))
+	if err != nil {
+		t.Fatalf("NewAgent() error = %v", err)
+	}
+
+	// 一次运行包含工具调用前后两次模型调用，用量逐次累加
+	want := TokenUsage{PromptTokens: 250, CompletionTokens: 50, TotalTokens: 300, CachedTokens: 40, ReasoningTokens: 10}
+	for run := 1; run <= 2; run++ {
+		if _, err := a.Chat(context.Background(), "查看系统信息"); err != nil {
+			t.Fatalf("Chat() error = %v", err)
+		}
+
+		got := a.GetRunUsage()
+		if got.PromptTokens != want.PromptTokens || got.CompletionTokens != want.CompletionTokens || got.TotalTokens != want.TotalTokens ||
+			got.CachedTokens != want.CachedTokens || got.ReasoningTokens != want.ReasoningTokens {
+			t.Errorf("run %d GetRunUsage() = %+v, want %+v", run, got, want)
+		}
+
+		// 累计用量包含之前的每次运行
+		total := a.GetTokenUsage()
+		if total.PromptTokens != int64(run)*want.PromptTokens || total.TotalTokens != int64(run)*want.TotalTokens || total.CachedTokens != int64(run)*want.CachedTokens {
+			t.Errorf("run %d GetTokenUsage() = %+v", run, total)
+		}
+	}
+}
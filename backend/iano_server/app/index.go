@@ -79,6 +79,13 @@ func (a *App) InitDB() error {
 		&models.Tool{},
 		&models.MCPServer{},
 		&models.MCPServerTool{},
+		&models.File{},
+		&models.Prompt{},
+		&models.Memory{},
+		&models.KnowledgeBase{},
+		&models.KnowledgeDocument{},
+		&models.KnowledgeChunk{},
+		&models.Checkpoint{},
 	)
 }
 
@@ -129,6 +136,10 @@ func (a *App) Start() error {
 
 func (a *App) Shutdown() {
 	a.Log.Info("服务关闭")
+	if a.Container != nil {
+		a.Container.ProcessManager.Shutdown()
+		a.Container.TerminalManager.Shutdown()
+	}
 	if a.DB != nil {
 		sqlDB, err := a.DB.DB()
 		if err == nil {
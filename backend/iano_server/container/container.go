@@ -2,6 +2,7 @@ package container
 
 import (
 	"context"
+	"iano_agent/tools"
 	"iano_server/controllers"
 	"iano_server/pkg/config"
 	"iano_server/services"
@@ -23,17 +24,33 @@ type Container struct {
 	ProviderService     *services.ProviderService
 	MCPService          *services.MCPService
 	AgentRuntimeService *services.AgentRuntimeService
+	SummaryService      *services.SummaryService
+	ApprovalService     *services.ApprovalService
+	RunRegistry         *services.RunRegistry
+	AttachmentService   *services.AttachmentService
+	PromptService       *services.PromptService
+	MemoryService       *services.MemoryService
+	KnowledgeService    *services.KnowledgeService
+	CheckpointService   *services.CheckpointService
+	ProcessManager      *tools.ProcessManager
+	TerminalManager     *tools.TerminalManager
 
 	AgentSSEClientMap *services.AgentSSEClientMap
 
-	AgentController    *controllers.AgentController
-	MessageController  *controllers.MessageController
-	SessionController  *controllers.SessionController
-	ToolController     *controllers.ToolController
-	ProviderController *controllers.ProviderController
-	ChatController     *controllers.ChatController
-	MCPController      *controllers.MCPController
-	BaseController     *controllers.BaseController
+	AgentController      *controllers.AgentController
+	MessageController    *controllers.MessageController
+	SessionController    *controllers.SessionController
+	ToolController       *controllers.ToolController
+	ProviderController   *controllers.ProviderController
+	ChatController       *controllers.ChatController
+	MCPController        *controllers.MCPController
+	AttachmentController *controllers.AttachmentController
+	PromptController     *controllers.PromptController
+	MemoryController     *controllers.MemoryController
+	KnowledgeController  *controllers.KnowledgeController
+	CheckpointController *controllers.CheckpointController
+	TerminalController   *controllers.TerminalController
+	BaseController       *controllers.BaseController
 }
 
 func NewContainer(ctx context.Context, db *gorm.DB, cfg *config.Config) *Container {
@@ -58,28 +75,61 @@ func (c *Container) Provide(db *gorm.DB, cfg *config.Config) {
 	c.ToolService = services.NewToolService(db)
 	c.ProviderService = services.NewProviderService(db)
 	c.MCPService = services.NewMCPService(db)
+	c.PromptService = services.NewPromptService(db)
+	c.MemoryService = services.NewMemoryService(db)
+	c.AttachmentService = services.NewAttachmentService(db, cfg.Upload.Path, int64(cfg.Upload.MaxSize)<<20)
+	c.KnowledgeService = services.NewKnowledgeService(db, c.ProviderService, c.AttachmentService)
+	c.CheckpointService = services.NewCheckpointService(db, cfg.Checkpoint.Path, int64(cfg.Checkpoint.MaxSize)<<20)
+	c.ProcessManager = tools.NewProcessManager(cfg.Process.MaxPerSession)
+	c.TerminalManager = tools.NewTerminalManager(cfg.Terminal.MaxPerSession)
+	// 服务退出时结束所有后台进程和终端
+	go func() {
+		<-c.Ctx.Done()
+		c.ProcessManager.Shutdown()
+		c.TerminalManager.Shutdown()
+	}()
 	c.AgentRuntimeService = services.NewAgentRuntimeServiceWithMCP(
 		db,
 		c.AgentService,
 		c.ProviderService,
 		c.ToolService,
 		c.MCPService,
+		c.PromptService,
+		c.MemoryService,
+		c.KnowledgeService,
+		c.ProcessManager,
+		c.TerminalManager,
 	)
 	c.AgentSSEClientMap = services.NewAgentSSEClientMap()
+	c.SummaryService = services.NewSummaryService(c.MessageService, c.SessionService, c.AttachmentService)
+	c.ApprovalService = services.NewApprovalService()
+	c.RunRegistry = services.NewRunRegistry()
 
 	c.AgentController = controllers.NewAgentController(c.AgentService, c.AgentRuntimeService)
 	c.MessageController = controllers.NewMessageController(c.MessageService)
-	c.SessionController = controllers.NewSessionController(c.SessionService)
+	c.SessionController = controllers.NewSessionController(c.SessionService, c.ProcessManager, c.TerminalManager)
 	c.ToolController = controllers.NewToolController(c.ToolService)
 	c.ProviderController = controllers.NewProviderController(c.ProviderService)
 	c.ChatController = controllers.NewChatController(
 		c.AgentService,
 		c.ProviderService,
 		c.MessageService,
+		c.SessionService,
 		c.AgentRuntimeService,
 		c.AgentSSEClientMap,
+		c.SummaryService,
+		c.ApprovalService,
+		c.RunRegistry,
+		c.AttachmentService,
+		c.CheckpointService,
 	)
 	c.MCPController = controllers.NewMCPController(c.MCPService)
+	c.AttachmentController = controllers.NewAttachmentController(c.AttachmentService)
+	c.PromptController = controllers.NewPromptController(c.PromptService)
+	c.MemoryController = controllers.NewMemoryController(c.MemoryService)
+	c.KnowledgeController = controllers.NewKnowledgeController(c.KnowledgeService, c.ProviderService)
+	c.CheckpointController = controllers.NewCheckpointController(c.CheckpointService)
+	c.TerminalController = controllers.NewTerminalController(c.TerminalManager)
 	c.BaseController = controllers.NewBaseController(c.ProviderService, c.SessionService, c.ToolService, c.AgentService)
 }
 
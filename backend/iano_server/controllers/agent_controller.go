@@ -1,6 +1,9 @@
 package controllers
 
 import (
+	"encoding/json"
+	"fmt"
+	iano "iano_agent"
 	"iano_server/models"
 	"iano_server/services"
 	web "iano_web"
@@ -21,27 +24,55 @@ func NewAgentController(agentService *services.AgentService, runtimeService *ser
 }
 
 type CreateAgentRequest struct {
-	Name         string   `json:"name" example:"助手"`
-	Description  string   `json:"description" example:"通用助手"`
-	Type         string   `json:"type" example:"main"`
-	IsSubAgent   bool     `json:"is_sub_agent" example:"false"`
-	ProviderID   string   `json:"provider_id" example:"provider-001"`
-	Model        string   `json:"model" example:"gpt-4"`
-	Instructions string   `json:"instructions" example:"你是一个智能助手"`
-	Tools        string   `json:"tools" example:"file_read,file_write"`
-	McpServerIDs []string `json:"mcp_server_ids" example:"mcp-001"` // 关联的 MCP 服务器 ID
+	Name                string            `json:"name" example:"助手"`
+	Description         string            `json:"description" example:"通用助手"`
+	Type                string            `json:"type" example:"main"`
+	IsSubAgent          bool              `json:"is_sub_agent" example:"false"`
+	ProviderID          string            `json:"provider_id" example:"provider-001"`
+	Model               string            `json:"model" example:"gpt-4"`
+	Instructions        string            `json:"instructions" example:"你是一个智能助手"` // 指令，支持 text/template 变量和 include 引用提示词库
+	Tools               string            `json:"tools" example:"file_read,file_write"`
+	McpServerIDs        []string          `json:"mcp_server_ids" example:"mcp-001"`             // 关联的 MCP 服务器 ID
+	FallbackProviderIDs []string          `json:"fallback_provider_ids" example:"provider-002"` // 备用提供商 ID 列表，按顺序故障转移
+	ApprovalPolicy      string            `json:"approval_policy" example:"ask"`                // 工具审批策略 auto/ask/deny
+	ToolApprovals       map[string]string `json:"tool_approvals"`                               // 工具级审批策略，工具名 -> 策略
+	SubAgentIDs         []string          `json:"sub_agent_ids" example:"agent-002"`            // 可委派的子 Agent ID 列表
+	MaxDelegationDepth  int               `json:"max_delegation_depth" example:"2"`             // 最大委派深度，0 表示使用默认值
+	MaxRounds           int               `json:"max_rounds" example:"30"`                      // 单次运行最多调用模型的轮数，0 表示使用默认值
+	MaxToolCalls        int               `json:"max_tool_calls" example:"20"`                  // 单次运行最多执行的工具调用次数，0 表示不限制
+	TokenBudget         int64             `json:"token_budget" example:"50000"`                 // 单次运行的 Token 预算，0 表示不限制
+	RunTimeout          int               `json:"run_timeout" example:"300"`                    // 单次运行的时长上限（秒），0 表示不限制
+	ResponseSchema      json.RawMessage   `json:"response_schema" swaggertype:"object"`         // 最终答复需要满足的 JSON Schema
+	MaxSchemaRetries    int               `json:"max_schema_retries" example:"2"`               // 最终答复不符合 Schema 时的重新提示次数，0 表示使用默认值
+	MemoryScope         string            `json:"memory_scope" example:"agent"`                 // 长期记忆范围 agent/user/workspace，为空表示不启用记忆
+	KnowledgeBaseIDs    []string          `json:"knowledge_base_ids" example:"kb-001"`          // 绑定的知识库 ID 列表，绑定后启用 kb_search 工具
+	AllowGitWrite       bool              `json:"allow_git_write" example:"false"`              // 允许 Git 写操作（创建/切换分支、提交）
 }
 
 type UpdateAgentRequest struct {
-	Name         *string   `json:"name,omitempty" example:"助手"`
-	Description  *string   `json:"description,omitempty" example:"通用助手"`
-	Type         *string   `json:"type,omitempty" example:"main"`
-	IsSubAgent   *bool     `json:"is_sub_agent,omitempty" example:"false"`
-	ProviderID   *string   `json:"provider_id,omitempty" example:"provider-001"`
-	Model        *string   `json:"model,omitempty" example:"gpt-4"`
-	Instructions *string   `json:"instructions,omitempty" example:"你是一个智能助手"`
-	Tools        *string   `json:"tools,omitempty" example:"file_read,file_write"`
-	McpServerIDs *[]string `json:"mcp_server_ids,omitempty" example:"mcp-001"` // 关联的 MCP 服务器 ID
+	Name                *string            `json:"name,omitempty" example:"助手"`
+	Description         *string            `json:"description,omitempty" example:"通用助手"`
+	Type                *string            `json:"type,omitempty" example:"main"`
+	IsSubAgent          *bool              `json:"is_sub_agent,omitempty" example:"false"`
+	ProviderID          *string            `json:"provider_id,omitempty" example:"provider-001"`
+	Model               *string            `json:"model,omitempty" example:"gpt-4"`
+	Instructions        *string            `json:"instructions,omitempty" example:"你是一个智能助手"`
+	Tools               *string            `json:"tools,omitempty" example:"file_read,file_write"`
+	McpServerIDs        *[]string          `json:"mcp_server_ids,omitempty" example:"mcp-001"`             // 关联的 MCP 服务器 ID
+	FallbackProviderIDs *[]string          `json:"fallback_provider_ids,omitempty" example:"provider-002"` // 备用提供商 ID 列表，按顺序故障转移
+	ApprovalPolicy      *string            `json:"approval_policy,omitempty" example:"ask"`                // 工具审批策略 auto/ask/deny
+	ToolApprovals       *map[string]string `json:"tool_approvals,omitempty"`                               // 工具级审批策略，工具名 -> 策略
+	SubAgentIDs         *[]string          `json:"sub_agent_ids,omitempty" example:"agent-002"`            // 可委派的子 Agent ID 列表
+	MaxDelegationDepth  *int               `json:"max_delegation_depth,omitempty" example:"2"`             // 最大委派深度，0 表示使用默认值
+	MaxRounds           *int               `json:"max_rounds,omitempty" example:"30"`                      // 单次运行最多调用模型的轮数，0 表示使用默认值
+	MaxToolCalls        *int               `json:"max_tool_calls,omitempty" example:"20"`                  // 单次运行最多执行的工具调用次数，0 表示不限制
+	TokenBudget         *int64             `json:"token_budget,omitempty" example:"50000"`                 // 单次运行的 Token 预算，0 表示不限制
+	RunTimeout          *int               `json:"run_timeout,omitempty" example:"300"`                    // 单次运行的时长上限（秒），0 表示不限制
+	ResponseSchema      *json.RawMessage   `json:"response_schema,omitempty" swaggertype:"object"`         // 最终答复需要满足的 JSON Schema，传 {} 清除
+	MaxSchemaRetries    *int               `json:"max_schema_retries,omitempty" example:"2"`               // 最终答复不符合 Schema 时的重新提示次数，0 表示使用默认值
+	MemoryScope         *string            `json:"memory_scope,omitempty" example:"agent"`                 // 长期记忆范围 agent/user/workspace，为空表示不启用记忆
+	KnowledgeBaseIDs    *[]string          `json:"knowledge_base_ids,omitempty" example:"kb-001"`          // 绑定的知识库 ID 列表，绑定后启用 kb_search 工具
+	AllowGitWrite       *bool              `json:"allow_git_write,omitempty" example:"false"`              // 允许 Git 写操作（创建/切换分支、提交）
 }
 
 // Create godoc
@@ -61,18 +92,51 @@ func (c *AgentController) Create(ctx *web.Context) {
 		ctx.JSON(http.StatusBadRequest, models.Fail(err.Error()))
 		return
 	}
+	toolApprovals, err := marshalToolApprovals(req.ApprovalPolicy, req.ToolApprovals)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.Fail(err.Error()))
+		return
+	}
+	responseSchema, err := normalizeResponseSchema(req.ResponseSchema)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.Fail(err.Error()))
+		return
+	}
+	if err := iano.ParsePromptTemplate(req.Instructions); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.Fail(err.Error()))
+		return
+	}
+	if !models.IsValidMemoryScope(req.MemoryScope) {
+		ctx.JSON(http.StatusBadRequest, models.Fail(fmt.Sprintf("无效的记忆范围: %s", req.MemoryScope)))
+		return
+	}
 
 	agent := &models.Agent{
-		Name:         req.Name,
-		Description:  req.Description,
-		Type:         models.AgentType(req.Type),
-		IsSubAgent:   req.IsSubAgent,
-		ProviderID:   req.ProviderID,
-		Model:        req.Model,
-		Instructions: req.Instructions,
-		Tools:        req.Tools,
-		MCPServerIDs: req.McpServerIDs,
+		Name:                req.Name,
+		Description:         req.Description,
+		Type:                models.AgentType(req.Type),
+		IsSubAgent:          req.IsSubAgent,
+		ProviderID:          req.ProviderID,
+		Model:               req.Model,
+		Instructions:        req.Instructions,
+		Tools:               req.Tools,
+		MCPServerIDs:        req.McpServerIDs,
+		FallbackProviderIDs: req.FallbackProviderIDs,
+		ApprovalPolicy:      req.ApprovalPolicy,
+		ToolApprovals:       toolApprovals,
+		SubAgentIDs:         req.SubAgentIDs,
+		MaxDelegationDepth:  req.MaxDelegationDepth,
+		MaxRounds:           req.MaxRounds,
+		MaxToolCalls:        req.MaxToolCalls,
+		TokenBudget:         req.TokenBudget,
+		RunTimeout:          req.RunTimeout,
+		ResponseSchema:      responseSchema,
+		MaxSchemaRetries:    req.MaxSchemaRetries,
+		MemoryScope:         req.MemoryScope,
+		KnowledgeBaseIDs:    req.KnowledgeBaseIDs,
+		AllowGitWrite:       req.AllowGitWrite,
 	}
+	agent.NewID()
 	if err := c.agentService.Create(agent); err != nil {
 		ctx.JSON(http.StatusInternalServerError, models.Fail(err.Error()))
 		return
@@ -181,6 +245,10 @@ func (c *AgentController) Update(ctx *web.Context) {
 		updates["model"] = *req.Model
 	}
 	if req.Instructions != nil {
+		if err := iano.ParsePromptTemplate(*req.Instructions); err != nil {
+			ctx.JSON(http.StatusBadRequest, models.Fail(err.Error()))
+			return
+		}
 		updates["instructions"] = *req.Instructions
 	}
 	if req.Tools != nil {
@@ -189,6 +257,66 @@ func (c *AgentController) Update(ctx *web.Context) {
 	if req.McpServerIDs != nil {
 		updates["mcp_server_ids"] = models.StrArray(*req.McpServerIDs)
 	}
+	if req.FallbackProviderIDs != nil {
+		updates["fallback_provider_ids"] = models.StrArray(*req.FallbackProviderIDs)
+	}
+	if req.ApprovalPolicy != nil {
+		if _, err := marshalToolApprovals(*req.ApprovalPolicy, nil); err != nil {
+			ctx.JSON(http.StatusBadRequest, models.Fail(err.Error()))
+			return
+		}
+		updates["approval_policy"] = *req.ApprovalPolicy
+	}
+	if req.ToolApprovals != nil {
+		toolApprovals, err := marshalToolApprovals("", *req.ToolApprovals)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, models.Fail(err.Error()))
+			return
+		}
+		updates["tool_approvals"] = toolApprovals
+	}
+	if req.SubAgentIDs != nil {
+		updates["sub_agent_ids"] = models.StrArray(*req.SubAgentIDs)
+	}
+	if req.MaxDelegationDepth != nil {
+		updates["max_delegation_depth"] = *req.MaxDelegationDepth
+	}
+	if req.MaxRounds != nil {
+		updates["max_rounds"] = *req.MaxRounds
+	}
+	if req.MaxToolCalls != nil {
+		updates["max_tool_calls"] = *req.MaxToolCalls
+	}
+	if req.TokenBudget != nil {
+		updates["token_budget"] = *req.TokenBudget
+	}
+	if req.RunTimeout != nil {
+		updates["run_timeout"] = *req.RunTimeout
+	}
+	if req.ResponseSchema != nil {
+		responseSchema, err := normalizeResponseSchema(*req.ResponseSchema)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, models.Fail(err.Error()))
+			return
+		}
+		updates["response_schema"] = responseSchema
+	}
+	if req.MaxSchemaRetries != nil {
+		updates["max_schema_retries"] = *req.MaxSchemaRetries
+	}
+	if req.MemoryScope != nil {
+		if !models.IsValidMemoryScope(*req.MemoryScope) {
+			ctx.JSON(http.StatusBadRequest, models.Fail(fmt.Sprintf("无效的记忆范围: %s", *req.MemoryScope)))
+			return
+		}
+		updates["memory_scope"] = *req.MemoryScope
+	}
+	if req.KnowledgeBaseIDs != nil {
+		updates["knowledge_base_ids"] = models.StrArray(*req.KnowledgeBaseIDs)
+	}
+	if req.AllowGitWrite != nil {
+		updates["allow_git_write"] = *req.AllowGitWrite
+	}
 
 	agent, err := c.agentService.Update(id, updates)
 	if err != nil {
@@ -199,6 +327,41 @@ func (c *AgentController) Update(ctx *web.Context) {
 	ctx.JSON(http.StatusOK, models.Success(agent))
 }
 
+// marshalToolApprovals 校验审批策略，并将工具级策略序列化为 JSON
+func marshalToolApprovals(policy string, approvals map[string]string) (string, error) {
+	if policy != "" && !iano.ApprovalPolicy(policy).IsValid() {
+		return "", fmt.Errorf("无效的审批策略: %s", policy)
+	}
+	if len(approvals) == 0 {
+		return "", nil
+	}
+
+	for name, p := range approvals {
+		if !iano.ApprovalPolicy(p).IsValid() {
+			return "", fmt.Errorf("工具 %s 的审批策略无效: %s", name, p)
+		}
+	}
+	data, err := json.Marshal(approvals)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// normalizeResponseSchema 校验响应 Schema 并序列化为 JSON，空值和 {} 表示不限制输出格式
+func normalizeResponseSchema(raw json.RawMessage) (string, error) {
+	switch strings.TrimSpace(string(raw)) {
+	case "", "null", `""`, "{}":
+		return "", nil
+	}
+
+	schema, err := iano.ParseResponseSchema(raw)
+	if err != nil {
+		return "", err
+	}
+	return string(schema.Raw()), nil
+}
+
 // Delete godoc
 // @Summary 删除 Agent
 // @Description 删除指定 Agent
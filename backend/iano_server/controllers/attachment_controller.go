@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"fmt"
+	"iano_server/models"
+	"iano_server/services"
+	web "iano_web"
+	"mime"
+	"net/http"
+	"os"
+)
+
+type AttachmentController struct {
+	attachmentService *services.AttachmentService
+}
+
+func NewAttachmentController(attachmentService *services.AttachmentService) *AttachmentController {
+	return &AttachmentController{attachmentService: attachmentService}
+}
+
+// Upload godoc
+// @Summary 上传附件
+// @Description 上传一个或多个附件（表单字段 file，可重复），返回的附件 ID 可在 /api/chat/stream 的 attachment_ids 中引用
+// @Tags Attachment
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "附件"
+// @Success 201 {object} models.Response{data=[]models.Attachment}
+// @Failure 400 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/attachments [post]
+func (c *AttachmentController) Upload(ctx *web.Context) {
+	if err := ctx.Request.ParseMultipartForm(32 << 20); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.Fail(fmt.Sprintf("解析上传表单失败: %v", err)))
+		return
+	}
+	headers := ctx.Request.MultipartForm.File["file"]
+	if len(headers) == 0 {
+		ctx.JSON(http.StatusBadRequest, models.Fail("请选择要上传的文件"))
+		return
+	}
+
+	attachments := make([]models.Attachment, 0, len(headers))
+	for _, header := range headers {
+		src, err := header.Open()
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, models.Fail(err.Error()))
+			return
+		}
+
+		file, err := c.attachmentService.Save(header.Filename, src)
+		src.Close()
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, models.Fail(fmt.Sprintf("%s: %v", header.Filename, err)))
+			return
+		}
+		attachments = append(attachments, file.ToAttachment())
+	}
+
+	ctx.JSON(http.StatusCreated, models.Success(attachments))
+}
+
+// GetByID godoc
+// @Summary 获取附件信息
+// @Description 根据 ID 获取附件信息
+// @Tags Attachment
+// @Produce json
+// @Param id path string true "附件 ID"
+// @Success 200 {object} models.Response{data=models.Attachment}
+// @Failure 404 {object} models.Response
+// @Router /api/attachments/{id} [get]
+func (c *AttachmentController) GetByID(ctx *web.Context) {
+	file, err := c.attachmentService.GetByID(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, models.Fail("附件不存在"))
+		return
+	}
+	ctx.JSON(http.StatusOK, models.Success(file.ToAttachment()))
+}
+
+// Content godoc
+// @Summary 下载附件
+// @Description 获取附件的原始内容，图片和纯文本直接打开，其他类型作为下载返回
+// @Tags Attachment
+// @Produce octet-stream
+// @Param id path string true "附件 ID"
+// @Success 200 {file} file
+// @Failure 404 {object} models.Response
+// @Router /api/attachments/{id}/content [get]
+func (c *AttachmentController) Content(ctx *web.Context) {
+	file, err := c.attachmentService.GetByID(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, models.Fail("附件不存在"))
+		return
+	}
+
+	f, err := os.Open(c.attachmentService.Path(file))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, models.Fail("附件内容不存在"))
+		return
+	}
+	defer f.Close()
+
+	// 只有图片和纯文本在浏览器中直接打开，其他类型作为下载返回，并禁止浏览器猜测类型
+	disposition := "attachment"
+	if file.IsInline() {
+		disposition = "inline"
+	}
+	ctx.SetHeader("Content-Type", file.MimeType)
+	ctx.SetHeader("X-Content-Type-Options", "nosniff")
+	ctx.SetHeader("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": file.Name}))
+	http.ServeContent(ctx.Writer, ctx.Request, file.Name, file.CreatedAt, f)
+}
@@ -3,14 +3,18 @@ package controllers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	iano "iano_agent"
+	agentmodel "iano_agent/model"
 	"iano_server/models"
 	"iano_server/services"
 	web "iano_web"
+	"log/slog"
 	"net/http"
+	"strings"
+	"time"
 
-	"github.com/cloudwego/eino-ext/components/model/openai"
 	"github.com/cloudwego/eino/schema"
 )
 
@@ -18,23 +22,41 @@ type ChatController struct {
 	agentService        *services.AgentService
 	providerService     *services.ProviderService
 	messageService      *services.MessageService
+	sessionService      *services.SessionService
 	agentRuntimeService *services.AgentRuntimeService
 	agentSSEClientMap   *services.AgentSSEClientMap
+	summaryService      *services.SummaryService
+	approvalService     *services.ApprovalService
+	runRegistry         *services.RunRegistry
+	attachmentService   *services.AttachmentService
+	checkpointService   *services.CheckpointService
 }
 
 func NewChatController(
 	agentService *services.AgentService,
 	providerService *services.ProviderService,
 	messageService *services.MessageService,
+	sessionService *services.SessionService,
 	agentRuntimeService *services.AgentRuntimeService,
 	agentSSEClientMap *services.AgentSSEClientMap,
+	summaryService *services.SummaryService,
+	approvalService *services.ApprovalService,
+	runRegistry *services.RunRegistry,
+	attachmentService *services.AttachmentService,
+	checkpointService *services.CheckpointService,
 ) *ChatController {
 	return &ChatController{
 		agentService:        agentService,
 		providerService:     providerService,
 		messageService:      messageService,
+		sessionService:      sessionService,
 		agentRuntimeService: agentRuntimeService,
 		agentSSEClientMap:   agentSSEClientMap,
+		summaryService:      summaryService,
+		approvalService:     approvalService,
+		runRegistry:         runRegistry,
+		attachmentService:   attachmentService,
+		checkpointService:   checkpointService,
 	}
 }
 
@@ -53,10 +75,35 @@ type ChatResponse struct {
 }
 
 type StreamChatRequest struct {
-	SessionID string `json:"session_id" validate:"required" example:"session-001"`
-	AgentID   string `json:"agent_id" example:"default"`
-	Message   string `json:"message" validate:"required" example:"你好"`
-	WorkDir   string `json:"work_dir" example:"E:\\codes\\project"`
+	SessionID      string          `json:"session_id" validate:"required" example:"session-001"`
+	AgentID        string          `json:"agent_id" example:"default"`
+	Message        string          `json:"message" validate:"required" example:"你好"`
+	WorkDir        string          `json:"work_dir" example:"E:\\codes\\project"`
+	ResponseSchema json.RawMessage `json:"response_schema,omitempty" swaggertype:"object"`                          // 本次请求最终答复需要满足的 JSON Schema，优先于 Agent 配置
+	AttachmentIDs  []string        `json:"attachment_ids,omitempty" example:"3f0c6a1e-6a4b-4f7e-9d7a-0b6f1c2d3e4f"` // 通过 /api/attachments 上传的附件 ID
+	Budget         *RunBudget      `json:"budget,omitempty"`                                                        // 本次请求的运行预算，非零字段只能收紧 Agent 配置
+	UserID         string          `json:"user_id,omitempty" example:"alice"`                                       // 发起对话的用户，Agent 按用户区分记忆时使用
+}
+
+// RunBudget 请求级运行预算，字段为 0 时使用 Agent 配置
+type RunBudget struct {
+	MaxRounds    int   `json:"max_rounds,omitempty" example:"10"`      // 最多调用模型的轮数
+	MaxToolCalls int   `json:"max_tool_calls,omitempty" example:"5"`   // 最多执行的工具调用次数
+	TokenBudget  int64 `json:"token_budget,omitempty" example:"20000"` // Token 预算
+	Timeout      int   `json:"timeout,omitempty" example:"120"`        // 运行时长上限（秒）
+}
+
+// toRunBudget 转换为 Agent 的运行预算，未指定时返回 nil
+func (b *RunBudget) toRunBudget() *iano.RunBudget {
+	if b == nil {
+		return nil
+	}
+	return &iano.RunBudget{
+		MaxRounds:    b.MaxRounds,
+		MaxToolCalls: b.MaxToolCalls,
+		TokenBudget:  b.TokenBudget,
+		Timeout:      time.Duration(b.Timeout) * time.Second,
+	}
 }
 
 func (c *ChatController) chatWithProvider(ctx context.Context, message string) (string, error) {
@@ -66,11 +113,7 @@ func (c *ChatController) chatWithProvider(ctx context.Context, message string) (
 	}
 
 	provider := providers[0]
-	chatModel, err := openai.NewChatModel(ctx, &openai.ChatModelConfig{
-		BaseURL: provider.BaseUrl,
-		APIKey:  provider.ApiKey,
-		Model:   provider.Model,
-	})
+	chatModel, err := agentmodel.CreateModel(c.providerService.ModelConfig(&provider))
 	if err != nil {
 		return "", fmt.Errorf("创建 ChatModel 失败: %v", err)
 	}
@@ -106,12 +149,33 @@ func (c *ChatController) StreamChat(ctx *web.Context) {
 		return
 	}
 
+	responseSchema, err := requestResponseSchema(req.ResponseSchema)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.Fail(err.Error()))
+		return
+	}
+	files, err := c.attachmentService.GetByIDs(req.AttachmentIDs)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.Fail(err.Error()))
+		return
+	}
+
+	// 登记本次运行，以便通过 /api/chat/stop 停止
+	runCtx, run, err := c.runRegistry.Start(ctx.Request.Context(), req.SessionID)
+	if err != nil {
+		ctx.JSON(http.StatusConflict, models.Fail(err.Error()))
+		return
+	}
+	defer c.runRegistry.Finish(run)
+
 	sse, err := ctx.SSE()
 	if err != nil {
 		ctx.String(http.StatusInternalServerError, "SSE not supported: %v", err)
 		return
 	}
 
+	status := models.MessageStatusCompleted
+
 	// 检查会话是否存在
 	if !c.agentSSEClientMap.CheckSession(req.SessionID) {
 		c.agentSSEClientMap.CreateSession(req.SessionID)
@@ -136,6 +200,7 @@ func (c *ChatController) StreamChat(ctx *web.Context) {
 		if err == nil {
 			userMsg.Content = req.Message
 		}
+		attachFiles(userMsg, req.Message, files)
 		c.messageService.Create(userMsg)
 
 		// 创建助手消息
@@ -146,13 +211,15 @@ func (c *ChatController) StreamChat(ctx *web.Context) {
 		}
 		assistantMsg.NewID()
 		c.messageService.Create(assistantMsg)
+		run.SetMessageID(assistantMsg.ID)
 
 		sse.EmitDataToID(req.SessionID, models.MessageEventCreated.ToString(), map[string]interface{}{
-			"type":       models.MessageTypeUser.ToString(),
-			"id":         userMsg.ID,
-			"session_id": userMsg.SessionID,
-			"content":    userMsg.Content,
-			"created_at": userMsg.CreatedAt,
+			"type":        models.MessageTypeUser.ToString(),
+			"id":          userMsg.ID,
+			"session_id":  userMsg.SessionID,
+			"content":     userMsg.Content,
+			"attachments": userMsg.GetAttachments(),
+			"created_at":  userMsg.CreatedAt,
 		})
 
 		sse.EmitDataToID(req.SessionID, models.MessageEventCreated.ToString(), map[string]interface{}{
@@ -173,52 +240,39 @@ func (c *ChatController) StreamChat(ctx *web.Context) {
 
 		// 获取 Agent 实例
 		agentParams := &services.AgentParams{
-			AgentID:  agentID,
-			WorkDir:  req.WorkDir,
-			Callback: Callback(req.SessionID, sse, c.messageService, assistantMsg.ID, &accumulatedContent),
+			AgentID:         agentID,
+			WorkDir:         req.WorkDir,
+			UserID:          req.UserID,
+			SessionID:       req.SessionID,
+			Callback:        Callback(req.SessionID, sse, c.messageService, assistantMsg.ID, &accumulatedContent),
+			ApprovalHandler: c.approvalHandler(req.SessionID, sse),
 		}
-		agent, err := c.agentRuntimeService.GetAgent(ctx.Request.Context(), agentParams)
+		agent, err := c.agentRuntimeService.GetAgent(runCtx, agentParams)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, models.Fail(err.Error()))
+			c.failStream(sse, req.SessionID, assistantMsg.ID, err)
 			return
 		}
+		agent.Agent.SetResponseSchema(responseSchema)
+		agent.Agent.SetRunBudget(req.Budget.toRunBudget())
+		agent.Agent.SetPromptVars(c.promptVars(ctx, req.SessionID))
+		agent.Agent.SetCheckpointHandler(c.checkpointService.Handler(req.SessionID, assistantMsg.ID))
+		agent.Agent.SetArtifactSaver(c.attachmentService.SaveArtifact)
 		c.agentSSEClientMap.AddAgent(req.SessionID, agent)
 
-		// 从数据库加载历史消息
-		historyMessages, err := c.messageService.GetBySessionID(req.SessionID)
+		chatMessages, err := c.loadHistory(runCtx, sse, req.SessionID, agent)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, models.Fail(err.Error()))
+			c.failStream(sse, req.SessionID, assistantMsg.ID, err)
 			return
 		}
 
-		// 转换为 schema.Message 格式
-		chatMessages := make([]*schema.Message, 0, len(historyMessages))
-		for _, msg := range historyMessages {
-			switch msg.Type {
-			case models.MessageTypeUser:
-				chatMessages = append(chatMessages, schema.UserMessage(msg.Content))
-			case models.MessageTypeAssistant:
-				chatMessages = append(chatMessages, schema.AssistantMessage(msg.Content, nil))
-			case models.MessageTypeTool:
-				chatMessages = append(chatMessages, schema.ToolMessage(msg.Content, msg.Content))
-			case models.MessageTypeSystem:
-				chatMessages = append(chatMessages, schema.SystemMessage(msg.Content))
-			}
-		}
-
 		// 调用 Agent 进行聊天
-		_, err = agent.Chat(ctx.Request.Context(), chatMessages)
-		if err != nil {
-			errSend := models.CreateErrCompleted(req.SessionID, models.MessageStatusFailed, err.Error())
-			sse.EmitDataToID(req.SessionID, models.MessageEventCompleted.ToString(), errSend)
-		}
-
-		sse.EmitDataToID(req.SessionID, models.MessageEventCompleted.ToString(), map[string]string{"status": "completed"})
+		_, err = agent.Chat(runCtx, chatMessages)
+		status = c.completeRun(sse, req.SessionID, assistantMsg.ID, agent, run, err)
 	} else {
 		// Agent 已绑定，继续聊天
 		agentHolder := c.agentSSEClientMap.GetSessionAgent(req.SessionID)
 		if agentHolder == nil {
-			ctx.JSON(http.StatusInternalServerError, models.Fail("会话不存在"))
+			c.failStream(sse, req.SessionID, "", errors.New("会话不存在"))
 			return
 		}
 
@@ -230,6 +284,7 @@ func (c *ChatController) StreamChat(ctx *web.Context) {
 		}
 		userMsg.NewID()
 		userMsg.Content = req.Message
+		attachFiles(userMsg, req.Message, files)
 		c.messageService.Create(userMsg)
 
 		// 创建助手消息
@@ -240,13 +295,15 @@ func (c *ChatController) StreamChat(ctx *web.Context) {
 		}
 		assistantMsg.NewID()
 		c.messageService.Create(assistantMsg)
+		run.SetMessageID(assistantMsg.ID)
 
 		sse.EmitDataToID(req.SessionID, models.MessageEventCreated.ToString(), map[string]interface{}{
-			"type":       models.MessageTypeUser.ToString(),
-			"id":         userMsg.ID,
-			"session_id": userMsg.SessionID,
-			"content":    userMsg.Content,
-			"created_at": userMsg.CreatedAt,
+			"type":        models.MessageTypeUser.ToString(),
+			"id":          userMsg.ID,
+			"session_id":  userMsg.SessionID,
+			"content":     userMsg.Content,
+			"attachments": userMsg.GetAttachments(),
+			"created_at":  userMsg.CreatedAt,
 		})
 
 		sse.EmitDataToID(req.SessionID, models.MessageEventCreated.ToString(), map[string]interface{}{
@@ -267,26 +324,281 @@ func (c *ChatController) StreamChat(ctx *web.Context) {
 
 		// 添加回调
 		agentHolder.Agent.AppendCB(Callback(req.SessionID, sse, c.messageService, assistantMsg.ID, &accumulatedContent))
+		// 审批事件需要发送到当前连接
+		agentHolder.Agent.SetApprovalHandler(c.approvalHandler(req.SessionID, sse))
+		// 请求级 Schema 只对本次请求生效，未指定时恢复为 Agent 配置
+		agentHolder.Agent.SetResponseSchema(responseSchema)
+		agentHolder.Agent.SetRunBudget(req.Budget.toRunBudget())
+		agentHolder.Agent.SetPromptVars(c.promptVars(ctx, req.SessionID))
+		// 本次请求修改的文件记录到当前助手消息的检查点
+		agentHolder.Agent.SetCheckpointHandler(c.checkpointService.Handler(req.SessionID, assistantMsg.ID))
+		// 命令输出被截断时完整日志保存为附件供下载
+		agentHolder.Agent.SetArtifactSaver(c.attachmentService.SaveArtifact)
+
+		// 每轮都带上历史和摘要发送给 Agent
+		chatMessages, err := c.loadHistory(runCtx, sse, req.SessionID, agentHolder)
+		if err != nil {
+			c.failStream(sse, req.SessionID, assistantMsg.ID, err)
+			return
+		}
+		_, err = agentHolder.Chat(runCtx, chatMessages)
+		status = c.completeRun(sse, req.SessionID, assistantMsg.ID, agentHolder, run, err)
+	}
+
+	sse.EmitDataToID(req.SessionID, models.MessageEventDone.ToString(), map[string]string{"status": status.ToString()})
+	sse.Close()
+}
+
+// loadHistory 从数据库加载历史消息，超出保留轮数的部分压缩为摘要，并推送新生成的摘要
+func (c *ChatController) loadHistory(ctx context.Context, sse *web.SSEContext, sessionID string, agent *services.AgentWrapper) ([]*schema.Message, error) {
+	chatMessages, summaryMsg, err := c.summaryService.BuildHistory(ctx, sessionID, agent.Agent, c.supportsVision(agent))
+	if err != nil {
+		return nil, err
+	}
 
-		// 发送消息给 Agent
-		chatMessages := []*schema.Message{
-			schema.UserMessage(req.Message),
+	if summaryMsg != nil {
+		content, _ := summaryMsg.GetContent()
+		sse.EmitDataToID(sessionID, models.MessageEventSummary.ToString(), map[string]interface{}{
+			"id":             summaryMsg.ID,
+			"session_id":     summaryMsg.SessionID,
+			"rounds":         content.Summary.Rounds,
+			"old_tokens":     content.Summary.OldTokens,
+			"summary_tokens": content.Summary.SummaryTokens,
+			"saved_tokens":   content.Summary.SavedTokens,
+			"created_at":     summaryMsg.CreatedAt,
+		})
+	}
+	return chatMessages, nil
+}
+
+// promptVars 本次请求的提示词变量，包括会话标题、语言区域和会话自定义变量
+func (c *ChatController) promptVars(ctx *web.Context, sessionID string) *iano.PromptVars {
+	vars := &iano.PromptVars{}
+	if session, err := c.sessionService.GetByID(sessionID); err == nil {
+		vars.SessionTitle = session.Title
+		if cfg, err := session.GetConfig(); err == nil {
+			vars.Locale = cfg.Locale
+			vars.Vars = cfg.PromptVars
 		}
-		_, err := agentHolder.Agent.Chat(ctx.Request.Context(), chatMessages)
-		if err != nil {
-			errSend := models.CreateErrCompleted(req.SessionID, models.MessageStatusFailed, err.Error())
-			sse.EmitDataToID(req.SessionID, models.MessageEventCompleted.ToString(), errSend)
+	}
+	if vars.Locale == "" {
+		vars.Locale = acceptLanguage(ctx.GetHeader("Accept-Language"))
+	}
+	return vars
+}
+
+// acceptLanguage 取 Accept-Language 中的首选语言，例如 "zh-CN,zh;q=0.9" 返回 zh-CN
+func acceptLanguage(header string) string {
+	lang, _, _ := strings.Cut(header, ",")
+	lang, _, _ = strings.Cut(lang, ";")
+	return strings.TrimSpace(lang)
+}
+
+// failStream SSE 响应开始后无法再返回 HTTP 错误，改为推送 error 事件并将助手消息标记为失败
+func (c *ChatController) failStream(sse *web.SSEContext, sessionID, assistantMsgID string, err error) {
+	if assistantMsgID != "" {
+		if _, updateErr := c.messageService.Update(assistantMsgID, map[string]interface{}{
+			"status": models.MessageStatusFailed.ToString(),
+		}); updateErr != nil {
+			slog.Warn("保存失败的消息状态失败", "messageID", assistantMsgID, "error", updateErr)
 		}
+	}
+	sse.EmitDataToID(sessionID, models.MessageEventError.ToString(), map[string]string{"error": err.Error()})
+}
 
-		sse.EmitDataToID(req.SessionID, models.MessageEventCompleted.ToString(), map[string]string{"status": "completed"})
+// completeRun 记录用量并发送消息完成事件，返回本次运行的最终状态
+//
+// 手动停止时已生成的内容已由回调实时写入，这里只将助手消息标记为 stopped。
+func (c *ChatController) completeRun(sse *web.SSEContext, sessionID, assistantMsgID string, agent *services.AgentWrapper, run *services.ChatRun, err error) models.MessageStatus {
+	usage := c.recordUsage(sessionID, assistantMsgID, agent.Agent.GetRunUsage())
+
+	status := models.MessageStatusCompleted
+	var stopped *iano.RunStopError
+	switch {
+	case run.Stopped():
+		status = models.MessageStatusStopped
+		if _, err := c.messageService.Update(assistantMsgID, map[string]interface{}{
+			"status": status.ToString(),
+		}); err != nil {
+			slog.Warn("保存已停止的消息失败", "messageID", assistantMsgID, "error", err)
+		}
+	case errors.As(err, &stopped):
+		// 达到预算上限属于正常结束，已生成的内容和结束说明已由回调写入
+		slog.Info("运行因预算限制提前结束", "messageID", assistantMsgID, "reason", stopped.Reason)
+	case err != nil:
+		errSend := models.CreateErrCompleted(sessionID, models.MessageStatusFailed, err.Error())
+		sse.EmitDataToID(sessionID, models.MessageEventCompleted.ToString(), errSend)
 	}
 
-	sse.EmitDataToID(req.SessionID, models.MessageEventDone.ToString(), map[string]string{"status": "completed"})
-	sse.Close()
+	completed := map[string]interface{}{
+		"id":       assistantMsgID,
+		"status":   status.ToString(),
+		"usage":    usage,
+		"provider": c.answeredProvider(agent),
+	}
+	if structured := agent.Agent.GetStructuredOutput(); structured != nil && status == models.MessageStatusCompleted {
+		c.updateMessageContent(assistantMsgID, func(content *models.MessageContent) {
+			content.Structured = structured
+		})
+		completed["structured"] = structured
+	}
+	if citations := services.ToCitations(agent.Agent.GetCitations()); len(citations) > 0 {
+		// 检索到的知识库分块作为引用块保存，编号与答复中的 [n] 对应
+		c.updateMessageContent(assistantMsgID, func(content *models.MessageContent) {
+			for i := range citations {
+				content.Blocks = append(content.Blocks, models.ContentBlock{Type: "citation", Citation: &citations[i]})
+			}
+		})
+		completed["citations"] = citations
+	}
+	if stopped != nil && status == models.MessageStatusCompleted {
+		c.updateMessageContent(assistantMsgID, func(content *models.MessageContent) {
+			content.StopReason = string(stopped.Reason)
+		})
+		completed["stop_reason"] = stopped.Reason
+		completed["stop_detail"] = stopped.Detail
+	}
+	sse.EmitDataToID(sessionID, models.MessageEventCompleted.ToString(), completed)
+	return status
+}
+
+// attachFiles 将附件信息写入用户消息，没有附件时保持纯文本内容
+func attachFiles(userMsg *models.Message, text string, files []models.File) {
+	if len(files) == 0 {
+		return
+	}
+
+	content := &models.MessageContent{Text: text}
+	for i := range files {
+		content.Attachments = append(content.Attachments, files[i].ToAttachment())
+	}
+	if err := userMsg.SetContent(content); err != nil {
+		slog.Warn("保存消息附件失败", "messageID", userMsg.ID, "error", err)
+	}
+}
+
+// supportsVision Agent 的首选提供商是否支持图片输入，支持时历史中的图片附件以多模态消息发送
+func (c *ChatController) supportsVision(agent *services.AgentWrapper) bool {
+	provider, err := c.providerService.GetByID(agent.ProviderID)
+	return err == nil && provider.SupportsVision
+}
+
+// requestResponseSchema 解析请求级响应 Schema，未指定时返回 nil
+func requestResponseSchema(raw json.RawMessage) (*iano.ResponseSchema, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	return iano.ParseResponseSchema(raw)
+}
+
+// updateMessageContent 修改助手消息的结构化内容并保存
+func (c *ChatController) updateMessageContent(assistantMsgID string, update func(content *models.MessageContent)) {
+	msg, err := c.messageService.GetByID(assistantMsgID)
+	if err != nil {
+		slog.Warn("保存消息内容失败", "messageID", assistantMsgID, "error", err)
+		return
+	}
+
+	content, err := msg.GetContent()
+	if err != nil {
+		content = &models.MessageContent{Text: msg.Content}
+	}
+	update(content)
+	if err := msg.SetContent(content); err != nil {
+		slog.Warn("保存消息内容失败", "messageID", assistantMsgID, "error", err)
+		return
+	}
+
+	if _, err := c.messageService.Update(assistantMsgID, map[string]interface{}{
+		"content": msg.Content,
+	}); err != nil {
+		slog.Warn("保存消息内容失败", "messageID", assistantMsgID, "error", err)
+	}
+}
+
+// recordUsage 将本次运行的 Token 用量写入助手消息和会话
+func (c *ChatController) recordUsage(sessionID, assistantMsgID string, runUsage *iano.TokenUsage) *models.MessageUsage {
+	usage := &models.MessageUsage{
+		InputTokens:     int(runUsage.PromptTokens),
+		OutputTokens:    int(runUsage.CompletionTokens),
+		TotalTokens:     int(runUsage.TotalTokens),
+		CachedTokens:    int(runUsage.CachedTokens),
+		ReasoningTokens: int(runUsage.ReasoningTokens),
+	}
+
+	if _, err := c.messageService.Update(assistantMsgID, map[string]interface{}{
+		"input_tokens":  usage.InputTokens,
+		"output_tokens": usage.OutputTokens,
+	}); err != nil {
+		slog.Warn("保存消息 Token 用量失败", "messageID", assistantMsgID, "error", err)
+	}
+
+	if usage.TotalTokens > 0 {
+		if err := c.sessionService.AddTokens(sessionID, usage.TotalTokens); err != nil {
+			slog.Warn("更新会话 Token 用量失败", "sessionID", sessionID, "error", err)
+		}
+	}
+
+	return usage
+}
+
+// answeredProvider 本次对话实际响应的提供商，发生故障转移时与 Agent 配置的首选提供商不同
+func (c *ChatController) answeredProvider(agent *services.AgentWrapper) map[string]interface{} {
+	providerID := agent.AnsweredProviderID()
+	result := map[string]interface{}{
+		"id":          providerID,
+		"is_fallback": providerID != agent.ProviderID,
+	}
+	if provider, err := c.providerService.GetByID(providerID); err == nil {
+		result["name"] = provider.Name
+		result["model"] = provider.Model
+	}
+	if providerID != agent.ProviderID {
+		slog.Info("本次对话由备用提供商响应", "primary", agent.ProviderID, "answered", providerID)
+	}
+	return result
+}
+
+// approvalHandler 创建工具调用审批处理函数：推送 tool_approval_required 事件后等待 /api/chat/approve
+func (c *ChatController) approvalHandler(sessionID string, sse *web.SSEContext) iano.ApprovalHandler {
+	return func(ctx context.Context, req *iano.ApprovalRequest) (*iano.ApprovalDecision, error) {
+		pending := c.approvalService.Register(sessionID, req)
+		sse.EmitDataToID(sessionID, models.MessageEventToolApproval.ToString(), pending)
+
+		decision, err := c.approvalService.Wait(ctx, pending)
+
+		resolved := map[string]interface{}{
+			"id":           pending.ID,
+			"session_id":   sessionID,
+			"tool_call_id": pending.ToolCallID,
+			"approved":     false,
+		}
+		if decision != nil {
+			resolved["approved"] = decision.Approved
+			resolved["reason"] = decision.Reason
+		} else if errors.Is(err, context.DeadlineExceeded) {
+			resolved["reason"] = "审批超时"
+		}
+		sse.EmitDataToID(sessionID, models.MessageEventToolApprovalResolved.ToString(), resolved)
+
+		return decision, err
+	}
 }
 
 func Callback(sessionID string, sse *web.SSEContext, messageService *services.MessageService, assistantMsgID string, accumulatedContent *map[string]interface{}) func(msg *iano.Message) {
 	return func(msg *iano.Message) {
+		// 工具执行过程中的输出只推送给前端，不计入回复内容
+		if msg.Progress != nil {
+			sse.EmitDataToID(sessionID, models.MessageEventToolProgress.ToString(), msg)
+			return
+		}
+
+		// 子 Agent 的中间消息单独推送，不计入主 Agent 的回复内容
+		if msg.Depth > 0 {
+			sse.EmitDataToID(sessionID, models.MessageEventSubAgent.ToString(), msg)
+			return
+		}
+
 		// 更新累积的内容
 		if accumulatedContent != nil {
 			content := *accumulatedContent
@@ -357,3 +669,81 @@ func (c *ChatController) ClearSession(ctx *web.Context) {
 
 	ctx.JSON(http.StatusOK, models.Success(map[string]string{"message": "Session cleared successfully"}))
 }
+
+type ApproveToolCallRequest struct {
+	ID       string `json:"id" validate:"required" example:"3f0c6a1e-6a4b-4f7e-9d7a-0b6f1c2d3e4f"` // tool_approval_required 事件中的审批 ID
+	Approved bool   `json:"approved" example:"true"`                                               // 是否允许执行
+	Reason   string `json:"reason" example:"不允许删除该文件"`                                             // 拒绝原因，会返回给模型
+}
+
+// ApproveToolCall godoc
+// @Summary 审批工具调用
+// @Description 对等待审批的工具调用作出决定，超时未审批的调用会被自动拒绝
+// @Tags Chat
+// @Accept json
+// @Produce json
+// @Param request body ApproveToolCallRequest true "审批结果"
+// @Success 200 {object} models.Response{data=services.PendingApproval}
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Router /api/chat/approve [post]
+func (c *ChatController) ApproveToolCall(ctx *web.Context) {
+	var req ApproveToolCallRequest
+	if err := ctx.BindAndValidate(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.Fail(err.Error()))
+		return
+	}
+
+	pending, err := c.approvalService.Resolve(req.ID, req.Approved, req.Reason)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, models.Fail(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.Success(pending))
+}
+
+// GetPendingApprovals godoc
+// @Summary 获取等待审批的工具调用
+// @Description 获取指定会话中等待审批的工具调用，用于客户端重连后恢复审批界面
+// @Tags Chat
+// @Produce json
+// @Param session_id path string true "会话 ID"
+// @Success 200 {object} models.Response{data=[]services.PendingApproval}
+// @Router /api/chat/approvals/{session_id} [get]
+func (c *ChatController) GetPendingApprovals(ctx *web.Context) {
+	sessionID := ctx.Param("session_id")
+	ctx.JSON(http.StatusOK, models.Success(c.approvalService.ListBySession(sessionID)))
+}
+
+type StopChatRequest struct {
+	SessionID string `json:"session_id" validate:"required" example:"session-001"`
+}
+
+// StopChat godoc
+// @Summary 停止对话
+// @Description 停止会话中正在执行的对话：取消模型请求和正在执行的命令，已生成的内容以 stopped 状态保存
+// @Tags Chat
+// @Accept json
+// @Produce json
+// @Param request body StopChatRequest true "会话"
+// @Success 200 {object} models.Response{data=services.ChatRun}
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Router /api/chat/stop [post]
+func (c *ChatController) StopChat(ctx *web.Context) {
+	var req StopChatRequest
+	if err := ctx.BindAndValidate(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.Fail(err.Error()))
+		return
+	}
+
+	run, err := c.runRegistry.Stop(req.SessionID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, models.Fail(err.Error()))
+		return
+	}
+	slog.Info("对话已被手动停止", "sessionID", req.SessionID, "runID", run.ID, "messageID", run.MessageID())
+
+	ctx.JSON(http.StatusOK, models.Success(run))
+}
@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"iano_server/models"
+	"iano_server/services"
+	web "iano_web"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+// CheckpointController 工作区检查点接口，用于查看 Agent 对文件的修改并撤销
+type CheckpointController struct {
+	checkpointService *services.CheckpointService
+}
+
+func NewCheckpointController(checkpointService *services.CheckpointService) *CheckpointController {
+	return &CheckpointController{
+		checkpointService: checkpointService,
+	}
+}
+
+// RestoreCheckpointRequest 恢复检查点请求
+type RestoreCheckpointRequest struct {
+	Scope string `json:"scope" example:"message"` // message 撤销该消息中的修改，after 撤销该消息之后所有消息中的修改，默认为 message
+}
+
+// List godoc
+// @Summary 获取会话的检查点
+// @Description 按消息分组返回 Agent 修改文件前保存的检查点，先修改的消息在前
+// @Tags Checkpoint
+// @Produce json
+// @Param id path string true "会话 ID"
+// @Success 200 {object} models.Response{data=[]services.CheckpointGroup}
+// @Failure 500 {object} models.Response
+// @Router /api/sessions/{id}/checkpoints [get]
+func (c *CheckpointController) List(ctx *web.Context) {
+	groups, err := c.checkpointService.List(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, models.Success(groups))
+}
+
+// Diff godoc
+// @Summary 查看检查点与当前文件的差异
+// @Description 比较消息修改前的文件内容与当前内容，scope=after 时比较该消息之后所有消息修改的文件
+// @Tags Checkpoint
+// @Produce json
+// @Param id path string true "会话 ID"
+// @Param message_id path string true "消息 ID"
+// @Param scope query string false "范围 message/after，默认为 message"
+// @Success 200 {object} models.Response{data=[]services.CheckpointDiff}
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/sessions/{id}/checkpoints/{message_id}/diff [get]
+func (c *CheckpointController) Diff(ctx *web.Context) {
+	scope := ctx.Query("scope")
+	if !models.IsValidCheckpointScope(scope) {
+		ctx.JSON(http.StatusBadRequest, models.Fail(fmt.Sprintf("无效的恢复范围: %s", scope)))
+		return
+	}
+
+	diffs, err := c.checkpointService.Diff(ctx.Param("id"), ctx.Param("message_id"), scope)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, models.Fail("Message not found"))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, models.Success(diffs))
+}
+
+// Restore godoc
+// @Summary 恢复检查点
+// @Description 将文件恢复为消息修改前的内容，修改前不存在的文件会被删除；scope=after 时撤销该消息之后所有消息中的修改
+// @Tags Checkpoint
+// @Accept json
+// @Produce json
+// @Param id path string true "会话 ID"
+// @Param message_id path string true "消息 ID"
+// @Param request body RestoreCheckpointRequest false "恢复范围"
+// @Success 200 {object} models.Response{data=services.CheckpointRestoreResult}
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/sessions/{id}/checkpoints/{message_id}/restore [post]
+func (c *CheckpointController) Restore(ctx *web.Context) {
+	var req RestoreCheckpointRequest
+	if len(ctx.Body()) > 0 {
+		if err := ctx.Bind(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, models.Fail(err.Error()))
+			return
+		}
+	}
+	if !models.IsValidCheckpointScope(req.Scope) {
+		ctx.JSON(http.StatusBadRequest, models.Fail(fmt.Sprintf("无效的恢复范围: %s", req.Scope)))
+		return
+	}
+
+	result, err := c.checkpointService.Restore(ctx.Param("id"), ctx.Param("message_id"), req.Scope)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, models.Fail("Message not found"))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, models.Success(result))
+}
@@ -0,0 +1,361 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"iano_server/models"
+	"iano_server/services"
+	web "iano_web"
+	"net/http"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+// maxChunkSize 知识库分块大小的上限（字符数）
+const maxChunkSize = 8000
+
+// KnowledgeController 知识库接口
+type KnowledgeController struct {
+	knowledgeService *services.KnowledgeService
+	providerService  *services.ProviderService
+}
+
+func NewKnowledgeController(knowledgeService *services.KnowledgeService, providerService *services.ProviderService) *KnowledgeController {
+	return &KnowledgeController{
+		knowledgeService: knowledgeService,
+		providerService:  providerService,
+	}
+}
+
+type CreateKnowledgeBaseRequest struct {
+	Name                string `json:"name" validate:"required" example:"运维手册"`          // 名称
+	Description         string `json:"description" example:"值班和故障处理文档"`                  // 描述
+	EmbeddingProviderID string `json:"embedding_provider_id" example:"provider-001"`     // 向量模型提供商，为空时只使用全文检索
+	EmbeddingModel      string `json:"embedding_model" example:"text-embedding-3-small"` // 向量模型名称，指定提供商时必填
+	ChunkSize           int    `json:"chunk_size" example:"800"`                         // 分块的最大字符数，0 表示使用默认值
+	ChunkOverlap        int    `json:"chunk_overlap" example:"100"`                      // 相邻分块重叠的字符数
+}
+
+type UpdateKnowledgeBaseRequest struct {
+	Name                *string `json:"name,omitempty" example:"运维手册"`                          // 名称
+	Description         *string `json:"description,omitempty" example:"值班和故障处理文档"`              // 描述
+	EmbeddingProviderID *string `json:"embedding_provider_id,omitempty" example:"provider-001"` // 向量模型提供商，更换后需要重新导入文档
+	EmbeddingModel      *string `json:"embedding_model,omitempty" example:"bge-m3"`             // 向量模型名称
+	ChunkSize           *int    `json:"chunk_size,omitempty" example:"800"`                     // 分块的最大字符数，对之后导入的文档生效
+	ChunkOverlap        *int    `json:"chunk_overlap,omitempty" example:"100"`                  // 相邻分块重叠的字符数
+}
+
+type IngestKnowledgeRequest struct {
+	AttachmentIDs []string `json:"attachment_ids,omitempty" example:"file-001"`      // 已上传的附件 ID
+	Path          string   `json:"path,omitempty" example:"/data/project/docs"`      // 服务器上的文件或目录（绝对路径），例如 Agent 的工作目录，目录下支持的文件递归导入
+	Title         string   `json:"title,omitempty" example:"值班手册"`                   // 直接导入的文本标题
+	Content       string   `json:"content,omitempty" example:"# 值班手册\n\n告警先确认影响范围。"` // 直接导入的 Markdown 文本
+}
+
+// validateEmbedding 校验向量模型配置
+func (c *KnowledgeController) validateEmbedding(providerID, modelName string) error {
+	if providerID == "" {
+		return nil
+	}
+	if modelName == "" {
+		return fmt.Errorf("指定向量模型提供商时必须填写向量模型名称")
+	}
+	if _, err := c.providerService.GetByID(providerID); err != nil {
+		return fmt.Errorf("向量模型提供商 %s 不存在", providerID)
+	}
+	return nil
+}
+
+// validateChunking 校验分块参数
+func validateChunking(size, overlap int) error {
+	if size < 0 || size > maxChunkSize {
+		return fmt.Errorf("分块大小必须在 0 到 %d 之间", maxChunkSize)
+	}
+	if overlap < 0 {
+		return fmt.Errorf("分块重叠不能为负数")
+	}
+	return nil
+}
+
+// Create godoc
+// @Summary 创建知识库
+// @Description 创建知识库，可以指定向量模型以启用向量检索
+// @Tags Knowledge
+// @Accept json
+// @Produce json
+// @Param knowledge_base body CreateKnowledgeBaseRequest true "知识库信息"
+// @Success 201 {object} models.Response{data=models.KnowledgeBase}
+// @Failure 400 {object} models.Response
+// @Router /api/knowledge-bases [post]
+func (c *KnowledgeController) Create(ctx *web.Context) {
+	var req CreateKnowledgeBaseRequest
+	if err := ctx.BindAndValidate(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.Fail(err.Error()))
+		return
+	}
+	if err := c.validateEmbedding(req.EmbeddingProviderID, req.EmbeddingModel); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.Fail(err.Error()))
+		return
+	}
+	if err := validateChunking(req.ChunkSize, req.ChunkOverlap); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.Fail(err.Error()))
+		return
+	}
+
+	kb := &models.KnowledgeBase{
+		Name:                req.Name,
+		Description:         req.Description,
+		EmbeddingProviderID: req.EmbeddingProviderID,
+		EmbeddingModel:      req.EmbeddingModel,
+		ChunkSize:           req.ChunkSize,
+		ChunkOverlap:        req.ChunkOverlap,
+	}
+	kb.NewID()
+	if err := c.knowledgeService.Create(kb); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.Fail(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, models.Success(kb))
+}
+
+// GetAll godoc
+// @Summary 获取知识库列表
+// @Description 获取全部知识库及其文档数量
+// @Tags Knowledge
+// @Produce json
+// @Success 200 {object} models.Response{data=[]models.KnowledgeBase}
+// @Failure 500 {object} models.Response
+// @Router /api/knowledge-bases [get]
+func (c *KnowledgeController) GetAll(ctx *web.Context) {
+	kbs, err := c.knowledgeService.GetAll()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, models.Success(kbs))
+}
+
+// GetByID godoc
+// @Summary 获取知识库详情
+// @Description 根据 ID 获取知识库
+// @Tags Knowledge
+// @Produce json
+// @Param id path string true "知识库 ID"
+// @Success 200 {object} models.Response{data=models.KnowledgeBase}
+// @Failure 404 {object} models.Response
+// @Router /api/knowledge-bases/{id} [get]
+func (c *KnowledgeController) GetByID(ctx *web.Context) {
+	kb, err := c.knowledgeService.GetByID(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, models.Fail("Knowledge base not found"))
+		return
+	}
+	ctx.JSON(http.StatusOK, models.Success(kb))
+}
+
+// Update godoc
+// @Summary 更新知识库
+// @Description 更新知识库配置，更换向量模型后已有文档只使用全文检索，重新导入后生成新的向量
+// @Tags Knowledge
+// @Accept json
+// @Produce json
+// @Param id path string true "知识库 ID"
+// @Param knowledge_base body UpdateKnowledgeBaseRequest true "更新内容"
+// @Success 200 {object} models.Response{data=models.KnowledgeBase}
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Router /api/knowledge-bases/{id} [put]
+func (c *KnowledgeController) Update(ctx *web.Context) {
+	kb, err := c.knowledgeService.GetByID(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, models.Fail("Knowledge base not found"))
+		return
+	}
+
+	var req UpdateKnowledgeBaseRequest
+	if err := ctx.Bind(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.Fail(err.Error()))
+		return
+	}
+
+	providerID, modelName := kb.EmbeddingProviderID, kb.EmbeddingModel
+	size, overlap := kb.ChunkSize, kb.ChunkOverlap
+	updates := make(map[string]interface{})
+	if req.Name != nil {
+		if *req.Name == "" {
+			ctx.JSON(http.StatusBadRequest, models.Fail("知识库名称不能为空"))
+			return
+		}
+		updates["name"] = *req.Name
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if req.EmbeddingProviderID != nil {
+		providerID = *req.EmbeddingProviderID
+		updates["embedding_provider_id"] = providerID
+	}
+	if req.EmbeddingModel != nil {
+		modelName = *req.EmbeddingModel
+		updates["embedding_model"] = modelName
+	}
+	if req.ChunkSize != nil {
+		size = *req.ChunkSize
+		updates["chunk_size"] = size
+	}
+	if req.ChunkOverlap != nil {
+		overlap = *req.ChunkOverlap
+		updates["chunk_overlap"] = overlap
+	}
+	if err := c.validateEmbedding(providerID, modelName); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.Fail(err.Error()))
+		return
+	}
+	if err := validateChunking(size, overlap); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.Fail(err.Error()))
+		return
+	}
+
+	kb, err = c.knowledgeService.Update(kb.ID, updates)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, models.Success(kb))
+}
+
+// Delete godoc
+// @Summary 删除知识库
+// @Description 删除知识库及其全部文档和索引
+// @Tags Knowledge
+// @Produce json
+// @Param id path string true "知识库 ID"
+// @Success 200 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/knowledge-bases/{id} [delete]
+func (c *KnowledgeController) Delete(ctx *web.Context) {
+	err := c.knowledgeService.Delete(ctx.Param("id"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, models.Fail("Knowledge base not found"))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, models.Success(map[string]string{"message": "Knowledge base deleted successfully"}))
+}
+
+// ListDocuments godoc
+// @Summary 获取知识库文档列表
+// @Description 获取知识库中的文档及其导入状态，新导入的在前
+// @Tags Knowledge
+// @Produce json
+// @Param id path string true "知识库 ID"
+// @Success 200 {object} models.Response{data=[]models.KnowledgeDocument}
+// @Failure 500 {object} models.Response
+// @Router /api/knowledge-bases/{id}/documents [get]
+func (c *KnowledgeController) ListDocuments(ctx *web.Context) {
+	docs, err := c.knowledgeService.ListDocuments(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, models.Success(docs))
+}
+
+// Ingest godoc
+// @Summary 导入文档
+// @Description 从附件、服务器路径或文本导入 Markdown、文本、HTML 和 PDF 文档，提取失败的文件记录为 failed 状态
+// @Tags Knowledge
+// @Accept json
+// @Produce json
+// @Param id path string true "知识库 ID"
+// @Param request body IngestKnowledgeRequest true "导入来源"
+// @Success 200 {object} models.Response{data=[]models.KnowledgeDocument}
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Router /api/knowledge-bases/{id}/documents [post]
+func (c *KnowledgeController) Ingest(ctx *web.Context) {
+	var req IngestKnowledgeRequest
+	if err := ctx.Bind(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.Fail(err.Error()))
+		return
+	}
+
+	docs, err := c.knowledgeService.Ingest(ctx.Request.Context(), ctx.Param("id"), &services.IngestRequest{
+		AttachmentIDs: req.AttachmentIDs,
+		Path:          req.Path,
+		Title:         req.Title,
+		Content:       req.Content,
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, models.Fail("Knowledge base not found"))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, models.Success(docs))
+}
+
+// DeleteDocument godoc
+// @Summary 删除知识库文档
+// @Description 删除文档及其分块和索引
+// @Tags Knowledge
+// @Produce json
+// @Param id path string true "知识库 ID"
+// @Param doc_id path string true "文档 ID"
+// @Success 200 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/knowledge-bases/{id}/documents/{doc_id} [delete]
+func (c *KnowledgeController) DeleteDocument(ctx *web.Context) {
+	err := c.knowledgeService.DeleteDocument(ctx.Param("id"), ctx.Param("doc_id"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, models.Fail("Document not found"))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, models.Success(map[string]string{"message": "Document deleted successfully"}))
+}
+
+// Search godoc
+// @Summary 检索知识库
+// @Description 按与 kb_search 工具相同的方式检索知识库，用于调试检索效果
+// @Tags Knowledge
+// @Produce json
+// @Param id path string true "知识库 ID"
+// @Param q query string true "检索内容"
+// @Param limit query int false "返回的分块数，默认 5"
+// @Success 200 {object} models.Response{data=[]models.Citation}
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Router /api/knowledge-bases/{id}/search [get]
+func (c *KnowledgeController) Search(ctx *web.Context) {
+	kb, err := c.knowledgeService.GetByID(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, models.Fail("Knowledge base not found"))
+		return
+	}
+	query := ctx.Query("q")
+	if query == "" {
+		ctx.JSON(http.StatusBadRequest, models.Fail("检索内容不能为空"))
+		return
+	}
+	limit, _ := strconv.Atoi(ctx.Query("limit"))
+
+	chunks, err := c.knowledgeService.Search(ctx.Request.Context(), []string{kb.ID}, query, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, models.Success(services.ToCitations(chunks)))
+}
@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"errors"
+	"iano_server/models"
+	"iano_server/services"
+	web "iano_web"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+// MemoryController Agent 长期记忆接口，用于查看和删除 Agent 记住的内容
+type MemoryController struct {
+	memoryService *services.MemoryService
+}
+
+func NewMemoryController(memoryService *services.MemoryService) *MemoryController {
+	return &MemoryController{
+		memoryService: memoryService,
+	}
+}
+
+// List godoc
+// @Summary 获取记忆列表
+// @Description 按 Agent、用户、工作区和关键字过滤记忆，新记忆在前
+// @Tags Memory
+// @Produce json
+// @Param agent_id query string false "Agent ID"
+// @Param user_id query string false "用户 ID"
+// @Param workspace query string false "工作区"
+// @Param q query string false "关键字，匹配内容或标签"
+// @Success 200 {object} models.Response{data=[]models.Memory}
+// @Failure 500 {object} models.Response
+// @Router /api/memories [get]
+func (c *MemoryController) List(ctx *web.Context) {
+	memories, err := c.memoryService.List(services.MemoryFilter{
+		AgentID:   ctx.Query("agent_id"),
+		UserID:    ctx.Query("user_id"),
+		Workspace: ctx.Query("workspace"),
+		Keyword:   ctx.Query("q"),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, models.Success(memories))
+}
+
+// GetByID godoc
+// @Summary 获取记忆详情
+// @Description 根据 ID 获取一条记忆
+// @Tags Memory
+// @Produce json
+// @Param id path string true "记忆 ID"
+// @Success 200 {object} models.Response{data=models.Memory}
+// @Failure 404 {object} models.Response
+// @Router /api/memories/{id} [get]
+func (c *MemoryController) GetByID(ctx *web.Context) {
+	memory, err := c.memoryService.GetByID(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, models.Fail("Memory not found"))
+		return
+	}
+	ctx.JSON(http.StatusOK, models.Success(memory))
+}
+
+// Delete godoc
+// @Summary 删除记忆
+// @Description 删除一条记忆
+// @Tags Memory
+// @Produce json
+// @Param id path string true "记忆 ID"
+// @Success 200 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/memories/{id} [delete]
+func (c *MemoryController) Delete(ctx *web.Context) {
+	err := c.memoryService.Delete(ctx.Param("id"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, models.Fail("Memory not found"))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, models.Success(map[string]string{"message": "Memory deleted successfully"}))
+}
+
+// DeleteByAgent godoc
+// @Summary 清空 Agent 的记忆
+// @Description 删除指定 Agent 的全部记忆
+// @Tags Memory
+// @Produce json
+// @Param agent_id query string true "Agent ID"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/memories [delete]
+func (c *MemoryController) DeleteByAgent(ctx *web.Context) {
+	agentID := ctx.Query("agent_id")
+	if agentID == "" {
+		ctx.JSON(http.StatusBadRequest, models.Fail("agent_id 不能为空"))
+		return
+	}
+
+	count, err := c.memoryService.DeleteByAgent(agentID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, models.Success(map[string]int64{"deleted": count}))
+}
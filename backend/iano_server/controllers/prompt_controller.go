@@ -0,0 +1,207 @@
+package controllers
+
+import (
+	"errors"
+	iano "iano_agent"
+	"iano_server/models"
+	"iano_server/services"
+	web "iano_web"
+	"net/http"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// PromptController 提示词库接口
+type PromptController struct {
+	promptService *services.PromptService
+}
+
+func NewPromptController(promptService *services.PromptService) *PromptController {
+	return &PromptController{
+		promptService: promptService,
+	}
+}
+
+type CreatePromptRequest struct {
+	Name        string `json:"name" validate:"required" example:"coding-style"`        // 名称，Agent 指令中通过 {{include "coding-style"}} 引用
+	Description string `json:"description" example:"团队代码风格约定"`                         // 说明
+	Content     string `json:"content" validate:"required" example:"回答使用{{.Locale}}。"` // 片段内容，按 text/template 渲染
+	Tags        string `json:"tags,omitempty" example:"code,style"`                    // 标签，逗号分隔
+}
+
+type UpdatePromptRequest struct {
+	Description *string `json:"description,omitempty" example:"团队代码风格约定"`     // 说明
+	Content     *string `json:"content,omitempty" example:"回答使用{{.Locale}}。"` // 片段内容
+	Tags        *string `json:"tags,omitempty" example:"code,style"`          // 标签，逗号分隔
+}
+
+// Create godoc
+// @Summary 创建提示词
+// @Description 在提示词库中创建一个片段，版本号从 1 开始
+// @Tags Prompt
+// @Accept json
+// @Produce json
+// @Param prompt body CreatePromptRequest true "提示词信息"
+// @Success 201 {object} models.Response{data=models.Prompt}
+// @Failure 400 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/prompts [post]
+func (c *PromptController) Create(ctx *web.Context) {
+	var req CreatePromptRequest
+	if err := ctx.BindAndValidate(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.Fail(err.Error()))
+		return
+	}
+	if strings.ContainsAny(req.Name, "@\"") {
+		ctx.JSON(http.StatusBadRequest, models.Fail("提示词名称不能包含 @ 或引号"))
+		return
+	}
+	if err := iano.ParsePromptTemplate(req.Content); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.Fail(err.Error()))
+		return
+	}
+
+	prompt := &models.Prompt{
+		Name:        req.Name,
+		Description: req.Description,
+		Content:     req.Content,
+		Tags:        req.Tags,
+	}
+	prompt.NewID()
+	if err := c.promptService.Create(prompt); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.Fail(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, models.Success(prompt))
+}
+
+// GetAll godoc
+// @Summary 获取提示词列表
+// @Description 获取提示词库中每个片段的最新版本
+// @Tags Prompt
+// @Produce json
+// @Success 200 {object} models.Response{data=[]models.Prompt}
+// @Failure 500 {object} models.Response
+// @Router /api/prompts [get]
+func (c *PromptController) GetAll(ctx *web.Context) {
+	prompts, err := c.promptService.GetAll()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, models.Success(prompts))
+}
+
+// GetByID godoc
+// @Summary 获取提示词详情
+// @Description 根据 ID 获取提示词的某个版本
+// @Tags Prompt
+// @Produce json
+// @Param id path string true "提示词 ID"
+// @Success 200 {object} models.Response{data=models.Prompt}
+// @Failure 404 {object} models.Response
+// @Router /api/prompts/{id} [get]
+func (c *PromptController) GetByID(ctx *web.Context) {
+	prompt, err := c.promptService.GetByID(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, models.Fail("Prompt not found"))
+		return
+	}
+	ctx.JSON(http.StatusOK, models.Success(prompt))
+}
+
+// GetVersions godoc
+// @Summary 获取提示词的历史版本
+// @Description 获取与指定提示词同名的全部版本，新版本在前
+// @Tags Prompt
+// @Produce json
+// @Param id path string true "提示词 ID"
+// @Success 200 {object} models.Response{data=[]models.Prompt}
+// @Failure 404 {object} models.Response
+// @Router /api/prompts/{id}/versions [get]
+func (c *PromptController) GetVersions(ctx *web.Context) {
+	prompt, err := c.promptService.GetByID(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, models.Fail("Prompt not found"))
+		return
+	}
+	versions, err := c.promptService.GetVersions(prompt.Name)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, models.Success(versions))
+}
+
+// Update godoc
+// @Summary 更新提示词
+// @Description 基于指定版本保存一个新版本，旧版本保留，名称不可修改
+// @Tags Prompt
+// @Accept json
+// @Produce json
+// @Param id path string true "提示词 ID"
+// @Param prompt body UpdatePromptRequest true "更新内容"
+// @Success 200 {object} models.Response{data=models.Prompt}
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/prompts/{id} [put]
+func (c *PromptController) Update(ctx *web.Context) {
+	var req UpdatePromptRequest
+	if err := ctx.Bind(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.Fail(err.Error()))
+		return
+	}
+
+	updates := make(map[string]interface{})
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if req.Content != nil {
+		if err := iano.ParsePromptTemplate(*req.Content); err != nil {
+			ctx.JSON(http.StatusBadRequest, models.Fail(err.Error()))
+			return
+		}
+		updates["content"] = *req.Content
+	}
+	if req.Tags != nil {
+		updates["tags"] = *req.Tags
+	}
+
+	prompt, err := c.promptService.Update(ctx.Param("id"), updates)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, models.Fail("Prompt not found"))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.Fail(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.Success(prompt))
+}
+
+// Delete godoc
+// @Summary 删除提示词
+// @Description 删除与指定提示词同名的全部版本
+// @Tags Prompt
+// @Produce json
+// @Param id path string true "提示词 ID"
+// @Success 200 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/prompts/{id} [delete]
+func (c *PromptController) Delete(ctx *web.Context) {
+	err := c.promptService.Delete(ctx.Param("id"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, models.Fail("Prompt not found"))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, models.Success(map[string]string{"message": "Prompt deleted successfully"}))
+}
@@ -1,10 +1,14 @@
 package controllers
 
 import (
+	"encoding/json"
+	"fmt"
+	agentmodel "iano_agent/model"
 	"iano_server/models"
 	"iano_server/services"
 	web "iano_web"
 	"net/http"
+	"strings"
 )
 
 type ProviderController struct {
@@ -16,23 +20,38 @@ func NewProviderController(providerService *services.ProviderService) *ProviderC
 }
 
 type CreateProviderRequest struct {
-	Name        string  `json:"name" binding:"required" example:"OpenAI"`
-	BaseUrl     string  `json:"base_url" binding:"required" example:"https://api.openai.com/v1"`
-	ApiKey      string  `json:"api_key" binding:"required" example:"sk-xxx"`
-	Model       string  `json:"model" binding:"required" example:"gpt-4"`
-	Temperature float32 `json:"temperature" example:"0.7"`
-	MaxTokens   int     `json:"max_tokens" example:"2000"`
-	IsDefault   bool    `json:"is_default" example:"true"`
+	Name                string   `json:"name" binding:"required" example:"OpenAI"`
+	Type                string   `json:"type" example:"openai"`
+	BaseUrl             string   `json:"base_url" example:"https://api.openai.com/v1"` // Claude、Gemini、Ollama 为空时使用官方默认地址
+	ApiKey              string   `json:"api_key" example:"sk-xxx"`
+	Model               string   `json:"model" binding:"required" example:"gpt-4"`
+	Temperature         float32  `json:"temperature" example:"0.7"`
+	MaxTokens           int      `json:"max_tokens" example:"2000"`
+	IsDefault           bool     `json:"is_default" example:"true"`
+	Extra               string   `json:"extra,omitempty" example:"{}"`
+	FallbackProviderIDs []string `json:"fallback_provider_ids" example:"provider-002"` // 备用提供商 ID 列表，当前提供商限流或不可用时按顺序切换
+	SupportsVision      bool     `json:"supports_vision" example:"true"`               // 模型是否支持图片输入
 }
 
 type UpdateProviderRequest struct {
-	Name        *string  `json:"name,omitempty" example:"OpenAI"`
-	BaseUrl     *string  `json:"base_url,omitempty" example:"https://api.openai.com/v1"`
-	ApiKey      *string  `json:"api_key,omitempty" example:"sk-xxx"`
-	Model       *string  `json:"model,omitempty" example:"gpt-4"`
-	Temperature *float32 `json:"temperature,omitempty" example:"0.7"`
-	MaxTokens   *int     `json:"max_tokens,omitempty" example:"2000"`
-	IsDefault   *bool    `json:"is_default,omitempty" example:"true"`
+	Name                *string   `json:"name,omitempty" example:"OpenAI"`
+	Type                *string   `json:"type,omitempty" example:"openai"`
+	BaseUrl             *string   `json:"base_url,omitempty" example:"https://api.openai.com/v1"`
+	ApiKey              *string   `json:"api_key,omitempty" example:"sk-xxx"`
+	Model               *string   `json:"model,omitempty" example:"gpt-4"`
+	Temperature         *float32  `json:"temperature,omitempty" example:"0.7"`
+	MaxTokens           *int      `json:"max_tokens,omitempty" example:"2000"`
+	IsDefault           *bool     `json:"is_default,omitempty" example:"true"`
+	Extra               *string   `json:"extra,omitempty" example:"{}"`
+	FallbackProviderIDs *[]string `json:"fallback_provider_ids,omitempty" example:"provider-002"` // 备用提供商 ID 列表，当前提供商限流或不可用时按顺序切换
+	SupportsVision      *bool     `json:"supports_vision,omitempty" example:"true"`               // 模型是否支持图片输入
+}
+
+type DiscoverModelsRequest struct {
+	Type    string `json:"type" example:"ollama"`
+	BaseUrl string `json:"base_url" example:"http://localhost:11434"`
+	ApiKey  string `json:"api_key" example:""`
+	Extra   string `json:"extra,omitempty" example:"{}"`
 }
 
 // Create godoc
@@ -53,14 +72,30 @@ func (c *ProviderController) Create(ctx *web.Context) {
 		return
 	}
 
+	if req.Type == "" {
+		req.Type = models.ProviderTypeOpenAI
+	}
+	if err := validateProviderSettings(req.Type, req.Extra); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.Fail(err.Error()))
+		return
+	}
+	if req.BaseUrl == "" && !agentmodel.ProviderType(req.Type).HasDefaultBaseURL() {
+		ctx.JSON(http.StatusBadRequest, models.Fail("base_url 不能为空"))
+		return
+	}
+
 	provider := &models.Provider{
-		Name:        req.Name,
-		BaseUrl:     req.BaseUrl,
-		ApiKey:      req.ApiKey,
-		Model:       req.Model,
-		Temperature: req.Temperature,
-		MaxTokens:   req.MaxTokens,
-		IsDefault:   req.IsDefault,
+		Name:                req.Name,
+		Type:                req.Type,
+		BaseUrl:             req.BaseUrl,
+		ApiKey:              req.ApiKey,
+		Model:               req.Model,
+		Temperature:         req.Temperature,
+		MaxTokens:           req.MaxTokens,
+		IsDefault:           req.IsDefault,
+		Extra:               req.Extra,
+		FallbackProviderIDs: req.FallbackProviderIDs,
+		SupportsVision:      req.SupportsVision,
 	}
 
 	provider.NewID()
@@ -153,6 +188,20 @@ func (c *ProviderController) Update(ctx *web.Context) {
 	if req.Name != nil {
 		updates["name"] = *req.Name
 	}
+	if req.Type != nil {
+		if err := validateProviderSettings(*req.Type, ""); err != nil {
+			ctx.JSON(http.StatusBadRequest, models.Fail(err.Error()))
+			return
+		}
+		updates["type"] = *req.Type
+	}
+	if req.Extra != nil {
+		if err := validateProviderSettings("", *req.Extra); err != nil {
+			ctx.JSON(http.StatusBadRequest, models.Fail(err.Error()))
+			return
+		}
+		updates["extra"] = *req.Extra
+	}
 	if req.BaseUrl != nil {
 		updates["base_url"] = *req.BaseUrl
 	}
@@ -171,6 +220,12 @@ func (c *ProviderController) Update(ctx *web.Context) {
 	if req.IsDefault != nil {
 		updates["is_default"] = *req.IsDefault
 	}
+	if req.FallbackProviderIDs != nil {
+		updates["fallback_provider_ids"] = models.StrArray(*req.FallbackProviderIDs)
+	}
+	if req.SupportsVision != nil {
+		updates["supports_vision"] = *req.SupportsVision
+	}
 
 	provider, err := c.providerService.Update(id, updates)
 	if err != nil {
@@ -200,3 +255,68 @@ func (c *ProviderController) Delete(ctx *web.Context) {
 	}
 	ctx.JSON(http.StatusOK, models.Success(map[string]string{"message": "Provider deleted successfully"}))
 }
+
+// DiscoverModels godoc
+// @Summary 发现可用模型
+// @Description 查询提供商可用的模型列表（如 Ollama 本地已安装的模型），用于选择 Provider 的模型
+// @Tags Provider
+// @Accept json
+// @Produce json
+// @Param request body DiscoverModelsRequest true "提供商连接信息"
+// @Success 200 {object} models.Response{data=[]agentmodel.ModelInfo}
+// @Failure 400 {object} models.Response
+// @Failure 502 {object} models.Response
+// @Router /api/providers/models [post]
+func (c *ProviderController) DiscoverModels(ctx *web.Context) {
+	var req DiscoverModelsRequest
+	if err := ctx.Bind(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.Fail(err.Error()))
+		return
+	}
+
+	providerType := agentmodel.ProviderType(req.Type)
+	if providerType == "" {
+		providerType = agentmodel.ProviderOllama
+	}
+	extra, err := parseProviderExtra(req.Extra)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.Fail(err.Error()))
+		return
+	}
+
+	list, err := agentmodel.ListModels(ctx.Request.Context(), &agentmodel.Config{
+		Type:    providerType,
+		BaseURL: req.BaseUrl,
+		APIKey:  req.ApiKey,
+		Extra:   extra,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, models.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, models.Success(list))
+}
+
+// validateProviderSettings 校验提供商类型是否已注册以及专属参数是否为合法 JSON 对象
+func validateProviderSettings(providerType string, extra string) error {
+	if providerType != "" {
+		if _, ok := agentmodel.GlobalRegistry.Get(agentmodel.ProviderType(providerType)); !ok {
+			return fmt.Errorf("不支持的模型提供商: %s", providerType)
+		}
+	}
+	_, err := parseProviderExtra(extra)
+	return err
+}
+
+// parseProviderExtra 解析提供商专属参数
+func parseProviderExtra(extra string) (map[string]interface{}, error) {
+	if strings.TrimSpace(extra) == "" {
+		return nil, nil
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal([]byte(extra), &result); err != nil {
+		return nil, fmt.Errorf("extra 必须是 JSON 对象: %w", err)
+	}
+	return result, nil
+}
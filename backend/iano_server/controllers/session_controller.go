@@ -2,6 +2,8 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
+	"iano_agent/tools"
 	"iano_server/models"
 	"iano_server/services"
 	web "iano_web"
@@ -9,11 +11,13 @@ import (
 )
 
 type SessionController struct {
-	sessionService *services.SessionService
+	sessionService  *services.SessionService
+	processManager  *tools.ProcessManager  // 会话结束时停止其中的后台进程
+	terminalManager *tools.TerminalManager // 会话结束时关闭其中的终端
 }
 
-func NewSessionController(sessionService *services.SessionService) *SessionController {
-	return &SessionController{sessionService: sessionService}
+func NewSessionController(sessionService *services.SessionService, processManager *tools.ProcessManager, terminalManager *tools.TerminalManager) *SessionController {
+	return &SessionController{sessionService: sessionService, processManager: processManager, terminalManager: terminalManager}
 }
 
 type CreateSessionRequest struct {
@@ -166,6 +170,10 @@ func (c *SessionController) Update(ctx *web.Context) {
 		ctx.JSON(http.StatusInternalServerError, models.Fail(err.Error()))
 		return
 	}
+	// 会话完成或归档后不再对话，停止其中的后台进程和终端
+	if !session.CanChat() {
+		c.stopSessionTools(id)
+	}
 
 	ctx.JSON(http.StatusOK, models.Success(session))
 }
@@ -239,6 +247,12 @@ func (c *SessionController) UpdateConfig(ctx *web.Context) {
 	if len(req.Config.SelectedTools) > 0 {
 		currentConfig.SelectedTools = req.Config.SelectedTools
 	}
+	if req.Config.Locale != "" {
+		currentConfig.Locale = req.Config.Locale
+	}
+	if req.Config.PromptVars != nil {
+		currentConfig.PromptVars = req.Config.PromptVars
+	}
 
 	if err := session.SetConfig(currentConfig); err != nil {
 		ctx.JSON(http.StatusInternalServerError, models.Fail(err.Error()))
@@ -274,9 +288,16 @@ func (c *SessionController) Delete(ctx *web.Context) {
 		ctx.JSON(http.StatusInternalServerError, models.Fail(err.Error()))
 		return
 	}
+	c.stopSessionTools(id)
 	ctx.JSON(http.StatusOK, models.Success(map[string]string{"message": "Session deleted successfully"}))
 }
 
+// stopSessionTools 停止会话中 Agent 启动的后台进程和终端
+func (c *SessionController) stopSessionTools(id string) {
+	c.processManager.StopSession(id)
+	c.terminalManager.CloseSession(id)
+}
+
 // GetConfig godoc
 // @Summary 获取会话配置
 // @Description 获取指定会话的配置
@@ -304,3 +325,38 @@ func (c *SessionController) GetConfig(ctx *web.Context) {
 
 	ctx.JSON(http.StatusOK, models.Success(config))
 }
+
+// ListProcesses godoc
+// @Summary 获取会话的后台进程
+// @Description 获取 Agent 在会话中启动的后台进程及其状态
+// @Tags Session
+// @Produce json
+// @Param id path string true "会话 ID"
+// @Success 200 {object} models.Response{data=[]tools.ProcessInfo}
+// @Router /api/sessions/{id}/processes [get]
+func (c *SessionController) ListProcesses(ctx *web.Context) {
+	ctx.JSON(http.StatusOK, models.Success(c.processManager.List(ctx.Param("id"))))
+}
+
+// StopProcess godoc
+// @Summary 停止会话的后台进程
+// @Description 停止会话中指定名称的后台进程及其子进程
+// @Tags Session
+// @Produce json
+// @Param id path string true "会话 ID"
+// @Param name path string true "进程名称"
+// @Success 200 {object} models.Response{data=tools.ProcessInfo}
+// @Failure 404 {object} models.Response
+// @Router /api/sessions/{id}/processes/{name}/stop [post]
+func (c *SessionController) StopProcess(ctx *web.Context) {
+	info, err := c.processManager.Stop(ctx.Param("id"), ctx.Param("name"))
+	if errors.Is(err, tools.ErrProcessNotFound) {
+		ctx.JSON(http.StatusNotFound, models.Fail(err.Error()))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, models.Success(info))
+}
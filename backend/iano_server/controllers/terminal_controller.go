@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"iano_agent/tools"
+	"iano_server/models"
+	web "iano_web"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// terminalReadWait 转发终端输出时每次等待新输出的时间
+const terminalReadWait = 30 * time.Second
+
+// TerminalController 会话终端接口，用户可以通过 WebSocket 查看和接管 Agent 打开的终端
+type TerminalController struct {
+	terminalManager *tools.TerminalManager
+}
+
+func NewTerminalController(terminalManager *tools.TerminalManager) *TerminalController {
+	return &TerminalController{
+		terminalManager: terminalManager,
+	}
+}
+
+// TerminalClientMessage WebSocket 客户端发送的文本消息，二进制消息按原始按键写入终端
+type TerminalClientMessage struct {
+	Type string `json:"type" example:"input"` // input 输入按键，resize 调整终端大小
+	Data string `json:"data,omitempty"`       // 输入的内容，type 为 input 时使用
+	Rows int    `json:"rows,omitempty"`       // 行数，type 为 resize 时使用
+	Cols int    `json:"cols,omitempty"`       // 列数，type 为 resize 时使用
+}
+
+// TerminalExitMessage 终端退出时服务端发送的文本消息，终端输出以二进制消息发送
+type TerminalExitMessage struct {
+	Type     string `json:"type" example:"exit"`
+	Status   string `json:"status" example:"exited"`
+	ExitCode *int   `json:"exit_code,omitempty"`
+}
+
+// List godoc
+// @Summary 获取会话的终端
+// @Description 获取 Agent 在会话中打开的终端及其状态
+// @Tags Terminal
+// @Produce json
+// @Param id path string true "会话 ID"
+// @Success 200 {object} models.Response{data=[]tools.TerminalInfo}
+// @Router /api/sessions/{id}/terminals [get]
+func (c *TerminalController) List(ctx *web.Context) {
+	ctx.JSON(http.StatusOK, models.Success(c.terminalManager.List(ctx.Param("id"))))
+}
+
+// Close godoc
+// @Summary 关闭会话的终端
+// @Description 结束终端中运行的程序及其子进程
+// @Tags Terminal
+// @Produce json
+// @Param id path string true "会话 ID"
+// @Param name path string true "终端名称"
+// @Success 200 {object} models.Response{data=tools.TerminalInfo}
+// @Failure 404 {object} models.Response
+// @Router /api/sessions/{id}/terminals/{name}/close [post]
+func (c *TerminalController) Close(ctx *web.Context) {
+	info, err := c.terminalManager.Close(ctx.Param("id"), ctx.Param("name"))
+	if errors.Is(err, tools.ErrTerminalNotFound) {
+		ctx.JSON(http.StatusNotFound, models.Fail(err.Error()))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, models.Success(info))
+}
+
+// Attach godoc
+// @Summary 连接会话的终端
+// @Description 通过 WebSocket 查看和操作终端。服务端先发送最近的输出，之后实时转发新输出（二进制消息，含 ANSI 控制序列），终端退出时发送 exit 文本消息后关闭连接。
+// @Description 连接默认只读，忽略客户端消息；write 为 true 时接管终端，客户端发送二进制消息作为按键输入，或发送 TerminalClientMessage 文本消息输入内容、调整大小。
+// @Tags Terminal
+// @Param id path string true "会话 ID"
+// @Param name path string true "终端名称"
+// @Param offset query int false "从该偏移开始发送输出，默认发送最近的输出"
+// @Param write query bool false "接管终端，允许输入和调整大小"
+// @Success 101 {object} TerminalExitMessage
+// @Failure 404 {object} models.Response
+// @Router /api/sessions/{id}/terminals/{name}/ws [get]
+func (c *TerminalController) Attach(ctx *web.Context) {
+	sessionID, name := ctx.Param("id"), ctx.Param("name")
+	if _, err := c.terminalManager.Status(sessionID, name); err != nil {
+		ctx.JSON(http.StatusNotFound, models.Fail(err.Error()))
+		return
+	}
+	offset := int64(-1)
+	if v, err := strconv.ParseInt(ctx.Query("offset"), 10, 64); err == nil && v >= 0 {
+		offset = v
+	}
+	// 写入终端不经过 terminal_write 的审批，只有明确请求接管的连接才转发输入
+	readonly := ctx.Query("write") != "true"
+
+	conn, err := ctx.UpgradeWebSocket()
+	if err != nil {
+		slog.Warn("终端 WebSocket 升级失败", "session", sessionID, "name", name, "error", err)
+		return
+	}
+	defer conn.Close()
+
+	// 客户端断开时停止转发输出
+	runCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		defer cancel()
+		c.readInput(conn, sessionID, name, readonly)
+	}()
+	c.writeOutput(runCtx, conn, sessionID, name, offset)
+}
+
+// readInput 将客户端消息写入终端，连接断开时返回
+func (c *TerminalController) readInput(conn *websocket.Conn, sessionID, name string, readonly bool) {
+	for {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if readonly {
+			continue
+		}
+
+		if messageType == websocket.BinaryMessage {
+			_, err = c.terminalManager.Write(sessionID, name, string(data))
+		} else {
+			var msg TerminalClientMessage
+			if err := json.Unmarshal(data, &msg); err != nil {
+				slog.Warn("无效的终端消息", "session", sessionID, "name", name, "error", err)
+				continue
+			}
+			switch msg.Type {
+			case "input":
+				_, err = c.terminalManager.Write(sessionID, name, msg.Data)
+			case "resize":
+				_, err = c.terminalManager.Resize(sessionID, name, msg.Rows, msg.Cols)
+			default:
+				slog.Warn("未知的终端消息类型", "session", sessionID, "name", name, "type", msg.Type)
+			}
+		}
+		if err != nil {
+			slog.Warn("操作终端失败", "session", sessionID, "name", name, "error", err)
+		}
+	}
+}
+
+// writeOutput 转发终端输出，终端退出后发送 exit 消息并关闭连接
+func (c *TerminalController) writeOutput(ctx context.Context, conn *websocket.Conn, sessionID, name string, offset int64) {
+	for {
+		out, err := c.terminalManager.Read(ctx, sessionID, name, offset, 0, terminalReadWait)
+		if err != nil {
+			if ctx.Err() == nil {
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, err.Error()))
+			}
+			return
+		}
+		if out.Output != "" {
+			if err := conn.WriteMessage(websocket.BinaryMessage, []byte(out.Output)); err != nil {
+				return
+			}
+		}
+		offset = out.NextOffset
+
+		if !out.More && out.Status != tools.ProcessStatusRunning {
+			conn.WriteJSON(TerminalExitMessage{Type: "exit", Status: out.Status, ExitCode: out.ExitCode})
+			conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+			return
+		}
+	}
+}
@@ -1,5 +1,7 @@
 package models
 
+import "encoding/json"
+
 // AgentType Agent 类型
 type AgentType string
 
@@ -12,15 +14,29 @@ const (
 // Agent Agent 模型
 type Agent struct {
 	BaseModel
-	Name         string    `gorm:"column:name" json:"name"`
-	Description  string    `gorm:"column:description" json:"description"`
-	Type         AgentType `gorm:"size:20;default:'main'" json:"type"`
-	IsSubAgent   bool      `gorm:"default:false" json:"is_sub_agent"`
-	ProviderID   string    `gorm:"column:provider_id" json:"provider_id"`
-	Model        string    `gorm:"column:model" json:"model"`
-	Instructions string    `gorm:"column:instructions;type:text" json:"instructions"`
-	Tools        string    `gorm:"column:tools;type:text" json:"tools"`
-	MCPServerIDs StrArray  `gorm:"column:mcp_server_ids;type:text" json:"mcp_server_ids"`
+	Name                string    `gorm:"column:name" json:"name"`
+	Description         string    `gorm:"column:description" json:"description"`
+	Type                AgentType `gorm:"size:20;default:'main'" json:"type"`
+	IsSubAgent          bool      `gorm:"default:false" json:"is_sub_agent"`
+	ProviderID          string    `gorm:"column:provider_id" json:"provider_id"`
+	Model               string    `gorm:"column:model" json:"model"`
+	Instructions        string    `gorm:"column:instructions;type:text" json:"instructions"`
+	Tools               string    `gorm:"column:tools;type:text" json:"tools"`
+	MCPServerIDs        StrArray  `gorm:"column:mcp_server_ids;type:text" json:"mcp_server_ids"`
+	FallbackProviderIDs StrArray  `gorm:"column:fallback_provider_ids;type:text" json:"fallback_provider_ids"` // 备用提供商 ID 列表，为空时使用 Provider 的备用列表
+	ApprovalPolicy      string    `gorm:"column:approval_policy;size:20" json:"approval_policy"`               // 工具审批策略 auto/ask/deny，为空时危险工具需要审批
+	ToolApprovals       string    `gorm:"column:tool_approvals;type:text" json:"tool_approvals"`               // 工具级审批策略（JSON，工具名 -> 策略）
+	SubAgentIDs         StrArray  `gorm:"column:sub_agent_ids;type:text" json:"sub_agent_ids"`                 // 可委派的子 Agent ID 列表
+	MaxDelegationDepth  int       `gorm:"column:max_delegation_depth;default:0" json:"max_delegation_depth"`   // 最大委派深度，0 表示使用默认值
+	MaxRounds           int       `gorm:"column:max_rounds;default:0" json:"max_rounds"`                       // 单次运行最多调用模型的轮数，0 表示使用默认值
+	MaxToolCalls        int       `gorm:"column:max_tool_calls;default:0" json:"max_tool_calls"`               // 单次运行最多执行的工具调用次数，0 表示不限制
+	TokenBudget         int64     `gorm:"column:token_budget;default:0" json:"token_budget"`                   // 单次运行（包括作为子 Agent 被委派时）的 Token 预算，0 表示不限制
+	RunTimeout          int       `gorm:"column:run_timeout;default:0" json:"run_timeout"`                     // 单次运行的时长上限（秒），0 表示不限制
+	ResponseSchema      string    `gorm:"column:response_schema;type:text" json:"response_schema"`             // 最终答复需要满足的 JSON Schema，为空表示自由文本
+	MaxSchemaRetries    int       `gorm:"column:max_schema_retries;default:0" json:"max_schema_retries"`       // 最终答复不符合 Schema 时的重新提示次数，0 表示使用默认值
+	MemoryScope         string    `gorm:"column:memory_scope;size:20" json:"memory_scope"`                     // 长期记忆范围 agent/user/workspace，为空表示不启用记忆
+	KnowledgeBaseIDs    StrArray  `gorm:"column:knowledge_base_ids;type:text" json:"knowledge_base_ids"`       // 绑定的知识库 ID 列表，绑定后启用 kb_search 工具
+	AllowGitWrite       bool      `gorm:"column:allow_git_write;default:false" json:"allow_git_write"`         // 允许 git_branch 创建/切换分支和 git_commit 提交
 }
 
 func (Agent) TableName() string {
@@ -46,3 +62,16 @@ func (a *Agent) IsCustomAgent() bool {
 func (a *Agent) GetInstructions() string {
 	return a.Instructions
 }
+
+// GetToolApprovals 解析工具级审批策略
+func (a *Agent) GetToolApprovals() map[string]string {
+	if a.ToolApprovals == "" {
+		return nil
+	}
+
+	var approvals map[string]string
+	if err := json.Unmarshal([]byte(a.ToolApprovals), &approvals); err != nil {
+		return nil
+	}
+	return approvals
+}
@@ -0,0 +1,38 @@
+package models
+
+// 检查点恢复范围
+const (
+	CheckpointScopeMessage = "message" // 只撤销指定消息中的修改
+	CheckpointScopeAfter   = "after"   // 撤销指定消息之后所有消息中的修改
+)
+
+// IsValidCheckpointScope 是否为有效的恢复范围，空字符串表示 message
+func IsValidCheckpointScope(scope string) bool {
+	switch scope {
+	case "", CheckpointScopeMessage, CheckpointScopeAfter:
+		return true
+	}
+	return false
+}
+
+// Checkpoint 工具修改文件前保存的文件原内容，同一条消息中每个文件只保存第一次修改前的内容
+//
+// 内容按 SHA-256 寻址存储，相同内容只保存一份。
+type Checkpoint struct {
+	BaseModel
+	SessionID string `gorm:"column:session_id;size:36;not null;index" json:"session_id"` // 所属会话
+	MessageID string `gorm:"column:message_id;size:36;not null;index" json:"message_id"` // 修改文件的助手消息
+	CallID    string `gorm:"column:call_id;size:100" json:"call_id"`                     // 工具调用 ID
+	ToolName  string `gorm:"column:tool_name;size:100" json:"tool_name"`                 // 修改文件的工具
+	Path      string `gorm:"column:path;size:1000;not null" json:"path"`                 // 文件的绝对路径
+	Existed   bool   `gorm:"column:existed" json:"existed"`                              // 修改前文件是否存在，不存在时恢复即删除该文件
+	Skipped   bool   `gorm:"column:skipped" json:"skipped"`                              // 文件超过大小上限，未保存内容，无法恢复
+	Size      int64  `gorm:"column:size" json:"size"`                                    // 修改前的文件大小（字节）
+	Mode      uint32 `gorm:"column:mode" json:"mode"`                                    // 修改前的文件权限
+	Hash      string `gorm:"column:hash;size:64;index" json:"hash"`                      // 修改前内容的 SHA-256，文件不存在时为空
+}
+
+// TableName 返回表名
+func (Checkpoint) TableName() string {
+	return "checkpoints"
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 附件类型
+const (
+	AttachmentTypeImage = "image"
+	AttachmentTypeFile  = "file"
+)
+
+// textMimeTypes 可以作为文本内联给模型的非 text/* 类型
+var textMimeTypes = map[string]bool{
+	"application/json":       true,
+	"application/xml":        true,
+	"application/yaml":       true,
+	"application/x-yaml":     true,
+	"application/toml":       true,
+	"application/javascript": true,
+	"application/x-sh":       true,
+	"application/sql":        true,
+}
+
+// inlineMimeTypes 可以在浏览器中直接打开的类型，其他类型（如 HTML、SVG）一律作为下载返回，避免存储型 XSS
+var inlineMimeTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+	"image/bmp":  true,
+	"text/plain": true,
+}
+
+// File 上传的附件文件，内容按 SHA-256 寻址存储，相同内容只保存一份
+type File struct {
+	BaseModel
+	Name     string `gorm:"column:name;size:255" json:"name"`           // 原始文件名
+	MimeType string `gorm:"column:mime_type;size:100" json:"mime_type"` // 文件类型
+	Size     int64  `gorm:"column:size" json:"size"`                    // 文件大小（字节）
+	Hash     string `gorm:"column:hash;size:64;index" json:"hash"`      // 内容的 SHA-256
+}
+
+func (File) TableName() string {
+	return "files"
+}
+
+// IsImage 是否为图片
+func (f *File) IsImage() bool {
+	return strings.HasPrefix(f.MimeType, "image/")
+}
+
+// IsText 是否为可以直接阅读的文本文件
+func (f *File) IsText() bool {
+	return IsTextMimeType(f.MimeType)
+}
+
+// IsInline 下载时是否可以在浏览器中直接打开
+func (f *File) IsInline() bool {
+	return inlineMimeTypes[f.MimeType]
+}
+
+// IsTextMimeType 是否为可以直接阅读的文本类型
+func IsTextMimeType(mimeType string) bool {
+	return strings.HasPrefix(mimeType, "text/") || textMimeTypes[mimeType]
+}
+
+// ToAttachment 转换为消息中的附件信息
+func (f *File) ToAttachment() Attachment {
+	attachmentType := AttachmentTypeFile
+	if f.IsImage() {
+		attachmentType = AttachmentTypeImage
+	}
+	return Attachment{
+		ID:       f.ID,
+		Type:     attachmentType,
+		URL:      fmt.Sprintf("/api/attachments/%s/content", f.ID),
+		Name:     f.Name,
+		Size:     f.Size,
+		MimeType: f.MimeType,
+	}
+}
@@ -0,0 +1,68 @@
+package models
+
+// 知识库文档状态
+const (
+	KnowledgeDocumentStatusReady  = "ready"  // 已完成分块和索引
+	KnowledgeDocumentStatusFailed = "failed" // 提取或索引失败，原因见 Error
+)
+
+// KnowledgeBase 知识库，Agent 绑定后可以通过 kb_search 工具检索其中的文档
+type KnowledgeBase struct {
+	BaseModel
+	Name                string `gorm:"column:name;size:100;not null;uniqueIndex" json:"name"`             // 知识库名称
+	Description         string `gorm:"column:description;type:text" json:"description"`                   // 知识库描述
+	EmbeddingProviderID string `gorm:"column:embedding_provider_id;size:36" json:"embedding_provider_id"` // 向量模型提供商，为空时只使用全文检索
+	EmbeddingModel      string `gorm:"column:embedding_model;size:100" json:"embedding_model"`            // 向量模型名称
+	ChunkSize           int    `gorm:"column:chunk_size;default:0" json:"chunk_size"`                     // 分块的最大字符数，0 表示使用默认值
+	ChunkOverlap        int    `gorm:"column:chunk_overlap;default:0" json:"chunk_overlap"`               // 相邻分块重叠的字符数
+	DocumentCount       int64  `gorm:"-" json:"document_count"`                                           // 文档数量，查询时填充
+}
+
+func (KnowledgeBase) TableName() string {
+	return "knowledge_bases"
+}
+
+// KnowledgeDocument 知识库中的文档
+type KnowledgeDocument struct {
+	BaseModel
+	KnowledgeBaseID string `gorm:"column:knowledge_base_id;size:36;not null;index" json:"knowledge_base_id"` // 所属知识库
+	Title           string `gorm:"column:title;size:255" json:"title"`                                       // 文档标题
+	Source          string `gorm:"column:source;size:500" json:"source"`                                     // 来源，文件路径或附件名，同一知识库中相同来源的文档重新导入时替换
+	Size            int64  `gorm:"column:size" json:"size"`                                                  // 原始文件大小（字节）
+	ChunkCount      int    `gorm:"column:chunk_count;default:0" json:"chunk_count"`                          // 分块数量
+	Status          string `gorm:"column:status;size:20" json:"status"`                                      // 状态 ready/failed
+	Error           string `gorm:"column:error;type:text" json:"error,omitempty"`                            // 导入失败的原因
+}
+
+func (KnowledgeDocument) TableName() string {
+	return "knowledge_documents"
+}
+
+// KnowledgeChunk 文档分块，全文索引保存在 knowledge_chunks_fts 虚拟表中
+type KnowledgeChunk struct {
+	BaseModel
+	KnowledgeBaseID string `gorm:"column:knowledge_base_id;size:36;not null;index" json:"knowledge_base_id"` // 所属知识库
+	DocumentID      string `gorm:"column:document_id;size:36;not null;index" json:"document_id"`             // 所属文档
+	Ordinal         int    `gorm:"column:ordinal" json:"ordinal"`                                            // 分块在文档中的序号
+	Heading         string `gorm:"column:heading;size:500" json:"heading"`                                   // 所在小节的标题
+	Content         string `gorm:"column:content;type:text;not null" json:"content"`                         // 分块内容
+	Embedding       string `gorm:"column:embedding;type:text" json:"-"`                                      // 向量（JSON 数组），未配置向量模型时为空
+}
+
+func (KnowledgeChunk) TableName() string {
+	return "knowledge_chunks"
+}
+
+// Citation 答复引用的知识库分块，保存在助手消息的内容块中
+type Citation struct {
+	Index           int     `json:"index"`                    // 引用编号，对应答复中的 [n]
+	KnowledgeBaseID string  `json:"knowledge_base_id"`        // 所属知识库
+	KnowledgeBase   string  `json:"knowledge_base,omitempty"` // 知识库名称
+	DocumentID      string  `json:"document_id"`              // 所属文档
+	ChunkID         string  `json:"chunk_id"`                 // 分块 ID
+	Title           string  `json:"title"`                    // 文档标题
+	Heading         string  `json:"heading,omitempty"`        // 小节标题
+	Source          string  `json:"source,omitempty"`         // 文档来源
+	Content         string  `json:"content"`                  // 分块内容
+	Score           float64 `json:"score"`                    // 检索相关度
+}
@@ -0,0 +1,32 @@
+package models
+
+// 记忆范围，决定 Agent 的长期记忆在哪些会话之间共享
+const (
+	MemoryScopeAgent     = "agent"     // 同一 Agent 的所有会话共享
+	MemoryScopeUser      = "user"      // 按用户区分
+	MemoryScopeWorkspace = "workspace" // 按工作目录区分
+)
+
+// IsValidMemoryScope 是否为有效的记忆范围，空字符串表示不启用记忆
+func IsValidMemoryScope(scope string) bool {
+	switch scope {
+	case "", MemoryScopeAgent, MemoryScopeUser, MemoryScopeWorkspace:
+		return true
+	}
+	return false
+}
+
+// Memory Agent 的长期记忆
+type Memory struct {
+	BaseModel
+	AgentID   string `gorm:"column:agent_id;size:36;not null;index" json:"agent_id"` // 所属 Agent
+	UserID    string `gorm:"column:user_id;size:100;index" json:"user_id"`           // 所属用户，为空表示不区分用户
+	Workspace string `gorm:"column:workspace;size:500" json:"workspace"`             // 所属工作区，为空表示不区分工作区
+	Content   string `gorm:"column:content;type:text;not null" json:"content"`       // 记忆内容
+	Tags      string `gorm:"column:tags;size:255" json:"tags"`                       // 标签，逗号分隔
+}
+
+// TableName 返回表名
+func (Memory) TableName() string {
+	return "memories"
+}
@@ -27,6 +27,7 @@ const (
 	MessageStatusCompleted MessageStatus = "completed"
 	MessageStatusFailed    MessageStatus = "failed"
 	MessageStatusStreaming MessageStatus = "streaming"
+	MessageStatusStopped   MessageStatus = "stopped" // 对话被手动停止，内容为已生成的部分
 )
 
 func (s MessageStatus) ToString() string {
@@ -53,13 +54,15 @@ type ToolCall struct {
 }
 
 type ContentBlock struct {
-	Type     string    `json:"type"`                // "text" 或 "tool_call"
+	Type     string    `json:"type"`                // "text"、"tool_call" 或 "citation"
 	Text     string    `json:"text,omitempty"`      // 当 type 为 "text" 时
 	ToolCall *ToolCall `json:"tool_call,omitempty"` // 当 type 为 "tool_call" 时
+	Citation *Citation `json:"citation,omitempty"`  // 当 type 为 "citation" 时
 }
 
 type Attachment struct {
-	Type     string `json:"type"`
+	ID       string `json:"id,omitempty"` // 上传后的附件 ID
+	Type     string `json:"type"`         // "image" 或 "file"
 	URL      string `json:"url"`
 	Name     string `json:"name"`
 	Size     int64  `json:"size"`
@@ -67,13 +70,25 @@ type Attachment struct {
 }
 
 type MessageContent struct {
-	Blocks           []ContentBlock `json:"blocks,omitempty"`
-	Text             string         `json:"text,omitempty"`
-	ToolCalls        []ToolCall     `json:"tool_calls,omitempty"`
-	ReasoningContent string         `json:"reasoning_content,omitempty"`
-	ThinkContent     string         `json:"think_content,omitempty"`
-	IsThink          bool           `json:"is_think,omitempty"`
-	Attachments      []Attachment   `json:"attachments,omitempty"`
+	Blocks           []ContentBlock  `json:"blocks,omitempty"`
+	Text             string          `json:"text,omitempty"`
+	ToolCalls        []ToolCall      `json:"tool_calls,omitempty"`
+	ReasoningContent string          `json:"reasoning_content,omitempty"`
+	ThinkContent     string          `json:"think_content,omitempty"`
+	IsThink          bool            `json:"is_think,omitempty"`
+	Attachments      []Attachment    `json:"attachments,omitempty"`
+	Summary          *SummaryInfo    `json:"summary,omitempty"`
+	Structured       json.RawMessage `json:"structured,omitempty"`  // 通过响应 Schema 校验的最终答复
+	StopReason       string          `json:"stop_reason,omitempty"` // 运行因预算限制提前结束的原因
+}
+
+// SummaryInfo 上下文摘要信息，存在于摘要系统消息中
+type SummaryInfo struct {
+	UntilMessageID string `json:"until_message_id"` // 摘要覆盖到的最后一条消息 ID
+	Rounds         int    `json:"rounds"`           // 本次摘要的对话轮数
+	OldTokens      int    `json:"old_tokens"`       // 被摘要内容的 Token 数
+	SummaryTokens  int    `json:"summary_tokens"`   // 摘要的 Token 数
+	SavedTokens    int    `json:"saved_tokens"`     // 节省的 Token 数
 }
 
 // Message 消息模型
@@ -150,6 +165,15 @@ func (m *Message) SetText(text string) error {
 	return m.SetContent(content)
 }
 
+// IsSummary 是否为上下文摘要消息
+func (m *Message) IsSummary() bool {
+	if m.Type != MessageTypeSystem {
+		return false
+	}
+	content, err := m.GetContent()
+	return err == nil && content.Summary != nil
+}
+
 // AddAttachment 添加附件
 func (m *Message) AddAttachment(attachment Attachment) error {
 	content, err := m.GetContent()
@@ -160,6 +184,30 @@ func (m *Message) AddAttachment(attachment Attachment) error {
 	return m.SetContent(content)
 }
 
+// GetAttachments 获取附件列表
+func (m *Message) GetAttachments() []Attachment {
+	content, err := m.GetContent()
+	if err != nil {
+		return nil
+	}
+	return content.Attachments
+}
+
+// GetCitations 获取答复引用的知识库分块，按引用编号排列
+func (m *Message) GetCitations() []Citation {
+	content, err := m.GetContent()
+	if err != nil {
+		return nil
+	}
+	var citations []Citation
+	for _, block := range content.Blocks {
+		if block.Type == "citation" && block.Citation != nil {
+			citations = append(citations, *block.Citation)
+		}
+	}
+	return citations
+}
+
 // AddFeedback 添加反馈
 func (m *Message) AddFeedback(rating FeedbackRating, comment string) {
 	now := PtrJSONTime(time.Now())
@@ -173,6 +221,15 @@ func (m *Message) HasFeedback() bool {
 	return m.FeedbackRating != nil
 }
 
+// MessageUsage 消息 Token 用量
+type MessageUsage struct {
+	InputTokens     int `json:"input_tokens"`
+	OutputTokens    int `json:"output_tokens"`
+	TotalTokens     int `json:"total_tokens"`
+	CachedTokens    int `json:"cached_tokens"`
+	ReasoningTokens int `json:"reasoning_tokens"`
+}
+
 // MessageHistory 消息历史（用于 Agent 输入）
 type MessageHistory struct {
 	Role    string `json:"role"`
@@ -207,8 +264,14 @@ const (
 	MessageEventCreated   MessageEvent = "message_created"   // 消息创建事件
 	MessageEventContent   MessageEvent = "message_content"   // 助手消息内容事件
 	MessageEventCompleted MessageEvent = "message_completed" // 消息完成事件
+	MessageEventSummary   MessageEvent = "summary_created"   // 上下文摘要事件
 	MessageEventError     MessageEvent = "error"             // 错误事件
 	MessageEventDone      MessageEvent = "done"              // 会话完成事件
+
+	MessageEventToolApproval         MessageEvent = "tool_approval_required" // 工具调用等待审批事件
+	MessageEventToolApprovalResolved MessageEvent = "tool_approval_resolved" // 工具调用审批完成事件
+	MessageEventSubAgent             MessageEvent = "subagent_message"       // 子 Agent 中间消息事件
+	MessageEventToolProgress         MessageEvent = "tool_progress"          // 工具执行输出事件
 )
 
 func (e MessageEvent) ToString() string {
@@ -0,0 +1,18 @@
+package models
+
+// Prompt 提示词库中的片段
+// 同名片段按版本保存，每次修改生成新版本，Agent 指令中通过 {{include "name"}} 引用最新版本，
+// 通过 {{include "name@2"}} 引用指定版本
+type Prompt struct {
+	BaseModel
+	Name        string `gorm:"column:name;size:100;not null;uniqueIndex:idx_prompt_name_version" json:"name"` // 名称，同名片段共享版本序列
+	Version     int    `gorm:"column:version;not null;uniqueIndex:idx_prompt_name_version" json:"version"`    // 版本号，从 1 开始递增
+	Description string `gorm:"column:description;size:500" json:"description"`                                // 说明
+	Content     string `gorm:"column:content;type:text" json:"content"`                                       // 片段内容，按 text/template 渲染
+	Tags        string `gorm:"column:tags;size:255" json:"tags"`                                              // 标签，逗号分隔
+}
+
+// TableName 返回表名
+func (Prompt) TableName() string {
+	return "prompts"
+}
@@ -1,16 +1,46 @@
 package models
 
+import "encoding/json"
+
+// ProviderTypeOpenAI 默认提供商类型（OpenAI 兼容接口）
+const ProviderTypeOpenAI = "openai"
+
 type Provider struct {
 	BaseModel
-	Name        string  `gorm:"column:name;size:255;not null" json:"name"`
-	BaseUrl     string  `gorm:"column:base_url;size:255;not null" json:"base_url"`
-	ApiKey      string  `gorm:"column:api_key;size:255;not null" json:"api_key"`
-	Model       string  `gorm:"column:model;size:255;not null" json:"model"`
-	Temperature float32 `gorm:"column:temperature;default:0.7" json:"temperature"`
-	MaxTokens   int     `gorm:"column:max_tokens;default:2048" json:"max_tokens"`
-	IsDefault   bool    `gorm:"column:is_default;default:false" json:"is_default"`
+	Name                string   `gorm:"column:name;size:255;not null" json:"name"`
+	Type                string   `gorm:"column:type;size:50;default:'openai'" json:"type"` // 提供商类型：openai, claude, gemini, ollama, azure
+	BaseUrl             string   `gorm:"column:base_url;size:255;not null" json:"base_url"`
+	ApiKey              string   `gorm:"column:api_key;size:255;not null" json:"api_key"`
+	Model               string   `gorm:"column:model;size:255;not null" json:"model"`
+	Temperature         float32  `gorm:"column:temperature;default:0.7" json:"temperature"`
+	MaxTokens           int      `gorm:"column:max_tokens;default:2048" json:"max_tokens"`
+	IsDefault           bool     `gorm:"column:is_default;default:false" json:"is_default"`
+	Extra               string   `gorm:"column:extra;type:text" json:"extra,omitempty"`                       // 提供商专属参数（JSON）
+	FallbackProviderIDs StrArray `gorm:"column:fallback_provider_ids;type:text" json:"fallback_provider_ids"` // 备用提供商 ID 列表，当前提供商限流或不可用时按顺序切换
+	SupportsVision      bool     `gorm:"column:supports_vision;default:false" json:"supports_vision"`         // 模型是否支持图片输入
 }
 
 func (table *Provider) TableName() string {
 	return "providers"
 }
+
+// GetType 获取提供商类型，未设置时视为 OpenAI 兼容接口
+func (table *Provider) GetType() string {
+	if table.Type == "" {
+		return ProviderTypeOpenAI
+	}
+	return table.Type
+}
+
+// GetExtra 解析提供商专属参数
+func (table *Provider) GetExtra() map[string]interface{} {
+	if table.Extra == "" {
+		return nil
+	}
+
+	var extra map[string]interface{}
+	if err := json.Unmarshal([]byte(table.Extra), &extra); err != nil {
+		return nil
+	}
+	return extra
+}
@@ -29,6 +29,9 @@ type SessionConfig struct {
 	KeepRounds      int      `json:"keep_rounds"`
 	EnableRateLimit bool     `json:"enable_rate_limit"`
 	RateLimitRPM    int      `json:"rate_limit_rpm"`
+
+	Locale     string            `json:"locale,omitempty"`      // 用户的语言区域，渲染 Agent 指令中的 {{.Locale}}，为空时使用请求的 Accept-Language
+	PromptVars map[string]string `json:"prompt_vars,omitempty"` // 自定义提示词变量，Agent 指令中通过 {{.Vars.name}} 引用
 }
 
 // DefaultSessionConfig 返回默认配置
@@ -7,17 +7,23 @@ import (
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
-	Log      LogConfig
+	Server     ServerConfig
+	Database   DatabaseConfig
+	JWT        JWTConfig
+	Log        LogConfig
+	Upload     UploadConfig
+	Checkpoint CheckpointConfig
+	Process    ProcessConfig
+	Terminal   TerminalConfig
 }
 
 type ServerConfig struct {
-	Port         string `mapstructure:"port"`
-	Mode         string `mapstructure:"mode"`
-	ReadTimeout  int    `mapstructure:"read_timeout"`
-	WriteTimeout int    `mapstructure:"write_timeout"`
+	Port           string   `mapstructure:"port"`
+	Mode           string   `mapstructure:"mode"`
+	ReadTimeout    int      `mapstructure:"read_timeout"`
+	WriteTimeout   int      `mapstructure:"write_timeout"`
+	APIKey         string   `mapstructure:"api_key"`         // 访问 /api 接口需要的 API Key（X-API-Key 请求头或 api_key 查询参数），为空时不校验
+	AllowedOrigins []string `mapstructure:"allowed_origins"` // 允许建立 WebSocket 连接的页面来源，与服务同源的页面总是允许
 }
 
 type DatabaseConfig struct {
@@ -41,6 +47,24 @@ type LogConfig struct {
 	Compress   bool   `mapstructure:"compress"`
 }
 
+type UploadConfig struct {
+	Path    string `mapstructure:"path"`     // 附件存储目录，文件按内容哈希寻址
+	MaxSize int    `mapstructure:"max_size"` // 单个附件大小上限（MB）
+}
+
+type CheckpointConfig struct {
+	Path    string `mapstructure:"path"`     // 检查点存储目录，文件内容按哈希寻址
+	MaxSize int    `mapstructure:"max_size"` // 保存内容的单个文件大小上限（MB），超过时无法恢复
+}
+
+type ProcessConfig struct {
+	MaxPerSession int `mapstructure:"max_per_session"` // 每个会话可同时运行的后台进程数上限
+}
+
+type TerminalConfig struct {
+	MaxPerSession int `mapstructure:"max_per_session"` // 每个会话可同时打开的终端数上限
+}
+
 var cfg *Config
 
 func Load(path string) *Config {
@@ -56,6 +80,7 @@ func Load(path string) *Config {
 	viper.SetDefault("server.mode", "debug")
 	viper.SetDefault("server.read_timeout", 300)
 	viper.SetDefault("server.write_timeout", 300)
+	viper.SetDefault("server.allowed_origins", []string{"http://localhost:34115", "http://127.0.0.1:34115", "http://wails.localhost", "http://wails.localhost:34115", "wails://wails"})
 	viper.SetDefault("database.name", "root/data/iano_chat.db")
 	viper.SetDefault("database.max_open_conns", 25)
 	viper.SetDefault("database.max_idle_conns", 5)
@@ -67,6 +92,12 @@ func Load(path string) *Config {
 	viper.SetDefault("log.max_backups", 30)
 	viper.SetDefault("log.max_age", 7)
 	viper.SetDefault("log.compress", true)
+	viper.SetDefault("upload.path", "root/cache/attachments")
+	viper.SetDefault("upload.max_size", 20)
+	viper.SetDefault("checkpoint.path", "root/cache/checkpoints")
+	viper.SetDefault("checkpoint.max_size", 20)
+	viper.SetDefault("process.max_per_session", 5)
+	viper.SetDefault("terminal.max_per_session", 3)
 
 	viper.AutomaticEnv()
 
@@ -2,6 +2,9 @@ package routes
 
 import (
 	"net/http"
+	"net/url"
+	"slices"
+	"strings"
 	"time"
 
 	"iano_server/container"
@@ -17,9 +20,17 @@ func SetupRoutes(cnr *container.Container) *web.Engine {
 	engine.SetWriteTimeout(time.Duration(cnr.GetConfig().Server.WriteTimeout) * time.Second)
 	engine.SetGracefulShutdown(true)
 
-	engine.Use(webMiddleware.CORS())
+	cors := webMiddleware.DefaultCORSConfig
+	cors.AllowHeaders = append(append([]string{}, cors.AllowHeaders...), "X-API-Key")
+	engine.Use(webMiddleware.CORSWithConfig(cors))
 	engine.Use(webMiddleware.Recovery())
 	engine.Use(webMiddleware.Logger())
+	if apiKey := cnr.GetConfig().Server.APIKey; apiKey != "" {
+		engine.Use(apiKeyAuth(apiKey))
+	}
+
+	// 浏览器不限制跨站 WebSocket，只允许同源页面和配置中的来源连接
+	web.SetWebSocketConfig(web.WebSocketConfig{CheckOrigin: checkOrigin(cnr.GetConfig().Server.AllowedOrigins)})
 
 	docs.SwaggerInfo.Title = "IANO Chat API"
 	docs.SwaggerInfo.Description = "IANO Chat 是一个智能对话系统，支持多 Agent、工具调用、流式响应等功能。"
@@ -112,17 +123,55 @@ func SetupRoutes(cnr *container.Container) *web.Engine {
 	engine.DELETE("/api/sessions/:id", cnr.SessionController.Delete)
 	engine.GET("/api/sessions/:id/config", cnr.SessionController.GetConfig)
 	engine.PUT("/api/sessions/:id/config", cnr.SessionController.UpdateConfig)
+	engine.GET("/api/sessions/:id/checkpoints", cnr.CheckpointController.List)
+	engine.GET("/api/sessions/:id/checkpoints/:message_id/diff", cnr.CheckpointController.Diff)
+	engine.POST("/api/sessions/:id/checkpoints/:message_id/restore", cnr.CheckpointController.Restore)
+	engine.GET("/api/sessions/:id/processes", cnr.SessionController.ListProcesses)
+	engine.POST("/api/sessions/:id/processes/:name/stop", cnr.SessionController.StopProcess)
+	engine.GET("/api/sessions/:id/terminals", cnr.TerminalController.List)
+	engine.POST("/api/sessions/:id/terminals/:name/close", cnr.TerminalController.Close)
+	engine.GET("/api/sessions/:id/terminals/:name/ws", cnr.TerminalController.Attach)
 
 	engine.POST("/api/providers", cnr.ProviderController.Create)
 	engine.GET("/api/providers", cnr.ProviderController.GetAll)
 	engine.GET("/api/providers/default", cnr.ProviderController.GetDefault)
+	engine.POST("/api/providers/models", cnr.ProviderController.DiscoverModels)
 	engine.GET("/api/providers/:id", cnr.ProviderController.GetByID)
 	engine.PUT("/api/providers/:id", cnr.ProviderController.Update)
 	engine.DELETE("/api/providers/:id", cnr.ProviderController.Delete)
 
+	engine.POST("/api/prompts", cnr.PromptController.Create)
+	engine.GET("/api/prompts", cnr.PromptController.GetAll)
+	engine.GET("/api/prompts/:id", cnr.PromptController.GetByID)
+	engine.GET("/api/prompts/:id/versions", cnr.PromptController.GetVersions)
+	engine.PUT("/api/prompts/:id", cnr.PromptController.Update)
+	engine.DELETE("/api/prompts/:id", cnr.PromptController.Delete)
+
+	engine.GET("/api/memories", cnr.MemoryController.List)
+	engine.DELETE("/api/memories", cnr.MemoryController.DeleteByAgent)
+	engine.GET("/api/memories/:id", cnr.MemoryController.GetByID)
+	engine.DELETE("/api/memories/:id", cnr.MemoryController.Delete)
+
+	engine.POST("/api/knowledge-bases", cnr.KnowledgeController.Create)
+	engine.GET("/api/knowledge-bases", cnr.KnowledgeController.GetAll)
+	engine.GET("/api/knowledge-bases/:id", cnr.KnowledgeController.GetByID)
+	engine.PUT("/api/knowledge-bases/:id", cnr.KnowledgeController.Update)
+	engine.DELETE("/api/knowledge-bases/:id", cnr.KnowledgeController.Delete)
+	engine.GET("/api/knowledge-bases/:id/documents", cnr.KnowledgeController.ListDocuments)
+	engine.POST("/api/knowledge-bases/:id/documents", cnr.KnowledgeController.Ingest)
+	engine.DELETE("/api/knowledge-bases/:id/documents/:doc_id", cnr.KnowledgeController.DeleteDocument)
+	engine.GET("/api/knowledge-bases/:id/search", cnr.KnowledgeController.Search)
+
 	engine.POST("/api/chat/stream", cnr.ChatController.StreamChat)
+	engine.POST("/api/chat/stop", cnr.ChatController.StopChat)
+	engine.POST("/api/chat/approve", cnr.ChatController.ApproveToolCall)
+	engine.GET("/api/chat/approvals/:session_id", cnr.ChatController.GetPendingApprovals)
 	engine.DELETE("/api/chat/session/:session_id", cnr.ChatController.ClearSession)
 
+	engine.POST("/api/attachments", cnr.AttachmentController.Upload)
+	engine.GET("/api/attachments/:id", cnr.AttachmentController.GetByID)
+	engine.GET("/api/attachments/:id/content", cnr.AttachmentController.Content)
+
 	engine.POST("/api/mcp/servers", cnr.MCPController.CreateServer)
 	engine.GET("/api/mcp/servers", cnr.MCPController.GetAllServers)
 	engine.GET("/api/mcp/servers/:id", cnr.MCPController.GetServerByID)
@@ -137,3 +186,29 @@ func SetupRoutes(cnr *container.Container) *web.Engine {
 
 	return engine
 }
+
+// apiKeyAuth 校验 /api 接口的 API Key，WebSocket 无法设置请求头时通过 api_key 查询参数传递
+func apiKeyAuth(key string) web.HandlerFunc {
+	auth := webMiddleware.APIKeyAuth(key)
+	return func(c *web.Context) {
+		if !strings.HasPrefix(c.Request.URL.Path, "/api/") {
+			c.Next()
+			return
+		}
+		auth(c)
+	}
+}
+
+// checkOrigin 允许没有 Origin 的非浏览器客户端、与服务同源的页面和 allowed 中的来源
+func checkOrigin(allowed []string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+			return true
+		}
+		return slices.Contains(allowed, origin)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	iano "iano_agent"
+	agentmodel "iano_agent/model"
+	"iano_agent/tools"
 	script_engine "iano_script_engine"
 	"iano_server/models"
 	web "iano_web"
@@ -13,7 +15,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/cloudwego/eino-ext/components/model/openai"
 	"github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/schema"
 	"gorm.io/gorm"
@@ -166,12 +167,24 @@ func (m *AgentSSEClientMap) StartHeartbeat() {
 // AgentRuntimeService Agent 运行时服务
 // 完全解耦会话和 Agent，只负责根据 Agent 配置创建和使用 Agent 实例
 type AgentRuntimeService struct {
-	db              *gorm.DB
-	agentService    *AgentService
-	providerService *ProviderService
-	toolService     *ToolService
-	mcpService      *MCPService
-	modelCache      map[string]model.ToolCallingChatModel
+	db               *gorm.DB
+	agentService     *AgentService
+	providerService  *ProviderService
+	toolService      *ToolService
+	mcpService       *MCPService
+	promptService    *PromptService         // 渲染指令时加载 include 引用的提示词片段
+	memoryService    *MemoryService         // Agent 的长期记忆存储
+	knowledgeService *KnowledgeService      // Agent 绑定的知识库检索
+	processManager   *tools.ProcessManager  // 按会话管理 Agent 启动的后台进程
+	terminalManager  *tools.TerminalManager // 按会话管理 Agent 打开的终端
+	modelCache       map[string]*cachedModel
+	modelMux         sync.RWMutex
+}
+
+// cachedModel 缓存的 ChatModel 及创建时 Provider 的更新时间，Provider 更新后旧模型不再命中
+type cachedModel struct {
+	model     model.ToolCallingChatModel
+	updatedAt time.Time
 }
 
 // NewAgentRuntimeService 创建 Agent 运行时服务
@@ -181,13 +194,15 @@ func NewAgentRuntimeService(
 	providerService *ProviderService,
 	toolService *ToolService,
 ) *AgentRuntimeService {
-	return &AgentRuntimeService{
+	s := &AgentRuntimeService{
 		db:              db,
 		agentService:    agentService,
 		providerService: providerService,
 		toolService:     toolService,
-		modelCache:      make(map[string]model.ToolCallingChatModel),
+		modelCache:      make(map[string]*cachedModel),
 	}
+	providerService.OnChange(s.InvalidateModel)
+	return s
 }
 
 // NewAgentRuntimeServiceWithMCP 创建带 MCP 支持的 Agent 运行时服务
@@ -197,21 +212,36 @@ func NewAgentRuntimeServiceWithMCP(
 	providerService *ProviderService,
 	toolService *ToolService,
 	mcpService *MCPService,
+	promptService *PromptService,
+	memoryService *MemoryService,
+	knowledgeService *KnowledgeService,
+	processManager *tools.ProcessManager,
+	terminalManager *tools.TerminalManager,
 ) *AgentRuntimeService {
-	return &AgentRuntimeService{
-		db:              db,
-		agentService:    agentService,
-		providerService: providerService,
-		toolService:     toolService,
-		mcpService:      mcpService,
-		modelCache:      make(map[string]model.ToolCallingChatModel),
-	}
+	s := &AgentRuntimeService{
+		db:               db,
+		agentService:     agentService,
+		providerService:  providerService,
+		toolService:      toolService,
+		mcpService:       mcpService,
+		promptService:    promptService,
+		memoryService:    memoryService,
+		knowledgeService: knowledgeService,
+		processManager:   processManager,
+		terminalManager:  terminalManager,
+		modelCache:       make(map[string]*cachedModel),
+	}
+	providerService.OnChange(s.InvalidateModel)
+	return s
 }
 
 type AgentParams struct {
-	AgentID  string
-	WorkDir  string
-	Callback iano.MessageCallback
+	AgentID         string
+	WorkDir         string
+	UserID          string // 发起对话的用户，按用户区分记忆时使用
+	SessionID       string // 所属会话，后台进程和终端按会话记录，会话结束时停止
+	Callback        iano.MessageCallback
+	ApprovalHandler iano.ApprovalHandler // 工具调用审批处理函数
 }
 
 // GetAgent 根据 Agent ID 获取 Agent 实例
@@ -223,7 +253,17 @@ func (s *AgentRuntimeService) GetAgent(ctx context.Context, params *AgentParams)
 		return nil, fmt.Errorf("agent not found: %w", err)
 	}
 
-	chatModel, err := s.getOrCreateChatModel(ctx, agent.ProviderID)
+	return s.newAgent(ctx, agent, params)
+}
+
+// newAgent 根据 Agent 配置创建 Agent 实例
+func (s *AgentRuntimeService) newAgent(ctx context.Context, agent *models.Agent, params *AgentParams) (*AgentWrapper, error) {
+	provider, err := s.resolveProvider(agent.ProviderID)
+	if err != nil {
+		return nil, err
+	}
+
+	chatModel, err := s.getAgentChatModel(ctx, agent, provider)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get chat model: %w", err)
 	}
@@ -232,8 +272,12 @@ func (s *AgentRuntimeService) GetAgent(ctx context.Context, params *AgentParams)
 	allowedCommands := s.getAllowedCommands(allowedTools)
 
 	opts := []iano.Option{
+		iano.WithName(agent.Name),
 		iano.WithSystemPrompt(agent.Instructions),
 	}
+	if s.promptService != nil {
+		opts = append(opts, iano.WithPromptLoader(s.promptService.Load))
+	}
 	if len(allowedTools) > 0 {
 		opts = append(opts, iano.WithAllowedTools(allowedTools))
 	}
@@ -243,6 +287,25 @@ func (s *AgentRuntimeService) GetAgent(ctx context.Context, params *AgentParams)
 	if len(allowedCommands) > 0 {
 		opts = append(opts, iano.WithAllowedCommands(allowedCommands))
 	}
+	if agent.AllowGitWrite {
+		opts = append(opts, iano.WithGitWrite(true))
+	}
+	opts = append(opts, s.approvalOptions(agent, params.ApprovalHandler)...)
+	if agent.MaxDelegationDepth > 0 {
+		opts = append(opts, iano.WithMaxDelegationDepth(agent.MaxDelegationDepth))
+	}
+	opts = append(opts, s.responseSchemaOptions(agent)...)
+	opts = append(opts, s.budgetOptions(agent)...)
+	opts = append(opts, s.memoryOptions(agent, params)...)
+	if s.knowledgeService != nil && len(agent.KnowledgeBaseIDs) > 0 {
+		opts = append(opts, iano.WithKnowledgeBases(s.knowledgeService, agent.KnowledgeBaseIDs...))
+	}
+	if s.processManager != nil && params.SessionID != "" {
+		opts = append(opts, iano.WithProcessManager(s.processManager, params.SessionID))
+	}
+	if s.terminalManager != nil && params.SessionID != "" {
+		opts = append(opts, iano.WithTerminalManager(s.terminalManager, params.SessionID))
+	}
 
 	agentInstance, err := iano.NewAgent(chatModel, opts...)
 	if err != nil {
@@ -253,19 +316,127 @@ func (s *AgentRuntimeService) GetAgent(ctx context.Context, params *AgentParams)
 	agentInstance.AppendCB(params.Callback)
 
 	if err := s.loadToolsToAgent(ctx, agentInstance, agent); err != nil {
-		slog.Warn("Failed to load tools to agent", "agentID", params.AgentID, "error", err)
+		slog.Warn("Failed to load tools to agent", "agentID", agent.ID, "error", err)
 	}
 
+	s.loadSubAgents(agentInstance, agent, params)
+
 	return &AgentWrapper{
-		Agent:  agentInstance,
-		Config: agent,
+		Agent:      agentInstance,
+		Config:     agent,
+		ProviderID: provider.ID,
 	}, nil
 }
 
+// loadSubAgents 将 Agent 关联的子 Agent 注册为 delegate_to_<name> 工具
+// 子 Agent 在每次委派时按其自身的指令、工具和提供商创建，与主 Agent 共享工作目录
+func (s *AgentRuntimeService) loadSubAgents(agentInstance *iano.Agent, config *models.Agent, params *AgentParams) {
+	for _, subID := range config.SubAgentIDs {
+		if subID == "" || subID == config.ID {
+			continue
+		}
+
+		sub, err := s.agentService.GetByID(subID)
+		if err != nil {
+			slog.Warn("子 Agent 不存在", "agentID", config.ID, "subAgentID", subID, "error", err)
+			continue
+		}
+		if !sub.IsSubAgentType() {
+			slog.Warn("关联的 Agent 不是子 Agent，已忽略", "agentID", config.ID, "subAgentID", subID)
+			continue
+		}
+
+		err = agentInstance.AddSubAgent(&iano.SubAgentConfig{
+			Name:        sub.Name,
+			Description: sub.Description,
+			TokenBudget: sub.TokenBudget,
+			Factory: func(ctx context.Context) (*iano.Agent, error) {
+				wrapper, err := s.newAgent(ctx, sub, &AgentParams{AgentID: sub.ID, WorkDir: params.WorkDir, UserID: params.UserID, SessionID: params.SessionID})
+				if err != nil {
+					return nil, err
+				}
+				return wrapper.Agent, nil
+			},
+		})
+		if err != nil {
+			slog.Warn("注册子 Agent 失败", "agentID", config.ID, "subAgentID", subID, "error", err)
+		}
+	}
+}
+
+// memoryOptions 根据 Agent 的记忆范围生成长期记忆选项，未启用记忆时为空
+func (s *AgentRuntimeService) memoryOptions(agent *models.Agent, params *AgentParams) []iano.Option {
+	if s.memoryService == nil || agent.MemoryScope == "" {
+		return nil
+	}
+
+	scope := tools.MemoryScope{AgentID: agent.ID}
+	switch agent.MemoryScope {
+	case models.MemoryScopeUser:
+		scope.UserID = params.UserID
+	case models.MemoryScopeWorkspace:
+		scope.Workspace = params.WorkDir
+	}
+	return []iano.Option{iano.WithMemory(s.memoryService, scope)}
+}
+
+// approvalOptions 根据 Agent 配置生成工具审批选项
+func (s *AgentRuntimeService) approvalOptions(agent *models.Agent, handler iano.ApprovalHandler) []iano.Option {
+	opts := []iano.Option{iano.WithApprovalHandler(handler)}
+	if agent.ApprovalPolicy != "" {
+		opts = append(opts, iano.WithApprovalPolicy(iano.ApprovalPolicy(agent.ApprovalPolicy)))
+	}
+	if approvals := agent.GetToolApprovals(); len(approvals) > 0 {
+		toolApprovals := make(map[string]iano.ApprovalPolicy, len(approvals))
+		for name, policy := range approvals {
+			toolApprovals[name] = iano.ApprovalPolicy(policy)
+		}
+		opts = append(opts, iano.WithToolApprovals(toolApprovals))
+	}
+	return opts
+}
+
+// responseSchemaOptions 根据 Agent 配置生成结构化输出选项，Schema 无效时忽略
+func (s *AgentRuntimeService) responseSchemaOptions(agent *models.Agent) []iano.Option {
+	if agent.ResponseSchema == "" {
+		return nil
+	}
+
+	responseSchema, err := iano.ParseResponseSchema([]byte(agent.ResponseSchema))
+	if err != nil {
+		slog.Warn("Agent 的响应 Schema 无效，已忽略", "agentID", agent.ID, "error", err)
+		return nil
+	}
+	opts := []iano.Option{iano.WithResponseSchema(responseSchema)}
+	if agent.MaxSchemaRetries > 0 {
+		opts = append(opts, iano.WithMaxSchemaRetries(agent.MaxSchemaRetries))
+	}
+	return opts
+}
+
+// budgetOptions 根据 Agent 配置生成单次运行的预算选项
+func (s *AgentRuntimeService) budgetOptions(agent *models.Agent) []iano.Option {
+	return []iano.Option{
+		iano.WithMaxRounds(agent.MaxRounds),
+		iano.WithMaxToolCalls(agent.MaxToolCalls),
+		iano.WithTokenBudget(agent.TokenBudget),
+		iano.WithRunTimeout(time.Duration(agent.RunTimeout) * time.Second),
+	}
+}
+
 // AgentWrapper Agent 包装器
 type AgentWrapper struct {
-	Agent  *iano.Agent
-	Config *models.Agent
+	Agent      *iano.Agent
+	Config     *models.Agent
+	ProviderID string // 首选提供商 ID
+}
+
+// AnsweredProviderID 最近一次对话实际响应的提供商 ID
+func (w *AgentWrapper) AnsweredProviderID() string {
+	if id := w.Agent.GetRunProvider(); id != "" {
+		return id
+	}
+	return w.ProviderID
 }
 
 // Chat 执行对话
@@ -286,50 +457,112 @@ func (w *AgentWrapper) GetAgentInfo() map[string]interface{} {
 	}
 }
 
-// getOrCreateChatModel 获取或创建 ChatModel
-func (s *AgentRuntimeService) getOrCreateChatModel(ctx context.Context, providerID string) (model.ToolCallingChatModel, error) {
-	if providerID != "" {
-		if m, exists := s.modelCache[providerID]; exists {
-			return m, nil
-		}
-
-		provider, err := s.providerService.GetByID(providerID)
+// resolveProvider 获取 Provider，providerID 为空时使用默认 Provider
+func (s *AgentRuntimeService) resolveProvider(providerID string) (*models.Provider, error) {
+	if providerID == "" {
+		defaultProvider, err := s.providerService.GetDefault()
 		if err != nil {
-			return nil, fmt.Errorf("provider not found: %w", err)
+			return nil, fmt.Errorf("no default provider configured")
 		}
+		providerID = defaultProvider.ID
+	}
 
-		return s.createChatModelFromProvider(ctx, provider)
+	provider, err := s.providerService.GetByID(providerID)
+	if err != nil {
+		return nil, fmt.Errorf("provider not found: %w", err)
 	}
+	return provider, nil
+}
 
-	defaultProvider, err := s.providerService.GetDefault()
+// getAgentChatModel 获取 Agent 使用的 ChatModel，配置了备用提供商时组装为故障转移模型
+func (s *AgentRuntimeService) getAgentChatModel(ctx context.Context, agent *models.Agent, provider *models.Provider) (model.ToolCallingChatModel, error) {
+	primary, err := s.getOrCreateChatModel(ctx, provider)
 	if err != nil {
-		return nil, fmt.Errorf("no default provider configured")
+		return nil, err
 	}
 
-	if m, exists := s.modelCache[defaultProvider.ID]; exists {
-		return m, nil
+	fallbackIDs := agent.FallbackProviderIDs
+	if len(fallbackIDs) == 0 {
+		fallbackIDs = provider.FallbackProviderIDs
+	}
+	if len(fallbackIDs) == 0 {
+		return primary, nil
+	}
+
+	targets := []agentmodel.FailoverTarget{{Name: provider.ID, Model: primary}}
+	seen := map[string]bool{provider.ID: true}
+	for _, id := range fallbackIDs {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		fallback, err := s.providerService.GetByID(id)
+		if err != nil {
+			slog.Warn("Fallback provider not found", "providerID", id, "error", err)
+			continue
+		}
+		m, err := s.getOrCreateChatModel(ctx, fallback)
+		if err != nil {
+			slog.Warn("Failed to create fallback chat model", "providerID", id, "error", err)
+			continue
+		}
+		targets = append(targets, agentmodel.FailoverTarget{Name: fallback.ID, Model: m})
+	}
+
+	if len(targets) == 1 {
+		return primary, nil
 	}
+	return agentmodel.NewFailoverChatModel(targets, nil)
+}
 
-	return s.createChatModelFromProvider(ctx, defaultProvider)
+// getOrCreateChatModel 获取或创建 Provider 的 ChatModel
+func (s *AgentRuntimeService) getOrCreateChatModel(ctx context.Context, provider *models.Provider) (model.ToolCallingChatModel, error) {
+	if m, exists := s.getCachedModel(provider); exists {
+		return m, nil
+	}
+	return s.createChatModelFromProvider(ctx, provider)
 }
 
-// createChatModelFromProvider 从 Provider 创建 ChatModel
-func (s *AgentRuntimeService) createChatModelFromProvider(ctx context.Context, provider *models.Provider) (model.ToolCallingChatModel, error) {
-	chatModel, err := openai.NewChatModel(ctx, &openai.ChatModelConfig{
-		BaseURL:     provider.BaseUrl,
-		APIKey:      provider.ApiKey,
-		Model:       provider.Model,
-		Temperature: &provider.Temperature,
-		MaxTokens:   &provider.MaxTokens,
-	})
+// createChatModelFromProvider 通过模型工厂注册表从 Provider 创建 ChatModel
+//
+// 创建期间 Provider 可能被更新，缓存中已有更新版本的模型时不覆盖，本次仍返回按传入配置创建的模型。
+func (s *AgentRuntimeService) createChatModelFromProvider(_ context.Context, provider *models.Provider) (model.ToolCallingChatModel, error) {
+	chatModel, err := agentmodel.CreateModel(s.providerService.ModelConfig(provider))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create chat model: %w", err)
 	}
 
-	s.modelCache[provider.ID] = chatModel
+	s.modelMux.Lock()
+	defer s.modelMux.Unlock()
+	if cached, ok := s.modelCache[provider.ID]; ok && cached.updatedAt.After(provider.UpdatedAt) {
+		return chatModel, nil
+	}
+	s.modelCache[provider.ID] = &cachedModel{model: chatModel, updatedAt: provider.UpdatedAt}
 	return chatModel, nil
 }
 
+// getCachedModel 获取缓存的 ChatModel，缓存按更早的 Provider 配置创建时视为未命中
+func (s *AgentRuntimeService) getCachedModel(provider *models.Provider) (model.ToolCallingChatModel, bool) {
+	s.modelMux.RLock()
+	defer s.modelMux.RUnlock()
+	cached, exists := s.modelCache[provider.ID]
+	if !exists || !cached.updatedAt.Equal(provider.UpdatedAt) {
+		return nil, false
+	}
+	return cached.model, true
+}
+
+// InvalidateModel 失效指定 Provider 的 ChatModel 缓存和熔断状态，Provider 更新或删除后调用
+func (s *AgentRuntimeService) InvalidateModel(providerID string) {
+	s.modelMux.Lock()
+	delete(s.modelCache, providerID)
+	s.modelMux.Unlock()
+
+	agentmodel.GlobalBreakers.Reset(providerID)
+}
+
 // parseTools 解析工具列表
 func (s *AgentRuntimeService) parseTools(toolsStr string) []string {
 	if toolsStr == "" {
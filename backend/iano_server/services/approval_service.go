@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	iano "iano_agent"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PendingApproval 等待审批的工具调用
+type PendingApproval struct {
+	ID         string    `json:"id"`           // 审批 ID
+	SessionID  string    `json:"session_id"`   // 所属会话
+	ToolCallID string    `json:"tool_call_id"` // 工具调用 ID
+	ToolName   string    `json:"tool"`         // 工具名称
+	Arguments  string    `json:"arguments"`    // 调用参数（JSON）
+	ExpiresAt  time.Time `json:"expires_at"`   // 超时时间，超时视为拒绝
+	CreatedAt  time.Time `json:"created_at"`
+
+	result chan *iano.ApprovalDecision
+}
+
+// ApprovalService 工具调用审批服务
+//
+// Agent 在执行需要审批的工具前通过 Register 登记请求并通过 Wait 阻塞，
+// 客户端调用 /api/chat/approve 后由 Resolve 唤醒。
+type ApprovalService struct {
+	mux     sync.Mutex
+	pending map[string]*PendingApproval
+}
+
+func NewApprovalService() *ApprovalService {
+	return &ApprovalService{
+		pending: make(map[string]*PendingApproval),
+	}
+}
+
+// Register 登记审批请求
+func (s *ApprovalService) Register(sessionID string, req *iano.ApprovalRequest) *PendingApproval {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	pending := &PendingApproval{
+		ID:         uuid.New().String(),
+		SessionID:  sessionID,
+		ToolCallID: req.ID,
+		ToolName:   req.ToolName,
+		Arguments:  req.Arguments,
+		ExpiresAt:  req.CreatedAt.Add(req.Timeout),
+		CreatedAt:  req.CreatedAt,
+		result:     make(chan *iano.ApprovalDecision, 1),
+	}
+	s.pending[pending.ID] = pending
+	return pending
+}
+
+// Wait 等待审批结果，ctx 结束（包括超时）时返回 ctx 的错误
+func (s *ApprovalService) Wait(ctx context.Context, pending *PendingApproval) (*iano.ApprovalDecision, error) {
+	defer s.remove(pending.ID)
+
+	select {
+	case decision := <-pending.result:
+		return decision, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Resolve 提交审批结果
+func (s *ApprovalService) Resolve(id string, approved bool, reason string) (*PendingApproval, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	pending, ok := s.pending[id]
+	if !ok {
+		return nil, fmt.Errorf("审批请求不存在或已过期")
+	}
+	delete(s.pending, id)
+
+	pending.result <- &iano.ApprovalDecision{Approved: approved, Reason: reason}
+	return pending, nil
+}
+
+// ListBySession 获取会话中等待审批的请求
+func (s *ApprovalService) ListBySession(sessionID string) []*PendingApproval {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	result := make([]*PendingApproval, 0)
+	for _, p := range s.pending {
+		if p.SessionID == sessionID {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+func (s *ApprovalService) remove(id string) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	delete(s.pending, id)
+}
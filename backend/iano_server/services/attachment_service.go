@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"iano_agent/tools"
+	"iano_server/models"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+	"gorm.io/gorm"
+)
+
+// MaxInlineTextSize 作为上下文内联给模型的文本附件大小上限
+const MaxInlineTextSize = 64 << 10
+
+// AttachmentService 附件服务，文件按内容的 SHA-256 存储在 dir 下
+type AttachmentService struct {
+	db      *gorm.DB
+	dir     string
+	maxSize int64
+}
+
+func NewAttachmentService(db *gorm.DB, dir string, maxSize int64) *AttachmentService {
+	return &AttachmentService{
+		db:      db,
+		dir:     dir,
+		maxSize: maxSize,
+	}
+}
+
+// Save 保存上传的文件，相同内容的文件只在磁盘上保存一份
+//
+// 文件类型根据内容判断，不使用客户端声明的 Content-Type。
+func (s *AttachmentService) Save(name string, r io.Reader) (*models.File, error) {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建附件目录失败: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(s.dir, "upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := sha256.New()
+	var head headBuffer
+	size, err := io.Copy(io.MultiWriter(tmp, hash, &head), io.LimitReader(r, s.maxSize+1))
+	tmp.Close()
+	if err != nil {
+		return nil, fmt.Errorf("保存附件失败: %w", err)
+	}
+	if size > s.maxSize {
+		return nil, fmt.Errorf("附件大小超过上限 %d MB", s.maxSize>>20)
+	}
+
+	file := &models.File{
+		Name:     filepath.Base(name),
+		MimeType: detectMimeType(name, head.data),
+		Size:     size,
+		Hash:     hex.EncodeToString(hash.Sum(nil)),
+	}
+	file.NewID()
+
+	path := s.Path(file)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, fmt.Errorf("创建附件目录失败: %w", err)
+		}
+		if err := os.Rename(tmp.Name(), path); err != nil {
+			return nil, fmt.Errorf("保存附件失败: %w", err)
+		}
+	}
+
+	if err := s.db.Create(file).Error; err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// SaveArtifact 将工具产生的文件（例如命令的完整日志）保存为附件，下载地址为附件内容接口
+func (s *AttachmentService) SaveArtifact(ctx context.Context, name string, r io.Reader) (*tools.Artifact, error) {
+	file, err := s.Save(name, r)
+	if err != nil {
+		return nil, err
+	}
+	return &tools.Artifact{
+		ID:   file.ID,
+		Name: file.Name,
+		Size: file.Size,
+		URL:  "/api/attachments/" + file.ID + "/content",
+	}, nil
+}
+
+func (s *AttachmentService) GetByID(id string) (*models.File, error) {
+	var file models.File
+	if err := s.db.First(&file, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// GetByIDs 按传入顺序获取附件，任意一个不存在时返回错误
+func (s *AttachmentService) GetByIDs(ids []string) ([]models.File, error) {
+	files := make([]models.File, 0, len(ids))
+	for _, id := range ids {
+		file, err := s.GetByID(id)
+		if err != nil {
+			return nil, fmt.Errorf("附件 %s 不存在", id)
+		}
+		files = append(files, *file)
+	}
+	return files, nil
+}
+
+// Path 附件在磁盘上的路径：<dir>/<哈希前两位>/<哈希>
+func (s *AttachmentService) Path(file *models.File) string {
+	return filepath.Join(s.dir, file.Hash[:2], file.Hash)
+}
+
+// Read 读取附件内容
+func (s *AttachmentService) Read(file *models.File) ([]byte, error) {
+	return os.ReadFile(s.Path(file))
+}
+
+// BuildUserMessage 将用户输入和附件组装为模型消息
+//
+// 文本附件作为上下文块放在用户输入之前；vision 为 true 时图片以多模态内容发送，
+// 否则只提示模型存在该图片。
+func (s *AttachmentService) BuildUserMessage(text string, files []models.File, vision bool) (*schema.Message, error) {
+	var blocks []string
+	var images []schema.MessageInputPart
+
+	for i := range files {
+		file := &files[i]
+		switch {
+		case file.IsImage() && vision:
+			data, err := s.Read(file)
+			if err != nil {
+				return nil, fmt.Errorf("读取附件 %s 失败: %w", file.Name, err)
+			}
+			encoded := base64.StdEncoding.EncodeToString(data)
+			images = append(images, schema.MessageInputPart{
+				Type: schema.ChatMessagePartTypeImageURL,
+				Image: &schema.MessageInputImage{
+					MessagePartCommon: schema.MessagePartCommon{
+						Base64Data: &encoded,
+						MIMEType:   file.MimeType,
+					},
+				},
+			})
+		case file.IsImage():
+			blocks = append(blocks, fmt.Sprintf("[附件 %s 是图片，当前模型不支持图片输入]", file.Name))
+		case file.IsText() && file.Size <= MaxInlineTextSize:
+			data, err := s.Read(file)
+			if err != nil {
+				return nil, fmt.Errorf("读取附件 %s 失败: %w", file.Name, err)
+			}
+			blocks = append(blocks, fmt.Sprintf("<file name=%q>\n%s\n</file>", file.Name, data))
+		default:
+			blocks = append(blocks, fmt.Sprintf("[附件 %s（%s，%d 字节）无法直接读取]", file.Name, file.MimeType, file.Size))
+		}
+	}
+
+	content := text
+	if len(blocks) > 0 {
+		content = strings.Join(blocks, "\n\n") + "\n\n" + text
+	}
+	if len(images) == 0 {
+		return schema.UserMessage(content), nil
+	}
+
+	// 多模态消息的文本放在 UserInputMultiContent 中，Content 必须为空
+	parts := append([]schema.MessageInputPart{{Type: schema.ChatMessagePartTypeText, Text: content}}, images...)
+	return &schema.Message{
+		Role:                  schema.User,
+		UserInputMultiContent: parts,
+	}, nil
+}
+
+// detectMimeType 根据文件头判断文件类型，去掉 charset 等参数
+//
+// 内容是普通文本时再按扩展名细分为 JSON、Markdown 等文本类型，扩展名不能把文本变成图片或其他二进制类型。
+func detectMimeType(name string, head []byte) string {
+	sniffed, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil {
+		return "application/octet-stream"
+	}
+	if sniffed == "text/plain" {
+		byExt, _, err := mime.ParseMediaType(mime.TypeByExtension(filepath.Ext(name)))
+		if err == nil && models.IsTextMimeType(byExt) {
+			return byExt
+		}
+	}
+	return sniffed
+}
+
+// headBuffer 保存写入内容的前 512 字节，用于判断文件类型
+type headBuffer struct {
+	data []byte
+}
+
+func (b *headBuffer) Write(p []byte) (int, error) {
+	if remain := 512 - len(b.data); remain > 0 {
+		if len(p) < remain {
+			remain = len(p)
+		}
+		b.data = append(b.data, p[:remain]...)
+	}
+	return len(p), nil
+}
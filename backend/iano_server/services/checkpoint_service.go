@@ -0,0 +1,362 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	iano "iano_agent"
+	"iano_agent/tools"
+	"iano_server/models"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// maxCheckpointDiffSize 返回的单个文件 diff 最大字节数
+const maxCheckpointDiffSize = 64 << 10
+
+// 检查点文件与当前内容相比的状态
+const (
+	CheckpointFileModified  = "modified"  // 内容已修改
+	CheckpointFileCreated   = "created"   // 修改前不存在，现在存在
+	CheckpointFileDeleted   = "deleted"   // 修改前存在，现在已删除
+	CheckpointFileUnchanged = "unchanged" // 与修改前相同
+)
+
+// CheckpointService 工作区检查点服务，在 Agent 修改文件前保存原内容，用于查看差异和撤销修改
+//
+// 文件内容按 SHA-256 存储在 dir 下，超过 maxSize 的文件只记录元数据。
+type CheckpointService struct {
+	db      *gorm.DB
+	dir     string
+	maxSize int64
+}
+
+func NewCheckpointService(db *gorm.DB, dir string, maxSize int64) *CheckpointService {
+	return &CheckpointService{
+		db:      db,
+		dir:     dir,
+		maxSize: maxSize,
+	}
+}
+
+// CheckpointGroup 一条消息中被修改的文件
+type CheckpointGroup struct {
+	MessageID string               `json:"message_id"` // 修改文件的助手消息
+	CreatedAt time.Time            `json:"created_at"` // 第一次修改的时间
+	Files     []*models.Checkpoint `json:"files"`      // 修改前保存的文件
+}
+
+// CheckpointDiff 文件修改前的内容与当前内容的差异
+type CheckpointDiff struct {
+	Path      string `json:"path"`       // 文件的绝对路径
+	MessageID string `json:"message_id"` // 最早修改该文件的消息
+	Status    string `json:"status"`     // modified/created/deleted/unchanged
+	Binary    bool   `json:"binary"`     // 二进制文件，不生成 diff
+	Skipped   bool   `json:"skipped"`    // 修改前的内容未保存，无法比较和恢复
+	Diff      string `json:"diff"`       // 从修改前到当前内容的 unified diff
+}
+
+// CheckpointRestoreResult 恢复检查点的结果
+type CheckpointRestoreResult struct {
+	Restored []string          `json:"restored"`         // 恢复为修改前内容的文件
+	Deleted  []string          `json:"deleted"`          // 修改前不存在而被删除的文件
+	Failed   map[string]string `json:"failed,omitempty"` // 恢复失败的文件及原因
+}
+
+// Handler 返回绑定会话和助手消息的检查点处理函数，供 Agent 在修改文件前调用
+func (s *CheckpointService) Handler(sessionID, messageID string) iano.CheckpointHandler {
+	return func(ctx context.Context, req *iano.CheckpointRequest) error {
+		return s.Save(ctx, sessionID, messageID, req)
+	}
+}
+
+// Save 保存即将被修改的文件的当前内容，同一条消息中已保存过的文件跳过
+func (s *CheckpointService) Save(ctx context.Context, sessionID, messageID string, req *iano.CheckpointRequest) error {
+	db := s.db.WithContext(ctx)
+	for _, path := range req.Paths {
+		var count int64
+		if err := db.Model(&models.Checkpoint{}).
+			Where("message_id = ? AND path = ?", messageID, path).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+
+		checkpoint := &models.Checkpoint{
+			SessionID: sessionID,
+			MessageID: messageID,
+			CallID:    req.CallID,
+			ToolName:  req.ToolName,
+			Path:      path,
+		}
+		checkpoint.NewID()
+		if err := s.snapshot(checkpoint); err != nil {
+			return err
+		}
+		if checkpoint.Path == "" {
+			continue
+		}
+		if err := db.Create(checkpoint).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// snapshot 读取文件当前内容并保存，路径是目录时清空 Path 表示不需要检查点
+func (s *CheckpointService) snapshot(checkpoint *models.Checkpoint) error {
+	info, err := os.Stat(checkpoint.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取文件 %s 失败: %w", checkpoint.Path, err)
+	}
+	if info.IsDir() {
+		checkpoint.Path = ""
+		return nil
+	}
+
+	checkpoint.Existed = true
+	checkpoint.Size = info.Size()
+	checkpoint.Mode = uint32(info.Mode().Perm())
+	if info.Size() > s.maxSize {
+		checkpoint.Skipped = true
+		return nil
+	}
+
+	data, err := os.ReadFile(checkpoint.Path)
+	if err != nil {
+		return fmt.Errorf("读取文件 %s 失败: %w", checkpoint.Path, err)
+	}
+	sum := sha256.Sum256(data)
+	checkpoint.Hash = hex.EncodeToString(sum[:])
+	return s.writeBlob(checkpoint.Hash, data)
+}
+
+// blobPath 文件内容在磁盘上的路径：<dir>/<哈希前两位>/<哈希>
+func (s *CheckpointService) blobPath(hash string) string {
+	return filepath.Join(s.dir, hash[:2], hash)
+}
+
+func (s *CheckpointService) writeBlob(hash string, data []byte) error {
+	path := s.blobPath(hash)
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建检查点目录失败: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "checkpoint-*")
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("保存检查点失败: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("保存检查点失败: %w", err)
+	}
+	return nil
+}
+
+// List 按消息分组返回会话的检查点，先修改的消息在前
+func (s *CheckpointService) List(sessionID string) ([]*CheckpointGroup, error) {
+	var checkpoints []*models.Checkpoint
+	if err := s.db.Where("session_id = ?", sessionID).
+		Order("created_at ASC").
+		Find(&checkpoints).Error; err != nil {
+		return nil, err
+	}
+
+	groups := make([]*CheckpointGroup, 0)
+	index := make(map[string]*CheckpointGroup)
+	for _, c := range checkpoints {
+		group, ok := index[c.MessageID]
+		if !ok {
+			group = &CheckpointGroup{MessageID: c.MessageID, CreatedAt: c.CreatedAt}
+			index[c.MessageID] = group
+			groups = append(groups, group)
+		}
+		group.Files = append(group.Files, c)
+	}
+	return groups, nil
+}
+
+// selectCheckpoints 按恢复范围选出检查点，每个文件只保留最早的一个，即这些消息修改之前的内容
+//
+// scope 为 message 时选出该消息的检查点；为 after 时选出该消息之后的消息的检查点。
+func (s *CheckpointService) selectCheckpoints(sessionID, messageID, scope string) ([]*models.Checkpoint, error) {
+	var message models.Message
+	if err := s.db.First(&message, "id = ? AND session_id = ?", messageID, sessionID).Error; err != nil {
+		return nil, err
+	}
+
+	var checkpoints []*models.Checkpoint
+	query := s.db.Where("session_id = ?", sessionID)
+	if scope == models.CheckpointScopeAfter {
+		query = query.Where("message_id <> ?", messageID)
+	} else {
+		query = query.Where("message_id = ?", messageID)
+	}
+	if err := query.Order("created_at ASC").Find(&checkpoints).Error; err != nil {
+		return nil, err
+	}
+
+	selected := make([]*models.Checkpoint, 0, len(checkpoints))
+	seen := make(map[string]bool)
+	for _, c := range checkpoints {
+		if scope == models.CheckpointScopeAfter && !c.CreatedAt.After(message.CreatedAt) {
+			continue
+		}
+		if seen[c.Path] {
+			continue
+		}
+		seen[c.Path] = true
+		selected = append(selected, c)
+	}
+	return selected, nil
+}
+
+// Diff 比较检查点保存的内容与文件当前内容
+func (s *CheckpointService) Diff(sessionID, messageID, scope string) ([]*CheckpointDiff, error) {
+	checkpoints, err := s.selectCheckpoints(sessionID, messageID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	diffs := make([]*CheckpointDiff, 0, len(checkpoints))
+	for _, c := range checkpoints {
+		d := &CheckpointDiff{Path: c.Path, MessageID: c.MessageID, Skipped: c.Skipped}
+		diffs = append(diffs, d)
+
+		current, err := os.ReadFile(c.Path)
+		exists := err == nil
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("读取文件 %s 失败: %w", c.Path, err)
+		}
+		switch {
+		case !c.Existed && !exists:
+			d.Status = CheckpointFileUnchanged
+		case !c.Existed:
+			d.Status = CheckpointFileCreated
+		case !exists:
+			d.Status = CheckpointFileDeleted
+		default:
+			d.Status = CheckpointFileModified
+		}
+		if c.Skipped {
+			continue
+		}
+
+		var original []byte
+		if c.Existed {
+			if original, err = os.ReadFile(s.blobPath(c.Hash)); err != nil {
+				return nil, fmt.Errorf("读取检查点 %s 失败: %w", c.Path, err)
+			}
+		}
+		if d.Status == CheckpointFileModified && bytes.Equal(original, current) {
+			d.Status = CheckpointFileUnchanged
+		}
+		if d.Status == CheckpointFileUnchanged {
+			continue
+		}
+		if isBinary(original) || isBinary(current) {
+			d.Binary = true
+			continue
+		}
+
+		oldName, newName := diffName("a", c.Path), diffName("b", c.Path)
+		if !c.Existed {
+			oldName = "/dev/null"
+		}
+		if !exists {
+			newName = "/dev/null"
+		}
+		d.Diff = tools.UnifiedDiff(oldName, newName, string(original), string(current))
+		if len(d.Diff) > maxCheckpointDiffSize {
+			d.Diff = d.Diff[:maxCheckpointDiffSize] + "\n... (diff 被截断)"
+		}
+	}
+	return diffs, nil
+}
+
+// Restore 将文件恢复为检查点保存的内容，修改前不存在的文件会被删除
+func (s *CheckpointService) Restore(sessionID, messageID, scope string) (*CheckpointRestoreResult, error) {
+	checkpoints, err := s.selectCheckpoints(sessionID, messageID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CheckpointRestoreResult{
+		Restored: make([]string, 0),
+		Deleted:  make([]string, 0),
+		Failed:   make(map[string]string),
+	}
+	for _, c := range checkpoints {
+		switch {
+		case c.Skipped:
+			result.Failed[c.Path] = "文件超过检查点大小上限，未保存修改前的内容"
+		case !c.Existed:
+			if err := os.Remove(c.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				result.Failed[c.Path] = err.Error()
+				continue
+			}
+			result.Deleted = append(result.Deleted, c.Path)
+		default:
+			if err := s.restoreFile(c); err != nil {
+				result.Failed[c.Path] = err.Error()
+				continue
+			}
+			result.Restored = append(result.Restored, c.Path)
+		}
+	}
+	return result, nil
+}
+
+func (s *CheckpointService) restoreFile(c *models.Checkpoint) error {
+	data, err := os.ReadFile(s.blobPath(c.Hash))
+	if err != nil {
+		return fmt.Errorf("读取检查点失败: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.Path), 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+	mode := fs.FileMode(c.Mode)
+	if mode == 0 {
+		mode = 0644
+	}
+	if err := os.WriteFile(c.Path, data, mode); err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	return nil
+}
+
+// isBinary 内容开头包含 NUL 字节时视为二进制
+func isBinary(data []byte) bool {
+	if len(data) > 8000 {
+		data = data[:8000]
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
+
+// diffName diff 文件头中的路径，prefix 为 a 或 b
+func diffName(prefix, path string) string {
+	return prefix + "/" + strings.TrimPrefix(filepath.ToSlash(path), "/")
+}
@@ -0,0 +1,693 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iano_agent/knowledge"
+	agentmodel "iano_agent/model"
+	"iano_agent/tools"
+	"iano_server/models"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"gorm.io/gorm"
+)
+
+// 知识库导入和检索的限制
+const (
+	maxKnowledgeFileSize   = 20 << 20 // 单个文件的大小上限
+	maxKnowledgeFiles      = 1000     // 按目录导入时的文件数量上限
+	knowledgeCandidateSize = 4        // 每路检索的候选数量为返回数量的倍数
+	knowledgeLikeWindow    = 500      // 不支持 FTS5 时参与匹配的分块数量上限
+	rrfK                   = 60       // 倒数排名融合的平滑参数
+)
+
+// knowledgeFTSTable 分块的全文索引，tokens 为 knowledge.Tokenize 切分后用空格连接的词项
+const knowledgeFTSTable = "knowledge_chunks_fts"
+
+// KnowledgeService 知识库服务，实现 tools.KnowledgeSearcher
+//
+// 文档按段落分块后写入 SQLite FTS5 全文索引，按 BM25 排序；知识库配置了向量模型时同时保存分块向量，
+// 检索时将全文检索和向量检索的结果按倒数排名融合。
+type KnowledgeService struct {
+	db                *gorm.DB
+	providerService   *ProviderService
+	attachmentService *AttachmentService
+	fts               bool // SQLite 是否支持 FTS5，不支持时退化为关键字匹配
+}
+
+func NewKnowledgeService(db *gorm.DB, providerService *ProviderService, attachmentService *AttachmentService) *KnowledgeService {
+	s := &KnowledgeService{
+		db:                db,
+		providerService:   providerService,
+		attachmentService: attachmentService,
+	}
+
+	err := db.Exec("CREATE VIRTUAL TABLE IF NOT EXISTS " + knowledgeFTSTable +
+		" USING fts5(tokens, chunk_id UNINDEXED, knowledge_base_id UNINDEXED)").Error
+	if err != nil {
+		slog.Warn("SQLite 不支持 FTS5，知识库检索退化为关键字匹配", "error", err)
+	} else {
+		s.fts = true
+	}
+	return s
+}
+
+var _ tools.KnowledgeSearcher = (*KnowledgeService)(nil)
+
+// Create 创建知识库，名称已存在时返回错误
+func (s *KnowledgeService) Create(kb *models.KnowledgeBase) error {
+	var count int64
+	if err := s.db.Model(&models.KnowledgeBase{}).Where("name = ?", kb.Name).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("知识库 %s 已存在", kb.Name)
+	}
+	return s.db.Create(kb).Error
+}
+
+// GetAll 获取全部知识库及其文档数量
+func (s *KnowledgeService) GetAll() ([]models.KnowledgeBase, error) {
+	var kbs []models.KnowledgeBase
+	if err := s.db.Order("name").Find(&kbs).Error; err != nil {
+		return nil, err
+	}
+
+	var counts []struct {
+		KnowledgeBaseID string
+		Count           int64
+	}
+	err := s.db.Model(&models.KnowledgeDocument{}).
+		Select("knowledge_base_id, COUNT(*) AS count").
+		Group("knowledge_base_id").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range counts {
+		for i := range kbs {
+			if kbs[i].ID == c.KnowledgeBaseID {
+				kbs[i].DocumentCount = c.Count
+			}
+		}
+	}
+	return kbs, nil
+}
+
+func (s *KnowledgeService) GetByID(id string) (*models.KnowledgeBase, error) {
+	var kb models.KnowledgeBase
+	if err := s.db.First(&kb, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(&models.KnowledgeDocument{}).Where("knowledge_base_id = ?", id).Count(&kb.DocumentCount).Error; err != nil {
+		return nil, err
+	}
+	return &kb, nil
+}
+
+// Update 更新知识库，更换向量模型时清空已有分块的向量，重新导入文档后才会生成新的向量
+func (s *KnowledgeService) Update(id string, updates map[string]interface{}) (*models.KnowledgeBase, error) {
+	kb, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if name, ok := updates["name"].(string); ok && name != kb.Name {
+		var count int64
+		if err := s.db.Model(&models.KnowledgeBase{}).Where("name = ? AND id != ?", name, id).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return nil, fmt.Errorf("知识库 %s 已存在", name)
+		}
+	}
+
+	providerID, ok1 := updates["embedding_provider_id"].(string)
+	modelName, ok2 := updates["embedding_model"].(string)
+	embeddingChanged := (ok1 && providerID != kb.EmbeddingProviderID) || (ok2 && modelName != kb.EmbeddingModel)
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.KnowledgeBase{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+			return err
+		}
+		if embeddingChanged {
+			return tx.Model(&models.KnowledgeChunk{}).Where("knowledge_base_id = ?", id).Update("embedding", "").Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetByID(id)
+}
+
+// Delete 删除知识库及其全部文档和索引
+func (s *KnowledgeService) Delete(id string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var docIDs []string
+		if err := tx.Model(&models.KnowledgeDocument{}).Where("knowledge_base_id = ?", id).Pluck("id", &docIDs).Error; err != nil {
+			return err
+		}
+		if err := s.deleteDocuments(tx, docIDs); err != nil {
+			return err
+		}
+
+		result := tx.Delete(&models.KnowledgeBase{}, "id = ?", id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// ListDocuments 获取知识库中的文档，新导入的在前
+func (s *KnowledgeService) ListDocuments(kbID string) ([]models.KnowledgeDocument, error) {
+	var docs []models.KnowledgeDocument
+	if err := s.db.Where("knowledge_base_id = ?", kbID).Order("created_at DESC").Find(&docs).Error; err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// DeleteDocument 删除知识库中的一个文档及其分块
+func (s *KnowledgeService) DeleteDocument(kbID, docID string) error {
+	var count int64
+	if err := s.db.Model(&models.KnowledgeDocument{}).Where("id = ? AND knowledge_base_id = ?", docID, kbID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return s.deleteDocuments(tx, []string{docID})
+	})
+}
+
+// deleteDocuments 删除文档、分块和全文索引
+func (s *KnowledgeService) deleteDocuments(tx *gorm.DB, docIDs []string) error {
+	if len(docIDs) == 0 {
+		return nil
+	}
+	if s.fts {
+		err := tx.Exec("DELETE FROM "+knowledgeFTSTable+" WHERE chunk_id IN (SELECT id FROM knowledge_chunks WHERE document_id IN ?)", docIDs).Error
+		if err != nil {
+			return err
+		}
+	}
+	if err := tx.Where("document_id IN ?", docIDs).Delete(&models.KnowledgeChunk{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("id IN ?", docIDs).Delete(&models.KnowledgeDocument{}).Error
+}
+
+// IngestRequest 导入文档的来源，可以同时指定多种
+type IngestRequest struct {
+	AttachmentIDs []string // 已上传的附件 ID
+	Path          string   // 服务器上的文件或目录（绝对路径），目录下支持的文件递归导入
+	Title         string   // 直接导入的文本标题
+	Content       string   // 直接导入的 Markdown 文本
+}
+
+// Ingest 导入文档，每个文件生成一条文档记录，提取或索引失败的文件记录为 failed 状态，不影响其他文件
+//
+// 同一知识库中来源相同的文档导入成功后替换原有文档。
+func (s *KnowledgeService) Ingest(ctx context.Context, kbID string, req *IngestRequest) ([]models.KnowledgeDocument, error) {
+	kb, err := s.GetByID(kbID)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.AttachmentIDs) == 0 && req.Path == "" && strings.TrimSpace(req.Content) == "" {
+		return nil, fmt.Errorf("请指定要导入的附件、路径或文本内容")
+	}
+	embedder, err := s.embedder(kb)
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []models.KnowledgeDocument
+	if len(req.AttachmentIDs) > 0 {
+		files, err := s.attachmentService.GetByIDs(req.AttachmentIDs)
+		if err != nil {
+			return nil, err
+		}
+		if len(files) != len(req.AttachmentIDs) {
+			return nil, fmt.Errorf("部分附件不存在")
+		}
+		for i := range files {
+			data, err := s.attachmentService.Read(&files[i])
+			docs = append(docs, s.ingestDocument(ctx, kb, embedder, files[i].Name, files[i].Name, data, err))
+		}
+	}
+
+	if req.Path != "" {
+		paths, err := collectKnowledgeFiles(req.Path)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			data, err := readKnowledgeFile(path)
+			docs = append(docs, s.ingestDocument(ctx, kb, embedder, path, path, data, err))
+		}
+	}
+
+	if content := strings.TrimSpace(req.Content); content != "" {
+		title := strings.TrimSpace(req.Title)
+		if title == "" {
+			title = "未命名文档"
+		}
+		docs = append(docs, s.ingestDocument(ctx, kb, embedder, title+".md", title, []byte(content), nil))
+	}
+	return docs, nil
+}
+
+// ingestDocument 提取、分块并索引一个文档，readErr 为读取文件时的错误
+func (s *KnowledgeService) ingestDocument(ctx context.Context, kb *models.KnowledgeBase, embedder embedding.Embedder,
+	name, source string, data []byte, readErr error) models.KnowledgeDocument {
+	doc := models.KnowledgeDocument{
+		KnowledgeBaseID: kb.ID,
+		Title:           strings.TrimSuffix(filepath.Base(name), filepath.Ext(name)),
+		Source:          source,
+		Size:            int64(len(data)),
+		Status:          models.KnowledgeDocumentStatusReady,
+	}
+	doc.NewID()
+
+	chunks, err := s.buildChunks(ctx, kb, embedder, &doc, name, data, readErr)
+	if err != nil {
+		doc.Status = models.KnowledgeDocumentStatusFailed
+		doc.Error = err.Error()
+		slog.Warn("导入知识库文档失败", "knowledgeBaseID", kb.ID, "source", source, "error", err)
+	}
+	doc.ChunkCount = len(chunks)
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 导入成功时替换来源相同的文档，失败时只替换之前失败的记录
+		replaced := tx.Model(&models.KnowledgeDocument{}).Where("knowledge_base_id = ? AND source = ?", kb.ID, source)
+		if doc.Status == models.KnowledgeDocumentStatusFailed {
+			replaced = replaced.Where("status = ?", models.KnowledgeDocumentStatusFailed)
+		}
+		var oldIDs []string
+		if err := replaced.Pluck("id", &oldIDs).Error; err != nil {
+			return err
+		}
+		if err := s.deleteDocuments(tx, oldIDs); err != nil {
+			return err
+		}
+
+		if err := tx.Create(&doc).Error; err != nil {
+			return err
+		}
+		if len(chunks) == 0 {
+			return nil
+		}
+		if err := tx.CreateInBatches(chunks, 100).Error; err != nil {
+			return err
+		}
+		return s.indexChunks(tx, chunks)
+	})
+	if err != nil {
+		doc.Status = models.KnowledgeDocumentStatusFailed
+		doc.Error = fmt.Sprintf("保存文档失败: %v", err)
+		doc.ChunkCount = 0
+	}
+	return doc
+}
+
+// buildChunks 提取文档文本并分块，配置了向量模型时生成分块向量
+func (s *KnowledgeService) buildChunks(ctx context.Context, kb *models.KnowledgeBase, embedder embedding.Embedder,
+	doc *models.KnowledgeDocument, name string, data []byte, readErr error) ([]models.KnowledgeChunk, error) {
+	if readErr != nil {
+		return nil, readErr
+	}
+	extracted, err := knowledge.Extract(name, data)
+	if err != nil {
+		return nil, err
+	}
+	doc.Title = extracted.Title
+
+	pieces := knowledge.Split(extracted.Text, knowledge.ChunkOptions{Size: kb.ChunkSize, Overlap: kb.ChunkOverlap})
+	if len(pieces) == 0 {
+		return nil, fmt.Errorf("文档中没有可索引的内容")
+	}
+
+	chunks := make([]models.KnowledgeChunk, len(pieces))
+	texts := make([]string, len(pieces))
+	for i, piece := range pieces {
+		chunks[i] = models.KnowledgeChunk{
+			KnowledgeBaseID: kb.ID,
+			DocumentID:      doc.ID,
+			Ordinal:         piece.Ordinal,
+			Heading:         piece.Heading,
+			Content:         piece.Content,
+		}
+		chunks[i].NewID()
+		texts[i] = chunkText(&chunks[i])
+	}
+
+	if embedder != nil {
+		vectors, err := embedder.EmbedStrings(ctx, texts)
+		if err != nil {
+			return nil, fmt.Errorf("生成向量失败: %w", err)
+		}
+		for i := range chunks {
+			data, err := json.Marshal(vectors[i])
+			if err != nil {
+				return nil, err
+			}
+			chunks[i].Embedding = string(data)
+		}
+	}
+	return chunks, nil
+}
+
+// indexChunks 将分块写入全文索引
+func (s *KnowledgeService) indexChunks(tx *gorm.DB, chunks []models.KnowledgeChunk) error {
+	if !s.fts {
+		return nil
+	}
+	for i := range chunks {
+		err := tx.Exec("INSERT INTO "+knowledgeFTSTable+" (tokens, chunk_id, knowledge_base_id) VALUES (?, ?, ?)",
+			strings.Join(knowledge.Tokenize(chunkText(&chunks[i])), " "), chunks[i].ID, chunks[i].KnowledgeBaseID).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chunkText 参与检索的分块文本，包含小节标题
+func chunkText(chunk *models.KnowledgeChunk) string {
+	if chunk.Heading == "" {
+		return chunk.Content
+	}
+	return chunk.Heading + "\n" + chunk.Content
+}
+
+// collectKnowledgeFiles 列出路径下支持导入的文件，跳过隐藏目录
+func collectKnowledgeFiles(root string) ([]string, error) {
+	if !filepath.IsAbs(root) {
+		return nil, fmt.Errorf("路径必须是绝对路径: %s", root)
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, fmt.Errorf("读取路径失败: %w", err)
+	}
+	if !info.IsDir() {
+		if !knowledge.IsSupported(root) {
+			return nil, fmt.Errorf("不支持的文件类型: %s，支持 %s", filepath.Ext(root), strings.Join(knowledge.SupportedExtensions, " "))
+		}
+		return []string{root}, nil
+	}
+
+	var paths []string
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if knowledge.IsSupported(d.Name()) {
+			if len(paths) >= maxKnowledgeFiles {
+				return fmt.Errorf("文件数量超过上限 %d", maxKnowledgeFiles)
+			}
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("目录 %s 中没有支持导入的文件", root)
+	}
+	return paths, nil
+}
+
+func readKnowledgeFile(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > maxKnowledgeFileSize {
+		return nil, fmt.Errorf("文件大小超过上限 %d MB", maxKnowledgeFileSize>>20)
+	}
+	return os.ReadFile(path)
+}
+
+// embedder 创建知识库的向量模型，未配置时返回 nil
+func (s *KnowledgeService) embedder(kb *models.KnowledgeBase) (embedding.Embedder, error) {
+	if kb.EmbeddingProviderID == "" {
+		return nil, nil
+	}
+	provider, err := s.providerService.GetByID(kb.EmbeddingProviderID)
+	if err != nil {
+		return nil, fmt.Errorf("向量模型提供商不存在: %w", err)
+	}
+	config := s.providerService.ModelConfig(provider)
+	config.Model = kb.EmbeddingModel
+	return agentmodel.CreateEmbedder(config)
+}
+
+// Search 在指定知识库中检索分块，合并全文检索和向量检索的结果，向量检索失败时只使用全文检索
+func (s *KnowledgeService) Search(ctx context.Context, kbIDs []string, query string, limit int) ([]*tools.KnowledgeChunk, error) {
+	query = strings.TrimSpace(query)
+	if len(kbIDs) == 0 || query == "" {
+		return nil, nil
+	}
+	if limit <= 0 {
+		limit = tools.DefaultKBSearchLimit
+	}
+	candidates := limit * knowledgeCandidateSize
+
+	keyword, err := s.keywordSearch(ctx, kbIDs, query, candidates)
+	if err != nil {
+		return nil, err
+	}
+	rankings := [][]string{keyword}
+
+	vector, err := s.vectorSearch(ctx, kbIDs, query, candidates)
+	if err != nil {
+		slog.Warn("知识库向量检索失败，只使用全文检索", "error", err)
+	} else if len(vector) > 0 {
+		rankings = append(rankings, vector)
+	}
+
+	ids, scores := fuseRankings(rankings, limit)
+	return s.loadChunks(ctx, ids, scores)
+}
+
+// keywordSearch 全文检索，返回按 BM25 排序的分块 ID
+func (s *KnowledgeService) keywordSearch(ctx context.Context, kbIDs []string, query string, limit int) ([]string, error) {
+	terms := uniqueTerms(knowledge.Tokenize(query))
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	if s.fts {
+		quoted := make([]string, len(terms))
+		for i, term := range terms {
+			quoted[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+		}
+		var ids []string
+		err := s.db.WithContext(ctx).Raw("SELECT chunk_id FROM "+knowledgeFTSTable+
+			" WHERE "+knowledgeFTSTable+" MATCH ? AND knowledge_base_id IN ? ORDER BY bm25("+knowledgeFTSTable+") LIMIT ?",
+			strings.Join(quoted, " OR "), kbIDs, limit).Scan(&ids).Error
+		return ids, err
+	}
+
+	// 不支持 FTS5 时按查询词项的命中比例排序
+	db := s.db.WithContext(ctx).Model(&models.KnowledgeChunk{}).Where("knowledge_base_id IN ?", kbIDs)
+	conditions := s.db.Where("content LIKE ?", "%"+terms[0]+"%")
+	for _, term := range terms[1:] {
+		conditions = conditions.Or("content LIKE ?", "%"+term+"%")
+	}
+	var chunks []models.KnowledgeChunk
+	if err := db.Where(conditions).Limit(knowledgeLikeWindow).Find(&chunks).Error; err != nil {
+		return nil, err
+	}
+	scores := make(map[string]float64, len(chunks))
+	ids := make([]string, 0, len(chunks))
+	for i := range chunks {
+		scores[chunks[i].ID] = tools.ScoreMemory(terms, chunkText(&chunks[i]))
+		ids = append(ids, chunks[i].ID)
+	}
+	sort.SliceStable(ids, func(i, j int) bool { return scores[ids[i]] > scores[ids[j]] })
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+	return ids, nil
+}
+
+// vectorSearch 在配置了向量模型的知识库中按余弦相似度检索，返回分块 ID
+func (s *KnowledgeService) vectorSearch(ctx context.Context, kbIDs []string, query string, limit int) ([]string, error) {
+	var kbs []models.KnowledgeBase
+	if err := s.db.WithContext(ctx).Where("id IN ? AND embedding_provider_id != ''", kbIDs).Find(&kbs).Error; err != nil {
+		return nil, err
+	}
+
+	scores := make(map[string]float64)
+	var ids []string
+	for i := range kbs {
+		embedder, err := s.embedder(&kbs[i])
+		if err != nil {
+			return nil, err
+		}
+		vectors, err := embedder.EmbedStrings(ctx, []string{query})
+		if err != nil {
+			return nil, err
+		}
+		if len(vectors) != 1 {
+			return nil, fmt.Errorf("向量数量 %d 与查询数量不一致", len(vectors))
+		}
+
+		// 分块向量全部加载到内存中计算，适用于内部文档规模的知识库
+		var chunks []models.KnowledgeChunk
+		err = s.db.WithContext(ctx).Select("id", "embedding").
+			Where("knowledge_base_id = ? AND embedding != ''", kbs[i].ID).
+			Find(&chunks).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, chunk := range chunks {
+			var vector []float64
+			if err := json.Unmarshal([]byte(chunk.Embedding), &vector); err != nil {
+				continue
+			}
+			if score := agentmodel.CosineSimilarity(vectors[0], vector); score > 0 {
+				scores[chunk.ID] = score
+				ids = append(ids, chunk.ID)
+			}
+		}
+	}
+
+	sort.SliceStable(ids, func(i, j int) bool { return scores[ids[i]] > scores[ids[j]] })
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+	return ids, nil
+}
+
+// fuseRankings 按倒数排名融合（RRF）合并多路检索结果，返回前 limit 个分块 ID 和融合后的相关度
+func fuseRankings(rankings [][]string, limit int) ([]string, map[string]float64) {
+	scores := make(map[string]float64)
+	var ids []string
+	for _, ranking := range rankings {
+		for rank, id := range ranking {
+			if _, ok := scores[id]; !ok {
+				ids = append(ids, id)
+			}
+			scores[id] += 1 / float64(rrfK+rank+1)
+		}
+	}
+	sort.SliceStable(ids, func(i, j int) bool { return scores[ids[i]] > scores[ids[j]] })
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+	return ids, scores
+}
+
+// loadChunks 按 ids 的顺序加载分块及其文档和知识库信息
+func (s *KnowledgeService) loadChunks(ctx context.Context, ids []string, scores map[string]float64) ([]*tools.KnowledgeChunk, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var chunks []models.KnowledgeChunk
+	if err := s.db.WithContext(ctx).Where("id IN ?", ids).Find(&chunks).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*models.KnowledgeChunk, len(chunks))
+	docIDs := make([]string, 0, len(chunks))
+	kbIDs := make([]string, 0, len(chunks))
+	for i := range chunks {
+		byID[chunks[i].ID] = &chunks[i]
+		docIDs = append(docIDs, chunks[i].DocumentID)
+		kbIDs = append(kbIDs, chunks[i].KnowledgeBaseID)
+	}
+
+	var docs []models.KnowledgeDocument
+	if err := s.db.WithContext(ctx).Where("id IN ?", docIDs).Find(&docs).Error; err != nil {
+		return nil, err
+	}
+	docByID := make(map[string]*models.KnowledgeDocument, len(docs))
+	for i := range docs {
+		docByID[docs[i].ID] = &docs[i]
+	}
+	var kbs []models.KnowledgeBase
+	if err := s.db.WithContext(ctx).Where("id IN ?", kbIDs).Find(&kbs).Error; err != nil {
+		return nil, err
+	}
+	kbNames := make(map[string]string, len(kbs))
+	for _, kb := range kbs {
+		kbNames[kb.ID] = kb.Name
+	}
+
+	result := make([]*tools.KnowledgeChunk, 0, len(ids))
+	for _, id := range ids {
+		chunk, ok := byID[id]
+		if !ok {
+			continue
+		}
+		item := &tools.KnowledgeChunk{
+			ID:              chunk.ID,
+			KnowledgeBaseID: chunk.KnowledgeBaseID,
+			KnowledgeBase:   kbNames[chunk.KnowledgeBaseID],
+			DocumentID:      chunk.DocumentID,
+			Heading:         chunk.Heading,
+			Ordinal:         chunk.Ordinal,
+			Content:         chunk.Content,
+			Score:           scores[id],
+		}
+		if doc, ok := docByID[chunk.DocumentID]; ok {
+			item.Title, item.Source = doc.Title, doc.Source
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+// uniqueTerms 去掉重复的词项，保持原有顺序
+func uniqueTerms(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	result := make([]string, 0, len(terms))
+	for _, term := range terms {
+		if !seen[term] {
+			seen[term] = true
+			result = append(result, term)
+		}
+	}
+	return result
+}
+
+// ToCitations 将 Agent 本次运行引用的分块转换为消息中的引用，编号从 1 开始
+func ToCitations(chunks []*tools.KnowledgeChunk) []models.Citation {
+	citations := make([]models.Citation, 0, len(chunks))
+	for i, chunk := range chunks {
+		citations = append(citations, models.Citation{
+			Index:           i + 1,
+			KnowledgeBaseID: chunk.KnowledgeBaseID,
+			KnowledgeBase:   chunk.KnowledgeBase,
+			DocumentID:      chunk.DocumentID,
+			ChunkID:         chunk.ID,
+			Title:           chunk.Title,
+			Heading:         chunk.Heading,
+			Source:          chunk.Source,
+			Content:         chunk.Content,
+			Score:           chunk.Score,
+		})
+	}
+	return citations
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iano_agent/tools"
+	"iano_server/models"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// memorySearchWindow 搜索记忆时参与排序的最近记忆条数
+const memorySearchWindow = 500
+
+// MemoryService Agent 长期记忆服务，实现 tools.MemoryStore
+type MemoryService struct {
+	db *gorm.DB
+}
+
+func NewMemoryService(db *gorm.DB) *MemoryService {
+	return &MemoryService{db: db}
+}
+
+var _ tools.MemoryStore = (*MemoryService)(nil)
+
+// scoped 限定在记忆范围内的查询
+func (s *MemoryService) scoped(ctx context.Context, scope tools.MemoryScope) *gorm.DB {
+	return s.db.WithContext(ctx).
+		Where("agent_id = ? AND user_id = ? AND workspace = ?", scope.AgentID, scope.UserID, scope.Workspace)
+}
+
+// Save 保存一条记忆，范围内已有相同内容时返回已有的记忆
+func (s *MemoryService) Save(ctx context.Context, scope tools.MemoryScope, content string, tags []string) (*tools.MemoryItem, error) {
+	if scope.AgentID == "" {
+		return nil, fmt.Errorf("记忆范围缺少 Agent ID")
+	}
+
+	var existing models.Memory
+	err := s.scoped(ctx, scope).Where("content = ?", content).First(&existing).Error
+	if err == nil {
+		return toMemoryItem(&existing), nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	memory := &models.Memory{
+		AgentID:   scope.AgentID,
+		UserID:    scope.UserID,
+		Workspace: scope.Workspace,
+		Content:   content,
+		Tags:      strings.Join(tags, ","),
+	}
+	memory.NewID()
+	if err := s.db.WithContext(ctx).Create(memory).Error; err != nil {
+		return nil, err
+	}
+	return toMemoryItem(memory), nil
+}
+
+// Search 在范围内最近的记忆中按相关度排序，相关度相同时新记忆在前，query 为空时返回最近的记忆
+func (s *MemoryService) Search(ctx context.Context, scope tools.MemoryScope, query string, limit int) ([]*tools.MemoryItem, error) {
+	var memories []models.Memory
+	err := s.scoped(ctx, scope).Order("created_at DESC").Limit(memorySearchWindow).Find(&memories).Error
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*tools.MemoryItem, 0, len(memories))
+	if query == "" {
+		for i := range memories {
+			items = append(items, toMemoryItem(&memories[i]))
+		}
+	} else {
+		terms := tools.MemoryTerms(query)
+		scores := make(map[string]float64)
+		for i := range memories {
+			if score := tools.ScoreMemory(terms, memories[i].Content+" "+memories[i].Tags); score > 0 {
+				scores[memories[i].ID] = score
+				items = append(items, toMemoryItem(&memories[i]))
+			}
+		}
+		sort.SliceStable(items, func(i, j int) bool {
+			return scores[items[i].ID] > scores[items[j].ID]
+		})
+	}
+
+	if limit > 0 && len(items) > limit {
+		items = items[:limit]
+	}
+	return items, nil
+}
+
+// Forget 删除范围内的一条记忆
+func (s *MemoryService) Forget(ctx context.Context, scope tools.MemoryScope, id string) error {
+	result := s.scoped(ctx, scope).Where("id = ?", id).Delete(&models.Memory{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("记忆 %s 不存在", id)
+	}
+	return nil
+}
+
+// MemoryFilter 查询记忆列表的过滤条件，为空的字段不参与过滤
+type MemoryFilter struct {
+	AgentID   string
+	UserID    string
+	Workspace string
+	Keyword   string // 按内容或标签模糊匹配
+}
+
+// List 按过滤条件获取记忆列表，新记忆在前
+func (s *MemoryService) List(filter MemoryFilter) ([]models.Memory, error) {
+	query := s.db.Model(&models.Memory{})
+	if filter.AgentID != "" {
+		query = query.Where("agent_id = ?", filter.AgentID)
+	}
+	if filter.UserID != "" {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Workspace != "" {
+		query = query.Where("workspace = ?", filter.Workspace)
+	}
+	if filter.Keyword != "" {
+		like := "%" + filter.Keyword + "%"
+		query = query.Where("content LIKE ? OR tags LIKE ?", like, like)
+	}
+
+	var memories []models.Memory
+	if err := query.Order("created_at DESC").Find(&memories).Error; err != nil {
+		return nil, err
+	}
+	return memories, nil
+}
+
+func (s *MemoryService) GetByID(id string) (*models.Memory, error) {
+	var memory models.Memory
+	if err := s.db.First(&memory, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &memory, nil
+}
+
+// Delete 删除一条记忆
+func (s *MemoryService) Delete(id string) error {
+	result := s.db.Delete(&models.Memory{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// DeleteByAgent 删除 Agent 的全部记忆，返回删除的条数
+func (s *MemoryService) DeleteByAgent(agentID string) (int64, error) {
+	result := s.db.Delete(&models.Memory{}, "agent_id = ?", agentID)
+	return result.RowsAffected, result.Error
+}
+
+func toMemoryItem(memory *models.Memory) *tools.MemoryItem {
+	return &tools.MemoryItem{
+		ID:        memory.ID,
+		Content:   memory.Content,
+		Tags:      tools.SplitMemoryTags(memory.Tags),
+		CreatedAt: memory.CreatedAt,
+	}
+}
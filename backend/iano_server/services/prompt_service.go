@@ -0,0 +1,140 @@
+package services
+
+import (
+	"fmt"
+	"iano_server/models"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// PromptService 提示词库服务
+type PromptService struct {
+	db *gorm.DB
+}
+
+func NewPromptService(db *gorm.DB) *PromptService {
+	return &PromptService{db: db}
+}
+
+// Create 创建提示词片段的第一个版本，名称已存在时返回错误
+func (s *PromptService) Create(prompt *models.Prompt) error {
+	var count int64
+	if err := s.db.Model(&models.Prompt{}).Where("name = ?", prompt.Name).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("提示词 %s 已存在", prompt.Name)
+	}
+
+	prompt.Version = 1
+	return s.db.Create(prompt).Error
+}
+
+func (s *PromptService) GetByID(id string) (*models.Prompt, error) {
+	var prompt models.Prompt
+	if err := s.db.First(&prompt, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &prompt, nil
+}
+
+// GetAll 获取每个提示词片段的最新版本
+func (s *PromptService) GetAll() ([]models.Prompt, error) {
+	var prompts []models.Prompt
+	latest := s.db.Model(&models.Prompt{}).Select("name, MAX(version) AS version").Group("name")
+	err := s.db.Joins("JOIN (?) AS latest ON latest.name = prompts.name AND latest.version = prompts.version", latest).
+		Order("prompts.name").
+		Find(&prompts).Error
+	if err != nil {
+		return nil, err
+	}
+	return prompts, nil
+}
+
+// GetVersions 获取提示词片段的全部版本，新版本在前
+func (s *PromptService) GetVersions(name string) ([]models.Prompt, error) {
+	var prompts []models.Prompt
+	if err := s.db.Where("name = ?", name).Order("version DESC").Find(&prompts).Error; err != nil {
+		return nil, err
+	}
+	return prompts, nil
+}
+
+// GetByName 按名称获取提示词片段，version 为 0 时返回最新版本
+func (s *PromptService) GetByName(name string, version int) (*models.Prompt, error) {
+	var prompt models.Prompt
+	query := s.db.Where("name = ?", name)
+	if version > 0 {
+		query = query.Where("version = ?", version)
+	} else {
+		query = query.Order("version DESC")
+	}
+	if err := query.First(&prompt).Error; err != nil {
+		return nil, err
+	}
+	return &prompt, nil
+}
+
+// Update 基于指定版本保存一个新版本，名称不可修改
+func (s *PromptService) Update(id string, updates map[string]interface{}) (*models.Prompt, error) {
+	base, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	latest, err := s.GetByName(base.Name, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	prompt := &models.Prompt{
+		Name:        base.Name,
+		Version:     latest.Version + 1,
+		Description: base.Description,
+		Content:     base.Content,
+		Tags:        base.Tags,
+	}
+	if v, ok := updates["description"].(string); ok {
+		prompt.Description = v
+	}
+	if v, ok := updates["content"].(string); ok {
+		prompt.Content = v
+	}
+	if v, ok := updates["tags"].(string); ok {
+		prompt.Tags = v
+	}
+	prompt.NewID()
+
+	if err := s.db.Create(prompt).Error; err != nil {
+		return nil, err
+	}
+	return prompt, nil
+}
+
+// Delete 删除提示词片段的全部版本
+func (s *PromptService) Delete(id string) error {
+	prompt, err := s.GetByID(id)
+	if err != nil {
+		return err
+	}
+	return s.db.Delete(&models.Prompt{}, "name = ?", prompt.Name).Error
+}
+
+// Load 按引用名称加载提示词内容，名称可以带版本号，例如 coding-style@2
+func (s *PromptService) Load(ref string) (string, error) {
+	name, version := ref, 0
+	if i := strings.LastIndex(ref, "@"); i > 0 {
+		v, err := strconv.Atoi(ref[i+1:])
+		if err != nil || v <= 0 {
+			return "", fmt.Errorf("无效的版本号: %s", ref[i+1:])
+		}
+		name, version = ref[:i], v
+	}
+
+	prompt, err := s.GetByName(name, version)
+	if err != nil {
+		return "", fmt.Errorf("提示词 %s 不存在", ref)
+	}
+	return prompt.Content, nil
+}
@@ -1,44 +1,90 @@
 package services
 
 import (
+	agentmodel "iano_agent/model"
 	"iano_server/models"
+	"sync"
 
 	"gorm.io/gorm"
 )
 
+// ProviderChangeHandler Provider 更新或删除后的回调
+type ProviderChangeHandler func(providerID string)
+
 type ProviderService struct {
-	db *gorm.DB
+	db        *gorm.DB
+	mux       sync.RWMutex
+	onChanges []ProviderChangeHandler
 }
 
 func NewProviderService(db *gorm.DB) *ProviderService {
 	return &ProviderService{db: db}
 }
 
-type ProviderDTO struct {
-	ID          string  `json:"id"`
-	Name        string  `json:"name"`
-	BaseURL     string  `json:"base_url"`
-	Model       string  `json:"model"`
-	APIKey      string  `json:"-"`
-	Temperature float64 `json:"temperature"`
-	MaxTokens   int     `json:"max_tokens"`
-	IsDefault   bool    `json:"is_default"`
-	CreatedAt   string  `json:"created_at"`
-	UpdatedAt   string  `json:"updated_at"`
+// OnChange 注册 Provider 变更回调，用于失效依赖 Provider 配置的缓存
+func (s *ProviderService) OnChange(handler ProviderChangeHandler) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.onChanges = append(s.onChanges, handler)
 }
 
-func (s *ProviderService) ToDTO(provider *models.Provider) *ProviderDTO {
-	return &ProviderDTO{
-		ID:          provider.ID,
-		Name:        provider.Name,
+// notifyChange 通知 Provider 已变更
+func (s *ProviderService) notifyChange(providerID string) {
+	s.mux.RLock()
+	handlers := append([]ProviderChangeHandler(nil), s.onChanges...)
+	s.mux.RUnlock()
+
+	for _, handler := range handlers {
+		handler(providerID)
+	}
+}
+
+// ModelConfig 将 Provider 转换为模型工厂配置
+func (s *ProviderService) ModelConfig(provider *models.Provider) *agentmodel.Config {
+	return &agentmodel.Config{
+		Type:        agentmodel.ProviderType(provider.GetType()),
 		BaseURL:     provider.BaseUrl,
-		Model:       provider.Model,
 		APIKey:      provider.ApiKey,
+		Model:       provider.Model,
 		Temperature: provider.Temperature,
 		MaxTokens:   provider.MaxTokens,
-		IsDefault:   provider.IsDefault,
-		CreatedAt:   provider.CreatedAt.Format("2006-01-02 15:04:05"),
-		UpdatedAt:   provider.UpdatedAt.Format("2006-01-02 15:04:05"),
+		Extra:       provider.GetExtra(),
+	}
+}
+
+type ProviderDTO struct {
+	ID                  string   `json:"id"`
+	Name                string   `json:"name"`
+	Type                string   `json:"type"`
+	BaseURL             string   `json:"base_url"`
+	Model               string   `json:"model"`
+	APIKey              string   `json:"-"`
+	Temperature         float32  `json:"temperature"`
+	MaxTokens           int      `json:"max_tokens"`
+	IsDefault           bool     `json:"is_default"`
+	Extra               string   `json:"extra,omitempty"`
+	FallbackProviderIDs []string `json:"fallback_provider_ids"` // 备用提供商 ID 列表
+	SupportsVision      bool     `json:"supports_vision"`       // 模型是否支持图片输入
+	CreatedAt           string   `json:"created_at"`
+	UpdatedAt           string   `json:"updated_at"`
+}
+
+func (s *ProviderService) ToDTO(provider *models.Provider) *ProviderDTO {
+	return &ProviderDTO{
+		ID:                  provider.ID,
+		Name:                provider.Name,
+		Type:                provider.GetType(),
+		BaseURL:             provider.BaseUrl,
+		Model:               provider.Model,
+		APIKey:              provider.ApiKey,
+		Temperature:         provider.Temperature,
+		MaxTokens:           provider.MaxTokens,
+		IsDefault:           provider.IsDefault,
+		Extra:               provider.Extra,
+		FallbackProviderIDs: provider.FallbackProviderIDs,
+		SupportsVision:      provider.SupportsVision,
+		CreatedAt:           provider.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:           provider.UpdatedAt.Format("2006-01-02 15:04:05"),
 	}
 }
 
@@ -111,6 +157,8 @@ func (s *ProviderService) Update(id string, updates map[string]interface{}) (*mo
 	if err := s.db.Model(&provider).Updates(updates).Error; err != nil {
 		return nil, err
 	}
+
+	s.notifyChange(id)
 	return &provider, nil
 }
 
@@ -122,6 +170,8 @@ func (s *ProviderService) Delete(id string) error {
 	if result.RowsAffected == 0 {
 		return gorm.ErrRecordNotFound
 	}
+
+	s.notifyChange(id)
 	return nil
 }
 
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ChatRun 正在执行的对话
+type ChatRun struct {
+	ID             string    `json:"id"`
+	SessionID      string    `json:"session_id"`
+	AssistantMsgID string    `json:"message_id"` // 本次运行写入的助手消息
+	StartedAt      time.Time `json:"started_at"`
+
+	cancel  context.CancelFunc
+	mux     sync.Mutex
+	stopped bool
+}
+
+// SetMessageID 设置本次运行写入的助手消息
+func (r *ChatRun) SetMessageID(id string) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.AssistantMsgID = id
+}
+
+// MessageID 本次运行写入的助手消息
+func (r *ChatRun) MessageID() string {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return r.AssistantMsgID
+}
+
+// Stopped 是否已被手动停止
+func (r *ChatRun) Stopped() bool {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return r.stopped
+}
+
+func (r *ChatRun) stop() {
+	r.mux.Lock()
+	r.stopped = true
+	r.mux.Unlock()
+	r.cancel()
+}
+
+// RunRegistry 按会话登记正在执行的对话，用于从其他请求中停止
+type RunRegistry struct {
+	mux  sync.Mutex
+	runs map[string]*ChatRun
+}
+
+func NewRunRegistry() *RunRegistry {
+	return &RunRegistry{
+		runs: make(map[string]*ChatRun),
+	}
+}
+
+// Start 登记一次对话运行，返回的 ctx 在 Stop 或 Finish 时取消
+//
+// 同一会话同时只允许一个运行。
+func (r *RunRegistry) Start(parent context.Context, sessionID string) (context.Context, *ChatRun, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if _, exists := r.runs[sessionID]; exists {
+		return nil, nil, fmt.Errorf("会话正在对话中，请先停止当前对话")
+	}
+
+	ctx, cancel := context.WithCancel(parent)
+	run := &ChatRun{
+		ID:        uuid.New().String(),
+		SessionID: sessionID,
+		StartedAt: time.Now(),
+		cancel:    cancel,
+	}
+	r.runs[sessionID] = run
+	return ctx, run, nil
+}
+
+// Finish 结束运行并释放资源
+func (r *RunRegistry) Finish(run *ChatRun) {
+	r.mux.Lock()
+	if current, ok := r.runs[run.SessionID]; ok && current == run {
+		delete(r.runs, run.SessionID)
+	}
+	r.mux.Unlock()
+	run.cancel()
+}
+
+// Stop 停止会话中正在执行的对话
+func (r *RunRegistry) Stop(sessionID string) (*ChatRun, error) {
+	r.mux.Lock()
+	run, ok := r.runs[sessionID]
+	r.mux.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("会话没有正在执行的对话")
+	}
+
+	run.stop()
+	return run, nil
+}
+
+// Get 获取会话中正在执行的对话
+func (r *RunRegistry) Get(sessionID string) (*ChatRun, bool) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	run, ok := r.runs[sessionID]
+	return run, ok
+}
@@ -53,6 +53,12 @@ func (s *SessionService) Update(id string, updates map[string]interface{}) (*mod
 	return &session, nil
 }
 
+// AddTokens 累加会话的 Token 用量
+func (s *SessionService) AddTokens(id string, tokens int) error {
+	return s.db.Model(&models.Session{}).Where("id = ?", id).
+		Update("total_tokens", gorm.Expr("total_tokens + ?", tokens)).Error
+}
+
 func (s *SessionService) Delete(id string) error {
 	result := s.db.Delete(&models.Session{}, "id = ?", id)
 	if result.Error != nil {
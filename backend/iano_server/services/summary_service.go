@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	iano "iano_agent"
+	"iano_server/models"
+	"log/slog"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// SummaryService 会话上下文摘要服务
+type SummaryService struct {
+	messageService    *MessageService
+	sessionService    *SessionService
+	attachmentService *AttachmentService
+}
+
+func NewSummaryService(messageService *MessageService, sessionService *SessionService, attachmentService *AttachmentService) *SummaryService {
+	return &SummaryService{
+		messageService:    messageService,
+		sessionService:    sessionService,
+		attachmentService: attachmentService,
+	}
+}
+
+// GetSessionConfig 获取会话配置，会话不存在时返回默认配置
+func (s *SummaryService) GetSessionConfig(sessionID string) *models.SessionConfig {
+	session, err := s.sessionService.GetByID(sessionID)
+	if err != nil {
+		return models.DefaultSessionConfig()
+	}
+	cfg, err := session.GetConfig()
+	if err != nil {
+		return models.DefaultSessionConfig()
+	}
+	return cfg
+}
+
+// BuildHistory 加载会话历史并转换为模型消息，每轮对话都通过它构建发送给模型的上下文。
+// 已被摘要覆盖的消息以最近一条摘要代替，摘要中的 UntilMessageID 记录覆盖到的位置；
+// 带附件的用户消息按保存的附件重新组装，vision 为 true 时图片以多模态内容发送。
+// 会话开启摘要且摘要之后的轮数超过 KeepRounds 的两倍时，将较早的轮次压缩为新的摘要并持久化为系统消息，
+// 返回值中的摘要消息为 nil 表示本次未压缩。
+func (s *SummaryService) BuildHistory(ctx context.Context, sessionID string, agent *iano.Agent, vision bool) ([]*schema.Message, *models.Message, error) {
+	historyMessages, err := s.messageService.GetBySessionID(sessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 找到最近一条摘要
+	var lastSummary *models.Message
+	var lastSummaryInfo *models.SummaryInfo
+	lastSummaryIndex := -1
+	for i := range historyMessages {
+		msg := &historyMessages[i]
+		if !msg.IsSummary() {
+			continue
+		}
+		content, _ := msg.GetContent()
+		lastSummary = msg
+		lastSummaryInfo = content.Summary
+		lastSummaryIndex = i
+	}
+
+	chatMessages := make([]*schema.Message, 0, len(historyMessages))
+	messageIDs := make([]string, 0, len(historyMessages))
+
+	// 跳过已被摘要覆盖的消息
+	start := 0
+	if lastSummary != nil {
+		untilID := lastSummaryInfo.UntilMessageID
+		start = -1
+		for i, msg := range historyMessages {
+			if msg.ID == untilID {
+				start = i + 1
+				break
+			}
+		}
+		// 找不到覆盖位置时（例如消息已被删除），视为摘要覆盖了它之前创建的全部消息
+		if start < 0 {
+			start = lastSummaryIndex
+			if start > 0 {
+				untilID = historyMessages[start-1].ID
+			}
+		}
+		chatMessages = append(chatMessages, iano.NewSummaryMessage(lastSummary.GetText()))
+		messageIDs = append(messageIDs, untilID)
+	}
+
+	for _, msg := range historyMessages[start:] {
+		if msg.IsSummary() {
+			continue
+		}
+
+		if msg.Type == models.MessageTypeUser && len(msg.GetAttachments()) > 0 {
+			chatMessages = append(chatMessages, s.userMessage(&msg, vision))
+			messageIDs = append(messageIDs, msg.ID)
+			continue
+		}
+
+		text := msg.GetText()
+		if text == "" {
+			continue
+		}
+
+		switch msg.Type {
+		case models.MessageTypeUser:
+			chatMessages = append(chatMessages, schema.UserMessage(text))
+		case models.MessageTypeAssistant:
+			chatMessages = append(chatMessages, schema.AssistantMessage(text, nil))
+		case models.MessageTypeTool:
+			chatMessages = append(chatMessages, schema.ToolMessage(text, ""))
+		case models.MessageTypeSystem:
+			chatMessages = append(chatMessages, schema.SystemMessage(text))
+		default:
+			continue
+		}
+		messageIDs = append(messageIDs, msg.ID)
+	}
+
+	cfg := s.GetSessionConfig(sessionID)
+	if !cfg.EnableSummary || agent == nil || !iano.NeedsSummary(chatMessages, cfg.KeepRounds) {
+		return chatMessages, nil, nil
+	}
+
+	result, err := agent.Summarize(ctx, chatMessages, cfg.KeepRounds)
+	if err != nil {
+		// 摘要失败不影响对话，使用完整历史继续
+		slog.Warn("会话摘要失败，使用完整历史", "sessionID", sessionID, "error", err)
+		return chatMessages, nil, nil
+	}
+	if !result.Compacted() {
+		return chatMessages, nil, nil
+	}
+
+	summaryMsg := &models.Message{
+		SessionID: sessionID,
+		Type:      models.MessageTypeSystem,
+		Status:    models.MessageStatusCompleted,
+	}
+	summaryMsg.NewID()
+	if result.Usage != nil {
+		summaryMsg.InputTokens = int(result.Usage.PromptTokens)
+		summaryMsg.OutputTokens = int(result.Usage.CompletionTokens)
+	}
+	err = summaryMsg.SetContent(&models.MessageContent{
+		Text: result.Summary,
+		Summary: &models.SummaryInfo{
+			UntilMessageID: messageIDs[result.LastSummarizedIndex],
+			Rounds:         result.RoundsSummarized,
+			OldTokens:      result.OldTokens,
+			SummaryTokens:  result.SummaryTokens,
+			SavedTokens:    result.SavedTokens,
+		},
+	})
+	if err != nil {
+		return result.Messages, nil, nil
+	}
+
+	if err := s.messageService.Create(summaryMsg); err != nil {
+		slog.Warn("保存会话摘要失败", "sessionID", sessionID, "error", err)
+		return result.Messages, nil, nil
+	}
+	// 生成摘要的调用同样计入会话用量
+	if result.Usage != nil && result.Usage.TotalTokens > 0 {
+		if err := s.sessionService.AddTokens(sessionID, int(result.Usage.TotalTokens)); err != nil {
+			slog.Warn("更新会话 Token 用量失败", "sessionID", sessionID, "error", err)
+		}
+	}
+
+	return result.Messages, summaryMsg, nil
+}
+
+// userMessage 按保存的附件重新组装用户消息，已不存在或读取失败的附件只保留文本
+func (s *SummaryService) userMessage(msg *models.Message, vision bool) *schema.Message {
+	text := msg.GetText()
+	attachments := msg.GetAttachments()
+	files := make([]models.File, 0, len(attachments))
+	for _, attachment := range attachments {
+		file, err := s.attachmentService.GetByID(attachment.ID)
+		if err != nil {
+			slog.Warn("历史消息的附件不存在", "messageID", msg.ID, "attachmentID", attachment.ID, "error", err)
+			continue
+		}
+		files = append(files, *file)
+	}
+
+	userMsg, err := s.attachmentService.BuildUserMessage(text, files, vision)
+	if err != nil {
+		slog.Warn("组装历史消息的附件失败", "messageID", msg.ID, "error", err)
+		return schema.UserMessage(text)
+	}
+	return userMsg
+}
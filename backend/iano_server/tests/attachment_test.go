@@ -0,0 +1,245 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"iano_server/models"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// pngHeader PNG 文件头，足以让内容识别判断为图片
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00")
+
+// uploadFile 以 multipart 表单上传单个文件，contentType 为客户端声明的类型
+func (s *TestServer) uploadFile(t *testing.T, name, contentType string, data []byte) (int, map[string]interface{}) {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="file"; filename="`+name+`"`)
+	header.Set("Content-Type", contentType)
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(data)
+	writer.Close()
+
+	resp, err := s.Client().Post(s.URL+"/api/attachments", writer.FormDataContentType(), &body)
+	if err != nil {
+		t.Fatalf("Failed to upload: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var response map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	return resp.StatusCode, response
+}
+
+// uploadedFile 上传文件并返回保存的附件记录
+func (s *TestServer) uploadedFile(t *testing.T, name, contentType string, data []byte) *models.File {
+	t.Helper()
+
+	status, response := s.uploadFile(t, name, contentType, data)
+	if status != http.StatusCreated {
+		t.Fatalf("upload %s: status %d, %v", name, status, response)
+	}
+	id := response["data"].([]interface{})[0].(map[string]interface{})["id"].(string)
+	file, err := s.Container.AttachmentService.GetByID(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestAttachmentUploadSizeLimit(t *testing.T) {
+	server := NewTestServer(t)
+
+	// 测试配置的上限为 1 MB
+	status, response := server.uploadFile(t, "big.txt", "text/plain", bytes.Repeat([]byte("a"), 1<<20+1))
+	if status != http.StatusBadRequest || !strings.Contains(response["message"].(string), "超过上限") {
+		t.Errorf("oversized upload: status %d, %v", status, response)
+	}
+
+	file := server.uploadedFile(t, "limit.txt", "text/plain", bytes.Repeat([]byte("a"), 1<<20))
+	if file.Size != 1<<20 {
+		t.Errorf("size = %d, want %d", file.Size, 1<<20)
+	}
+}
+
+func TestAttachmentUploadDedup(t *testing.T) {
+	server := NewTestServer(t)
+
+	first := server.uploadedFile(t, "a.txt", "text/plain", []byte("same content"))
+	second := server.uploadedFile(t, "b.txt", "text/plain", []byte("same content"))
+	other := server.uploadedFile(t, "c.txt", "text/plain", []byte("other content"))
+
+	// 相同内容各有一条附件记录，磁盘上只保存一份
+	if first.ID == second.ID || first.Hash != second.Hash || first.Hash == other.Hash {
+		t.Errorf("first = %+v, second = %+v, other = %+v", first, second, other)
+	}
+	service := server.Container.AttachmentService
+	if service.Path(first) != service.Path(second) {
+		t.Errorf("paths differ: %s, %s", service.Path(first), service.Path(second))
+	}
+	data, err := os.ReadFile(service.Path(second))
+	if err != nil || string(data) != "same content" {
+		t.Errorf("stored content = %q, %v", data, err)
+	}
+}
+
+func TestAttachmentContentHeaders(t *testing.T) {
+	server := NewTestServer(t)
+
+	tests := []struct {
+		name            string
+		declared        string
+		data            []byte
+		wantType        string
+		wantDisposition string
+	}{
+		{"photo.png", "image/png", pngHeader, "image/png", "inline"},
+		{"notes.txt", "text/plain", []byte("普通文本"), "text/plain", "inline"},
+		// 声明为图片的 HTML 按内容识别，作为下载返回
+		{"evil.png", "image/png", []byte("<html><script>alert(1)</script></html>"), "text/html", "attachment"},
+		// SVG 不能作为图片在浏览器中打开
+		{"logo.svg", "image/svg+xml", []byte(`<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`), "text/plain", "inline"},
+		{"data.json", "text/html", []byte(`{"a": 1}`), "application/json", "attachment"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := server.uploadedFile(t, tt.name, tt.declared, tt.data)
+			if file.MimeType != tt.wantType {
+				t.Errorf("mime type = %s, want %s", file.MimeType, tt.wantType)
+			}
+
+			resp, err := server.Client().Get(server.URL + "/api/attachments/" + file.ID + "/content")
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if got := resp.Header.Get("Content-Type"); !strings.HasPrefix(got, tt.wantType) {
+				t.Errorf("Content-Type = %s, want %s", got, tt.wantType)
+			}
+			if got := resp.Header.Get("X-Content-Type-Options"); got != "nosniff" {
+				t.Errorf("X-Content-Type-Options = %q", got)
+			}
+			if got := resp.Header.Get("Content-Disposition"); !strings.HasPrefix(got, tt.wantDisposition+";") || !strings.Contains(got, tt.name) {
+				t.Errorf("Content-Disposition = %s, want %s", got, tt.wantDisposition)
+			}
+		})
+	}
+}
+
+func TestAttachmentBuildUserMessage(t *testing.T) {
+	server := NewTestServer(t)
+	service := server.Container.AttachmentService
+
+	text := server.uploadedFile(t, "config.json", "application/json", []byte(`{"debug": true}`))
+	image := server.uploadedFile(t, "screen.png", "image/png", pngHeader)
+	binary := server.uploadedFile(t, "report.pdf", "application/pdf", []byte("%PDF-1.7\n"))
+	files := []models.File{*text, *image, *binary}
+
+	t.Run("支持图片输入", func(t *testing.T) {
+		msg, err := service.BuildUserMessage("看看这些文件", files, true)
+		if err != nil {
+			t.Fatalf("BuildUserMessage() error = %v", err)
+		}
+		if msg.Content != "" || len(msg.UserInputMultiContent) != 2 {
+			t.Fatalf("message = %+v", msg)
+		}
+		textPart, imagePart := msg.UserInputMultiContent[0], msg.UserInputMultiContent[1]
+		if textPart.Type != schema.ChatMessagePartTypeText || !strings.Contains(textPart.Text, "<file name=\"config.json\">\n{\"debug\": true}\n</file>") ||
+			!strings.Contains(textPart.Text, "report.pdf") || !strings.HasSuffix(textPart.Text, "看看这些文件") {
+			t.Errorf("text part = %q", textPart.Text)
+		}
+		if imagePart.Type != schema.ChatMessagePartTypeImageURL || imagePart.Image.MIMEType != "image/png" || imagePart.Image.Base64Data == nil {
+			t.Errorf("image part = %+v", imagePart)
+		}
+	})
+
+	t.Run("不支持图片输入", func(t *testing.T) {
+		msg, err := service.BuildUserMessage("看看这些文件", files, false)
+		if err != nil {
+			t.Fatalf("BuildUserMessage() error = %v", err)
+		}
+		if len(msg.UserInputMultiContent) != 0 || !strings.Contains(msg.Content, "screen.png 是图片") ||
+			!strings.Contains(msg.Content, "report.pdf（application/pdf") {
+			t.Errorf("content = %q", msg.Content)
+		}
+	})
+}
+
+func TestAttachmentHistoryAcrossTurns(t *testing.T) {
+	server := NewTestServer(t)
+	text := server.uploadedFile(t, "config.json", "application/json", []byte(`{"debug": true}`))
+	image := server.uploadedFile(t, "screen.png", "image/png", pngHeader)
+
+	created := server.Do(t, http.MethodPost, "/api/sessions", map[string]interface{}{"title": "attachments"})
+	sessionID := created["data"].(map[string]interface{})["id"].(string)
+
+	// 第一轮带附件提问，第二轮只发送文本
+	createdAt := time.Now()
+	addMessage := func(msgType models.MessageType, content *models.MessageContent) {
+		msg := &models.Message{SessionID: sessionID, Type: msgType, Status: models.MessageStatusCompleted}
+		msg.NewID()
+		msg.SetContent(content)
+		createdAt = createdAt.Add(time.Millisecond)
+		msg.CreatedAt = createdAt
+		if err := server.Container.MessageService.Create(msg); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+	addMessage(models.MessageTypeUser, &models.MessageContent{
+		Text:        "看看这些文件",
+		Attachments: []models.Attachment{text.ToAttachment(), image.ToAttachment()},
+	})
+	addMessage(models.MessageTypeAssistant, &models.MessageContent{Text: "已查看"})
+	addMessage(models.MessageTypeUser, &models.MessageContent{Text: "配置里的 debug 是什么"})
+
+	build := func(vision bool) []*schema.Message {
+		t.Helper()
+		messages, _, err := server.Container.SummaryService.BuildHistory(context.Background(), sessionID, nil, vision)
+		if err != nil {
+			t.Fatalf("BuildHistory() error = %v", err)
+		}
+		if len(messages) != 3 || messages[2].Content != "配置里的 debug 是什么" {
+			t.Fatalf("history = %+v", messages)
+		}
+		return messages
+	}
+
+	t.Run("支持图片输入", func(t *testing.T) {
+		first := build(true)[0]
+		if first.Content != "" || len(first.UserInputMultiContent) != 2 {
+			t.Fatalf("first turn = %+v", first)
+		}
+		if textPart := first.UserInputMultiContent[0]; !strings.Contains(textPart.Text, "<file name=\"config.json\">\n{\"debug\": true}\n</file>") {
+			t.Errorf("text part = %q", textPart.Text)
+		}
+		if imagePart := first.UserInputMultiContent[1]; imagePart.Type != schema.ChatMessagePartTypeImageURL || imagePart.Image.Base64Data == nil {
+			t.Errorf("image part = %+v", imagePart)
+		}
+	})
+
+	t.Run("不支持图片输入", func(t *testing.T) {
+		first := build(false)[0]
+		if len(first.UserInputMultiContent) != 0 || !strings.Contains(first.Content, "{\"debug\": true}") ||
+			!strings.Contains(first.Content, "screen.png 是图片") || !strings.HasSuffix(first.Content, "看看这些文件") {
+			t.Errorf("first turn = %q", first.Content)
+		}
+	})
+}
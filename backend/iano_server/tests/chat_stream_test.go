@@ -0,0 +1,186 @@
+package tests
+
+import (
+	"iano_server/models"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFileRules 先调用 file_write 写入 note.txt，再根据用户消息回复
+const writeFileRules = `[
+	{
+		"match": "记录",
+		"responses": [
+			{"think": "需要写入文件", "tool_calls": [{"name": "file_write", "arguments": {"path": "note.txt", "content": "hello"}}]},
+			{"chunks": ["已经", "记录好了"]}
+		]
+	}
+]`
+
+// completedEvent 返回最后一个 message_completed 事件的数据
+func completedEvent(t *testing.T, events []SSEEvent) map[string]interface{} {
+	t.Helper()
+	completed := FindEvents(events, models.MessageEventCompleted)
+	if len(completed) == 0 {
+		t.Fatalf("no %s event in %+v", models.MessageEventCompleted, events)
+	}
+	return completed[len(completed)-1].Data
+}
+
+// hasFailedEvent 是否推送了状态为 failed 的 message_completed 事件
+func hasFailedEvent(events []SSEEvent) bool {
+	for _, event := range FindEvents(events, models.MessageEventCompleted) {
+		if event.Data["status"] == models.MessageStatusFailed.ToString() {
+			return true
+		}
+	}
+	return false
+}
+
+// assistantText 拼接推送给客户端的助手回复内容和思考内容
+func assistantText(events []SSEEvent) (text string, think string) {
+	var textBuf, thinkBuf strings.Builder
+	for _, event := range FindEvents(events, models.MessageEventContent) {
+		if content, ok := event.Data["content"].(string); ok {
+			textBuf.WriteString(content)
+		}
+		if content, ok := event.Data["think_content"].(string); ok {
+			thinkBuf.WriteString(content)
+		}
+	}
+	return textBuf.String(), thinkBuf.String()
+}
+
+func TestIntegrationChatToolLoop(t *testing.T) {
+	server := NewTestServer(t)
+	agentID := server.CreateScriptAgent(t, writeFileRules, map[string]interface{}{
+		"tools":           "file_write",
+		"approval_policy": "auto",
+	})
+	workDir := t.TempDir()
+
+	events := server.StreamChat(t, map[string]interface{}{
+		"session_id": "tool-loop",
+		"agent_id":   agentID,
+		"message":    "帮我记录一下",
+		"work_dir":   workDir,
+	}, nil)
+
+	if got := completedEvent(t, events)["status"]; got != models.MessageStatusCompleted.ToString() {
+		t.Errorf("status = %v, want completed", got)
+	}
+	if text, think := assistantText(events); !strings.HasSuffix(text, "已经记录好了") || !strings.Contains(text+think, "需要写入文件") {
+		t.Errorf("assistant text = %q, think = %q", text, think)
+	}
+	data, err := os.ReadFile(filepath.Join(workDir, "note.txt"))
+	if err != nil || string(data) != "hello" {
+		t.Errorf("note.txt = %q, %v", data, err)
+	}
+}
+
+func TestIntegrationChatApproval(t *testing.T) {
+	tests := []struct {
+		name     string
+		approved bool
+	}{
+		{name: "允许执行", approved: true},
+		{name: "拒绝执行", approved: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := NewTestServer(t)
+			agentID := server.CreateScriptAgent(t, writeFileRules, map[string]interface{}{
+				"tools":           "file_write",
+				"approval_policy": "ask",
+			})
+			workDir := t.TempDir()
+
+			events := server.StreamChat(t, map[string]interface{}{
+				"session_id": "approval-" + tt.name,
+				"agent_id":   agentID,
+				"message":    "帮我记录一下",
+				"work_dir":   workDir,
+			}, func(event SSEEvent) {
+				if event.Event != models.MessageEventToolApproval.ToString() {
+					return
+				}
+				if event.Data["tool"] != "file_write" {
+					t.Errorf("approval tool = %v", event.Data["tool"])
+				}
+				resp := server.Do(t, http.MethodPost, "/api/chat/approve", map[string]interface{}{
+					"id":       event.Data["id"],
+					"approved": tt.approved,
+					"reason":   "测试拒绝",
+				})
+				AssertSuccess(t, resp)
+			})
+
+			resolved := FindEvents(events, models.MessageEventToolApprovalResolved)
+			if len(resolved) != 1 || resolved[0].Data["approved"] != tt.approved {
+				t.Fatalf("resolved events = %+v", resolved)
+			}
+			if got := completedEvent(t, events)["status"]; got != models.MessageStatusCompleted.ToString() {
+				t.Errorf("status = %v, want completed", got)
+			}
+			_, err := os.Stat(filepath.Join(workDir, "note.txt"))
+			if written := err == nil; written != tt.approved {
+				t.Errorf("note.txt written = %v, want %v", written, tt.approved)
+			}
+		})
+	}
+}
+
+func TestIntegrationChatErrors(t *testing.T) {
+	server := NewTestServer(t)
+
+	t.Run("模型返回错误", func(t *testing.T) {
+		agentID := server.CreateScriptAgent(t, `[{"responses": [{"error": "服务暂时不可用"}]}]`, nil)
+		events := server.StreamChat(t, map[string]interface{}{
+			"session_id": "model-error",
+			"agent_id":   agentID,
+			"message":    "你好",
+		}, nil)
+
+		if !hasFailedEvent(events) {
+			t.Errorf("no failed %s event in %+v", models.MessageEventCompleted, events)
+		}
+	})
+
+	t.Run("达到最大轮数", func(t *testing.T) {
+		agentID := server.CreateScriptAgent(t, `[{"responses": [{"tool_calls": [{"name": "file_write", "arguments": {"path": "loop.txt", "content": "x"}}]}]}]`, map[string]interface{}{
+			"tools":           "file_write",
+			"approval_policy": "auto",
+		})
+		events := server.StreamChat(t, map[string]interface{}{
+			"session_id": "max-rounds",
+			"agent_id":   agentID,
+			"message":    "一直写",
+			"work_dir":   t.TempDir(),
+			"budget":     map[string]interface{}{"max_rounds": 2},
+		}, nil)
+
+		completed := completedEvent(t, events)
+		if completed["status"] != models.MessageStatusCompleted.ToString() || completed["stop_reason"] != "max_rounds" {
+			t.Errorf("completed = %+v", completed)
+		}
+		if text, _ := assistantText(events); !strings.Contains(text, "运行已停止") {
+			t.Errorf("assistant text = %q", text)
+		}
+	})
+
+	t.Run("Agent 不存在", func(t *testing.T) {
+		events := server.StreamChat(t, map[string]interface{}{
+			"session_id": "missing-agent",
+			"agent_id":   "missing",
+			"message":    "你好",
+		}, nil)
+		errs := FindEvents(events, models.MessageEventError)
+		if len(errs) != 1 || !strings.Contains(errs[0].Data["error"].(string), "agent not found") {
+			t.Errorf("error events = %+v", errs)
+		}
+	})
+}
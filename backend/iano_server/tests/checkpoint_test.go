@@ -0,0 +1,128 @@
+package tests
+
+import (
+	"iano_server/models"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// checkpointRules 第一轮新建 note.txt，第二轮修改 note.txt 并新建 todo.txt
+const checkpointRules = `[
+	{
+		"match": "第一轮",
+		"responses": [
+			{"tool_calls": [{"name": "file_write", "arguments": {"path": "note.txt", "content": "v1\n"}}]},
+			{"content": "已创建"}
+		]
+	},
+	{
+		"match": "第二轮",
+		"responses": [
+			{"tool_calls": [
+				{"name": "file_write", "arguments": {"path": "note.txt", "content": "v2\n"}},
+				{"name": "file_write", "arguments": {"path": "todo.txt", "content": "todo\n"}}
+			]},
+			{"tool_calls": [{"name": "file_write", "arguments": {"path": "note.txt", "content": "v3\n"}}]},
+			{"content": "已修改"}
+		]
+	}
+]`
+
+// assistantMessageID 返回流式聊天中创建的助手消息 ID
+func assistantMessageID(t *testing.T, events []SSEEvent) string {
+	t.Helper()
+	for _, event := range FindEvents(events, models.MessageEventCreated) {
+		if event.Data["type"] == models.MessageTypeAssistant.ToString() {
+			return event.Data["id"].(string)
+		}
+	}
+	t.Fatalf("no assistant %s event in %+v", models.MessageEventCreated, events)
+	return ""
+}
+
+func readWorkFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if os.IsNotExist(err) {
+		return "<missing>"
+	}
+	if err != nil {
+		t.Fatalf("ReadFile(%s) error = %v", name, err)
+	}
+	return string(data)
+}
+
+func TestIntegrationCheckpoints(t *testing.T) {
+	server := NewTestServer(t)
+	agentID := server.CreateScriptAgent(t, checkpointRules, map[string]interface{}{
+		"tools":           "file_write",
+		"approval_policy": "auto",
+	})
+	workDir := t.TempDir()
+	const sessionID = "checkpoints"
+
+	chat := func(message string) string {
+		events := server.StreamChat(t, map[string]interface{}{
+			"session_id": sessionID,
+			"agent_id":   agentID,
+			"message":    message,
+			"work_dir":   workDir,
+		}, nil)
+		return assistantMessageID(t, events)
+	}
+	first := chat("第一轮")
+	second := chat("第二轮")
+	if got := readWorkFile(t, workDir, "note.txt") + readWorkFile(t, workDir, "todo.txt"); got != "v3\ntodo\n" {
+		t.Fatalf("files after chat = %q", got)
+	}
+
+	list := server.Do(t, http.MethodGet, "/api/sessions/"+sessionID+"/checkpoints", nil)
+	AssertSuccess(t, list)
+	groups := list["data"].([]interface{})
+	if len(groups) != 2 {
+		t.Fatalf("checkpoint groups = %+v", groups)
+	}
+	// 同一条消息中 note.txt 被修改两次，只保存第一次修改前的内容
+	secondGroup := groups[1].(map[string]interface{})
+	if secondGroup["message_id"] != second || len(secondGroup["files"].([]interface{})) != 2 {
+		t.Errorf("second group = %+v", secondGroup)
+	}
+
+	diff := server.Do(t, http.MethodGet, "/api/sessions/"+sessionID+"/checkpoints/"+second+"/diff", nil)
+	AssertSuccess(t, diff)
+	statuses := make(map[string]string)
+	for _, item := range diff["data"].([]interface{}) {
+		d := item.(map[string]interface{})
+		name := filepath.Base(d["path"].(string))
+		statuses[name] = d["status"].(string)
+		if name == "note.txt" && !strings.Contains(d["diff"].(string), "-v1\n+v3\n") {
+			t.Errorf("note.txt diff = %q", d["diff"])
+		}
+	}
+	if statuses["note.txt"] != "modified" || statuses["todo.txt"] != "created" {
+		t.Errorf("diff statuses = %v", statuses)
+	}
+
+	// 撤销第一轮之后的所有修改
+	restored := server.Do(t, http.MethodPost, "/api/sessions/"+sessionID+"/checkpoints/"+first+"/restore", map[string]string{"scope": "after"})
+	AssertSuccess(t, restored)
+	if got := readWorkFile(t, workDir, "note.txt") + readWorkFile(t, workDir, "todo.txt"); got != "v1\n<missing>" {
+		t.Errorf("files after restoring changes after first message = %q", got)
+	}
+
+	// 撤销第一轮的修改
+	restored = server.Do(t, http.MethodPost, "/api/sessions/"+sessionID+"/checkpoints/"+first+"/restore", nil)
+	AssertSuccess(t, restored)
+	if deleted := restored["data"].(map[string]interface{})["deleted"].([]interface{}); len(deleted) != 1 {
+		t.Errorf("deleted = %v", deleted)
+	}
+	if got := readWorkFile(t, workDir, "note.txt"); got != "<missing>" {
+		t.Errorf("note.txt after restoring first message = %q", got)
+	}
+
+	AssertError(t, server.Do(t, http.MethodPost, "/api/sessions/"+sessionID+"/checkpoints/"+first+"/restore", map[string]string{"scope": "all"}))
+	AssertError(t, server.Do(t, http.MethodGet, "/api/sessions/other/checkpoints/"+first+"/diff", nil))
+}
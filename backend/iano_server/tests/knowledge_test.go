@@ -0,0 +1,196 @@
+package tests
+
+import (
+	"iano_server/models"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// kbRules 询问数据库配置时先检索知识库，再按引用编号作答
+const kbRules = `[
+	{"match": "数据库", "responses": [
+		{"tool_calls": [{"name": "kb_search", "arguments": {"query": "数据库连接串"}}]},
+		{"content": "连接串写在 config.yaml 的 database 节 [1]"}
+	]},
+	{"responses": [{"content": "好的"}]}
+]`
+
+const deployGuide = `# 部署指南
+
+## 安装
+
+下载安装包并解压到 /opt/iano。
+
+## 数据库
+
+数据库连接串写在 config.yaml 的 database 节，修改后需要重启服务。
+`
+
+const faqPage = `<html><head><title>常见问题</title></head><body>
+<h2>端口</h2><p>服务默认监听 8080 端口。</p>
+</body></html>`
+
+func TestIntegrationKnowledgeBase(t *testing.T) {
+	server := NewTestServer(t)
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"deploy.md":         deployGuide,
+		"faq/ports.html":    faqPage,
+		"logo.png":          "png",
+		".git/notes.md":     "# 隐藏目录中的文件不导入",
+		"broken/bad.txt":    "\xff\xfe\x00",
+		"node_modules/a.md": "# 依赖包中的文件不导入",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	embedProvider := server.Do(t, http.MethodPost, "/api/providers", map[string]interface{}{
+		"name":     "embedding-script",
+		"type":     "script",
+		"base_url": "script://local",
+		"model":    "script",
+	})
+	AssertSuccess(t, embedProvider)
+	embedProviderID := embedProvider["data"].(map[string]interface{})["id"].(string)
+
+	t.Run("参数校验", func(t *testing.T) {
+		AssertError(t, server.Do(t, http.MethodPost, "/api/knowledge-bases", map[string]interface{}{"name": ""}))
+		AssertError(t, server.Do(t, http.MethodPost, "/api/knowledge-bases", map[string]interface{}{
+			"name": "no-model", "embedding_provider_id": embedProviderID,
+		}))
+		AssertError(t, server.Do(t, http.MethodPost, "/api/knowledge-bases", map[string]interface{}{
+			"name": "bad-chunk", "chunk_size": -1,
+		}))
+	})
+
+	created := server.Do(t, http.MethodPost, "/api/knowledge-bases", map[string]interface{}{
+		"name":                  "运维手册",
+		"embedding_provider_id": embedProviderID,
+		"embedding_model":       "hash",
+		"chunk_size":            200,
+	})
+	AssertSuccess(t, created)
+	kbID := created["data"].(map[string]interface{})["id"].(string)
+	AssertError(t, server.Do(t, http.MethodPost, "/api/knowledge-bases", map[string]interface{}{"name": "运维手册"}))
+
+	ingest := func(body map[string]interface{}) []interface{} {
+		t.Helper()
+		resp := server.Do(t, http.MethodPost, "/api/knowledge-bases/"+kbID+"/documents", body)
+		AssertSuccess(t, resp)
+		return resp["data"].([]interface{})
+	}
+
+	t.Run("导入目录", func(t *testing.T) {
+		docs := ingest(map[string]interface{}{"path": dir})
+		status := make(map[string]string)
+		for _, d := range docs {
+			doc := d.(map[string]interface{})
+			status[filepath.Base(doc["source"].(string))] = doc["status"].(string)
+			if doc["status"] == models.KnowledgeDocumentStatusReady && doc["chunk_count"].(float64) == 0 {
+				t.Errorf("ready document without chunks: %+v", doc)
+			}
+		}
+		want := map[string]string{
+			"deploy.md":  models.KnowledgeDocumentStatusReady,
+			"ports.html": models.KnowledgeDocumentStatusReady,
+			"bad.txt":    models.KnowledgeDocumentStatusFailed,
+		}
+		if len(status) != len(want) {
+			t.Fatalf("ingested = %v, want %v", status, want)
+		}
+		for name, s := range want {
+			if status[name] != s {
+				t.Errorf("%s status = %q, want %q", name, status[name], s)
+			}
+		}
+
+		// 重新导入同一路径时替换原有文档
+		ingest(map[string]interface{}{"path": dir})
+		listed := server.Do(t, http.MethodGet, "/api/knowledge-bases/"+kbID+"/documents", nil)
+		if docs := listed["data"].([]interface{}); len(docs) != 3 {
+			t.Errorf("documents after re-ingest = %d, want 3", len(docs))
+		}
+
+		AssertError(t, server.Do(t, http.MethodPost, "/api/knowledge-bases/"+kbID+"/documents", map[string]interface{}{"path": "relative/docs"}))
+		AssertError(t, server.Do(t, http.MethodPost, "/api/knowledge-bases/"+kbID+"/documents", map[string]interface{}{}))
+	})
+
+	t.Run("导入文本", func(t *testing.T) {
+		docs := ingest(map[string]interface{}{"title": "值班手册", "content": "告警先确认影响范围，再通知负责人。"})
+		if doc := docs[0].(map[string]interface{}); doc["title"] != "值班手册" || doc["status"] != models.KnowledgeDocumentStatusReady {
+			t.Errorf("document = %+v", doc)
+		}
+	})
+
+	t.Run("检索", func(t *testing.T) {
+		resp := server.Do(t, http.MethodGet, "/api/knowledge-bases/"+kbID+"/search?q=数据库连接串在哪里配置&limit=2", nil)
+		AssertSuccess(t, resp)
+		results := resp["data"].([]interface{})
+		if len(results) == 0 || len(results) > 2 {
+			t.Fatalf("search results = %+v", results)
+		}
+		top := results[0].(map[string]interface{})
+		if top["heading"] != "部署指南 > 数据库" || !strings.Contains(top["content"].(string), "config.yaml") || top["knowledge_base"] != "运维手册" {
+			t.Errorf("top result = %+v", top)
+		}
+		AssertError(t, server.Do(t, http.MethodGet, "/api/knowledge-bases/"+kbID+"/search", nil))
+	})
+
+	t.Run("Agent 引用", func(t *testing.T) {
+		agentID := server.CreateScriptAgent(t, kbRules, map[string]interface{}{
+			"knowledge_base_ids": []string{kbID},
+		})
+		events := server.StreamChat(t, map[string]interface{}{
+			"session_id": "kb-session",
+			"agent_id":   agentID,
+			"message":    "数据库连接串在哪里配置？",
+		}, nil)
+
+		completed := completedEvent(t, events)
+		citations, _ := completed["citations"].([]interface{})
+		if len(citations) == 0 {
+			t.Fatalf("completed = %+v", completed)
+		}
+		first := citations[0].(map[string]interface{})
+		if first["index"] != float64(1) || first["title"] != "部署指南" || first["knowledge_base_id"] != kbID {
+			t.Errorf("citation = %+v", first)
+		}
+
+		msg, err := server.Container.MessageService.GetByID(completed["id"].(string))
+		if err != nil {
+			t.Fatal(err)
+		}
+		stored := msg.GetCitations()
+		if len(stored) != len(citations) || stored[0].ChunkID != first["chunk_id"] || !strings.Contains(stored[0].Content, "config.yaml") {
+			t.Errorf("stored citations = %+v", stored)
+		}
+	})
+
+	t.Run("删除", func(t *testing.T) {
+		listed := server.Do(t, http.MethodGet, "/api/knowledge-bases/"+kbID+"/documents", nil)
+		docID := listed["data"].([]interface{})[0].(map[string]interface{})["id"].(string)
+		AssertSuccess(t, server.Do(t, http.MethodDelete, "/api/knowledge-bases/"+kbID+"/documents/"+docID, nil))
+		AssertError(t, server.Do(t, http.MethodDelete, "/api/knowledge-bases/"+kbID+"/documents/"+docID, nil))
+
+		AssertSuccess(t, server.Do(t, http.MethodDelete, "/api/knowledge-bases/"+kbID, nil))
+		AssertError(t, server.Do(t, http.MethodGet, "/api/knowledge-bases/"+kbID, nil))
+		var count int64
+		server.DB.DB.Model(&models.KnowledgeChunk{}).Where("knowledge_base_id = ?", kbID).Count(&count)
+		if count != 0 {
+			t.Errorf("chunks after delete = %d", count)
+		}
+		server.DB.DB.Raw("SELECT COUNT(*) FROM knowledge_chunks_fts WHERE knowledge_base_id = ?", kbID).Scan(&count)
+		if count != 0 {
+			t.Errorf("full-text index rows after delete = %d", count)
+		}
+	})
+}
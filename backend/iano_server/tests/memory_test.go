@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"iano_server/models"
+	"net/http"
+	"testing"
+)
+
+// rememberRules 要求记住时调用 memory_save，然后回复确认
+const rememberRules = `[
+	{"match": "记住", "responses": [
+		{"tool_calls": [{"name": "memory_save", "arguments": {"content": "用户喜欢的编程语言是 Go", "tags": "偏好"}}]},
+		{"content": "记住了"}
+	]},
+	{"responses": [{"content": "好的"}]}
+]`
+
+func listMemories(t *testing.T, server *TestServer, query string) []interface{} {
+	t.Helper()
+	resp := server.Do(t, http.MethodGet, "/api/memories?"+query, nil)
+	AssertSuccess(t, resp)
+	return resp["data"].([]interface{})
+}
+
+func TestIntegrationMemory(t *testing.T) {
+	server := NewTestServer(t)
+
+	t.Run("无效的记忆范围", func(t *testing.T) {
+		AssertError(t, server.Do(t, http.MethodPost, "/api/agents", map[string]interface{}{
+			"name":         "memory-invalid",
+			"memory_scope": "session",
+		}))
+	})
+
+	agentID := server.CreateScriptAgent(t, rememberRules, map[string]interface{}{
+		"approval_policy": "auto",
+		"memory_scope":    models.MemoryScopeUser,
+	})
+	remember := func(sessionID, userID string) {
+		events := server.StreamChat(t, map[string]interface{}{
+			"session_id": sessionID,
+			"agent_id":   agentID,
+			"user_id":    userID,
+			"message":    "记住我喜欢 Go",
+		}, nil)
+		if got := completedEvent(t, events)["status"]; got != models.MessageStatusCompleted.ToString() {
+			t.Fatalf("status = %v, want completed", got)
+		}
+	}
+
+	remember("memory-alice-1", "alice")
+	// 同一用户在另一个会话中重复记住相同的内容不会产生新的记忆
+	remember("memory-alice-2", "alice")
+	remember("memory-bob", "bob")
+
+	alice := listMemories(t, server, "agent_id="+agentID+"&user_id=alice")
+	if len(alice) != 1 {
+		t.Fatalf("alice memories = %+v", alice)
+	}
+	memory := alice[0].(map[string]interface{})
+	if memory["content"] != "用户喜欢的编程语言是 Go" || memory["tags"] != "偏好" || memory["workspace"] != "" {
+		t.Errorf("memory = %+v", memory)
+	}
+	if all := listMemories(t, server, "agent_id="+agentID+"&q=编程语言"); len(all) != 2 {
+		t.Errorf("agent memories = %+v", all)
+	}
+
+	id := memory["id"].(string)
+	AssertSuccess(t, server.Do(t, http.MethodGet, "/api/memories/"+id, nil))
+	AssertSuccess(t, server.Do(t, http.MethodDelete, "/api/memories/"+id, nil))
+	AssertError(t, server.Do(t, http.MethodGet, "/api/memories/"+id, nil))
+
+	AssertError(t, server.Do(t, http.MethodDelete, "/api/memories", nil))
+	deleted := server.Do(t, http.MethodDelete, "/api/memories?agent_id="+agentID, nil)
+	AssertSuccess(t, deleted)
+	if count := deleted["data"].(map[string]interface{})["deleted"]; count != float64(1) {
+		t.Errorf("deleted = %v, want 1", count)
+	}
+	if left := listMemories(t, server, "agent_id="+agentID); len(left) != 0 {
+		t.Errorf("memories after clear = %+v", left)
+	}
+}
@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"iano_agent/tools"
+	"net/http"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// processRules 启动一个后台服务并读取其输出
+const processRules = `[
+	{
+		"match": "启动",
+		"responses": [
+			{"tool_calls": [{"name": "process_start", "arguments": {"name": "dev", "command": "echo listening; sleep 60"}}]},
+			{"tool_calls": [{"name": "process_output", "arguments": {"name": "dev", "offset": 0, "wait": 5}}]},
+			{"content": "已启动"}
+		]
+	}
+]`
+
+func TestIntegrationBackgroundProcess(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("依赖 bash")
+	}
+
+	server := NewTestServer(t)
+	agentID := server.CreateScriptAgent(t, processRules, map[string]interface{}{
+		"tools":           `["process_start", "process_output"]`,
+		"approval_policy": "auto",
+	})
+	created := server.Do(t, http.MethodPost, "/api/sessions", map[string]interface{}{"title": "dev server"})
+	sessionID := created["data"].(map[string]interface{})["id"].(string)
+
+	server.StreamChat(t, map[string]interface{}{
+		"session_id": sessionID,
+		"agent_id":   agentID,
+		"message":    "启动开发服务",
+		"work_dir":   t.TempDir(),
+	}, nil)
+
+	list := server.Do(t, http.MethodGet, "/api/sessions/"+sessionID+"/processes", nil)
+	processes := list["data"].([]interface{})
+	if len(processes) != 1 {
+		t.Fatalf("processes = %+v", list)
+	}
+	if p := processes[0].(map[string]interface{}); p["name"] != "dev" || p["status"] != tools.ProcessStatusRunning {
+		t.Errorf("process = %+v", p)
+	}
+	out, err := server.Container.ProcessManager.Output(t.Context(), sessionID, "dev", 0, 0, 0)
+	if err != nil || !strings.Contains(out.Output, "listening") {
+		t.Errorf("output = %+v, %v", out, err)
+	}
+
+	if resp := server.Do(t, http.MethodPost, "/api/sessions/"+sessionID+"/processes/missing/stop", nil); resp["code"] == float64(200) {
+		t.Errorf("stop missing process = %+v", resp)
+	}
+
+	// 删除会话时停止其中的后台进程
+	server.Do(t, http.MethodDelete, "/api/sessions/"+sessionID, nil)
+	if list := server.Container.ProcessManager.List(sessionID); len(list) != 0 {
+		t.Errorf("processes after session delete = %+v", list)
+	}
+}
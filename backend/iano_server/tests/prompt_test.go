@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"iano_server/models"
+	"net/http"
+	"testing"
+)
+
+func TestIntegrationPromptLibrary(t *testing.T) {
+	server := NewTestServer(t)
+
+	created := server.Do(t, http.MethodPost, "/api/prompts", map[string]interface{}{
+		"name":        "library-style",
+		"description": "回答风格",
+		"content":     "回答使用{{.Locale}}。",
+	})
+	AssertSuccess(t, created)
+	first := created["data"].(map[string]interface{})
+	if first["version"] != float64(1) {
+		t.Fatalf("created prompt = %+v", first)
+	}
+	id := first["id"].(string)
+
+	t.Run("重名和语法错误", func(t *testing.T) {
+		AssertError(t, server.Do(t, http.MethodPost, "/api/prompts", map[string]interface{}{"name": "library-style", "content": "x"}))
+		AssertError(t, server.Do(t, http.MethodPost, "/api/prompts", map[string]interface{}{"name": "broken", "content": "{{.Locale"}))
+		AssertError(t, server.Do(t, http.MethodPut, "/api/prompts/"+id, map[string]interface{}{"content": "{{if}}"}))
+	})
+
+	t.Run("修改生成新版本", func(t *testing.T) {
+		updated := server.Do(t, http.MethodPut, "/api/prompts/"+id, map[string]interface{}{"content": "简洁地回答。"})
+		AssertSuccess(t, updated)
+		second := updated["data"].(map[string]interface{})
+		if second["version"] != float64(2) || second["id"] == id || second["description"] != "回答风格" {
+			t.Errorf("updated prompt = %+v", second)
+		}
+
+		versions := server.Do(t, http.MethodGet, "/api/prompts/"+id+"/versions", nil)["data"].([]interface{})
+		if len(versions) != 2 || versions[0].(map[string]interface{})["version"] != float64(2) {
+			t.Errorf("versions = %+v", versions)
+		}
+
+		latest := 0
+		for _, p := range server.Do(t, http.MethodGet, "/api/prompts", nil)["data"].([]interface{}) {
+			if prompt := p.(map[string]interface{}); prompt["name"] == "library-style" {
+				latest++
+				if prompt["content"] != "简洁地回答。" {
+					t.Errorf("latest prompt = %+v", prompt)
+				}
+			}
+		}
+		if latest != 1 {
+			t.Errorf("list contains %d versions of library-style, want 1", latest)
+		}
+	})
+
+	t.Run("Agent 指令校验模板语法", func(t *testing.T) {
+		resp := server.Do(t, http.MethodPost, "/api/agents", map[string]interface{}{
+			"name":         "broken-instructions",
+			"instructions": "你是 {{.AgentName",
+		})
+		AssertError(t, resp)
+	})
+
+	t.Run("引用不存在的提示词时运行失败", func(t *testing.T) {
+		agentID := server.CreateScriptAgent(t, `[{"responses": [{"content": "好的"}]}]`, map[string]interface{}{
+			"instructions": `{{include "library-style@1"}}{{include "library-missing"}}`,
+		})
+		events := server.StreamChat(t, map[string]interface{}{
+			"session_id": "prompt-missing",
+			"agent_id":   agentID,
+			"message":    "你好",
+		}, nil)
+		if !hasFailedEvent(events) {
+			t.Errorf("no failed %s event in %+v", models.MessageEventCompleted, events)
+		}
+	})
+
+	t.Run("删除全部版本", func(t *testing.T) {
+		AssertSuccess(t, server.Do(t, http.MethodDelete, "/api/prompts/"+id, nil))
+		versions := server.Do(t, http.MethodGet, "/api/prompts/"+id+"/versions", nil)
+		AssertError(t, versions)
+	})
+}
@@ -0,0 +1,54 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+)
+
+// replyRules 返回固定回复的脚本规则
+func replyRules(reply string) []interface{} {
+	return []interface{}{map[string]interface{}{"responses": []interface{}{map[string]interface{}{"content": reply}}}}
+}
+
+func TestProviderServiceOnChange(t *testing.T) {
+	server := NewTestServer(t)
+	agentID := server.CreateScriptAgent(t, ToJSON(replyRules("ok")), nil)
+	agent := server.Do(t, http.MethodGet, "/api/agents/"+agentID, nil)
+	providerID := agent["data"].(map[string]interface{})["provider_id"].(string)
+
+	var changed []string
+	server.Container.ProviderService.OnChange(func(id string) { changed = append(changed, id) })
+
+	server.Do(t, http.MethodPut, "/api/providers/"+providerID, map[string]interface{}{"temperature": 0.2})
+	server.Do(t, http.MethodDelete, "/api/providers/"+providerID, nil)
+	if len(changed) != 2 || changed[0] != providerID || changed[1] != providerID {
+		t.Errorf("changed = %v, want update and delete of %s", changed, providerID)
+	}
+}
+
+func TestIntegrationProviderUpdate(t *testing.T) {
+	server := NewTestServer(t)
+	agentID := server.CreateScriptAgent(t, ToJSON(replyRules("旧配置")), nil)
+	agent := server.Do(t, http.MethodGet, "/api/agents/"+agentID, nil)
+	providerID := agent["data"].(map[string]interface{})["provider_id"].(string)
+
+	chat := func(sessionID string) string {
+		t.Helper()
+		events := server.StreamChat(t, map[string]interface{}{"session_id": sessionID, "agent_id": agentID, "message": "你好"}, nil)
+		text, _ := assistantText(events)
+		return text
+	}
+
+	if got := chat("provider-before"); got != "旧配置" {
+		t.Fatalf("reply before update = %q", got)
+	}
+
+	// 缓存的模型在 Provider 更新后失效，之后创建的 Agent 使用新配置
+	updated := server.Do(t, http.MethodPut, "/api/providers/"+providerID, map[string]interface{}{
+		"extra": ToJSON(map[string]interface{}{"rules": replyRules("新配置")}),
+	})
+	AssertSuccess(t, updated)
+	if got := chat("provider-after"); got != "新配置" {
+		t.Errorf("reply after update = %q, want 新配置", got)
+	}
+}
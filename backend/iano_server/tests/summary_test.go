@@ -0,0 +1,202 @@
+package tests
+
+import (
+	"context"
+	iano "iano_agent"
+	"iano_server/models"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// summaryChatModel 总是返回固定摘要的测试模型，并记录每次收到的消息
+type summaryChatModel struct {
+	mu     sync.Mutex
+	inputs [][]*schema.Message
+}
+
+func (m *summaryChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.inputs = append(m.inputs, input)
+	msg := schema.AssistantMessage("较早的对话摘要", nil)
+	msg.ResponseMeta = &schema.ResponseMeta{Usage: &schema.TokenUsage{PromptTokens: 120, CompletionTokens: 8, TotalTokens: 128}}
+	return msg, nil
+}
+
+func (m *summaryChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	msg, _ := m.Generate(ctx, input, opts...)
+	return schema.StreamReaderFromArray([]*schema.Message{msg}), nil
+}
+
+func (m *summaryChatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	return m, nil
+}
+
+// createSummarySession 创建只保留 keepRounds 轮原文的会话，返回会话 ID
+func createSummarySession(t *testing.T, server *TestServer, keepRounds int) string {
+	t.Helper()
+	created := server.Do(t, http.MethodPost, "/api/sessions", map[string]interface{}{"title": "summary"})
+	sessionID := created["data"].(map[string]interface{})["id"].(string)
+	updated := server.Do(t, http.MethodPut, "/api/sessions/"+sessionID+"/config", map[string]interface{}{
+		"config": map[string]interface{}{"enable_summary": true, "keep_rounds": keepRounds},
+	})
+	AssertSuccess(t, updated)
+	return sessionID
+}
+
+func TestSummaryServiceBuildHistory(t *testing.T) {
+	server := NewTestServer(t)
+	sessionID := createSummarySession(t, server, 1)
+	m := &summaryChatModel{}
+	agent, err := iano.NewAgent(m, iano.WithAllowedTools([]string{"file_read"}))
+	if err != nil {
+		t.Fatalf("NewAgent() error = %v", err)
+	}
+
+	createdAt := time.Now()
+	var lastIDs []string
+	addRound := func(n int) {
+		lastIDs = lastIDs[:0]
+		for _, msgType := range []models.MessageType{models.MessageTypeUser, models.MessageTypeAssistant} {
+			msg := &models.Message{SessionID: sessionID, Type: msgType, Status: models.MessageStatusCompleted}
+			msg.NewID()
+			msg.SetText(strings.Repeat("第"+string(rune('0'+n))+"轮"+msgType.ToString(), 20))
+			createdAt = createdAt.Add(time.Millisecond)
+			msg.CreatedAt = createdAt
+			if err := server.Container.MessageService.Create(msg); err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			lastIDs = append(lastIDs, msg.ID)
+		}
+	}
+	build := func() ([]*schema.Message, *models.Message) {
+		t.Helper()
+		messages, summaryMsg, err := server.Container.SummaryService.BuildHistory(context.Background(), sessionID, agent, false)
+		if err != nil {
+			t.Fatalf("BuildHistory() error = %v", err)
+		}
+		return messages, summaryMsg
+	}
+
+	// 未超过保留轮数的两倍时不压缩
+	addRound(1)
+	addRound(2)
+	if messages, summaryMsg := build(); summaryMsg != nil || len(messages) != 4 || len(m.inputs) != 0 {
+		t.Fatalf("BuildHistory() = %d messages, summary %v, model calls %d", len(messages), summaryMsg, len(m.inputs))
+	}
+
+	addRound(3)
+	messages, summaryMsg := build()
+	if summaryMsg == nil {
+		t.Fatal("BuildHistory() did not summarize")
+	}
+	content, _ := summaryMsg.GetContent()
+	if content.Summary.Rounds != 2 || content.Summary.SavedTokens <= 0 {
+		t.Errorf("summary info = %+v", content.Summary)
+	}
+	// 摘要调用的完整用量记录到摘要消息并计入会话
+	if summaryMsg.InputTokens != 120 || summaryMsg.OutputTokens != 8 {
+		t.Errorf("summary usage = %d input, %d output", summaryMsg.InputTokens, summaryMsg.OutputTokens)
+	}
+	if session, err := server.Container.SessionService.GetByID(sessionID); err != nil || session.TotalTokens != 128 {
+		t.Errorf("session total tokens = %+v, %v", session, err)
+	}
+	if usage := agent.GetTokenUsage(); usage.PromptTokens != 120 || usage.TotalTokens != 128 {
+		t.Errorf("agent token usage = %+v", usage)
+	}
+	if len(messages) != 3 || !iano.IsSummaryMessage(messages[0]) || !strings.Contains(messages[1].Content, "第3轮") {
+		t.Errorf("compacted history = %+v", messages)
+	}
+
+	// 摘要覆盖的消息以摘要代替，新的一轮未超出窗口时不再调用模型
+	addRound(4)
+	round4 := append([]string(nil), lastIDs...)
+	messages, summaryMsg = build()
+	if summaryMsg != nil || len(m.inputs) != 1 || len(messages) != 5 || messages[0].Content != iano.SummaryPrefix+"较早的对话摘要" {
+		t.Fatalf("history after summary = %+v, new summary %v, model calls %d", messages, summaryMsg, len(m.inputs))
+	}
+
+	// 摘要之后的轮数再次超出时，已有摘要与较早的轮次合并为新摘要
+	addRound(5)
+	if _, summaryMsg = build(); summaryMsg == nil || len(m.inputs) != 2 {
+		t.Fatalf("second summary = %v, model calls %d", summaryMsg, len(m.inputs))
+	}
+	content, _ = summaryMsg.GetContent()
+	transcript := m.inputs[1][1].Content
+	if content.Summary.UntilMessageID != round4[1] || !strings.Contains(transcript, "此前的摘要: 较早的对话摘要") || strings.Contains(transcript, "第2轮") {
+		t.Errorf("second summary = %+v, prompt = %q", content.Summary, transcript)
+	}
+}
+
+func TestSummaryServiceBuildHistoryMissingUntilMessage(t *testing.T) {
+	server := NewTestServer(t)
+	sessionID := createSummarySession(t, server, 1)
+
+	createdAt := time.Now()
+	addMessage := func(msgType models.MessageType, content *models.MessageContent) *models.Message {
+		msg := &models.Message{SessionID: sessionID, Type: msgType, Status: models.MessageStatusCompleted}
+		msg.NewID()
+		msg.SetContent(content)
+		createdAt = createdAt.Add(time.Millisecond)
+		msg.CreatedAt = createdAt
+		if err := server.Container.MessageService.Create(msg); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		return msg
+	}
+	addMessage(models.MessageTypeUser, &models.MessageContent{Text: "第1轮问题"})
+	addMessage(models.MessageTypeAssistant, &models.MessageContent{Text: "第1轮回答"})
+	// 摘要覆盖到的消息已被删除
+	addMessage(models.MessageTypeSystem, &models.MessageContent{
+		Text:    "较早的对话摘要",
+		Summary: &models.SummaryInfo{UntilMessageID: "deleted-message", Rounds: 1},
+	})
+	addMessage(models.MessageTypeUser, &models.MessageContent{Text: "第2轮问题"})
+
+	messages, _, err := server.Container.SummaryService.BuildHistory(context.Background(), sessionID, nil, false)
+	if err != nil {
+		t.Fatalf("BuildHistory() error = %v", err)
+	}
+	if len(messages) != 2 || messages[0].Content != iano.SummaryPrefix+"较早的对话摘要" || messages[1].Content != "第2轮问题" {
+		t.Errorf("history = %+v", messages)
+	}
+}
+
+// summaryRules 生成摘要的请求返回固定摘要，其他消息直接回复
+const summaryRules = `[
+	{"match": "^请总结以下历史对话", "responses": [{"content": "用户问过几个编号问题"}]},
+	{"responses": [{"content": "收到"}]}
+]`
+
+func TestIntegrationChatSummary(t *testing.T) {
+	server := NewTestServer(t)
+	agentID := server.CreateScriptAgent(t, summaryRules, nil)
+	sessionID := createSummarySession(t, server, 1)
+
+	// 第一轮创建并绑定 Agent，之后的每一轮都重新构建带摘要的历史
+	var summaries []int
+	for i := 1; i <= 5; i++ {
+		events := server.StreamChat(t, map[string]interface{}{
+			"session_id": sessionID,
+			"agent_id":   agentID,
+			"message":    "问题 " + string(rune('0'+i)),
+		}, nil)
+		if got := completedEvent(t, events)["status"]; got != models.MessageStatusCompleted.ToString() {
+			t.Fatalf("round %d status = %v", i, got)
+		}
+		if len(FindEvents(events, models.MessageEventSummary)) > 0 {
+			summaries = append(summaries, i)
+		}
+	}
+
+	// 保留 1 轮时，未摘要的轮数超过 2 轮才压缩
+	if len(summaries) != 2 || summaries[0] != 3 || summaries[1] != 5 {
+		t.Errorf("summaries created in rounds %v, want [3 5]", summaries)
+	}
+}
@@ -0,0 +1,196 @@
+package tests
+
+import (
+	"encoding/json"
+	"iano_agent/tools"
+	"iano_server/controllers"
+	"iano_server/pkg/config"
+	"net/http"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// terminalRules 打开一个等待输入的终端
+const terminalRules = `[
+	{
+		"match": "初始化",
+		"responses": [
+			{"tool_calls": [{"name": "terminal_open", "arguments": {"name": "init", "command": "read -p 'package name: ' n; echo \"created $n\""}}]},
+			{"content": "请在终端中输入包名"}
+		]
+	}
+]`
+
+// readTerminal 读取终端 WebSocket 的输出直到出现 want，返回读到的输出
+func readTerminal(t *testing.T, conn *websocket.Conn, want string) string {
+	t.Helper()
+	var output strings.Builder
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for !strings.Contains(output.String(), want) {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("output does not contain %q: %q, %v", want, output.String(), err)
+		}
+		if messageType == websocket.BinaryMessage {
+			output.Write(data)
+		}
+	}
+	return output.String()
+}
+
+func TestIntegrationTerminal(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("伪终端仅支持 Linux")
+	}
+
+	server := NewTestServer(t)
+	agentID := server.CreateScriptAgent(t, terminalRules, map[string]interface{}{
+		"tools":           `["terminal_open", "terminal_write", "terminal_read"]`,
+		"approval_policy": "auto",
+	})
+	created := server.Do(t, http.MethodPost, "/api/sessions", map[string]interface{}{"title": "npm init"})
+	sessionID := created["data"].(map[string]interface{})["id"].(string)
+
+	server.StreamChat(t, map[string]interface{}{
+		"session_id": sessionID,
+		"agent_id":   agentID,
+		"message":    "初始化项目",
+		"work_dir":   t.TempDir(),
+	}, nil)
+
+	list := server.Do(t, http.MethodGet, "/api/sessions/"+sessionID+"/terminals", nil)
+	terminals := list["data"].([]interface{})
+	if len(terminals) != 1 || terminals[0].(map[string]interface{})["name"] != "init" {
+		t.Fatalf("terminals = %+v", list)
+	}
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/sessions/" + sessionID + "/terminals/"
+	if _, resp, err := websocket.DefaultDialer.Dial(wsURL+"missing/ws", nil); err == nil || resp.StatusCode != http.StatusNotFound {
+		t.Errorf("attach missing terminal = %v", err)
+	}
+
+	// 默认只读连接收到同一个终端的输出，发送的内容被忽略
+	watcher, _, err := websocket.DefaultDialer.Dial(wsURL+"init/ws", nil)
+	if err != nil {
+		t.Fatalf("attach readonly error = %v", err)
+	}
+	defer watcher.Close()
+	readTerminal(t, watcher, "package name: ")
+	watcher.WriteMessage(websocket.BinaryMessage, []byte("ignored\r"))
+
+	// 用户接管终端，先看到 Agent 打开终端后的输出
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL+"init/ws?write=true", nil)
+	if err != nil {
+		t.Fatalf("attach error = %v", err)
+	}
+	defer conn.Close()
+	readTerminal(t, conn, "package name: ")
+	if err := conn.WriteJSON(controllers.TerminalClientMessage{Type: "resize", Rows: 30, Cols: 100}); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.WriteJSON(controllers.TerminalClientMessage{Type: "input", Data: "demo\r"}); err != nil {
+		t.Fatal(err)
+	}
+	readTerminal(t, conn, "created demo")
+	readTerminal(t, watcher, "created demo")
+
+	// 终端退出后收到 exit 消息，连接关闭
+	var exit controllers.TerminalExitMessage
+	for {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("no exit message: %v", err)
+		}
+		if messageType == websocket.TextMessage {
+			json.Unmarshal(data, &exit)
+			break
+		}
+	}
+	if exit.Type != "exit" || exit.Status != tools.ProcessStatusExited || exit.ExitCode == nil || *exit.ExitCode != 0 {
+		t.Errorf("exit message = %+v", exit)
+	}
+	info, err := server.Container.TerminalManager.Status(sessionID, "init")
+	if err != nil || info.Rows != 30 || info.Cols != 100 {
+		t.Errorf("terminal after resize = %+v, %v", info, err)
+	}
+
+	// 删除会话时关闭其中的终端
+	server.Do(t, http.MethodDelete, "/api/sessions/"+sessionID, nil)
+	if list := server.Container.TerminalManager.List(sessionID); len(list) != 0 {
+		t.Errorf("terminals after session delete = %+v", list)
+	}
+}
+
+func TestTerminalAttachAccess(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("伪终端仅支持 Linux")
+	}
+
+	server := NewTestServerWithConfig(t, func(cfg *config.Config) {
+		cfg.Server.APIKey = "secret"
+		cfg.Server.AllowedOrigins = []string{"http://app.example"}
+	})
+	if _, err := server.Container.TerminalManager.Open("access", tools.TerminalOpenOptions{Name: "sh", Command: "cat", Dir: t.TempDir()}); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/sessions/access/terminals/sh/ws"
+
+	dial := func(query string, origin string) (*websocket.Conn, int) {
+		t.Helper()
+		header := http.Header{}
+		if origin != "" {
+			header.Set("Origin", origin)
+		}
+		conn, resp, err := websocket.DefaultDialer.Dial(wsURL+query, header)
+		if err != nil {
+			if resp == nil {
+				t.Fatalf("dial %s: %v", query, err)
+			}
+			return nil, resp.StatusCode
+		}
+		t.Cleanup(func() { conn.Close() })
+		return conn, http.StatusSwitchingProtocols
+	}
+
+	// 与 REST 接口使用同一个 API Key
+	resp, err := server.Client().Get(server.URL + "/api/sessions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("REST without api key: status %d", resp.StatusCode)
+	}
+	if _, status := dial("", ""); status != http.StatusUnauthorized {
+		t.Errorf("attach without api key: status %d", status)
+	}
+
+	// 跨站页面不能连接终端
+	if _, status := dial("?api_key=secret&write=true", "http://evil.example"); status != http.StatusForbidden {
+		t.Errorf("attach from other origin: status %d", status)
+	}
+	if _, status := dial("?api_key=secret", "http://app.example"); status != http.StatusSwitchingProtocols {
+		t.Errorf("attach from allowed origin: status %d", status)
+	}
+
+	watcher, status := dial("?api_key=secret", server.URL)
+	if status != http.StatusSwitchingProtocols {
+		t.Fatalf("attach from same origin: status %d", status)
+	}
+	watcher.WriteMessage(websocket.BinaryMessage, []byte("ignored\r"))
+	conn, _ := dial("?api_key=secret&write=true", server.URL)
+	if conn == nil {
+		t.Fatal("attach with write access failed")
+	}
+	conn.WriteMessage(websocket.BinaryMessage, []byte("typed\r"))
+
+	// cat 回显输入，只读连接发送的内容没有写入终端
+	output := readTerminal(t, watcher, "typed")
+	if strings.Contains(output, "ignored") {
+		t.Errorf("readonly input was written: %q", output)
+	}
+}
@@ -1,11 +1,17 @@
 package tests
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
+	"iano_server/container"
 	"iano_server/models"
+	"iano_server/pkg/config"
+	"iano_server/routes"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/glebarez/sqlite"
@@ -35,6 +41,15 @@ func NewTestDB() (*TestDB, error) {
 		&models.Message{},
 		&models.Agent{},
 		&models.Tool{},
+		&models.MCPServer{},
+		&models.MCPServerTool{},
+		&models.File{},
+		&models.Prompt{},
+		&models.Memory{},
+		&models.KnowledgeBase{},
+		&models.KnowledgeDocument{},
+		&models.KnowledgeChunk{},
+		&models.Checkpoint{},
 	)
 	if err != nil {
 		return nil, err
@@ -100,3 +115,160 @@ func ToJSON(v interface{}) string {
 	b, _ := json.Marshal(v)
 	return string(b)
 }
+
+// TestServer 使用内存数据库和完整路由的测试服务
+type TestServer struct {
+	*httptest.Server
+	DB        *TestDB
+	Container *container.Container
+}
+
+// NewTestServer 创建测试服务，测试结束时自动关闭
+func NewTestServer(t *testing.T) *TestServer {
+	t.Helper()
+	return NewTestServerWithConfig(t, nil)
+}
+
+// NewTestServerWithConfig 创建测试服务，configure 可以在启动前修改配置
+func NewTestServerWithConfig(t *testing.T, configure func(cfg *config.Config)) *TestServer {
+	t.Helper()
+
+	testDB, err := NewTestDB()
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+
+	cfg := &config.Config{
+		Server:     config.ServerConfig{Port: "0", Mode: "release", ReadTimeout: 30, WriteTimeout: 30},
+		Upload:     config.UploadConfig{Path: t.TempDir(), MaxSize: 1},
+		Checkpoint: config.CheckpointConfig{Path: t.TempDir(), MaxSize: 1},
+		Process:    config.ProcessConfig{MaxPerSession: 2},
+		Terminal:   config.TerminalConfig{MaxPerSession: 2},
+	}
+	if configure != nil {
+		configure(cfg)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cnr := container.NewContainer(ctx, testDB.DB, cfg)
+	server := httptest.NewServer(routes.SetupRoutes(cnr))
+
+	t.Cleanup(func() {
+		server.Close()
+		cancel()
+		testDB.Close()
+	})
+	return &TestServer{Server: server, DB: testDB, Container: cnr}
+}
+
+// Do 发送 JSON 请求并解析响应
+func (s *TestServer) Do(t *testing.T, method, path string, body interface{}) map[string]interface{} {
+	t.Helper()
+
+	req, err := http.NewRequest(method, s.URL+path, strings.NewReader(ToJSON(body)))
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.Client().Do(req)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var response map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	return response
+}
+
+// CreateScriptAgent 创建使用脚本模型的提供商和 Agent，返回 Agent ID
+//
+// rules 为脚本模型的规则列表，格式见 iano_agent/model.Script；
+// agent 中的字段会覆盖默认的 Agent 配置。
+func (s *TestServer) CreateScriptAgent(t *testing.T, rules string, agent map[string]interface{}) string {
+	t.Helper()
+
+	var parsed interface{}
+	if err := json.Unmarshal([]byte(rules), &parsed); err != nil {
+		t.Fatalf("Invalid script rules: %v", err)
+	}
+	provider := s.Do(t, http.MethodPost, "/api/providers", map[string]interface{}{
+		"name":     "script-" + t.Name(),
+		"type":     "script",
+		"base_url": "script://local",
+		"model":    "script",
+		"extra":    ToJSON(map[string]interface{}{"rules": parsed}),
+	})
+	AssertSuccess(t, provider)
+	providerID := provider["data"].(map[string]interface{})["id"].(string)
+
+	req := map[string]interface{}{
+		"name":         "agent-" + t.Name(),
+		"type":         "main",
+		"provider_id":  providerID,
+		"model":        "script",
+		"instructions": "你是一个测试助手。",
+	}
+	for k, v := range agent {
+		req[k] = v
+	}
+	created := s.Do(t, http.MethodPost, "/api/agents", req)
+	AssertSuccess(t, created)
+	return created["data"].(map[string]interface{})["id"].(string)
+}
+
+// SSEEvent 流式聊天接口推送的事件
+type SSEEvent struct {
+	Event string
+	Data  map[string]interface{}
+}
+
+// StreamChat 调用流式聊天接口并读取全部事件，onEvent 不为空时在收到每个事件时调用
+func (s *TestServer) StreamChat(t *testing.T, body interface{}, onEvent func(event SSEEvent)) []SSEEvent {
+	t.Helper()
+
+	resp, err := s.Client().Post(s.URL+"/api/chat/stream", "application/json", strings.NewReader(ToJSON(body)))
+	if err != nil {
+		t.Fatalf("Failed to send chat request: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var events []SSEEvent
+	var current SSEEvent
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			current.Event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(line, "data:"))), &current.Data)
+		case line == "" && current.Event != "":
+			events = append(events, current)
+			if onEvent != nil {
+				onEvent(current)
+			}
+			if current.Event == models.MessageEventDone.ToString() {
+				return events
+			}
+			current = SSEEvent{}
+		}
+	}
+	return events
+}
+
+// FindEvents 返回指定类型的事件
+func FindEvents(events []SSEEvent, eventType models.MessageEvent) []SSEEvent {
+	var found []SSEEvent
+	for _, event := range events {
+		if event.Event == eventType.ToString() {
+			found = append(found, event)
+		}
+	}
+	return found
+}
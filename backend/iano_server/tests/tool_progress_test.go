@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"iano_server/models"
+	"io"
+	"net/http"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// longOutputRules 执行一条输出很多行的命令
+const longOutputRules = `[
+	{
+		"match": "测试",
+		"responses": [
+			{"tool_calls": [{"name": "shell_execute", "arguments": {"command": "seq 1 20000"}}]},
+			{"content": "测试完成"}
+		]
+	}
+]`
+
+func TestIntegrationToolProgress(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("依赖 bash")
+	}
+
+	server := NewTestServer(t)
+	agentID := server.CreateScriptAgent(t, longOutputRules, map[string]interface{}{
+		"tools":           "shell_execute",
+		"approval_policy": "auto",
+	})
+
+	events := server.StreamChat(t, map[string]interface{}{
+		"session_id": "tool-progress",
+		"agent_id":   agentID,
+		"message":    "跑一下测试",
+		"work_dir":   t.TempDir(),
+	}, nil)
+
+	progress := FindEvents(events, models.MessageEventToolProgress)
+	if len(progress) == 0 {
+		t.Fatalf("no %s event in %d events", models.MessageEventToolProgress, len(events))
+	}
+	first := progress[0].Data["tool_progress"].(map[string]interface{})
+	if first["tool_name"] != "shell_execute" || first["stream"] != "stdout" || first["line"] != "1" || first["call_id"] == "" {
+		t.Errorf("first progress = %+v", first)
+	}
+	// 进度不计入回复内容
+	if text, _ := assistantText(events); text != "测试完成" {
+		t.Errorf("assistant text = %q", text)
+	}
+
+	// 输出被截断，完整日志保存为附件
+	var file models.File
+	if err := server.DB.DB.Where("name LIKE ?", "shell_execute-%.log").First(&file).Error; err != nil {
+		t.Fatalf("log artifact not saved: %v", err)
+	}
+	resp, err := server.Client().Get(server.URL + "/api/attachments/" + file.ID + "/content")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(string(data), "1\n2\n") || !strings.HasSuffix(string(data), "\n20000\n") {
+		t.Errorf("log content: status %d, %d bytes", resp.StatusCode, len(data))
+	}
+}
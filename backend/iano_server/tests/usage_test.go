@@ -0,0 +1,54 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+)
+
+// systemInfoRules 先调用 system_info 再回复，一次对话包含两次模型调用
+const systemInfoRules = `[
+	{
+		"responses": [
+			{"tool_calls": [{"name": "system_info", "arguments": {"info_type": "os"}}]},
+			{"content": "系统信息已获取"}
+		]
+	}
+]`
+
+func TestIntegrationTokenUsage(t *testing.T) {
+	server := NewTestServer(t)
+	agentID := server.CreateScriptAgent(t, systemInfoRules, map[string]interface{}{
+		"tools":           `["system_info"]`,
+		"approval_policy": "auto",
+	})
+	created := server.Do(t, http.MethodPost, "/api/sessions", map[string]interface{}{"title": "usage"})
+	sessionID := created["data"].(map[string]interface{})["id"].(string)
+
+	sessionTotal := 0
+	for _, message := range []string{"查看系统信息", "再看一次"} {
+		events := server.StreamChat(t, map[string]interface{}{
+			"session_id": sessionID,
+			"agent_id":   agentID,
+			"message":    message,
+		}, nil)
+		completed := completedEvent(t, events)
+		usage := completed["usage"].(map[string]interface{})
+		input, output, total := int(usage["input_tokens"].(float64)), int(usage["output_tokens"].(float64)), int(usage["total_tokens"].(float64))
+
+		// 脚本模型每次调用都返回用量，工具调用前后两次调用的用量计入同一条助手消息
+		if text, _ := assistantText(events); text != "系统信息已获取" || input == 0 || output == 0 || total != input+output {
+			t.Fatalf("text = %q, usage = %+v", text, usage)
+		}
+		msg, err := server.Container.MessageService.GetByID(completed["id"].(string))
+		if err != nil || msg.InputTokens != input || msg.OutputTokens != output {
+			t.Errorf("assistant message tokens = %d/%d, want %d/%d, %v", msg.InputTokens, msg.OutputTokens, input, output, err)
+		}
+		sessionTotal += total
+	}
+
+	// 会话累计用量等于各次运行之和
+	session, err := server.Container.SessionService.GetByID(sessionID)
+	if err != nil || session.TotalTokens != sessionTotal {
+		t.Errorf("session total tokens = %d, want %d, %v", session.TotalTokens, sessionTotal, err)
+	}
+}
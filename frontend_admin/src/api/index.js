@@ -17,7 +17,10 @@ async function request(url, options = {}) {
     ...options,
   }
 
-  if (options.body && typeof options.body === 'object') {
+  if (options.body instanceof FormData) {
+    // 由浏览器设置带 boundary 的 multipart Content-Type
+    delete config.headers['Content-Type']
+  } else if (options.body && typeof options.body === 'object') {
     config.body = JSON.stringify(options.body)
   }
 
@@ -45,6 +48,7 @@ export const providerApi = {
   create: (data) => api.post('/providers', data),
   update: (id, data) => api.put(`/providers/${id}`, data),
   delete: (id) => api.delete(`/providers/${id}`),
+  discoverModels: (data) => api.post('/providers/models', data),
 }
 
 export const agentApi = {
@@ -100,11 +104,54 @@ export const messageApi = {
 export const chatApi = {
   chat: (data) => api.post('/chat', data),
   streamChat: (data) => api.post('/chat/stream', data),
+  stop: (sessionId) => api.post('/chat/stop', { session_id: sessionId }),
+  approve: (data) => api.post('/chat/approve', data),
+  getPendingApprovals: (sessionId) => api.get(`/chat/approvals/${sessionId}`),
   clearSession: (sessionId) => api.delete(`/chat/session/${sessionId}`),
   getConversation: (sessionId) => api.get(`/chat/conversation?session_id=${sessionId}`),
   getPoolStats: () => api.get('/chat/pool-stats'),
 }
 
+export const attachmentApi = {
+  upload: (files) => {
+    const form = new FormData()
+    for (const file of files) {
+      form.append('file', file)
+    }
+    return api.post('/attachments', form)
+  },
+  getById: (id) => api.get(`/attachments/${id}`),
+  contentUrl: (id) => `${API_BASE}/attachments/${id}/content`,
+}
+
+export const promptApi = {
+  getAll: () => api.get('/prompts'),
+  getById: (id) => api.get(`/prompts/${id}`),
+  getVersions: (id) => api.get(`/prompts/${id}/versions`),
+  create: (data) => api.post('/prompts', data),
+  update: (id, data) => api.put(`/prompts/${id}`, data),
+  delete: (id) => api.delete(`/prompts/${id}`),
+}
+
+export const memoryApi = {
+  getAll: (params = {}) => api.get(`/memories?${new URLSearchParams(params)}`),
+  getById: (id) => api.get(`/memories/${id}`),
+  delete: (id) => api.delete(`/memories/${id}`),
+  deleteByAgent: (agentId) => api.delete(`/memories?agent_id=${agentId}`),
+}
+
+export const knowledgeApi = {
+  getAll: () => api.get('/knowledge-bases'),
+  getById: (id) => api.get(`/knowledge-bases/${id}`),
+  create: (data) => api.post('/knowledge-bases', data),
+  update: (id, data) => api.put(`/knowledge-bases/${id}`, data),
+  delete: (id) => api.delete(`/knowledge-bases/${id}`),
+  getDocuments: (id) => api.get(`/knowledge-bases/${id}/documents`),
+  ingest: (id, data) => api.post(`/knowledge-bases/${id}/documents`, data),
+  deleteDocument: (id, docId) => api.delete(`/knowledge-bases/${id}/documents/${docId}`),
+  search: (id, q, limit = 5) => api.get(`/knowledge-bases/${id}/search?${new URLSearchParams({ q, limit })}`),
+}
+
 export const mcpApi = {
   getAllServers: () => api.get('/mcp/servers'),
   getServerById: (id) => api.get(`/mcp/servers/${id}`),
@@ -29,6 +29,21 @@ const routes = [
         name: "Tools",
         component: () => import("@/views/tools/index.vue"),
       },
+      {
+        path: "prompts",
+        name: "Prompts",
+        component: () => import("@/views/prompts/index.vue"),
+      },
+      {
+        path: "memories",
+        name: "Memories",
+        component: () => import("@/views/memories/index.vue"),
+      },
+      {
+        path: "knowledge",
+        name: "Knowledge",
+        component: () => import("@/views/knowledge/index.vue"),
+      },
       {
         path: "mcp",
         name: "MCP",
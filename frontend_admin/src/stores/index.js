@@ -2,3 +2,6 @@ export { useProviderStore } from './provider'
 export { useAgentStore } from './agent'
 export { useToolStore } from './tool'
 export { useMCPStore } from './mcp'
+export { usePromptStore } from './prompt'
+export { useMemoryStore } from './memory'
+export { useKnowledgeStore } from './knowledge'
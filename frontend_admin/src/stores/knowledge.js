@@ -0,0 +1,147 @@
+import { defineStore } from 'pinia'
+import { ref, computed } from 'vue'
+import { knowledgeApi } from '@/api'
+
+export const useKnowledgeStore = defineStore('knowledge', () => {
+  const knowledgeBases = ref([])
+  const documents = ref([])
+  const loading = ref(false)
+  const error = ref(null)
+
+  const totalCount = computed(() => knowledgeBases.value.length)
+
+  const documentCount = computed(() =>
+    knowledgeBases.value.reduce((sum, kb) => sum + (kb.document_count || 0), 0)
+  )
+
+  const fetchAll = async () => {
+    loading.value = true
+    error.value = null
+    try {
+      const result = await knowledgeApi.getAll()
+      knowledgeBases.value = result.data || []
+      return result.data
+    } catch (e) {
+      error.value = e.message
+      throw e
+    } finally {
+      loading.value = false
+    }
+  }
+
+  const create = async (data) => {
+    loading.value = true
+    error.value = null
+    try {
+      const result = await knowledgeApi.create(data)
+      await fetchAll()
+      return result.data
+    } catch (e) {
+      error.value = e.message
+      throw e
+    } finally {
+      loading.value = false
+    }
+  }
+
+  const update = async (id, data) => {
+    loading.value = true
+    error.value = null
+    try {
+      const result = await knowledgeApi.update(id, data)
+      await fetchAll()
+      return result.data
+    } catch (e) {
+      error.value = e.message
+      throw e
+    } finally {
+      loading.value = false
+    }
+  }
+
+  const remove = async (id) => {
+    loading.value = true
+    error.value = null
+    try {
+      await knowledgeApi.delete(id)
+      await fetchAll()
+    } catch (e) {
+      error.value = e.message
+      throw e
+    } finally {
+      loading.value = false
+    }
+  }
+
+  const fetchDocuments = async (id) => {
+    loading.value = true
+    error.value = null
+    try {
+      const result = await knowledgeApi.getDocuments(id)
+      documents.value = result.data || []
+      return result.data
+    } catch (e) {
+      error.value = e.message
+      throw e
+    } finally {
+      loading.value = false
+    }
+  }
+
+  const ingest = async (id, data) => {
+    loading.value = true
+    error.value = null
+    try {
+      const result = await knowledgeApi.ingest(id, data)
+      await Promise.all([fetchDocuments(id), fetchAll()])
+      return result.data
+    } catch (e) {
+      error.value = e.message
+      throw e
+    } finally {
+      loading.value = false
+    }
+  }
+
+  const removeDocument = async (id, docId) => {
+    loading.value = true
+    error.value = null
+    try {
+      await knowledgeApi.deleteDocument(id, docId)
+      documents.value = documents.value.filter(d => d.id !== docId)
+      await fetchAll()
+    } catch (e) {
+      error.value = e.message
+      throw e
+    } finally {
+      loading.value = false
+    }
+  }
+
+  const search = async (id, q, limit) => {
+    try {
+      const result = await knowledgeApi.search(id, q, limit)
+      return result.data || []
+    } catch (e) {
+      error.value = e.message
+      throw e
+    }
+  }
+
+  return {
+    knowledgeBases,
+    documents,
+    loading,
+    error,
+    totalCount,
+    documentCount,
+    fetchAll,
+    create,
+    update,
+    remove,
+    fetchDocuments,
+    ingest,
+    removeDocument,
+    search,
+  }
+})
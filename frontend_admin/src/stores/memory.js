@@ -0,0 +1,69 @@
+import { defineStore } from 'pinia'
+import { ref, computed } from 'vue'
+import { memoryApi } from '@/api'
+
+export const useMemoryStore = defineStore('memory', () => {
+  const memories = ref([])
+  const loading = ref(false)
+  const error = ref(null)
+
+  const totalCount = computed(() => memories.value.length)
+
+  const agentCount = computed(() =>
+    new Set(memories.value.map(m => m.agent_id)).size
+  )
+
+  const fetchAll = async (params = {}) => {
+    loading.value = true
+    error.value = null
+    try {
+      const result = await memoryApi.getAll(params)
+      memories.value = result.data || []
+      return result.data
+    } catch (e) {
+      error.value = e.message
+      throw e
+    } finally {
+      loading.value = false
+    }
+  }
+
+  const remove = async (id) => {
+    loading.value = true
+    error.value = null
+    try {
+      await memoryApi.delete(id)
+      memories.value = memories.value.filter(m => m.id !== id)
+    } catch (e) {
+      error.value = e.message
+      throw e
+    } finally {
+      loading.value = false
+    }
+  }
+
+  const clearAgent = async (agentId) => {
+    loading.value = true
+    error.value = null
+    try {
+      await memoryApi.deleteByAgent(agentId)
+      memories.value = memories.value.filter(m => m.agent_id !== agentId)
+    } catch (e) {
+      error.value = e.message
+      throw e
+    } finally {
+      loading.value = false
+    }
+  }
+
+  return {
+    memories,
+    loading,
+    error,
+    totalCount,
+    agentCount,
+    fetchAll,
+    remove,
+    clearAgent,
+  }
+})
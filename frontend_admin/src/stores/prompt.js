@@ -0,0 +1,97 @@
+import { defineStore } from 'pinia'
+import { ref, computed } from 'vue'
+import { promptApi } from '@/api'
+
+export const usePromptStore = defineStore('prompt', () => {
+  const prompts = ref([])
+  const loading = ref(false)
+  const error = ref(null)
+
+  const totalCount = computed(() => prompts.value.length)
+
+  const versionCount = computed(() =>
+    prompts.value.reduce((sum, p) => sum + (p.version || 0), 0)
+  )
+
+  const fetchAll = async () => {
+    loading.value = true
+    error.value = null
+    try {
+      const result = await promptApi.getAll()
+      prompts.value = result.data || []
+      return result.data
+    } catch (e) {
+      error.value = e.message
+      throw e
+    } finally {
+      loading.value = false
+    }
+  }
+
+  const fetchVersions = async (id) => {
+    try {
+      const result = await promptApi.getVersions(id)
+      return result.data || []
+    } catch (e) {
+      error.value = e.message
+      throw e
+    }
+  }
+
+  const create = async (data) => {
+    loading.value = true
+    error.value = null
+    try {
+      const result = await promptApi.create(data)
+      await fetchAll()
+      return result.data
+    } catch (e) {
+      error.value = e.message
+      throw e
+    } finally {
+      loading.value = false
+    }
+  }
+
+  const update = async (id, data) => {
+    loading.value = true
+    error.value = null
+    try {
+      const result = await promptApi.update(id, data)
+      await fetchAll()
+      return result.data
+    } catch (e) {
+      error.value = e.message
+      throw e
+    } finally {
+      loading.value = false
+    }
+  }
+
+  const remove = async (id) => {
+    loading.value = true
+    error.value = null
+    try {
+      await promptApi.delete(id)
+      await fetchAll()
+    } catch (e) {
+      error.value = e.message
+      throw e
+    } finally {
+      loading.value = false
+    }
+  }
+
+  return {
+    prompts,
+    loading,
+    error,
+    totalCount,
+    versionCount,
+    fetchAll,
+    fetchVersions,
+    create,
+    update,
+    remove,
+  }
+})
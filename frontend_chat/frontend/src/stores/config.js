@@ -41,6 +41,18 @@ export function buildApiUrl(path) {
   return `${API_BASE}${path}`
 }
 
+/**
+ * 构建访问 API 下 WebSocket 接口的地址
+ * @param path - API 路径
+ * @returns ws:// 或 wss:// 开头的完整地址
+ */
+export function buildApiWsUrl(path) {
+  const base = API_BASE.startsWith('/')
+    ? `${window.location.protocol === 'https:' ? 'wss:' : 'ws:'}//${window.location.host}${API_BASE}`
+    : API_BASE.replace(/^http/, 'ws')
+  return `${base}${path}`
+}
+
 /**
  * 获取默认请求头
  * @returns 请求头对象
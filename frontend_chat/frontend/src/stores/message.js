@@ -9,6 +9,7 @@ import { useSessionStore } from './session'
 import { useAgentStore } from './agent'
 
 const HEARTBEAT_TIMEOUT = 90000 // 90秒心跳超时
+const MAX_TOOL_OUTPUT_LINES = 200 // 每个工具调用展示的最大输出行数
 
 export const useMessageStore = defineStore('message', () => {
   /** 消息列表 */
@@ -329,6 +330,15 @@ export const useMessageStore = defineStore('message', () => {
                     is_think: isInThink
                   })
                 })
+              } else if (currentEventType === 'tool_progress' && assistantMessageId && eventData.tool_progress) {
+                // 命令运行中的输出，每个工具调用只保留最近的行
+                const progress = eventData.tool_progress
+                const currentMessage = messages.value.find(m => m.id === assistantMessageId)
+                const toolOutputs = { ...(currentMessage?.tool_outputs || {}) }
+                const output = toolOutputs[progress.call_id] || { tool_name: progress.tool_name, lines: [] }
+                const lines = [...output.lines, progress.line]
+                toolOutputs[progress.call_id] = { ...output, lines: lines.slice(-MAX_TOOL_OUTPUT_LINES) }
+                updateMessage(assistantMessageId, { tool_outputs: toolOutputs })
               } else if (currentEventType === 'message_completed' && assistantMessageId) {
                 const currentMessage = messages.value.find(m => m.id === assistantMessageId)
                 let currentContent = { blocks: [], text: '', tool_calls: [], reasoning_content: '', think_content: '', is_think: false }